@@ -0,0 +1,154 @@
+// Package apiversion lets a service serve several versions of its API side by
+// side and tell clients when a version or endpoint is going away.
+//
+// Each version is served under its own path prefix, e.g. /api/v1 and /api/v2,
+// and routed like any other path. Negotiate lets clients call the unversioned
+// path, /api/..., and pick the version with the API-Version header instead.
+// Deprecated routes declare a Deprecation, which is sent as the Deprecation
+// (RFC 9745), Sunset (RFC 8594) and Link headers.
+package apiversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Headers used to negotiate versions and announce deprecations
+const (
+	// HeaderAPIVersion selects the version of an unversioned request and
+	// reports the version that served every response
+	HeaderAPIVersion  = "API-Version"
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+// versionPattern matches a version path segment or header value, e.g. v2
+var versionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// Deprecation declares a version or route deprecated
+type Deprecation struct {
+	// Since is when the route was deprecated
+	Since time.Time
+	// Sunset, if set, is when the route stops being served
+	Sunset time.Time
+	// Successor, if set, is the URL of the replacement, sent as a Link with
+	// rel="successor-version"
+	Successor string
+	// Docs, if set, is the URL of the migration notes, sent as a Link with
+	// rel="deprecation"
+	Docs string
+}
+
+// SetHeaders adds the deprecation headers to h
+func (d Deprecation) SetHeaders(h http.Header) {
+	h.Set(HeaderDeprecation, fmt.Sprintf("@%d", d.Since.Unix()))
+	if !d.Sunset.IsZero() {
+		h.Set(HeaderSunset, d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Successor != "" {
+		h.Add(HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, d.Successor))
+	}
+	if d.Docs != "" {
+		h.Add(HeaderLink, fmt.Sprintf(`<%s>; rel="deprecation"`, d.Docs))
+	}
+}
+
+// Handler returns next with the deprecation headers added to its responses.
+// Gin routes use middleware.Deprecated instead.
+func (d Deprecation) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.SetHeaders(w.Header())
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Config describes the versions of an API
+type Config struct {
+	// Prefix is the path the versions are served under, e.g. /api for
+	// /api/v1 and /api/v2
+	Prefix string
+	// Versions are the versions served, e.g. v1 and v2
+	Versions []string
+	// Default is the version unversioned requests without an API-Version
+	// header get. Changing it changes what existing clients receive, so it is
+	// usually the oldest supported version.
+	Default string
+}
+
+// Negotiate routes unversioned requests under cfg.Prefix to the version named
+// by their API-Version header, "v2" or just "2", or to cfg.Default, by
+// rewriting the path before next routes it. Requests that already name a
+// version in their path are passed through. Either way the served version is
+// reported in the API-Version response header. A header naming a version that
+// is not served is answered with a 400.
+func Negotiate(cfg Config, next http.Handler) http.Handler {
+	prefix := strings.TrimSuffix(cfg.Prefix, "/")
+	served := make(map[string]bool, len(cfg.Versions))
+	for _, version := range cfg.Versions {
+		served[version] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix+"/")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Explicitly versioned paths route as they are
+		segment, _, _ := strings.Cut(rest, "/")
+		if versionPattern.MatchString(segment) {
+			if served[segment] {
+				w.Header().Set(HeaderAPIVersion, segment)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := cfg.Default
+		if requested := r.Header.Get(HeaderAPIVersion); requested != "" {
+			version = normalize(requested)
+			if !served[version] {
+				writeUnsupported(w, requested, cfg.Versions)
+				return
+			}
+		}
+
+		// The response depends on the header, so caches must key on it
+		w.Header().Add("Vary", HeaderAPIVersion)
+		w.Header().Set(HeaderAPIVersion, version)
+
+		// Rewrite a copy, as http.StripPrefix does
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = prefix + "/" + version + "/" + rest
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// normalize turns a requested version such as "2" or "V2" into "v2"
+func normalize(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
+
+func writeUnsupported(w http.ResponseWriter, requested string, versions []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":    fmt.Sprintf("unsupported API version %q", requested),
+		"versions": versions,
+	})
+}
@@ -0,0 +1,140 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	deprecatedSince = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt        = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// newVersionedRouter serves v1 and v2 of /api/items, with v1 deprecated in
+// favour of v2, and a current /api/v1/health with no v2
+func newVersionedRouter(defaultVersion string) http.Handler {
+	text := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		})
+	}
+
+	itemsV1 := Deprecation{Since: deprecatedSince, Sunset: sunsetAt, Successor: "/api/v2/items"}
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/items", itemsV1.Handler(text("items v1")))
+	mux.Handle("/api/v1/health", text("health v1"))
+	mux.Handle("/api/v2/items", text("items v2"))
+
+	return Negotiate(Config{Prefix: "/api", Versions: []string{"v1", "v2"}, Default: defaultVersion}, mux)
+}
+
+func get(t *testing.T, handler http.Handler, path, version string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if version != "" {
+		req.Header.Set(HeaderAPIVersion, version)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeprecatedRouteSendsDeprecationHeaders(t *testing.T) {
+	w := get(t, newVersionedRouter("v1"), "/api/v1/items", "")
+
+	if w.Code != http.StatusOK || w.Body.String() != "items v1" {
+		t.Fatalf("got %d %q, want v1 items", w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get(HeaderDeprecation), "@1767225600"; got != want {
+		t.Fatalf("%s = %q, want %q", HeaderDeprecation, got, want)
+	}
+	if got, want := w.Header().Get(HeaderSunset), "Wed, 01 Jul 2026 00:00:00 GMT"; got != want {
+		t.Fatalf("%s = %q, want %q", HeaderSunset, got, want)
+	}
+	if got, want := w.Header().Get(HeaderLink), `</api/v2/items>; rel="successor-version"`; got != want {
+		t.Fatalf("%s = %q, want %q", HeaderLink, got, want)
+	}
+}
+
+func TestCurrentRouteSendsNoDeprecationHeaders(t *testing.T) {
+	router := newVersionedRouter("v1")
+
+	for _, path := range []string{"/api/v2/items", "/api/v1/health"} {
+		w := get(t, router, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got %d, want %d", path, w.Code, http.StatusOK)
+		}
+		for _, header := range []string{HeaderDeprecation, HeaderSunset, HeaderLink} {
+			if got := w.Header().Get(header); got != "" {
+				t.Fatalf("%s: unexpected %s %q", path, header, got)
+			}
+		}
+	}
+}
+
+func TestVersionsRouteIndependently(t *testing.T) {
+	router := newVersionedRouter("v1")
+
+	tests := []struct {
+		name        string
+		path        string
+		version     string
+		wantCode    int
+		wantBody    string
+		wantVersion string
+	}{
+		{name: "v1 path", path: "/api/v1/items", wantCode: http.StatusOK, wantBody: "items v1", wantVersion: "v1"},
+		{name: "v2 path", path: "/api/v2/items", wantCode: http.StatusOK, wantBody: "items v2", wantVersion: "v2"},
+		{name: "path wins over header", path: "/api/v1/items", version: "v2", wantCode: http.StatusOK, wantBody: "items v1", wantVersion: "v1"},
+		{name: "unversioned gets the default", path: "/api/items", wantCode: http.StatusOK, wantBody: "items v1", wantVersion: "v1"},
+		{name: "header selects v2", path: "/api/items", version: "v2", wantCode: http.StatusOK, wantBody: "items v2", wantVersion: "v2"},
+		{name: "bare version number", path: "/api/items", version: "2", wantCode: http.StatusOK, wantBody: "items v2", wantVersion: "v2"},
+		{name: "route missing from v2", path: "/api/v2/health", wantCode: http.StatusNotFound, wantVersion: "v2"},
+		{name: "unsupported version header", path: "/api/items", version: "v9", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, router, tt.path, tt.version)
+			if w.Code != tt.wantCode {
+				t.Fatalf("got %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Fatalf("got body %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get(HeaderAPIVersion); got != tt.wantVersion {
+				t.Fatalf("%s = %q, want %q", HeaderAPIVersion, got, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestNegotiateLeavesOtherPathsAlone(t *testing.T) {
+	w := get(t, newVersionedRouter("v2"), "/health", "v2")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if got := w.Header().Get(HeaderAPIVersion); got != "" {
+		t.Fatalf("unexpected %s %q outside the API prefix", HeaderAPIVersion, got)
+	}
+}
+
+func TestDeprecationHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/old", Deprecation{Since: deprecatedSince, Docs: "https://docs.example.com/migrate"}.Handler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	))
+
+	w := get(t, mux, "/old", "")
+	if got := w.Header().Get(HeaderDeprecation); got != "@1767225600" {
+		t.Fatalf("%s = %q", HeaderDeprecation, got)
+	}
+	if got := w.Header().Get(HeaderSunset); got != "" {
+		t.Fatalf("unexpected %s %q without a sunset date", HeaderSunset, got)
+	}
+	if got, want := w.Header().Get(HeaderLink), `<https://docs.example.com/migrate>; rel="deprecation"`; got != want {
+		t.Fatalf("%s = %q, want %q", HeaderLink, got, want)
+	}
+}
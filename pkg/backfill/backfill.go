@@ -0,0 +1,198 @@
+// Package backfill fills new columns on existing rows in small, throttled,
+// resumable batches instead of one long transaction that locks the table
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Job describes one backfill
+type Job struct {
+	// Name identifies the job's saved cursor
+	Name string
+	// Table is the table to backfill
+	Table string
+	// KeyColumn is the table's increasing integer key; "id" if empty
+	KeyColumn string
+	// Pending selects rows that still need backfilling, e.g. "tenant_id = ''".
+	// Other rows are skipped, so rerunning a job is safe.
+	Pending string
+	// Apply backfills one batch. rows is already restricted to the batch's
+	// pending rows, e.g. rows.Update("tenant_id", "default").
+	Apply func(rows *gorm.DB) error
+}
+
+// Config holds configuration for a backfill run
+type Config struct {
+	// BatchSize is the number of rows updated per transaction
+	BatchSize int
+	// Pause is the delay between batches, keeping load on the database low
+	Pause time.Duration
+	// DryRun counts the rows that would be backfilled without changing anything
+	DryRun bool
+	// Clock times the pauses; the system clock if nil
+	Clock clock.Clock
+}
+
+// DefaultConfig returns default backfill configuration
+func DefaultConfig() Config {
+	return Config{
+		BatchSize: 500,
+		Pause:     100 * time.Millisecond,
+	}
+}
+
+// Progress reports how far a run got
+type Progress struct {
+	Batches int
+	Rows    int
+	// Cursor is the key of the last row processed
+	Cursor int64
+}
+
+// Runner runs backfill jobs
+type Runner struct {
+	db      *gorm.DB
+	cursors CursorStore
+	cfg     Config
+	logger  *zap.Logger
+}
+
+// NewRunner creates a backfill runner saving its progress in cursors
+func NewRunner(db *gorm.DB, cursors CursorStore, cfg Config, logger *zap.Logger) *Runner {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultConfig().BatchSize
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real()
+	}
+	return &Runner{db: db, cursors: cursors, cfg: cfg, logger: logger}
+}
+
+// Run backfills job from its saved cursor until no pending rows remain. Each
+// batch commits with its cursor, so a stopped run resumes after the last
+// committed batch. A dry run reads from the saved cursor but saves nothing.
+func (r *Runner) Run(ctx context.Context, job Job) (Progress, error) {
+	if job.Name == "" || job.Table == "" || job.Apply == nil {
+		return Progress{}, errors.New("backfill: job needs a name, table and apply func")
+	}
+	keyColumn := job.KeyColumn
+	if keyColumn == "" {
+		keyColumn = "id"
+	}
+
+	cursor, err := r.cursors.Load(ctx, job.Name)
+	if err != nil {
+		return Progress{}, fmt.Errorf("backfill: failed to load cursor: %w", err)
+	}
+	progress := Progress{Cursor: cursor}
+
+	r.logger.Info("Starting backfill",
+		zap.String("job", job.Name),
+		zap.Int64("cursor", cursor),
+		zap.Int("batch_size", r.cfg.BatchSize),
+		zap.Bool("dry_run", r.cfg.DryRun),
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		keys, err := r.nextBatch(ctx, job, keyColumn, progress.Cursor)
+		if err != nil {
+			return progress, fmt.Errorf("backfill: failed to select batch: %w", err)
+		}
+		if len(keys) == 0 {
+			break
+		}
+		last := keys[len(keys)-1]
+
+		if !r.cfg.DryRun {
+			if err := r.applyBatch(ctx, job, keyColumn, keys, last); err != nil {
+				return progress, err
+			}
+		}
+
+		progress.Batches++
+		progress.Rows += len(keys)
+		progress.Cursor = last
+
+		r.logger.Info("Backfilled batch",
+			zap.String("job", job.Name),
+			zap.Int("batch", progress.Batches),
+			zap.Int("rows", len(keys)),
+			zap.Int("total_rows", progress.Rows),
+			zap.Int64("cursor", last),
+			zap.Bool("dry_run", r.cfg.DryRun),
+		)
+
+		if len(keys) < r.cfg.BatchSize {
+			break
+		}
+		if err := r.pause(ctx); err != nil {
+			return progress, err
+		}
+	}
+
+	r.logger.Info("Backfill finished",
+		zap.String("job", job.Name),
+		zap.Int("batches", progress.Batches),
+		zap.Int("rows", progress.Rows),
+		zap.Bool("dry_run", r.cfg.DryRun),
+	)
+	return progress, nil
+}
+
+// nextBatch returns the keys of the next pending rows after cursor
+func (r *Runner) nextBatch(ctx context.Context, job Job, keyColumn string, cursor int64) ([]int64, error) {
+	query := r.db.WithContext(ctx).Table(job.Table).
+		Where(keyColumn+" > ?", cursor).
+		Order(keyColumn).
+		Limit(r.cfg.BatchSize)
+	if job.Pending != "" {
+		query = query.Where(job.Pending)
+	}
+
+	var keys []int64
+	err := query.Pluck(keyColumn, &keys).Error
+	return keys, err
+}
+
+// applyBatch backfills keys and saves the cursor in one transaction
+func (r *Runner) applyBatch(ctx context.Context, job Job, keyColumn string, keys []int64, last int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows := tx.Table(job.Table).Where(keyColumn+" IN ?", keys)
+		if job.Pending != "" {
+			rows = rows.Where(job.Pending)
+		}
+		if err := job.Apply(rows); err != nil {
+			return fmt.Errorf("backfill: failed to apply batch ending at %d: %w", last, err)
+		}
+
+		if err := r.cursors.Save(ctx, tx, job.Name, last); err != nil {
+			return fmt.Errorf("backfill: failed to save cursor: %w", err)
+		}
+		return nil
+	})
+}
+
+// pause waits between batches, returning early if ctx is cancelled
+func (r *Runner) pause(ctx context.Context) error {
+	if r.cfg.Pause <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.cfg.Clock.After(r.cfg.Pause):
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// widget is a table gaining a region column that existing rows lack
+type widget struct {
+	ID     int64
+	Region string
+}
+
+func newTestDB(t *testing.T, rows int) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := NewGormCursorStore(db).Migrate(); err != nil {
+		t.Fatalf("failed to migrate cursors: %v", err)
+	}
+	for i := 1; i <= rows; i++ {
+		if err := db.Create(&widget{ID: int64(i)}).Error; err != nil {
+			t.Fatalf("failed to seed: %v", err)
+		}
+	}
+	return db
+}
+
+// regionJob sets a default region on widgets that have none
+func regionJob() Job {
+	return Job{
+		Name:    "widgets_region",
+		Table:   "widgets",
+		Pending: "region = ''",
+		Apply: func(rows *gorm.DB) error {
+			return rows.Update("region", "eu-west").Error
+		},
+	}
+}
+
+func countPending(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Table("widgets").Where("region = ''").Count(&count).Error; err != nil {
+		t.Fatalf("failed to count: %v", err)
+	}
+	return count
+}
+
+func TestRunner_BackfillsInBatches(t *testing.T) {
+	db := newTestDB(t, 25)
+	cursors := NewGormCursorStore(db)
+	runner := NewRunner(db, cursors, Config{BatchSize: 10}, zap.NewNop())
+
+	progress, err := runner.Run(context.Background(), regionJob())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if progress.Batches != 3 || progress.Rows != 25 || progress.Cursor != 25 {
+		t.Fatalf("expected 3 batches covering 25 rows, got %+v", progress)
+	}
+	if pending := countPending(t, db); pending != 0 {
+		t.Fatalf("expected every row to be backfilled, %d pending", pending)
+	}
+	if saved, _ := cursors.Load(context.Background(), "widgets_region"); saved != 25 {
+		t.Fatalf("expected the cursor to be saved at 25, got %d", saved)
+	}
+
+	// A rerun finds nothing left to do
+	progress, err = runner.Run(context.Background(), regionJob())
+	if err != nil || progress.Rows != 0 {
+		t.Fatalf("expected an idempotent rerun, got %+v (%v)", progress, err)
+	}
+}
+
+func TestRunner_ResumesFromSavedCursor(t *testing.T) {
+	db := newTestDB(t, 25)
+	cursors := NewGormCursorStore(db)
+
+	// A previous run stopped after committing the batch ending at row 10
+	if err := cursors.Save(context.Background(), db, "widgets_region", 10); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	runner := NewRunner(db, cursors, Config{BatchSize: 10}, zap.NewNop())
+	progress, err := runner.Run(context.Background(), regionJob())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if progress.Batches != 2 || progress.Rows != 15 {
+		t.Fatalf("expected the run to resume after row 10, got %+v", progress)
+	}
+	if pending := countPending(t, db); pending != 10 {
+		t.Fatalf("expected rows up to the cursor to be left alone, %d pending", pending)
+	}
+}
+
+func TestRunner_DryRunChangesNothing(t *testing.T) {
+	db := newTestDB(t, 25)
+	cursors := NewGormCursorStore(db)
+	runner := NewRunner(db, cursors, Config{BatchSize: 10, DryRun: true}, zap.NewNop())
+
+	progress, err := runner.Run(context.Background(), regionJob())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if progress.Rows != 25 {
+		t.Fatalf("expected the dry run to count 25 rows, got %+v", progress)
+	}
+	if pending := countPending(t, db); pending != 25 {
+		t.Fatalf("expected a dry run to change nothing, %d rows backfilled", 25-pending)
+	}
+	if saved, _ := cursors.Load(context.Background(), "widgets_region"); saved != 0 {
+		t.Fatalf("expected a dry run not to save its cursor, got %d", saved)
+	}
+}
@@ -0,0 +1,67 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CursorStore saves how far each backfill job has got
+type CursorStore interface {
+	// Load returns the saved cursor for job, or 0 if it has not started
+	Load(ctx context.Context, job string) (int64, error)
+	// Save records the cursor for job within tx, the batch's transaction
+	Save(ctx context.Context, tx *gorm.DB, job string, cursor int64) error
+}
+
+// Cursor is a saved backfill position
+type Cursor struct {
+	Job       string `gorm:"primaryKey;type:varchar(255)"`
+	LastKey   int64  `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// TableName implements gorm's tabler interface
+func (Cursor) TableName() string {
+	return "backfill_cursors"
+}
+
+// GormCursorStore keeps cursors in the backfill_cursors table, so they commit
+// atomically with the batches they describe
+type GormCursorStore struct {
+	db *gorm.DB
+}
+
+// NewGormCursorStore creates a cursor store on db
+func NewGormCursorStore(db *gorm.DB) *GormCursorStore {
+	return &GormCursorStore{db: db}
+}
+
+// Migrate creates the cursor table if it does not exist
+func (s *GormCursorStore) Migrate() error {
+	return s.db.AutoMigrate(&Cursor{})
+}
+
+// Load implements CursorStore
+func (s *GormCursorStore) Load(ctx context.Context, job string) (int64, error) {
+	var cursor Cursor
+	err := s.db.WithContext(ctx).Where("job = ?", job).First(&cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cursor.LastKey, nil
+}
+
+// Save implements CursorStore
+func (s *GormCursorStore) Save(ctx context.Context, tx *gorm.DB, job string, cursor int64) error {
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_key", "updated_at"}),
+	}).Create(&Cursor{Job: job, LastKey: cursor, UpdatedAt: time.Now()}).Error
+}
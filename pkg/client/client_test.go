@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// observations returns the number of duration samples recorded for service/operation
+func observations(t *testing.T, service, operation string) uint64 {
+	t.Helper()
+
+	var metric dto.Metric
+	observer := outboundRequestDuration.WithLabelValues(service, operation)
+	if err := observer.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestClient_RecordsDurationObservation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	defer server.Close()
+
+	c := New(Config{Service: "user-service", BaseURL: server.URL})
+	before := observations(t, "user-service", "get_user")
+
+	var user struct {
+		ID string `json:"id"`
+	}
+	if err := c.GetJSON(context.Background(), "get_user", "/api/v1/users/42", &user); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if user.ID != "42" {
+		t.Fatalf("unexpected response %+v", user)
+	}
+
+	if got := observations(t, "user-service", "get_user") - before; got != 1 {
+		t.Fatalf("expected 1 duration observation, got %d", got)
+	}
+	if got := testutil.ToFloat64(outboundRequestsTotal.WithLabelValues("user-service", "get_user", "200")); got < 1 {
+		t.Fatalf("expected the request to be counted, got %v", got)
+	}
+}
+
+func TestClient_ErrorStatusIncrementsErrorCounter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := New(Config{Service: "kyc-service", BaseURL: server.URL})
+	errors503 := outboundErrorsTotal.WithLabelValues("kyc-service", "get_status", "503")
+	before := testutil.ToFloat64(errors503)
+
+	err := c.GetJSON(context.Background(), "get_status", "/api/v1/kyc/1", nil)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503 StatusError, got %v", err)
+	}
+	if got := testutil.ToFloat64(errors503) - before; got != 1 {
+		t.Fatalf("expected the error counter to increase by 1, got %v", got)
+	}
+}
+
+func TestClient_NetworkErrorIsCounted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	c := New(Config{Service: "investment-service", BaseURL: server.URL})
+	networkErrors := outboundErrorsTotal.WithLabelValues("investment-service", "list", networkErrorCode)
+	before := testutil.ToFloat64(networkErrors)
+
+	if err := c.GetJSON(context.Background(), "list", "/api/v1/investments", nil); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if got := testutil.ToFloat64(networkErrors) - before; got != 1 {
+		t.Fatalf("expected the network error to be counted, got %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrEgressDenied is returned for outbound requests the egress policy does not allow
+var ErrEgressDenied = errors.New("egress denied")
+
+// EgressPolicy restricts where outbound requests may go. Use it for every call
+// whose target is configured or influenced by users, such as webhook subscriber
+// URLs, so the service cannot be turned against internal systems.
+type EgressPolicy struct {
+	// AllowedHosts lists the hosts requests may reach: exact names, "*.example.com"
+	// for any subdomain, IP addresses or CIDR ranges. Empty allows any host that
+	// resolves to a public address.
+	AllowedHosts []string `mapstructure:"allowed_hosts"`
+	// AllowPrivate permits loopback, private, link-local and other non-public
+	// addresses. Addresses listed in AllowedHosts are always permitted.
+	AllowPrivate bool `mapstructure:"allow_private"`
+}
+
+// DefaultEgressPolicy allows any public host and blocks non-public addresses
+func DefaultEgressPolicy() EgressPolicy {
+	return EgressPolicy{}
+}
+
+// CheckURL reports whether the policy allows a request to u, returning an error
+// wrapping ErrEgressDenied if not. Host names are checked against AllowedHosts
+// here; the addresses they resolve to are checked when connecting.
+func (p EgressPolicy) CheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrEgressDenied, u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrEgressDenied)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(ip)
+	}
+	if len(p.AllowedHosts) > 0 && !p.hostAllowed(host) {
+		return fmt.Errorf("%w: host %s is not allowlisted", ErrEgressDenied, host)
+	}
+	return nil
+}
+
+// checkIP reports whether the policy allows a request addressed to ip. Once
+// AllowedHosts is set, an IP address must be listed in it like any host.
+func (p EgressPolicy) checkIP(ip net.IP) error {
+	if p.ipAllowlisted(ip) {
+		return nil
+	}
+	if len(p.AllowedHosts) > 0 {
+		return fmt.Errorf("%w: address %s is not allowlisted", ErrEgressDenied, ip)
+	}
+	return p.checkAddress(ip)
+}
+
+// checkAddress reports whether a connection may be made to ip. Non-public
+// addresses need AllowPrivate or an AllowedHosts entry.
+func (p EgressPolicy) checkAddress(ip net.IP) error {
+	if p.AllowPrivate || isPublicIP(ip) || p.ipAllowlisted(ip) {
+		return nil
+	}
+	return fmt.Errorf("%w: address %s is not public", ErrEgressDenied, ip)
+}
+
+// hostAllowed matches a host name against the name entries of AllowedHosts
+func (p EgressPolicy) hostAllowed(host string) bool {
+	for _, entry := range p.AllowedHosts {
+		entry = strings.ToLower(entry)
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// ipAllowlisted matches ip against the address and CIDR entries of AllowedHosts
+func (p EgressPolicy) ipAllowlisted(ip net.IP) bool {
+	for _, entry := range p.AllowedHosts {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// nonPublicNetworks are reserved ranges the net.IP predicates do not cover
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "this" network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved, including broadcast
+		"64:ff9b::/96",  // NAT64, which can reach IPv4 private ranges
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// egressTransport enforces an EgressPolicy on every request, including redirects
+type egressTransport struct {
+	policy EgressPolicy
+	base   http.RoundTripper
+}
+
+// NewEgressTransport returns a transport that refuses requests the policy does
+// not allow. Resolved addresses are checked again as each connection is made, so
+// a permitted name that resolves to a blocked address is still refused.
+func NewEgressTransport(policy EgressPolicy) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%w: unresolved address %s", ErrEgressDenied, host)
+			}
+			return policy.checkAddress(ip)
+		},
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on our behalf, bypassing the address check
+	base.Proxy = nil
+	base.DialContext = dialer.DialContext
+
+	return &egressTransport{policy: policy, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEgressPolicy_CheckURL(t *testing.T) {
+	allowlist := EgressPolicy{AllowedHosts: []string{"api.exchangerates.example", "*.hooks.example.com", "203.0.113.0/24"}}
+
+	tests := []struct {
+		name    string
+		policy  EgressPolicy
+		target  string
+		allowed bool
+	}{
+		{"public host without allowlist", DefaultEgressPolicy(), "https://partner.example.org/hook", true},
+		{"public IP without allowlist", DefaultEgressPolicy(), "http://8.8.8.8/", true},
+		{"private IP", DefaultEgressPolicy(), "http://10.0.0.5/admin", false},
+		{"loopback", DefaultEgressPolicy(), "http://127.0.0.1:8080/", false},
+		{"cloud metadata", DefaultEgressPolicy(), "http://169.254.169.254/latest/meta-data/", false},
+		{"IPv6 loopback", DefaultEgressPolicy(), "http://[::1]/", false},
+		{"IPv4-mapped private", DefaultEgressPolicy(), "http://[::ffff:192.168.1.1]/", false},
+		{"carrier-grade NAT", DefaultEgressPolicy(), "http://100.64.1.1/", false},
+		{"non-HTTP scheme", DefaultEgressPolicy(), "file:///etc/passwd", false},
+		{"private IP with AllowPrivate", EgressPolicy{AllowPrivate: true}, "http://10.0.0.5/", true},
+		{"allowlisted domain", allowlist, "https://api.exchangerates.example/latest", true},
+		{"allowlisted domain is case-insensitive", allowlist, "https://API.ExchangeRates.example./latest", true},
+		{"allowlisted subdomain", allowlist, "https://acme.hooks.example.com/events", true},
+		{"wildcard does not match the apex", allowlist, "https://hooks.example.com/events", false},
+		{"lookalike domain", allowlist, "https://api.exchangerates.example.attacker.net/", false},
+		{"non-allowlisted domain", allowlist, "https://attacker.net/", false},
+		{"allowlisted range", allowlist, "http://203.0.113.7/", true},
+		{"public IP outside the allowlist", allowlist, "http://8.8.8.8/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.target)
+			if err != nil {
+				t.Fatalf("bad target: %v", err)
+			}
+			err = tt.policy.CheckURL(u)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected %s to be allowed, got %v", tt.target, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrEgressDenied) {
+				t.Fatalf("expected %s to be denied, got %v", tt.target, err)
+			}
+		})
+	}
+}
+
+func TestEgressTransport_BlocksAndAllows(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	get := func(policy EgressPolicy, target string) error {
+		resp, err := (&http.Client{Transport: NewEgressTransport(policy)}).Get(target)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// The test server listens on loopback, which is blocked by default
+	if err := get(DefaultEgressPolicy(), server.URL); !errors.Is(err, ErrEgressDenied) {
+		t.Fatalf("expected a request to a private address to be blocked, got %v", err)
+	}
+
+	// A permitted name resolving to a blocked address is refused when connecting
+	localhost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if err := get(EgressPolicy{AllowedHosts: []string{"localhost"}}, localhost); !errors.Is(err, ErrEgressDenied) {
+		t.Fatalf("expected a name resolving to loopback to be blocked, got %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected blocked requests never to reach the server, got %d calls", calls)
+	}
+
+	// Allowlisting the address lets the request through
+	if err := get(EgressPolicy{AllowedHosts: []string{"127.0.0.1"}}, server.URL); err != nil {
+		t.Fatalf("expected an allowlisted address to be reachable, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestClient_EgressPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	blocked := New(Config{Service: "webhooks", BaseURL: server.URL, Egress: &EgressPolicy{}})
+	if err := blocked.GetJSON(context.Background(), "deliver", "/hook", nil); !errors.Is(err, ErrEgressDenied) {
+		t.Fatalf("expected the client to enforce its egress policy, got %v", err)
+	}
+
+	allowed := New(Config{Service: "webhooks", BaseURL: server.URL, Egress: &EgressPolicy{AllowedHosts: []string{"127.0.0.0/8"}}})
+	if err := allowed.GetJSON(context.Background(), "deliver", "/hook", nil); err != nil {
+		t.Fatalf("expected an allowlisted destination to be reachable, got %v", err)
+	}
+}
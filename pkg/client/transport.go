@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Outbound request metrics. Labels are limited to the target service, the
+// operation name and the status code so cardinality stays bounded.
+var (
+	outboundRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbound_requests_total",
+			Help: "Total number of outbound requests to other services",
+		},
+		[]string{"service", "operation", "code"},
+	)
+
+	outboundRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "outbound_request_duration_seconds",
+			Help:    "Outbound request duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"service", "operation"},
+	)
+
+	outboundErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbound_request_errors_total",
+			Help: "Total number of outbound requests that failed or returned a 5xx status",
+		},
+		[]string{"service", "operation", "code"},
+	)
+)
+
+// networkErrorCode labels requests that failed before a response was received
+const networkErrorCode = "error"
+
+type operationKey struct{}
+
+// WithOperation names the outbound call made with ctx for metrics and tracing
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFrom returns the operation set by WithOperation, defaulting to the HTTP method
+func operationFrom(req *http.Request) string {
+	if operation, ok := req.Context().Value(operationKey{}).(string); ok && operation != "" {
+		return operation
+	}
+	return req.Method
+}
+
+// transport instruments outbound requests with metrics and tracing spans
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// NewTransport wraps base so every request records outbound metrics and a tracing
+// span for service. Use WithOperation on the request context to name the call.
+func NewTransport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := operationFrom(req)
+
+	span, ctx := opentracing.StartSpanFromContext(req.Context(), t.service+"."+operation)
+	defer span.Finish()
+
+	ext.SpanKindRPCClient.Set(span)
+	ext.PeerService.Set(span, t.service)
+	ext.HTTPMethod.Set(span, req.Method)
+	ext.HTTPUrl.Set(span, req.URL.String())
+
+	// Propagate the trace to the target service; RoundTrip must not modify the caller's request
+	req = req.Clone(ctx)
+	_ = span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	outboundRequestDuration.WithLabelValues(t.service, operation).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		outboundRequestsTotal.WithLabelValues(t.service, operation, networkErrorCode).Inc()
+		outboundErrorsTotal.WithLabelValues(t.service, operation, networkErrorCode).Inc()
+		ext.Error.Set(span, true)
+		span.SetTag("error.message", err.Error())
+		return nil, err
+	}
+
+	code := strconv.Itoa(resp.StatusCode)
+	outboundRequestsTotal.WithLabelValues(t.service, operation, code).Inc()
+	ext.HTTPStatusCode.Set(span, uint16(resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		outboundErrorsTotal.WithLabelValues(t.service, operation, code).Inc()
+		ext.Error.Set(span, true)
+	}
+
+	return resp, nil
+}
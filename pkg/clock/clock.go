@@ -0,0 +1,197 @@
+// Package clock abstracts time so time-dependent code can be tested without sleeping
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real returns a Clock backed by the time package
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.ticker.C }
+func (t realTicker) Stop()               { t.ticker.Stop() }
+
+// Fake is a Clock that only moves when told to. Timers, sleeps and tickers fire
+// as Advance moves the time past their deadlines.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending After, Sleep or ticker deadline
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+	// interval is set for tickers, which are rescheduled after firing
+	interval time.Duration
+	stopped  bool
+}
+
+// NewFake creates a fake clock set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now implements Clock
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since implements Clock
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After implements Clock
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, &waiter{deadline: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Sleep implements Clock. It blocks until another goroutine advances the clock by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTicker implements Clock
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{deadline: f.now.Add(d), ch: make(chan time.Time, 1), interval: d}
+	f.waiters = append(f.waiters, w)
+	return &fakeTicker{clock: f, waiter: w}
+}
+
+// Advance moves the clock forward by d, firing every timer, sleep and ticker
+// whose deadline is reached, in deadline order
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for {
+		next := f.nextWaiter(end)
+		if next == nil {
+			break
+		}
+		f.now = next.deadline
+		f.fire(next)
+	}
+	f.now = end
+}
+
+// Set moves the clock to t, firing waiters as Advance does. Moving backwards fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.Advance(t.Sub(f.Now()))
+}
+
+// Waiters returns the number of pending timers, sleeps and tickers, so tests can
+// wait for a goroutine to start waiting before advancing the clock
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// nextWaiter returns the waiter with the earliest deadline not after end
+func (f *Fake) nextWaiter(end time.Time) *waiter {
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+	})
+	if len(f.waiters) == 0 || f.waiters[0].deadline.After(end) {
+		return nil
+	}
+	return f.waiters[0]
+}
+
+// fire delivers a tick to w and reschedules it if it is a ticker
+func (f *Fake) fire(w *waiter) {
+	// Like time.Ticker, drop ticks a slow receiver has not consumed
+	select {
+	case w.ch <- f.now:
+	default:
+	}
+
+	if w.interval > 0 {
+		w.deadline = w.deadline.Add(w.interval)
+		return
+	}
+	f.remove(w)
+}
+
+func (f *Fake) remove(w *waiter) {
+	for i, candidate := range f.waiters {
+		if candidate == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *Fake
+	waiter *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	if !t.waiter.stopped {
+		t.waiter.stopped = true
+		t.clock.remove(t.waiter)
+	}
+}
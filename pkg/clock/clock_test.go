@@ -0,0 +1,108 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_AdvanceMovesNow(t *testing.T) {
+	c := NewFake(epoch)
+
+	c.Advance(90 * time.Second)
+
+	if got := c.Now(); !got.Equal(epoch.Add(90 * time.Second)) {
+		t.Fatalf("expected %v, got %v", epoch.Add(90*time.Second), got)
+	}
+	if got := c.Since(epoch); got != 90*time.Second {
+		t.Fatalf("expected Since to be 90s, got %v", got)
+	}
+}
+
+func TestFake_AfterFiresOnlyAtDeadline(t *testing.T) {
+	c := NewFake(epoch)
+	ch := c.After(time.Minute)
+
+	c.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("After fired before its deadline")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case fired := <-ch:
+		if !fired.Equal(epoch.Add(time.Minute)) {
+			t.Fatalf("expected the tick at the deadline, got %v", fired)
+		}
+	default:
+		t.Fatal("After did not fire at its deadline")
+	}
+}
+
+func TestFake_SleepReturnsWhenAdvanced(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan struct{})
+
+	go func() {
+		c.Sleep(time.Hour)
+		close(done)
+	}()
+
+	// Wait for the sleeper to register before moving time
+	for c.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(time.Hour)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep did not return after the clock advanced")
+	}
+}
+
+func TestFake_TickerFiresEachInterval(t *testing.T) {
+	c := NewFake(epoch)
+	ticker := c.NewTicker(10 * time.Second)
+
+	for i := 1; i <= 3; i++ {
+		c.Advance(10 * time.Second)
+		select {
+		case tick := <-ticker.C():
+			if want := epoch.Add(time.Duration(i) * 10 * time.Second); !tick.Equal(want) {
+				t.Fatalf("tick %d: expected %v, got %v", i, want, tick)
+			}
+		default:
+			t.Fatalf("ticker did not fire for interval %d", i)
+		}
+	}
+
+	ticker.Stop()
+	c.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+	if c.Waiters() != 0 {
+		t.Fatalf("expected no pending waiters, got %d", c.Waiters())
+	}
+}
+
+func TestFake_AdvanceFiresWaitersInDeadlineOrder(t *testing.T) {
+	c := NewFake(epoch)
+	later := c.After(2 * time.Minute)
+	sooner := c.After(time.Minute)
+
+	c.Advance(5 * time.Minute)
+
+	if tick := <-sooner; !tick.Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("expected the sooner timer to fire at 1m, got %v", tick)
+	}
+	if tick := <-later; !tick.Equal(epoch.Add(2 * time.Minute)) {
+		t.Fatalf("expected the later timer to fire at 2m, got %v", tick)
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/adil-faiyaz98/sparkfund/pkg/masking"
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -17,12 +21,15 @@ type Config struct {
 	Environment string `mapstructure:"environment"`
 
 	Server struct {
-		Port            string        `mapstructure:"port"`
-		ReadTimeout     time.Duration `mapstructure:"read_timeout"`
-		WriteTimeout    time.Duration `mapstructure:"write_timeout"`
-		IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
-		ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
-		TrustedProxies  []string      `mapstructure:"trusted_proxies"`
+		Port              string        `mapstructure:"port"`
+		ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+		WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+		IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+		ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`
+		PreStopDelay      time.Duration `mapstructure:"pre_stop_delay"`
+		MaxConnections    int           `mapstructure:"max_connections"`
+		TrustedProxies    []string      `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
 
 	Database struct {
@@ -44,6 +51,26 @@ type Config struct {
 		Refresh time.Duration `mapstructure:"refresh"`
 		Issuer  string        `mapstructure:"issuer"`
 		Enabled bool          `mapstructure:"enabled"`
+		// RefreshLeadTime is how long before expiry clients are advised to refresh
+		RefreshLeadTime time.Duration `mapstructure:"refresh_lead_time"`
+		// SigningKeyID selects which of Keys signs new tokens
+		SigningKeyID string `mapstructure:"signing_key_id"`
+		// Keys replaces Secret with a rotatable set of secrets identified by kid.
+		// To rotate, add the new key, make it the signing key and give the previous
+		// one a retires_at (RFC 3339) at least one token lifetime away. An entry
+		// without an id verifies tokens issued without a kid.
+		Keys []struct {
+			ID        string `mapstructure:"id"`
+			Secret    string `mapstructure:"secret"`
+			RetiresAt string `mapstructure:"retires_at"`
+		} `mapstructure:"keys"`
+		// Denylist rejects access tokens revoked at logout before they expire.
+		// Prefix must match the keys the service issuing the tokens writes.
+		Denylist struct {
+			Enabled bool                `mapstructure:"enabled"`
+			Prefix  string              `mapstructure:"prefix"`
+			Redis   redisclient.Options `mapstructure:"redis"`
+		} `mapstructure:"denylist"`
 	} `mapstructure:"jwt"`
 
 	RateLimit struct {
@@ -80,6 +107,14 @@ type Config struct {
 		AllowedHeaders []string `mapstructure:"allowed_headers"`
 		TrustedProxies []string `mapstructure:"trusted_proxies"`
 		EnableCSRF     bool     `mapstructure:"enable_csrf"`
+		// Masking is the per-field, per-role PII masking policy for API responses
+		Masking masking.Policy `mapstructure:"masking"`
+		// ContentSecurityPolicy is sent on API routes; Swagger and download routes override it
+		ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+		// HSTSMaxAge is the Strict-Transport-Security max-age on HTTPS responses; zero disables HSTS
+		HSTSMaxAge time.Duration `mapstructure:"hsts_max_age"`
+		// Egress restricts outbound calls to user-influenced URLs such as webhooks
+		Egress client.EgressPolicy `mapstructure:"egress"`
 	} `mapstructure:"security"`
 
 	Feature struct {
@@ -91,8 +126,33 @@ type Config struct {
 	Tracing struct {
 		Enabled     bool   `mapstructure:"enabled"`
 		ServiceName string `mapstructure:"service_name"`
+		// SamplingRate is the head sampling ratio; zero keeps the environment default
+		SamplingRate         float64       `mapstructure:"sampling_rate"`
+		AlwaysSampleErrors   bool          `mapstructure:"always_sample_errors"`
+		SlowRequestThreshold time.Duration `mapstructure:"slow_request_threshold"`
+		// SlowRequestRoutes overrides SlowRequestThreshold for routes such as
+		// "GET /api/v1/reports/:id". It is a list because viper lowercases map keys.
+		SlowRequestRoutes []struct {
+			Route     string        `mapstructure:"route"`
+			Threshold time.Duration `mapstructure:"threshold"`
+		} `mapstructure:"slow_request_routes"`
 	} `mapstructure:"tracing"`
 
+	// SLO holds the response time and availability objectives requests are
+	// measured against. A request counts toward the first objective whose routes
+	// match it; with none configured a single 300ms, 99% objective covers
+	// every route.
+	SLO struct {
+		Objectives []struct {
+			Name string `mapstructure:"name"`
+			// Routes are route pattern prefixes such as "/api/v1/documents";
+			// empty covers every route
+			Routes  []string      `mapstructure:"routes"`
+			Latency time.Duration `mapstructure:"latency"`
+			Target  float64       `mapstructure:"target"`
+		} `mapstructure:"objectives"`
+	} `mapstructure:"slo"`
+
 	Cache struct {
 		Enabled         bool          `mapstructure:"enabled"`
 		TTL             time.Duration `mapstructure:"ttl"`
@@ -108,6 +168,8 @@ type Config struct {
 }
 
 var (
+	// mu guards config, which Reload updates while the service is running
+	mu      sync.RWMutex
 	config  Config
 	once    sync.Once
 	logger  *logrus.Logger
@@ -127,81 +189,95 @@ func Load(configPath string) error {
 			logger = logrus.New()
 		}
 
-		// Set defaults
-		setDefaults()
+		cfg, err := read(configPath)
+		mu.Lock()
+		config = cfg
+		mu.Unlock()
+		initErr = err
+	})
 
-		// Get environment
-		env := os.Getenv("APP_ENV")
-		if env == "" {
-			env = "development"
-		}
+	return initErr
+}
 
-		// Initialize viper
-		v := viper.New()
+// read loads the configuration files and environment overrides in
+// configPath. It returns the defaults and an error if they cannot be used.
+func read(configPath string) (Config, error) {
+	var cfg Config
+	setDefaults(&cfg)
 
-		// Load base configuration that applies to all environments
-		v.SetConfigName("config.base")
-		v.SetConfigType("yaml")
-		v.AddConfigPath(configPath)
-		v.AddConfigPath("./config")
-		v.AddConfigPath(".")
+	// Get environment
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
 
-		// Read base config file (optional)
-		if err := v.ReadInConfig(); err != nil {
-			if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
-				logger.Warnf("Error reading base config: %v", err)
-			}
-		}
+	// Initialize viper
+	v := viper.New()
 
-		// Now load environment specific config to override base config
-		v.SetConfigName(fmt.Sprintf("config.%s", env))
+	// Load base configuration that applies to all environments
+	v.SetConfigName("config.base")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(configPath)
+	v.AddConfigPath("./config")
+	v.AddConfigPath(".")
 
-		// Attempt to read the environment-specific config
-		if err := v.MergeInConfig(); err != nil {
-			if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
-				logger.Warnf("Error reading %s config: %v", env, err)
-			}
+	// Read base config file (optional)
+	if err := v.ReadInConfig(); err != nil {
+		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			logger.Warnf("Error reading base config: %v", err)
 		}
+	}
 
-		// Enable environment variable overrides
-		v.SetEnvPrefix("APP")
-		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-		v.AutomaticEnv()
-
-		// Handle secret injection via files (e.g., Kubernetes secrets)
-		loadSecretsFromFiles(v)
+	// Now load environment specific config to override base config
+	v.SetConfigName(fmt.Sprintf("config.%s", env))
 
-		// Unmarshal config
-		if err := v.Unmarshal(&config); err != nil {
-			logger.Errorf("Failed to unmarshal configuration: %v", err)
-			initErr = err
-			return
+	// Attempt to read the environment-specific config
+	if err := v.MergeInConfig(); err != nil {
+		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			logger.Warnf("Error reading %s config: %v", env, err)
 		}
+	}
 
-		// Set environment in config
-		config.Environment = env
+	// Enable environment variable overrides
+	v.SetEnvPrefix("APP")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
 
-		// Validate critical configuration
-		initErr = validateConfig(&config)
-	})
+	// Handle secret injection via files (e.g., Kubernetes secrets)
+	loadSecretsFromFiles(v)
 
-	return initErr
+	// Unmarshal config
+	if err := v.Unmarshal(&cfg); err != nil {
+		logger.Errorf("Failed to unmarshal configuration: %v", err)
+		return cfg, err
+	}
+
+	// Set environment in config
+	cfg.Environment = env
+
+	// Validate critical configuration
+	return cfg, validateConfig(&cfg)
 }
 
 // Get returns the loaded configuration
 func Get() Config {
+	mu.RLock()
+	defer mu.RUnlock()
 	return config
 }
 
 // setDefaults sets default configuration values
-func setDefaults() {
+func setDefaults(config *Config) {
 	config.Environment = "development"
 
 	config.Server.Port = "8081"
 	config.Server.ReadTimeout = 5 * time.Second
+	config.Server.ReadHeaderTimeout = 5 * time.Second
 	config.Server.WriteTimeout = 10 * time.Second
 	config.Server.IdleTimeout = 120 * time.Second
 	config.Server.ShutdownTimeout = 30 * time.Second
+	config.Server.PreStopDelay = 5 * time.Second
+	config.Server.MaxConnections = 1000
 	config.Server.TrustedProxies = []string{"127.0.0.1", "172.16.0.0/12", "172.17.0.0/16", "192.168.0.0/16"}
 
 	config.Database.Host = "postgres"
@@ -216,8 +292,11 @@ func setDefaults() {
 
 	config.JWT.Expiry = 24 * time.Hour
 	config.JWT.Refresh = 7 * 24 * time.Hour
+	config.JWT.RefreshLeadTime = 5 * time.Minute
 	config.JWT.Issuer = "sparkfund"
 	config.JWT.Enabled = true
+	config.JWT.Denylist.Prefix = "denylist:"
+	config.JWT.Denylist.Redis = redisclient.DefaultOptions()
 
 	config.RateLimit.Enabled = true
 	config.RateLimit.Requests = 60
@@ -244,6 +323,9 @@ func setDefaults() {
 	config.Security.AllowedHeaders = []string{"Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "X-CSRF-Token"}
 	config.Security.TrustedProxies = []string{"127.0.0.1", "172.16.0.0/12", "192.168.0.0/16"}
 	config.Security.EnableCSRF = true
+	config.Security.Masking = masking.DefaultPolicy()
+	config.Security.HSTSMaxAge = 365 * 24 * time.Hour
+	config.Security.Egress = client.DefaultEgressPolicy()
 
 	config.Feature.EnableSwagger = true
 	config.Feature.EnableAuth = true
@@ -251,6 +333,8 @@ func setDefaults() {
 
 	config.Tracing.Enabled = true
 	config.Tracing.ServiceName = "kyc-service"
+	config.Tracing.AlwaysSampleErrors = true
+	config.Tracing.SlowRequestThreshold = 2 * time.Second
 
 	config.Cache.Enabled = true
 	config.Cache.TTL = 5 * time.Minute
@@ -288,10 +372,22 @@ func loadSecretsFromFiles(v *viper.Viper) {
 
 // validateConfig checks that critical configuration is present
 func validateConfig(cfg *Config) error {
+	// Reject a key set that cannot sign, rather than failing on the first request
+	if _, err := cfg.JWTKeySet(); err != nil {
+		return err
+	}
+
+	for i, objective := range cfg.SLO.Objectives {
+		if objective.Name == "" || objective.Latency <= 0 || objective.Target <= 0 || objective.Target >= 1 {
+			return fmt.Errorf("slo.objectives[%d] needs a name, a positive latency and a target between 0 and 1", i)
+		}
+	}
+
 	// In production, enforce certain security settings
 	if os.Getenv("APP_ENV") == "production" {
-		// Require JWT secret in production
-		if cfg.JWT.Secret == "" {
+		// Require JWT secret in production, unless tokens are signed with RS256
+		alg, _ := jwtkeys.AlgFromEnv()
+		if cfg.JWT.Secret == "" && len(cfg.JWT.Keys) == 0 && alg != "RS256" {
 			return errors.New("JWT authentication is not properly configured for production")
 		}
 
@@ -309,13 +405,38 @@ func validateConfig(cfg *Config) error {
 	return nil
 }
 
-// Reload refreshes configuration at runtime
-func Reload(configPath string) error {
-	once = sync.Once{}
-	return Load(configPath)
+// JWTKeySet returns the keys tokens are signed and verified with: the RSA key in
+// ACCESS_TOKEN_PRIVATE_KEY if AUTH_SIGNING_ALG is RS256, otherwise JWT.Keys if
+// configured, otherwise JWT.Secret alone
+func (c *Config) JWTKeySet() (*jwtkeys.KeySet, error) {
+	alg, err := jwtkeys.AlgFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if alg == "RS256" {
+		return jwtkeys.FromRSAEnv(c.JWT.SigningKeyID)
+	}
+
+	if len(c.JWT.Keys) == 0 {
+		return jwtkeys.FromSecret(c.JWT.Secret), nil
+	}
+
+	keys := make([]jwtkeys.Key, 0, len(c.JWT.Keys))
+	for _, entry := range c.JWT.Keys {
+		key := jwtkeys.Key{ID: entry.ID, Secret: []byte(entry.Secret)}
+		if entry.RetiresAt != "" {
+			retiresAt, err := time.Parse(time.RFC3339, entry.RetiresAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid retires_at for JWT key %q: %w", entry.ID, err)
+			}
+			key.RetiresAt = retiresAt
+		}
+		keys = append(keys, key)
+	}
+	return jwtkeys.New(jwtkeys.Config{SigningKeyID: c.JWT.SigningKeyID, Keys: keys})
 }
 
 // IsProduction returns true if the current environment is production
 func IsProduction() bool {
-	return config.Environment == "production"
+	return Get().Environment == "production"
 }
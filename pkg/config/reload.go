@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Only some settings can change while the service is running. A reload applies
+// these and leaves everything else as it was loaded at startup:
+//
+//   - log.level
+//   - rate_limit
+//   - feature
+//
+// Changes to any other section, such as server.port or the database settings,
+// are logged and ignored until the service is restarted.
+
+// ReloadHook is called with the new configuration after a reload changed it
+type ReloadHook func(Config)
+
+var (
+	hooksMu sync.Mutex
+	hooks   []ReloadHook
+)
+
+// OnReload registers hook to apply reloaded settings, e.g. to set the log
+// level or replace rate limits. Hooks run in the order they were registered.
+func OnReload(hook ReloadHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, hook)
+}
+
+// Reload re-reads the configuration from configPath and applies the reloadable
+// settings. Changes to settings that need a restart are logged and ignored. If
+// the new configuration cannot be loaded the current one is kept.
+func Reload(configPath string) error {
+	if logger == nil {
+		return fmt.Errorf("config reload: configuration was never loaded")
+	}
+
+	loaded, err := read(configPath)
+	if err != nil {
+		return fmt.Errorf("config reload: %w", err)
+	}
+
+	// Apply the whole reloadable subset at once, so Get never returns a mix
+	mu.Lock()
+	applied, ignored := applyReloadable(&config, loaded)
+	current := config
+	mu.Unlock()
+
+	for _, section := range ignored {
+		logger.Warnf("Config reload ignored changes to %s; they take effect after a restart", section)
+	}
+	if len(applied) == 0 {
+		logger.Info("Config reload found no reloadable changes")
+		return nil
+	}
+	logger.Infof("Config reloaded: %s", strings.Join(applied, ", "))
+
+	hooksMu.Lock()
+	registered := append([]ReloadHook(nil), hooks...)
+	hooksMu.Unlock()
+	for _, hook := range registered {
+		hook(current)
+	}
+	return nil
+}
+
+// WatchReload reloads the configuration from configPath on every SIGHUP until
+// ctx is done
+func WatchReload(ctx context.Context, configPath string) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sighup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sighup:
+				if err := Reload(configPath); err != nil {
+					logger.Errorf("Failed to reload configuration: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// applyReloadable copies the reloadable settings from loaded into current. It
+// returns the reloadable settings that changed and the sections with changes
+// that need a restart.
+func applyReloadable(current *Config, loaded Config) (applied, ignored []string) {
+	if current.Log.Level != loaded.Log.Level {
+		current.Log.Level = loaded.Log.Level
+		applied = append(applied, "log.level")
+	}
+	if current.RateLimit != loaded.RateLimit {
+		current.RateLimit = loaded.RateLimit
+		applied = append(applied, "rate_limit")
+	}
+	if current.Feature != loaded.Feature {
+		current.Feature = loaded.Feature
+		applied = append(applied, "feature")
+	}
+
+	// With the reloadable settings copied, any remaining difference needs a restart
+	have, want := reflect.ValueOf(*current), reflect.ValueOf(loaded)
+	for i := 0; i < have.NumField(); i++ {
+		if !reflect.DeepEqual(have.Field(i).Interface(), want.Field(i).Interface()) {
+			ignored = append(ignored, sectionName(have.Type().Field(i)))
+		}
+	}
+	return applied, ignored
+}
+
+// sectionName is the name of a Config field in the config files
+func sectionName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
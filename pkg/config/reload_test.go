@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	sharedLogger "github.com/adil-faiyaz98/sparkfund/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// writeConfig writes a config.base.yaml with the given log level and port to dir
+func writeConfig(t *testing.T, dir, level, port string) {
+	t.Helper()
+	data := []byte("server:\n  port: \"" + port + "\"\nlog:\n  level: " + level + "\n")
+	if err := os.WriteFile(filepath.Join(dir, "config.base.yaml"), data, 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+// loadFresh loads dir as if the service had just started
+func loadFresh(t *testing.T, dir string) {
+	t.Helper()
+	t.Setenv("APP_ENV", "test")
+	once = sync.Once{}
+	t.Cleanup(func() {
+		once = sync.Once{}
+		hooks = nil
+	})
+	if err := Load(dir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+}
+
+func TestReloadChangesLogLevelAndIgnoresPort(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "info", "8081")
+	loadFresh(t, dir)
+
+	sharedLogger.SetLevel(Get().Log.Level)
+	OnReload(func(cfg Config) {
+		sharedLogger.SetLevel(cfg.Log.Level)
+	})
+	t.Cleanup(func() { sharedLogger.SetLevel("info") })
+	if sharedLogger.GetLogger().Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("expected debug logging to start disabled")
+	}
+
+	writeConfig(t, dir, "debug", "9090")
+	if err := Reload(dir); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	cfg := Get()
+	if cfg.Log.Level != "debug" {
+		t.Fatalf("log level = %q, want debug", cfg.Log.Level)
+	}
+	if !sharedLogger.GetLogger().Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("expected the reload to enable debug logging")
+	}
+	if cfg.Server.Port != "8081" {
+		t.Fatalf("server port = %q, want the startup port 8081", cfg.Server.Port)
+	}
+}
+
+func TestReloadKeepsConfigWhenLoadFails(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "warn", "8081")
+	loadFresh(t, dir)
+
+	called := false
+	OnReload(func(Config) { called = true })
+
+	data := []byte("log:\n  level: debug\nserver:\n  read_timeout: soon\n")
+	if err := os.WriteFile(filepath.Join(dir, "config.base.yaml"), data, 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := Reload(dir); err == nil {
+		t.Fatal("expected the reload to fail")
+	}
+	if called {
+		t.Fatal("expected no hooks to run after a failed reload")
+	}
+	if got := Get().Log.Level; got != "warn" {
+		t.Fatalf("log level = %q, want the previous level warn", got)
+	}
+}
+
+func TestApplyReloadable(t *testing.T) {
+	var current Config
+	setDefaults(&current)
+
+	loaded := current
+	loaded.Log.Level = "debug"
+	loaded.Log.Format = "text"
+	loaded.RateLimit.Requests = 120
+	loaded.Feature.EnableSwagger = false
+	loaded.Server.Port = "9090"
+	loaded.Database.Host = "replica"
+
+	applied, ignored := applyReloadable(&current, loaded)
+
+	if want := []string{"log.level", "rate_limit", "feature"}; !reflect.DeepEqual(applied, want) {
+		t.Fatalf("applied = %v, want %v", applied, want)
+	}
+	if want := []string{"server", "database", "log"}; !reflect.DeepEqual(ignored, want) {
+		t.Fatalf("ignored = %v, want %v", ignored, want)
+	}
+	if current.RateLimit.Requests != 120 || current.Feature.EnableSwagger {
+		t.Fatalf("expected rate limits and features to be applied, got %+v %+v", current.RateLimit, current.Feature)
+	}
+	if current.Server.Port != "8081" || current.Database.Host != "postgres" || current.Log.Format != "json" {
+		t.Fatal("expected restart-only settings to keep their startup values")
+	}
+}
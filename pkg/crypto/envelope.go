@@ -0,0 +1,238 @@
+// Package crypto provides field-level envelope encryption for PII. Each value is
+// encrypted with AES-256-GCM under a data key, and the data key is stored
+// alongside the ciphertext wrapped by a KeyProvider such as a KMS.
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// envelopeVersion is the first byte of every envelope
+	envelopeVersion byte = 1
+	// dataKeySize is the AES-256 data key length in bytes
+	dataKeySize = 32
+	// DefaultMaxDataKeyUses bounds encryptions per data key, well below the
+	// 2^32 limit for random 96-bit GCM nonces
+	DefaultMaxDataKeyUses = 1 << 20
+)
+
+var (
+	// ErrMalformedEnvelope is returned when ciphertext is not a valid envelope
+	ErrMalformedEnvelope = errors.New("crypto: malformed envelope")
+	// ErrNoEncryptor is returned by the gorm types when no default Encryptor is set
+	ErrNoEncryptor = errors.New("crypto: no default encryptor configured")
+)
+
+// KeyProvider creates and unwraps data keys, typically backed by a KMS
+type KeyProvider interface {
+	// GenerateDataKey returns a new data key in plaintext and wrapped form, and
+	// the ID of the key that wrapped it
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, keyID string, err error)
+	// DecryptDataKey unwraps a data key wrapped by keyID
+	DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// dataKey is the data key currently used for encryption
+type dataKey struct {
+	plaintext []byte
+	wrapped   []byte
+	keyID     string
+	uses      int
+}
+
+// Encryptor encrypts and decrypts fields with envelope encryption. A data key
+// is reused for up to MaxDataKeyUses encryptions, and unwrapped data keys are
+// cached, so the KeyProvider is not called for every field.
+type Encryptor struct {
+	provider KeyProvider
+
+	// MaxDataKeyUses is how many values are encrypted before a new data key is generated
+	MaxDataKeyUses int
+
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped map[[sha256.Size]byte][]byte
+}
+
+// NewEncryptor creates a new encryptor using provider for data keys
+func NewEncryptor(provider KeyProvider) *Encryptor {
+	return &Encryptor{
+		provider:       provider,
+		MaxDataKeyUses: DefaultMaxDataKeyUses,
+		unwrapped:      make(map[[sha256.Size]byte][]byte),
+	}
+}
+
+// Encrypt encrypts plaintext into a self-describing envelope. Every call uses a
+// fresh random nonce, so equal plaintexts give different ciphertexts.
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(key.plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("crypto: failed to generate nonce: %w", err)
+	}
+
+	// version | keyID length | keyID | wrapped key length | wrapped key | nonce | ciphertext
+	header := make([]byte, 0, 4+len(key.keyID)+len(key.wrapped)+len(nonce))
+	header = append(header, envelopeVersion, byte(len(key.keyID)))
+	header = append(header, key.keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(key.wrapped)))
+	header = append(header, key.wrapped...)
+	header = append(header, nonce...)
+
+	// The header is authenticated so the key ID and wrapped key cannot be swapped
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// Decrypt decrypts an envelope produced by Encrypt
+func (e *Encryptor) Decrypt(ctx context.Context, envelope []byte) ([]byte, error) {
+	if len(envelope) < 2 || envelope[0] != envelopeVersion {
+		return nil, ErrMalformedEnvelope
+	}
+
+	offset := 2
+	keyIDLen := int(envelope[1])
+	if len(envelope) < offset+keyIDLen+2 {
+		return nil, ErrMalformedEnvelope
+	}
+	keyID := string(envelope[offset : offset+keyIDLen])
+	offset += keyIDLen
+
+	wrappedLen := int(binary.BigEndian.Uint16(envelope[offset:]))
+	offset += 2
+	if len(envelope) < offset+wrappedLen {
+		return nil, ErrMalformedEnvelope
+	}
+	wrapped := envelope[offset : offset+wrappedLen]
+	offset += wrappedLen
+
+	key, err := e.unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope) < offset+aead.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+	nonce := envelope[offset : offset+aead.NonceSize()]
+	header := envelope[:offset+aead.NonceSize()]
+
+	plaintext, err := aead.Open(nil, nonce, envelope[len(header):], header)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts s and returns the envelope as base64
+func (e *Encryptor) EncryptString(ctx context.Context, s string) (string, error) {
+	envelope, err := e.Encrypt(ctx, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// DecryptString decrypts a base64 envelope produced by EncryptString
+func (e *Encryptor) DecryptString(ctx context.Context, s string) (string, error) {
+	envelope, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", ErrMalformedEnvelope
+	}
+	plaintext, err := e.Decrypt(ctx, envelope)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// dataKey returns the data key to encrypt with, generating a new one when the
+// current key has been used MaxDataKeyUses times
+func (e *Encryptor) dataKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current == nil || (e.MaxDataKeyUses > 0 && e.current.uses >= e.MaxDataKeyUses) {
+		plaintext, wrapped, keyID, err := e.provider.GenerateDataKey(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: failed to generate data key: %w", err)
+		}
+		if len(plaintext) != dataKeySize {
+			return nil, fmt.Errorf("crypto: data key must be %d bytes, got %d", dataKeySize, len(plaintext))
+		}
+		if len(keyID) > 255 || len(wrapped) > 65535 {
+			return nil, errors.New("crypto: key ID or wrapped data key too long")
+		}
+		e.current = &dataKey{plaintext: plaintext, wrapped: wrapped, keyID: keyID}
+		e.unwrapped[unwrappedCacheKey(keyID, wrapped)] = plaintext
+	}
+
+	e.current.uses++
+	return e.current, nil
+}
+
+// unwrap returns the plaintext of a wrapped data key, asking the provider only
+// for keys it has not seen before
+func (e *Encryptor) unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	cacheKey := unwrappedCacheKey(keyID, wrapped)
+
+	e.mu.Lock()
+	key, ok := e.unwrapped[cacheKey]
+	e.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err := e.provider.DecryptDataKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to decrypt data key: %w", err)
+	}
+
+	e.mu.Lock()
+	e.unwrapped[cacheKey] = key
+	e.mu.Unlock()
+	return key, nil
+}
+
+// unwrappedCacheKey identifies a wrapped data key in the unwrapped key cache
+func unwrappedCacheKey(keyID string, wrapped []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte(keyID))
+	h.Write([]byte{0})
+	h.Write(wrapped)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
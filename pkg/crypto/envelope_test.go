@@ -0,0 +1,148 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// countingProvider counts calls to the wrapped provider
+type countingProvider struct {
+	KeyProvider
+	generated int
+	decrypted int
+}
+
+func (p *countingProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	p.generated++
+	return p.KeyProvider.GenerateDataKey(ctx)
+}
+
+func (p *countingProvider) DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	p.decrypted++
+	return p.KeyProvider.DecryptDataKey(ctx, keyID, wrapped)
+}
+
+func randomKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func newTestEncryptor(t *testing.T) *Encryptor {
+	t.Helper()
+	provider, err := NewLocalKeyProvider("master-1", randomKey(t))
+	if err != nil {
+		t.Fatalf("NewLocalKeyProvider: %v", err)
+	}
+	return NewEncryptor(provider)
+}
+
+func TestEncryptor_RoundTrip(t *testing.T) {
+	e := newTestEncryptor(t)
+	ctx := context.Background()
+
+	envelope, err := e.Encrypt(ctx, []byte("AB1234567"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if bytes.Contains(envelope, []byte("AB1234567")) {
+		t.Fatal("envelope contains the plaintext")
+	}
+
+	plaintext, err := e.Decrypt(ctx, envelope)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if string(plaintext) != "AB1234567" {
+		t.Fatalf("expected the original plaintext, got %q", plaintext)
+	}
+
+	encrypted, err := e.EncryptString(ctx, "+44 20 7946 0958")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	decrypted, err := e.DecryptString(ctx, encrypted)
+	if err != nil || decrypted != "+44 20 7946 0958" {
+		t.Fatalf("expected the original string, got %q (%v)", decrypted, err)
+	}
+}
+
+func TestEncryptor_CiphertextIsNonDeterministic(t *testing.T) {
+	e := newTestEncryptor(t)
+
+	first, _ := e.EncryptString(context.Background(), "1990-01-01")
+	second, _ := e.EncryptString(context.Background(), "1990-01-01")
+	if first == second {
+		t.Fatal("expected equal plaintexts to encrypt differently")
+	}
+}
+
+func TestEncryptor_DetectsTampering(t *testing.T) {
+	e := newTestEncryptor(t)
+
+	envelope, _ := e.Encrypt(context.Background(), []byte("secret"))
+	envelope[len(envelope)-1] ^= 0xff
+
+	if _, err := e.Decrypt(context.Background(), envelope); err == nil {
+		t.Fatal("expected a tampered envelope to fail to decrypt")
+	}
+	if _, err := e.Decrypt(context.Background(), []byte{9, 9}); !errors.Is(err, ErrMalformedEnvelope) {
+		t.Fatalf("expected ErrMalformedEnvelope, got %v", err)
+	}
+}
+
+func TestEncryptor_ReusesAndRotatesDataKeys(t *testing.T) {
+	local, _ := NewLocalKeyProvider("master-1", randomKey(t))
+	provider := &countingProvider{KeyProvider: local}
+	e := NewEncryptor(provider)
+	e.MaxDataKeyUses = 3
+
+	var envelopes [][]byte
+	for i := 0; i < 4; i++ {
+		envelope, err := e.Encrypt(context.Background(), []byte("value"))
+		if err != nil {
+			t.Fatalf("Encrypt: %v", err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	if provider.generated != 2 {
+		t.Fatalf("expected a new data key after 3 uses, generated %d", provider.generated)
+	}
+
+	// A fresh encryptor, e.g. after a restart, unwraps each data key once
+	reader := NewEncryptor(provider)
+	for _, envelope := range envelopes {
+		if _, err := reader.Decrypt(context.Background(), envelope); err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+	}
+	if provider.decrypted != 2 {
+		t.Fatalf("expected 2 data key unwraps, got %d", provider.decrypted)
+	}
+}
+
+func TestLocalKeyProvider_DecryptsAfterMasterKeyRotation(t *testing.T) {
+	oldKey, newKey := randomKey(t), randomKey(t)
+
+	oldProvider, _ := NewLocalKeyProvider("master-1", oldKey)
+	envelope, _ := NewEncryptor(oldProvider).Encrypt(context.Background(), []byte("value"))
+
+	rotated, _ := NewLocalKeyProvider("master-2", newKey)
+	if _, err := NewEncryptor(rotated).Decrypt(context.Background(), envelope); err == nil {
+		t.Fatal("expected decryption to fail without the old master key")
+	}
+
+	if err := rotated.AddKey("master-1", oldKey); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	plaintext, err := NewEncryptor(rotated).Decrypt(context.Background(), envelope)
+	if err != nil || string(plaintext) != "value" {
+		t.Fatalf("expected the old envelope to decrypt, got %q (%v)", plaintext, err)
+	}
+}
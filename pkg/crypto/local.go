@@ -0,0 +1,79 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// LocalKeyProvider wraps data keys with in-process AES-256 master keys. It is
+// intended for development and tests; production should use a KMS-backed
+// KeyProvider so master keys never leave the KMS.
+type LocalKeyProvider struct {
+	activeKeyID string
+	masterKeys  map[string][]byte
+}
+
+// NewLocalKeyProvider creates a provider that wraps new data keys with the
+// 32-byte master key activeKeyID. Older master keys can be added with AddKey so
+// data encrypted before a rotation can still be read.
+func NewLocalKeyProvider(activeKeyID string, masterKey []byte) (*LocalKeyProvider, error) {
+	p := &LocalKeyProvider{
+		activeKeyID: activeKeyID,
+		masterKeys:  make(map[string][]byte),
+	}
+	if err := p.AddKey(activeKeyID, masterKey); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// AddKey registers a master key that can unwrap data keys
+func (p *LocalKeyProvider) AddKey(keyID string, masterKey []byte) error {
+	if keyID == "" {
+		return errors.New("crypto: master key ID is required")
+	}
+	if len(masterKey) != dataKeySize {
+		return fmt.Errorf("crypto: master key must be %d bytes, got %d", dataKeySize, len(masterKey))
+	}
+	p.masterKeys[keyID] = masterKey
+	return nil
+}
+
+// GenerateDataKey implements KeyProvider
+func (p *LocalKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	plaintext := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, "", err
+	}
+
+	aead, err := newGCM(p.masterKeys[p.activeKeyID])
+	if err != nil {
+		return nil, nil, "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, "", err
+	}
+
+	wrapped := aead.Seal(nonce, nonce, plaintext, []byte(p.activeKeyID))
+	return plaintext, wrapped, p.activeKeyID, nil
+}
+
+// DecryptDataKey implements KeyProvider
+func (p *LocalKeyProvider) DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	masterKey, ok := p.masterKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("crypto: unknown master key %q", keyID)
+	}
+
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+}
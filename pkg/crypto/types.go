@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	defaultMu        sync.RWMutex
+	defaultEncryptor *Encryptor
+)
+
+// SetDefault sets the Encryptor used by EncryptedString and EncryptedBytes.
+// Services call it once at startup with their configured KeyProvider.
+func SetDefault(e *Encryptor) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultEncryptor = e
+}
+
+// Default returns the Encryptor set by SetDefault
+func Default() (*Encryptor, error) {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultEncryptor == nil {
+		return nil, ErrNoEncryptor
+	}
+	return defaultEncryptor, nil
+}
+
+// EncryptedString is a string column that is encrypted when written to the
+// database and decrypted when read, e.g.
+//
+//	PhoneNumber crypto.EncryptedString `gorm:"type:text"`
+type EncryptedString string
+
+// Value implements driver.Valuer
+func (s EncryptedString) Value() (driver.Value, error) {
+	e, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return e.EncryptString(context.Background(), string(s))
+}
+
+// Scan implements sql.Scanner
+func (s *EncryptedString) Scan(value interface{}) error {
+	if value == nil {
+		*s = ""
+		return nil
+	}
+
+	ciphertext, err := scanText(value)
+	if err != nil {
+		return err
+	}
+
+	e, err := Default()
+	if err != nil {
+		return err
+	}
+	plaintext, err := e.DecryptString(context.Background(), ciphertext)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// GormDataType implements gorm's schema.GormDataTypeInterface
+func (EncryptedString) GormDataType() string {
+	return "text"
+}
+
+// EncryptedBytes is a binary column that is encrypted when written to the
+// database and decrypted when read
+type EncryptedBytes []byte
+
+// Value implements driver.Valuer
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	e, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return e.Encrypt(context.Background(), b)
+}
+
+// Scan implements sql.Scanner
+func (b *EncryptedBytes) Scan(value interface{}) error {
+	if value == nil {
+		*b = nil
+		return nil
+	}
+
+	envelope, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("crypto: cannot scan %T into EncryptedBytes", value)
+	}
+
+	e, err := Default()
+	if err != nil {
+		return err
+	}
+	plaintext, err := e.Decrypt(context.Background(), envelope)
+	if err != nil {
+		return err
+	}
+	*b = plaintext
+	return nil
+}
+
+// GormDataType implements gorm's schema.GormDataTypeInterface
+func (EncryptedBytes) GormDataType() string {
+	return "bytes"
+}
+
+// scanText converts a text column value to a string
+func scanText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return "", errors.New("crypto: encrypted string column must be text")
+}
@@ -0,0 +1,83 @@
+package crypto
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// customer is a model with encrypted PII fields
+type customer struct {
+	ID          uint
+	Name        string
+	PhoneNumber EncryptedString
+	DocumentRaw EncryptedBytes
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&customer{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestEncryptedTypes_RoundTripThroughGorm(t *testing.T) {
+	SetDefault(newTestEncryptor(t))
+	t.Cleanup(func() { SetDefault(nil) })
+	db := newTestDB(t)
+
+	original := customer{Name: "Ada", PhoneNumber: "+44 20 7946 0958", DocumentRaw: []byte{0x25, 0x50, 0x44, 0x46}}
+	if err := db.Create(&original).Error; err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var loaded customer
+	if err := db.First(&loaded, original.ID).Error; err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if loaded.PhoneNumber != original.PhoneNumber {
+		t.Fatalf("expected phone number %q, got %q", original.PhoneNumber, loaded.PhoneNumber)
+	}
+	if string(loaded.DocumentRaw) != string(original.DocumentRaw) {
+		t.Fatalf("expected document %x, got %x", original.DocumentRaw, loaded.DocumentRaw)
+	}
+
+	// The column holds ciphertext, not the phone number
+	var stored string
+	db.Raw("SELECT phone_number FROM customers WHERE id = ?", original.ID).Scan(&stored)
+	if stored == "" || stored == string(original.PhoneNumber) {
+		t.Fatalf("expected ciphertext in the database, got %q", stored)
+	}
+}
+
+func TestEncryptedTypes_StoredCiphertextIsNonDeterministic(t *testing.T) {
+	SetDefault(newTestEncryptor(t))
+	t.Cleanup(func() { SetDefault(nil) })
+	db := newTestDB(t)
+
+	first := customer{Name: "Ada", PhoneNumber: "+44 20 7946 0958"}
+	second := customer{Name: "Grace", PhoneNumber: "+44 20 7946 0958"}
+	db.Create(&first)
+	db.Create(&second)
+
+	var stored []string
+	db.Raw("SELECT phone_number FROM customers ORDER BY id").Scan(&stored)
+	if len(stored) != 2 || stored[0] == stored[1] {
+		t.Fatalf("expected the same phone number to be stored differently, got %v", stored)
+	}
+}
+
+func TestEncryptedTypes_RequireDefaultEncryptor(t *testing.T) {
+	SetDefault(nil)
+
+	if _, err := EncryptedString("value").Value(); err != ErrNoEncryptor {
+		t.Fatalf("expected ErrNoEncryptor, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/sony/gobreaker"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBulkheadFull is returned when too many database operations are already in flight
+	ErrBulkheadFull = errors.New("database: too many concurrent operations")
+	// ErrCircuitOpen is returned while the database circuit breaker is open
+	ErrCircuitOpen = errors.New("database: circuit breaker is open")
+)
+
+// GuardConfig holds configuration for the database bulkhead and circuit breaker
+type GuardConfig struct {
+	// MaxConcurrent bounds in-flight operations; size it to the connection pool
+	MaxConcurrent int
+	// AcquireTimeout is how long an operation may wait for a slot. Zero fails immediately.
+	AcquireTimeout time.Duration
+	// FailureRatio opens the breaker once this share of requests in Interval fail
+	FailureRatio float64
+	// MinRequests is the number of requests needed before FailureRatio is evaluated
+	MinRequests uint32
+	// Interval is the window over which failures are counted while the breaker is closed
+	Interval time.Duration
+	// OpenTimeout is how long the breaker stays open before letting a trial request through
+	OpenTimeout time.Duration
+}
+
+// DefaultGuardConfig returns default guard configuration for a pool of maxOpenConns connections
+func DefaultGuardConfig(maxOpenConns int) GuardConfig {
+	return GuardConfig{
+		MaxConcurrent:  maxOpenConns,
+		AcquireTimeout: 100 * time.Millisecond,
+		FailureRatio:   0.5,
+		MinRequests:    20,
+		Interval:       10 * time.Second,
+		OpenTimeout:    5 * time.Second,
+	}
+}
+
+// Guard protects the database with a bulkhead that bounds concurrent operations and
+// a circuit breaker that fails fast while the database is erroring. Excess load is
+// rejected with ErrBulkheadFull or ErrCircuitOpen instead of queueing indefinitely.
+type Guard struct {
+	name    string
+	config  GuardConfig
+	slots   chan struct{}
+	breaker *gobreaker.TwoStepCircuitBreaker
+}
+
+// NewGuard creates a new database guard
+func NewGuard(name string, cfg GuardConfig) *Guard {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = DefaultConfig().MaxOpenConns
+	}
+
+	return &Guard{
+		name:   name,
+		config: cfg,
+		slots:  make(chan struct{}, cfg.MaxConcurrent),
+		breaker: gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+			Name:     name,
+			Interval: cfg.Interval,
+			Timeout:  cfg.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				if counts.Requests < cfg.MinRequests {
+					return false
+				}
+				return float64(counts.TotalFailures)/float64(counts.Requests) >= cfg.FailureRatio
+			},
+		}),
+	}
+}
+
+// Do runs fn if a slot is available and the breaker is closed
+func (g *Guard) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := g.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	done(err)
+	return err
+}
+
+// acquire reserves a bulkhead slot and a breaker permit. The returned func must be
+// called with the operation's result to release them.
+func (g *Guard) acquire(ctx context.Context) (func(error), error) {
+	if err := g.acquireSlot(ctx); err != nil {
+		metrics.RecordDBRejection(g.name, "bulkhead")
+		return nil, err
+	}
+
+	report, err := g.breaker.Allow()
+	if err != nil {
+		<-g.slots
+		metrics.RecordDBRejection(g.name, "circuit_open")
+		return nil, ErrCircuitOpen
+	}
+
+	return func(opErr error) {
+		report(isHealthyResult(opErr))
+		<-g.slots
+	}, nil
+}
+
+// acquireSlot takes a bulkhead slot, waiting at most AcquireTimeout
+func (g *Guard) acquireSlot(ctx context.Context) error {
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if g.config.AcquireTimeout <= 0 {
+		return ErrBulkheadFull
+	}
+
+	timer := time.NewTimer(g.config.AcquireTimeout)
+	defer timer.Stop()
+
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Postgres error classes for statements the database rejected because of the
+// data they carried, e.g. a duplicate key or a value out of range
+const (
+	sqlStateClassDataException       = "22"
+	sqlStateClassIntegrityConstraint = "23"
+)
+
+// isHealthyResult reports whether err says nothing about database health.
+// Missing rows, cancelled requests and statements rejected for their data,
+// such as constraint violations, must not trip the breaker.
+func isHealthyResult(err error) bool {
+	return err == nil ||
+		errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, context.Canceled) ||
+		isDataError(err)
+}
+
+// isDataError reports whether err is Postgres rejecting a statement because of
+// a data exception or an integrity constraint violation
+func isDataError(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+	code := sqlErr.SQLState()
+	return strings.HasPrefix(code, sqlStateClassDataException) ||
+		strings.HasPrefix(code, sqlStateClassIntegrityConstraint)
+}
+
+// IsOverloaded reports whether err is a guard rejection that should be surfaced
+// to clients as 503 Service Unavailable
+func IsOverloaded(err error) bool {
+	return errors.Is(err, ErrBulkheadFull) || errors.Is(err, ErrCircuitOpen)
+}
+
+// guardReleaseKey stores the release func between gorm's before and after callbacks
+const guardReleaseKey = "database:guard_release"
+
+// Name implements gorm.Plugin
+func (g *Guard) Name() string {
+	return "database_guard:" + g.name
+}
+
+// Initialize implements gorm.Plugin, guarding every query, raw, create, update
+// and delete issued through db.
+//
+// Row, Rows and Scan are not guarded: their rows are read after gorm's callbacks
+// return, so the slot would be released while the connection is still in use.
+// Wrap reads that hold a cursor open in Do to bound them.
+func (g *Guard) Initialize(db *gorm.DB) error {
+	for _, err := range []error{
+		db.Callback().Create().Before("*").Register("guard:before_create", g.before),
+		db.Callback().Create().After("*").Register("guard:after_create", g.after),
+		db.Callback().Query().Before("*").Register("guard:before_query", g.before),
+		db.Callback().Query().After("*").Register("guard:after_query", g.after),
+		db.Callback().Update().Before("*").Register("guard:before_update", g.before),
+		db.Callback().Update().After("*").Register("guard:after_update", g.after),
+		db.Callback().Delete().Before("*").Register("guard:before_delete", g.before),
+		db.Callback().Delete().After("*").Register("guard:after_delete", g.after),
+		db.Callback().Raw().Before("*").Register("guard:before_raw", g.before),
+		db.Callback().Raw().After("*").Register("guard:after_raw", g.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before acquires the guard for a gorm statement, aborting it when rejected
+func (g *Guard) before(db *gorm.DB) {
+	done, err := g.acquire(db.Statement.Context)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(guardReleaseKey, done)
+}
+
+// after releases the guard once a gorm statement has run
+func (g *Guard) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(guardReleaseKey)
+	if !ok {
+		return
+	}
+	if done, ok := value.(func(error)); ok {
+		done(db.Error)
+	}
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGuard_RejectsExcessConcurrentOperations(t *testing.T) {
+	guard := NewGuard("test", GuardConfig{
+		MaxConcurrent:  2,
+		AcquireTimeout: 10 * time.Millisecond,
+		FailureRatio:   0.5,
+		MinRequests:    100,
+		OpenTimeout:    time.Second,
+	})
+
+	// Hold every slot with operations that block until released
+	release := make(chan struct{})
+	var started, finished sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		started.Add(1)
+		finished.Add(1)
+		go func() {
+			defer finished.Done()
+			guard.Do(context.Background(), func(ctx context.Context) error {
+				started.Done()
+				<-release
+				return nil
+			})
+		}()
+	}
+	started.Wait()
+
+	// Excess calls must fail fast instead of blocking until a slot frees up
+	result := make(chan error, 1)
+	go func() {
+		result <- guard.Do(context.Background(), func(ctx context.Context) error {
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrBulkheadFull) {
+			t.Fatalf("expected ErrBulkheadFull, got %v", err)
+		}
+		if !IsOverloaded(err) {
+			t.Fatal("expected a bulkhead rejection to count as overloaded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("excess operation blocked instead of being rejected")
+	}
+
+	close(release)
+	finished.Wait()
+
+	// Slots are returned once operations complete
+	if err := guard.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected a free slot after release, got %v", err)
+	}
+}
+
+func TestGuard_OpensBreakerOnErrors(t *testing.T) {
+	guard := NewGuard("test", GuardConfig{
+		MaxConcurrent: 10,
+		FailureRatio:  0.5,
+		MinRequests:   4,
+		OpenTimeout:   time.Minute,
+	})
+
+	dbErr := errors.New("connection refused")
+	for i := 0; i < 4; i++ {
+		if err := guard.Do(context.Background(), func(ctx context.Context) error { return dbErr }); !errors.Is(err, dbErr) {
+			t.Fatalf("expected the database error, got %v", err)
+		}
+	}
+
+	called := false
+	err := guard.Do(context.Background(), func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if called {
+		t.Fatal("operation ran while the breaker was open")
+	}
+}
+
+func TestGuard_NotFoundDoesNotTripBreaker(t *testing.T) {
+	guard := NewGuard("test", GuardConfig{
+		MaxConcurrent: 10,
+		FailureRatio:  0.5,
+		MinRequests:   4,
+		OpenTimeout:   time.Minute,
+	})
+
+	for i := 0; i < 10; i++ {
+		guard.Do(context.Background(), func(ctx context.Context) error { return gorm.ErrRecordNotFound })
+	}
+
+	if err := guard.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected the breaker to stay closed, got %v", err)
+	}
+}
+
+func TestGuard_DataErrorsDoNotTripBreaker(t *testing.T) {
+	config := GuardConfig{
+		MaxConcurrent: 10,
+		FailureRatio:  0.5,
+		MinRequests:   4,
+		OpenTimeout:   time.Minute,
+	}
+	guard := NewGuard("test", config)
+
+	// unique violation, foreign key violation, invalid text representation
+	for _, code := range []string{"23505", "23503", "22P02"} {
+		for i := 0; i < 4; i++ {
+			guard.Do(context.Background(), func(ctx context.Context) error { return sqlStateError(code) })
+		}
+	}
+	if err := guard.Do(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected the breaker to stay closed, got %v", err)
+	}
+
+	// a connection failure still counts against the database
+	guard = NewGuard("test", config)
+	for i := 0; i < 4; i++ {
+		guard.Do(context.Background(), func(ctx context.Context) error { return sqlStateError("08006") })
+	}
+	if err := guard.Do(context.Background(), func(ctx context.Context) error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected connection failures to open the breaker, got %v", err)
+	}
+}
+
+// guardedRecord is a table for exercising the guard through gorm
+type guardedRecord struct {
+	ID   uint
+	Name string
+}
+
+func TestGuard_LeavesRowsToCallers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&guardedRecord{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	guard := NewGuard("test", GuardConfig{
+		MaxConcurrent:  1,
+		AcquireTimeout: 10 * time.Millisecond,
+		FailureRatio:   0.5,
+		MinRequests:    100,
+		OpenTimeout:    time.Second,
+	})
+	if err := db.Use(guard); err != nil {
+		t.Fatalf("failed to install guard: %v", err)
+	}
+
+	// Hold the only slot
+	started := make(chan struct{})
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		guard.Do(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer func() {
+		close(release)
+		<-finished
+	}()
+
+	var records []guardedRecord
+	if err := db.Find(&records).Error; !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("expected Find to be rejected, got %v", err)
+	}
+
+	// The slot could not be held while the caller reads the rows, so Rows is not guarded
+	rows, err := db.Model(&guardedRecord{}).Rows()
+	if err != nil {
+		t.Fatalf("expected Rows to bypass the guard, got %v", err)
+	}
+	rows.Close()
+}
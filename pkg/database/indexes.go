@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// identifierPattern matches the table and index names EnsureIndexes accepts
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Index is an index a service's queries rely on but AutoMigrate does not
+// create from struct tags, such as a composite index in a specific order
+type Index struct {
+	Name  string
+	Table string
+	// Columns are the indexed columns or expressions in order, e.g.
+	// "created_at DESC"
+	Columns []string
+	Unique  bool
+	// Where, if set, makes this a partial index over the matching rows
+	Where string
+}
+
+// SQL returns the statement creating the index if it does not exist.
+// Concurrent builds do not block writes to the table but cannot run inside a
+// transaction.
+func (i Index) SQL(concurrently bool) string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if i.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX ")
+	if concurrently {
+		b.WriteString("CONCURRENTLY ")
+	}
+	fmt.Fprintf(&b, `IF NOT EXISTS "%s" ON "%s" (%s)`, i.Name, i.Table, strings.Join(i.Columns, ", "))
+	if i.Where != "" {
+		b.WriteString(" WHERE " + i.Where)
+	}
+	return b.String()
+}
+
+// validate checks the index is fully declared
+func (i Index) validate() error {
+	if !identifierPattern.MatchString(i.Name) || !identifierPattern.MatchString(i.Table) {
+		return fmt.Errorf("invalid index %q on table %q", i.Name, i.Table)
+	}
+	if len(i.Columns) == 0 {
+		return fmt.Errorf("index %s has no columns", i.Name)
+	}
+	return nil
+}
+
+// EnsureIndexes creates each of indexes that does not exist yet and returns
+// the names of those it created. Existing indexes are left alone, so it is
+// safe, and cheap, to run at every startup. On Postgres indexes are built
+// concurrently so startup does not lock busy tables against writes; db must
+// therefore not be a transaction. An index left invalid by an interrupted
+// concurrent build is dropped and built again.
+//
+// A failure to create one index does not stop the others; the failures are
+// returned together. Missing indexes slow queries down rather than break
+// them, so callers usually log the error and carry on.
+func EnsureIndexes(ctx context.Context, db *gorm.DB, indexes []Index) ([]string, error) {
+	db = db.WithContext(ctx)
+	postgres := db.Dialector.Name() == "postgres"
+
+	var created []string
+	var errs []error
+	for _, index := range indexes {
+		if err := index.validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		exists, err := indexExists(db, index, postgres)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check index %s: %w", index.Name, err))
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		log.Printf("Creating missing index %s on %s", index.Name, index.Table)
+		if err := db.Exec(index.SQL(postgres)).Error; err != nil {
+			errs = append(errs, fmt.Errorf("failed to create index %s: %w", index.Name, err))
+			continue
+		}
+		created = append(created, index.Name)
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// indexExists reports whether a usable index named index.Name exists on its
+// table. On Postgres an invalid index, left by a failed concurrent build, is
+// dropped and reported missing so it gets rebuilt.
+func indexExists(db *gorm.DB, index Index, postgres bool) (bool, error) {
+	if !postgres {
+		return db.Migrator().HasIndex(index.Table, index.Name), nil
+	}
+
+	var valid []bool
+	err := db.Raw(`
+		SELECT i.indisvalid
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		JOIN pg_class t ON t.oid = i.indrelid
+		WHERE c.relname = ? AND t.relname = ? AND pg_table_is_visible(c.oid)
+	`, index.Name, index.Table).Scan(&valid).Error
+	if err != nil {
+		return false, err
+	}
+	if len(valid) == 0 {
+		return false, nil
+	}
+	if valid[0] {
+		return true, nil
+	}
+
+	log.Printf("Index %s on %s is invalid, rebuilding it", index.Name, index.Table)
+	if err := db.Exec(fmt.Sprintf(`DROP INDEX CONCURRENTLY IF EXISTS "%s"`, index.Name)).Error; err != nil {
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// indexedLedgerEntry is a table that needs a composite index AutoMigrate does not create
+type indexedLedgerEntry struct {
+	ID        uint
+	AccountID uint
+	Status    string
+	CreatedAt time.Time
+}
+
+func (indexedLedgerEntry) TableName() string { return "ledger_entries" }
+
+var ledgerIndexes = []Index{
+	{Name: "idx_ledger_entries_account_created", Table: "ledger_entries", Columns: []string{"account_id", "created_at DESC", "id DESC"}},
+	{Name: "idx_ledger_entries_pending", Table: "ledger_entries", Columns: []string{"created_at"}, Where: "status = 'pending'"},
+}
+
+func newIndexTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&indexedLedgerEntry{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestEnsureIndexes_CreatesMissingIndexesOnce(t *testing.T) {
+	db := newIndexTestDB(t)
+	ctx := context.Background()
+
+	created, err := EnsureIndexes(ctx, db, ledgerIndexes)
+	if err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected both indexes to be created, got %v", created)
+	}
+	for _, index := range ledgerIndexes {
+		if !db.Migrator().HasIndex(index.Table, index.Name) {
+			t.Fatalf("expected index %s to exist", index.Name)
+		}
+	}
+
+	// A second run, as at the next startup, finds nothing to do
+	created, err = EnsureIndexes(ctx, db, ledgerIndexes)
+	if err != nil {
+		t.Fatalf("second EnsureIndexes: %v", err)
+	}
+	if len(created) != 0 {
+		t.Fatalf("expected the second run to be a no-op, created %v", created)
+	}
+}
+
+func TestEnsureIndexes_ContinuesPastFailures(t *testing.T) {
+	db := newIndexTestDB(t)
+
+	indexes := []Index{
+		{Name: "idx_missing_table", Table: "no_such_table", Columns: []string{"id"}},
+		{Name: "bad name", Table: "ledger_entries", Columns: []string{"id"}},
+		ledgerIndexes[0],
+	}
+	created, err := EnsureIndexes(context.Background(), db, indexes)
+	if err == nil {
+		t.Fatal("expected the failed indexes to be reported")
+	}
+	if !strings.Contains(err.Error(), "idx_missing_table") || !strings.Contains(err.Error(), "bad name") {
+		t.Fatalf("expected both failures in the error, got %v", err)
+	}
+	if len(created) != 1 || created[0] != ledgerIndexes[0].Name {
+		t.Fatalf("expected the valid index to still be created, got %v", created)
+	}
+}
+
+func TestIndex_SQL(t *testing.T) {
+	index := Index{
+		Name:    "idx_documents_pending",
+		Table:   "documents",
+		Columns: []string{"user_id", "created_at DESC"},
+		Unique:  true,
+		Where:   "status = 'pending'",
+	}
+
+	want := `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "idx_documents_pending" ON "documents" (user_id, created_at DESC) WHERE status = 'pending'`
+	if got := index.SQL(true); got != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+	if got := index.SQL(false); strings.Contains(got, "CONCURRENTLY") {
+		t.Fatalf("expected a plain build, got %s", got)
+	}
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MigrationLockKey derives the Postgres advisory lock key for a service's
+// migrations. Replicas of one service share a key; different services do not
+// block each other.
+func MigrationLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("sparkfund:migrations:" + name))
+	return int64(h.Sum64())
+}
+
+// WithMigrationLock runs migrate while holding a Postgres advisory lock keyed by
+// name, so replicas starting together migrate one at a time. Replicas that lose
+// the race wait for the lock, for no longer than ctx allows, and then run migrate
+// themselves, which finds the schema already up to date; migrate must therefore
+// be idempotent.
+//
+// The lock is session scoped and held on a dedicated connection, so migrate is
+// free to open its own transactions on db.
+func WithMigrationLock(ctx context.Context, db *gorm.DB, name string, migrate func(db *gorm.DB) error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reserve connection for migration lock: %w", err)
+	}
+	defer conn.Close()
+
+	key := MigrationLockKey(name)
+	start := time.Now()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return fmt.Errorf("failed to acquire migration lock for %s: %w", name, err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		log.Printf("Waited %v for the %s migration lock", waited.Round(time.Millisecond), name)
+	}
+	defer func() {
+		// Use a fresh context so a cancelled startup still releases the lock;
+		// closing the connection would release it too, but only once the pool drops it
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("Failed to release the %s migration lock: %v", name, err)
+		}
+	}()
+
+	return migrate(db.WithContext(ctx))
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/testfixtures"
+	"gorm.io/gorm"
+)
+
+func TestMigrationLockKey(t *testing.T) {
+	if MigrationLockKey("kyc") != MigrationLockKey("kyc") {
+		t.Fatal("expected the key to be stable across calls")
+	}
+	if MigrationLockKey("kyc") == MigrationLockKey("investment") {
+		t.Fatal("expected services to use different keys")
+	}
+}
+
+func TestWithMigrationLock_ConcurrentReplicasMigrateOnce(t *testing.T) {
+	db := testfixtures.Postgres(t)
+	if err := db.Exec("DROP TABLE IF EXISTS migration_lock_runs").Error; err != nil {
+		t.Fatalf("failed to reset table: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DROP TABLE IF EXISTS migration_lock_runs") })
+
+	// An idempotent migration that records each time it actually applies
+	var mu sync.Mutex
+	var applied, running, overlapped int
+	entered := make(chan struct{}, 2)
+	migrate := func(tx *gorm.DB) error {
+		mu.Lock()
+		running++
+		if running > 1 {
+			overlapped++
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		entered <- struct{}{}
+
+		if tx.Migrator().HasTable("migration_lock_runs") {
+			return nil
+		}
+		// Hold the lock long enough for the other replica to queue behind it
+		time.Sleep(200 * time.Millisecond)
+		if err := tx.Exec("CREATE TABLE migration_lock_runs (id int)").Error; err != nil {
+			return err
+		}
+		mu.Lock()
+		applied++
+		mu.Unlock()
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := make(chan struct{})
+	errs := make(chan error, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- WithMigrationLock(ctx, db, "migration-lock-test", migrate)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("migration failed: %v", err)
+		}
+	}
+	if len(entered) != 2 {
+		t.Fatalf("expected both replicas to run migrate, got %d", len(entered))
+	}
+	if overlapped != 0 {
+		t.Fatal("expected the second replica to wait for the first to finish")
+	}
+	if applied != 1 {
+		t.Fatalf("expected the migration to apply exactly once, applied %d times", applied)
+	}
+}
+
+func TestWithMigrationLock_WaitIsBoundedByContext(t *testing.T) {
+	db := testfixtures.Postgres(t)
+
+	held := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- WithMigrationLock(context.Background(), db, "migration-lock-timeout", func(*gorm.DB) error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ran := false
+	err := WithMigrationLock(ctx, db, "migration-lock-timeout", func(*gorm.DB) error {
+		ran = true
+		return nil
+	})
+	if err == nil || ran {
+		t.Fatalf("expected the waiting replica to give up without migrating, got err=%v ran=%v", err, ran)
+	}
+	if ctx.Err() == nil {
+		t.Fatalf("expected the wait to end with the context, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("lock holder failed: %v", err)
+	}
+}
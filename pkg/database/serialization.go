@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/retry"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes for transactions that lost a conflict with a concurrent
+// transaction and may succeed if run again
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// DefaultTransactionRetryPolicy returns a policy of 5 attempts with short,
+// heavily jittered backoff, so transactions that conflicted do not collide again
+func DefaultTransactionRetryPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: 5,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    500 * time.Millisecond,
+		Strategy:    retry.StrategyExponential,
+		Jitter:      0.5,
+	}
+}
+
+// WithRetryingTransaction runs fn in a transaction, running the whole
+// transaction again when Postgres aborts it with a serialization failure or
+// deadlock. Other errors are returned at once. fn may run several times, so it
+// must not have side effects outside the transaction.
+func WithRetryingTransaction(ctx context.Context, db *gorm.DB, policy retry.Policy, fn func(tx *gorm.DB) error) error {
+	policy.Retryable = IsSerializationFailure
+	return policy.Do(ctx, func(ctx context.Context) error {
+		return WithTransaction(ctx, db, fn)
+	})
+}
+
+// WithSerializableTransaction runs fn in a SERIALIZABLE transaction, retried
+// with WithRetryingTransaction. Use it for transfers and balance updates that
+// must behave as if run one at a time.
+func WithSerializableTransaction(ctx context.Context, db *gorm.DB, policy retry.Policy, fn func(tx *gorm.DB) error) error {
+	return WithRetryingTransaction(ctx, db, policy, func(tx *gorm.DB) error {
+		if err := tx.Exec("SET TRANSACTION ISOLATION LEVEL SERIALIZABLE").Error; err != nil {
+			return fmt.Errorf("failed to set isolation level: %w", err)
+		}
+		return fn(tx)
+	})
+}
+
+// IsSerializationFailure reports whether err is Postgres aborting a transaction
+// because of a serialization failure or deadlock with a concurrent transaction
+func IsSerializationFailure(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+	switch sqlErr.SQLState() {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	}
+	return false
+}
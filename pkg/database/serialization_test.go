@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/retry"
+	"github.com/adil-faiyaz98/sparkfund/pkg/testfixtures"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// ledgerEntry is written by the transactions under test
+type ledgerEntry struct {
+	ID     uint
+	Amount int
+}
+
+func newLedgerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	// Every connection to an in-memory database is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&ledgerEntry{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func fastRetryPolicy() retry.Policy {
+	policy := DefaultTransactionRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	return policy
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	if !IsSerializationFailure(fmt.Errorf("transfer: %w", sqlStateError(sqlStateSerializationFailure))) {
+		t.Fatal("expected a wrapped serialization failure to be detected")
+	}
+	if !IsSerializationFailure(sqlStateError(sqlStateDeadlockDetected)) {
+		t.Fatal("expected a deadlock to be detected")
+	}
+	if IsSerializationFailure(sqlStateError("23505")) {
+		t.Fatal("expected a unique violation not to be a serialization failure")
+	}
+	if IsSerializationFailure(errors.New("connection refused")) {
+		t.Fatal("expected a plain error not to be a serialization failure")
+	}
+}
+
+func TestWithRetryingTransaction_RetriesSerializationFailure(t *testing.T) {
+	db := newLedgerTestDB(t)
+
+	attempts := 0
+	err := WithRetryingTransaction(context.Background(), db, fastRetryPolicy(), func(tx *gorm.DB) error {
+		attempts++
+		if err := tx.Create(&ledgerEntry{Amount: 100}).Error; err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return fmt.Errorf("debit: %w", sqlStateError(sqlStateSerializationFailure))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected the transaction to succeed once retried, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+
+	// The failed attempts were rolled back, so only the last one's write remains
+	var count int64
+	db.Model(&ledgerEntry{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected 1 ledger entry, got %d", count)
+	}
+}
+
+func TestWithRetryingTransaction_CapsAttempts(t *testing.T) {
+	db := newLedgerTestDB(t)
+	policy := fastRetryPolicy()
+	policy.MaxAttempts = 4
+
+	attempts := 0
+	err := WithRetryingTransaction(context.Background(), db, policy, func(tx *gorm.DB) error {
+		attempts++
+		return sqlStateError(sqlStateDeadlockDetected)
+	})
+	if !IsSerializationFailure(err) {
+		t.Fatalf("expected the last serialization failure once retries ran out, got %v", err)
+	}
+	if attempts != 4 {
+		t.Fatalf("expected 4 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetryingTransaction_DoesNotRetryOtherErrors(t *testing.T) {
+	db := newLedgerTestDB(t)
+	errInsufficientFunds := errors.New("insufficient funds")
+
+	attempts := 0
+	err := WithRetryingTransaction(context.Background(), db, fastRetryPolicy(), func(tx *gorm.DB) error {
+		attempts++
+		return errInsufficientFunds
+	})
+	if !errors.Is(err, errInsufficientFunds) {
+		t.Fatalf("expected the business error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestWithSerializableTransaction_ConcurrentTransfersAllApply(t *testing.T) {
+	db := testfixtures.Postgres(t)
+	// A regular table, as the transactions run on different pooled connections
+	if err := db.Exec("CREATE TABLE serialization_balances (id int PRIMARY KEY, amount int NOT NULL)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DROP TABLE serialization_balances") })
+	if err := db.Exec("INSERT INTO serialization_balances VALUES (1, 0)").Error; err != nil {
+		t.Fatalf("failed to seed balance: %v", err)
+	}
+
+	// Read-modify-write under SERIALIZABLE: concurrent transactions conflict and
+	// must be retried for every increment to land
+	const transfers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, transfers)
+	for i := 0; i < transfers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- WithSerializableTransaction(context.Background(), db, retry.Policy{
+				MaxAttempts: 50,
+				BaseDelay:   5 * time.Millisecond,
+				MaxDelay:    50 * time.Millisecond,
+				Jitter:      0.5,
+			}, func(tx *gorm.DB) error {
+				var amount int
+				if err := tx.Raw("SELECT amount FROM serialization_balances WHERE id = 1").Scan(&amount).Error; err != nil {
+					return err
+				}
+				return tx.Exec("UPDATE serialization_balances SET amount = ? WHERE id = 1", amount+10).Error
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected every transfer to apply, got %v", err)
+		}
+	}
+	var amount int
+	db.Raw("SELECT amount FROM serialization_balances WHERE id = 1").Scan(&amount)
+	if amount != transfers*10 {
+		t.Fatalf("expected balance %d, got %d", transfers*10, amount)
+	}
+}
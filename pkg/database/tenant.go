@@ -0,0 +1,148 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// TenantColumn is the column that marks a model as tenant-scoped
+const TenantColumn = "tenant_id"
+
+// ErrCrossTenantWrite is returned when a write carries a tenant other than the caller's
+var ErrCrossTenantWrite = errors.New("database: record belongs to another tenant")
+
+// TenantScope is a gorm plugin isolating tenant-scoped models, those with a
+// tenant_id column, by the tenant in the statement context. Queries, updates and
+// deletes are restricted to the caller's tenant, and creates and updates are
+// stamped with it. A statement on a tenant-scoped model without a tenant in its
+// context fails with tenant.ErrMissingTenant unless the context was marked with
+// tenant.WithoutScope.
+//
+// Raw SQL and Exec are not rewritten; repositories must scope those themselves.
+type TenantScope struct{}
+
+// NewTenantScope creates the tenant isolation plugin
+func NewTenantScope() *TenantScope {
+	return &TenantScope{}
+}
+
+// Name implements gorm.Plugin
+func (s *TenantScope) Name() string {
+	return "tenant_scope"
+}
+
+// Initialize implements gorm.Plugin
+func (s *TenantScope) Initialize(db *gorm.DB) error {
+	for _, err := range []error{
+		db.Callback().Create().Before("gorm:create").Register("tenant:create", s.create),
+		db.Callback().Query().Before("gorm:query").Register("tenant:query", s.filter),
+		db.Callback().Update().Before("gorm:update").Register("tenant:update", s.update),
+		db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", s.filter),
+		db.Callback().Row().Before("gorm:row").Register("tenant:row", s.filter),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantFor returns the tenant column of the statement's model and the caller's
+// tenant. ok is false when the statement does not need scoping.
+func (s *TenantScope) tenantFor(db *gorm.DB) (field *schema.Field, id string, ok bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, "", false
+	}
+	field = db.Statement.Schema.LookUpField(TenantColumn)
+	if field == nil {
+		return nil, "", false
+	}
+
+	ctx := db.Statement.Context
+	if tenant.IsUnscoped(ctx) {
+		return nil, "", false
+	}
+	id, found := tenant.FromContext(ctx)
+	if !found {
+		db.AddError(tenant.ErrMissingTenant)
+		return nil, "", false
+	}
+	return field, id, true
+}
+
+// condition matches rows of the statement's table that belong to id
+func (s *TenantScope) condition(db *gorm.DB, field *schema.Field, id string) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: id}
+}
+
+// filter restricts a query, row or delete to the caller's tenant
+func (s *TenantScope) filter(db *gorm.DB) {
+	field, id, ok := s.tenantFor(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{s.condition(db, field, id)}})
+}
+
+// update restricts an update to the caller's tenant and stamps the model with it,
+// so a Save cannot move a row to another tenant or clear its tenant
+func (s *TenantScope) update(db *gorm.DB) {
+	field, id, ok := s.tenantFor(db)
+	if !ok {
+		return
+	}
+	s.stamp(db, field, id)
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{s.condition(db, field, id)}})
+}
+
+// create stamps new records with the caller's tenant. An upsert only updates an
+// existing row of the same tenant, which also covers the fallback gorm's Save
+// makes to an upsert when its update matches no rows.
+func (s *TenantScope) create(db *gorm.DB) {
+	field, id, ok := s.tenantFor(db)
+	if !ok {
+		return
+	}
+	s.stamp(db, field, id)
+
+	if c, exists := db.Statement.Clauses[clause.OnConflict{}.Name()]; exists {
+		if onConflict, isOnConflict := c.Expression.(clause.OnConflict); isOnConflict && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, s.condition(db, field, id))
+			c.Expression = onConflict
+			db.Statement.Clauses[onConflict.Name()] = c
+		}
+	}
+}
+
+// stamp sets the tenant on every record being written, rejecting records that
+// already belong to another tenant
+func (s *TenantScope) stamp(db *gorm.DB, field *schema.Field, id string) {
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			s.stampRecord(db, field, reflect.Indirect(value.Index(i)), id)
+		}
+	case reflect.Struct:
+		s.stampRecord(db, field, value, id)
+	}
+}
+
+func (s *TenantScope) stampRecord(db *gorm.DB, field *schema.Field, record reflect.Value, id string) {
+	ctx := db.Statement.Context
+	current, zero := field.ValueOf(ctx, record)
+	if zero {
+		if err := field.Set(ctx, record, id); err != nil {
+			db.AddError(err)
+		}
+		return
+	}
+	if current != id {
+		db.AddError(ErrCrossTenantWrite)
+	}
+}
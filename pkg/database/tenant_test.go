@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// account is a tenant-scoped model
+type account struct {
+	ID       uint
+	TenantID string
+	Name     string
+}
+
+func newTenantTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&account{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Use(NewTenantScope()); err != nil {
+		t.Fatalf("failed to install tenant scope: %v", err)
+	}
+	return db
+}
+
+func TestTenantScope_StampsAndIsolatesRecords(t *testing.T) {
+	db := newTenantTestDB(t)
+	tenantA := tenant.WithTenant(context.Background(), "tenant-a")
+	tenantB := tenant.WithTenant(context.Background(), "tenant-b")
+
+	owned := account{Name: "owned by b"}
+	if err := db.WithContext(tenantB).Create(&owned).Error; err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if owned.TenantID != "tenant-b" {
+		t.Fatalf("expected the record to be stamped with tenant-b, got %q", owned.TenantID)
+	}
+
+	// Direct lookup by ID from another tenant finds nothing
+	var found account
+	err := db.WithContext(tenantA).First(&found, owned.ID).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected tenant-a lookup to find nothing, got %v", err)
+	}
+
+	var listed []account
+	if err := db.WithContext(tenantA).Find(&listed).Error; err != nil || len(listed) != 0 {
+		t.Fatalf("expected tenant-a to list no records, got %d (%v)", len(listed), err)
+	}
+
+	// The owning tenant still sees it
+	if err := db.WithContext(tenantB).First(&found, owned.ID).Error; err != nil {
+		t.Fatalf("expected tenant-b to find its record, got %v", err)
+	}
+}
+
+func TestTenantScope_CrossTenantWritesAffectNothing(t *testing.T) {
+	db := newTenantTestDB(t)
+	tenantA := tenant.WithTenant(context.Background(), "tenant-a")
+	tenantB := tenant.WithTenant(context.Background(), "tenant-b")
+
+	owned := account{Name: "original"}
+	if err := db.WithContext(tenantB).Create(&owned).Error; err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Saving a forged record with tenant-b's ID must not overwrite or move it
+	forged := account{ID: owned.ID, Name: "hijacked"}
+	if err := db.WithContext(tenantA).Save(&forged).Error; err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := db.WithContext(tenantA).Delete(&account{}, owned.ID).Error; err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	var current account
+	if err := db.WithContext(tenantB).First(&current, owned.ID).Error; err != nil {
+		t.Fatalf("expected tenant-b's record to survive, got %v", err)
+	}
+	if current.Name != "original" || current.TenantID != "tenant-b" {
+		t.Fatalf("tenant-b's record was modified: %+v", current)
+	}
+
+	// A record explicitly carrying another tenant is refused
+	err := db.WithContext(tenantA).Create(&account{TenantID: "tenant-b", Name: "planted"}).Error
+	if !errors.Is(err, ErrCrossTenantWrite) {
+		t.Fatalf("expected ErrCrossTenantWrite, got %v", err)
+	}
+}
+
+func TestTenantScope_RequiresTenant(t *testing.T) {
+	db := newTenantTestDB(t)
+
+	var accounts []account
+	err := db.WithContext(context.Background()).Find(&accounts).Error
+	if !errors.Is(err, tenant.ErrMissingTenant) {
+		t.Fatalf("expected ErrMissingTenant, got %v", err)
+	}
+
+	// System tasks may opt out explicitly
+	if err := db.WithContext(tenant.WithoutScope(context.Background())).Find(&accounts).Error; err != nil {
+		t.Fatalf("expected an unscoped query to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QueryType names a class of queries sharing a statement timeout
+type QueryType string
+
+const (
+	// QueryTypeDefault covers request-path reads and writes; it also applies to
+	// any query type without a timeout of its own
+	QueryTypeDefault QueryType = "default"
+	// QueryTypeReport covers aggregate and summary queries
+	QueryTypeReport QueryType = "report"
+	// QueryTypeAnalytics covers long-running scans such as AML analysis
+	QueryTypeAnalytics QueryType = "analytics"
+)
+
+// sqlStateQueryCanceled is the Postgres error code for a statement cancelled
+// by statement_timeout
+const sqlStateQueryCanceled = "57014"
+
+// StatementTimeouts maps query types to the longest Postgres may run one of
+// their statements before cancelling it. A zero or missing entry falls back to
+// QueryTypeDefault; a zero default leaves the connection's own setting.
+type StatementTimeouts map[QueryType]time.Duration
+
+// DefaultStatementTimeouts returns conservative timeouts: short for the request
+// path, longer for reports and analytics
+func DefaultStatementTimeouts() StatementTimeouts {
+	return StatementTimeouts{
+		QueryTypeDefault:   5 * time.Second,
+		QueryTypeReport:    30 * time.Second,
+		QueryTypeAnalytics: 2 * time.Minute,
+	}
+}
+
+// For returns the statement timeout for queryType
+func (t StatementTimeouts) For(queryType QueryType) time.Duration {
+	if timeout := t[queryType]; timeout > 0 {
+		return timeout
+	}
+	return t[QueryTypeDefault]
+}
+
+// WithStatementTimeout runs fn in a transaction whose statements Postgres
+// cancels once they run longer than the timeout for queryType. The timeout is
+// set with SET LOCAL, so it ends with the transaction and never leaks to other
+// users of the pooled connection.
+func WithStatementTimeout(ctx context.Context, db *gorm.DB, timeouts StatementTimeouts, queryType QueryType, fn func(tx *gorm.DB) error) error {
+	timeout := timeouts.For(queryType)
+	if timeout <= 0 {
+		return fn(db.WithContext(ctx))
+	}
+
+	return WithTransaction(ctx, db, func(tx *gorm.DB) error {
+		if err := tx.Exec(setStatementTimeoutSQL(timeout)).Error; err != nil {
+			return fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+		return fn(tx)
+	})
+}
+
+// setStatementTimeoutSQL renders the SET LOCAL for timeout. SET does not accept
+// bind parameters, so the value is formatted as whole milliseconds.
+func setStatementTimeoutSQL(timeout time.Duration) string {
+	ms := timeout.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)
+}
+
+// IsStatementTimeout reports whether err is Postgres cancelling a statement
+// that exceeded its statement timeout
+func IsStatementTimeout(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == sqlStateQueryCanceled
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/testfixtures"
+	"gorm.io/gorm"
+)
+
+func TestStatementTimeouts_For(t *testing.T) {
+	timeouts := StatementTimeouts{
+		QueryTypeDefault: 2 * time.Second,
+		QueryTypeReport:  30 * time.Second,
+	}
+
+	if got := timeouts.For(QueryTypeReport); got != 30*time.Second {
+		t.Fatalf("report timeout = %v, want 30s", got)
+	}
+	if got := timeouts.For(QueryTypeAnalytics); got != 2*time.Second {
+		t.Fatalf("expected an unconfigured type to use the default, got %v", got)
+	}
+	if got := (StatementTimeouts{}).For(QueryTypeReport); got != 0 {
+		t.Fatalf("expected no timeout without configuration, got %v", got)
+	}
+}
+
+func TestSetStatementTimeoutSQL(t *testing.T) {
+	tests := map[time.Duration]string{
+		1500 * time.Millisecond: "SET LOCAL statement_timeout = 1500",
+		time.Minute:             "SET LOCAL statement_timeout = 60000",
+		time.Microsecond:        "SET LOCAL statement_timeout = 1",
+	}
+	for timeout, want := range tests {
+		if got := setStatementTimeoutSQL(timeout); got != want {
+			t.Errorf("setStatementTimeoutSQL(%v) = %q, want %q", timeout, got, want)
+		}
+	}
+}
+
+// sqlStateError mimics a driver error carrying a Postgres SQLSTATE
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsStatementTimeout(t *testing.T) {
+	if !IsStatementTimeout(fmt.Errorf("summary: %w", sqlStateError(sqlStateQueryCanceled))) {
+		t.Fatal("expected a wrapped query_canceled error to be a statement timeout")
+	}
+	if IsStatementTimeout(sqlStateError("23505")) {
+		t.Fatal("expected a unique violation not to be a statement timeout")
+	}
+	if IsStatementTimeout(errors.New("connection refused")) {
+		t.Fatal("expected a plain error not to be a statement timeout")
+	}
+}
+
+func TestWithStatementTimeout_DatabaseCancelsSlowQuery(t *testing.T) {
+	db := testfixtures.Postgres(t)
+	timeouts := StatementTimeouts{
+		QueryTypeDefault: 100 * time.Millisecond,
+		QueryTypeReport:  5 * time.Second,
+	}
+
+	// The default budget is exceeded, so Postgres cancels the statement
+	start := time.Now()
+	err := WithStatementTimeout(context.Background(), db, timeouts, QueryTypeDefault, func(tx *gorm.DB) error {
+		return tx.Exec("SELECT pg_sleep(2)").Error
+	})
+	if !IsStatementTimeout(err) {
+		t.Fatalf("expected the statement to be cancelled by its timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected cancellation near the 100ms timeout, took %v", elapsed)
+	}
+
+	// The same statement fits within the report budget
+	err = WithStatementTimeout(context.Background(), db, timeouts, QueryTypeReport, func(tx *gorm.DB) error {
+		return tx.Exec("SELECT pg_sleep(0.2)").Error
+	})
+	if err != nil {
+		t.Fatalf("expected the report query to finish within its timeout, got %v", err)
+	}
+
+	// SET LOCAL must not outlive the transaction on the pooled connection
+	var setting string
+	if err := db.Raw("SHOW statement_timeout").Scan(&setting).Error; err != nil {
+		t.Fatalf("failed to read statement_timeout: %v", err)
+	}
+	if setting == "100ms" || setting == "5s" {
+		t.Fatalf("statement timeout leaked outside its transaction: %s", setting)
+	}
+}
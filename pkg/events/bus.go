@@ -0,0 +1,206 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned when publishing to a closed bus
+var ErrClosed = errors.New("events: bus is closed")
+
+// AllEvents subscribes to every event type
+const AllEvents = "*"
+
+// Handler consumes an event
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher publishes events. The Bus implements it; producers should depend
+// on Publisher so tests can substitute a fake.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// SubscriberError reports a subscriber that failed to handle an event, by
+// returning an error or by panicking
+type SubscriberError struct {
+	Subscriber string
+	EventType  string
+	Err        error
+}
+
+func (e *SubscriberError) Error() string {
+	return fmt.Sprintf("events: subscriber %s failed on %s: %v", e.Subscriber, e.EventType, e.Err)
+}
+
+func (e *SubscriberError) Unwrap() error {
+	return e.Err
+}
+
+// Config holds bus configuration
+type Config struct {
+	// OnError is called for every failed asynchronous delivery, whose errors
+	// have no caller to return to. Failures are logged when it is nil.
+	OnError func(err *SubscriberError)
+}
+
+// subscription is a handler registered for a pattern
+type subscription struct {
+	name    string
+	pattern string
+	handler Handler
+	async   bool
+}
+
+// Bus delivers published events to every subscriber whose pattern matches the
+// event type. Synchronous subscribers run in the publisher's goroutine, in
+// the order they subscribed, and their errors are returned from Publish.
+// Asynchronous subscribers each run in their own goroutine. A subscriber that
+// fails or panics never stops the others. It is safe for concurrent use.
+type Bus struct {
+	config        Config
+	mu            sync.RWMutex
+	subscriptions []subscription
+	closed        bool
+	pending       sync.WaitGroup
+}
+
+// NewBus creates an event bus
+func NewBus(config Config) *Bus {
+	return &Bus{config: config}
+}
+
+// Subscribe registers a synchronous handler for events matching pattern. A
+// pattern is an event type, a prefix ending in ".*" such as "verification.*",
+// or AllEvents. name identifies the subscriber in errors.
+func (b *Bus) Subscribe(name, pattern string, handler Handler) {
+	b.subscribe(subscription{name: name, pattern: pattern, handler: handler})
+}
+
+// SubscribeAsync registers a handler that runs in the background, so a slow
+// consumer such as a notifier does not delay the publisher. Its errors go to
+// Config.OnError.
+func (b *Bus) SubscribeAsync(name, pattern string, handler Handler) {
+	b.subscribe(subscription{name: name, pattern: pattern, handler: handler, async: true})
+}
+
+func (b *Bus) subscribe(sub subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, sub)
+}
+
+// Publish delivers event to every matching subscriber. It returns the errors
+// of the synchronous subscribers that failed, joined, once they have all run;
+// asynchronous subscribers are started but not waited for. Asynchronous
+// handlers get a context that is not cancelled with ctx.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	var inline []subscription
+	for _, sub := range b.subscriptions {
+		if !matches(sub.pattern, event.Type) {
+			continue
+		}
+		if sub.async {
+			b.pending.Add(1)
+			go func(sub subscription) {
+				defer b.pending.Done()
+				if err := deliver(context.WithoutCancel(ctx), sub, event); err != nil {
+					b.reportAsync(err)
+				}
+			}(sub)
+			continue
+		}
+		inline = append(inline, sub)
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range inline {
+		if err := deliver(ctx, sub, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the bus accepting events and waits for asynchronous deliveries
+// to finish, or for ctx to be done
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reportAsync hands a failed asynchronous delivery to OnError
+func (b *Bus) reportAsync(err *SubscriberError) {
+	if b.config.OnError != nil {
+		b.config.OnError(err)
+		return
+	}
+	log.Printf("%v", err)
+}
+
+// deliver runs sub's handler, turning a panic into an error
+func deliver(ctx context.Context, sub subscription, event Event) (subErr *SubscriberError) {
+	defer func() {
+		if r := recover(); r != nil {
+			subErr = &SubscriberError{
+				Subscriber: sub.name,
+				EventType:  event.Type,
+				Err:        fmt.Errorf("panic: %v\n%s", r, debug.Stack()),
+			}
+		}
+	}()
+
+	if err := sub.handler(ctx, event); err != nil {
+		return &SubscriberError{Subscriber: sub.name, EventType: event.Type, Err: err}
+	}
+	return nil
+}
+
+// matches reports whether eventType matches a subscription pattern
+func matches(pattern, eventType string) bool {
+	switch {
+	case pattern == AllEvents:
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == eventType
+	}
+}
+
+// On adapts a handler for events whose Data is a T, so subscribers receive
+// the payload typed. An event with any other payload is reported as an error
+// rather than passed on.
+func On[T any](handle func(ctx context.Context, event Event, data T) error) Handler {
+	return func(ctx context.Context, event Event) error {
+		data, ok := event.Data.(T)
+		if !ok {
+			var want T
+			return fmt.Errorf("events: %s carries %T, want %T", event.Type, event.Data, want)
+		}
+		return handle(ctx, event, data)
+	}
+}
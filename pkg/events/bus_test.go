@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// verificationDecided is a sample event payload
+type verificationDecided struct {
+	Status string
+}
+
+func TestPublish_FansOutToMatchingSubscribers(t *testing.T) {
+	bus := NewBus(Config{})
+	var mu sync.Mutex
+	var got []string
+	record := func(name string) Handler {
+		return func(ctx context.Context, event Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, name+":"+event.Type)
+			return nil
+		}
+	}
+
+	bus.Subscribe("outbox", "verification.approved", record("outbox"))
+	bus.Subscribe("audit", "verification.*", record("audit"))
+	bus.Subscribe("metrics", AllEvents, record("metrics"))
+	bus.Subscribe("payments", "transaction.*", record("payments"))
+	notified := make(chan Event, 1)
+	bus.SubscribeAsync("notifier", "verification.approved", func(ctx context.Context, event Event) error {
+		notified <- event
+		return nil
+	})
+
+	event := New("verification.approved", "kyc-service", "ver-1", verificationDecided{Status: "approved"})
+	if err := bus.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Synchronous subscribers have run, in order, by the time Publish returns
+	want := []string{"outbox:verification.approved", "audit:verification.approved", "metrics:verification.approved"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got deliveries %v, want %v", got, want)
+	}
+
+	select {
+	case delivered := <-notified:
+		if delivered.ID != event.ID {
+			t.Fatalf("async subscriber got event %s, want %s", delivered.ID, event.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("async subscriber never received the event")
+	}
+}
+
+func TestPublish_IsolatesFailingSubscribers(t *testing.T) {
+	asyncErrs := make(chan *SubscriberError, 2)
+	bus := NewBus(Config{OnError: func(err *SubscriberError) { asyncErrs <- err }})
+
+	var delivered int
+	count := func(ctx context.Context, event Event) error {
+		delivered++
+		return nil
+	}
+	bus.Subscribe("first", AllEvents, count)
+	bus.Subscribe("panics", AllEvents, func(ctx context.Context, event Event) error {
+		panic("nil map write")
+	})
+	bus.Subscribe("fails", AllEvents, func(ctx context.Context, event Event) error {
+		return errors.New("audit store unavailable")
+	})
+	bus.Subscribe("last", AllEvents, count)
+	bus.SubscribeAsync("async-panics", AllEvents, func(ctx context.Context, event Event) error {
+		panic("boom")
+	})
+
+	err := bus.Publish(context.Background(), New("transaction.flagged", "investment-service", "tx-1", nil))
+	if delivered != 2 {
+		t.Fatalf("expected the healthy subscribers to run, got %d deliveries", delivered)
+	}
+
+	var subErr *SubscriberError
+	if !errors.As(err, &subErr) || subErr.Subscriber != "panics" || !strings.Contains(err.Error(), "nil map write") {
+		t.Fatalf("expected the panic to be reported, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "audit store unavailable") {
+		t.Fatalf("expected the failing subscriber's error to be reported, got %v", err)
+	}
+
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case err := <-asyncErrs:
+		if err.Subscriber != "async-panics" {
+			t.Fatalf("unexpected async error %v", err)
+		}
+	default:
+		t.Fatal("expected the async panic to be reported to OnError")
+	}
+
+	if err := bus.Publish(context.Background(), New("transaction.flagged", "investment-service", "tx-2", nil)); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestOn_DeliversTypedPayload(t *testing.T) {
+	bus := NewBus(Config{})
+	var status string
+	bus.Subscribe("typed", "verification.*", On(func(ctx context.Context, event Event, data verificationDecided) error {
+		status = data.Status
+		return nil
+	}))
+
+	if err := bus.Publish(context.Background(), New("verification.rejected", "kyc-service", "ver-2", verificationDecided{Status: "rejected"})); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if status != "rejected" {
+		t.Fatalf("expected the typed payload, got %q", status)
+	}
+
+	err := bus.Publish(context.Background(), New("verification.rejected", "kyc-service", "ver-3", "not a payload"))
+	if err == nil || !strings.Contains(err.Error(), "carries string") {
+		t.Fatalf("expected a payload type error, got %v", err)
+	}
+}
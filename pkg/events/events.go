@@ -0,0 +1,46 @@
+// Package events defines the domain event shared by the services and an
+// in-process bus that fans each published event out to its subscribers. A
+// producer publishes "verification.approved" once; the outbox, metrics, audit
+// log and notifier each subscribe to it without the producer knowing about
+// any of them.
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is something that happened in the domain, e.g. a verification reached
+// an outcome or a transaction was flagged
+type Event struct {
+	// ID is unique per event, so consumers can deduplicate redeliveries
+	ID string `json:"id"`
+	// Type names what happened as "<aggregate>.<change>", e.g.
+	// "verification.approved"; subscribers are matched on it
+	Type string `json:"type"`
+	// Source is the service that published the event
+	Source string `json:"source"`
+	// AggregateID identifies the entity the event is about
+	AggregateID string `json:"aggregate_id,omitempty"`
+	// TenantID is the partner tenant the entity belongs to, if any
+	TenantID   string    `json:"tenant_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Data is the event-specific payload, usually a struct named after the
+	// event. Subscribe with On to receive it typed.
+	Data interface{} `json:"data,omitempty"`
+	// Metadata carries cross-cutting context such as a request or trace ID
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// New creates an event of eventType about aggregateID, published by source
+func New(eventType, source, aggregateID string, data interface{}) Event {
+	return Event{
+		ID:          uuid.NewString(),
+		Type:        eventType,
+		Source:      source,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        data,
+	}
+}
@@ -0,0 +1,101 @@
+// Package fields implements sparse fieldsets: the ?fields= query parameter lets
+// clients ask for a subset of a resource's JSON fields to reduce payload size.
+package fields
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Param is the query parameter clients select fields with, e.g. ?fields=id,status
+const Param = "fields"
+
+// ErrUnknownField is returned when a client selects a field outside the allowlist
+var ErrUnknownField = errors.New("fields: unknown field")
+
+// Allowlist is the set of JSON fields of a resource that clients may select.
+// Fields missing from the list, such as internal or sensitive ones, can never be
+// requested.
+type Allowlist []string
+
+// Parse validates a comma separated list of field names. An empty value selects
+// every field and returns nil.
+func (a Allowlist) Parse(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var selected []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !a.allows(name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownField, name)
+		}
+		seen[name] = true
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+func (a Allowlist) allows(name string) bool {
+	for _, allowed := range a {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns v restricted to the selected fields. v must encode to a JSON
+// object or an array of objects; for an array every element is restricted. When
+// selected is empty v is returned unchanged.
+func Select(v interface{}, selected []string) (interface{}, error) {
+	if len(selected) == 0 {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i] = project(items[i], selected)
+		}
+		return items, nil
+	}
+
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return project(item, selected), nil
+}
+
+// project keeps the selected keys of item. A selected field omitted from item,
+// e.g. by omitempty, stays omitted.
+func project(item map[string]json.RawMessage, selected []string) map[string]json.RawMessage {
+	if item == nil {
+		return nil
+	}
+	projected := make(map[string]json.RawMessage, len(selected))
+	for _, name := range selected {
+		if value, ok := item[name]; ok {
+			projected[name] = value
+		}
+	}
+	return projected
+}
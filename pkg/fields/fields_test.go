@@ -0,0 +1,73 @@
+package fields
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type item struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	Notes  string `json:"notes,omitempty"`
+	Secret string `json:"-"`
+}
+
+var itemFields = Allowlist{"id", "status", "notes"}
+
+func TestSelect_ReturnsOnlyRequestedFields(t *testing.T) {
+	selected, err := itemFields.Parse("id, status")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	projected, err := Select([]item{{ID: 1, Status: "active", Notes: "n"}, {ID: 2, Status: "sold"}}, selected)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+
+	data, _ := json.Marshal(projected)
+	if got, want := string(data), `[{"id":1,"status":"active"},{"id":2,"status":"sold"}]`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestSelect_SingleObject(t *testing.T) {
+	projected, err := Select(item{ID: 7, Status: "active"}, []string{"status"})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+
+	data, _ := json.Marshal(projected)
+	if got, want := string(data), `{"status":"active"}`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestSelect_NoSelectionReturnsValue(t *testing.T) {
+	value := item{ID: 1}
+	projected, err := Select(value, nil)
+	if err != nil || projected != value {
+		t.Fatalf("expected the value unchanged, got %v (%v)", projected, err)
+	}
+}
+
+func TestParse_RejectsUnknownField(t *testing.T) {
+	for _, raw := range []string{"id,secret", "Secret", "id,password_hash"} {
+		if _, err := itemFields.Parse(raw); !errors.Is(err, ErrUnknownField) {
+			t.Fatalf("%q: expected ErrUnknownField, got %v", raw, err)
+		}
+	}
+}
+
+func TestParse_EmptySelectsAll(t *testing.T) {
+	selected, err := itemFields.Parse("  ")
+	if err != nil || selected != nil {
+		t.Fatalf("expected no selection, got %v (%v)", selected, err)
+	}
+
+	selected, err = itemFields.Parse("id,,id")
+	if err != nil || len(selected) != 1 {
+		t.Fatalf("expected duplicates and blanks to be ignored, got %v (%v)", selected, err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package georisk classifies countries and IP addresses by AML risk, so AML
+// rules and security enrichment share one high-risk country list. Country
+// lookups are in-memory; IP lookups are resolved to countries in batches and
+// cached.
+package georisk
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/cache"
+)
+
+// Level is the risk classification of a country
+type Level string
+
+// Risk levels, from lowest to highest
+const (
+	// LevelUnknown is used when an IP cannot be placed in a country
+	LevelUnknown  Level = "unknown"
+	LevelStandard Level = "standard"
+	LevelElevated Level = "elevated"
+	LevelHigh     Level = "high"
+)
+
+// DefaultHighRisk is the high-risk country list used when none is configured
+var DefaultHighRisk = []string{"AF", "BY", "KP", "MM", "RU", "SY", "VE", "YE", "ZW"}
+
+// Classification is the risk of a country or of the country an IP is in
+type Classification struct {
+	// Country is the ISO 3166-1 alpha-2 code, empty if it could not be resolved
+	Country string `json:"country,omitempty"`
+	Level   Level  `json:"level"`
+}
+
+// Resolver maps IP addresses to ISO 3166-1 alpha-2 country codes. It is given
+// a whole batch at once so a remote or file-backed lookup can amortize its
+// cost; IPs it cannot place are left out of the result.
+type Resolver interface {
+	ResolveCountries(ctx context.Context, ips []string) (map[string]string, error)
+}
+
+// Config holds configuration for a Classifier
+type Config struct {
+	// HighRisk and ElevatedRisk list ISO 3166-1 alpha-2 country codes; any
+	// other country is LevelStandard
+	HighRisk     []string
+	ElevatedRisk []string
+	// CacheTTL is how long an IP's resolved country is cached
+	CacheTTL time.Duration
+}
+
+// DefaultConfig returns the default classifier configuration
+func DefaultConfig() Config {
+	return Config{
+		HighRisk: DefaultHighRisk,
+		CacheTTL: time.Hour,
+	}
+}
+
+// ConfigFromEnv reads comma-separated country codes from
+// GEORISK_HIGH_RISK_COUNTRIES and GEORISK_ELEVATED_RISK_COUNTRIES and the IP
+// cache TTL from GEORISK_CACHE_TTL (e.g. "30m"), falling back to DefaultConfig
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if value, ok := os.LookupEnv("GEORISK_HIGH_RISK_COUNTRIES"); ok {
+		cfg.HighRisk = splitCountries(value)
+	}
+	if value, ok := os.LookupEnv("GEORISK_ELEVATED_RISK_COUNTRIES"); ok {
+		cfg.ElevatedRisk = splitCountries(value)
+	}
+	if value := os.Getenv("GEORISK_CACHE_TTL"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			cfg.CacheTTL = d
+		}
+	}
+	return cfg
+}
+
+// Classifier classifies countries and IPs by risk. It is safe for concurrent use.
+type Classifier struct {
+	levels   map[string]Level
+	resolver Resolver
+	ips      *cache.Cache
+}
+
+// New creates a classifier. resolver may be nil if only country codes are
+// classified. Call Close to stop the IP cache's cleanup.
+func New(resolver Resolver, cfg Config) *Classifier {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultConfig().CacheTTL
+	}
+
+	levels := make(map[string]Level, len(cfg.HighRisk)+len(cfg.ElevatedRisk))
+	for _, code := range cfg.ElevatedRisk {
+		levels[normalize(code)] = LevelElevated
+	}
+	// A country on both lists is high risk
+	for _, code := range cfg.HighRisk {
+		levels[normalize(code)] = LevelHigh
+	}
+
+	return &Classifier{
+		levels:   levels,
+		resolver: resolver,
+		ips: cache.New(cache.Config{
+			DefaultExpiration: cfg.CacheTTL,
+			CleanupInterval:   cfg.CacheTTL,
+		}),
+	}
+}
+
+// Country classifies an ISO 3166-1 alpha-2 country code; case is ignored. An
+// empty code is LevelUnknown.
+func (c *Classifier) Country(code string) Classification {
+	code = normalize(code)
+	if code == "" {
+		return Classification{Level: LevelUnknown}
+	}
+	if level, ok := c.levels[code]; ok {
+		return Classification{Country: code, Level: level}
+	}
+	return Classification{Country: code, Level: LevelStandard}
+}
+
+// Countries classifies each of codes, keyed by the code as given
+func (c *Classifier) Countries(codes []string) map[string]Classification {
+	result := make(map[string]Classification, len(codes))
+	for _, code := range codes {
+		result[code] = c.Country(code)
+	}
+	return result
+}
+
+// IPs classifies each of ips by the country it resolves to, keyed by the IP as
+// given. Cached IPs are answered from memory and the rest are resolved in a
+// single Resolver call. Unparseable or unresolvable IPs are LevelUnknown. If
+// the resolver fails, the IPs it was asked about are LevelUnknown and the
+// error is returned alongside the classifications.
+func (c *Classifier) IPs(ctx context.Context, ips []string) (map[string]Classification, error) {
+	result := make(map[string]Classification, len(ips))
+	var misses []string
+	missing := make(map[string]bool)
+
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			result[ip] = Classification{Level: LevelUnknown}
+			continue
+		}
+		if country, ok := c.ips.Get(ip); ok {
+			result[ip] = c.Country(country.(string))
+			continue
+		}
+		if !missing[ip] {
+			missing[ip] = true
+			misses = append(misses, ip)
+		}
+	}
+
+	if len(misses) == 0 {
+		return result, nil
+	}
+	if c.resolver == nil {
+		for _, ip := range misses {
+			result[ip] = Classification{Level: LevelUnknown}
+		}
+		return result, nil
+	}
+
+	countries, err := c.resolver.ResolveCountries(ctx, misses)
+	for _, ip := range misses {
+		country := countries[ip]
+		// Unresolvable IPs are cached too, so they are not looked up on every
+		// record; a failed lookup is not
+		if err == nil {
+			c.ips.Set(ip, normalize(country))
+		}
+		result[ip] = c.Country(country)
+	}
+	return result, err
+}
+
+// Close stops the IP cache's background cleanup
+func (c *Classifier) Close() {
+	c.ips.Stop()
+}
+
+// normalize upper-cases and trims a country code
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// splitCountries splits a comma-separated list of country codes
+func splitCountries(value string) []string {
+	var codes []string
+	for _, code := range strings.Split(value, ",") {
+		if code = normalize(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
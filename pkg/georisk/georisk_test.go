@@ -0,0 +1,152 @@
+package georisk
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// countingResolver records every batch it is asked to resolve
+type countingResolver struct {
+	Resolver
+	batches [][]string
+	err     error
+}
+
+func (r *countingResolver) ResolveCountries(ctx context.Context, ips []string) (map[string]string, error) {
+	r.batches = append(r.batches, append([]string(nil), ips...))
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.Resolver.ResolveCountries(ctx, ips)
+}
+
+func newTestClassifier(t *testing.T) (*Classifier, *countingResolver) {
+	t.Helper()
+
+	prefixes, err := NewPrefixResolver(map[string]string{
+		"203.0.113.0/24":  "ru",
+		"198.51.100.0/24": "TR",
+		"192.0.2.0/24":    "GB",
+		"192.0.2.128/25":  "KP",
+	})
+	if err != nil {
+		t.Fatalf("NewPrefixResolver: %v", err)
+	}
+	resolver := &countingResolver{Resolver: prefixes}
+
+	classifier := New(resolver, Config{
+		HighRisk:     []string{"RU", "KP"},
+		ElevatedRisk: []string{"TR"},
+	})
+	t.Cleanup(classifier.Close)
+	return classifier, resolver
+}
+
+func TestClassifier_IPsClassifiesABatch(t *testing.T) {
+	classifier, resolver := newTestClassifier(t)
+
+	got, err := classifier.IPs(context.Background(), []string{
+		"203.0.113.7", "198.51.100.1", "192.0.2.1", "192.0.2.200", "10.0.0.1", "not-an-ip",
+	})
+	if err != nil {
+		t.Fatalf("IPs: %v", err)
+	}
+
+	want := map[string]Classification{
+		"203.0.113.7":  {Country: "RU", Level: LevelHigh},
+		"198.51.100.1": {Country: "TR", Level: LevelElevated},
+		"192.0.2.1":    {Country: "GB", Level: LevelStandard},
+		"192.0.2.200":  {Country: "KP", Level: LevelHigh}, // the more specific prefix wins
+		"10.0.0.1":     {Level: LevelUnknown},
+		"not-an-ip":    {Level: LevelUnknown},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d classifications, got %v", len(want), got)
+	}
+	for ip, classification := range want {
+		if got[ip] != classification {
+			t.Errorf("%s: expected %+v, got %+v", ip, classification, got[ip])
+		}
+	}
+
+	if len(resolver.batches) != 1 || len(resolver.batches[0]) != 5 {
+		t.Fatalf("expected the valid IPs to be resolved in one batch, got %v", resolver.batches)
+	}
+}
+
+func TestClassifier_IPsCachesRepeatedQueries(t *testing.T) {
+	classifier, resolver := newTestClassifier(t)
+	ctx := context.Background()
+
+	if _, err := classifier.IPs(ctx, []string{"203.0.113.7", "10.0.0.1", "203.0.113.7"}); err != nil {
+		t.Fatalf("IPs: %v", err)
+	}
+	got, err := classifier.IPs(ctx, []string{"203.0.113.7", "10.0.0.1", "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("IPs: %v", err)
+	}
+	if got["203.0.113.7"].Level != LevelHigh || got["10.0.0.1"].Level != LevelUnknown {
+		t.Fatalf("expected cached classifications to be unchanged, got %v", got)
+	}
+
+	// Duplicates are resolved once, and only the new IP is resolved the second time
+	if len(resolver.batches) != 2 {
+		t.Fatalf("expected 2 resolver calls, got %v", resolver.batches)
+	}
+	first := append([]string(nil), resolver.batches[0]...)
+	sort.Strings(first)
+	if len(first) != 2 || first[0] != "10.0.0.1" || first[1] != "203.0.113.7" {
+		t.Fatalf("expected the first batch to hold each IP once, got %v", resolver.batches[0])
+	}
+	if len(resolver.batches[1]) != 1 || resolver.batches[1][0] != "198.51.100.1" {
+		t.Fatalf("expected only the uncached IP to be resolved, got %v", resolver.batches[1])
+	}
+}
+
+func TestClassifier_IPsDoesNotCacheFailedLookups(t *testing.T) {
+	classifier, resolver := newTestClassifier(t)
+	ctx := context.Background()
+
+	resolver.err = errors.New("geoip unavailable")
+	got, err := classifier.IPs(ctx, []string{"203.0.113.7"})
+	if err == nil {
+		t.Fatal("expected the resolver error to be returned")
+	}
+	if got["203.0.113.7"].Level != LevelUnknown {
+		t.Fatalf("expected an unknown classification, got %+v", got["203.0.113.7"])
+	}
+
+	resolver.err = nil
+	got, err = classifier.IPs(ctx, []string{"203.0.113.7"})
+	if err != nil || got["203.0.113.7"].Level != LevelHigh {
+		t.Fatalf("expected the IP to be resolved once the resolver recovers, got %+v, %v", got["203.0.113.7"], err)
+	}
+}
+
+func TestClassifier_Countries(t *testing.T) {
+	classifier := New(nil, Config{HighRisk: []string{"ru"}, ElevatedRisk: []string{"TR", "RU"}})
+	defer classifier.Close()
+
+	got := classifier.Countries([]string{"RU", " tr ", "GB", ""})
+	want := map[string]Level{"RU": LevelHigh, " tr ": LevelElevated, "GB": LevelStandard, "": LevelUnknown}
+	for code, level := range want {
+		if got[code].Level != level {
+			t.Errorf("%q: expected %s, got %s", code, level, got[code].Level)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("GEORISK_HIGH_RISK_COUNTRIES", "ir, kp,,")
+	t.Setenv("GEORISK_CACHE_TTL", "invalid")
+
+	cfg := ConfigFromEnv()
+	if len(cfg.HighRisk) != 2 || cfg.HighRisk[0] != "IR" || cfg.HighRisk[1] != "KP" {
+		t.Fatalf("expected [IR KP], got %v", cfg.HighRisk)
+	}
+	if cfg.CacheTTL != DefaultConfig().CacheTTL {
+		t.Fatalf("expected the default cache TTL, got %v", cfg.CacheTTL)
+	}
+}
@@ -0,0 +1,57 @@
+package georisk
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// PrefixResolver is a Resolver backed by a fixed table of network prefixes,
+// e.g. a GeoIP country export or known office and partner ranges. The most
+// specific matching prefix wins.
+type PrefixResolver struct {
+	networks []prefixCountry
+}
+
+// prefixCountry is a network prefix and the country it is in
+type prefixCountry struct {
+	network *net.IPNet
+	country string
+}
+
+// NewPrefixResolver creates a resolver from CIDR prefixes mapped to ISO
+// 3166-1 alpha-2 country codes
+func NewPrefixResolver(prefixes map[string]string) (*PrefixResolver, error) {
+	r := &PrefixResolver{networks: make([]prefixCountry, 0, len(prefixes))}
+	for cidr, country := range prefixes {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("georisk: invalid prefix %q: %w", cidr, err)
+		}
+		r.networks = append(r.networks, prefixCountry{network: network, country: normalize(country)})
+	}
+	return r, nil
+}
+
+// ResolveCountries implements Resolver
+func (r *PrefixResolver) ResolveCountries(ctx context.Context, ips []string) (map[string]string, error) {
+	countries := make(map[string]string, len(ips))
+	for _, raw := range ips {
+		ip := net.ParseIP(raw)
+		if ip == nil {
+			continue
+		}
+
+		best := -1
+		for _, candidate := range r.networks {
+			if !candidate.network.Contains(ip) {
+				continue
+			}
+			if ones, _ := candidate.network.Mask.Size(); ones > best {
+				best = ones
+				countries[raw] = candidate.country
+			}
+		}
+	}
+	return countries, nil
+}
@@ -0,0 +1,404 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+)
+
+var (
+	// ErrKeyNotFound is returned when no key matches the requested key ID
+	ErrKeyNotFound = errors.New("jwks: key not found")
+	// ErrKeysExpired is returned when the cached keys are older than MaxKeyAge
+	// and could not be refreshed
+	ErrKeysExpired = errors.New("jwks: cached keys expired")
+	// ErrCircuitOpen is returned by Refresh while the circuit breaker is
+	// skipping fetches from an unavailable endpoint
+	ErrCircuitOpen = errors.New("jwks: key endpoint circuit breaker is open")
+)
+
+// Degraded mode metrics, labelled by client name
+var (
+	degradedGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "jwks_degraded",
+			Help: "Whether a JWKS client is serving cached or fallback keys because its endpoint is unavailable",
+		},
+		[]string{"jwks"},
+	)
+
+	fallbackKeysTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jwks_fallback_keys_total",
+			Help: "Total number of keys served from the cache or static fallback keys while the endpoint was unavailable",
+		},
+		[]string{"jwks", "source"},
+	)
+)
+
+// Config holds JWKS client configuration
+type Config struct {
+	// URL is the JWKS endpoint, e.g. https://auth-service/.well-known/jwks.json
+	URL string
+	// RefreshInterval is how often keys are refreshed in the background
+	RefreshInterval time.Duration
+	// MinRefreshInterval rate-limits refreshes triggered by unknown key IDs
+	MinRefreshInterval time.Duration
+	// HTTPTimeout bounds a single fetch of the key set
+	HTTPTimeout time.Duration
+	// MaxKeyAge is how long fetched keys stay usable without a successful
+	// refresh, so keys the endpoint may have revoked are not trusted forever.
+	// Zero keeps them indefinitely.
+	MaxKeyAge time.Duration
+	// FallbackKeys are static public keys by key ID, served only while the
+	// endpoint is unavailable and no usable cached key matches
+	FallbackKeys map[string]interface{}
+	// BreakerFailures is the number of consecutive failed fetches that open
+	// the circuit breaker. While it is open fetches are skipped and cached or
+	// fallback keys are served.
+	BreakerFailures uint32
+	// BreakerOpenTimeout is how long the breaker stays open before a trial fetch
+	BreakerOpenTimeout time.Duration
+	// Name labels the client's metrics; it defaults to URL
+	Name string
+	// Clock ages cached keys; nil means the system clock
+	Clock clock.Clock
+}
+
+// DefaultConfig returns default JWKS client configuration
+func DefaultConfig(url string) Config {
+	return Config{
+		URL:                url,
+		RefreshInterval:    15 * time.Minute,
+		MinRefreshInterval: time.Minute,
+		HTTPTimeout:        10 * time.Second,
+		MaxKeyAge:          24 * time.Hour,
+		BreakerFailures:    3,
+		BreakerOpenTimeout: 30 * time.Second,
+	}
+}
+
+// Client caches the keys published at a JWKS endpoint. Keys are refreshed in
+// the background and on a key ID miss. If the endpoint is unavailable the last
+// known keys keep being served until they are MaxKeyAge old, then any
+// FallbackKeys; a circuit breaker stops every miss waiting on a dead endpoint.
+type Client struct {
+	config      Config
+	httpClient  *http.Client
+	clock       clock.Clock
+	breaker     *gobreaker.CircuitBreaker
+	keys        map[string]interface{}
+	fetchedAt   time.Time
+	mu          sync.RWMutex
+	refreshMu   sync.Mutex
+	lastAttempt time.Time
+	stopRefresh chan struct{}
+	stopOnce    sync.Once
+}
+
+// jsonWebKey is a single entry of a JWKS document
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// New creates a JWKS client, performs an initial fetch and starts the background refresh.
+// A failed initial fetch is returned alongside a usable client, which retries on the next
+// refresh or key ID miss.
+func New(cfg Config) (*Client, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.URL
+	}
+	if cfg.BreakerFailures == 0 {
+		cfg.BreakerFailures = DefaultConfig(cfg.URL).BreakerFailures
+	}
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
+	degraded := degradedGauge.WithLabelValues(cfg.Name)
+	degraded.Set(0)
+	client := &Client{
+		config:      cfg,
+		httpClient:  &http.Client{Timeout: cfg.HTTPTimeout},
+		clock:       clk,
+		keys:        make(map[string]interface{}),
+		stopRefresh: make(chan struct{}),
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    cfg.Name,
+			Timeout: cfg.BreakerOpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= cfg.BreakerFailures
+			},
+			OnStateChange: func(_ string, _, to gobreaker.State) {
+				if to == gobreaker.StateClosed {
+					degraded.Set(0)
+				} else {
+					degraded.Set(1)
+				}
+			},
+		}),
+	}
+
+	err := client.Refresh(context.Background())
+
+	// Start refresh goroutine if refresh interval is greater than 0
+	if cfg.RefreshInterval > 0 {
+		go client.startRefresh()
+	}
+
+	return client, err
+}
+
+// Key returns the public key for the given key ID. An unknown key ID, or keys
+// past MaxKeyAge, trigger a refresh to pick up rotated keys, at most once per
+// MinRefreshInterval. While the endpoint is unavailable a matching static
+// fallback key is returned if no cached key matches; once the cached keys
+// expire without one ErrKeysExpired is returned.
+func (c *Client) Key(ctx context.Context, kid string) (interface{}, error) {
+	if key, ok := c.lookup(kid); ok {
+		return c.served(key, "cache"), nil
+	}
+
+	c.refreshMu.Lock()
+	// Another caller may have refreshed while we waited for the lock
+	if key, ok := c.lookup(kid); ok {
+		c.refreshMu.Unlock()
+		return c.served(key, "cache"), nil
+	}
+	if c.clock.Since(c.lastAttempt) >= c.config.MinRefreshInterval {
+		// Errors are ignored so the last known keys keep being served
+		_ = c.refreshLocked(ctx)
+	}
+	c.refreshMu.Unlock()
+
+	if key, ok := c.lookup(kid); ok {
+		return c.served(key, "cache"), nil
+	}
+
+	// Static keys stand in for the endpoint only while it cannot be reached;
+	// a fresh key set that lacks the key ID is authoritative
+	expired := c.expired()
+	if c.degraded() || expired {
+		if key, ok := c.config.FallbackKeys[kid]; ok {
+			fallbackKeysTotal.WithLabelValues(c.config.Name, "static").Inc()
+			return key, nil
+		}
+	}
+	if expired {
+		return nil, fmt.Errorf("%w: %s", ErrKeysExpired, kid)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+}
+
+// Degraded reports whether the circuit breaker has given up on the endpoint
+// for now, so keys are being served from the cache or fallback keys
+func (c *Client) Degraded() bool {
+	return c.degraded()
+}
+
+// Refresh fetches the key set. On failure the previously cached keys are kept.
+// While the circuit breaker is open it returns ErrCircuitOpen without a fetch.
+func (c *Client) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refreshLocked(ctx)
+}
+
+// Stop stops the background refresh
+func (c *Client) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopRefresh)
+	})
+}
+
+// lookup returns a cached key that has not expired
+func (c *Client) lookup(kid string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.expiredLocked() {
+		return nil, false
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// expired reports whether there is no usable cached key set: none was ever
+// fetched, or the last was fetched more than MaxKeyAge ago
+func (c *Client) expired() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.expiredLocked()
+}
+
+func (c *Client) expiredLocked() bool {
+	if c.fetchedAt.IsZero() {
+		return true
+	}
+	return c.config.MaxKeyAge > 0 && c.clock.Since(c.fetchedAt) > c.config.MaxKeyAge
+}
+
+func (c *Client) degraded() bool {
+	return c.breaker.State() != gobreaker.StateClosed
+}
+
+// served counts a key served while degraded and returns it
+func (c *Client) served(key interface{}, source string) interface{} {
+	if c.degraded() {
+		fallbackKeysTotal.WithLabelValues(c.config.Name, source).Inc()
+	}
+	return key
+}
+
+// refreshLocked fetches and replaces the key set through the circuit breaker;
+// refreshMu must be held
+func (c *Client) refreshLocked(ctx context.Context) error {
+	c.lastAttempt = c.clock.Now()
+
+	result, err := c.breaker.Execute(func() (interface{}, error) {
+		return c.fetch(ctx)
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return ErrCircuitOpen
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = result.(map[string]interface{})
+	c.fetchedAt = c.clock.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// fetch downloads and parses the key set
+func (c *Client) fetch(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: failed to fetch keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status code %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks: failed to decode keys: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip keys we cannot use rather than rejecting the whole set
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: no usable keys in key set")
+	}
+
+	return keys, nil
+}
+
+// startRefresh periodically refreshes the key set
+func (c *Client) startRefresh() {
+	ticker := time.NewTicker(c.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.config.HTTPTimeout)
+			_ = c.Refresh(ctx)
+			cancel()
+		case <-c.stopRefresh:
+			return
+		}
+	}
+}
+
+// publicKey converts a JWK into an RSA or ECDSA public key
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwks: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("jwks: unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: invalid key encoding: %w", err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
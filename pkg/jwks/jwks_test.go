@@ -0,0 +1,309 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+)
+
+// keyServer serves a swappable key set and counts fetches
+type keyServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+	down bool
+	hits int32
+}
+
+func newKeyServer(t *testing.T) *keyServer {
+	ks := &keyServer{keys: make(map[string]*rsa.PublicKey)}
+	ks.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ks.hits, 1)
+
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+
+		if ks.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var set struct {
+			Keys []jsonWebKey `json:"keys"`
+		}
+		for kid, key := range ks.keys {
+			set.Keys = append(set.Keys, jsonWebKey{
+				Kid: kid,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(ks.Close)
+	return ks
+}
+
+func (ks *keyServer) setKey(t *testing.T, kid string) *rsa.PublicKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	ks.mu.Lock()
+	ks.keys = map[string]*rsa.PublicKey{kid: &privateKey.PublicKey}
+	ks.mu.Unlock()
+	return &privateKey.PublicKey
+}
+
+func (ks *keyServer) setDown(down bool) {
+	ks.mu.Lock()
+	ks.down = down
+	ks.mu.Unlock()
+}
+
+func (ks *keyServer) fetches() int32 {
+	return atomic.LoadInt32(&ks.hits)
+}
+
+func newTestClient(t *testing.T, url string, minRefresh time.Duration) *Client {
+	cfg := DefaultConfig(url)
+	cfg.RefreshInterval = 0
+	cfg.MinRefreshInterval = minRefresh
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("initial fetch failed: %v", err)
+	}
+	t.Cleanup(client.Stop)
+	return client
+}
+
+func TestKey_ServedFromCache(t *testing.T) {
+	ks := newKeyServer(t)
+	want := ks.setKey(t, "key-1")
+	client := newTestClient(t, ks.URL, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		key, err := client.Key(context.Background(), "key-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if key.(*rsa.PublicKey).N.Cmp(want.N) != 0 {
+			t.Fatalf("unexpected key returned")
+		}
+	}
+
+	if ks.fetches() != 1 {
+		t.Fatalf("expected 1 fetch, got %d", ks.fetches())
+	}
+}
+
+func TestKey_RefreshesOnRotation(t *testing.T) {
+	ks := newKeyServer(t)
+	ks.setKey(t, "key-1")
+	client := newTestClient(t, ks.URL, 0)
+
+	rotated := ks.setKey(t, "key-2")
+
+	key, err := client.Key(context.Background(), "key-2")
+	if err != nil {
+		t.Fatalf("expected rotated key to be fetched, got %v", err)
+	}
+	if key.(*rsa.PublicKey).N.Cmp(rotated.N) != 0 {
+		t.Fatalf("unexpected key returned")
+	}
+	if ks.fetches() != 2 {
+		t.Fatalf("expected 2 fetches, got %d", ks.fetches())
+	}
+}
+
+func TestKey_MissRefreshIsRateLimited(t *testing.T) {
+	ks := newKeyServer(t)
+	ks.setKey(t, "key-1")
+	client := newTestClient(t, ks.URL, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		if _, err := client.Key(context.Background(), "unknown"); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("expected ErrKeyNotFound, got %v", err)
+		}
+	}
+
+	// The initial fetch happened moments ago, so no miss may trigger another
+	if ks.fetches() != 1 {
+		t.Fatalf("expected kid misses to be rate limited, got %d fetches", ks.fetches())
+	}
+}
+
+func TestKey_ServesStaleKeysDuringOutage(t *testing.T) {
+	ks := newKeyServer(t)
+	ks.setKey(t, "key-1")
+	client := newTestClient(t, ks.URL, 0)
+
+	ks.setDown(true)
+
+	if err := client.Refresh(context.Background()); err == nil {
+		t.Fatalf("expected refresh to fail while the endpoint is down")
+	}
+	if _, err := client.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("expected last known key to be served, got %v", err)
+	}
+	if _, err := client.Key(context.Background(), "key-2"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// newOutageClient creates a client whose breaker opens after two failed
+// fetches and stays open for openTimeout
+func newOutageClient(t *testing.T, url string, clk clock.Clock, openTimeout time.Duration, fallback map[string]interface{}) *Client {
+	cfg := DefaultConfig(url)
+	cfg.RefreshInterval = 0
+	cfg.MinRefreshInterval = 0
+	cfg.MaxKeyAge = time.Hour
+	cfg.BreakerFailures = 2
+	cfg.BreakerOpenTimeout = openTimeout
+	cfg.FallbackKeys = fallback
+	cfg.Clock = clk
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("initial fetch failed: %v", err)
+	}
+	t.Cleanup(client.Stop)
+	return client
+}
+
+// openBreaker fails fetches until the client's breaker opens
+func openBreaker(t *testing.T, ks *keyServer, client *Client) {
+	t.Helper()
+	ks.setDown(true)
+	for i := 0; i < 2; i++ {
+		if err := client.Refresh(context.Background()); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected fetch %d to fail against the endpoint, got %v", i+1, err)
+		}
+	}
+	if !client.Degraded() {
+		t.Fatal("expected the breaker to open")
+	}
+}
+
+func TestKey_ServesCachedKeysWhileBreakerOpen(t *testing.T) {
+	ks := newKeyServer(t)
+	want := ks.setKey(t, "key-1")
+	client := newOutageClient(t, ks.URL, clock.NewFake(time.Now()), time.Hour, nil)
+
+	openBreaker(t, ks, client)
+	fetches := ks.fetches()
+
+	for i := 0; i < 3; i++ {
+		key, err := client.Key(context.Background(), "key-1")
+		if err != nil {
+			t.Fatalf("expected the cached key while the breaker is open, got %v", err)
+		}
+		if key.(*rsa.PublicKey).N.Cmp(want.N) != 0 {
+			t.Fatalf("unexpected key returned")
+		}
+	}
+	if _, err := client.Key(context.Background(), "key-2"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := client.Refresh(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if ks.fetches() != fetches {
+		t.Fatalf("expected no fetches while the breaker is open, got %d more", ks.fetches()-fetches)
+	}
+}
+
+func TestKey_ExpiredCachedKeysAreNotUsed(t *testing.T) {
+	ks := newKeyServer(t)
+	ks.setKey(t, "key-1")
+	clk := clock.NewFake(time.Now())
+	client := newOutageClient(t, ks.URL, clk, time.Hour, nil)
+
+	openBreaker(t, ks, client)
+
+	clk.Advance(59 * time.Minute)
+	if _, err := client.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("expected the cached key within MaxKeyAge, got %v", err)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, err := client.Key(context.Background(), "key-1"); !errors.Is(err, ErrKeysExpired) {
+		t.Fatalf("expected ErrKeysExpired once the cached keys are too old, got %v", err)
+	}
+}
+
+func TestKey_StaticFallbackOnlyWhileEndpointUnavailable(t *testing.T) {
+	ks := newKeyServer(t)
+	ks.setKey(t, "key-1")
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	static := &privateKey.PublicKey
+	clk := clock.NewFake(time.Now())
+	client := newOutageClient(t, ks.URL, clk, time.Hour, map[string]interface{}{"static-1": static})
+
+	// The live key set does not publish the static key, so it is not trusted
+	if _, err := client.Key(context.Background(), "static-1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound while the endpoint is up, got %v", err)
+	}
+
+	openBreaker(t, ks, client)
+
+	key, err := client.Key(context.Background(), "static-1")
+	if err != nil {
+		t.Fatalf("expected the static key while the endpoint is down, got %v", err)
+	}
+	if key != static {
+		t.Fatalf("unexpected key returned")
+	}
+
+	// Static keys outlive the cached ones
+	clk.Advance(2 * time.Hour)
+	if _, err := client.Key(context.Background(), "static-1"); err != nil {
+		t.Fatalf("expected the static key after the cache expired, got %v", err)
+	}
+	if _, err := client.Key(context.Background(), "key-1"); !errors.Is(err, ErrKeysExpired) {
+		t.Fatalf("expected ErrKeysExpired, got %v", err)
+	}
+}
+
+func TestKey_BreakerClosesWhenEndpointRecovers(t *testing.T) {
+	ks := newKeyServer(t)
+	ks.setKey(t, "key-1")
+	clk := clock.NewFake(time.Now())
+	client := newOutageClient(t, ks.URL, clk, 20*time.Millisecond, nil)
+
+	openBreaker(t, ks, client)
+	clk.Advance(2 * time.Hour)
+
+	ks.setDown(false)
+	rotated := ks.setKey(t, "key-2")
+	time.Sleep(30 * time.Millisecond)
+
+	key, err := client.Key(context.Background(), "key-2")
+	if err != nil {
+		t.Fatalf("expected a trial fetch to pick up the new keys, got %v", err)
+	}
+	if key.(*rsa.PublicKey).N.Cmp(rotated.N) != 0 {
+		t.Fatalf("unexpected key returned")
+	}
+	if client.Degraded() {
+		t.Fatal("expected the breaker to close after a successful fetch")
+	}
+}
@@ -0,0 +1,24 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keyfunc resolves the verification key for a token from its kid header
+func (c *Client) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("jwks: token has no kid header")
+	}
+	return c.Key(context.Background(), kid)
+}
+
+// VerifyingKeyfunc is Keyfunc restricted to the allowed signing algorithms, e.g.
+// []string{"RS256"}. Use it with jwtalg.ParserOptions(allowed).
+func (c *Client) VerifyingKeyfunc(allowed []string) jwt.Keyfunc {
+	return jwtalg.Keyfunc(allowed, c.Keyfunc)
+}
@@ -0,0 +1,44 @@
+package jwtalg
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Reasons a token fails validation, as returned by Classify. They are stable
+// and safe to expose: clients use them to tell a token they should refresh from
+// one they should discard.
+const (
+	// ReasonExpired is a correctly signed token past its exp
+	ReasonExpired = "expired"
+	// ReasonNotYetValid is a correctly signed token before its nbf or iat
+	ReasonNotYetValid = "not_yet_valid"
+	// ReasonSignatureInvalid is a token whose signature or algorithm does not verify
+	ReasonSignatureInvalid = "signature_invalid"
+	// ReasonMalformed is a value that cannot be decoded as a JWT
+	ReasonMalformed = "malformed"
+	// ReasonInvalid is any other validation failure, such as a bad claim
+	ReasonInvalid = "invalid"
+)
+
+// Classify returns the reason err, from parsing and validating a token, rejected
+// it. Signature failures take precedence, so a forged token is never reported as
+// merely expired.
+func Classify(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, jwt.ErrTokenUnverifiable),
+		errors.Is(err, ErrAlgorithmNotAllowed),
+		errors.Is(err, ErrKeyTypeMismatch):
+		return ReasonSignatureInvalid
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ReasonMalformed
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ReasonExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet),
+		errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ReasonNotYetValid
+	}
+	return ReasonInvalid
+}
@@ -0,0 +1,50 @@
+package jwtalg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestClassify(t *testing.T) {
+	secret := []byte("test-secret")
+	sign := func(claims jwt.MapClaims) string {
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return tokenString
+	}
+
+	valid := sign(claims())
+	parts := strings.Split(valid, ".")
+	// Swap in a different payload under the original signature
+	tampered := parts[0] + "." + strings.Split(sign(jwt.MapClaims{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()}), ".")[1] + "." + parts[2]
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	tests := map[string]struct {
+		token string
+		want  string
+	}{
+		"expired":       {sign(jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(-time.Minute).Unix()}), ReasonExpired},
+		"not yet valid": {sign(jwt.MapClaims{"sub": "user-1", "nbf": time.Now().Add(time.Hour).Unix()}), ReasonNotYetValid},
+		"tampered":      {tampered, ReasonSignatureInvalid},
+		"bad signature": {valid[:len(valid)-2] + "xx", ReasonSignatureInvalid},
+		"alg none":      {none, ReasonSignatureInvalid},
+		"malformed":     {"not-a-token", ReasonMalformed},
+	}
+	for name, tc := range tests {
+		_, err := parse(tc.token, []string{"HS256"}, secret)
+		if err == nil {
+			t.Fatalf("%s: expected the token to be rejected", name)
+		}
+		if got := Classify(err); got != tc.want {
+			t.Errorf("%s: Classify(%v) = %q, want %q", name, err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package jwtalg verifies the signing algorithm of JWTs against an allowlist,
+// preventing algorithm confusion attacks such as alg "none" or an HS256 token
+// verified with an RSA public key as the HMAC secret.
+package jwtalg
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// EnvVar names the environment variable holding a comma-separated allowlist, e.g. "RS256,ES256"
+const EnvVar = "JWT_ALLOWED_ALGORITHMS"
+
+var (
+	// DefaultAllowed is the allowlist used when none is configured
+	DefaultAllowed = []string{"HS256"}
+
+	// ErrAlgorithmNotAllowed is returned for tokens signed with an algorithm outside the allowlist
+	ErrAlgorithmNotAllowed = errors.New("jwt: signing algorithm not allowed")
+	// ErrKeyTypeMismatch is returned when the verification key does not match the token's algorithm
+	ErrKeyTypeMismatch = errors.New("jwt: key type does not match signing algorithm")
+)
+
+// FromEnv returns the allowlist from JWT_ALLOWED_ALGORITHMS, or DefaultAllowed if unset
+func FromEnv() []string {
+	return Parse(os.Getenv(EnvVar))
+}
+
+// Parse parses a comma-separated allowlist, returning DefaultAllowed if it is empty
+func Parse(value string) []string {
+	var allowed []string
+	for _, alg := range strings.Split(value, ",") {
+		if alg = strings.TrimSpace(alg); alg != "" {
+			allowed = append(allowed, alg)
+		}
+	}
+	if len(allowed) == 0 {
+		return DefaultAllowed
+	}
+	return allowed
+}
+
+// orDefault returns allowed, or DefaultAllowed if it is empty
+func orDefault(allowed []string) []string {
+	if len(allowed) == 0 {
+		return DefaultAllowed
+	}
+	return allowed
+}
+
+// Check verifies that token declares an allowed algorithm and that its signing
+// method is of the family the algorithm name claims. "none" is never allowed.
+// An empty allowlist means DefaultAllowed.
+func Check(token *jwt.Token, allowed []string) error {
+	allowed = orDefault(allowed)
+	alg, _ := token.Header["alg"].(string)
+	if alg == "" || strings.EqualFold(alg, "none") || !contains(allowed, alg) {
+		return fmt.Errorf("%w: %q", ErrAlgorithmNotAllowed, alg)
+	}
+	if token.Method == nil || token.Method.Alg() != alg {
+		return fmt.Errorf("%w: %q", ErrAlgorithmNotAllowed, alg)
+	}
+
+	var ok bool
+	switch {
+	case strings.HasPrefix(alg, "HS"):
+		_, ok = token.Method.(*jwt.SigningMethodHMAC)
+	case strings.HasPrefix(alg, "RS"):
+		_, ok = token.Method.(*jwt.SigningMethodRSA)
+	case strings.HasPrefix(alg, "PS"):
+		_, ok = token.Method.(*jwt.SigningMethodRSAPSS)
+	case strings.HasPrefix(alg, "ES"):
+		_, ok = token.Method.(*jwt.SigningMethodECDSA)
+	case alg == "EdDSA":
+		_, ok = token.Method.(*jwt.SigningMethodEd25519)
+	}
+	if !ok {
+		return fmt.Errorf("%w: unexpected signing method %q", ErrAlgorithmNotAllowed, alg)
+	}
+	return nil
+}
+
+// Keyfunc wraps key so that a verification key is only returned for tokens that
+// pass Check, and only if the key's type matches the token's algorithm
+func Keyfunc(allowed []string, key jwt.Keyfunc) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if err := Check(token, allowed); err != nil {
+			return nil, err
+		}
+
+		k, err := key(token)
+		if err != nil {
+			return nil, err
+		}
+		if !keyMatches(token.Method, k) {
+			return nil, fmt.Errorf("%w: %s", ErrKeyTypeMismatch, token.Method.Alg())
+		}
+		return k, nil
+	}
+}
+
+// ParserOptions returns parser options enforcing allowed, for use alongside Keyfunc
+func ParserOptions(allowed []string) []jwt.ParserOption {
+	return []jwt.ParserOption{jwt.WithValidMethods(orDefault(allowed))}
+}
+
+// keyMatches reports whether key can verify tokens signed with method
+func keyMatches(method jwt.SigningMethod, key interface{}) bool {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok := key.([]byte)
+		return ok
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		_, ok := key.(*ecdsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodEd25519:
+		_, ok := key.(ed25519.PublicKey)
+		return ok
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
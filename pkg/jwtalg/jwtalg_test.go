@@ -0,0 +1,120 @@
+package jwtalg
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return key
+}
+
+// parse verifies tokenString the way services do, with the allowlist enforced
+func parse(tokenString string, allowed []string, key interface{}) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, Keyfunc(allowed, func(token *jwt.Token) (interface{}, error) {
+		return key, nil
+	}), ParserOptions(allowed)...)
+}
+
+func TestAlgNoneIsRejected(t *testing.T) {
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	// Even a misconfigured allowlist must not accept alg none
+	for _, allowed := range [][]string{{"HS256"}, {"none", "HS256"}} {
+		if _, err := parse(tokenString, allowed, jwt.UnsafeAllowNoneSignatureType); err == nil {
+			t.Fatalf("expected alg none to be rejected with allowlist %v", allowed)
+		}
+	}
+}
+
+func TestHS256RejectedWhenRS256Expected(t *testing.T) {
+	key := generateRSAKey(t)
+	publicPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey),
+	})
+
+	// The attacker signs an HS256 token using the public key as the HMAC secret
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString(publicPEM)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	allowed := []string{"RS256"}
+	_, err = jwt.Parse(forged, Keyfunc(allowed, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}))
+	if !errors.Is(err, ErrAlgorithmNotAllowed) {
+		t.Fatalf("expected ErrAlgorithmNotAllowed, got %v", err)
+	}
+
+	// Even with HS256 allowed, the RSA public key is never used as an HMAC secret
+	_, err = jwt.Parse(forged, Keyfunc([]string{"RS256", "HS256"}, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}))
+	if !errors.Is(err, ErrKeyTypeMismatch) {
+		t.Fatalf("expected ErrKeyTypeMismatch, got %v", err)
+	}
+}
+
+func TestValidTokensAreAccepted(t *testing.T) {
+	key := generateRSAKey(t)
+	rs256, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims()).SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	token, err := parse(rs256, []string{"RS256"}, &key.PublicKey)
+	if err != nil || !token.Valid {
+		t.Fatalf("expected a valid RS256 token to be accepted, got %v", err)
+	}
+
+	secret := []byte("test-secret")
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString(secret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	token, err = parse(hs256, DefaultAllowed, secret)
+	if err != nil || !token.Valid {
+		t.Fatalf("expected a valid HS256 token to be accepted, got %v", err)
+	}
+}
+
+func TestAlgorithmOutsideAllowlistIsRejected(t *testing.T) {
+	secret := []byte("test-secret")
+	hs512, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims()).SignedString(secret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	if _, err := parse(hs512, []string{"HS256"}, secret); err == nil {
+		t.Fatal("expected HS512 to be rejected when only HS256 is allowed")
+	}
+}
+
+func TestParse(t *testing.T) {
+	if got := Parse(""); len(got) != 1 || got[0] != "HS256" {
+		t.Fatalf("expected the default allowlist, got %v", got)
+	}
+	if got := Parse(" RS256, ES256 ,"); len(got) != 2 || got[0] != "RS256" || got[1] != "ES256" {
+		t.Fatalf("unexpected allowlist %v", got)
+	}
+}
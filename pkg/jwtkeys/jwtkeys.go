@@ -0,0 +1,232 @@
+// Package jwtkeys holds the keys JWTs are signed and verified with, so the
+// signing key can be rotated without invalidating outstanding tokens. New tokens
+// are signed with one designated key and carry its ID in the kid header; tokens
+// signed with an older key keep validating until that key retires. Keys are HMAC
+// secrets (HS256) or RSA key pairs (RS256), whose public halves can be published
+// as a JWKS so other services validate tokens without a shared secret.
+package jwtkeys
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrKeyNotFound is returned for tokens whose kid is not in the key set
+	ErrKeyNotFound = errors.New("jwtkeys: key not found")
+	// ErrKeyRetired is returned for tokens signed with a key that has retired
+	ErrKeyRetired = errors.New("jwtkeys: key retired")
+)
+
+// Key is an HMAC secret or an RSA key pair identified by its key ID
+type Key struct {
+	// ID is written to the kid header of tokens signed with the key. An empty ID
+	// verifies tokens without a kid, such as those issued before rotation.
+	ID     string
+	Secret []byte
+	// PrivateKey makes the key sign with RS256 instead of Secret with HS256
+	PrivateKey *rsa.PrivateKey
+	// RetiresAt ends the key's overlap window: tokens signed with it are rejected
+	// from then on. Zero keeps the key until it is removed from the set.
+	RetiresAt time.Time
+}
+
+// Config holds the key set configuration
+type Config struct {
+	// SigningKeyID selects the key new tokens are signed with
+	SigningKeyID string
+	Keys         []Key
+	// Clock decides when keys retire; the system clock if nil
+	Clock clock.Clock
+}
+
+// KeySet signs tokens with its current key and verifies them with any key that
+// has not retired. It is safe for concurrent use.
+type KeySet struct {
+	clock   clock.Clock
+	mu      sync.RWMutex
+	keys    map[string]Key
+	signing string
+	// anyKID verifies every token with the signing key, whatever its kid
+	anyKID bool
+}
+
+// New creates a key set. The signing key must be in Keys and must not retire.
+func New(cfg Config) (*KeySet, error) {
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
+	keys := make(map[string]Key, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if err := key.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := keys[key.ID]; ok {
+			return nil, fmt.Errorf("jwtkeys: duplicate key %q", key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	signing, ok := keys[cfg.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("jwtkeys: signing key %q is not in the key set", cfg.SigningKeyID)
+	}
+	if !signing.RetiresAt.IsZero() {
+		return nil, fmt.Errorf("jwtkeys: signing key %q must not retire", cfg.SigningKeyID)
+	}
+
+	return &KeySet{clock: clk, keys: keys, signing: cfg.SigningKeyID}, nil
+}
+
+// FromSecret returns a key set holding a single secret without a key ID, which
+// signs and verifies tokens exactly as a plain shared secret does: the kid
+// header is ignored
+func FromSecret(secret string) *KeySet {
+	return &KeySet{
+		clock:  clock.Real(),
+		keys:   map[string]Key{"": {Secret: []byte(secret)}},
+		anyKID: true,
+	}
+}
+
+// SigningKey returns the ID and secret new tokens are signed with. The secret is
+// nil if the signing key is an RSA key; use Sign instead.
+func (s *KeySet) SigningKey() (string, []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signing, s.keys[s.signing].Secret
+}
+
+// Algorithm returns the signing algorithm of new tokens, "HS256" or "RS256"
+func (s *KeySet) Algorithm() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[s.signing].method().Alg()
+}
+
+// VerificationKey returns the secret for tokens with the given kid, or an error
+// if the key is unknown or has retired. RSA keys return their public key.
+func (s *KeySet) VerificationKey(kid string) (interface{}, error) {
+	key, err := s.verificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	return key.verifier(), nil
+}
+
+// verificationKey returns the key for tokens with the given kid, unless it is
+// unknown or has retired
+func (s *KeySet) verificationKey(kid string) (Key, error) {
+	s.mu.RLock()
+	if s.anyKID {
+		kid = s.signing
+	}
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+	if !key.RetiresAt.IsZero() && !s.clock.Now().Before(key.RetiresAt) {
+		return Key{}, fmt.Errorf("%w: %q", ErrKeyRetired, kid)
+	}
+	return key, nil
+}
+
+// Rotate makes key the signing key. The previous signing key keeps verifying
+// tokens for overlap, which should be at least the lifetime of issued tokens.
+func (s *KeySet) Rotate(key Key, overlap time.Duration) error {
+	if err := key.validate(); err != nil {
+		return err
+	}
+	key.RetiresAt = time.Time{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key.ID == s.signing {
+		return fmt.Errorf("jwtkeys: key %q is already the signing key", key.ID)
+	}
+	previous := s.keys[s.signing]
+	previous.RetiresAt = s.clock.Now().Add(overlap)
+	s.keys[s.signing] = previous
+
+	s.keys[key.ID] = key
+	s.signing = key.ID
+	s.anyKID = false
+	return nil
+}
+
+// Sign signs claims with the signing key, using HS256 or RS256 depending on the
+// key, and sets the kid header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	kid, key := s.signing, s.keys[s.signing]
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(key.signer())
+}
+
+// Keyfunc returns the verification key for a token from its kid header. The
+// token must be signed with the algorithm of that key, so an HS256 token cannot
+// be verified with an RSA public key as its secret. Wrap it with jwtalg.Keyfunc
+// to restrict the signing algorithm further.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := s.verificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if alg := key.method().Alg(); token.Method == nil || token.Method.Alg() != alg {
+		return nil, fmt.Errorf("%w: key %q verifies %s tokens only", jwtalg.ErrKeyTypeMismatch, kid, alg)
+	}
+	return key.verifier(), nil
+}
+
+// validate checks that the key has exactly one of a secret and a private key
+func (k Key) validate() error {
+	switch {
+	case k.PrivateKey != nil && len(k.Secret) > 0:
+		return fmt.Errorf("jwtkeys: key %q has both a secret and a private key", k.ID)
+	case k.PrivateKey == nil && len(k.Secret) == 0:
+		return fmt.Errorf("jwtkeys: key %q has no secret", k.ID)
+	}
+	return nil
+}
+
+// method returns the signing method of the key
+func (k Key) method() jwt.SigningMethod {
+	if k.PrivateKey != nil {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodHS256
+}
+
+// signer returns the key material tokens are signed with
+func (k Key) signer() interface{} {
+	if k.PrivateKey != nil {
+		return k.PrivateKey
+	}
+	return k.Secret
+}
+
+// verifier returns the key material tokens are verified with
+func (k Key) verifier() interface{} {
+	if k.PrivateKey != nil {
+		return &k.PrivateKey.PublicKey
+	}
+	return k.Secret
+}
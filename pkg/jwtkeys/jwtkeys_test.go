@@ -0,0 +1,143 @@
+package jwtkeys
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// parse verifies a token against the key set at the fake clock's time
+func parse(s *KeySet, clk *clock.Fake, token string) error {
+	_, err := jwt.Parse(token, s.Keyfunc, jwt.WithValidMethods([]string{"HS256"}), jwt.WithTimeFunc(clk.Now))
+	return err
+}
+
+func sign(t *testing.T, s *KeySet, clk *clock.Fake) string {
+	t.Helper()
+	token, err := s.Sign(jwt.MapClaims{"sub": "user-1", "exp": clk.Now().Add(24 * time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestKeySet_PreviousKeyValidatesUntilRetired(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	keys, err := New(Config{
+		SigningKeyID: "2024-01",
+		Keys:         []Key{{ID: "2024-01", Secret: []byte("old-secret")}},
+		Clock:        clk,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	old := sign(t, keys, clk)
+
+	if err := keys.Rotate(Key{ID: "2024-02", Secret: []byte("new-secret")}, time.Hour); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	current := sign(t, keys, clk)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(current, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("failed to read token: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != "2024-02" {
+		t.Fatalf("expected new tokens to carry the new kid, got %v", kid)
+	}
+
+	clk.Advance(59 * time.Minute)
+	if err := parse(keys, clk, old); err != nil {
+		t.Fatalf("expected a token signed with the previous key to validate during the overlap, got %v", err)
+	}
+
+	clk.Advance(time.Minute)
+	if err := parse(keys, clk, old); !errors.Is(err, ErrKeyRetired) {
+		t.Fatalf("expected a token signed with the retired key to be rejected, got %v", err)
+	}
+	if err := parse(keys, clk, current); err != nil {
+		t.Fatalf("expected a token signed with the current key to validate, got %v", err)
+	}
+}
+
+func TestKeySet_ConfiguredRetirement(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	previous, err := New(Config{
+		SigningKeyID: "",
+		Keys:         []Key{{Secret: []byte("legacy-secret")}},
+		Clock:        clk,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	legacy := sign(t, previous, clk)
+
+	// The legacy secret is listed without an ID so tokens issued without a kid keep validating
+	keys, err := New(Config{
+		SigningKeyID: "2024-02",
+		Keys: []Key{
+			{ID: "2024-02", Secret: []byte("new-secret")},
+			{Secret: []byte("legacy-secret"), RetiresAt: testEpoch.Add(time.Hour)},
+		},
+		Clock: clk,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := parse(keys, clk, legacy); err != nil {
+		t.Fatalf("expected a token without a kid to validate before retirement, got %v", err)
+	}
+	clk.Advance(time.Hour)
+	if err := parse(keys, clk, legacy); !errors.Is(err, ErrKeyRetired) {
+		t.Fatalf("expected a token without a kid to be rejected after retirement, got %v", err)
+	}
+}
+
+func TestKeySet_RejectsUnknownAndForgedKeys(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	keys, err := New(Config{SigningKeyID: "a", Keys: []Key{{ID: "a", Secret: []byte("secret-a")}}, Clock: clk})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	other, err := New(Config{SigningKeyID: "b", Keys: []Key{{ID: "b", Secret: []byte("secret-b")}}, Clock: clk})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := parse(keys, clk, sign(t, other, clk)); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected an unknown kid to be rejected, got %v", err)
+	}
+
+	// A known kid signed with a different secret fails signature verification
+	forger, err := New(Config{SigningKeyID: "a", Keys: []Key{{ID: "a", Secret: []byte("guessed")}}, Clock: clk})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := parse(keys, clk, sign(t, forger, clk)); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Fatalf("expected a forged token to be rejected, got %v", err)
+	}
+}
+
+func TestNew_ValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing signing key", Config{SigningKeyID: "a", Keys: []Key{{ID: "b", Secret: []byte("s")}}}},
+		{"empty secret", Config{SigningKeyID: "a", Keys: []Key{{ID: "a"}}}},
+		{"duplicate key", Config{SigningKeyID: "a", Keys: []Key{{ID: "a", Secret: []byte("s")}, {ID: "a", Secret: []byte("t")}}}},
+		{"retiring signing key", Config{SigningKeyID: "a", Keys: []Key{{ID: "a", Secret: []byte("s"), RetiresAt: testEpoch}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
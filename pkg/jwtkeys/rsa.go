@@ -0,0 +1,114 @@
+package jwtkeys
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// AlgEnvVar names the environment variable selecting the signing algorithm,
+	// "HS256" (the default) or "RS256"
+	AlgEnvVar = "AUTH_SIGNING_ALG"
+	// PrivateKeyEnvVar names the environment variable holding the PEM encoded
+	// RSA private key tokens are signed with in RS256 mode
+	PrivateKeyEnvVar = "ACCESS_TOKEN_PRIVATE_KEY"
+)
+
+// AlgFromEnv returns the signing algorithm from AUTH_SIGNING_ALG, "HS256" if unset
+func AlgFromEnv() (string, error) {
+	alg := strings.ToUpper(strings.TrimSpace(os.Getenv(AlgEnvVar)))
+	switch alg {
+	case "":
+		return jwt.SigningMethodHS256.Alg(), nil
+	case jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg():
+		return alg, nil
+	}
+	return "", fmt.Errorf("jwtkeys: unsupported %s %q", AlgEnvVar, alg)
+}
+
+// FromRSAEnv returns a key set signing with RS256 using the private key in
+// ACCESS_TOKEN_PRIVATE_KEY. The key ID is kid, or the key's RFC 7638 thumbprint
+// if kid is empty, since JWKS clients look keys up by kid.
+func FromRSAEnv(kid string) (*KeySet, error) {
+	pemData := os.Getenv(PrivateKeyEnvVar)
+	if pemData == "" {
+		return nil, fmt.Errorf("jwtkeys: %s is required for RS256 signing", PrivateKeyEnvVar)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pemData))
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: invalid %s: %w", PrivateKeyEnvVar, err)
+	}
+	if kid == "" {
+		kid = Thumbprint(&privateKey.PublicKey)
+	}
+	return New(Config{SigningKeyID: kid, Keys: []Key{{ID: kid, PrivateKey: privateKey}}})
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of an RSA public key,
+// base64url encoded
+func Thumbprint(key *rsa.PublicKey) string {
+	// The members must be in lexicographic order with no whitespace
+	data, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{E: encodeBigInt(big.NewInt(int64(key.E))), Kty: "RSA", N: encodeBigInt(key.N)})
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JSONWebKey is the public half of an RSA key in JWK format
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JSONWebKeySet is a JWKS document, as served at a jwks.json endpoint
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the RSA keys that have not retired. HMAC
+// secrets are never published, so a key set without RSA keys has no entries.
+func (s *KeySet) JWKS() JSONWebKeySet {
+	now := s.clock.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for kid, key := range s.keys {
+		if key.PrivateKey == nil || (!key.RetiresAt.IsZero() && !now.Before(key.RetiresAt)) {
+			continue
+		}
+		public := key.PrivateKey.PublicKey
+		set.Keys = append(set.Keys, JSONWebKey{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: kid,
+			N:   encodeBigInt(public.N),
+			E:   encodeBigInt(big.NewInt(int64(public.E))),
+		})
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// encodeBigInt encodes an integer as base64url big-endian bytes
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
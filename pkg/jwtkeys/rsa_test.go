@@ -0,0 +1,151 @@
+package jwtkeys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwks"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return key
+}
+
+func TestKeySet_RS256VerifiesThroughJWKS(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	keys, err := New(Config{SigningKeyID: "rsa-1", Keys: []Key{{ID: "rsa-1", PrivateKey: newRSAKey(t)}}, Clock: clk})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if alg := keys.Algorithm(); alg != "RS256" {
+		t.Fatalf("expected RS256, got %s", alg)
+	}
+	token := sign(t, keys, clk)
+
+	// A downstream service verifies with the published public key only
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(keys.JWKS())
+	}))
+	defer server.Close()
+
+	cfg := jwks.DefaultConfig(server.URL)
+	cfg.RefreshInterval = 0
+	client, err := jwks.New(cfg)
+	if err != nil {
+		t.Fatalf("jwks.New: %v", err)
+	}
+	allowed := []string{"RS256"}
+	_, err = jwt.Parse(token, client.VerifyingKeyfunc(allowed), append(jwtalg.ParserOptions(allowed), jwt.WithTimeFunc(clk.Now))...)
+	if err != nil {
+		t.Fatalf("expected the token to verify against the JWKS, got %v", err)
+	}
+}
+
+func TestKeySet_RejectsHS256TokenForRSAKey(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	privateKey := newRSAKey(t)
+	keys, err := New(Config{SigningKeyID: "rsa-1", Keys: []Key{{ID: "rsa-1", PrivateKey: privateKey}}, Clock: clk})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Forge an HS256 token using the public key as the HMAC secret
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "attacker"})
+	forged.Header["kid"] = "rsa-1"
+	signed, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("failed to sign forged token: %v", err)
+	}
+
+	_, err = jwt.Parse(signed, keys.Keyfunc, jwt.WithValidMethods([]string{"HS256", "RS256"}), jwt.WithTimeFunc(clk.Now))
+	if !errors.Is(err, jwtalg.ErrKeyTypeMismatch) {
+		t.Fatalf("expected an HS256 token for an RSA key to be rejected, got %v", err)
+	}
+}
+
+func TestKeySet_JWKSOmitsSecretsAndRetiredKeys(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	keys, err := New(Config{
+		SigningKeyID: "rsa-2",
+		Keys: []Key{
+			{ID: "rsa-2", PrivateKey: newRSAKey(t)},
+			{ID: "rsa-1", PrivateKey: newRSAKey(t), RetiresAt: testEpoch.Add(time.Hour)},
+			{ID: "hmac", Secret: []byte("secret")},
+		},
+		Clock: clk,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	set := keys.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != "rsa-1" || set.Keys[1].Kid != "rsa-2" {
+		t.Fatalf("expected both RSA keys, got %+v", set.Keys)
+	}
+	clk.Advance(time.Hour)
+	if set := keys.JWKS(); len(set.Keys) != 1 || set.Keys[0].Kid != "rsa-2" {
+		t.Fatalf("expected the retired key to be withdrawn, got %+v", set.Keys)
+	}
+}
+
+func TestFromRSAEnv(t *testing.T) {
+	privateKey := newRSAKey(t)
+	t.Setenv(PrivateKeyEnvVar, string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})))
+
+	keys, err := FromRSAEnv("")
+	if err != nil {
+		t.Fatalf("FromRSAEnv: %v", err)
+	}
+	kid, _ := keys.SigningKey()
+	if kid != Thumbprint(&privateKey.PublicKey) {
+		t.Fatalf("expected the key ID to default to the thumbprint, got %q", kid)
+	}
+
+	t.Setenv(PrivateKeyEnvVar, "not a key")
+	if _, err := FromRSAEnv("rsa-1"); err == nil {
+		t.Fatal("expected an invalid private key to be rejected")
+	}
+}
+
+func TestAlgFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "HS256", false},
+		{"rs256", "RS256", false},
+		{"HS256", "HS256", false},
+		{"none", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv(AlgEnvVar, tt.value)
+		got, err := AlgFromEnv()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("AlgFromEnv with %q = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -7,22 +7,15 @@ import (
 
 var log *zap.Logger
 
-func InitLogger(level string) error {
+// level is shared by every logger built here, so SetLevel changes it live
+var level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+func InitLogger(lvl string) error {
 	config := zap.NewProductionConfig()
 	
 	// Set log level
-	switch level {
-	case "debug":
-		config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
-	case "info":
-		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	case "warn":
-		config.Level = zap.NewAtomicLevelAt(zapcore.WarnLevel)
-	case "error":
-		config.Level = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
-	default:
-		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	}
+	SetLevel(lvl)
+	config.Level = level
 
 	// Configure output
 	config.OutputPaths = []string{"stdout"}
@@ -47,6 +40,7 @@ func GetLogger() *zap.Logger {
 	if log == nil {
 		// Fallback to basic logger if not initialized
 		config := zap.NewProductionConfig()
+		config.Level = level
 		config.OutputPaths = []string{"stdout"}
 		config.ErrorOutputPaths = []string{"stderr"}
 		log, _ = config.Build()
@@ -54,6 +48,23 @@ func GetLogger() *zap.Logger {
 	return log
 }
 
+// SetLevel changes the level of the logger while it is running, e.g. after a
+// config reload. Unknown levels fall back to info.
+func SetLevel(lvl string) {
+	switch lvl {
+	case "debug":
+		level.SetLevel(zapcore.DebugLevel)
+	case "info":
+		level.SetLevel(zapcore.InfoLevel)
+	case "warn":
+		level.SetLevel(zapcore.WarnLevel)
+	case "error":
+		level.SetLevel(zapcore.ErrorLevel)
+	default:
+		level.SetLevel(zapcore.InfoLevel)
+	}
+}
+
 // Helper functions for common logging patterns
 func Info(msg string, fields ...zap.Field) {
 	GetLogger().Info(msg, fields...)
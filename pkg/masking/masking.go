@@ -0,0 +1,160 @@
+package masking
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Strategy determines how a field value is masked
+type Strategy string
+
+const (
+	// StrategyNone returns the value unchanged
+	StrategyNone Strategy = "none"
+	// StrategyLast4 masks all but the last four characters, e.g. "*****6789"
+	StrategyLast4 Strategy = "last4"
+	// StrategyEmail keeps the first character of the local part and the domain, e.g. "j***@example.com"
+	StrategyEmail Strategy = "email"
+	// StrategyRedact replaces the value entirely
+	StrategyRedact Strategy = "redact"
+)
+
+// redacted replaces values masked with StrategyRedact
+const redacted = "[REDACTED]"
+
+// FieldPolicy controls how one field is masked for each caller role
+type FieldPolicy struct {
+	// Default applies to callers without a role listed in Roles
+	Default Strategy `mapstructure:"default" yaml:"default"`
+	// Roles overrides the strategy for specific roles
+	Roles map[string]Strategy `mapstructure:"roles" yaml:"roles"`
+}
+
+// Policy maps JSON field names to their masking policy
+type Policy map[string]FieldPolicy
+
+// DefaultPolicy returns the default PII masking policy. Compliance officers and
+// admins see full values; everyone else, including support agents, sees masked values.
+func DefaultPolicy() Policy {
+	fullAccess := map[string]Strategy{
+		"admin":              StrategyNone,
+		"compliance_officer": StrategyNone,
+	}
+
+	return Policy{
+		"document_number": {Default: StrategyLast4, Roles: fullAccess},
+		"ssn":             {Default: StrategyLast4, Roles: fullAccess},
+		"date_of_birth":   {Default: StrategyRedact, Roles: fullAccess},
+		"email":           {Default: StrategyEmail, Roles: fullAccess},
+	}
+}
+
+// Merge returns a copy of p with the fields in overrides replaced, so a
+// configured policy only has to name the fields it changes
+func (p Policy) Merge(overrides Policy) Policy {
+	merged := make(Policy, len(p)+len(overrides))
+	for field, fieldPolicy := range p {
+		merged[field] = fieldPolicy
+	}
+	for field, fieldPolicy := range overrides {
+		merged[field] = fieldPolicy
+	}
+	return merged
+}
+
+// strictness orders strategies so the least restrictive one granted by any role wins
+var strictness = map[Strategy]int{
+	StrategyNone:   0,
+	StrategyLast4:  1,
+	StrategyEmail:  1,
+	StrategyRedact: 2,
+}
+
+// StrategyFor returns the strategy for field given the caller's roles. A caller
+// with several roles gets the least restrictive strategy any of them grants.
+func (p Policy) StrategyFor(field string, roles []string) Strategy {
+	fieldPolicy, ok := p[field]
+	if !ok {
+		return StrategyNone
+	}
+
+	strategy := fieldPolicy.Default
+	if strategy == "" {
+		strategy = StrategyRedact
+	}
+	for _, role := range roles {
+		if override, ok := fieldPolicy.Roles[role]; ok && strictness[override] < strictness[strategy] {
+			strategy = override
+		}
+	}
+	return strategy
+}
+
+// Apply masks every policy field found anywhere in a JSON document
+func (p Policy) Apply(data []byte, roles []string) ([]byte, error) {
+	if len(p) == 0 {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(p.mask(doc, roles))
+}
+
+// mask walks a decoded JSON value, masking string fields named in the policy
+func (p Policy) mask(v interface{}, roles []string) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if s, ok := child.(string); ok {
+				if _, inPolicy := p[key]; inPolicy {
+					value[key] = Mask(s, p.StrategyFor(key, roles))
+					continue
+				}
+			}
+			value[key] = p.mask(child, roles)
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = p.mask(child, roles)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// Mask applies strategy to a single value
+func Mask(value string, strategy Strategy) string {
+	if value == "" {
+		return value
+	}
+
+	switch strategy {
+	case StrategyNone:
+		return value
+	case StrategyLast4:
+		runes := []rune(value)
+		if len(runes) <= 4 {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case StrategyEmail:
+		at := strings.LastIndex(value, "@")
+		if at <= 0 {
+			return redacted
+		}
+		local := []rune(value[:at])
+		return string(local[0]) + strings.Repeat("*", len(local)-1) + value[at:]
+	default:
+		return redacted
+	}
+}
@@ -0,0 +1,188 @@
+package masking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value    string
+		strategy Strategy
+		want     string
+	}{
+		{"123456789", StrategyNone, "123456789"},
+		{"123456789", StrategyLast4, "*****6789"},
+		{"123", StrategyLast4, "***"},
+		{"jane@example.com", StrategyEmail, "j***@example.com"},
+		{"not-an-email", StrategyEmail, redacted},
+		{"1990-01-01", StrategyRedact, redacted},
+		{"", StrategyRedact, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Mask(tt.value, tt.strategy); got != tt.want {
+			t.Errorf("Mask(%q, %q) = %q, want %q", tt.value, tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestStrategyFor_LeastRestrictiveRoleWins(t *testing.T) {
+	policy := Policy{
+		"document_number": {
+			Default: StrategyRedact,
+			Roles:   map[string]Strategy{"support": StrategyLast4, "compliance_officer": StrategyNone},
+		},
+	}
+
+	if got := policy.StrategyFor("document_number", nil); got != StrategyRedact {
+		t.Errorf("anonymous: got %q", got)
+	}
+	if got := policy.StrategyFor("document_number", []string{"support"}); got != StrategyLast4 {
+		t.Errorf("support: got %q", got)
+	}
+	if got := policy.StrategyFor("document_number", []string{"support", "compliance_officer"}); got != StrategyNone {
+		t.Errorf("support and compliance: got %q", got)
+	}
+	if got := policy.StrategyFor("status", []string{"support"}); got != StrategyNone {
+		t.Errorf("field outside the policy: got %q", got)
+	}
+}
+
+func maskedRouter(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	// Stand-in for the auth middleware, which stores the token's roles in the context
+	router.Use(func(c *gin.Context) {
+		c.Set(RolesKey, []string{role})
+		c.Next()
+	})
+	router.Use(Middleware(DefaultPolicy()))
+	router.GET("/documents", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"documents": []gin.H{{
+				"id":              "doc-1",
+				"document_number": "123456789",
+				"owner": gin.H{
+					"email":         "jane@example.com",
+					"date_of_birth": "1990-01-01",
+				},
+			}},
+		})
+	})
+	return router
+}
+
+func getDocument(t *testing.T, role string) map[string]interface{} {
+	w := httptest.NewRecorder()
+	maskedRouter(role).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/documents", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+
+	var body struct {
+		Documents []map[string]interface{} `json:"documents"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if len(body.Documents) != 1 {
+		t.Fatalf("expected one document, got %d", len(body.Documents))
+	}
+	return body.Documents[0]
+}
+
+func TestMiddleware_SupportRoleGetsMaskedValues(t *testing.T) {
+	doc := getDocument(t, "support")
+	owner := doc["owner"].(map[string]interface{})
+
+	if doc["document_number"] != "*****6789" {
+		t.Errorf("document_number = %v", doc["document_number"])
+	}
+	if owner["email"] != "j***@example.com" {
+		t.Errorf("email = %v", owner["email"])
+	}
+	if owner["date_of_birth"] != redacted {
+		t.Errorf("date_of_birth = %v", owner["date_of_birth"])
+	}
+	if doc["id"] != "doc-1" {
+		t.Errorf("non-PII field changed: id = %v", doc["id"])
+	}
+}
+
+func TestMiddleware_ComplianceRoleGetsFullValues(t *testing.T) {
+	doc := getDocument(t, "compliance_officer")
+	owner := doc["owner"].(map[string]interface{})
+
+	if doc["document_number"] != "123456789" {
+		t.Errorf("document_number = %v", doc["document_number"])
+	}
+	if owner["email"] != "jane@example.com" {
+		t.Errorf("email = %v", owner["email"])
+	}
+	if !strings.HasPrefix(owner["date_of_birth"].(string), "1990") {
+		t.Errorf("date_of_birth = %v", owner["date_of_birth"])
+	}
+}
+
+func TestMerge_KeepsDefaultsForUnconfiguredFields(t *testing.T) {
+	policy := DefaultPolicy().Merge(Policy{
+		"email": {Default: StrategyRedact},
+	})
+
+	if got := policy.StrategyFor("email", []string{"admin"}); got != StrategyRedact {
+		t.Errorf("configured field: got %q", got)
+	}
+	if got := policy.StrategyFor("ssn", nil); got != StrategyLast4 {
+		t.Errorf("default field: got %q", got)
+	}
+}
+
+func TestMiddleware_MasksNDJSONLineByLine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	var flushedBeforeEnd string
+	router := gin.New()
+	router.Use(Middleware(DefaultPolicy()))
+	router.GET("/export", func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		c.Writer.WriteString(`{"ssn":"123456789"}` + "\n")
+		c.Writer.Flush()
+		flushedBeforeEnd = w.Body.String()
+		c.Writer.WriteString(`{"ssn":"987654321"}`)
+	})
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+	if flushedBeforeEnd != `{"ssn":"*****6789"}`+"\n" {
+		t.Fatalf("expected the first line to be masked and sent before the handler finished, got %q", flushedBeforeEnd)
+	}
+	if w.Body.String() != `{"ssn":"*****6789"}`+"\n"+`{"ssn":"*****4321"}`+"\n" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestMiddleware_PassesThroughNonJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Middleware(DefaultPolicy()))
+	router.GET("/file", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/pdf", []byte("%PDF-1.7"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/file", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF-1.7" {
+		t.Fatalf("expected the body unchanged, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,238 @@
+package masking
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys the auth middlewares store the caller's roles under
+const (
+	RolesKey = "roles"
+	RoleKey  = "role"
+)
+
+// Middleware masks PII in JSON responses according to the caller's roles. It must
+// run after the auth middleware so the roles are available.
+//
+// JSON documents are buffered and masked whole. Line-delimited JSON (NDJSON) is
+// masked a line at a time as it is written, so streamed exports are never held
+// in memory. Other content types, such as file downloads, pass straight through.
+func Middleware(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &maskingWriter{
+			ResponseWriter: c.Writer,
+			policy:         policy,
+			roles:          callerRoles(c),
+			status:         http.StatusOK,
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		switch writer.mode {
+		case modeLines:
+			writer.flushLines(true)
+			return
+		case modePassthrough:
+			return
+		}
+
+		body := writer.body.Bytes()
+		if len(body) > 0 && writer.mode == modeDocument {
+			masked, err := policy.Apply(body, writer.roles)
+			if err != nil {
+				// Never fall back to the unmasked body
+				writer.Header().Del("Content-Length")
+				writer.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+				writer.ResponseWriter.Write([]byte(`{"error":"Failed to render response"}`))
+				return
+			}
+			body = masked
+			writer.Header().Del("Content-Length")
+		}
+
+		writer.ResponseWriter.WriteHeader(writer.status)
+		writer.ResponseWriter.Write(body)
+	}
+}
+
+// callerRoles returns the roles set by the auth middleware
+func callerRoles(c *gin.Context) []string {
+	value, exists := c.Get(RolesKey)
+	if !exists {
+		if value, exists = c.Get(RoleKey); !exists {
+			return nil
+		}
+	}
+
+	switch roles := value.(type) {
+	case []string:
+		return roles
+	case string:
+		return []string{roles}
+	case []interface{}:
+		result := make([]string, 0, len(roles))
+		for _, role := range roles {
+			if s, ok := role.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// writeMode is how maskingWriter handles a response, chosen from its content
+// type when the handler starts writing
+type writeMode int
+
+const (
+	modeUndecided writeMode = iota
+	// modeDocument buffers a JSON document and masks it once complete
+	modeDocument
+	// modeLines masks each line of line-delimited JSON as it is written
+	modeLines
+	// modePassthrough writes responses that carry no JSON unchanged
+	modePassthrough
+)
+
+// modeFor chooses the write mode for a response content type
+func modeFor(contentType string) writeMode {
+	switch {
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+		return modeLines
+	case strings.Contains(contentType, "json"):
+		return modeDocument
+	default:
+		return modePassthrough
+	}
+}
+
+// maskingWriter masks the response before it is sent, buffering only as much
+// of it as its content type requires
+type maskingWriter struct {
+	gin.ResponseWriter
+	policy Policy
+	roles  []string
+	mode   writeMode
+	body   bytes.Buffer
+	status int
+	err    error
+}
+
+// decide picks the write mode on the first write. Streamed modes send the
+// status line straight away; a masked line never has a known length.
+func (w *maskingWriter) decide() {
+	if w.mode != modeUndecided {
+		return
+	}
+	w.mode = modeFor(w.Header().Get("Content-Type"))
+	if w.mode == modeLines {
+		w.Header().Del("Content-Length")
+	}
+	if w.mode != modeDocument {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *maskingWriter) WriteHeader(status int) {
+	if w.mode == modeUndecided {
+		w.status = status
+	}
+}
+
+func (w *maskingWriter) WriteHeaderNow() {
+	w.decide()
+	if w.mode != modeDocument {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *maskingWriter) Write(data []byte) (int, error) {
+	w.decide()
+	switch w.mode {
+	case modePassthrough:
+		return w.ResponseWriter.Write(data)
+	case modeLines:
+		if w.err != nil {
+			return 0, w.err
+		}
+		w.body.Write(data)
+		if err := w.flushLines(false); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	default:
+		return w.body.Write(data)
+	}
+}
+
+func (w *maskingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// flushLines masks and writes every complete buffered line, and the trailing
+// partial line too once the handler is done. A line that cannot be masked ends
+// the response there rather than being sent unmasked.
+func (w *maskingWriter) flushLines(final bool) error {
+	for w.err == nil {
+		buffered := w.body.Bytes()
+		end := bytes.IndexByte(buffered, '\n')
+		if end < 0 {
+			if !final || len(buffered) == 0 {
+				return nil
+			}
+			end = len(buffered)
+		}
+		line := buffered[:end]
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			masked, err := w.policy.Apply(line, w.roles)
+			if err != nil {
+				w.err = err
+				break
+			}
+			if _, err := w.ResponseWriter.Write(append(masked, '\n')); err != nil {
+				w.err = err
+				break
+			}
+		}
+		w.body.Next(end + 1)
+	}
+	w.body.Reset()
+	return w.err
+}
+
+// Flush sends what has been written so far, except for buffered JSON documents,
+// which cannot be masked until they are complete
+func (w *maskingWriter) Flush() {
+	if w.mode == modeLines || w.mode == modePassthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *maskingWriter) Status() int {
+	if w.mode == modeLines || w.mode == modePassthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *maskingWriter) Size() int {
+	if w.mode == modeLines || w.mode == modePassthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+func (w *maskingWriter) Written() bool {
+	if w.mode == modeLines || w.mode == modePassthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.body.Len() > 0
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequestsInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served",
+		},
+		[]string{"method", "path"},
+	)
+)
+
+// RouteFunc names the route a request matched, used as the path label.
+// Returning a route template (e.g. /api/v1/users/{id}) keeps label cardinality bounded.
+type RouteFunc func(r *http.Request) string
+
+// MuxRouteLabel is a RouteFunc naming a request by the template of the
+// gorilla/mux route it matched. Unmatched requests fall back to the URL path.
+func MuxRouteLabel(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return ""
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// extend the write deadline of a long-running response
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// HTTPMiddleware records request count, duration and in-flight requests for plain
+// net/http handlers, including gorilla/mux routers via Router.Use. If route is nil
+// the URL path is used.
+func HTTPMiddleware(route RouteFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if route != nil {
+				if name := route(r); name != "" {
+					path = name
+				}
+			}
+
+			inFlight := httpRequestsInFlight.WithLabelValues(r.Method, path)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			// Process request
+			next.ServeHTTP(recorder, r)
+
+			// Record metrics
+			httpRequestsTotal.WithLabelValues(r.Method, path, strconv.Itoa(recorder.status)).Inc()
+			httpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// InstrumentHandlerFunc wraps a handler registered with http.HandleFunc under a fixed route
+func InstrumentHandlerFunc(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return HTTPMiddleware(func(*http.Request) string { return route })(handler).ServeHTTP
+}
+
+// Handler returns the Prometheus /metrics handler
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHTTPMiddleware_CountsRequestsByStatus(t *testing.T) {
+	handler := HTTPMiddleware(func(*http.Request) string { return "/test/items/{id}" })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("missing") != "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("ok"))
+		}),
+	)
+
+	okCounter := httpRequestsTotal.WithLabelValues("GET", "/test/items/{id}", "200")
+	notFoundCounter := httpRequestsTotal.WithLabelValues("GET", "/test/items/{id}", "404")
+	okBefore := testutil.ToFloat64(okCounter)
+	notFoundBefore := testutil.ToFloat64(notFoundCounter)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/items/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/items/2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/items/3?missing=1", nil))
+
+	if got := testutil.ToFloat64(okCounter) - okBefore; got != 2 {
+		t.Fatalf("expected 2 requests with status 200, got %v", got)
+	}
+	if got := testutil.ToFloat64(notFoundCounter) - notFoundBefore; got != 1 {
+		t.Fatalf("expected 1 request with status 404, got %v", got)
+	}
+	if got := testutil.ToFloat64(httpRequestsInFlight.WithLabelValues("GET", "/test/items/{id}")); got != 0 {
+		t.Fatalf("expected no requests in flight, got %v", got)
+	}
+}
+
+func TestHTTPMiddleware_SupportsResponseController(t *testing.T) {
+	var flushErr error
+	handler := HTTPMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flushErr = http.NewResponseController(w).Flush()
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/test/flush", nil))
+
+	if flushErr != nil {
+		t.Fatalf("expected the response controller to reach the underlying writer, got %v", flushErr)
+	}
+	if !recorder.Flushed {
+		t.Fatal("expected the underlying writer to be flushed")
+	}
+}
+
+func TestInstrumentHandlerFunc_UsesFixedRoute(t *testing.T) {
+	handler := InstrumentHandlerFunc("/test/create", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	counter := httpRequestsTotal.WithLabelValues("POST", "/test/create", "201")
+	before := testutil.ToFloat64(counter)
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/test/create?x=1", nil))
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Fatalf("expected 1 request with status 201, got %v", got)
+	}
+}
+
+func TestMuxRouteLabel_UsesRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(HTTPMiddleware(MuxRouteLabel))
+	router.HandleFunc("/test/mux/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	counter := httpRequestsTotal.WithLabelValues("GET", "/test/mux/{id}", "200")
+	before := testutil.ToFloat64(counter)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/mux/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/mux/2", nil))
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Fatalf("expected 2 requests labelled with the route template, got %v", got)
+	}
+}
@@ -53,6 +53,40 @@ var (
 		},
 	)
 
+	// Rate limit metrics
+	rateLimitRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_rejections_total",
+			Help: "Total number of requests rejected by the rate limiter",
+		},
+		[]string{"key_type"},
+	)
+
+	rateLimitAbusiveClientsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_abusive_clients_total",
+			Help: "Total number of clients that crossed the rate limit abuse threshold",
+		},
+		[]string{"key_type"},
+	)
+
+	dbRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_operations_rejected_total",
+			Help: "Total number of database operations rejected by the bulkhead or circuit breaker",
+		},
+		[]string{"database", "reason"},
+	)
+
+	// Authentication metrics
+	jwtValidationFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jwt_validation_failures_total",
+			Help: "Total number of bearer tokens rejected, by reason",
+		},
+		[]string{"reason"},
+	)
+
 	// Error metrics
 	errorTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -103,6 +137,26 @@ func RecordVerificationDuration(duration time.Duration) {
 	verificationDuration.Observe(duration.Seconds())
 }
 
+// RecordRateLimitRejection records a request rejected by the rate limiter
+func RecordRateLimitRejection(keyType string) {
+	rateLimitRejectionsTotal.WithLabelValues(keyType).Inc()
+}
+
+// RecordRateLimitAbuse records a client crossing the rate limit abuse threshold
+func RecordRateLimitAbuse(keyType string) {
+	rateLimitAbusiveClientsTotal.WithLabelValues(keyType).Inc()
+}
+
+// RecordDBRejection records a database operation shed by the bulkhead or circuit breaker
+func RecordDBRejection(database, reason string) {
+	dbRejectionsTotal.WithLabelValues(database, reason).Inc()
+}
+
+// RecordJWTValidationFailure records a bearer token rejected for reason, one of the jwtalg.Reason values
+func RecordJWTValidationFailure(reason string) {
+	jwtValidationFailuresTotal.WithLabelValues(reason).Inc()
+}
+
 // RecordError records an error occurrence
 func RecordError(errorType string) {
 	errorTotal.WithLabelValues(errorType).Inc()
@@ -1,23 +1,37 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// tokenLifetime is how long tokens issued by GenerateToken stay valid
+const tokenLifetime = 24 * time.Hour
+
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID   string `json:"user_id"`
+	Role     string `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// AuthMiddleware validates bearer tokens against the system clock
 func AuthMiddleware() gin.HandlerFunc {
+	return AuthMiddlewareWithClock(clock.Real())
+}
+
+// AuthMiddlewareWithClock validates bearer tokens, checking expiry against clk
+func AuthMiddlewareWithClock(clk clock.Clock) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -37,50 +51,85 @@ func AuthMiddleware() gin.HandlerFunc {
 		tokenString := parts[1]
 		claims := &Claims{}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
+		algorithms := jwtalg.FromEnv()
+		options := append(jwtalg.ParserOptions(algorithms), jwt.WithTimeFunc(clk.Now))
+		token, err := jwt.ParseWithClaims(tokenString, claims, jwtalg.Keyfunc(algorithms, func(token *jwt.Token) (interface{}, error) {
 			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		}), options...)
 
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			rejectToken(c, err)
 			return
 		}
 
 		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "token is invalid"})
-			c.Abort()
+			rejectToken(c, errors.New("token is invalid"))
 			return
 		}
 
 		// Check token expiration
-		if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has expired"})
-			c.Abort()
+		if claims.ExpiresAt != nil && claims.ExpiresAt.Before(clk.Now()) {
+			rejectToken(c, jwt.ErrTokenExpired)
 			return
 		}
 
 		// Set user information in context
 		c.Set("user_id", claims.UserID)
 		c.Set("role", claims.Role)
+		setTenant(c, claims.TenantID)
+		setRefreshHints(c, claims.ExpiresAt, clk.Now(), RefreshLeadTimeFromEnv())
 		c.Next()
 	}
 }
 
+// GenerateToken issues a token valid for tokenLifetime from now
 func GenerateToken(userID, role string) (string, error) {
+	return GenerateTokenWithClock(clock.Real(), userID, role)
+}
+
+// GenerateTokenWithClock issues a token valid for tokenLifetime from clk.Now()
+func GenerateTokenWithClock(clk clock.Clock, userID, role string) (string, error) {
+	now := clk.Now()
 	claims := &Claims{
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenLifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-} 
\ No newline at end of file
+} 
+
+// setTenant scopes the request context to the tenant from the caller's token
+func setTenant(c *gin.Context, tenantID string) {
+	if tenantID == "" {
+		return
+	}
+	c.Set(tenant.Claim, tenantID)
+	c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+}
+
+// tokenErrorMessages describe each jwtalg reason in a 401 response
+var tokenErrorMessages = map[string]string{
+	jwtalg.ReasonExpired:          "token has expired",
+	jwtalg.ReasonNotYetValid:      "token is not valid yet",
+	jwtalg.ReasonSignatureInvalid: "token signature is invalid",
+	jwtalg.ReasonMalformed:        "token is malformed",
+	jwtalg.ReasonInvalid:          "token is invalid",
+}
+
+// rejectToken aborts with a 401 whose code tells the client why its token was
+// refused, so it can refresh an expired token and discard a bad one
+func rejectToken(c *gin.Context, err error) {
+	reason := jwtalg.Classify(err)
+	metrics.RecordJWTValidationFailure(reason)
+	c.JSON(http.StatusUnauthorized, ErrorResponse{
+		Error: tokenErrorMessages[reason],
+		Code:  reason,
+	})
+	c.Abort()
+}
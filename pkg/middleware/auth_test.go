@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddleware_TokenExpiresAfterLifetime(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+
+	clk := clock.NewFake(testEpoch)
+	token, err := GenerateTokenWithClock(clk, "user-1", "user")
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClock: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddlewareWithClock(clk))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	clk.Advance(tokenLifetime - time.Minute)
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("request before expiry: got %d, want %d", code, http.StatusOK)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if code := request(); code != http.StatusUnauthorized {
+		t.Fatalf("request after expiry: got %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -123,6 +123,8 @@ func getCircuitBreaker(path string, cfg CircuitBreakerConfig) *gobreaker.Circuit
 // ErrorResponse represents a standard error response
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Code is a machine-readable reason, set where clients can act on it
+	Code string `json:"code,omitempty"`
 }
 
 // bodyLogWriter is a custom response writer that captures the status code
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/adil-faiyaz98/sparkfund/pkg/apiversion"
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks a route or group deprecated, adding the Deprecation, Sunset
+// and Link headers d describes to its responses
+func Deprecated(d apiversion.Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d.SetHeaders(c.Writer.Header())
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/apiversion"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecated_OnlyMarksDeprecatedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	deprecation := apiversion.Deprecation{
+		Since:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		Successor: "/api/v2/items",
+	}
+	router.GET("/api/v1/items", Deprecated(deprecation), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v2/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items", nil))
+	if got, want := w.Header().Get(apiversion.HeaderDeprecation), "@1767225600"; got != want {
+		t.Fatalf("%s = %q, want %q", apiversion.HeaderDeprecation, got, want)
+	}
+	if got, want := w.Header().Get(apiversion.HeaderSunset), "Wed, 01 Jul 2026 00:00:00 GMT"; got != want {
+		t.Fatalf("%s = %q, want %q", apiversion.HeaderSunset, got, want)
+	}
+	if got, want := w.Header().Get(apiversion.HeaderLink), `</api/v2/items>; rel="successor-version"`; got != want {
+		t.Fatalf("%s = %q, want %q", apiversion.HeaderLink, got, want)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/items", nil))
+	if got := w.Header().Get(apiversion.HeaderDeprecation); got != "" {
+		t.Fatalf("unexpected %s %q on the current route", apiversion.HeaderDeprecation, got)
+	}
+}
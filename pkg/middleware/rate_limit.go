@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
@@ -15,9 +16,13 @@ type RateLimiter struct {
 }
 
 func NewRateLimiter(redisAddr string) (*RateLimiter, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
-	})
+	return NewRateLimiterWithOptions(redisclient.Options{Addr: redisAddr})
+}
+
+// NewRateLimiterWithOptions creates a rate limiter with configured Redis pool
+// and timeout options
+func NewRateLimiterWithOptions(opts redisclient.Options) (*RateLimiter, error) {
+	client := redisclient.New(opts)
 
 	// Test connection
 	ctx := context.Background()
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token refresh hint headers set on authenticated responses
+const (
+	// HeaderTokenExpiresAt is the RFC 3339 expiry time of the caller's token
+	HeaderTokenExpiresAt = "X-Token-Expires-At"
+	// HeaderTokenRefreshAt is the RFC 3339 time after which the client should refresh
+	HeaderTokenRefreshAt = "X-Token-Refresh-At"
+	// HeaderTokenRefresh is set to RefreshRecommended once the refresh time has passed
+	HeaderTokenRefresh = "X-Token-Refresh"
+
+	// RefreshRecommended is the HeaderTokenRefresh value advising an immediate refresh
+	RefreshRecommended = "recommended"
+)
+
+// DefaultRefreshLeadTime is how long before expiry clients are advised to refresh
+const DefaultRefreshLeadTime = 5 * time.Minute
+
+// RefreshLeadTimeEnvVar overrides the refresh lead time used by AuthMiddleware
+const RefreshLeadTimeEnvVar = "JWT_REFRESH_LEAD_TIME"
+
+// RefreshLeadTimeFromEnv returns the lead time set in RefreshLeadTimeEnvVar,
+// or DefaultRefreshLeadTime if it is unset or invalid
+func RefreshLeadTimeFromEnv() time.Duration {
+	lead, err := time.ParseDuration(os.Getenv(RefreshLeadTimeEnvVar))
+	if err != nil || lead <= 0 {
+		return DefaultRefreshLeadTime
+	}
+	return lead
+}
+
+// setRefreshHints tells the client when its token expires and when to refresh
+// it, which is lead before expiry. Tokens without an expiry get no hints.
+func setRefreshHints(c *gin.Context, expiresAt *jwt.NumericDate, now time.Time, lead time.Duration) {
+	if expiresAt == nil {
+		return
+	}
+	if lead <= 0 {
+		lead = DefaultRefreshLeadTime
+	}
+
+	refreshAt := expiresAt.Add(-lead)
+	header := c.Writer.Header()
+	header.Set(HeaderTokenExpiresAt, expiresAt.UTC().Format(time.RFC3339))
+	header.Set(HeaderTokenRefreshAt, refreshAt.UTC().Format(time.RFC3339))
+	if !now.Before(refreshAt) {
+		header.Set(HeaderTokenRefresh, RefreshRecommended)
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// refreshHintResponse calls a JWTAuth-protected route with a token expiring after lifetime
+func refreshHintResponse(t *testing.T, clk clock.Clock, lifetime time.Duration) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := JWTConfig{Secret: "test-secret", Enabled: true, RefreshLeadTime: 10 * time.Minute, Clock: clk}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": clk.Now().Add(lifetime).Unix(),
+	}).SignedString([]byte(cfg.Secret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusOK)
+	}
+	return w
+}
+
+func TestJWTAuth_AdvisesRefreshNearExpiry(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	w := refreshHintResponse(t, clk, 3*time.Minute)
+
+	if got := w.Header().Get(HeaderTokenRefresh); got != RefreshRecommended {
+		t.Fatalf("%s = %q, want %q", HeaderTokenRefresh, got, RefreshRecommended)
+	}
+	if got, want := w.Header().Get(HeaderTokenExpiresAt), testEpoch.Add(3*time.Minute).Format(time.RFC3339); got != want {
+		t.Fatalf("%s = %q, want %q", HeaderTokenExpiresAt, got, want)
+	}
+	if got, want := w.Header().Get(HeaderTokenRefreshAt), testEpoch.Add(-7*time.Minute).Format(time.RFC3339); got != want {
+		t.Fatalf("%s = %q, want %q", HeaderTokenRefreshAt, got, want)
+	}
+}
+
+func TestJWTAuth_FreshTokenIsNotAdvisedToRefresh(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	w := refreshHintResponse(t, clk, time.Hour)
+
+	if got := w.Header().Get(HeaderTokenRefresh); got != "" {
+		t.Fatalf("expected no refresh advice for a fresh token, got %q", got)
+	}
+	if got, want := w.Header().Get(HeaderTokenRefreshAt), testEpoch.Add(50*time.Minute).Format(time.RFC3339); got != want {
+		t.Fatalf("%s = %q, want %q", HeaderTokenRefreshAt, got, want)
+	}
+}
+
+func TestAuthMiddleware_UsesRefreshLeadTimeFromEnv(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv(RefreshLeadTimeEnvVar, "2h")
+	gin.SetMode(gin.TestMode)
+
+	clk := clock.NewFake(testEpoch)
+	token, err := GenerateTokenWithClock(clk, "user-1", "user")
+	if err != nil {
+		t.Fatalf("GenerateTokenWithClock: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddlewareWithClock(clk))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	refreshAdvice := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header().Get(HeaderTokenRefresh)
+	}
+
+	clk.Advance(tokenLifetime - 3*time.Hour)
+	if got := refreshAdvice(); got != "" {
+		t.Fatalf("3h before expiry: expected no refresh advice, got %q", got)
+	}
+	clk.Advance(90 * time.Minute)
+	if got := refreshAdvice(); got != RefreshRecommended {
+		t.Fatalf("90m before expiry: %s = %q, want %q", HeaderTokenRefresh, got, RefreshRecommended)
+	}
+}
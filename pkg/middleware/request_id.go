@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader carries the request ID between services and back to the client
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the gin context key holding the request ID
+	RequestIDKey = "request_id"
+
+	// maxRequestIDLength bounds accepted incoming IDs; a UUID is 36 characters
+	maxRequestIDLength = 128
+)
+
+// Request ID formats
+const (
+	// RequestIDFormatUUID generates random UUIDv4 IDs
+	RequestIDFormatUUID = "uuid"
+	// RequestIDFormatULID generates 26 character ULIDs, which sort by creation time
+	RequestIDFormatULID = "ulid"
+)
+
+// RequestIDConfig holds configuration for the request ID middleware
+type RequestIDConfig struct {
+	// Format is RequestIDFormatUUID or RequestIDFormatULID. It only affects IDs
+	// generated here; a valid incoming ID is kept whatever its format.
+	Format string
+}
+
+// DefaultRequestIDConfig returns default request ID configuration
+func DefaultRequestIDConfig() RequestIDConfig {
+	return RequestIDConfig{Format: RequestIDFormatUUID}
+}
+
+// RequestID gives every request an ID. A valid incoming X-Request-ID is kept so
+// a request can be followed across services; a missing or malformed one is
+// replaced with a new ID. The final ID is stored in the context, set on the
+// request for outgoing calls and echoed in the response.
+func RequestID(cfg RequestIDConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID(cfg.Format)
+		}
+
+		c.Request.Header.Set(RequestIDHeader, requestID)
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the request ID set by RequestID, or "" if it has not run
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// ValidRequestID reports whether id is safe to accept from a client. Only
+// letters, digits and - _ . : are allowed, so a crafted ID cannot inject line
+// breaks or fake fields into logs.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch ch := id[i]; {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.' || ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID generates a request ID in the given format, defaulting to UUID
+func NewRequestID(format string) string {
+	if format == RequestIDFormatULID {
+		return newULID(time.Now())
+	}
+	return uuid.New().String()
+}
+
+// crockford is the base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID encodes a 48 bit millisecond timestamp followed by 80 random bits as
+// 26 Crockford base32 characters
+func newULID(now time.Time) string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(now.UnixMilli())<<16)
+	if _, err := rand.Read(id[6:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	// 128 bits take 26 characters of 5 bits, the first holding only the top 3
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIDRouter echoes the request ID seen by the handler and by outgoing calls
+func requestIDRouter(cfg RequestIDConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID(cfg))
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"context":  GetRequestID(c),
+			"outgoing": c.Request.Header.Get(RequestIDHeader),
+		})
+	})
+	return router
+}
+
+func sendWithRequestID(router *gin.Engine, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestID_PropagatesValidIncomingID(t *testing.T) {
+	router := requestIDRouter(DefaultRequestIDConfig())
+
+	w := sendWithRequestID(router, "gw-01HZX3K9Q2:req.42")
+	if got := w.Header().Get(RequestIDHeader); got != "gw-01HZX3K9Q2:req.42" {
+		t.Fatalf("expected the incoming ID to be echoed, got %q", got)
+	}
+	if want := `{"context":"gw-01HZX3K9Q2:req.42","outgoing":"gw-01HZX3K9Q2:req.42"}`; strings.TrimSpace(w.Body.String()) != want {
+		t.Fatalf("expected the handler to see the incoming ID, got %s", w.Body.String())
+	}
+}
+
+func TestRequestID_ReplacesMalformedIncomingID(t *testing.T) {
+	router := requestIDRouter(DefaultRequestIDConfig())
+
+	for _, incoming := range []string{
+		"abc\r\nlevel=error msg=forged",
+		`abc" user="admin`,
+		strings.Repeat("a", maxRequestIDLength+1),
+	} {
+		w := sendWithRequestID(router, incoming)
+		got := w.Header().Get(RequestIDHeader)
+		if got == incoming {
+			t.Fatalf("expected malformed ID %q to be replaced", incoming)
+		}
+		if _, err := uuid.Parse(got); err != nil {
+			t.Fatalf("expected a generated UUID in place of %q, got %q", incoming, got)
+		}
+		if !strings.Contains(w.Body.String(), `"context":"`+got+`"`) {
+			t.Fatalf("expected the response to echo the ID the handler saw, got %s", w.Body.String())
+		}
+	}
+}
+
+func TestRequestID_GeneratesConfiguredFormat(t *testing.T) {
+	w := sendWithRequestID(requestIDRouter(DefaultRequestIDConfig()), "")
+	if _, err := uuid.Parse(w.Header().Get(RequestIDHeader)); err != nil {
+		t.Fatalf("expected a UUID by default, got %q", w.Header().Get(RequestIDHeader))
+	}
+
+	w = sendWithRequestID(requestIDRouter(RequestIDConfig{Format: RequestIDFormatULID}), "")
+	got := w.Header().Get(RequestIDHeader)
+	if len(got) != 26 || strings.Trim(got, crockford) != "" {
+		t.Fatalf("expected a ULID, got %q", got)
+	}
+	if !ValidRequestID(got) {
+		t.Fatalf("expected a generated ULID to be accepted downstream, got %q", got)
+	}
+}
+
+func TestNewULID_SortsByTime(t *testing.T) {
+	earlier := newULID(time.UnixMilli(1700000000000))
+	later := newULID(time.UnixMilli(1700000000001))
+	if earlier >= later {
+		t.Fatalf("expected %q to sort before %q", earlier, later)
+	}
+	if got := newULID(time.UnixMilli(0)); !strings.HasPrefix(got, "0000000000") {
+		t.Fatalf("expected a zero timestamp prefix, got %q", got)
+	}
+}
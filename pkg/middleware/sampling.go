@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"os"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/uber/jaeger-client-go"
+)
+
+// Default head sampling ratios. Development traces everything; production keeps
+// a tenth of traces plus any retained by the error and latency rules.
+const (
+	DevelopmentSampleRatio = 1.0
+	ProductionSampleRatio  = 0.1
+)
+
+// DefaultSlowRequestThreshold is the latency above which a request's span is
+// retained regardless of the head sampling decision
+const DefaultSlowRequestThreshold = 2 * time.Second
+
+// defaultSampleRatio returns the head sampling ratio for the APP_ENV environment
+func defaultSampleRatio() float64 {
+	if os.Getenv("APP_ENV") == "production" {
+		return ProductionSampleRatio
+	}
+	return DevelopmentSampleRatio
+}
+
+// newSampler returns the head sampler for cfg. Root spans are sampled with
+// probability SampleRatio; spans continuing a remote trace follow the caller's
+// decision, so a trace is either kept or dropped as a whole.
+func newSampler(cfg TracingConfig) (jaeger.Sampler, error) {
+	switch {
+	case cfg.SampleRatio >= 1:
+		return jaeger.NewConstSampler(true), nil
+	case cfg.SampleRatio <= 0:
+		return jaeger.NewConstSampler(false), nil
+	default:
+		return jaeger.NewProbabilisticSampler(cfg.SampleRatio)
+	}
+}
+
+// retain force-samples span when the request failed or exceeded the latency
+// threshold, so errored and slow requests are kept even when the head sampler
+// dropped their trace
+func retain(cfg TracingConfig, span opentracing.Span, status int, elapsed time.Duration) {
+	failed := cfg.AlwaysSampleErrors && status >= 500
+	slow := cfg.SlowRequestThreshold > 0 && elapsed >= cfg.SlowRequestThreshold
+	if failed || slow {
+		ext.SamplingPriority.Set(span, 1)
+	}
+}
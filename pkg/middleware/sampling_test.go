@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestNewSampler_RespectsConfiguredRatio(t *testing.T) {
+	const ratio = 0.25
+	const traces = 20000
+
+	sampler, err := newSampler(TracingConfig{SampleRatio: ratio})
+	if err != nil {
+		t.Fatalf("newSampler: %v", err)
+	}
+	defer sampler.Close()
+
+	ids := rand.New(rand.NewSource(1))
+	sampled := 0
+	for i := 0; i < traces; i++ {
+		if ok, _ := sampler.IsSampled(jaeger.TraceID{Low: ids.Uint64()}, "GET /"); ok {
+			sampled++
+		}
+	}
+
+	if got := float64(sampled) / traces; math.Abs(got-ratio) > 0.02 {
+		t.Fatalf("sampled %.3f of traces, want about %.2f", got, ratio)
+	}
+}
+
+func TestNewSampler_BoundaryRatios(t *testing.T) {
+	id := jaeger.TraceID{Low: 42}
+
+	always, _ := newSampler(TracingConfig{SampleRatio: 1})
+	if ok, _ := always.IsSampled(id, "GET /"); !ok {
+		t.Fatal("ratio 1 should sample every trace")
+	}
+	never, _ := newSampler(TracingConfig{SampleRatio: 0})
+	if ok, _ := never.IsSampled(id, "GET /"); ok {
+		t.Fatal("ratio 0 should sample no trace")
+	}
+}
+
+func TestDefaultTracingConfig_SampleRatioByEnvironment(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	if got := DefaultTracingConfig().SampleRatio; got != DevelopmentSampleRatio {
+		t.Fatalf("development ratio: got %v, want %v", got, DevelopmentSampleRatio)
+	}
+
+	t.Setenv("APP_ENV", "production")
+	if got := DefaultTracingConfig().SampleRatio; got != ProductionSampleRatio {
+		t.Fatalf("production ratio: got %v, want %v", got, ProductionSampleRatio)
+	}
+}
+
+// tracedRouter routes requests through TracingMiddleware with a tracer whose head
+// sampler drops every trace, recording reported spans in the returned reporter
+func tracedRouter(t *testing.T, cfg TracingConfig) (*gin.Engine, *jaeger.InMemoryReporter) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	sampler, err := newSampler(cfg)
+	if err != nil {
+		t.Fatalf("newSampler: %v", err)
+	}
+	reporter := jaeger.NewInMemoryReporter()
+	tracer, closer := jaeger.NewTracer(cfg.ServiceName, sampler, reporter)
+	t.Cleanup(func() { closer.Close() })
+
+	previous := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() { opentracing.SetGlobalTracer(previous) })
+
+	router := gin.New()
+	router.Use(TracingMiddleware(cfg))
+	router.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(20 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	return router, reporter
+}
+
+func serve(router *gin.Engine, path string) {
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+func TestTracingMiddleware_ForceSamplesErroredRequests(t *testing.T) {
+	router, reporter := tracedRouter(t, TracingConfig{
+		ServiceName:        "test-service",
+		Enabled:            true,
+		SampleRatio:        0,
+		AlwaysSampleErrors: true,
+	})
+
+	serve(router, "/ok")
+	if got := reporter.SpansSubmitted(); got != 0 {
+		t.Fatalf("expected the successful request to be dropped, got %d spans", got)
+	}
+
+	serve(router, "/fail")
+	if got := reporter.SpansSubmitted(); got != 1 {
+		t.Fatalf("expected the errored request to be sampled, got %d spans", got)
+	}
+}
+
+func TestTracingMiddleware_ForceSamplesSlowRequests(t *testing.T) {
+	router, reporter := tracedRouter(t, TracingConfig{
+		ServiceName:          "test-service",
+		Enabled:              true,
+		SampleRatio:          0,
+		SlowRequestThreshold: 10 * time.Millisecond,
+	})
+
+	serve(router, "/fail")
+	if got := reporter.SpansSubmitted(); got != 0 {
+		t.Fatalf("expected errors not to be retained when disabled, got %d spans", got)
+	}
+
+	serve(router, "/slow")
+	if got := reporter.SpansSubmitted(); got != 1 {
+		t.Fatalf("expected the slow request to be sampled, got %d spans", got)
+	}
+}
@@ -1,31 +1,61 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/adil-faiyaz98/sparkfund/pkg/logger"
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/time/rate"
 )
 
+// Rate limiter key strategies
+const (
+	// RateLimitKeyIP gives each client IP its own bucket
+	RateLimitKeyIP = "ip"
+	// RateLimitKeyUser gives each authenticated user their own bucket, falling back to IP for anonymous requests
+	RateLimitKeyUser = "user"
+	// RateLimitKeyBoth applies the per-IP bucket and, for authenticated requests, the per-user bucket
+	RateLimitKeyBoth = "both"
+)
+
 // RateLimiterConfig holds configuration for the rate limiter
 type RateLimiterConfig struct {
 	Enabled  bool
 	Requests int
 	Window   time.Duration
 	Burst    int
+	// KeyStrategy is one of RateLimitKeyIP, RateLimitKeyUser or RateLimitKeyBoth.
+	// User keying reads the user ID set by the auth middleware, so the limiter must run after it.
+	KeyStrategy string
+	// AbuseThreshold is the number of rejections within one Window after which a
+	// client is reported as abusive
+	AbuseThreshold int
+	// Clock drives bucket refills; the system clock if nil
+	Clock clock.Clock
 }
 
 // DefaultRateLimiterConfig returns default rate limiter configuration
 func DefaultRateLimiterConfig() RateLimiterConfig {
 	return RateLimiterConfig{
-		Enabled:  true,
-		Requests: 60,
-		Window:   time.Minute,
-		Burst:    10,
+		Enabled:        true,
+		Requests:       60,
+		Window:         time.Minute,
+		Burst:          10,
+		KeyStrategy:    RateLimitKeyIP,
+		AbuseThreshold: 100,
 	}
 }
 
@@ -37,45 +67,215 @@ func RateLimiter(cfg RateLimiterConfig) gin.HandlerFunc {
 		}
 	}
 
-	// Create a map to store limiters for each client
-	limiters := make(map[string]*rate.Limiter)
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
 
-	return func(c *gin.Context) {
-		// Get client IP
-		clientIP := c.ClientIP()
+	// Limiters and rejection counts for each client key
+	clients := newRateLimitClients(cfg)
+
+	reject := func(c *gin.Context, keyType, key string) {
+		metrics.RecordRateLimitRejection(keyType)
+		count := clients.reject(key, clk.Now())
 
-		// Get or create rate limiter for this client
-		limiter, exists := limiters[clientIP]
-		if !exists {
-			// Create rate limiter with configured values
-			limiter = rate.NewLimiter(rate.Limit(cfg.Requests)/rate.Limit(cfg.Window.Seconds()), cfg.Burst)
-			limiters[clientIP] = limiter
+		// Report each client once per window when it crosses the abuse threshold
+		if cfg.AbuseThreshold > 0 && count == cfg.AbuseThreshold {
+			metrics.RecordRateLimitAbuse(keyType)
+			logger.Warn("Client exceeded rate limit abuse threshold",
+				logger.String("key", key),
+				logger.Int("rejections", count),
+			)
 		}
 
-		// Check if request is allowed
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, ErrorResponse{
-				Error: "Rate limit exceeded",
-			})
-			c.Abort()
-			return
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Error: "Rate limit exceeded",
+		})
+		c.Abort()
+	}
+
+	return func(c *gin.Context) {
+		ipKey := "ip:" + c.ClientIP()
+		userID := rateLimitUserID(c)
+
+		switch {
+		case cfg.KeyStrategy == RateLimitKeyUser && userID != "":
+			if key := "user:" + userID; !clients.allow(key, clk.Now()) {
+				reject(c, RateLimitKeyUser, key)
+				return
+			}
+		case cfg.KeyStrategy == RateLimitKeyBoth && userID != "":
+			if !clients.allow(ipKey, clk.Now()) {
+				reject(c, RateLimitKeyIP, ipKey)
+				return
+			}
+			if key := "user:" + userID; !clients.allow(key, clk.Now()) {
+				reject(c, RateLimitKeyUser, key)
+				return
+			}
+		default:
+			if !clients.allow(ipKey, clk.Now()) {
+				reject(c, RateLimitKeyIP, ipKey)
+				return
+			}
 		}
 
 		c.Next()
 	}
 }
 
+// rateLimitClient is the state RateLimiter keeps for one client key
+type rateLimitClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+	// rejections counts the rejections in the window starting at windowStart
+	rejections  int
+	windowStart time.Time
+}
+
+// rateLimitClients holds the state of each client key. Clients idle long
+// enough for their bucket to refill are forgotten, so the state stays bounded
+// by the number of recently active clients.
+type rateLimitClients struct {
+	cfg       RateLimiterConfig
+	idleTTL   time.Duration
+	mu        sync.Mutex
+	clients   map[string]*rateLimitClient
+	lastSweep time.Time
+}
+
+func newRateLimitClients(cfg RateLimiterConfig) *rateLimitClients {
+	// Forgetting a client is only safe once its bucket is full again and its
+	// rejection window has ended
+	idleTTL := cfg.Window
+	if cfg.Requests > 0 {
+		if refill := time.Duration(int64(cfg.Window) * int64(cfg.Burst) / int64(cfg.Requests)); refill > idleTTL {
+			idleTTL = refill
+		}
+	}
+	return &rateLimitClients{
+		cfg:     cfg,
+		idleTTL: idleTTL,
+		clients: make(map[string]*rateLimitClient),
+	}
+}
+
+// allow reports whether key may make a request at now
+func (r *rateLimitClients) allow(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.client(key, now).limiter.AllowN(now, 1)
+}
+
+// reject records a rejected request of key and returns how many of its
+// requests were rejected in the current window
+func (r *rateLimitClients) reject(key string, now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client := r.client(key, now)
+	if now.Sub(client.windowStart) >= r.cfg.Window {
+		client.windowStart = now
+		client.rejections = 0
+	}
+	client.rejections++
+	return client.rejections
+}
+
+// client returns the state of key, evicting idle clients at most once per
+// idleTTL. r.mu must be held.
+func (r *rateLimitClients) client(key string, now time.Time) *rateLimitClient {
+	if now.Sub(r.lastSweep) >= r.idleTTL {
+		for k, client := range r.clients {
+			if now.Sub(client.lastSeen) >= r.idleTTL {
+				delete(r.clients, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	client, exists := r.clients[key]
+	if !exists {
+		client = &rateLimitClient{
+			limiter: rate.NewLimiter(rate.Limit(r.cfg.Requests)/rate.Limit(r.cfg.Window.Seconds()), r.cfg.Burst),
+		}
+		r.clients[key] = client
+	}
+	client.lastSeen = now
+	return client
+}
+
+// ReloadableRateLimiter is a RateLimiter whose configuration can be replaced
+// while the service is running, e.g. after a config reload
+type ReloadableRateLimiter struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewReloadableRateLimiter creates a rate limiter with the configuration cfg
+func NewReloadableRateLimiter(cfg RateLimiterConfig) *ReloadableRateLimiter {
+	r := &ReloadableRateLimiter{}
+	r.Update(cfg)
+	return r
+}
+
+// Update replaces the limiter's configuration. Requests already being handled
+// finish under the old limits; every client starts the new limits with a full
+// bucket.
+func (r *ReloadableRateLimiter) Update(cfg RateLimiterConfig) {
+	handler := RateLimiter(cfg)
+	r.handler.Store(&handler)
+}
+
+// Handler returns the middleware, which applies the latest configuration
+func (r *ReloadableRateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		(*r.handler.Load())(c)
+	}
+}
+
+// rateLimitUserID returns the authenticated user ID set by JWTAuth or AuthMiddleware
+func rateLimitUserID(c *gin.Context) string {
+	for _, key := range []string{"userID", "user_id"} {
+		if value, exists := c.Get(key); exists && value != nil {
+			if id := fmt.Sprint(value); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
 // JWTConfig holds configuration for JWT authentication
 type JWTConfig struct {
 	Secret  string
 	Enabled bool
+	// Keys verifies tokens by their kid header, for zero-downtime key rotation;
+	// Secret is the only key if nil
+	Keys *jwtkeys.KeySet
+	// Algorithms is the allowlist of signing algorithms; jwtalg.DefaultAllowed if empty
+	Algorithms []string
+	// RefreshLeadTime is how long before expiry clients are advised to refresh;
+	// DefaultRefreshLeadTime if zero
+	RefreshLeadTime time.Duration
+	// Clock validates token expiry; the system clock if nil
+	Clock clock.Clock
+	// Denylist rejects tokens revoked before they expire, by their jti claim;
+	// revocation is not checked if nil
+	Denylist TokenDenylist
+}
+
+// TokenDenylist reports whether a token was revoked, e.g. at logout
+type TokenDenylist interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
 }
 
 // DefaultJWTConfig returns default JWT configuration
 func DefaultJWTConfig() JWTConfig {
 	return JWTConfig{
-		Secret:  "your-secret-key",
-		Enabled: true,
+		Secret:          "your-secret-key",
+		Enabled:         true,
+		Algorithms:      jwtalg.FromEnv(),
+		RefreshLeadTime: DefaultRefreshLeadTime,
 	}
 }
 
@@ -87,6 +287,16 @@ func JWTAuth(cfg JWTConfig) gin.HandlerFunc {
 		}
 	}
 
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+	options := append(jwtalg.ParserOptions(cfg.Algorithms), jwt.WithTimeFunc(clk.Now))
+	keys := cfg.Keys
+	if keys == nil {
+		keys = jwtkeys.FromSecret(cfg.Secret)
+	}
+
 	return func(c *gin.Context) {
 		// Skip auth for health endpoints
 		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/ready" || c.Request.URL.Path == "/live" || c.Request.URL.Path == "/metrics" {
@@ -107,19 +317,14 @@ func JWTAuth(cfg JWTConfig) gin.HandlerFunc {
 		// Extract the token
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("Invalid signing method")
-			}
-			return []byte(cfg.Secret), nil
-		})
+		token, err := jwt.Parse(tokenString, jwtalg.Keyfunc(cfg.Algorithms, keys.Keyfunc), options...)
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{
-				Error: "Invalid or expired token",
-			})
-			c.Abort()
+		if err != nil {
+			rejectToken(c, err)
+			return
+		}
+		if !token.Valid {
+			rejectToken(c, errors.New("token is invalid"))
 			return
 		}
 
@@ -133,9 +338,34 @@ func JWTAuth(cfg JWTConfig) gin.HandlerFunc {
 			return
 		}
 
+		// Tokens without a jti cannot be revoked and pass unchecked
+		if jti, _ := claims["jti"].(string); cfg.Denylist != nil && jti != "" {
+			revoked, err := cfg.Denylist.IsRevoked(c.Request.Context(), jti)
+			if err != nil {
+				// Fail closed: a revoked token must not slip through while Redis is down
+				c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+					Error: "Token revocation list unavailable",
+				})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, ErrorResponse{
+					Error: "Token has been revoked",
+					Code:  "revoked",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// Add user info to context
 		c.Set("userID", claims["sub"])
 		c.Set("roles", claims["roles"])
+		setTenant(c, tenant.FromClaims(claims))
+
+		expiresAt, _ := claims.GetExpirationTime()
+		setRefreshHints(c, expiresAt, clk.Now(), cfg.RefreshLeadTime)
 
 		c.Next()
 	}
@@ -187,20 +417,6 @@ func CORS(cfg CORSConfig) gin.HandlerFunc {
 	}
 }
 
-// SecurityHeaders adds security headers to responses
-func SecurityHeaders() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Add security headers
-		c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
-		c.Writer.Header().Set("X-Frame-Options", "DENY")
-		c.Writer.Header().Set("Content-Security-Policy", "default-src 'self'")
-		c.Writer.Header().Set("X-XSS-Protection", "1; mode=block")
-		c.Writer.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-
-		c.Next()
-	}
-}
-
 // CSRFProtection adds CSRF protection
 func CSRFProtection() gin.HandlerFunc {
 	return func(c *gin.Context) {
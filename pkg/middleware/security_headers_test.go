@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func securityHeadersRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(SecurityHeaders(DefaultSecurityHeadersConfig()))
+	ok := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	router.GET("/api/v1/verifications", ok)
+	router.GET("/swagger-ui.html", OverrideSecurityHeaders(SwaggerSecurityHeaders()), ok)
+	documents := router.Group("/api/v1/documents", OverrideSecurityHeaders(DownloadSecurityHeaders()))
+	documents.GET("/:id/download", ok)
+	return router
+}
+
+func getSecurityHeaders(router *gin.Engine, path string, https bool) http.Header {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if https {
+		req.Header.Set("X-Forwarded-Proto", "https")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Header()
+}
+
+func TestSecurityHeaders_RoutesGetTheirOwnPolicy(t *testing.T) {
+	router := securityHeadersRouter()
+
+	api := getSecurityHeaders(router, "/api/v1/verifications", false)
+	if got := api.Get("Content-Security-Policy"); got != StrictContentSecurityPolicy {
+		t.Fatalf("expected the strict CSP on API routes, got %q", got)
+	}
+
+	swagger := getSecurityHeaders(router, "/swagger-ui.html", false)
+	if got := swagger.Get("Content-Security-Policy"); got != SwaggerContentSecurityPolicy {
+		t.Fatalf("expected the relaxed CSP on the Swagger UI, got %q", got)
+	}
+	if got := swagger.Get("X-Frame-Options"); got != "DENY" {
+		t.Fatalf("expected the override to keep unrelated headers, got X-Frame-Options %q", got)
+	}
+
+	download := getSecurityHeaders(router, "/api/v1/documents/doc-1/download", false)
+	if got := download.Get("Content-Disposition"); got != "attachment" {
+		t.Fatalf("expected downloads to be sent as attachments, got %q", got)
+	}
+	if got := download.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("expected downloads to forbid sniffing, got %q", got)
+	}
+	if got := download.Get("Content-Security-Policy"); got != DownloadContentSecurityPolicy {
+		t.Fatalf("expected the download CSP, got %q", got)
+	}
+}
+
+func TestSecurityHeaders_HSTSOnlyOverHTTPS(t *testing.T) {
+	router := securityHeadersRouter()
+
+	if got := getSecurityHeaders(router, "/api/v1/verifications", false).Get("Strict-Transport-Security"); got != "" {
+		t.Fatalf("expected no HSTS over plain HTTP, got %q", got)
+	}
+	if got := getSecurityHeaders(router, "/api/v1/verifications", true).Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Fatalf("unexpected HSTS header %q", got)
+	}
+}
@@ -0,0 +1,421 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var testEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// rateLimitedRouter allows one request per hour per bucket, timed by clk
+func rateLimitedRouter(strategy string, clk clock.Clock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	// Stand-in for JWTAuth: the user ID comes from a header in tests
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("userID", userID)
+		}
+		c.Next()
+	})
+	router.Use(RateLimiter(RateLimiterConfig{
+		Enabled:     true,
+		Requests:    1,
+		Window:      time.Hour,
+		Burst:       1,
+		KeyStrategy: strategy,
+		Clock:       clk,
+	}))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func doRateLimitedRequest(router *gin.Engine, ip, userID string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ip + ":1234"
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimiter_UserStrategyGivesUsersIndependentBuckets(t *testing.T) {
+	router := rateLimitedRouter(RateLimitKeyUser, clock.NewFake(testEpoch))
+
+	if code := doRateLimitedRequest(router, "10.0.0.1", "alice"); code != http.StatusOK {
+		t.Fatalf("first request for alice: got %d, want %d", code, http.StatusOK)
+	}
+	if code := doRateLimitedRequest(router, "10.0.0.1", "bob"); code != http.StatusOK {
+		t.Fatalf("first request for bob from the same IP: got %d, want %d", code, http.StatusOK)
+	}
+	if code := doRateLimitedRequest(router, "10.0.0.1", "alice"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request for alice: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiter_AnonymousRequestsShareBucketByIP(t *testing.T) {
+	clk := clock.NewFake(testEpoch)
+	router := rateLimitedRouter(RateLimitKeyUser, clk)
+
+	if code := doRateLimitedRequest(router, "10.0.0.2", ""); code != http.StatusOK {
+		t.Fatalf("first anonymous request: got %d, want %d", code, http.StatusOK)
+	}
+	if code := doRateLimitedRequest(router, "10.0.0.2", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("second anonymous request from the same IP: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := doRateLimitedRequest(router, "10.0.0.3", ""); code != http.StatusOK {
+		t.Fatalf("anonymous request from another IP: got %d, want %d", code, http.StatusOK)
+	}
+
+	// The bucket refills once the window has passed
+	clk.Advance(59 * time.Minute)
+	if code := doRateLimitedRequest(router, "10.0.0.2", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("request before the window has passed: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+	clk.Advance(time.Minute)
+	if code := doRateLimitedRequest(router, "10.0.0.2", ""); code != http.StatusOK {
+		t.Fatalf("request after the window has passed: got %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRateLimiter_IPStrategyIgnoresUser(t *testing.T) {
+	router := rateLimitedRouter(RateLimitKeyIP, clock.NewFake(testEpoch))
+
+	if code := doRateLimitedRequest(router, "10.0.0.4", "alice"); code != http.StatusOK {
+		t.Fatalf("first request: got %d, want %d", code, http.StatusOK)
+	}
+	if code := doRateLimitedRequest(router, "10.0.0.4", "bob"); code != http.StatusTooManyRequests {
+		t.Fatalf("second user from the same IP: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiter_BothStrategyAppliesIPAndUserBuckets(t *testing.T) {
+	router := rateLimitedRouter(RateLimitKeyBoth, clock.NewFake(testEpoch))
+
+	if code := doRateLimitedRequest(router, "10.0.0.5", "alice"); code != http.StatusOK {
+		t.Fatalf("first request: got %d, want %d", code, http.StatusOK)
+	}
+	// The IP bucket is exhausted even though bob's user bucket is not
+	if code := doRateLimitedRequest(router, "10.0.0.5", "bob"); code != http.StatusTooManyRequests {
+		t.Fatalf("second user from the same IP: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// alice's user bucket is exhausted even from a new IP
+	if code := doRateLimitedRequest(router, "10.0.0.6", "alice"); code != http.StatusTooManyRequests {
+		t.Fatalf("alice from another IP: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestReloadableRateLimiter_AppliesNewLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := RateLimiterConfig{Enabled: true, Requests: 1, Window: time.Hour, Burst: 1, Clock: clock.NewFake(testEpoch)}
+	limiter := NewReloadableRateLimiter(cfg)
+
+	router := gin.New()
+	router.Use(limiter.Handler())
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	doRateLimitedRequest(router, "10.0.0.7", "")
+	if code := doRateLimitedRequest(router, "10.0.0.7", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("second request under the old limit: got %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	cfg.Burst = 3
+	limiter.Update(cfg)
+	for i := 0; i < 3; i++ {
+		if code := doRateLimitedRequest(router, "10.0.0.7", ""); code != http.StatusOK {
+			t.Fatalf("request %d under the new limit: got %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+
+	cfg.Enabled = false
+	limiter.Update(cfg)
+	if code := doRateLimitedRequest(router, "10.0.0.7", ""); code != http.StatusOK {
+		t.Fatalf("request with rate limiting disabled: got %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRateLimitClients_CountsRejectionsPerWindow(t *testing.T) {
+	clients := newRateLimitClients(RateLimiterConfig{Requests: 1, Window: time.Minute, Burst: 1})
+
+	if count := clients.reject("ip:10.0.0.1", testEpoch); count != 1 {
+		t.Fatalf("first rejection: got count %d, want 1", count)
+	}
+	if count := clients.reject("ip:10.0.0.1", testEpoch.Add(30*time.Second)); count != 2 {
+		t.Fatalf("second rejection in the window: got count %d, want 2", count)
+	}
+	if count := clients.reject("ip:10.0.0.1", testEpoch.Add(time.Minute)); count != 1 {
+		t.Fatalf("rejection in the next window: got count %d, want 1", count)
+	}
+}
+
+func TestRateLimitClients_EvictsIdleClients(t *testing.T) {
+	// A bucket of 10 refills in 10 minutes at one request a minute
+	clients := newRateLimitClients(RateLimiterConfig{Requests: 1, Window: time.Minute, Burst: 10})
+
+	for i := 0; i < 100; i++ {
+		clients.allow(fmt.Sprintf("ip:10.0.0.%d", i), testEpoch)
+	}
+	clients.allow("ip:10.0.0.0", testEpoch.Add(5*time.Minute))
+
+	clients.allow("ip:10.0.1.1", testEpoch.Add(9*time.Minute))
+	if got := len(clients.clients); got != 101 {
+		t.Fatalf("before the buckets refill: got %d clients, want 101", got)
+	}
+
+	clients.allow("ip:10.0.1.1", testEpoch.Add(10*time.Minute))
+	if got := len(clients.clients); got != 2 {
+		t.Fatalf("after idle buckets refill: got %d clients, want 2", got)
+	}
+}
+
+func TestJWTAuth_ScopesRequestToTokenTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := JWTConfig{Secret: "test-secret", Enabled: true}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":        "user-1",
+		tenant.Claim: "tenant-a",
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(cfg.Secret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	var tenantID string
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/", func(c *gin.Context) {
+		tenantID, _ = tenant.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusOK)
+	}
+	if tenantID != "tenant-a" {
+		t.Fatalf("expected the request to be scoped to tenant-a, got %q", tenantID)
+	}
+}
+
+// stubDenylist is a TokenDenylist holding revoked jtis in memory
+type stubDenylist struct {
+	revoked map[string]bool
+	err     error
+}
+
+func (d stubDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return d.revoked[jti], d.err
+}
+
+func TestJWTAuth_RejectsRevokedTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sign := func(jti string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "user-1",
+			"jti": jti,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte("test-secret"))
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		denylist stubDenylist
+		jti      string
+		want     int
+	}{
+		{"active token", stubDenylist{revoked: map[string]bool{"other": true}}, "active", http.StatusOK},
+		{"revoked token", stubDenylist{revoked: map[string]bool{"logged-out": true}}, "logged-out", http.StatusUnauthorized},
+		{"denylist unavailable", stubDenylist{err: errors.New("connection refused")}, "active", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(JWTAuth(JWTConfig{Secret: "test-secret", Enabled: true, Denylist: tt.denylist}))
+			router.GET("/", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+sign(tt.jti))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("got %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_AcceptsPreviousKeyDuringRotationOverlap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clk := clock.NewFake(testEpoch)
+	keys, err := jwtkeys.New(jwtkeys.Config{
+		SigningKeyID: "2025-01",
+		Keys:         []jwtkeys.Key{{ID: "2025-01", Secret: []byte("old-secret")}},
+		Clock:        clk,
+	})
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+
+	sign := func() string {
+		token, err := keys.Sign(jwt.MapClaims{"sub": "user-1", "exp": testEpoch.Add(24 * time.Hour).Unix()})
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+	old := sign()
+	if err := keys.Rotate(jwtkeys.Key{ID: "2025-02", Secret: []byte("new-secret")}, time.Hour); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	current := sign()
+
+	router := gin.New()
+	router.Use(JWTAuth(JWTConfig{Keys: keys, Enabled: true, Clock: clk}))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request(old); code != http.StatusOK {
+		t.Fatalf("previous key during overlap: got %d, want %d", code, http.StatusOK)
+	}
+	if code := request(current); code != http.StatusOK {
+		t.Fatalf("current key: got %d, want %d", code, http.StatusOK)
+	}
+
+	clk.Advance(time.Hour)
+	if code := request(old); code != http.StatusUnauthorized {
+		t.Fatalf("retired key: got %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := request(current); code != http.StatusOK {
+		t.Fatalf("current key after retirement: got %d, want %d", code, http.StatusOK)
+	}
+}
+
+// jwtFailures reads jwt_validation_failures_total for reason from the default registry
+func jwtFailures(t *testing.T, reason string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "jwt_validation_failures_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "reason" && label.GetValue() == reason {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestJWTAuth_ClassifiesRejectedTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clk := clock.NewFake(testEpoch)
+	cfg := JWTConfig{Secret: "test-secret", Enabled: true, Clock: clk}
+
+	sign := func(exp time.Time) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "user-1",
+			"exp": exp.Unix(),
+		}).SignedString([]byte(cfg.Secret))
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+	expired := sign(testEpoch.Add(-time.Minute))
+	valid := sign(testEpoch.Add(time.Hour))
+	// Re-sign the same claims with another key and keep the original header and payload
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": testEpoch.Add(time.Hour).Unix(),
+	}).SignedString([]byte("attacker-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	tampered := valid[:strings.LastIndex(valid, ".")] + forged[strings.LastIndex(forged, "."):]
+
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		reason string
+	}{
+		{"expired", expired, jwtalg.ReasonExpired},
+		{"tampered", tampered, jwtalg.ReasonSignatureInvalid},
+		{"malformed", "not-a-token", jwtalg.ReasonMalformed},
+	} {
+		before := jwtFailures(t, tc.reason)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: got %d, want %d", tc.name, w.Code, http.StatusUnauthorized)
+		}
+		var body ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tc.name, err)
+		}
+		if body.Code != tc.reason {
+			t.Fatalf("%s: code = %q, want %q", tc.name, body.Code, tc.reason)
+		}
+		if got := jwtFailures(t, tc.reason) - before; got != 1 {
+			t.Fatalf("%s: expected one %s failure to be counted, got %v", tc.name, tc.reason, got)
+		}
+	}
+}
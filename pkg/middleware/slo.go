@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Results recorded by the SLO counters
+const (
+	SLOWithinObjective = "within"
+	SLOOverObjective   = "over"
+	SLOSuccess         = "success"
+	SLOError           = "error"
+)
+
+// SLO metrics. The share of a window's requests that missed an objective,
+// divided by the error budget (1 - target), is the burn rate, e.g. for the last
+// hour:
+//
+//	sum by (slo) (rate(slo_latency_requests_total{result="over"}[1h]))
+//	  / sum by (slo) (rate(slo_latency_requests_total[1h]))
+//	  / on (slo) (1 - slo_objective_target_ratio)
+var (
+	sloLatencyRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slo_latency_requests_total",
+			Help: "Requests counted against a latency objective, by whether they were within or over it",
+		},
+		[]string{"slo", "method", "route", "result"},
+	)
+
+	sloAvailabilityRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "slo_availability_requests_total",
+			Help: "Requests counted against an availability objective, by whether they succeeded or errored",
+		},
+		[]string{"slo", "method", "route", "result"},
+	)
+
+	sloObjectiveLatency = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "slo_objective_latency_seconds",
+			Help: "Response time a request must be within to meet its latency objective",
+		},
+		[]string{"slo"},
+	)
+
+	sloObjectiveTarget = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "slo_objective_target_ratio",
+			Help: "Share of requests that should meet the objective, e.g. 0.99",
+		},
+		[]string{"slo"},
+	)
+)
+
+// SLO is a response time and availability objective for a group of routes,
+// e.g. 99% of document requests served within 300ms
+type SLO struct {
+	// Name identifies the objective in metrics, e.g. "documents"
+	Name string
+	// Routes are the route pattern prefixes the objective covers, e.g.
+	// "/api/v1/documents"; empty covers every route
+	Routes []string
+	// Latency is the response time a request must be within to meet the objective
+	Latency time.Duration
+	// Target is the share of requests that should meet the objective, e.g. 0.99
+	Target float64
+}
+
+// covers reports whether the objective applies to a route pattern
+func (s SLO) covers(route string) bool {
+	if len(s.Routes) == 0 {
+		return true
+	}
+	for _, prefix := range s.Routes {
+		if route == prefix || strings.HasPrefix(route, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SLOConfig holds configuration for SLOMiddleware
+type SLOConfig struct {
+	// Objectives are matched in order and a request counts toward the first
+	// that covers its route, so list specific route groups before a catch-all
+	Objectives []SLO
+	// IsError reports whether a response status counts against availability;
+	// server errors (5xx) if nil
+	IsError func(status int) bool
+}
+
+// DefaultSLOConfig returns a single objective covering every route: 99% of
+// requests within 300ms
+func DefaultSLOConfig() SLOConfig {
+	return SLOConfig{
+		Objectives: []SLO{{Name: "default", Latency: 300 * time.Millisecond, Target: 0.99}},
+	}
+}
+
+// ObjectiveFor returns the objective covering a route pattern, or false if none does
+func (cfg SLOConfig) ObjectiveFor(route string) (SLO, bool) {
+	for _, objective := range cfg.Objectives {
+		if objective.covers(route) {
+			return objective, true
+		}
+	}
+	return SLO{}, false
+}
+
+// SLOMiddleware counts every request against the objective for its route: as
+// within or over the latency objective, and as a success or an error for
+// availability. Requests that matched no route are not counted, as the path
+// label would be unbounded.
+func SLOMiddleware(cfg SLOConfig) gin.HandlerFunc {
+	isError := cfg.IsError
+	if isError == nil {
+		isError = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+	for _, objective := range cfg.Objectives {
+		sloObjectiveLatency.WithLabelValues(objective.Name).Set(objective.Latency.Seconds())
+		sloObjectiveTarget.WithLabelValues(objective.Name).Set(objective.Target)
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		route := c.FullPath()
+		if route == "" {
+			return
+		}
+		objective, ok := cfg.ObjectiveFor(route)
+		if !ok {
+			return
+		}
+
+		latency := SLOWithinObjective
+		if elapsed > objective.Latency {
+			latency = SLOOverObjective
+		}
+		sloLatencyRequests.WithLabelValues(objective.Name, c.Request.Method, route, latency).Inc()
+
+		availability := SLOSuccess
+		if isError(c.Writer.Status()) {
+			availability = SLOError
+		}
+		sloAvailabilityRequests.WithLabelValues(objective.Name, c.Request.Method, route, availability).Inc()
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// sloRouter serves routes that take a given time and fail when asked to
+func sloRouter(cfg SLOConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SLOMiddleware(cfg))
+
+	handle := func(c *gin.Context) {
+		if d, err := time.ParseDuration(c.Query("sleep")); err == nil {
+			time.Sleep(d)
+		}
+		status := http.StatusOK
+		switch c.Query("fail") {
+		case "server":
+			status = http.StatusInternalServerError
+		case "client":
+			status = http.StatusBadRequest
+		}
+		c.Status(status)
+	}
+	router.GET("/slo/documents/:id", handle)
+	router.GET("/slo/reports", handle)
+	return router
+}
+
+func TestSLOMiddleware_CountsRequestsOverTheObjective(t *testing.T) {
+	router := sloRouter(SLOConfig{Objectives: []SLO{
+		{Name: "slo-test-documents", Routes: []string{"/slo/documents"}, Latency: 20 * time.Millisecond, Target: 0.99},
+		{Name: "slo-test-default", Latency: time.Second, Target: 0.9},
+	}})
+
+	route := "/slo/documents/:id"
+	within := sloLatencyRequests.WithLabelValues("slo-test-documents", "GET", route, SLOWithinObjective)
+	over := sloLatencyRequests.WithLabelValues("slo-test-documents", "GET", route, SLOOverObjective)
+
+	serve(router, "/slo/documents/1")
+	serve(router, "/slo/documents/2?sleep=40ms")
+	serve(router, "/slo/documents/3?sleep=40ms")
+
+	if got := testutil.ToFloat64(within); got != 1 {
+		t.Fatalf("within = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(over); got != 2 {
+		t.Fatalf("over = %v, want 2", got)
+	}
+
+	// The same latency is within the catch-all objective
+	serve(router, "/slo/reports?sleep=40ms")
+	if got := testutil.ToFloat64(sloLatencyRequests.WithLabelValues("slo-test-default", "GET", "/slo/reports", SLOWithinObjective)); got != 1 {
+		t.Fatalf("expected the reports route to use the catch-all objective, got %v", got)
+	}
+
+	if got := testutil.ToFloat64(sloObjectiveLatency.WithLabelValues("slo-test-documents")); got != 0.02 {
+		t.Fatalf("objective latency = %v, want 0.02", got)
+	}
+	if got := testutil.ToFloat64(sloObjectiveTarget.WithLabelValues("slo-test-default")); got != 0.9 {
+		t.Fatalf("objective target = %v, want 0.9", got)
+	}
+}
+
+func TestSLOMiddleware_CountsErrorsAgainstAvailability(t *testing.T) {
+	router := sloRouter(SLOConfig{Objectives: []SLO{
+		{Name: "slo-test-availability", Latency: time.Second, Target: 0.999},
+	}})
+
+	route := "/slo/reports"
+	success := sloAvailabilityRequests.WithLabelValues("slo-test-availability", "GET", route, SLOSuccess)
+	failure := sloAvailabilityRequests.WithLabelValues("slo-test-availability", "GET", route, SLOError)
+
+	serve(router, "/slo/reports")
+	serve(router, "/slo/reports?fail=client")
+	serve(router, "/slo/reports?fail=server")
+
+	if got := testutil.ToFloat64(success); got != 2 {
+		t.Fatalf("success = %v, want 2: client errors should not count against availability", got)
+	}
+	if got := testutil.ToFloat64(failure); got != 1 {
+		t.Fatalf("error = %v, want 1", got)
+	}
+}
+
+func TestSLOMiddleware_CustomErrorClassification(t *testing.T) {
+	router := sloRouter(SLOConfig{
+		Objectives: []SLO{{Name: "slo-test-strict", Latency: time.Second, Target: 0.99}},
+		IsError:    func(status int) bool { return status >= http.StatusBadRequest },
+	})
+
+	failure := sloAvailabilityRequests.WithLabelValues("slo-test-strict", "GET", "/slo/reports", SLOError)
+	serve(router, "/slo/reports?fail=client")
+
+	if got := testutil.ToFloat64(failure); got != 1 {
+		t.Fatalf("error = %v, want 1", got)
+	}
+}
+
+func TestSLOMiddleware_SkipsUnmatchedRoutes(t *testing.T) {
+	router := sloRouter(SLOConfig{Objectives: []SLO{
+		{Name: "slo-test-unmatched", Routes: []string{"/slo/documents"}, Latency: time.Second, Target: 0.99},
+	}})
+
+	before := testutil.CollectAndCount(sloLatencyRequests)
+	serve(router, "/slo/reports")
+	serve(router, "/no/such/route")
+	if got := testutil.CollectAndCount(sloLatencyRequests); got != before {
+		t.Fatalf("expected requests outside every objective to be skipped, got %d new series", got-before)
+	}
+}
+
+func TestSLO_Covers(t *testing.T) {
+	objective := SLO{Routes: []string{"/api/v1/documents"}}
+	for route, want := range map[string]bool{
+		"/api/v1/documents":         true,
+		"/api/v1/documents/:id":     true,
+		"/api/v1/documents-archive": false,
+		"/api/v1/verifications/:id": false,
+	} {
+		if got := objective.covers(route); got != want {
+			t.Errorf("covers(%q) = %v, want %v", route, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.uber.org/zap"
+)
+
+// SlowRequestConfig holds configuration for SlowRequestLogger
+type SlowRequestConfig struct {
+	// Threshold is the latency at which a request counts as slow; zero disables
+	// the check for routes without an entry in Routes
+	Threshold time.Duration
+	// Routes overrides Threshold for individual routes, keyed by "METHOD /path"
+	// or "/path" using the route pattern, e.g. "GET /api/v1/reports/:id". A zero
+	// entry disables the check for that route.
+	Routes map[string]time.Duration
+	// Logger receives slow request entries; the shared logger if nil
+	Logger *zap.Logger
+}
+
+// DefaultSlowRequestConfig returns default slow request configuration
+func DefaultSlowRequestConfig() SlowRequestConfig {
+	return SlowRequestConfig{Threshold: DefaultSlowRequestThreshold}
+}
+
+// ThresholdFor returns the slow request threshold for a route pattern
+func (cfg SlowRequestConfig) ThresholdFor(method, route string) time.Duration {
+	if threshold, ok := cfg.Routes[method+" "+route]; ok {
+		return threshold
+	}
+	if threshold, ok := cfg.Routes[route]; ok {
+		return threshold
+	}
+	return cfg.Threshold
+}
+
+// SlowRequestLogger logs requests that take at least their route's threshold
+// and force-samples their trace, so latency outliers are captured without
+// logging every request. Register it after TracingMiddleware so the request's
+// span is available.
+func SlowRequestLogger(cfg SlowRequestConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		route := c.FullPath()
+		threshold := cfg.ThresholdFor(c.Request.Method, route)
+		if threshold <= 0 || elapsed < threshold {
+			return
+		}
+
+		if span := opentracing.SpanFromContext(c.Request.Context()); span != nil {
+			ext.SamplingPriority.Set(span, 1)
+			span.SetTag("slow_request", true)
+		}
+
+		log := cfg.Logger
+		if log == nil {
+			log = logger.GetLogger()
+		}
+		log.Warn("Slow request",
+			zap.String("method", c.Request.Method),
+			zap.String("route", route),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", elapsed),
+			zap.Duration("threshold", threshold),
+			zap.String("user_id", rateLimitUserID(c)),
+			zap.String("request_id", GetRequestID(c)),
+		)
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// slowRequestRouter traces with a head sampler that drops every trace and logs
+// slow requests into the returned observer
+func slowRequestRouter(t *testing.T, cfg SlowRequestConfig) (*gin.Engine, *jaeger.InMemoryReporter, *observer.ObservedLogs) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	reporter := jaeger.NewInMemoryReporter()
+	tracer, closer := jaeger.NewTracer("test-service", jaeger.NewConstSampler(false), reporter)
+	t.Cleanup(func() { closer.Close() })
+
+	previous := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() { opentracing.SetGlobalTracer(previous) })
+
+	core, logs := observer.New(zap.WarnLevel)
+	cfg.Logger = zap.New(core)
+
+	router := gin.New()
+	router.Use(TracingMiddleware(TracingConfig{ServiceName: "test-service", Enabled: true}))
+	router.Use(SlowRequestLogger(cfg))
+	sleep := func(d time.Duration) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("user_id", "user-7")
+			time.Sleep(d)
+			c.Status(http.StatusOK)
+		}
+	}
+	router.GET("/fast", sleep(0))
+	router.GET("/reports/:id", sleep(30*time.Millisecond))
+	router.GET("/exports/:id", sleep(30*time.Millisecond))
+	return router, reporter, logs
+}
+
+func TestSlowRequestLogger_LogsAndSamplesSlowRequests(t *testing.T) {
+	router, reporter, logs := slowRequestRouter(t, SlowRequestConfig{Threshold: 10 * time.Millisecond})
+
+	serve(router, "/fast")
+	if logs.Len() != 0 || reporter.SpansSubmitted() != 0 {
+		t.Fatalf("expected a fast request to be neither logged nor sampled, got %d logs and %d spans", logs.Len(), reporter.SpansSubmitted())
+	}
+
+	serve(router, "/reports/42")
+	if got := reporter.SpansSubmitted(); got != 1 {
+		t.Fatalf("expected the slow request's trace to be sampled, got %d spans", got)
+	}
+	entries := logs.FilterMessage("Slow request").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 slow request log, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["method"] != "GET" || fields["route"] != "/reports/:id" || fields["path"] != "/reports/42" || fields["user_id"] != "user-7" {
+		t.Fatalf("unexpected slow request fields %v", fields)
+	}
+	if latency, _ := fields["latency"].(time.Duration); latency < 30*time.Millisecond {
+		t.Fatalf("expected the logged latency to cover the handler, got %v", fields["latency"])
+	}
+}
+
+func TestSlowRequestLogger_PerRouteThresholds(t *testing.T) {
+	router, reporter, logs := slowRequestRouter(t, SlowRequestConfig{
+		Threshold: 10 * time.Millisecond,
+		Routes: map[string]time.Duration{
+			// Exports are expected to be slow
+			"GET /exports/:id": time.Second,
+			// Every fast request is slow by this route's standard
+			"/fast": time.Nanosecond,
+		},
+	})
+
+	serve(router, "/exports/1")
+	if logs.Len() != 0 || reporter.SpansSubmitted() != 0 {
+		t.Fatalf("expected the export to be within its route threshold, got %d logs and %d spans", logs.Len(), reporter.SpansSubmitted())
+	}
+
+	serve(router, "/fast")
+	if logs.Len() != 1 || reporter.SpansSubmitted() != 1 {
+		t.Fatalf("expected the route threshold to flag the request, got %d logs and %d spans", logs.Len(), reporter.SpansSubmitted())
+	}
+}
+
+func TestSlowRequestConfig_ThresholdFor(t *testing.T) {
+	cfg := SlowRequestConfig{
+		Threshold: time.Second,
+		Routes: map[string]time.Duration{
+			"POST /api/v1/reports": 10 * time.Second,
+			"/api/v1/reports":      5 * time.Second,
+			"/health":              0,
+		},
+	}
+
+	tests := []struct {
+		method, route string
+		want          time.Duration
+	}{
+		{http.MethodPost, "/api/v1/reports", 10 * time.Second},
+		{http.MethodGet, "/api/v1/reports", 5 * time.Second},
+		{http.MethodGet, "/health", 0},
+		{http.MethodGet, "/api/v1/users", time.Second},
+	}
+	for _, tt := range tests {
+		if got := cfg.ThresholdFor(tt.method, tt.route); got != tt.want {
+			t.Errorf("ThresholdFor(%s, %s) = %v, want %v", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
+
+// Requests without a route pattern, such as 404s, use the default threshold
+func TestSlowRequestLogger_UnmatchedRoute(t *testing.T) {
+	router, _, logs := slowRequestRouter(t, SlowRequestConfig{Threshold: time.Nanosecond})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if logs.Len() != 1 {
+		t.Fatalf("expected the unmatched request to be logged, got %d logs", logs.Len())
+	}
+}
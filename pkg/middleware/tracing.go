@@ -17,13 +17,24 @@ import (
 type TracingConfig struct {
 	ServiceName string
 	Enabled     bool
+	// SampleRatio is the fraction of new traces sampled at the root, from 0 to 1.
+	// Traces continued from an upstream service keep the caller's decision.
+	SampleRatio float64
+	// AlwaysSampleErrors keeps spans of requests answered with a 5xx status
+	AlwaysSampleErrors bool
+	// SlowRequestThreshold keeps spans of requests taking at least this long;
+	// zero disables latency-based retention
+	SlowRequestThreshold time.Duration
 }
 
 // DefaultTracingConfig returns default tracing configuration
 func DefaultTracingConfig() TracingConfig {
 	return TracingConfig{
-		ServiceName: "kyc-service",
-		Enabled:     true,
+		ServiceName:          "kyc-service",
+		Enabled:              true,
+		SampleRatio:          defaultSampleRatio(),
+		AlwaysSampleErrors:   true,
+		SlowRequestThreshold: DefaultSlowRequestThreshold,
 	}
 }
 
@@ -60,19 +71,23 @@ func InitTracing(cfg TracingConfig) (opentracing.Tracer, error) {
 		return opentracing.NoopTracer{}, nil
 	}
 
+	sampler, err := newSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	jaegerCfg := jaegercfg.Configuration{
 		ServiceName: cfg.ServiceName,
-		Sampler: &jaegercfg.SamplerConfig{
-			Type:  jaeger.SamplerTypeConst,
-			Param: 1,
-		},
 		Reporter: &jaegercfg.ReporterConfig{
 			LogSpans:            true,
 			BufferFlushInterval: 1 * time.Second,
 		},
 	}
 
-	tracer, _, err := jaegerCfg.NewTracer(jaegercfg.Logger(jaeger.StdLogger))
+	tracer, _, err := jaegerCfg.NewTracer(
+		jaegercfg.Logger(jaeger.StdLogger),
+		jaegercfg.Sampler(sampler),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -132,10 +147,10 @@ func TracingMiddleware(cfg TracingConfig) gin.HandlerFunc {
 		c.Next()
 
 		// Record metrics
-		duration := time.Since(start).Seconds()
+		elapsed := time.Since(start)
 		status := fmt.Sprintf("%d", c.Writer.Status())
 		RequestCounter.WithLabelValues(c.Request.Method, c.FullPath(), status).Inc()
-		RequestDuration.WithLabelValues(c.Request.Method, c.FullPath()).Observe(duration)
+		RequestDuration.WithLabelValues(c.Request.Method, c.FullPath()).Observe(elapsed.Seconds())
 
 		// Set additional span tags after response
 		ext.HTTPStatusCode.Set(span, uint16(c.Writer.Status()))
@@ -145,6 +160,7 @@ func TracingMiddleware(cfg TracingConfig) gin.HandlerFunc {
 				span.SetTag("error.message", c.Errors.Last().Error())
 			}
 		}
+		retain(cfg, span, c.Writer.Status(), elapsed)
 	}
 }
 
@@ -0,0 +1,178 @@
+// Package notify routes semantic notifications to users over email, SMS and
+// push. Callers describe what happened with a Notification; the Dispatcher
+// picks the channels from configuration and the user's preferences and hands
+// the notification to each channel's Provider.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoChannel is returned when a notification has no channel to go out on
+var ErrNoChannel = errors.New("notify: no channel for notification")
+
+// Channel is a delivery channel
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+	ChannelPush  Channel = "push"
+)
+
+// Kind names what a notification is about. Routing and preferences are keyed
+// by kind, so callers never choose channels themselves.
+type Kind string
+
+const (
+	KindVerificationCompleted Kind = "verification_completed"
+	KindTransactionAlert      Kind = "transaction_alert"
+	KindDocumentExpiry        Kind = "document_expiry"
+	KindSecurityEvent         Kind = "security_event"
+)
+
+// Recipient holds the contact details providers deliver to
+type Recipient struct {
+	UserID       string
+	Email        string
+	Phone        string
+	DeviceTokens []string
+}
+
+// Notification is a message for a single user
+type Notification struct {
+	Kind      Kind
+	Recipient Recipient
+	Subject   string
+	Body      string
+	// Data carries template variables and deep-link parameters for providers
+	Data map[string]string
+}
+
+// Notifier sends notifications. The Dispatcher implements it; services should
+// depend on Notifier so tests can substitute a fake.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Provider delivers notifications over one channel
+type Provider interface {
+	Channel() Channel
+	Send(ctx context.Context, n Notification) error
+}
+
+// Config holds routing configuration
+type Config struct {
+	// Routes lists the channels each kind is sent on. Kinds without a route use
+	// DefaultChannels.
+	Routes          map[Kind][]Channel `mapstructure:"routes"`
+	DefaultChannels []Channel          `mapstructure:"default_channels"`
+	// FallbackChannel is used when a user's preferences disable every routed
+	// channel. Empty drops such notifications with ErrNoChannel.
+	FallbackChannel Channel `mapstructure:"fallback_channel"`
+}
+
+// DefaultConfig returns routing that sends everything by email, adds SMS and
+// push for transaction alerts and security events, and falls back to email
+func DefaultConfig() Config {
+	return Config{
+		Routes: map[Kind][]Channel{
+			KindTransactionAlert: {ChannelPush, ChannelSMS, ChannelEmail},
+			KindSecurityEvent:    {ChannelSMS, ChannelEmail},
+		},
+		DefaultChannels: []Channel{ChannelEmail},
+		FallbackChannel: ChannelEmail,
+	}
+}
+
+// Dispatcher fans notifications out to the channels they are routed to and the
+// user has not disabled
+type Dispatcher struct {
+	config      Config
+	providers   map[Channel]Provider
+	preferences PreferenceStore
+}
+
+// NewDispatcher creates a dispatcher. preferences may be nil, in which case
+// every routed channel is used.
+func NewDispatcher(config Config, preferences PreferenceStore, providers ...Provider) *Dispatcher {
+	byChannel := make(map[Channel]Provider, len(providers))
+	for _, p := range providers {
+		byChannel[p.Channel()] = p
+	}
+	return &Dispatcher{
+		config:      config,
+		providers:   byChannel,
+		preferences: preferences,
+	}
+}
+
+// Notify sends n on every channel it resolves to. Delivery continues past a
+// failing channel; the errors are joined.
+func (d *Dispatcher) Notify(ctx context.Context, n Notification) error {
+	channels, err := d.Channels(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		if err := d.providers[channel].Send(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("notify: %s via %s: %w", n.Kind, channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Channels returns the channels n would be sent on: its route, less channels
+// the user has disabled or that have no provider or no contact detail
+func (d *Dispatcher) Channels(ctx context.Context, n Notification) ([]Channel, error) {
+	prefs := Preferences{}
+	if d.preferences != nil && n.Recipient.UserID != "" {
+		var err error
+		prefs, err = d.preferences.Preferences(ctx, n.Recipient.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("notify: failed to load preferences: %w", err)
+		}
+	}
+
+	route, ok := d.config.Routes[n.Kind]
+	if !ok {
+		route = d.config.DefaultChannels
+	}
+
+	var channels []Channel
+	for _, channel := range route {
+		if prefs.Allows(n.Kind, channel) && d.deliverable(n, channel) {
+			channels = append(channels, channel)
+		}
+	}
+
+	if len(channels) == 0 && d.config.FallbackChannel != "" && d.deliverable(n, d.config.FallbackChannel) {
+		channels = append(channels, d.config.FallbackChannel)
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("%w: %s to user %s", ErrNoChannel, n.Kind, n.Recipient.UserID)
+	}
+	return channels, nil
+}
+
+// deliverable reports whether channel has a provider and n has the contact
+// detail it needs
+func (d *Dispatcher) deliverable(n Notification, channel Channel) bool {
+	if _, ok := d.providers[channel]; !ok {
+		return false
+	}
+	switch channel {
+	case ChannelEmail:
+		return n.Recipient.Email != ""
+	case ChannelSMS:
+		return n.Recipient.Phone != ""
+	case ChannelPush:
+		return len(n.Recipient.DeviceTokens) > 0
+	default:
+		return true
+	}
+}
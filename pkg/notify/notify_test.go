@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+)
+
+// fakeProvider records the notifications sent through it
+type fakeProvider struct {
+	channel Channel
+	err     error
+	sent    []Notification
+}
+
+func (p *fakeProvider) Channel() Channel { return p.channel }
+
+func (p *fakeProvider) Send(ctx context.Context, n Notification) error {
+	p.sent = append(p.sent, n)
+	return p.err
+}
+
+type dispatcherFixture struct {
+	dispatcher       *Dispatcher
+	preferences      *MemoryPreferenceStore
+	email, sms, push *fakeProvider
+}
+
+func newDispatcherFixture(config Config) *dispatcherFixture {
+	f := &dispatcherFixture{
+		preferences: NewMemoryPreferenceStore(),
+		email:       &fakeProvider{channel: ChannelEmail},
+		sms:         &fakeProvider{channel: ChannelSMS},
+		push:        &fakeProvider{channel: ChannelPush},
+	}
+	f.dispatcher = NewDispatcher(config, f.preferences, f.email, f.sms, f.push)
+	return f
+}
+
+func alice(kind Kind) Notification {
+	return Notification{
+		Kind: kind,
+		Recipient: Recipient{
+			UserID:       "alice",
+			Email:        "alice@example.com",
+			Phone:        "+15550100",
+			DeviceTokens: []string{"device-1"},
+		},
+		Body: "Sign-in from a new device",
+	}
+}
+
+func TestDispatcher_SendsOnEveryRoutedChannel(t *testing.T) {
+	f := newDispatcherFixture(DefaultConfig())
+
+	if err := f.dispatcher.Notify(context.Background(), alice(KindSecurityEvent)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(f.sms.sent) != 1 || len(f.email.sent) != 1 {
+		t.Fatalf("expected SMS and email, got %d SMS and %d emails", len(f.sms.sent), len(f.email.sent))
+	}
+	if len(f.push.sent) != 0 {
+		t.Fatalf("expected no push for an unrouted channel, got %d", len(f.push.sent))
+	}
+}
+
+func TestDispatcher_RespectsDisabledChannel(t *testing.T) {
+	f := newDispatcherFixture(DefaultConfig())
+	f.preferences.Set("alice", Preferences{Disabled: []Channel{ChannelSMS}})
+
+	if err := f.dispatcher.Notify(context.Background(), alice(KindSecurityEvent)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(f.sms.sent) != 0 {
+		t.Fatalf("expected no SMS for a user who disabled it, got %d", len(f.sms.sent))
+	}
+	if len(f.email.sent) != 1 {
+		t.Fatalf("expected the notification to go by email only, got %d emails", len(f.email.sent))
+	}
+}
+
+func TestDispatcher_DisabledKindOnlyAffectsThatKind(t *testing.T) {
+	f := newDispatcherFixture(DefaultConfig())
+	f.preferences.Set("alice", Preferences{DisabledKinds: map[Kind][]Channel{KindTransactionAlert: {ChannelPush}}})
+
+	channels, err := f.dispatcher.Channels(context.Background(), alice(KindTransactionAlert))
+	if err != nil {
+		t.Fatalf("Channels failed: %v", err)
+	}
+	if want := []Channel{ChannelSMS, ChannelEmail}; !reflect.DeepEqual(channels, want) {
+		t.Fatalf("transaction alert channels = %v, want %v", channels, want)
+	}
+}
+
+func TestDispatcher_FallsBackWhenEveryRoutedChannelIsDisabled(t *testing.T) {
+	config := DefaultConfig()
+	config.Routes[KindDocumentExpiry] = []Channel{ChannelSMS}
+	f := newDispatcherFixture(config)
+	f.preferences.Set("alice", Preferences{Disabled: []Channel{ChannelSMS}})
+
+	if err := f.dispatcher.Notify(context.Background(), alice(KindDocumentExpiry)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(f.sms.sent) != 0 || len(f.email.sent) != 1 {
+		t.Fatalf("expected a fallback email only, got %d SMS and %d emails", len(f.sms.sent), len(f.email.sent))
+	}
+
+	config.FallbackChannel = ""
+	f = newDispatcherFixture(config)
+	f.preferences.Set("alice", Preferences{Disabled: []Channel{ChannelSMS}})
+	if err := f.dispatcher.Notify(context.Background(), alice(KindDocumentExpiry)); !errors.Is(err, ErrNoChannel) {
+		t.Fatalf("expected ErrNoChannel without a fallback, got %v", err)
+	}
+}
+
+func TestDispatcher_SkipsChannelsWithoutContact(t *testing.T) {
+	f := newDispatcherFixture(DefaultConfig())
+	n := alice(KindTransactionAlert)
+	n.Recipient.Phone = ""
+	n.Recipient.DeviceTokens = nil
+
+	if err := f.dispatcher.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(f.sms.sent) != 0 || len(f.push.sent) != 0 || len(f.email.sent) != 1 {
+		t.Fatalf("expected email only, got %d SMS, %d push and %d emails", len(f.sms.sent), len(f.push.sent), len(f.email.sent))
+	}
+}
+
+func TestDispatcher_ContinuesPastFailingChannel(t *testing.T) {
+	f := newDispatcherFixture(DefaultConfig())
+	f.sms.err = errors.New("gateway unavailable")
+
+	err := f.dispatcher.Notify(context.Background(), alice(KindSecurityEvent))
+	if err == nil || !errors.Is(err, f.sms.err) {
+		t.Fatalf("expected the SMS failure to be reported, got %v", err)
+	}
+	if len(f.email.sent) != 1 {
+		t.Fatalf("expected the email to be sent despite the SMS failure, got %d", len(f.email.sent))
+	}
+}
+
+func TestEmailProvider_PostsToEmailService(t *testing.T) {
+	var got message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/emails" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := NewEmailProvider(client.New(client.Config{Service: "email-service", BaseURL: server.URL}))
+	if err := provider.Send(context.Background(), alice(KindVerificationCompleted)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got.Kind != KindVerificationCompleted || !reflect.DeepEqual(got.To, []string{"alice@example.com"}) {
+		t.Fatalf("unexpected message %+v", got)
+	}
+
+	n := alice(KindVerificationCompleted)
+	n.Recipient.Email = ""
+	if err := provider.Send(context.Background(), n); !errors.Is(err, ErrNoContact) {
+		t.Fatalf("expected ErrNoContact, got %v", err)
+	}
+}
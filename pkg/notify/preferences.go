@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Preferences are a user's channel choices. The zero value allows everything.
+type Preferences struct {
+	// Disabled channels are never used for the user
+	Disabled []Channel `json:"disabled,omitempty"`
+	// DisabledKinds turns off channels for single kinds only
+	DisabledKinds map[Kind][]Channel `json:"disabled_kinds,omitempty"`
+}
+
+// Allows reports whether the preferences permit sending kind on channel
+func (p Preferences) Allows(kind Kind, channel Channel) bool {
+	for _, disabled := range p.Disabled {
+		if disabled == channel {
+			return false
+		}
+	}
+	for _, disabled := range p.DisabledKinds[kind] {
+		if disabled == channel {
+			return false
+		}
+	}
+	return true
+}
+
+// PreferenceStore looks up users' channel preferences. Users without stored
+// preferences get the zero Preferences.
+type PreferenceStore interface {
+	Preferences(ctx context.Context, userID string) (Preferences, error)
+}
+
+// MemoryPreferenceStore keeps preferences in memory. It is safe for concurrent
+// use.
+type MemoryPreferenceStore struct {
+	mu    sync.RWMutex
+	users map[string]Preferences
+}
+
+// NewMemoryPreferenceStore creates an empty preference store
+func NewMemoryPreferenceStore() *MemoryPreferenceStore {
+	return &MemoryPreferenceStore{users: make(map[string]Preferences)}
+}
+
+// Preferences implements PreferenceStore
+func (s *MemoryPreferenceStore) Preferences(ctx context.Context, userID string) (Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[userID], nil
+}
+
+// Set replaces a user's preferences
+func (s *MemoryPreferenceStore) Set(userID string, prefs Preferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = prefs
+}
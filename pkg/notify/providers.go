@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+)
+
+// ErrNoContact is returned by providers for recipients without the contact
+// detail their channel needs
+var ErrNoContact = errors.New("notify: recipient has no contact for channel")
+
+// HTTPProvider delivers notifications by posting them to a service: the email
+// service for email, or an SMS or push gateway
+type HTTPProvider struct {
+	channel Channel
+	client  *client.Client
+	path    string
+}
+
+// NewEmailProvider creates a provider that sends email through email-service
+func NewEmailProvider(c *client.Client) *HTTPProvider {
+	return &HTTPProvider{channel: ChannelEmail, client: c, path: "/api/v1/emails"}
+}
+
+// NewSMSProvider creates a provider that posts SMS messages to a gateway at path
+func NewSMSProvider(c *client.Client, path string) *HTTPProvider {
+	return &HTTPProvider{channel: ChannelSMS, client: c, path: path}
+}
+
+// NewPushProvider creates a provider that posts push notifications to a
+// gateway at path
+func NewPushProvider(c *client.Client, path string) *HTTPProvider {
+	return &HTTPProvider{channel: ChannelPush, client: c, path: path}
+}
+
+// message is the request body posted to providers
+type message struct {
+	Kind    Kind              `json:"kind"`
+	UserID  string            `json:"user_id,omitempty"`
+	To      []string          `json:"to"`
+	Subject string            `json:"subject,omitempty"`
+	Body    string            `json:"body"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Channel implements Provider
+func (p *HTTPProvider) Channel() Channel {
+	return p.channel
+}
+
+// Send implements Provider
+func (p *HTTPProvider) Send(ctx context.Context, n Notification) error {
+	var to []string
+	switch p.channel {
+	case ChannelEmail:
+		if n.Recipient.Email != "" {
+			to = []string{n.Recipient.Email}
+		}
+	case ChannelSMS:
+		if n.Recipient.Phone != "" {
+			to = []string{n.Recipient.Phone}
+		}
+	case ChannelPush:
+		to = n.Recipient.DeviceTokens
+	}
+	if len(to) == 0 {
+		return ErrNoContact
+	}
+
+	return p.client.PostJSON(ctx, "send_"+string(p.channel), p.path, message{
+		Kind:    n.Kind,
+		UserID:  n.Recipient.UserID,
+		To:      to,
+		Subject: n.Subject,
+		Body:    n.Body,
+		Data:    n.Data,
+	}, nil)
+}
@@ -0,0 +1,21 @@
+package password
+
+// commonPasswords is the built-in blocklist. It has the most common passwords
+// from public breach lists and the variants of them that satisfy typical
+// character class rules, which users reach for when a policy rejects the
+// original.
+var commonPasswords = []string{
+	"123456", "123456789", "12345678", "1234567890", "111111", "000000",
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd",
+	"qwerty", "qwerty123", "qwertyuiop", "1q2w3e4r", "1q2w3e4r5t",
+	"abc123", "iloveyou", "admin", "admin123", "welcome", "welcome1",
+	"letmein", "monkey", "dragon", "football", "baseball", "sunshine",
+	"princess", "master", "shadow", "superman", "trustno1", "changeme",
+	"Password1!", "Password123", "Password123!", "Password@123",
+	"P@ssw0rd123", "P@ssword123", "P@ssw0rd1234", "Passw0rd!",
+	"Qwerty123!", "Qwerty12345!", "Qwertyuiop1!", "Welcome123!",
+	"Welcome@123", "Admin@12345", "Admin123456!", "Letmein123!",
+	"Changeme123!", "Summer2024!", "Winter2024!", "Spring2024!",
+	"Autumn2024!", "Summer2025!", "Winter2025!", "Spring2025!",
+	"Autumn2025!", "Sparkfund1!", "Sparkfund123!",
+}
@@ -0,0 +1,170 @@
+// Package password enforces the password policy shared by the services that
+// set user passwords: a minimum length, required character classes, a
+// blocklist of common passwords and, optionally, a check against a breached
+// password corpus. Every rule a password fails is reported, so users can fix
+// them all at once.
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule identifies a policy rule
+type Rule string
+
+// Policy rules
+const (
+	RuleMinLength Rule = "min_length"
+	RuleUppercase Rule = "uppercase"
+	RuleLowercase Rule = "lowercase"
+	RuleNumber    Rule = "number"
+	RuleSpecial   Rule = "special"
+	RuleCommon    Rule = "common"
+	RuleBreached  Rule = "breached"
+)
+
+// ErrWeakPassword is matched by a *PolicyError with errors.Is
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// Violation is a rule a password failed
+type Violation struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError lists every rule a password failed
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(messages, "; "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// Has reports whether the password failed rule
+func (e *PolicyError) Has(rule Rule) bool {
+	for _, v := range e.Violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// BreachChecker reports whether a password appears in a corpus of breached
+// passwords
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// Config holds the password policy rules
+type Config struct {
+	// MinLength is counted in characters, not bytes
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireNumber    bool
+	RequireSpecial   bool
+	// Blocklist replaces the built-in list of common passwords when set.
+	// Entries are matched case-insensitively.
+	Blocklist []string
+}
+
+// DefaultConfig returns the default password policy
+func DefaultConfig() Config {
+	return Config{
+		MinLength:        12,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireNumber:    true,
+		RequireSpecial:   true,
+	}
+}
+
+// Policy validates passwords against a Config. It is safe for concurrent use.
+type Policy struct {
+	config    Config
+	blocklist map[string]bool
+	breaches  BreachChecker
+}
+
+// New creates a policy. breaches may be nil to skip the breached password check.
+func New(cfg Config, breaches BreachChecker) *Policy {
+	entries := cfg.Blocklist
+	if len(entries) == 0 {
+		entries = commonPasswords
+	}
+	blocklist := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		blocklist[strings.ToLower(entry)] = true
+	}
+	return &Policy{config: cfg, blocklist: blocklist, breaches: breaches}
+}
+
+// Validate checks password against every rule and returns a *PolicyError
+// listing the ones it fails. The breached password check is only made for a
+// password that passes the other rules, and it fails open: if the checker is
+// unavailable the password is accepted rather than blocking sign-ups.
+func (p *Policy) Validate(ctx context.Context, password string) error {
+	var violations []Violation
+	fail := func(rule Rule, message string) {
+		violations = append(violations, Violation{Rule: rule, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < p.config.MinLength {
+		fail(RuleMinLength, fmt.Sprintf("must be at least %d characters long", p.config.MinLength))
+	}
+
+	var upper, lower, number, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			number = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			special = true
+		}
+	}
+	if p.config.RequireUppercase && !upper {
+		fail(RuleUppercase, "must contain an uppercase letter")
+	}
+	if p.config.RequireLowercase && !lower {
+		fail(RuleLowercase, "must contain a lowercase letter")
+	}
+	if p.config.RequireNumber && !number {
+		fail(RuleNumber, "must contain a number")
+	}
+	if p.config.RequireSpecial && !special {
+		fail(RuleSpecial, "must contain a special character")
+	}
+	if p.blocklist[strings.ToLower(password)] {
+		fail(RuleCommon, "is too common")
+	}
+
+	if len(violations) == 0 && p.breaches != nil {
+		if breached, err := p.breaches.Breached(ctx, password); err == nil && breached {
+			fail(RuleBreached, "has appeared in a data breach")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubChecker reports the passwords in breached as breached
+type stubChecker struct {
+	breached map[string]bool
+	err      error
+	calls    int
+}
+
+func (c *stubChecker) Breached(ctx context.Context, password string) (bool, error) {
+	c.calls++
+	return c.breached[password], c.err
+}
+
+func TestValidate_RejectsWeakPassword(t *testing.T) {
+	policy := New(DefaultConfig(), nil)
+
+	err := policy.Validate(context.Background(), "password")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("expected a PolicyError, got %v", err)
+	}
+	for _, rule := range []Rule{RuleMinLength, RuleUppercase, RuleNumber, RuleSpecial, RuleCommon} {
+		if !policyErr.Has(rule) {
+			t.Errorf("expected a %s violation, got %+v", rule, policyErr.Violations)
+		}
+	}
+	if policyErr.Has(RuleLowercase) {
+		t.Errorf("did not expect a lowercase violation")
+	}
+}
+
+func TestValidate_RejectsCommonPasswordMeetingClassRules(t *testing.T) {
+	policy := New(DefaultConfig(), nil)
+
+	err := policy.Validate(context.Background(), "PASSWORD123!")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || !policyErr.Has(RuleCommon) {
+		t.Fatalf("expected a common password violation, got %v", err)
+	}
+}
+
+func TestValidate_RejectsBreachedPassword(t *testing.T) {
+	checker := &stubChecker{breached: map[string]bool{"Tr0ub4dor&3xyz": true}}
+	policy := New(DefaultConfig(), checker)
+
+	err := policy.Validate(context.Background(), "Tr0ub4dor&3xyz")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || !policyErr.Has(RuleBreached) {
+		t.Fatalf("expected a breached violation, got %v", err)
+	}
+
+	// A password failing other rules is not sent to the checker
+	checker.calls = 0
+	_ = policy.Validate(context.Background(), "short")
+	if checker.calls != 0 {
+		t.Fatalf("expected no breach check for a weak password")
+	}
+}
+
+func TestValidate_AcceptsStrongPassword(t *testing.T) {
+	checker := &stubChecker{}
+	policy := New(DefaultConfig(), checker)
+
+	if err := policy.Validate(context.Background(), "correct-Horse-battery-9"); err != nil {
+		t.Fatalf("expected the password to pass, got %v", err)
+	}
+	if checker.calls != 1 {
+		t.Fatalf("expected one breach check, got %d", checker.calls)
+	}
+
+	// An unavailable checker does not block the password
+	checker.err = errors.New("timeout")
+	if err := policy.Validate(context.Background(), "correct-Horse-battery-9"); err != nil {
+		t.Fatalf("expected the breach check to fail open, got %v", err)
+	}
+}
+
+func TestValidate_UsesConfiguredRules(t *testing.T) {
+	policy := New(Config{MinLength: 4, Blocklist: []string{"hunter2"}}, nil)
+
+	if err := policy.Validate(context.Background(), "password"); err != nil {
+		t.Fatalf("expected a configured blocklist to replace the built-in one, got %v", err)
+	}
+	var policyErr *PolicyError
+	if err := policy.Validate(context.Background(), "Hunter2"); !errors.As(err, &policyErr) || !policyErr.Has(RuleCommon) {
+		t.Fatalf("expected Hunter2 to be blocked, got %v", err)
+	}
+}
+
+func TestPwnedPasswordsChecker(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	var gotPath, gotPadding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotPadding = r.URL.Path, r.Header.Get("Add-Padding")
+		fmt.Fprint(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n")
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n")
+	}))
+	defer server.Close()
+
+	checker := NewPwnedPasswordsChecker(server.URL+"/range", server.Client())
+
+	breached, err := checker.Breached(context.Background(), "password")
+	if err != nil || !breached {
+		t.Fatalf("expected password to be breached, got %v, %v", breached, err)
+	}
+	if gotPath != "/range/5BAA6" || gotPadding != "true" {
+		t.Fatalf("expected only the hash prefix to be sent, got %s with padding %q", gotPath, gotPadding)
+	}
+
+	breached, err = checker.Breached(context.Background(), "correct-Horse-battery-9")
+	if err != nil || breached {
+		t.Fatalf("expected an unlisted password to pass, got %v, %v", breached, err)
+	}
+}
@@ -0,0 +1,73 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPwnedPasswordsURL is the Pwned Passwords range API
+const DefaultPwnedPasswordsURL = "https://api.pwnedpasswords.com/range/"
+
+// PwnedPasswordsChecker checks passwords against a Pwned Passwords style range
+// API using k-anonymity: only the first five characters of the password's
+// SHA-1 hash are sent, and the matching suffixes returned are compared
+// locally, so neither the password nor its full hash leaves the service.
+type PwnedPasswordsChecker struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewPwnedPasswordsChecker creates a checker for the range API at baseURL,
+// e.g. DefaultPwnedPasswordsURL or a self-hosted mirror. client may be nil to
+// use one with a 5 second timeout.
+func NewPwnedPasswordsChecker(baseURL string, client *http.Client) *PwnedPasswordsChecker {
+	if baseURL == "" {
+		baseURL = DefaultPwnedPasswordsURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &PwnedPasswordsChecker{baseURL: strings.TrimSuffix(baseURL, "/") + "/", client: client}
+}
+
+// Breached implements BreachChecker
+func (c *PwnedPasswordsChecker) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// Padding hides how many suffixes share the prefix from anyone watching
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("password: breach check failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("password: breach check returned %s", resp.Status)
+	}
+
+	// Each line is SUFFIX:COUNT; padding entries have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("password: breach check failed: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,327 @@
+// Package rates provides currency exchange rates shared across services. Rates
+// are fetched from an external source on a schedule, cached in memory and in
+// Redis, and served stale, with their age, while the source is unavailable.
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/sony/gobreaker"
+)
+
+var (
+	// ErrUnknownCurrency is returned for a currency the rates do not cover
+	ErrUnknownCurrency = errors.New("rates: unknown currency")
+	// ErrUnavailable is returned when no rates have ever been fetched or cached
+	ErrUnavailable = errors.New("rates: exchange rates unavailable")
+)
+
+// Rates is a snapshot of exchange rates against a base currency
+type Rates struct {
+	Base string `json:"base"`
+	// Rates holds units of each currency per one unit of Base
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+}
+
+// Quote is the rate for converting one currency to another
+type Quote struct {
+	From string
+	To   string
+	Rate float64
+	// AsOf is when the underlying rates were fetched
+	AsOf time.Time
+	// Stale is set when the rates are older than the refresh interval because
+	// the source could not be reached
+	Stale bool
+}
+
+// Convert converts amount of From into To
+func (q Quote) Convert(amount float64) float64 {
+	return amount * q.Rate
+}
+
+// Source fetches current rates from an external provider
+type Source interface {
+	Fetch(ctx context.Context, base string) (map[string]float64, error)
+}
+
+// Store shares fetched rates between replicas
+type Store interface {
+	// Load returns the stored rates for base, or nil if there are none
+	Load(ctx context.Context, base string) (*Rates, error)
+	Save(ctx context.Context, rates *Rates) error
+}
+
+// Config holds rates provider configuration
+type Config struct {
+	// Base is the currency rates are fetched against
+	Base string
+	// RefreshInterval is how often rates are refetched; older rates are stale
+	RefreshInterval time.Duration
+	// FetchTimeout bounds a single fetch from the source
+	FetchTimeout time.Duration
+	// BreakerFailures is how many consecutive failed fetches open the breaker
+	BreakerFailures uint32
+	// BreakerOpenTimeout is how long the breaker stays open before a trial fetch
+	BreakerOpenTimeout time.Duration
+	// Clock defaults to the real clock
+	Clock clock.Clock
+}
+
+// DefaultConfig returns default rates provider configuration
+func DefaultConfig() Config {
+	return Config{
+		Base:               "USD",
+		RefreshInterval:    15 * time.Minute,
+		FetchTimeout:       5 * time.Second,
+		BreakerFailures:    3,
+		BreakerOpenTimeout: time.Minute,
+	}
+}
+
+// Provider serves exchange rates from its cache, refreshing them from the source
+// behind a circuit breaker. When the source fails the last known rates are
+// served, marked stale.
+type Provider struct {
+	source  Source
+	store   Store
+	config  Config
+	clock   clock.Clock
+	breaker *gobreaker.CircuitBreaker
+
+	mu      sync.RWMutex
+	current *Rates
+
+	// refreshMu lets one caller at a time refresh the rates
+	refreshMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewProvider creates a rates provider. store may be nil to cache in memory only.
+func NewProvider(source Source, store Store, cfg Config) *Provider {
+	defaults := DefaultConfig()
+	if cfg.Base == "" {
+		cfg.Base = defaults.Base
+	}
+	cfg.Base = strings.ToUpper(cfg.Base)
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaults.RefreshInterval
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = defaults.FetchTimeout
+	}
+	if cfg.BreakerFailures == 0 {
+		cfg.BreakerFailures = defaults.BreakerFailures
+	}
+	if cfg.BreakerOpenTimeout <= 0 {
+		cfg.BreakerOpenTimeout = defaults.BreakerOpenTimeout
+	}
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
+	return &Provider{
+		source: source,
+		store:  store,
+		config: cfg,
+		clock:  clk,
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "rates",
+			Timeout: cfg.BreakerOpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= cfg.BreakerFailures
+			},
+		}),
+	}
+}
+
+// Start refreshes the rates now and then every RefreshInterval until Stop is called
+func (p *Provider) Start(ctx context.Context) {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	if err := p.Refresh(ctx); err != nil {
+		log.Printf("Initial exchange rate refresh failed: %v", err)
+	}
+
+	ticker := p.clock.NewTicker(p.config.RefreshInterval)
+	go func() {
+		defer close(p.done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				if err := p.Refresh(ctx); err != nil {
+					log.Printf("Exchange rate refresh failed: %v", err)
+				}
+			case <-p.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends scheduled refreshes
+func (p *Provider) Stop() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop = nil
+}
+
+// Refresh fetches rates from the source and caches them. While the breaker is
+// open it fails immediately without calling the source.
+func (p *Provider) Refresh(ctx context.Context) error {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	return p.refresh(ctx)
+}
+
+func (p *Provider) refresh(ctx context.Context) error {
+	result, err := p.breaker.Execute(func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(ctx, p.config.FetchTimeout)
+		defer cancel()
+		return p.source.Fetch(fetchCtx, p.config.Base)
+	})
+	if err != nil {
+		return fmt.Errorf("rates: refresh failed: %w", err)
+	}
+
+	rates := &Rates{
+		Base:      p.config.Base,
+		Rates:     normalize(result.(map[string]float64)),
+		FetchedAt: p.clock.Now(),
+	}
+	rates.Rates[p.config.Base] = 1
+	p.set(rates)
+
+	if p.store != nil {
+		if err := p.store.Save(ctx, rates); err != nil {
+			log.Printf("Failed to share exchange rates: %v", err)
+		}
+	}
+	return nil
+}
+
+// Rates returns the current rates. Stale rates are refreshed first, preferring
+// rates another replica already stored; if that fails the stale rates are returned.
+func (p *Provider) Rates(ctx context.Context) (*Rates, error) {
+	if rates := p.get(); rates != nil && !p.stale(rates) {
+		return rates, nil
+	}
+
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	// Another caller may have refreshed while this one waited
+	rates := p.get()
+	if rates != nil && !p.stale(rates) {
+		return rates, nil
+	}
+
+	if stored := p.load(ctx); stored != nil && (rates == nil || stored.FetchedAt.After(rates.FetchedAt)) {
+		p.set(stored)
+		rates = stored
+		if !p.stale(rates) {
+			return rates, nil
+		}
+	}
+
+	if err := p.refresh(ctx); err != nil {
+		if rates == nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		return rates, nil
+	}
+	return p.get(), nil
+}
+
+// Quote returns the rate for converting from into to
+func (p *Provider) Quote(ctx context.Context, from, to string) (Quote, error) {
+	rates, err := p.Rates(ctx)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	fromRate, ok := rates.Rates[from]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	toRate, ok := rates.Rates[to]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+
+	return Quote{
+		From:  from,
+		To:    to,
+		Rate:  toRate / fromRate,
+		AsOf:  rates.FetchedAt,
+		Stale: p.stale(rates),
+	}, nil
+}
+
+// Convert converts amount from one currency to another
+func (p *Provider) Convert(ctx context.Context, amount float64, from, to string) (float64, Quote, error) {
+	quote, err := p.Quote(ctx, from, to)
+	if err != nil {
+		return 0, Quote{}, err
+	}
+	return quote.Convert(amount), quote, nil
+}
+
+func (p *Provider) get() *Rates {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current
+}
+
+func (p *Provider) set(rates *Rates) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = rates
+}
+
+// load reads shared rates, treating a store failure like a miss
+func (p *Provider) load(ctx context.Context) *Rates {
+	if p.store == nil {
+		return nil
+	}
+	rates, err := p.store.Load(ctx, p.config.Base)
+	if err != nil {
+		log.Printf("Failed to load shared exchange rates: %v", err)
+		return nil
+	}
+	return rates
+}
+
+func (p *Provider) stale(rates *Rates) bool {
+	return p.clock.Since(rates.FetchedAt) >= p.config.RefreshInterval
+}
+
+// normalize upper-cases currency codes and drops unusable rates
+func normalize(rates map[string]float64) map[string]float64 {
+	normalized := make(map[string]float64, len(rates)+1)
+	for currency, rate := range rates {
+		if rate > 0 {
+			normalized[strings.ToUpper(currency)] = rate
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,290 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+)
+
+var epoch = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeSource returns fixed rates, or err when set, and counts fetches
+type fakeSource struct {
+	mu      sync.Mutex
+	rates   map[string]float64
+	err     error
+	fetches int
+}
+
+func (s *fakeSource) Fetch(ctx context.Context, base string) (map[string]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	if s.err != nil {
+		return nil, s.err
+	}
+	copied := make(map[string]float64, len(s.rates))
+	for currency, rate := range s.rates {
+		copied[currency] = rate
+	}
+	return copied, nil
+}
+
+func (s *fakeSource) set(rates map[string]float64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates, s.err = rates, err
+}
+
+func (s *fakeSource) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+// memoryStore stands in for Redis
+type memoryStore struct {
+	mu    sync.Mutex
+	rates map[string]*Rates
+}
+
+func (s *memoryStore) Load(ctx context.Context, base string) (*Rates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rates[base], nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, rates *Rates) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rates == nil {
+		s.rates = make(map[string]*Rates)
+	}
+	s.rates[rates.Base] = rates
+	return nil
+}
+
+func testConfig(clk clock.Clock) Config {
+	return Config{
+		Base:               "usd",
+		RefreshInterval:    10 * time.Minute,
+		FetchTimeout:       time.Second,
+		BreakerFailures:    2,
+		BreakerOpenTimeout: 5 * time.Minute,
+		Clock:              clk,
+	}
+}
+
+func newTestProvider(source Source, store Store, clk clock.Clock) *Provider {
+	return NewProvider(source, store, testConfig(clk))
+}
+
+func assertRate(t *testing.T, quote Quote, want float64) {
+	t.Helper()
+	if math.Abs(quote.Rate-want) > 1e-9 {
+		t.Fatalf("%s->%s rate = %v, want %v", quote.From, quote.To, quote.Rate, want)
+	}
+}
+
+func TestProvider_CacheHit(t *testing.T) {
+	source := &fakeSource{rates: map[string]float64{"EUR": 0.5, "gbp": 0.25}}
+	clk := clock.NewFake(epoch)
+	provider := newTestProvider(source, nil, clk)
+
+	quote, err := provider.Quote(context.Background(), "usd", "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRate(t, quote, 0.5)
+
+	// Cross rates go through the base, and repeated lookups stay in memory
+	clk.Advance(9 * time.Minute)
+	quote, err = provider.Quote(context.Background(), "EUR", "GBP")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRate(t, quote, 0.5)
+	if quote.Stale || !quote.AsOf.Equal(epoch) {
+		t.Fatalf("expected fresh rates as of %v, got %+v", epoch, quote)
+	}
+	if source.count() != 1 {
+		t.Fatalf("expected a single fetch, got %d", source.count())
+	}
+
+	amount, _, err := provider.Convert(context.Background(), 100, "GBP", "USD")
+	if err != nil || math.Abs(amount-400) > 1e-9 {
+		t.Fatalf("Convert(100 GBP) = %v, %v; want 400 USD", amount, err)
+	}
+}
+
+func TestProvider_RefreshesWhenStale(t *testing.T) {
+	source := &fakeSource{rates: map[string]float64{"EUR": 0.5}}
+	clk := clock.NewFake(epoch)
+	provider := newTestProvider(source, nil, clk)
+
+	if _, err := provider.Quote(context.Background(), "USD", "EUR"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	source.set(map[string]float64{"EUR": 0.8}, nil)
+	clk.Advance(10 * time.Minute)
+
+	quote, err := provider.Quote(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRate(t, quote, 0.8)
+	if quote.Stale || !quote.AsOf.Equal(clk.Now()) {
+		t.Fatalf("expected freshly fetched rates, got %+v", quote)
+	}
+	if source.count() != 2 {
+		t.Fatalf("expected a refetch once stale, got %d fetches", source.count())
+	}
+}
+
+func TestProvider_ScheduledRefresh(t *testing.T) {
+	source := &fakeSource{rates: map[string]float64{"EUR": 0.5}}
+	clk := clock.NewFake(epoch)
+	provider := newTestProvider(source, nil, clk)
+
+	provider.Start(context.Background())
+	defer provider.Stop()
+	if source.count() != 1 {
+		t.Fatalf("expected Start to fetch immediately, got %d fetches", source.count())
+	}
+
+	source.set(map[string]float64{"EUR": 0.9}, nil)
+	clk.Advance(10 * time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for source.count() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the scheduled refresh to fetch again")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	deadline = time.Now().Add(time.Second)
+	for {
+		if rates := provider.get(); rates != nil && rates.Rates["EUR"] == 0.9 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the scheduled refresh to replace the cached rates")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestProvider_BreakerOpenServesStaleRates(t *testing.T) {
+	source := &fakeSource{rates: map[string]float64{"EUR": 0.5}}
+	clk := clock.NewFake(epoch)
+	config := testConfig(clk)
+	// The breaker keeps real time
+	config.BreakerOpenTimeout = 50 * time.Millisecond
+	provider := NewProvider(source, nil, config)
+
+	if _, err := provider.Quote(context.Background(), "USD", "EUR"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	source.set(nil, errors.New("rates API down"))
+	clk.Advance(15 * time.Minute)
+
+	// Each failure falls back to the last known rates until the breaker opens
+	for i := 0; i < 5; i++ {
+		quote, err := provider.Quote(context.Background(), "USD", "EUR")
+		if err != nil {
+			t.Fatalf("expected stale rates while the source is down, got %v", err)
+		}
+		assertRate(t, quote, 0.5)
+		if !quote.Stale || !quote.AsOf.Equal(epoch) {
+			t.Fatalf("expected stale rates as of %v, got %+v", epoch, quote)
+		}
+	}
+	if source.count() != 3 {
+		t.Fatalf("expected the open breaker to stop fetches after 2 failures, got %d fetches", source.count())
+	}
+
+	// Once the breaker lets a trial through, recovered rates replace the stale ones
+	source.set(map[string]float64{"EUR": 0.6}, nil)
+	time.Sleep(2 * config.BreakerOpenTimeout)
+	quote, err := provider.Quote(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRate(t, quote, 0.6)
+	if quote.Stale {
+		t.Fatal("expected fresh rates after recovery")
+	}
+}
+
+func TestProvider_SharedStore(t *testing.T) {
+	store := &memoryStore{}
+	clk := clock.NewFake(epoch)
+
+	first := newTestProvider(&fakeSource{rates: map[string]float64{"EUR": 0.5}}, store, clk)
+	if err := first.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A replica whose source is down uses the rates another replica stored
+	down := &fakeSource{err: errors.New("rates API down")}
+	second := newTestProvider(down, store, clk)
+	quote, err := second.Quote(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("expected shared rates, got %v", err)
+	}
+	assertRate(t, quote, 0.5)
+	if down.count() != 0 {
+		t.Fatalf("expected fresh shared rates to avoid a fetch, got %d fetches", down.count())
+	}
+}
+
+func TestProvider_UnknownCurrency(t *testing.T) {
+	provider := newTestProvider(&fakeSource{rates: map[string]float64{"EUR": 0.5, "XXX": 0}}, nil, clock.NewFake(epoch))
+
+	for _, pair := range [][2]string{{"USD", "JPY"}, {"JPY", "USD"}, {"USD", "XXX"}} {
+		if _, err := provider.Quote(context.Background(), pair[0], pair[1]); !errors.Is(err, ErrUnknownCurrency) {
+			t.Fatalf("Quote(%s, %s) error = %v, want ErrUnknownCurrency", pair[0], pair[1], err)
+		}
+	}
+}
+
+func TestProvider_UnavailableWithoutRates(t *testing.T) {
+	provider := newTestProvider(&fakeSource{err: errors.New("rates API down")}, &memoryStore{}, clock.NewFake(epoch))
+
+	if _, err := provider.Quote(context.Background(), "USD", "EUR"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestHTTPSource_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("base") != "USD" || r.URL.Query().Get("key") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base":"USD","rates":{"EUR":0.92,"GBP":0.79}}`))
+	}))
+	defer server.Close()
+
+	rates, err := NewHTTPSource(server.URL+"?key=secret", nil).Fetch(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rates["EUR"] != 0.92 || rates["GBP"] != 0.79 {
+		t.Fatalf("unexpected rates: %v", rates)
+	}
+
+	if _, err := NewHTTPSource(server.URL, nil).Fetch(context.Background(), "USD"); err == nil {
+		t.Fatal("expected an error status to fail the fetch")
+	}
+}
@@ -0,0 +1,63 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// HTTPSource fetches rates from a JSON API answering GET <url>?base=USD with
+// {"rates": {"EUR": 0.92, ...}}
+type HTTPSource struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSource creates a source for the rates API at rawURL. A nil client uses
+// http.DefaultClient; the provider bounds each fetch with its FetchTimeout.
+func NewHTTPSource(rawURL string, client *http.Client) *HTTPSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSource{url: rawURL, client: client}
+}
+
+// Fetch implements Source
+func (s *HTTPSource) Fetch(ctx context.Context, base string) (map[string]float64, error) {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("base", base)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates API returned %s", resp.Status)
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode rates: %w", err)
+	}
+	if len(body.Rates) == 0 {
+		return nil, fmt.Errorf("rates API returned no rates for %s", base)
+	}
+	return body.Rates, nil
+}
@@ -0,0 +1,51 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore shares rates between replicas through Redis, so only one of them
+// needs to reach the source each refresh interval
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a Redis-backed store. Stored rates expire after ttl,
+// which should comfortably exceed the refresh interval so replicas can still
+// fall back to them during a source outage.
+func NewRedisStore(client *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: "rates:", ttl: ttl}
+}
+
+// Load implements Store
+func (s *RedisStore) Load(ctx context.Context, base string) (*Rates, error) {
+	data, err := s.client.Get(ctx, s.prefix+base).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rates Rates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, err
+	}
+	return &rates, nil
+}
+
+// Save implements Store
+func (s *RedisStore) Save(ctx context.Context, rates *Rates) error {
+	data, err := json.Marshal(rates)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+rates.Base, data, s.ttl).Err()
+}
@@ -0,0 +1,161 @@
+// Package redisclient builds Redis clients from shared, configurable pool and
+// timeout options, so every service tunes its connections the same way and
+// exports the same pool metrics.
+package redisclient
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// Options holds Redis connection and pool configuration. Zero fields take the
+// value from DefaultOptions.
+type Options struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+
+	// PoolSize caps the connections the client keeps open. Pipelines and
+	// transactions hold one connection each until they complete.
+	PoolSize int `mapstructure:"pool_size"`
+	// MinIdleConns keeps warm connections ready for bursts
+	MinIdleConns int `mapstructure:"min_idle_conns"`
+	// MaxRetries is how many times a failed command is retried; -1 disables retries
+	MaxRetries int `mapstructure:"max_retries"`
+
+	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	// PoolTimeout is how long a command waits for a free connection when the
+	// pool is exhausted
+	PoolTimeout time.Duration `mapstructure:"pool_timeout"`
+	// IdleTimeout closes connections idle for longer
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+}
+
+// DefaultOptions returns pool settings sized for rate limiting, locking and
+// caching: enough connections for concurrent short commands, and timeouts short
+// enough that a slow Redis fails requests fast rather than stalling them
+func DefaultOptions() Options {
+	return Options{
+		Addr:         "localhost:6379",
+		PoolSize:     50,
+		MinIdleConns: 10,
+		MaxRetries:   3,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+		PoolTimeout:  4 * time.Second,
+		IdleTimeout:  5 * time.Minute,
+	}
+}
+
+// withDefaults fills zero fields from DefaultOptions
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.Addr == "" {
+		o.Addr = defaults.Addr
+	}
+	if o.PoolSize <= 0 {
+		o.PoolSize = defaults.PoolSize
+	}
+	if o.MinIdleConns <= 0 {
+		o.MinIdleConns = defaults.MinIdleConns
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = defaults.MaxRetries
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = defaults.DialTimeout
+	}
+	if o.ReadTimeout <= 0 {
+		o.ReadTimeout = defaults.ReadTimeout
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = defaults.WriteTimeout
+	}
+	if o.PoolTimeout <= 0 {
+		o.PoolTimeout = defaults.PoolTimeout
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = defaults.IdleTimeout
+	}
+	return o
+}
+
+// RedisOptions converts the options to go-redis options
+func (o Options) RedisOptions() *redis.Options {
+	o = o.withDefaults()
+	return &redis.Options{
+		Addr:            o.Addr,
+		Password:        o.Password,
+		DB:              o.DB,
+		PoolSize:        o.PoolSize,
+		MinIdleConns:    o.MinIdleConns,
+		MaxRetries:      o.MaxRetries,
+		DialTimeout:     o.DialTimeout,
+		ReadTimeout:     o.ReadTimeout,
+		WriteTimeout:    o.WriteTimeout,
+		PoolTimeout:     o.PoolTimeout,
+		ConnMaxIdleTime: o.IdleTimeout,
+	}
+}
+
+// New creates a Redis client. It does not connect until the first command.
+func New(opts Options) *redis.Client {
+	return redis.NewClient(opts.RedisOptions())
+}
+
+// PoolStatsCollector exports a client's connection pool statistics. Register
+// one per client; name distinguishes clients in the "client" label.
+type PoolStatsCollector struct {
+	client *redis.Client
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// NewPoolStatsCollector creates a collector for client's pool statistics
+func NewPoolStatsCollector(name string, client *redis.Client) *PoolStatsCollector {
+	labels := prometheus.Labels{"client": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("redis_pool_"+metric, help, nil, labels)
+	}
+
+	return &PoolStatsCollector{
+		client:     client,
+		hits:       desc("hits_total", "Times a free connection was found in the pool"),
+		misses:     desc("misses_total", "Times a free connection was not found in the pool"),
+		timeouts:   desc("timeouts_total", "Times a command timed out waiting for a connection"),
+		totalConns: desc("total_connections", "Connections in the pool"),
+		idleConns:  desc("idle_connections", "Idle connections in the pool"),
+		staleConns: desc("stale_connections_total", "Stale connections removed from the pool"),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *PoolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+// Collect implements prometheus.Collector
+func (c *PoolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -0,0 +1,94 @@
+package redisclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNew_AppliesConfiguredOptions(t *testing.T) {
+	client := New(Options{
+		Addr:         "redis:6380",
+		DB:           2,
+		PoolSize:     7,
+		MinIdleConns: 3,
+		MaxRetries:   -1,
+		DialTimeout:  time.Second,
+		ReadTimeout:  250 * time.Millisecond,
+		WriteTimeout: 500 * time.Millisecond,
+		PoolTimeout:  2 * time.Second,
+		IdleTimeout:  time.Minute,
+	})
+	defer client.Close()
+
+	opts := client.Options()
+	if opts.Addr != "redis:6380" || opts.DB != 2 {
+		t.Fatalf("unexpected address %s/%d", opts.Addr, opts.DB)
+	}
+	if opts.PoolSize != 7 || opts.MinIdleConns != 3 {
+		t.Fatalf("pool size = %d, min idle = %d, want 7 and 3", opts.PoolSize, opts.MinIdleConns)
+	}
+	// go-redis normalizes -1 to 0 retries once the client is built
+	if opts.MaxRetries != 0 {
+		t.Fatalf("max retries = %d, want retries disabled", opts.MaxRetries)
+	}
+	if opts.DialTimeout != time.Second || opts.ReadTimeout != 250*time.Millisecond || opts.WriteTimeout != 500*time.Millisecond {
+		t.Fatalf("unexpected timeouts: dial %v, read %v, write %v", opts.DialTimeout, opts.ReadTimeout, opts.WriteTimeout)
+	}
+	if opts.PoolTimeout != 2*time.Second || opts.ConnMaxIdleTime != time.Minute {
+		t.Fatalf("pool timeout = %v, idle timeout = %v", opts.PoolTimeout, opts.ConnMaxIdleTime)
+	}
+}
+
+func TestNew_ZeroOptionsUseDefaults(t *testing.T) {
+	client := New(Options{Addr: "redis:6379"})
+	defer client.Close()
+
+	defaults := DefaultOptions()
+	opts := client.Options()
+	if opts.PoolSize != defaults.PoolSize || opts.MinIdleConns != defaults.MinIdleConns {
+		t.Fatalf("pool size = %d, min idle = %d, want defaults", opts.PoolSize, opts.MinIdleConns)
+	}
+	if opts.MaxRetries != defaults.MaxRetries || opts.ReadTimeout != defaults.ReadTimeout {
+		t.Fatalf("max retries = %d, read timeout = %v, want defaults", opts.MaxRetries, opts.ReadTimeout)
+	}
+}
+
+func TestPoolStatsCollector_ExportsPoolStats(t *testing.T) {
+	client := New(Options{Addr: "redis:6379"})
+	defer client.Close()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPoolStatsCollector("cache", client))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	want := map[string]bool{
+		"redis_pool_hits_total":              false,
+		"redis_pool_misses_total":            false,
+		"redis_pool_timeouts_total":          false,
+		"redis_pool_total_connections":       false,
+		"redis_pool_idle_connections":        false,
+		"redis_pool_stale_connections_total": false,
+	}
+	for _, family := range families {
+		if _, ok := want[family.GetName()]; !ok {
+			continue
+		}
+		want[family.GetName()] = true
+
+		label := family.GetMetric()[0].GetLabel()[0]
+		if label.GetName() != "client" || label.GetValue() != "cache" {
+			t.Fatalf("%s: expected a client=cache label, got %s=%s", family.GetName(), label.GetName(), label.GetValue())
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Errorf("expected %s to be exported", name)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrBudgetExhausted is returned, wrapping the last error, when a retry is
+// refused because the policy's budget has run out
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Retry budget metrics, labelled by budget name
+var (
+	budgetRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retry_budget_retries_total",
+			Help: "Total number of retries allowed or rejected by a retry budget",
+		},
+		[]string{"budget", "result"},
+	)
+
+	budgetTokens = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "retry_budget_tokens",
+			Help: "Retries currently available in a retry budget",
+		},
+		[]string{"budget"},
+	)
+)
+
+// BudgetConfig configures a retry budget
+type BudgetConfig struct {
+	// Name labels the budget's metrics, e.g. the target service
+	Name string `mapstructure:"name"`
+	// Ratio is the number of retries earned by each successful attempt, e.g.
+	// 0.1 allows retries to add at most 10% to the load on the target
+	Ratio float64 `mapstructure:"ratio"`
+	// Burst caps the retries that can be banked, and is what a new budget starts
+	// with. It bounds the retries made once successes stop entirely.
+	Burst int `mapstructure:"burst"`
+}
+
+// DefaultBudgetConfig returns a budget of 10% retries with a burst of 10
+func DefaultBudgetConfig() BudgetConfig {
+	return BudgetConfig{
+		Ratio: 0.1,
+		Burst: 10,
+	}
+}
+
+// Budget limits retries to a fraction of successful attempts. Uncoordinated
+// retries multiply load on a struggling dependency; sharing one budget between
+// every policy calling it means retries are throttled once errors dominate,
+// instead of turning an outage into a retry storm.
+//
+// A nil *Budget allows every retry.
+type Budget struct {
+	name     string
+	ratio    float64
+	capacity float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewBudget creates a retry budget. Zero config fields use DefaultBudgetConfig.
+func NewBudget(cfg BudgetConfig) *Budget {
+	defaults := DefaultBudgetConfig()
+	if cfg.Ratio <= 0 {
+		cfg.Ratio = defaults.Ratio
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = defaults.Burst
+	}
+	if cfg.Name == "" {
+		cfg.Name = "default"
+	}
+
+	b := &Budget{
+		name:     cfg.Name,
+		ratio:    cfg.Ratio,
+		capacity: float64(cfg.Burst),
+		tokens:   float64(cfg.Burst),
+	}
+	budgetTokens.WithLabelValues(b.name).Set(b.tokens)
+	return b
+}
+
+// Success records a successful attempt, earning Ratio of a retry
+func (b *Budget) Success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	tokens := b.tokens
+	b.mu.Unlock()
+
+	budgetTokens.WithLabelValues(b.name).Set(tokens)
+}
+
+// Allow reports whether a retry may be made, spending one retry if so
+func (b *Budget) Allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	tokens := b.tokens
+	b.mu.Unlock()
+
+	budgetTokens.WithLabelValues(b.name).Set(tokens)
+	if allowed {
+		budgetRetriesTotal.WithLabelValues(b.name, "allowed").Inc()
+	} else {
+		budgetRetriesTotal.WithLabelValues(b.name, "rejected").Inc()
+	}
+	return allowed
+}
+
+// Tokens returns the number of retries currently available
+func (b *Budget) Tokens() float64 {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBudget_CapsRetriesUnderSustainedFailure(t *testing.T) {
+	budget := NewBudget(BudgetConfig{Name: "sustained-failure", Ratio: 0.1, Burst: 5})
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Microsecond, Budget: budget}
+	rejected := budgetRetriesTotal.WithLabelValues("sustained-failure", "rejected")
+	allowed := budgetRetriesTotal.WithLabelValues("sustained-failure", "allowed")
+	rejectedBefore, allowedBefore := testutil.ToFloat64(rejected), testutil.ToFloat64(allowed)
+
+	calls := 0
+	exhausted := 0
+	for i := 0; i < 100; i++ {
+		err := policy.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errTemporary
+		})
+		if !errors.Is(err, errTemporary) {
+			t.Fatalf("expected the last error, got %v", err)
+		}
+		if errors.Is(err, ErrBudgetExhausted) {
+			exhausted++
+		}
+	}
+
+	// Without a budget this would be 300 calls; with no successes only the
+	// initial burst of retries is spent
+	if retries := calls - 100; retries != 5 {
+		t.Fatalf("expected retries to be capped at the burst of 5, got %d", retries)
+	}
+	if exhausted < 95 {
+		t.Fatalf("expected later operations to report ErrBudgetExhausted, got %d", exhausted)
+	}
+	if got := testutil.ToFloat64(rejected) - rejectedBefore; got != float64(exhausted) {
+		t.Fatalf("expected %d rejections to be counted, got %v", exhausted, got)
+	}
+	if got := testutil.ToFloat64(allowed) - allowedBefore; got != 5 {
+		t.Fatalf("expected 5 allowed retries to be counted, got %v", got)
+	}
+}
+
+func TestBudget_RetriesBoundedByRatioOfSuccesses(t *testing.T) {
+	budget := NewBudget(BudgetConfig{Name: "partial-failure", Ratio: 0.1, Burst: 5})
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Microsecond, Budget: budget}
+
+	// Half of all operations fail outright, the rest succeed first time
+	retries := 0
+	for i := 0; i < 1000; i++ {
+		failing := i%2 == 0
+		attempt := 0
+		policy.Do(context.Background(), func(ctx context.Context) error {
+			attempt++
+			if failing {
+				return errTemporary
+			}
+			return nil
+		})
+		retries += attempt - 1
+	}
+
+	// 500 successes earn 50 retries on top of the burst
+	if retries > 55 {
+		t.Fatalf("expected at most 55 retries, got %d", retries)
+	}
+	if retries < 50 {
+		t.Fatalf("expected earned retries to be spent, got %d", retries)
+	}
+}
+
+func TestBudget_RefillsOnSuccessUpToBurst(t *testing.T) {
+	budget := NewBudget(BudgetConfig{Ratio: 0.5, Burst: 2})
+
+	if !budget.Allow() || !budget.Allow() {
+		t.Fatal("expected a new budget to allow its burst")
+	}
+	if budget.Allow() {
+		t.Fatal("expected an empty budget to refuse a retry")
+	}
+
+	budget.Success()
+	budget.Success()
+	if !budget.Allow() {
+		t.Fatal("expected two successes at ratio 0.5 to earn a retry")
+	}
+
+	for i := 0; i < 100; i++ {
+		budget.Success()
+	}
+	if got := budget.Tokens(); got != 2 {
+		t.Fatalf("expected tokens to be capped at the burst, got %v", got)
+	}
+}
+
+func TestBudget_NilAllowsEveryRetry(t *testing.T) {
+	policy := Policy{MaxAttempts: 4, BaseDelay: time.Microsecond}
+
+	calls := 0
+	policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errTemporary
+	})
+	if calls != 4 {
+		t.Fatalf("expected MaxAttempts attempts without a budget, got %d", calls)
+	}
+}
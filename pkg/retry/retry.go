@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Strategy determines how the delay between attempts grows
+type Strategy string
+
+const (
+	// StrategyFixed waits BaseDelay between every attempt
+	StrategyFixed Strategy = "fixed"
+	// StrategyExponential doubles the delay after every attempt, up to MaxDelay
+	StrategyExponential Strategy = "exponential"
+)
+
+// Policy configures how an operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	MaxDelay time.Duration
+	// Strategy is fixed or exponential; exponential is used when empty
+	Strategy Strategy
+	// Jitter randomizes each delay by up to this fraction in either direction,
+	// e.g. 0.2 spreads a 1s delay over 0.8s–1.2s. Jitter keeps clients that
+	// failed together from retrying together.
+	Jitter float64
+	// Retryable reports whether err is worth retrying. All errors are retried when nil.
+	Retryable func(err error) bool
+	// Budget, if set, limits retries to a fraction of successful attempts. Share
+	// one budget between every policy calling the same dependency.
+	Budget *Budget
+}
+
+// DefaultPolicy returns a policy of 3 exponential attempts starting at 100ms
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Strategy:    StrategyExponential,
+		Jitter:      0.2,
+	}
+}
+
+var (
+	randMu sync.Mutex
+	random = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Backoff returns the delay before retry number attempt (1 for the first retry), without jitter
+func (p Policy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := p.BaseDelay
+	if p.Strategy != StrategyFixed {
+		for i := 1; i < attempt; i++ {
+			delay *= 2
+			if p.MaxDelay > 0 && delay >= p.MaxDelay {
+				break
+			}
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// delay returns the jittered delay before retry number attempt
+func (p Policy) delay(attempt int) time.Duration {
+	delay := p.Backoff(attempt)
+	if p.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+
+	randMu.Lock()
+	offset := (random.Float64()*2 - 1) * p.Jitter
+	randMu.Unlock()
+
+	delay = time.Duration(float64(delay) * (1 + offset))
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error or MaxAttempts is
+// reached, waiting between attempts according to the policy. It returns the last
+// error from fn on exhaustion. If the budget refuses a retry, Do returns the last
+// error wrapped with ErrBudgetExhausted. If ctx is done while waiting, Do stops
+// and returns an error wrapping ctx.Err().
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			p.Budget.Success()
+			return nil
+		}
+		if attempt >= attempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return err
+		}
+		if !p.Budget.Allow() {
+			return fmt.Errorf("retry: %w after %d attempts: %w", ErrBudgetExhausted, attempt, err)
+		}
+
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry: %w after %d attempts: %v", ctx.Err(), attempt, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// Do calls fn with DefaultPolicy
+func Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return DefaultPolicy().Do(ctx, fn)
+}
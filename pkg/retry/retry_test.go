@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTemporary = errors.New("temporary failure")
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTemporary
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestDo_ReturnsLastErrorOnExhaustion(t *testing.T) {
+	policy := Policy{MaxAttempts: 4, BaseDelay: time.Millisecond}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 4 {
+			return errTemporary
+		}
+		return errors.New("earlier failure")
+	})
+	if !errors.Is(err, errTemporary) {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("expected MaxAttempts attempts, got %d", calls)
+	}
+}
+
+func TestDo_NonRetryableShortCircuits(t *testing.T) {
+	errInvalid := errors.New("invalid request")
+	policy := Policy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, errInvalid)
+		},
+	}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errInvalid
+	})
+	if !errors.Is(err, errInvalid) {
+		t.Fatalf("expected errInvalid, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestDo_StopsWhenContextIsCancelled(t *testing.T) {
+	policy := Policy{MaxAttempts: 10, BaseDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := policy.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return errTemporary
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no attempts after cancellation, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Do did not return promptly after cancellation: %v", elapsed)
+	}
+}
+
+func TestBackoff_Exponential(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Strategy: StrategyExponential}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, want := range expected {
+		if got := policy.Backoff(i + 1); got != want {
+			t.Fatalf("retry %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestBackoff_Fixed(t *testing.T) {
+	policy := Policy{BaseDelay: 250 * time.Millisecond, MaxDelay: time.Second, Strategy: StrategyFixed}
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if got := policy.Backoff(attempt); got != 250*time.Millisecond {
+			t.Fatalf("retry %d: expected a fixed delay, got %v", attempt, got)
+		}
+	}
+}
+
+func TestDelay_JitterStaysInBounds(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: time.Minute, Strategy: StrategyFixed, Jitter: 0.2}
+
+	varied := false
+	for i := 0; i < 200; i++ {
+		delay := policy.delay(1)
+		if delay < 800*time.Millisecond || delay > 1200*time.Millisecond {
+			t.Fatalf("jittered delay %v outside ±20%% of 1s", delay)
+		}
+		if delay != time.Second {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Fatal("expected jitter to vary the delay")
+	}
+}
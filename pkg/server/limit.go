@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConnections is how many connections a service holds open at once
+const DefaultMaxConnections = 1000
+
+// rejectWriteTimeout bounds writing the rejection to a connection over the limit
+const rejectWriteTimeout = time.Second
+
+// rejectResponse is written to connections over the limit before they are closed
+var rejectResponse = []byte("HTTP/1.1 503 Service Unavailable\r\n" +
+	"Connection: close\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Length: 20\r\n" +
+	"\r\n" +
+	"too many connections")
+
+// MaxConnectionsFromEnv reads the connection limit from SERVER_MAX_CONNECTIONS,
+// falling back to DefaultMaxConnections. Zero or a negative value disables the limit.
+func MaxConnectionsFromEnv() int {
+	if value := os.Getenv("SERVER_MAX_CONNECTIONS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return DefaultMaxConnections
+}
+
+// LimitListener returns a listener that holds at most max connections open at
+// once. Unlike waiting in Accept, connections over the limit are answered with
+// a 503 and closed straight away, so clients fail fast instead of queueing in
+// the kernel backlog. A max of zero or less returns ln unchanged.
+func LimitListener(ln net.Listener, max int) net.Listener {
+	if max <= 0 {
+		return ln
+	}
+	return &limitListener{Listener: ln, slots: make(chan struct{}, max)}
+}
+
+// limitListener is a net.Listener with a bounded number of open connections
+type limitListener struct {
+	net.Listener
+	slots chan struct{}
+}
+
+// Accept waits for the next connection that fits under the limit
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case l.slots <- struct{}{}:
+			return &limitConn{Conn: conn, release: func() { <-l.slots }}, nil
+		default:
+			go reject(conn)
+		}
+	}
+}
+
+// reject tells a connection over the limit to come back later and closes it
+func reject(conn net.Conn) {
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+	conn.Write(rejectResponse)
+}
+
+// limitConn frees its slot in the listener when closed
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the connection and frees its slot
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
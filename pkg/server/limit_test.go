@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveLimited serves handler on a loopback listener capped at max connections
+func serveLimited(t *testing.T, max int, timeouts Timeouts, handler http.HandlerFunc) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go Serve(ctx, New("", handler, timeouts), LimitListener(ln, max), ShutdownConfig{DrainTimeout: time.Second})
+
+	return ln.Addr().String()
+}
+
+// get sends a GET over a fresh connection and returns the status line
+func get(t *testing.T, addr string) string {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the response: %v", err)
+	}
+	return strings.TrimSpace(status)
+}
+
+func TestLimitListener_RejectsConnectionsOverTheLimit(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	addr := serveLimited(t, 1, DefaultTimeouts(), func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write([]byte("ok"))
+	})
+
+	// Hold the only slot with an in-flight request
+	held := make(chan error, 1)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	go func() {
+		resp, err := client.Get("http://" + addr)
+		if err == nil {
+			resp.Body.Close()
+		}
+		held <- err
+	}()
+	<-started
+
+	if status := get(t, addr); !strings.Contains(status, "503") {
+		t.Fatalf("expected a connection over the limit to get a 503, got %q", status)
+	}
+
+	close(release)
+	if err := <-held; err != nil {
+		t.Fatalf("expected the held request to complete, got %v", err)
+	}
+
+	// The slot is free again once the first connection closes
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := get(t, addr)
+		if strings.Contains(status, "200") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the freed slot to be reused, got %q", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLimitListener_SlowHeaderClientGivesUpItsSlot(t *testing.T) {
+	timeouts := DefaultTimeouts()
+	timeouts.ReadHeader = 100 * time.Millisecond
+	addr := serveLimited(t, 1, timeouts, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Take the only slot and never finish the headers
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("expected the server to close the slow connection, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("slow client held its slot for %v", elapsed)
+	}
+
+	if status := get(t, addr); !strings.Contains(status, "200") {
+		t.Fatalf("expected the slot to be free after the slow client was cut off, got %q", status)
+	}
+}
+
+func TestLimitListener_ZeroDisablesTheLimit(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	if LimitListener(ln, 0) != ln {
+		t.Fatal("expected a zero limit to return the listener unchanged")
+	}
+}
+
+func TestMaxConnectionsFromEnv(t *testing.T) {
+	t.Setenv("SERVER_MAX_CONNECTIONS", "250")
+	if got := MaxConnectionsFromEnv(); got != 250 {
+		t.Fatalf("expected 250, got %d", got)
+	}
+
+	t.Setenv("SERVER_MAX_CONNECTIONS", "invalid")
+	if got := MaxConnectionsFromEnv(); got != DefaultMaxConnections {
+		t.Fatalf("expected the default limit, got %d", got)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is how long in-flight requests are given to finish on shutdown
+const DefaultShutdownTimeout = 10 * time.Second
+
+// ShutdownTimeoutFromEnv reads the drain timeout from SHUTDOWN_TIMEOUT (e.g. "30s"),
+// falling back to DefaultShutdownTimeout
+func ShutdownTimeoutFromEnv() time.Duration {
+	return durationFromEnv("SHUTDOWN_TIMEOUT", DefaultShutdownTimeout)
+}
+
+// Timeouts bounds how long a client may take to send a request and receive a
+// response, so slow clients cannot hold connections open indefinitely
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// DefaultTimeouts returns the timeouts the gin services use
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		ReadHeader: 5 * time.Second,
+		Read:       5 * time.Second,
+		Write:      10 * time.Second,
+		Idle:       120 * time.Second,
+	}
+}
+
+// TimeoutsFromEnv reads SERVER_READ_HEADER_TIMEOUT, SERVER_READ_TIMEOUT,
+// SERVER_WRITE_TIMEOUT and SERVER_IDLE_TIMEOUT (e.g. "15s"), falling back to
+// DefaultTimeouts for any that are unset or invalid
+func TimeoutsFromEnv() Timeouts {
+	timeouts := DefaultTimeouts()
+	timeouts.ReadHeader = durationFromEnv("SERVER_READ_HEADER_TIMEOUT", timeouts.ReadHeader)
+	timeouts.Read = durationFromEnv("SERVER_READ_TIMEOUT", timeouts.Read)
+	timeouts.Write = durationFromEnv("SERVER_WRITE_TIMEOUT", timeouts.Write)
+	timeouts.Idle = durationFromEnv("SERVER_IDLE_TIMEOUT", timeouts.Idle)
+	return timeouts
+}
+
+// New creates an http.Server for handler with the given timeouts
+func New(addr string, handler http.Handler, timeouts Timeouts) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+}
+
+// durationFromEnv parses a positive duration from the environment variable key
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// ListenAndServe runs srv until SIGINT or SIGTERM, then shuts it down gracefully
+// as described by Shutdown. Open connections are capped by MaxConnectionsFromEnv.
+func ListenAndServe(srv *http.Server, shutdown ShutdownConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, srv, LimitListener(ln, MaxConnectionsFromEnv()), shutdown)
+}
+
+// Serve runs srv on ln until ctx is cancelled, then shuts it down gracefully
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, shutdown ShutdownConfig) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		// The server stopped on its own, e.g. the listener failed
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	if err := Shutdown(srv, shutdown); err != nil {
+		return err
+	}
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
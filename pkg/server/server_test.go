@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServe_CompletesInFlightRequestOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- Serve(ctx, srv, ln, ShutdownConfig{DrainTimeout: 5 * time.Second})
+	}()
+
+	type response struct {
+		body string
+		err  error
+	}
+	respCh := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			respCh <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		respCh <- response{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	// Shutdown must wait for the in-flight request
+	select {
+	case err := <-serveErr:
+		t.Fatalf("server stopped before the in-flight request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	resp := <-respCh
+	if resp.err != nil || resp.body != "done" {
+		t.Fatalf("expected in-flight request to complete, got body %q err %v", resp.body, resp.err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestShutdownTimeoutFromEnv(t *testing.T) {
+	t.Setenv("SHUTDOWN_TIMEOUT", "45s")
+	if got := ShutdownTimeoutFromEnv(); got != 45*time.Second {
+		t.Fatalf("expected 45s, got %v", got)
+	}
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "invalid")
+	if got := ShutdownTimeoutFromEnv(); got != DefaultShutdownTimeout {
+		t.Fatalf("expected default timeout, got %v", got)
+	}
+}
+
+func TestNew_CutsOffSlowHeaderClient(t *testing.T) {
+	timeouts := DefaultTimeouts()
+	timeouts.ReadHeader = 100 * time.Millisecond
+	srv := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), timeouts)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Serve(ctx, srv, ln, ShutdownConfig{DrainTimeout: time.Second})
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Send an incomplete request and never finish the headers
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("expected the server to close the connection, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("slow client was held for %v", elapsed)
+	}
+}
+
+func TestTimeoutsFromEnv(t *testing.T) {
+	t.Setenv("SERVER_READ_HEADER_TIMEOUT", "2s")
+	t.Setenv("SERVER_WRITE_TIMEOUT", "invalid")
+
+	timeouts := TimeoutsFromEnv()
+	if timeouts.ReadHeader != 2*time.Second {
+		t.Fatalf("expected 2s read header timeout, got %v", timeouts.ReadHeader)
+	}
+	if timeouts.Write != DefaultTimeouts().Write {
+		t.Fatalf("expected default write timeout, got %v", timeouts.Write)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Readiness is told when the service stops being ready to receive traffic, so
+// its readiness probe can fail and load balancers stop routing to it
+type Readiness interface {
+	SetReady(ready bool)
+}
+
+// ReadinessFlag is a Readiness that readiness probes can consult. The zero value is ready.
+type ReadinessFlag struct {
+	notReady atomic.Bool
+}
+
+// SetReady implements Readiness
+func (f *ReadinessFlag) SetReady(ready bool) {
+	f.notReady.Store(!ready)
+}
+
+// Ready reports whether the service should receive traffic
+func (f *ReadinessFlag) Ready() bool {
+	return !f.notReady.Load()
+}
+
+// ServeHTTP serves a readiness probe: 200 while ready, 503 once shutdown begins
+func (f *ReadinessFlag) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !f.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"not ready"}`))
+		return
+	}
+	w.Write([]byte(`{"status":"ready"}`))
+}
+
+// ShutdownConfig controls the graceful shutdown sequence
+type ShutdownConfig struct {
+	// PreStopDelay is how long to keep serving after readiness flips to not-ready,
+	// giving load balancers time to deregister the instance. Zero skips the wait.
+	PreStopDelay time.Duration
+	// DrainTimeout is how long in-flight requests are given to finish
+	DrainTimeout time.Duration
+	// Readiness, if set, is flipped to not-ready when shutdown begins
+	Readiness Readiness
+}
+
+// ShutdownConfigFromEnv reads the drain timeout from SHUTDOWN_TIMEOUT and the
+// pre-stop delay from SHUTDOWN_PRE_STOP_DELAY (e.g. "5s"). readiness must back
+// the service's readiness probe, or load balancers keep routing to it during
+// the pre-stop delay.
+func ShutdownConfigFromEnv(readiness Readiness) ShutdownConfig {
+	return ShutdownConfig{
+		PreStopDelay: durationFromEnv("SHUTDOWN_PRE_STOP_DELAY", 0),
+		DrainTimeout: ShutdownTimeoutFromEnv(),
+		Readiness:    readiness,
+	}
+}
+
+// Shutdown stops srv gracefully: it flips readiness to not-ready, waits out the
+// pre-stop delay while still serving, then shuts srv down, giving in-flight
+// requests up to the drain timeout to complete
+func Shutdown(srv *http.Server, cfg ShutdownConfig) error {
+	if cfg.Readiness != nil {
+		cfg.Readiness.SetReady(false)
+	}
+
+	if cfg.PreStopDelay > 0 {
+		time.Sleep(cfg.PreStopDelay)
+	}
+
+	drainTimeout := cfg.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = DefaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	return srv.Shutdown(ctx)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReadiness records readiness changes alongside server events
+type fakeReadiness struct {
+	mu     sync.Mutex
+	events []string
+	times  []time.Time
+}
+
+func (f *fakeReadiness) record(event string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+	f.times = append(f.times, time.Now())
+}
+
+func (f *fakeReadiness) SetReady(ready bool) {
+	if ready {
+		f.record("ready")
+		return
+	}
+	f.record("not-ready")
+}
+
+// closeRecordingListener records when the server stops accepting connections
+type closeRecordingListener struct {
+	net.Listener
+	once      sync.Once
+	readiness *fakeReadiness
+}
+
+func (l *closeRecordingListener) Close() error {
+	l.once.Do(func() { l.readiness.record("shutdown") })
+	return l.Listener.Close()
+}
+
+func TestShutdown_FlipsReadinessThenWaitsThenShutsDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	readiness := &fakeReadiness{}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	go srv.Serve(&closeRecordingListener{Listener: ln, readiness: readiness})
+
+	const preStop = 100 * time.Millisecond
+	done := make(chan error, 1)
+	go func() {
+		done <- Shutdown(srv, ShutdownConfig{
+			PreStopDelay: preStop,
+			DrainTimeout: time.Second,
+			Readiness:    readiness,
+		})
+	}()
+
+	// The server keeps accepting requests during the pre-stop delay
+	time.Sleep(preStop / 4)
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("expected requests to be served during the pre-stop delay: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	readiness.mu.Lock()
+	defer readiness.mu.Unlock()
+
+	if len(readiness.events) != 2 || readiness.events[0] != "not-ready" || readiness.events[1] != "shutdown" {
+		t.Fatalf("expected not-ready then shutdown, got %v", readiness.events)
+	}
+	if waited := readiness.times[1].Sub(readiness.times[0]); waited < preStop {
+		t.Fatalf("expected shutdown to wait out the pre-stop delay, waited %v", waited)
+	}
+}
+
+func TestServe_ReadinessProbeFailsDuringDrain(t *testing.T) {
+	t.Setenv("SHUTDOWN_PRE_STOP_DELAY", "300ms")
+
+	var readiness ReadinessFlag
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.Handle("/ready", &readiness)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	srv := &http.Server{Handler: mux}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	base := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- Serve(ctx, srv, ln, ShutdownConfigFromEnv(&readiness))
+	}()
+
+	probe := func() int {
+		t.Helper()
+		resp, err := http.Get(base + "/ready")
+		if err != nil {
+			t.Fatalf("readiness probe: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := probe(); code != http.StatusOK {
+		t.Fatalf("before shutdown: got %d, want %d", code, http.StatusOK)
+	}
+
+	// Keep a request in flight while the server drains
+	go func() {
+		if resp, err := http.Get(base + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	time.Sleep(50 * time.Millisecond)
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Fatalf("during the drain: got %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	if err := <-serveErr; err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestReadinessFlag(t *testing.T) {
+	var flag ReadinessFlag
+	if !flag.Ready() {
+		t.Fatal("expected the zero value to be ready")
+	}
+
+	flag.SetReady(false)
+	if flag.Ready() {
+		t.Fatal("expected the flag to be not ready")
+	}
+}
@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// flushEvery is how many elements are written between flushes of an http.ResponseWriter
+const flushEvery = 100
+
+// ErrClosed is returned when writing to an ArrayEncoder that has been closed
+var ErrClosed = errors.New("stream: array encoder is closed")
+
+// ArrayEncoder writes a JSON array one element at a time, so large result sets
+// never have to be held in memory. The output is only valid JSON once Close is called.
+type ArrayEncoder struct {
+	w       io.Writer
+	enc     *json.Encoder
+	flusher http.Flusher
+	count   int
+	closed  bool
+}
+
+// NewArrayEncoder creates an encoder that writes a JSON array to w. If w is an
+// http.ResponseWriter that supports flushing, elements are flushed as they are written.
+func NewArrayEncoder(w io.Writer) *ArrayEncoder {
+	e := &ArrayEncoder{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		e.flusher = flusher
+	}
+	return e
+}
+
+// Encode writes v as the next array element
+func (e *ArrayEncoder) Encode(v interface{}) error {
+	if e.closed {
+		return ErrClosed
+	}
+
+	sep := ","
+	if e.count == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+
+	e.count++
+	if e.flusher != nil && e.count%flushEvery == 0 {
+		e.flusher.Flush()
+	}
+	return nil
+}
+
+// Count returns the number of elements written so far
+func (e *ArrayEncoder) Count() int {
+	return e.count
+}
+
+// Close terminates the array. An encoder with no elements writes "[]".
+func (e *ArrayEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	closing := "]\n"
+	if e.count == 0 {
+		closing = "[]\n"
+	}
+	if _, err := io.WriteString(e.w, closing); err != nil {
+		return err
+	}
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+	return nil
+}
+
+// EncodeRows streams every row of a database cursor to w as a JSON array. scan
+// converts the current row into the value to encode. rows is always closed.
+func EncodeRows(w io.Writer, rows *sql.Rows, scan func(*sql.Rows) (interface{}, error)) error {
+	defer rows.Close()
+
+	enc := NewArrayEncoder(w)
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// WriteHeader prepares w for a streamed JSON array response. Once streaming has
+// started the status code can no longer change, so errors part-way through can
+// only be reported by terminating the connection.
+func WriteHeader(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+}
@@ -0,0 +1,133 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Payload string `json:"payload"`
+}
+
+func TestArrayEncoder_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewArrayEncoder(&buf)
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var out []record
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if out == nil || len(out) != 0 {
+		t.Fatalf("expected an empty array, got %v", out)
+	}
+}
+
+func TestArrayEncoder_WritesValidArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteHeader(w, 200)
+
+	enc := NewArrayEncoder(w)
+	for i := 0; i < 3; i++ {
+		if err := enc.Encode(record{ID: i, Name: "r"}); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := enc.Encode(record{}); err != ErrClosed {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+	if !w.Flushed {
+		t.Fatal("expected the response to be flushed")
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+
+	var out []record
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(out) != 3 || out[2].ID != 2 {
+		t.Fatalf("unexpected output %v", out)
+	}
+}
+
+func TestArrayEncoder_LargeResultSetUsesBoundedMemory(t *testing.T) {
+	const total = 200000
+	payload := strings.Repeat("x", 200)
+
+	// Decode the stream as it is produced so the full output is never held in memory
+	pr, pw := io.Pipe()
+	decoded := make(chan error, 1)
+	count := 0
+	go func() {
+		dec := json.NewDecoder(pr)
+		if _, err := dec.Token(); err != nil {
+			decoded <- err
+			return
+		}
+		for dec.More() {
+			var r record
+			if err := dec.Decode(&r); err != nil {
+				decoded <- err
+				return
+			}
+			if r.ID != count {
+				decoded <- io.ErrUnexpectedEOF
+				return
+			}
+			count++
+		}
+		_, err := dec.Token()
+		decoded <- err
+	}()
+
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	baseline := stats.HeapAlloc
+	peak := baseline
+
+	enc := NewArrayEncoder(pw)
+	for i := 0; i < total; i++ {
+		if err := enc.Encode(record{ID: i, Name: "record", Payload: payload}); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		if i%10000 == 0 {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	pw.Close()
+
+	if err := <-decoded; err != nil {
+		t.Fatalf("stream is not valid JSON: %v", err)
+	}
+	if count != total {
+		t.Fatalf("decoded %d elements, want %d", count, total)
+	}
+
+	// The output is ~45MB; the heap must not grow with it
+	const limit = 16 << 20
+	if growth := peak - baseline; peak > baseline && growth > limit {
+		t.Fatalf("heap grew by %d bytes while streaming, want at most %d", growth, limit)
+	}
+}
@@ -0,0 +1,55 @@
+// Package tenant carries the caller's tenant through request contexts so data
+// access can be isolated per partner tenant
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Claim is the JWT claim holding the caller's tenant ID
+const Claim = "tenant_id"
+
+// ErrMissingTenant is returned when tenant-scoped data is accessed without a tenant in the context
+var ErrMissingTenant = errors.New("tenant: no tenant in context")
+
+type contextKey struct{}
+
+type unscopedKey struct{}
+
+// WithTenant returns a copy of ctx carrying tenant id
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant set by WithTenant
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithoutScope marks ctx as belonging to a system task, such as a migration or
+// backfill, that legitimately spans tenants. It must never be derived from a
+// request context.
+func WithoutScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// IsUnscoped reports whether ctx was marked by WithoutScope
+func IsUnscoped(ctx context.Context) bool {
+	unscoped, _ := ctx.Value(unscopedKey{}).(bool)
+	return unscoped
+}
+
+// FromClaims returns the tenant ID from decoded JWT claims, or "" if it is absent
+func FromClaims(claims map[string]interface{}) string {
+	switch id := claims[Claim].(type) {
+	case string:
+		return id
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(id)
+	}
+}
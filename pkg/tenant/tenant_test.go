@@ -0,0 +1,29 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("expected no tenant in an empty context")
+	}
+	if _, ok := FromContext(WithTenant(context.Background(), "")); ok {
+		t.Fatal("expected an empty tenant ID to count as missing")
+	}
+
+	id, ok := FromContext(WithTenant(context.Background(), "acme"))
+	if !ok || id != "acme" {
+		t.Fatalf("expected tenant acme, got %q (%v)", id, ok)
+	}
+}
+
+func TestFromClaims(t *testing.T) {
+	if id := FromClaims(map[string]interface{}{Claim: "acme"}); id != "acme" {
+		t.Fatalf("expected acme, got %q", id)
+	}
+	if id := FromClaims(map[string]interface{}{"sub": "user-1"}); id != "" {
+		t.Fatalf("expected no tenant, got %q", id)
+	}
+}
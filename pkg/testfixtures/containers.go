@@ -0,0 +1,130 @@
+package testfixtures
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Environment variables pointing integration tests at existing servers instead
+// of containers, e.g. in CI with service containers
+const (
+	PostgresDSNEnvVar = "SPARKFUND_TEST_POSTGRES_DSN"
+	RedisAddrEnvVar   = "SPARKFUND_TEST_REDIS_ADDR"
+)
+
+// Container images started when no server is configured
+const (
+	PostgresImage = "postgres:15-alpine"
+	RedisImage    = "redis:7-alpine"
+)
+
+// startupTimeout bounds how long a container may take to accept connections
+const startupTimeout = 30 * time.Second
+
+// Postgres returns a connection to the Postgres at PostgresDSNEnvVar or, when
+// unset, to a throwaway container removed when the test ends. The test is
+// skipped when neither is available.
+func Postgres(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(PostgresDSNEnvVar)
+	if dsn == "" {
+		addr := startContainer(t, PostgresImage, "5432/tcp",
+			"POSTGRES_USER=fixtures", "POSTGRES_PASSWORD=fixtures", "POSTGRES_DB=fixtures")
+		host, port, _ := strings.Cut(addr, ":")
+		dsn = fmt.Sprintf("host=%s port=%s user=fixtures password=fixtures dbname=fixtures sslmode=disable", host, port)
+	}
+
+	var db *gorm.DB
+	err := waitFor(func() error {
+		var err error
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			return err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Ping()
+	})
+	if err != nil {
+		t.Fatalf("testfixtures: postgres did not become ready: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// Redis returns a client for the Redis at RedisAddrEnvVar or, when unset, a
+// throwaway container removed when the test ends. The test is skipped when
+// neither is available.
+func Redis(t testing.TB) *redis.Client {
+	t.Helper()
+	addr := os.Getenv(RedisAddrEnvVar)
+	if addr == "" {
+		addr = startContainer(t, RedisImage, "6379/tcp")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := waitFor(func() error { return client.Ping(context.Background()).Err() }); err != nil {
+		t.Fatalf("testfixtures: redis did not become ready: %v", err)
+	}
+	return client
+}
+
+// startContainer runs image with its port published on a random host port and
+// returns that host:port. The container is removed when the test ends; the test
+// is skipped when docker is not available.
+func startContainer(t testing.TB, image, port string, env ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skipf("testfixtures: docker not available and no server configured for %s", image)
+	}
+
+	args := []string{"run", "-d", "--rm", "-p", "127.0.0.1::" + strings.TrimSuffix(port, "/tcp")}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+	out, err := exec.Command("docker", append(args, image)...).Output()
+	if err != nil {
+		t.Skipf("testfixtures: failed to start %s: %v", image, err)
+	}
+	id := strings.TrimSpace(string(out))
+	t.Cleanup(func() {
+		exec.Command("docker", "rm", "-f", id).Run()
+	})
+
+	out, err = exec.Command("docker", "port", id, port).Output()
+	if err != nil {
+		t.Fatalf("testfixtures: failed to find the published port of %s: %v", image, err)
+	}
+	// docker port may list an IPv6 binding too; the first line is enough
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return addr
+}
+
+// waitFor retries ready until it succeeds or startupTimeout passes
+func waitFor(ready func() error) error {
+	deadline := time.Now().Add(startupTimeout)
+	for {
+		err := ready()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
@@ -0,0 +1,158 @@
+package testfixtures
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is a fixture row of the users table
+type User struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TenantID     string    `gorm:"type:varchar(64);not null;default:''"`
+	Email        string    `gorm:"type:varchar(255);uniqueIndex;not null"`
+	PasswordHash string    `gorm:"type:varchar(255);not null"`
+	FirstName    string    `gorm:"type:varchar(100)"`
+	LastName     string    `gorm:"type:varchar(100)"`
+	Role         string    `gorm:"type:varchar(20);not null"`
+	Status       string    `gorm:"type:varchar(20);not null"`
+	CreatedAt    time.Time `gorm:"not null"`
+	UpdatedAt    time.Time `gorm:"not null"`
+}
+
+// Document is a fixture row of the documents table
+type Document struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	Type        string    `gorm:"type:varchar(50);not null"`
+	Name        string    `gorm:"type:varchar(255);not null"`
+	Path        string    `gorm:"type:varchar(255);not null"`
+	Size        int64     `gorm:"not null"`
+	ContentType string    `gorm:"type:varchar(100);not null"`
+	UploadedAt  time.Time `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+// Verification is a fixture row of the verifications table
+type Verification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null"`
+	KYCID       uuid.UUID  `gorm:"type:uuid;not null"`
+	DocumentID  *uuid.UUID `gorm:"type:uuid"`
+	Method      string     `gorm:"type:varchar(20);not null"`
+	Status      string     `gorm:"type:varchar(20);not null"`
+	Notes       string     `gorm:"type:text"`
+	CompletedAt *time.Time
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+// Investment is a fixture row of the investments table
+type Investment struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TenantID      string    `gorm:"type:varchar(64);not null;default:''"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	Amount        float64   `gorm:"not null"`
+	Currency      string    `gorm:"type:varchar(3);not null"`
+	Type          string    `gorm:"not null"`
+	Status        string    `gorm:"not null"`
+	Symbol        string    `gorm:"not null"`
+	Quantity      float64   `gorm:"not null"`
+	PurchasePrice float64   `gorm:"not null"`
+	PurchaseDate  time.Time `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"not null"`
+	UpdatedAt     time.Time `gorm:"not null"`
+}
+
+// BuildUser returns an active user with a unique email, then applies overrides
+func BuildUser(seq *Sequence, overrides ...func(*User)) *User {
+	n := seq.Next("user")
+	return apply(&User{
+		ID:           UUID("user", n),
+		Email:        fmt.Sprintf("user%d@fixtures.sparkfund.test", n),
+		PasswordHash: "$2a$10$fixturefixturefixturefixturefixturefixturefixturefixt",
+		FirstName:    "Test",
+		LastName:     fmt.Sprintf("User%d", n),
+		Role:         "user",
+		Status:       "active",
+		CreatedAt:    Time(n),
+		UpdatedAt:    Time(n),
+	}, overrides)
+}
+
+// BuildDocument returns a passport scan, then applies overrides; set UserID to
+// attach it to a user
+func BuildDocument(seq *Sequence, overrides ...func(*Document)) *Document {
+	n := seq.Next("document")
+	id := UUID("document", n)
+	return apply(&Document{
+		ID:          id,
+		Type:        "PASSPORT",
+		Name:        fmt.Sprintf("passport-%d.pdf", n),
+		Path:        fmt.Sprintf("documents/%s.pdf", id),
+		Size:        1024,
+		ContentType: "application/pdf",
+		UploadedAt:  Time(n),
+		CreatedAt:   Time(n),
+		UpdatedAt:   Time(n),
+	}, overrides)
+}
+
+// BuildVerification returns a pending document verification, then applies
+// overrides; set UserID and DocumentID to attach it
+func BuildVerification(seq *Sequence, overrides ...func(*Verification)) *Verification {
+	n := seq.Next("verification")
+	return apply(&Verification{
+		ID:        UUID("verification", n),
+		KYCID:     UUID("kyc", n),
+		Method:    "DOCUMENT",
+		Status:    "PENDING",
+		CreatedAt: Time(n),
+		UpdatedAt: Time(n),
+	}, overrides)
+}
+
+// BuildInvestment returns an active USD stock holding, then applies overrides;
+// set UserID to attach it to a user
+func BuildInvestment(seq *Sequence, overrides ...func(*Investment)) *Investment {
+	n := seq.Next("investment")
+	return apply(&Investment{
+		ID:            UUID("investment", n),
+		Amount:        1000,
+		Currency:      "USD",
+		Type:          "STOCK",
+		Status:        "ACTIVE",
+		Symbol:        "AAPL",
+		Quantity:      10,
+		PurchasePrice: 100,
+		PurchaseDate:  Time(n),
+		CreatedAt:     Time(n),
+		UpdatedAt:     Time(n),
+	}, overrides)
+}
+
+// User inserts a user built by BuildUser
+func (f *Fixtures) User(overrides ...func(*User)) *User {
+	f.t.Helper()
+	return Insert(f, BuildUser(f.seq, overrides...))
+}
+
+// Document inserts a document built by BuildDocument
+func (f *Fixtures) Document(overrides ...func(*Document)) *Document {
+	f.t.Helper()
+	return Insert(f, BuildDocument(f.seq, overrides...))
+}
+
+// Verification inserts a verification built by BuildVerification
+func (f *Fixtures) Verification(overrides ...func(*Verification)) *Verification {
+	f.t.Helper()
+	return Insert(f, BuildVerification(f.seq, overrides...))
+}
+
+// Investment inserts an investment built by BuildInvestment
+func (f *Fixtures) Investment(overrides ...func(*Investment)) *Investment {
+	f.t.Helper()
+	return Insert(f, BuildInvestment(f.seq, overrides...))
+}
@@ -0,0 +1,75 @@
+package testfixtures
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBuilders_AreDeterministic(t *testing.T) {
+	first, second := NewSequence(), NewSequence()
+
+	a, b := BuildUser(first), BuildUser(second)
+	if a.ID != b.ID || a.Email != b.Email || !a.CreatedAt.Equal(b.CreatedAt) {
+		t.Fatalf("expected identical users from fresh sequences, got %+v and %+v", a, b)
+	}
+
+	next := BuildUser(first)
+	if next.ID == a.ID || next.Email == a.Email {
+		t.Fatal("expected each user in a sequence to be distinct")
+	}
+	if !next.CreatedAt.After(a.CreatedAt) {
+		t.Fatal("expected later fixtures to be newer")
+	}
+
+	investment := BuildInvestment(first, func(i *Investment) {
+		i.UserID = a.ID
+		i.Amount = 2500
+	})
+	if investment.UserID != a.ID || investment.Amount != 2500 || investment.Currency != "USD" {
+		t.Fatalf("expected overrides on top of the defaults, got %+v", investment)
+	}
+}
+
+func TestFixtures_InsertAndTearDown(t *testing.T) {
+	db := Postgres(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var userID uuid.UUID
+	t.Run("seed", func(t *testing.T) {
+		f := New(t, db)
+		user := f.User()
+		document := f.Document(func(d *Document) { d.UserID = user.ID })
+		f.Verification(func(v *Verification) {
+			v.UserID = user.ID
+			v.DocumentID = &document.ID
+		})
+		f.Investment(func(i *Investment) { i.UserID = user.ID })
+		userID = user.ID
+
+		var stored User
+		if err := db.First(&stored, "id = ?", user.ID).Error; err != nil {
+			t.Fatalf("expected the user to be inserted: %v", err)
+		}
+		if stored.Email != user.Email {
+			t.Fatalf("stored email = %q, want %q", stored.Email, user.Email)
+		}
+	})
+
+	// The subtest's cleanups have run, so nothing it seeded may remain
+	for _, model := range []interface{}{&User{}, &Document{}, &Verification{}, &Investment{}} {
+		var count int64
+		column := "user_id"
+		if _, ok := model.(*User); ok {
+			column = "id"
+		}
+		if err := db.Model(model).Where(column+" = ?", userID).Count(&count).Error; err != nil {
+			t.Fatalf("failed to count %T: %v", model, err)
+		}
+		if count != 0 {
+			t.Fatalf("expected %T fixtures to be removed, found %d", model, count)
+		}
+	}
+}
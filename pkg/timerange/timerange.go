@@ -0,0 +1,170 @@
+// Package timerange parses the from/to query parameters of date-range
+// endpoints, so every endpoint accepts the same formats, applies the same
+// defaults and reports bad input the same way.
+//
+// A Range is half-open: From is inclusive and To is exclusive. A date-only
+// "to" such as 2025-03-31 covers the whole of that day, so it becomes midnight
+// at the start of the next day.
+package timerange
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the date-only form accepted alongside RFC 3339
+const DateLayout = "2006-01-02"
+
+var (
+	// ErrInvalidTime is returned for a bound that is neither RFC 3339 nor a date
+	ErrInvalidTime = errors.New("must be an RFC 3339 time or a YYYY-MM-DD date")
+	// ErrInverted is returned when from is after to
+	ErrInverted = errors.New("from must not be after to")
+	// ErrTooLong is returned when the range is longer than the parser allows
+	ErrTooLong = errors.New("range is too long")
+)
+
+// Error describes which parameter was rejected and why. Its Err is one of
+// ErrInvalidTime, ErrInverted or ErrTooLong.
+type Error struct {
+	// Param is the offending query parameter; for ErrInverted and ErrTooLong it
+	// is the "from" parameter
+	Param string
+	// Value is the rejected input
+	Value string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s %q: %v", e.Param, e.Value, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Range is the half-open interval [From, To)
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains reports whether t falls within the range
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.From) && t.Before(r.To)
+}
+
+// Duration returns the length of the range
+func (r Range) Duration() time.Duration {
+	return r.To.Sub(r.From)
+}
+
+// Parser parses from/to parameters
+type Parser struct {
+	// FromParam and ToParam name the query parameters in errors
+	FromParam string
+	ToParam   string
+	// DefaultSpan is the length of the range when from is omitted. Zero leaves
+	// the range unbounded below, which needs a zero MaxSpan.
+	DefaultSpan time.Duration
+	// MaxSpan caps the length of the range; zero allows any length
+	MaxSpan time.Duration
+	// Location interprets date-only bounds; UTC when nil
+	Location *time.Location
+	// Now returns the current time; time.Now when nil
+	Now func() time.Time
+}
+
+// DefaultParser returns a parser of "from" and "to" that defaults to the last
+// 30 days and allows at most a year
+func DefaultParser() Parser {
+	return Parser{
+		FromParam:   "from",
+		ToParam:     "to",
+		DefaultSpan: 30 * 24 * time.Hour,
+		MaxSpan:     366 * 24 * time.Hour,
+	}
+}
+
+// Parse parses raw from and to values. An omitted to defaults to now and an
+// omitted from to DefaultSpan before to, or the zero time without a DefaultSpan.
+func (p Parser) Parse(from, to string) (Range, error) {
+	var r Range
+
+	to = strings.TrimSpace(to)
+	if to == "" {
+		r.To = p.now()
+	} else {
+		t, dateOnly, err := p.parseTime(to)
+		if err != nil {
+			return Range{}, &Error{Param: p.param(p.ToParam, "to"), Value: to, Err: err}
+		}
+		if dateOnly {
+			// A date-only upper bound includes the whole day
+			t = t.AddDate(0, 0, 1)
+		}
+		r.To = t
+	}
+
+	from = strings.TrimSpace(from)
+	if from == "" {
+		if p.DefaultSpan > 0 {
+			r.From = r.To.Add(-p.DefaultSpan)
+		}
+	} else {
+		t, _, err := p.parseTime(from)
+		if err != nil {
+			return Range{}, &Error{Param: p.param(p.FromParam, "from"), Value: from, Err: err}
+		}
+		r.From = t
+	}
+
+	if r.From.After(r.To) {
+		return Range{}, &Error{Param: p.param(p.FromParam, "from"), Value: from, Err: ErrInverted}
+	}
+	if p.MaxSpan > 0 && r.Duration() > p.MaxSpan {
+		return Range{}, &Error{Param: p.param(p.FromParam, "from"), Value: from, Err: fmt.Errorf("%w: at most %s", ErrTooLong, formatSpan(p.MaxSpan))}
+	}
+	return r, nil
+}
+
+// parseTime parses an RFC 3339 time or a date, reporting which it was
+func (p Parser) parseTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+
+	location := p.Location
+	if location == nil {
+		location = time.UTC
+	}
+	if t, err := time.ParseInLocation(DateLayout, raw, location); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, ErrInvalidTime
+}
+
+func (p Parser) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p Parser) param(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// formatSpan formats whole days as days and anything else as a duration
+func formatSpan(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("%d days", d/day)
+	}
+	return d.String()
+}
@@ -0,0 +1,152 @@
+package timerange
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 3, 28, 12, 0, 0, 0, time.UTC)
+
+func testParser() Parser {
+	p := DefaultParser()
+	p.Now = func() time.Time { return now }
+	return p
+}
+
+func TestParse_DefaultsToLastSpanEndingNow(t *testing.T) {
+	r, err := testParser().Parse("", "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !r.To.Equal(now) {
+		t.Fatalf("To = %v, want %v", r.To, now)
+	}
+	if got, want := r.Duration(), 30*24*time.Hour; got != want {
+		t.Fatalf("Duration = %v, want %v", got, want)
+	}
+}
+
+func TestParse_DefaultSpanEndsAtGivenTo(t *testing.T) {
+	r, err := testParser().Parse("", "2025-01-31T00:00:00Z")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC); !r.From.Equal(want) {
+		t.Fatalf("From = %v, want %v", r.From, want)
+	}
+}
+
+func TestParse_DateOnlyToCoversWholeDay(t *testing.T) {
+	r, err := testParser().Parse("2025-03-01", "2025-03-01")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !r.From.Equal(want) {
+		t.Fatalf("From = %v, want %v", r.From, want)
+	}
+	if want := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC); !r.To.Equal(want) {
+		t.Fatalf("To = %v, want %v", r.To, want)
+	}
+	if !r.Contains(time.Date(2025, 3, 1, 23, 59, 59, 0, time.UTC)) {
+		t.Fatal("range should contain the last second of the day")
+	}
+	if r.Contains(r.To) {
+		t.Fatal("range should not contain its upper bound")
+	}
+}
+
+func TestParse_RFC3339KeepsExactBounds(t *testing.T) {
+	r, err := testParser().Parse("2025-03-01T10:00:00+01:00", "2025-03-01T12:30:00Z")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC); !r.From.Equal(want) {
+		t.Fatalf("From = %v, want %v", r.From, want)
+	}
+	if want := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC); !r.To.Equal(want) {
+		t.Fatalf("To = %v, want %v", r.To, want)
+	}
+}
+
+func TestParse_DateOnlyUsesLocation(t *testing.T) {
+	p := testParser()
+	p.Location = time.FixedZone("UTC+2", 2*60*60)
+
+	r, err := p.Parse("2025-03-01", "2025-03-01")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := time.Date(2025, 2, 28, 22, 0, 0, 0, time.UTC); !r.From.Equal(want) {
+		t.Fatalf("From = %v, want %v", r.From, want)
+	}
+}
+
+func TestParse_RejectsInvertedRange(t *testing.T) {
+	_, err := testParser().Parse("2025-03-02", "2025-03-01T00:00:00Z")
+	if !errors.Is(err, ErrInverted) {
+		t.Fatalf("err = %v, want ErrInverted", err)
+	}
+
+	var rangeErr *Error
+	if !errors.As(err, &rangeErr) || rangeErr.Param != "from" {
+		t.Fatalf("err = %#v, want *Error for from", err)
+	}
+}
+
+func TestParse_RejectsTooLongRange(t *testing.T) {
+	_, err := testParser().Parse("2020-01-01", "2025-01-01")
+	if !errors.Is(err, ErrTooLong) {
+		t.Fatalf("err = %v, want ErrTooLong", err)
+	}
+	if got, want := err.Error(), `invalid from "2020-01-01": range is too long: at most 366 days`; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestParse_ZeroMaxSpanAllowsAnyLength(t *testing.T) {
+	p := testParser()
+	p.MaxSpan = 0
+
+	if _, err := p.Parse("2000-01-01", "2025-01-01"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+}
+
+func TestParse_ZeroDefaultSpanLeavesFromUnbounded(t *testing.T) {
+	p := testParser()
+	p.DefaultSpan, p.MaxSpan = 0, 0
+
+	r, err := p.Parse("", "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !r.From.IsZero() {
+		t.Fatalf("From = %v, want the zero time", r.From)
+	}
+	if !r.Contains(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("range should contain any time before now")
+	}
+}
+
+func TestParse_InvalidTimeNamesParam(t *testing.T) {
+	p := testParser()
+	p.FromParam, p.ToParam = "start_date", "end_date"
+
+	for _, tc := range []struct {
+		from, to, param string
+	}{
+		{from: "yesterday", param: "start_date"},
+		{to: "03/01/2025", param: "end_date"},
+	} {
+		_, err := p.Parse(tc.from, tc.to)
+		if !errors.Is(err, ErrInvalidTime) {
+			t.Fatalf("Parse(%q, %q) err = %v, want ErrInvalidTime", tc.from, tc.to, err)
+		}
+
+		var rangeErr *Error
+		if !errors.As(err, &rangeErr) || rangeErr.Param != tc.param {
+			t.Fatalf("Parse(%q, %q) err = %#v, want *Error for %s", tc.from, tc.to, err, tc.param)
+		}
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sparkfund/api-gateway/internal/health"
+	"github.com/sparkfund/api-gateway/internal/loadbalancer"
 	"github.com/sparkfund/api-gateway/internal/middleware"
+	"github.com/sparkfund/api-gateway/internal/openapi"
 	"github.com/sparkfund/api-gateway/internal/proxy"
+	"github.com/sparkfund/api-gateway/internal/routing"
 )
 
 func main() {
@@ -42,10 +48,12 @@ func main() {
 			SecretKey     []byte
 			TokenExpiry   time.Duration
 			RefreshExpiry time.Duration
+			Algorithms    []string
 		}{
 			SecretKey:     []byte(os.Getenv("JWT_SECRET")),
 			TokenExpiry:   time.Hour * 24,
 			RefreshExpiry: time.Hour * 24 * 7,
+			Algorithms:    middleware.JWTAlgorithmsFromEnv(),
 		},
 	}
 
@@ -95,11 +103,40 @@ func main() {
 		})
 	})
 
+	// Consolidated API spec for all upstream services
+	specAggregator := openapi.NewAggregator(openapi.Config{
+		Title:   "SparkFund API",
+		Version: "1.0",
+		Upstreams: []openapi.Upstream{
+			{Name: "investment", SpecURL: getEnv("INVESTMENT_SERVICE_SPEC_URL", "http://investment-service:8080/swagger/doc.json")},
+			{Name: "user", SpecURL: getEnv("USER_SERVICE_SPEC_URL", "http://user-service:8084/swagger/doc.json")},
+			{Name: "kyc", SpecURL: getEnv("KYC_SERVICE_SPEC_URL", "http://kyc-service:8081/swagger/doc.json")},
+		},
+		RefreshInterval: 5 * time.Minute,
+	})
+	specAggregator.Start()
+	defer specAggregator.Stop()
+
+	router.GET("/openapi.json", specAggregator.SpecHandler())
+	router.GET("/docs", specAggregator.UIHandler())
+
 	// Health check (without auth)
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Combined health of all upstream services (without auth)
+	upstreamHealth := health.NewAggregator(health.Config{
+		Upstreams: []health.Upstream{
+			{Name: "investment", HealthURL: getEnv("INVESTMENT_SERVICE_HEALTH_URL", "http://investment-service:8080/health")},
+			{Name: "user", HealthURL: getEnv("USER_SERVICE_HEALTH_URL", "http://user-service:8084/health")},
+			{Name: "kyc", HealthURL: getEnv("KYC_SERVICE_HEALTH_URL", "http://kyc-service:8081/health")},
+		},
+		ProbeTimeout: getEnvDuration("GATEWAY_UPSTREAM_HEALTH_TIMEOUT", 2*time.Second),
+		CacheTTL:     getEnvDuration("GATEWAY_UPSTREAM_HEALTH_CACHE_TTL", 5*time.Second),
+	})
+	router.GET("/health/upstreams", upstreamHealth.Handler())
+
 	// Add Prometheus server to IP whitelist
 	securityMiddleware.AddToIPWhitelist("172.18.0.4") // Prometheus IP
 	securityMiddleware.AddToIPWhitelist("172.18.0.1") // Local testing IP
@@ -107,6 +144,23 @@ func main() {
 	// Apply security middleware
 	securityMiddleware.Apply(router)
 
+	// Spread Investment Service traffic over its instances, skipping any failing readiness probes
+	if instances := os.Getenv("INVESTMENT_SERVICE_INSTANCES"); instances != "" {
+		lbConfig := loadbalancer.DefaultConfig()
+		lbConfig.HealthCheckInterval = getEnvDuration("GATEWAY_HEALTH_PROBE_INTERVAL", lbConfig.HealthCheckInterval)
+		lbConfig.UnhealthyThreshold = getEnvInt("GATEWAY_UNHEALTHY_THRESHOLD", lbConfig.UnhealthyThreshold)
+		lbConfig.HealthyThreshold = getEnvInt("GATEWAY_HEALTHY_THRESHOLD", lbConfig.HealthyThreshold)
+
+		balancer := loadbalancer.NewLoadBalancer(lbConfig, nil)
+		defer balancer.Stop()
+		for _, instance := range strings.Split(instances, ",") {
+			if err := balancer.AddServer(strings.TrimSpace(instance), 1); err != nil {
+				log.Fatalf("Invalid Investment Service instance %q: %v", instance, err)
+			}
+		}
+		proxy.UseInvestmentBalancer(balancer)
+	}
+
 	// Investment service routes
 	investments := router.Group("/api/v1/investments")
 	{
@@ -138,9 +192,52 @@ func main() {
 		port = "8080"
 	}
 
+	// Tolerate trailing slash and, optionally, case variations in request paths
+	routeConfig := routing.DefaultConfig()
+	routeConfig.Mode = routing.Mode(getEnv("GATEWAY_ROUTE_MODE", string(routeConfig.Mode)))
+	routeConfig.Redirect = getEnvBool("GATEWAY_ROUTE_REDIRECT", routeConfig.Redirect)
+	routeConfig.CaseInsensitive = getEnvBool("GATEWAY_ROUTE_CASE_INSENSITIVE", routeConfig.CaseInsensitive)
+	if exempt := os.Getenv("GATEWAY_ROUTE_EXEMPT"); exempt != "" {
+		for _, prefix := range strings.Split(exempt, ",") {
+			routeConfig.Exempt = append(routeConfig.Exempt, strings.TrimSpace(prefix))
+		}
+	}
+
 	// Start server
 	log.Printf("API Gateway starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
+	if err := http.ListenAndServe(":"+port, routing.NewNormalizer(router, routeConfig)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// getEnv returns the value of an environment variable or a default
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvDuration returns an environment variable parsed as a duration, or a default
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvBool returns an environment variable parsed as a boolean, or a default
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt returns an environment variable parsed as a positive integer, or a default
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
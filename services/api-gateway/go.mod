@@ -1,6 +1,6 @@
 module github.com/sparkfund/api-gateway
 
-go 1.21
+go 1.22.0
 
 toolchain go1.24.0
 
@@ -9,6 +9,7 @@ require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/prometheus/client_golang v1.21.1
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/oauth2 v0.25.0
 	google.golang.org/grpc v1.71.0
 )
 
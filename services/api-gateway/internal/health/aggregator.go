@@ -0,0 +1,156 @@
+// Package health reports the health of every upstream service in one call
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Statuses of an upstream and of the platform as a whole
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Upstream is a service whose health endpoint is probed
+type Upstream struct {
+	Name      string `mapstructure:"name"`
+	HealthURL string `mapstructure:"health_url"`
+}
+
+// Config holds health aggregation configuration
+type Config struct {
+	Upstreams []Upstream `mapstructure:"upstreams"`
+	// ProbeTimeout bounds each upstream probe
+	ProbeTimeout time.Duration `mapstructure:"probe_timeout"`
+	// CacheTTL is how long a report is reused before upstreams are probed again
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+}
+
+// ServiceStatus is the probe result for one upstream
+type ServiceStatus struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the aggregated health of all upstreams
+type Report struct {
+	Status    string          `json:"status"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Services  []ServiceStatus `json:"services"`
+}
+
+// Aggregator probes every upstream concurrently and caches the combined report
+// briefly, so frequent dashboard polling does not turn into a probe storm
+type Aggregator struct {
+	config Config
+	client *http.Client
+
+	// mutex is held while probing, so concurrent callers share one round of probes
+	mutex  sync.Mutex
+	report *Report
+}
+
+// NewAggregator creates a new health aggregator
+func NewAggregator(config Config) *Aggregator {
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = 2 * time.Second
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = 5 * time.Second
+	}
+
+	return &Aggregator{
+		config: config,
+		client: &http.Client{Timeout: config.ProbeTimeout},
+	}
+}
+
+// Check returns the cached report, probing the upstreams if it has expired
+func (a *Aggregator) Check(ctx context.Context) Report {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.report != nil && time.Since(a.report.CheckedAt) < a.config.CacheTTL {
+		return *a.report
+	}
+
+	report := a.probeAll(ctx)
+	a.report = &report
+	return report
+}
+
+// probeAll probes every upstream at once and combines the results
+func (a *Aggregator) probeAll(ctx context.Context) Report {
+	services := make([]ServiceStatus, len(a.config.Upstreams))
+
+	var wg sync.WaitGroup
+	for i, upstream := range a.config.Upstreams {
+		wg.Add(1)
+		go func(i int, upstream Upstream) {
+			defer wg.Done()
+			services[i] = a.probe(ctx, upstream)
+		}(i, upstream)
+	}
+	wg.Wait()
+
+	up := 0
+	for _, service := range services {
+		if service.Status == StatusUp {
+			up++
+		}
+	}
+
+	status := StatusDegraded
+	switch up {
+	case len(services):
+		status = StatusOK
+	case 0:
+		status = StatusDown
+	}
+
+	return Report{
+		Status:    status,
+		CheckedAt: time.Now(),
+		Services:  services,
+	}
+}
+
+// probe requests an upstream's health endpoint; any 2xx response is up
+func (a *Aggregator) probe(ctx context.Context, upstream Upstream) ServiceStatus {
+	status := ServiceStatus{Name: upstream.Name, Status: StatusDown}
+
+	ctx, cancel := context.WithTimeout(ctx, a.config.ProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.HealthURL, nil)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	start := time.Now()
+	resp, err := a.client.Do(req)
+	status.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	resp.Body.Close()
+
+	status.HTTPStatus = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		status.Status = StatusUp
+	} else {
+		status.Error = fmt.Sprintf("health check returned %s", resp.Status)
+	}
+	return status
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthServer starts a fake upstream answering its health endpoint with status
+// after delay, counting probes
+func healthServer(t *testing.T, status int, delay time.Duration) (*httptest.Server, *int32) {
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &probes
+}
+
+func TestCheck_AggregatesMixedUpstreams(t *testing.T) {
+	healthy, _ := healthServer(t, http.StatusOK, 0)
+	failing, _ := healthServer(t, http.StatusServiceUnavailable, 0)
+	slow, _ := healthServer(t, http.StatusOK, time.Second)
+	unreachable, _ := healthServer(t, http.StatusOK, 0)
+	unreachable.Close()
+
+	aggregator := NewAggregator(Config{
+		Upstreams: []Upstream{
+			{Name: "investment", HealthURL: healthy.URL + "/health"},
+			{Name: "user", HealthURL: failing.URL + "/health"},
+			{Name: "kyc", HealthURL: slow.URL + "/health"},
+			{Name: "ai", HealthURL: unreachable.URL + "/health"},
+		},
+		ProbeTimeout: 100 * time.Millisecond,
+	})
+
+	start := time.Now()
+	report := aggregator.Check(context.Background())
+
+	// Probes run concurrently, each bounded by the probe timeout
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusDegraded, report.Status)
+	require.Len(t, report.Services, 4)
+
+	byName := make(map[string]ServiceStatus)
+	for _, service := range report.Services {
+		byName[service.Name] = service
+	}
+	assert.Equal(t, StatusUp, byName["investment"].Status)
+	assert.Equal(t, http.StatusOK, byName["investment"].HTTPStatus)
+	assert.Equal(t, StatusDown, byName["user"].Status)
+	assert.Equal(t, http.StatusServiceUnavailable, byName["user"].HTTPStatus)
+	assert.Equal(t, StatusDown, byName["kyc"].Status)
+	assert.NotEmpty(t, byName["kyc"].Error)
+	assert.Equal(t, StatusDown, byName["ai"].Status)
+	assert.NotEmpty(t, byName["ai"].Error)
+}
+
+func TestCheck_OverallStatus(t *testing.T) {
+	healthy, _ := healthServer(t, http.StatusOK, 0)
+	failing, _ := healthServer(t, http.StatusInternalServerError, 0)
+
+	allUp := NewAggregator(Config{Upstreams: []Upstream{
+		{Name: "investment", HealthURL: healthy.URL},
+		{Name: "user", HealthURL: healthy.URL},
+	}})
+	assert.Equal(t, StatusOK, allUp.Check(context.Background()).Status)
+
+	allDown := NewAggregator(Config{Upstreams: []Upstream{
+		{Name: "investment", HealthURL: failing.URL},
+		{Name: "user", HealthURL: failing.URL},
+	}})
+	assert.Equal(t, StatusDown, allDown.Check(context.Background()).Status)
+}
+
+func TestCheck_CachesForTTL(t *testing.T) {
+	server, probes := healthServer(t, http.StatusOK, 0)
+	aggregator := NewAggregator(Config{
+		Upstreams: []Upstream{{Name: "investment", HealthURL: server.URL}},
+		CacheTTL:  100 * time.Millisecond,
+	})
+
+	first := aggregator.Check(context.Background())
+	second := aggregator.Check(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(probes))
+	assert.Equal(t, first.CheckedAt, second.CheckedAt)
+
+	time.Sleep(150 * time.Millisecond)
+	aggregator.Check(context.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(probes))
+}
+
+func TestHandler_StatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	healthy, _ := healthServer(t, http.StatusOK, 0)
+	unreachable, _ := healthServer(t, http.StatusOK, 0)
+	unreachable.Close()
+
+	serve := func(upstreams ...Upstream) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/health/upstreams", NewAggregator(Config{Upstreams: upstreams}).Handler())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/upstreams", nil))
+		return w
+	}
+
+	w := serve(Upstream{Name: "investment", HealthURL: healthy.URL}, Upstream{Name: "user", HealthURL: unreachable.URL})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Len(t, report.Services, 2)
+
+	w = serve(Upstream{Name: "user", HealthURL: unreachable.URL})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,22 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the aggregated upstream health. It answers 503 only when every
+// upstream is down, so a degraded platform still reports its details as a success.
+func (a *Aggregator) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := a.Check(c.Request.Context())
+
+		code := http.StatusOK
+		if report.Status == StatusDown {
+			code = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(code, report)
+	}
+}
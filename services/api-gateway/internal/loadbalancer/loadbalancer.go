@@ -1,14 +1,16 @@
 package loadbalancer
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/sirupsen/logrus"
 )
 
 // Strategy defines the load balancing strategy
@@ -29,13 +31,20 @@ const (
 	LeastResponseTime Strategy = "least_response_time"
 )
 
-// Config holds load balancer configuration
+// Config holds load balancer configuration.
+//
+// Health checks actively probe every server, so an instance that is running but
+// failing its own readiness check leaves the rotation before it serves errors.
+// A server is removed after UnhealthyThreshold consecutive failed probes and
+// returns after HealthyThreshold consecutive successful ones.
 type Config struct {
 	Strategy            Strategy      `mapstructure:"strategy"`
 	HealthCheckEnabled  bool          `mapstructure:"health_check_enabled"`
 	HealthCheckPath     string        `mapstructure:"health_check_path"`
 	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
 	HealthCheckTimeout  time.Duration `mapstructure:"health_check_timeout"`
+	UnhealthyThreshold  int           `mapstructure:"unhealthy_threshold"`
+	HealthyThreshold    int           `mapstructure:"healthy_threshold"`
 	RetryCount          int           `mapstructure:"retry_count"`
 	RetryWaitTime       time.Duration `mapstructure:"retry_wait_time"`
 	MaxIdleConns        int           `mapstructure:"max_idle_conns"`
@@ -43,6 +52,23 @@ type Config struct {
 	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
 }
 
+// DefaultConfig returns a round-robin configuration probing each server's
+// readiness endpoint
+func DefaultConfig() Config {
+	return Config{
+		Strategy:            RoundRobin,
+		HealthCheckEnabled:  true,
+		HealthCheckPath:     "/ready",
+		HealthCheckInterval: 10 * time.Second,
+		HealthCheckTimeout:  2 * time.Second,
+		UnhealthyThreshold:  3,
+		HealthyThreshold:    1,
+		MaxIdleConns:        100,
+		MaxConnsPerHost:     100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
 // Server represents a backend server
 type Server struct {
 	URL             *url.URL
@@ -52,6 +78,7 @@ type Server struct {
 	ResponseTime    int64 // in milliseconds
 	LastHealthCheck time.Time
 	FailCount       int
+	SuccessCount    int
 }
 
 // LoadBalancer manages load balancing between servers
@@ -61,14 +88,25 @@ type LoadBalancer struct {
 	current  uint64
 	mutex    sync.RWMutex
 	client   *http.Client
-	logger   *logrus.Logger
+	logger   *log.Logger
 	config   Config
 	rand     *rand.Rand
 	stopCh   chan struct{}
 }
 
-// NewLoadBalancer creates a new load balancer
-func NewLoadBalancer(config Config, logger *logrus.Logger) *LoadBalancer {
+// NewLoadBalancer creates a new load balancer. A nil logger logs to the
+// standard logger.
+func NewLoadBalancer(config Config, logger *log.Logger) *LoadBalancer {
+	if logger == nil {
+		logger = log.Default()
+	}
+	if config.UnhealthyThreshold < 1 {
+		config.UnhealthyThreshold = 1
+	}
+	if config.HealthyThreshold < 1 {
+		config.HealthyThreshold = 1
+	}
+
 	// Create HTTP client with custom transport
 	transport := &http.Transport{
 		MaxIdleConns:        config.MaxIdleConns,
@@ -348,80 +386,82 @@ func (lb *LoadBalancer) healthCheck() {
 		case <-lb.stopCh:
 			return
 		case <-ticker.C:
-			lb.mutex.RLock()
-			servers := make([]*Server, len(lb.servers))
-			copy(servers, lb.servers)
-			lb.mutex.RUnlock()
-
-			for _, server := range servers {
-				go lb.checkServerHealth(server)
-			}
+			lb.CheckHealth()
 		}
 	}
 }
 
-// checkServerHealth checks the health of a server
-func (lb *LoadBalancer) checkServerHealth(server *Server) {
-	healthURL := server.URL.String()
-	if lb.config.HealthCheckPath != "" {
-		healthURL = server.URL.String() + lb.config.HealthCheckPath
-	}
+// CheckHealth probes every server once, concurrently, and returns when all
+// probes have finished
+func (lb *LoadBalancer) CheckHealth() {
+	lb.mutex.RLock()
+	servers := make([]*Server, len(lb.servers))
+	copy(servers, lb.servers)
+	lb.mutex.RUnlock()
 
-	// Create request
-	req, err := http.NewRequest("GET", healthURL, nil)
-	if err != nil {
-		lb.logger.Errorf("Failed to create health check request for %s: %v", server.URL.String(), err)
-		return
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *Server) {
+			defer wg.Done()
+			lb.checkServerHealth(server)
+		}(server)
 	}
+	wg.Wait()
+}
+
+// checkServerHealth probes a server and moves it in or out of the rotation
+func (lb *LoadBalancer) checkServerHealth(server *Server) {
+	healthURL := server.URL.String() + lb.config.HealthCheckPath
 
-	// Set timeout
 	ctx, cancel := context.WithTimeout(context.Background(), lb.config.HealthCheckTimeout)
 	defer cancel()
-	req = req.WithContext(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		lb.logger.Printf("Failed to create health check request for %s: %v", server.URL.String(), err)
+		return
+	}
 
-	// Send request
 	start := time.Now()
 	resp, err := lb.client.Do(req)
-	responseTime := time.Since(start)
+	lb.UpdateResponseTime(server, time.Since(start))
+	if err == nil {
+		// Drain the body so the connection is reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err = fmt.Errorf("status code %d", resp.StatusCode)
+		}
+	}
 
-	// Update response time
-	lb.UpdateResponseTime(server, responseTime)
+	lb.recordHealth(server, err)
+}
 
-	// Update server status
+// recordHealth counts a probe result, deactivating the server after
+// UnhealthyThreshold consecutive failures and reactivating it after
+// HealthyThreshold consecutive successes
+func (lb *LoadBalancer) recordHealth(server *Server, probeErr error) {
 	lb.mutex.Lock()
 	defer lb.mutex.Unlock()
 
 	server.LastHealthCheck = time.Now()
 
-	if err != nil {
+	if probeErr != nil {
 		server.FailCount++
-		lb.logger.Warnf("Health check failed for %s: %v", server.URL.String(), err)
-		if server.FailCount >= 3 {
-			if server.Active {
-				lb.logger.Warnf("Marking server %s as inactive after %d consecutive failures", server.URL.String(), server.FailCount)
-				server.Active = false
-			}
+		server.SuccessCount = 0
+		lb.logger.Printf("Health check failed for %s: %v", server.URL.String(), probeErr)
+		if server.Active && server.FailCount >= lb.config.UnhealthyThreshold {
+			lb.logger.Printf("Marking server %s as inactive after %d consecutive failures", server.URL.String(), server.FailCount)
+			server.Active = false
 		}
 		return
 	}
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		// Health check successful
-		if !server.Active {
-			lb.logger.Infof("Marking server %s as active after successful health check", server.URL.String())
-		}
+	server.SuccessCount++
+	server.FailCount = 0
+	if !server.Active && server.SuccessCount >= lb.config.HealthyThreshold {
+		lb.logger.Printf("Marking server %s as active after %d successful health checks", server.URL.String(), server.SuccessCount)
 		server.Active = true
-		server.FailCount = 0
-	} else {
-		// Health check failed
-		server.FailCount++
-		lb.logger.Warnf("Health check failed for %s with status code %d", server.URL.String(), resp.StatusCode)
-		if server.FailCount >= 3 {
-			if server.Active {
-				lb.logger.Warnf("Marking server %s as inactive after %d consecutive failures", server.URL.String(), server.FailCount)
-				server.Active = false
-			}
-		}
 	}
 }
 
@@ -0,0 +1,114 @@
+package loadbalancer
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instance starts a fake upstream whose readiness can be toggled
+func instance(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	var ready atomic.Bool
+	ready.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" && !ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &ready
+}
+
+func probingBalancer(t *testing.T, urls ...string) *LoadBalancer {
+	config := DefaultConfig()
+	// Probes are driven by the test through CheckHealth
+	config.HealthCheckEnabled = false
+	config.UnhealthyThreshold = 2
+	config.HealthyThreshold = 2
+
+	lb := NewLoadBalancer(config, log.New(io.Discard, "", 0))
+	t.Cleanup(lb.Stop)
+	for _, url := range urls {
+		require.NoError(t, lb.AddServer(url, 1))
+	}
+	return lb
+}
+
+// selected returns the distinct servers NextServer picks over a few rounds
+func selected(lb *LoadBalancer) map[string]bool {
+	urls := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		if server := lb.NextServer("10.0.0.1"); server != nil {
+			urls[server.URL.String()] = true
+		}
+	}
+	return urls
+}
+
+func TestHealthCheck_ExcludesUnreadyInstanceUntilItRecovers(t *testing.T) {
+	healthy, _ := instance(t)
+	degraded, ready := instance(t)
+	lb := probingBalancer(t, healthy.URL, degraded.URL)
+
+	ready.Store(false)
+	lb.CheckHealth()
+	assert.True(t, selected(lb)[degraded.URL], "one failed probe is below the unhealthy threshold")
+
+	lb.CheckHealth()
+	assert.Equal(t, map[string]bool{healthy.URL: true}, selected(lb), "the unready instance must leave the rotation")
+
+	ready.Store(true)
+	lb.CheckHealth()
+	assert.False(t, selected(lb)[degraded.URL], "one good probe is below the healthy threshold")
+
+	lb.CheckHealth()
+	assert.Equal(t, map[string]bool{healthy.URL: true, degraded.URL: true}, selected(lb), "the recovered instance must rejoin the rotation")
+}
+
+func TestHealthCheck_UnreachableInstanceIsExcluded(t *testing.T) {
+	healthy, _ := instance(t)
+	gone, _ := instance(t)
+	lb := probingBalancer(t, healthy.URL, gone.URL)
+	gone.Close()
+
+	lb.CheckHealth()
+	lb.CheckHealth()
+
+	assert.Equal(t, map[string]bool{healthy.URL: true}, selected(lb))
+}
+
+func TestHealthCheck_NoHealthyInstances(t *testing.T) {
+	server, ready := instance(t)
+	lb := probingBalancer(t, server.URL)
+
+	ready.Store(false)
+	lb.CheckHealth()
+	lb.CheckHealth()
+
+	assert.Nil(t, lb.NextServer("10.0.0.1"))
+}
+
+func TestHealthCheck_ProbesPeriodically(t *testing.T) {
+	server, ready := instance(t)
+	config := DefaultConfig()
+	config.HealthCheckInterval = 10 * time.Millisecond
+	config.UnhealthyThreshold = 1
+	lb := NewLoadBalancer(config, log.New(io.Discard, "", 0))
+	t.Cleanup(lb.Stop)
+	require.NoError(t, lb.AddServer(server.URL, 1))
+
+	ready.Store(false)
+	assert.Eventually(t, func() bool {
+		return lb.NextServer("10.0.0.1") == nil
+	}, time.Second, 10*time.Millisecond)
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -38,6 +39,8 @@ type SecurityConfig struct {
 		SecretKey     []byte
 		TokenExpiry   time.Duration
 		RefreshExpiry time.Duration
+		// Algorithms is the allowlist of HMAC signing algorithms; HS256 if empty
+		Algorithms []string
 	}
 	FileUpload struct {
 		MaxSize      int64
@@ -218,6 +221,26 @@ func (sm *SecurityMiddleware) TLSEnforcement() gin.HandlerFunc {
 	}
 }
 
+// JWTAlgorithmsFromEnv reads the allowed signing algorithms from
+// JWT_ALLOWED_ALGORITHMS (e.g. "HS256,HS512")
+func JWTAlgorithmsFromEnv() []string {
+	var algorithms []string
+	for _, alg := range strings.Split(os.Getenv("JWT_ALLOWED_ALGORITHMS"), ",") {
+		if alg = strings.TrimSpace(alg); alg != "" {
+			algorithms = append(algorithms, alg)
+		}
+	}
+	return algorithms
+}
+
+// jwtAlgorithms returns the configured signing algorithm allowlist
+func (sm *SecurityMiddleware) jwtAlgorithms() []string {
+	if len(sm.config.JWT.Algorithms) == 0 {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	return sm.config.JWT.Algorithms
+}
+
 // JWTValidation middleware validates JWT tokens
 func (sm *SecurityMiddleware) JWTValidation() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -244,7 +267,12 @@ func (sm *SecurityMiddleware) JWTValidation() gin.HandlerFunc {
 
 		// Parse and validate token
 		claims := &jwt.StandardClaims{}
-		parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		parser := &jwt.Parser{ValidMethods: sm.jwtAlgorithms()}
+		parsedToken, err := parser.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+			// The secret is an HMAC key; never use it to verify other algorithms
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
 			return sm.config.JWT.SecretKey, nil
 		})
 
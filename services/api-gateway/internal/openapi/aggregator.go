@@ -0,0 +1,280 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Upstream describes a service whose API spec is merged into the gateway spec
+type Upstream struct {
+	// Name namespaces conflicting schemas, e.g. "investment"
+	Name string `mapstructure:"name"`
+	// SpecURL is the upstream's swagger.json / openapi.json
+	SpecURL string `mapstructure:"spec_url"`
+	// StripPrefix is removed from upstream paths before Prefix is added
+	StripPrefix string `mapstructure:"strip_prefix"`
+	// Prefix is the gateway routing prefix for the upstream's paths
+	Prefix string `mapstructure:"prefix"`
+}
+
+// Config holds spec aggregation configuration
+type Config struct {
+	Title           string        `mapstructure:"title"`
+	Version         string        `mapstructure:"version"`
+	Upstreams       []Upstream    `mapstructure:"upstreams"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	FetchTimeout    time.Duration `mapstructure:"fetch_timeout"`
+}
+
+// Aggregator fetches upstream API specs and serves them merged into one document
+type Aggregator struct {
+	config Config
+	client *http.Client
+	mutex  sync.RWMutex
+	merged []byte
+	stopCh chan struct{}
+}
+
+// NewAggregator creates a new spec aggregator
+func NewAggregator(config Config) *Aggregator {
+	if config.FetchTimeout == 0 {
+		config.FetchTimeout = 10 * time.Second
+	}
+
+	return &Aggregator{
+		config: config,
+		client: &http.Client{Timeout: config.FetchTimeout},
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start performs an initial refresh and keeps the merged spec up to date
+func (a *Aggregator) Start() {
+	if err := a.Refresh(context.Background()); err != nil {
+		log.Printf("openapi: initial spec aggregation failed: %v", err)
+	}
+
+	if a.config.RefreshInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(a.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.Refresh(context.Background()); err != nil {
+					log.Printf("openapi: spec aggregation failed: %v", err)
+				}
+			case <-a.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background refresh
+func (a *Aggregator) Stop() {
+	close(a.stopCh)
+}
+
+// Spec returns the cached merged spec, or nil if none has been built yet
+func (a *Aggregator) Spec() []byte {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.merged
+}
+
+// Refresh fetches every upstream spec and rebuilds the merged spec. Upstreams
+// that are unavailable are left out with a warning rather than failing the merge.
+func (a *Aggregator) Refresh(ctx context.Context) error {
+	merged := map[string]interface{}{
+		"info": map[string]interface{}{
+			"title":   a.config.Title,
+			"version": a.config.Version,
+		},
+		"paths": map[string]interface{}{},
+	}
+
+	var schemaKey []string
+	included := 0
+
+	for _, upstream := range a.config.Upstreams {
+		doc, err := a.fetch(ctx, upstream)
+		if err != nil {
+			log.Printf("openapi: excluding %s from merged spec: %v", upstream.Name, err)
+			continue
+		}
+
+		// The merged document uses the format of the first available upstream
+		version, key := specFormat(doc)
+		if schemaKey == nil {
+			schemaKey = key
+			merged[version] = doc[version]
+		} else if strings.Join(key, ".") != strings.Join(schemaKey, ".") {
+			log.Printf("openapi: excluding %s from merged spec: spec format differs from other upstreams", upstream.Name)
+			continue
+		}
+
+		mergeSpec(merged, doc, upstream, schemaKey)
+		included++
+	}
+
+	if included == 0 && len(a.config.Upstreams) > 0 {
+		return fmt.Errorf("no upstream specs available")
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to encode merged spec: %w", err)
+	}
+
+	a.mutex.Lock()
+	a.merged = data
+	a.mutex.Unlock()
+
+	return nil
+}
+
+// fetch downloads and decodes an upstream spec
+func (a *Aggregator) fetch(ctx context.Context, upstream Upstream) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.SpecURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	return doc, nil
+}
+
+// specFormat returns the version field and the location of the schema section
+// for a Swagger 2.0 or OpenAPI 3.x document
+func specFormat(doc map[string]interface{}) (string, []string) {
+	if _, ok := doc["openapi"]; ok {
+		return "openapi", []string{"components", "schemas"}
+	}
+	return "swagger", []string{"definitions"}
+}
+
+// mergeSpec adds an upstream's paths and schemas to the merged spec. Schemas that
+// clash with a different schema of the same name are renamed to "<upstream>.<name>"
+// and references to them are rewritten.
+func mergeSpec(merged, doc map[string]interface{}, upstream Upstream, schemaKey []string) {
+	mergedSchemas := section(merged, schemaKey, true)
+	upstreamSchemas := section(doc, schemaKey, false)
+	refPrefix := "#/" + strings.Join(schemaKey, "/") + "/"
+
+	renames := make(map[string]string)
+	for _, name := range sortedKeys(upstreamSchemas) {
+		if existing, ok := mergedSchemas[name]; ok && !reflect.DeepEqual(existing, upstreamSchemas[name]) {
+			renames[refPrefix+name] = refPrefix + upstream.Name + "." + name
+		}
+	}
+
+	for _, name := range sortedKeys(upstreamSchemas) {
+		schema := rewriteRefs(upstreamSchemas[name], renames)
+		if renamed, ok := renames[refPrefix+name]; ok {
+			name = strings.TrimPrefix(renamed, refPrefix)
+		}
+		mergedSchemas[name] = schema
+	}
+
+	mergedPaths := merged["paths"].(map[string]interface{})
+	if paths, ok := doc["paths"].(map[string]interface{}); ok {
+		for path, item := range paths {
+			mergedPaths[gatewayPath(path, upstream)] = rewriteRefs(item, renames)
+		}
+	}
+}
+
+// gatewayPath maps an upstream path to the path the gateway exposes it on
+func gatewayPath(path string, upstream Upstream) string {
+	if upstream.StripPrefix != "" {
+		path = strings.TrimPrefix(path, upstream.StripPrefix)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return strings.TrimSuffix(upstream.Prefix, "/") + path
+}
+
+// section returns the nested object at key, optionally creating it
+func section(doc map[string]interface{}, key []string, create bool) map[string]interface{} {
+	current := doc
+	for _, k := range key {
+		next, ok := current[k].(map[string]interface{})
+		if !ok {
+			if !create {
+				return map[string]interface{}{}
+			}
+			next = map[string]interface{}{}
+			current[k] = next
+		}
+		current = next
+	}
+	return current
+}
+
+// rewriteRefs returns a copy of v with renamed $ref targets replaced
+func rewriteRefs(v interface{}, renames map[string]string) interface{} {
+	if len(renames) == 0 {
+		return v
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, child := range value {
+			if ref, ok := child.(string); ok && k == "$ref" {
+				if renamed, ok := renames[ref]; ok {
+					out[k] = renamed
+					continue
+				}
+			}
+			out[k] = rewriteRefs(child, renames)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, child := range value {
+			out[i] = rewriteRefs(child, renames)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// sortedKeys returns the keys of m in a stable order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
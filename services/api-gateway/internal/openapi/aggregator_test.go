@@ -0,0 +1,102 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func specServer(t *testing.T, spec string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(spec))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+const investmentSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/api/v1/investments": {
+      "get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Response"}}}}
+    }
+  },
+  "definitions": {
+    "Response": {"type": "object", "properties": {"investments": {"type": "array"}}},
+    "ErrorResponse": {"type": "object", "properties": {"error": {"type": "string"}}}
+  }
+}`
+
+const userSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/users": {
+      "get": {"responses": {"200": {"schema": {"$ref": "#/definitions/Response"}}}}
+    }
+  },
+  "definitions": {
+    "Response": {"type": "object", "properties": {"users": {"type": "array"}}},
+    "ErrorResponse": {"type": "object", "properties": {"error": {"type": "string"}}}
+  }
+}`
+
+func TestRefresh_MergesUpstreamSpecs(t *testing.T) {
+	investment := specServer(t, investmentSpec)
+	user := specServer(t, userSpec)
+
+	aggregator := NewAggregator(Config{
+		Title:   "SparkFund API",
+		Version: "1.0",
+		Upstreams: []Upstream{
+			{Name: "investment", SpecURL: investment.URL},
+			{Name: "user", SpecURL: user.URL, Prefix: "/api/v1"},
+		},
+	})
+
+	require.NoError(t, aggregator.Refresh(context.Background()))
+
+	var merged map[string]interface{}
+	require.NoError(t, json.Unmarshal(aggregator.Spec(), &merged))
+
+	paths := merged["paths"].(map[string]interface{})
+	assert.Contains(t, paths, "/api/v1/investments")
+	assert.Contains(t, paths, "/api/v1/users")
+
+	definitions := merged["definitions"].(map[string]interface{})
+	// Identical schemas are shared, conflicting ones are namespaced
+	assert.Contains(t, definitions, "ErrorResponse")
+	assert.Contains(t, definitions, "Response")
+	assert.Contains(t, definitions, "user.Response")
+
+	userRef := paths["/api/v1/users"].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})["200"].(map[string]interface{})["schema"].(map[string]interface{})["$ref"]
+	assert.Equal(t, "#/definitions/user.Response", userRef)
+}
+
+func TestRefresh_ExcludesUnavailableUpstream(t *testing.T) {
+	investment := specServer(t, investmentSpec)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	aggregator := NewAggregator(Config{
+		Upstreams: []Upstream{
+			{Name: "investment", SpecURL: investment.URL},
+			{Name: "user", SpecURL: down.URL},
+		},
+	})
+
+	require.NoError(t, aggregator.Refresh(context.Background()))
+
+	var merged map[string]interface{}
+	require.NoError(t, json.Unmarshal(aggregator.Spec(), &merged))
+	paths := merged["paths"].(map[string]interface{})
+	assert.Len(t, paths, 1)
+	assert.Contains(t, paths, "/api/v1/investments")
+}
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIPage renders Swagger UI against the merged spec
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>SparkFund API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+// SpecHandler serves the cached merged spec
+func (a *Aggregator) SpecHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		spec := a.Spec()
+		if spec == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API spec not available yet"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json", spec)
+	}
+}
+
+// UIHandler serves Swagger UI for the merged spec
+func (a *Aggregator) UIHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+	}
+}
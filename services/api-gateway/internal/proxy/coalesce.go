@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// upstreamResponse is a fully read upstream response that can be written to
+// several clients
+type upstreamResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// coalescedCall is an upstream request that identical requests are waiting on
+type coalescedCall struct {
+	done chan struct{}
+	resp *upstreamResponse
+	err  error
+}
+
+// coalescer makes identical concurrent requests share a single upstream call.
+// Unlike a cache it never serves a response after its call has finished.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{calls: make(map[string]*coalescedCall)}
+}
+
+// Do runs fn once for all concurrent callers with the same key and returns its
+// result to each of them. shared reports whether the result was also given to
+// other callers.
+func (c *coalescer) Do(key string, fn func() (*upstreamResponse, error)) (resp *upstreamResponse, shared bool, err error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.resp, true, call.err
+	}
+
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.resp, call.err = fn()
+	return call.resp, false, call.err
+}
+
+// identityHeaders identify the caller; responses are only shared between
+// requests that carry exactly the same values
+var identityHeaders = []string{"Authorization", "Cookie", "X-API-Key"}
+
+// coalesceKey identifies requests that may share an upstream response: the same
+// method, path and query, the same representation, and the same caller. The
+// upstream instance is not part of the key, so requests share a call whichever
+// instance it went to. The identity is hashed so credentials are not kept in
+// the map.
+func coalesceKey(r *http.Request) string {
+	identity := sha256.New()
+	for _, name := range identityHeaders {
+		for _, value := range r.Header.Values(name) {
+			identity.Write([]byte(name))
+			identity.Write([]byte{0})
+			identity.Write([]byte(value))
+			identity.Write([]byte{0})
+		}
+	}
+
+	target := r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	return r.Method + " " + target +
+		"|accept=" + r.Header.Get("Accept") +
+		"|encoding=" + r.Header.Get("Accept-Encoding") +
+		"|identity=" + hex.EncodeToString(identity.Sum(nil))
+}
+
+// coalescible reports whether r may share an upstream call with identical requests
+func coalescible(r *http.Request) bool {
+	return r.Method == http.MethodGet && r.Header.Get("Cache-Control") != "no-cache"
+}
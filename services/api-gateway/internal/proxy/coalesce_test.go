@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sparkfund/api-gateway/internal/loadbalancer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream starts a fake Investment Service that holds requests until release is
+// closed, echoes the caller's Authorization header and counts requests
+func upstream(t *testing.T, release <-chan struct{}) *int32 {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"caller":"` + r.Header.Get("Authorization") + `"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	parts := strings.Split(server.URL, ":")
+	oldURL, oldPort := investmentServiceURL, investmentServicePort
+	investmentServiceURL = strings.Join(parts[:len(parts)-1], ":")
+	investmentServicePort = parts[len(parts)-1]
+	t.Cleanup(func() { investmentServiceURL, investmentServicePort = oldURL, oldPort })
+
+	return &calls
+}
+
+func proxyRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Any("/api/v1/investments/*path", ProxyToInvestmentService)
+	return r
+}
+
+// sendConcurrently issues one request per authorization value at the same time
+func sendConcurrently(router http.Handler, method, target string, authorizations []string) []*httptest.ResponseRecorder {
+	recorders := make([]*httptest.ResponseRecorder, len(authorizations))
+	var wg sync.WaitGroup
+	for i, auth := range authorizations {
+		wg.Add(1)
+		go func(i int, auth string) {
+			defer wg.Done()
+			req := httptest.NewRequest(method, target, nil)
+			req.Header.Set("Authorization", auth)
+			recorders[i] = httptest.NewRecorder()
+			router.ServeHTTP(recorders[i], req)
+		}(i, auth)
+	}
+	wg.Wait()
+	return recorders
+}
+
+// waitForCalls waits until the fake upstream has received n requests
+func waitForCalls(t *testing.T, calls *int32, n int32) {
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(calls) < n && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProxy_CoalescesIdenticalConcurrentGETs(t *testing.T) {
+	release := make(chan struct{})
+	calls := upstream(t, release)
+	router := proxyRouter()
+
+	const n = 10
+	auth := make([]string, n)
+	for i := range auth {
+		auth[i] = "Bearer alice"
+	}
+
+	done := make(chan []*httptest.ResponseRecorder)
+	go func() { done <- sendConcurrently(router, http.MethodGet, "/api/v1/investments/?page=1", auth) }()
+
+	waitForCalls(t, calls, 1)
+	time.Sleep(50 * time.Millisecond) // let the duplicates join the in-flight call
+	close(release)
+	recorders := <-done
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	for _, w := range recorders {
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Bearer alice")
+	}
+}
+
+func TestProxy_CoalescesAcrossBalancedInstances(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	config := loadbalancer.DefaultConfig()
+	config.HealthCheckEnabled = false
+	lb := loadbalancer.NewLoadBalancer(config, log.New(io.Discard, "", 0))
+	t.Cleanup(lb.Stop)
+	for i := 0; i < 3; i++ {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(server.Close)
+		require.NoError(t, lb.AddServer(server.URL, 1))
+	}
+	UseInvestmentBalancer(lb)
+	t.Cleanup(func() { UseInvestmentBalancer(nil) })
+	router := proxyRouter()
+
+	const n = 10
+	auth := make([]string, n)
+	for i := range auth {
+		auth[i] = "Bearer alice"
+	}
+
+	done := make(chan []*httptest.ResponseRecorder)
+	go func() { done <- sendConcurrently(router, http.MethodGet, "/api/v1/investments/?page=1", auth) }()
+
+	waitForCalls(t, &calls, 1)
+	time.Sleep(50 * time.Millisecond) // let the duplicates join the in-flight call
+
+	// Only the request making the upstream call holds an instance
+	var load int64
+	for _, server := range lb.GetServers() {
+		load += atomic.LoadInt64(&server.CurrentLoad)
+	}
+	assert.Equal(t, int64(1), load)
+
+	close(release)
+	recorders := <-done
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, w := range recorders {
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestProxy_DoesNotShareResponsesBetweenCallers(t *testing.T) {
+	release := make(chan struct{})
+	calls := upstream(t, release)
+	router := proxyRouter()
+
+	done := make(chan []*httptest.ResponseRecorder)
+	go func() {
+		done <- sendConcurrently(router, http.MethodGet, "/api/v1/investments/1", []string{"Bearer alice", "Bearer bob"})
+	}()
+
+	waitForCalls(t, calls, 2)
+	close(release)
+	recorders := <-done
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.Contains(t, recorders[0].Body.String(), "Bearer alice")
+	assert.Contains(t, recorders[1].Body.String(), "Bearer bob")
+}
+
+func TestProxy_DoesNotCoalesceWrites(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	calls := upstream(t, release)
+	router := proxyRouter()
+
+	sendConcurrently(router, http.MethodDelete, "/api/v1/investments/1", []string{"Bearer alice", "Bearer alice", "Bearer alice"})
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestCoalesceKey_IncludesIdentityAndQuery(t *testing.T) {
+	request := func(target, auth string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", auth)
+		return req
+	}
+
+	base := coalesceKey(request("/a?x=1", "Bearer alice"))
+	require.Equal(t, base, coalesceKey(request("/a?x=1", "Bearer alice")))
+	assert.NotEqual(t, base, coalesceKey(request("/a?x=1", "Bearer bob")))
+	assert.NotEqual(t, base, coalesceKey(request("/a?x=2", "Bearer alice")))
+	assert.NotEqual(t, base, coalesceKey(request("/b?x=1", "Bearer alice")))
+	assert.NotContains(t, base, "alice", "credentials must not be kept in the key")
+}
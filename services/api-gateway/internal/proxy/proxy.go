@@ -7,8 +7,10 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sparkfund/api-gateway/internal/loadbalancer"
 )
 
 var investmentServiceURL = os.Getenv("INVESTMENT_SERVICE_URL")
@@ -23,23 +25,108 @@ func init() {
 	}
 }
 
-// ProxyToInvestmentService forwards the request to the Investment Service
+// investmentBalancer, when set, spreads requests over health-checked Investment
+// Service instances instead of sending them all to INVESTMENT_SERVICE_URL
+var investmentBalancer *loadbalancer.LoadBalancer
+
+// UseInvestmentBalancer routes Investment Service requests through lb, which
+// only selects instances passing their health checks
+func UseInvestmentBalancer(lb *loadbalancer.LoadBalancer) {
+	investmentBalancer = lb
+}
+
+// requests coalesces identical concurrent GETs to the Investment Service
+var requests = newCoalescer()
+
+// proxyError is a failure to forward a request, with the status returned to the client
+type proxyError struct {
+	status  int
+	message string
+}
+
+func (e *proxyError) Error() string {
+	return e.message
+}
+
+// ProxyToInvestmentService forwards the request to the Investment Service.
+// Identical concurrent GETs from the same caller share one upstream request.
 func ProxyToInvestmentService(c *gin.Context) {
+	call := func() (*upstreamResponse, error) {
+		return forwardToInvestmentService(c.Request, c.ClientIP())
+	}
+
+	var resp *upstreamResponse
+	var err error
+	if coalescible(c.Request) {
+		resp, _, err = requests.Do(coalesceKey(c.Request), call)
+	} else {
+		resp, err = call()
+	}
+
+	if err != nil {
+		status := http.StatusBadGateway
+		if proxyErr, ok := err.(*proxyError); ok {
+			status = proxyErr.status
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Copy response headers
+	for key, values := range resp.header {
+		for _, value := range values {
+			c.Header(key, value)
+		}
+	}
+
+	// Set response status code
+	c.Status(resp.status)
+
+	// Write response body
+	if len(resp.body) > 0 {
+		// Try to pretty print JSON responses
+		var prettyJSON bytes.Buffer
+		if err := json.Indent(&prettyJSON, resp.body, "", "  "); err == nil {
+			c.Data(resp.status, "application/json", prettyJSON.Bytes())
+		} else {
+			c.Data(resp.status, resp.header.Get("Content-Type"), resp.body)
+		}
+	}
+}
+
+// forwardToInvestmentService picks an Investment Service instance for clientIP
+// and forwards the request to it. Coalesced requests share one call, so only the
+// request that makes it adds to the instance's load.
+func forwardToInvestmentService(incoming *http.Request, clientIP string) (*upstreamResponse, error) {
+	baseURL := fmt.Sprintf("%s:%s", investmentServiceURL, investmentServicePort)
+	if investmentBalancer != nil {
+		server := investmentBalancer.NextServer(clientIP)
+		if server == nil {
+			return nil, &proxyError{status: http.StatusServiceUnavailable, message: "No healthy upstream available"}
+		}
+		investmentBalancer.IncrementLoad(server)
+		defer investmentBalancer.DecrementLoad(server)
+		baseURL = strings.TrimSuffix(server.URL.String(), "/")
+	}
+
 	// Construct the target URL
-	targetURL := fmt.Sprintf("%s:%s%s", investmentServiceURL, investmentServicePort, c.Request.URL.Path)
-	if c.Request.URL.RawQuery != "" {
-		targetURL += "?" + c.Request.URL.RawQuery
+	targetURL := baseURL + incoming.URL.Path
+	if incoming.URL.RawQuery != "" {
+		targetURL += "?" + incoming.URL.RawQuery
 	}
+	return forward(incoming, targetURL)
+}
 
+// forward sends the incoming request to targetURL and reads the whole response
+func forward(incoming *http.Request, targetURL string) (*upstreamResponse, error) {
 	// Create a new request
-	req, err := http.NewRequest(c.Request.Method, targetURL, c.Request.Body)
+	req, err := http.NewRequest(incoming.Method, targetURL, incoming.Body)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
+		return nil, &proxyError{status: http.StatusInternalServerError, message: "Failed to create request"}
 	}
 
 	// Copy headers from the original request
-	for key, values := range c.Request.Header {
+	for key, values := range incoming.Header {
 		for _, value := range values {
 			req.Header.Add(key, value)
 		}
@@ -56,36 +143,15 @@ func ProxyToInvestmentService(c *gin.Context) {
 	// Send the request
 	resp, err := client.Do(req)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to forward request"})
-		return
+		return nil, &proxyError{status: http.StatusBadGateway, message: "Failed to forward request"}
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response"})
-		return
+		return nil, &proxyError{status: http.StatusInternalServerError, message: "Failed to read response"}
 	}
 
-	// Copy response headers
-	for key, values := range resp.Header {
-		for _, value := range values {
-			c.Header(key, value)
-		}
-	}
-
-	// Set response status code
-	c.Status(resp.StatusCode)
-
-	// Write response body
-	if len(body) > 0 {
-		// Try to pretty print JSON responses
-		var prettyJSON bytes.Buffer
-		if err := json.Indent(&prettyJSON, body, "", "  "); err == nil {
-			c.Data(resp.StatusCode, "application/json", prettyJSON.Bytes())
-		} else {
-			c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), body)
-		}
-	}
-} 
\ No newline at end of file
+	return &upstreamResponse{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
@@ -0,0 +1,190 @@
+// Package routing tolerates minor variations in request paths, such as a
+// missing trailing slash or different letter case, so they reach the route the
+// client meant instead of a 404.
+package routing
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mode selects how strictly request paths must match registered routes
+type Mode string
+
+const (
+	// ModeStrict routes only exact matches; anything else is a 404
+	ModeStrict Mode = "strict"
+	// ModeLenient also routes paths that differ from a route only by a trailing
+	// slash or, with CaseInsensitive, by the case of its fixed segments
+	ModeLenient Mode = "lenient"
+)
+
+// Config holds route normalization configuration
+type Config struct {
+	Mode Mode
+	// Redirect sends clients to the canonical path instead of rewriting the
+	// request internally
+	Redirect bool
+	// CaseInsensitive matches fixed path segments regardless of case. Segments
+	// captured by route parameters, such as IDs, keep their case.
+	CaseInsensitive bool
+	// Exempt lists path prefixes that are always matched strictly, for routes
+	// where case or a trailing slash is meaningful
+	Exempt []string
+}
+
+// DefaultConfig returns the default route normalization configuration
+func DefaultConfig() Config {
+	return Config{Mode: ModeLenient}
+}
+
+// Normalizer wraps a gin engine, mapping request paths onto its registered routes
+// before they are routed
+type Normalizer struct {
+	engine *gin.Engine
+	config Config
+
+	once   sync.Once
+	routes map[string][]route
+}
+
+// route is a registered route split into path segments
+type route struct {
+	segments      []string
+	trailingSlash bool
+}
+
+// NewNormalizer wraps engine. gin's own trailing slash and fixed path redirects
+// are turned off so the configured mode alone decides what matches.
+func NewNormalizer(engine *gin.Engine, config Config) *Normalizer {
+	if config.Mode != ModeLenient {
+		config.Mode = ModeStrict
+	}
+	engine.RedirectTrailingSlash = false
+	engine.RedirectFixedPath = false
+
+	return &Normalizer{engine: engine, config: config}
+}
+
+// ServeHTTP implements http.Handler
+func (n *Normalizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if n.config.Mode == ModeLenient && !n.exempt(r.URL.Path) {
+		if canonical, ok := n.canonical(r.Method, r.URL.Path); ok && canonical != r.URL.Path {
+			if n.config.Redirect {
+				redirect(w, r, canonical)
+				return
+			}
+			r.URL.Path = canonical
+			r.URL.RawPath = ""
+		}
+	}
+	n.engine.ServeHTTP(w, r)
+}
+
+func (n *Normalizer) exempt(path string) bool {
+	for _, prefix := range n.config.Exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns path rewritten into the form of the route it leniently
+// matches. A path that already matches a route exactly is returned unchanged, so
+// an ID that happens to spell a fixed segment in another case is never rewritten.
+func (n *Normalizer) canonical(method, path string) (string, bool) {
+	// Routes are read on first use, once every route has been registered
+	n.once.Do(n.loadRoutes)
+
+	segments, trailingSlash := split(path)
+	for _, rt := range n.routes[method] {
+		if _, ok := rt.match(segments, trailingSlash, false, false); ok {
+			return path, true
+		}
+	}
+	for _, rt := range n.routes[method] {
+		if canonical, ok := rt.match(segments, trailingSlash, true, n.config.CaseInsensitive); ok {
+			return canonical, true
+		}
+	}
+	return "", false
+}
+
+func (n *Normalizer) loadRoutes() {
+	n.routes = make(map[string][]route)
+	for _, info := range n.engine.Routes() {
+		segments, trailingSlash := split(info.Path)
+		n.routes[info.Method] = append(n.routes[info.Method], route{segments: segments, trailingSlash: trailingSlash})
+	}
+}
+
+// match reports whether the path segments match the route, returning the path
+// rewritten into the route's form. Lenient matching ignores the trailing slash;
+// foldCase also ignores the case of fixed segments.
+func (rt route) match(segments []string, trailingSlash, lenient, foldCase bool) (string, bool) {
+	if !lenient && trailingSlash != rt.trailingSlash {
+		return "", false
+	}
+
+	canonical := make([]string, 0, len(segments))
+	for i, pattern := range rt.segments {
+		if strings.HasPrefix(pattern, "*") && i <= len(segments) {
+			// A catch-all keeps the rest of the path as requested
+			canonical = append(canonical, segments[i:]...)
+			return join(canonical, trailingSlash), true
+		}
+		if i >= len(segments) {
+			return "", false
+		}
+		switch {
+		case strings.HasPrefix(pattern, ":"):
+			canonical = append(canonical, segments[i])
+		case pattern == segments[i]:
+			canonical = append(canonical, pattern)
+		case foldCase && strings.EqualFold(pattern, segments[i]):
+			canonical = append(canonical, pattern)
+		default:
+			return "", false
+		}
+	}
+	if len(segments) != len(rt.segments) {
+		return "", false
+	}
+	return join(canonical, rt.trailingSlash), true
+}
+
+// split breaks a path into its segments and whether it ends in a slash
+func split(path string) ([]string, bool) {
+	trimmed := strings.Trim(path, "/")
+	trailingSlash := len(path) > 1 && strings.HasSuffix(path, "/")
+	if trimmed == "" {
+		return nil, false
+	}
+	return strings.Split(trimmed, "/"), trailingSlash
+}
+
+func join(segments []string, trailingSlash bool) string {
+	path := "/" + strings.Join(segments, "/")
+	if trailingSlash && len(segments) > 0 {
+		path += "/"
+	}
+	return path
+}
+
+// redirect sends the client to canonical, keeping the query. Methods other than
+// GET and HEAD get a 308 so the client resends the same body.
+func redirect(w http.ResponseWriter, r *http.Request, canonical string) {
+	target := *r.URL
+	target.Path = canonical
+	target.RawPath = ""
+
+	status := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		status = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, r, target.RequestURI(), status)
+}
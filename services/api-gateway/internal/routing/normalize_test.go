@@ -0,0 +1,107 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatewayRouter registers routes shaped like the gateway's and echoes the path
+// and id each request was routed with
+func gatewayRouter(config Config) http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	echo := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"path": c.Request.URL.Path, "id": c.Param("id")})
+	}
+	router.GET("/api/v1/investments/", echo)
+	router.POST("/api/v1/investments/", echo)
+	router.GET("/api/v1/investments/:id", echo)
+	router.GET("/api/v1/investments/export", echo)
+	router.GET("/health", echo)
+	router.GET("/files/Reports/summary", echo)
+
+	return NewNormalizer(router, config)
+}
+
+func serve(handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestLenient_TrailingSlashVariantsRouteTheSame(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeLenient})
+
+	withSlash := serve(router, http.MethodGet, "/api/v1/investments/?page=2")
+	withoutSlash := serve(router, http.MethodGet, "/api/v1/investments?page=2")
+
+	assert.Equal(t, http.StatusOK, withSlash.Code)
+	assert.Equal(t, http.StatusOK, withoutSlash.Code)
+	assert.Equal(t, withSlash.Body.String(), withoutSlash.Body.String())
+
+	// The other direction: a route registered without a slash
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/health/").Code)
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/api/v1/investments/42/").Code)
+}
+
+func TestStrict_TrailingSlashVariantIsNotFound(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeStrict})
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/api/v1/investments/").Code)
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/api/v1/investments").Code)
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/health/").Code)
+}
+
+func TestLenient_CaseInsensitiveKeepsParameterCase(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeLenient, CaseInsensitive: true})
+
+	w := serve(router, http.MethodGet, "/API/v1/Investments/AbC-123")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"path":"/api/v1/investments/AbC-123","id":"AbC-123"}`, w.Body.String())
+
+	// Without case folding only the slash is forgiven
+	router = gatewayRouter(Config{Mode: ModeLenient})
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/api/v1/Investments").Code)
+}
+
+func TestLenient_ExactMatchIsNeverRewritten(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeLenient, CaseInsensitive: true})
+
+	// "EXPORT" is a valid id, so it must not be folded into the export route
+	w := serve(router, http.MethodGet, "/api/v1/investments/EXPORT")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"path":"/api/v1/investments/EXPORT","id":"EXPORT"}`, w.Body.String())
+}
+
+func TestLenient_ExemptPathsStayStrict(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeLenient, CaseInsensitive: true, Exempt: []string{"/files/"}})
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/files/Reports/summary").Code)
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/files/reports/summary").Code)
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/files/Reports/summary/").Code)
+}
+
+func TestLenient_Redirect(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeLenient, Redirect: true})
+
+	w := serve(router, http.MethodGet, "/api/v1/investments?page=2")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/v1/investments/?page=2", w.Header().Get("Location"))
+
+	// Writes are redirected with 308 so the body is resent
+	w = serve(router, http.MethodPost, "/api/v1/investments")
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/api/v1/investments/", w.Header().Get("Location"))
+}
+
+func TestLenient_UnknownPathIsNotFound(t *testing.T) {
+	router := gatewayRouter(Config{Mode: ModeLenient, CaseInsensitive: true})
+
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/api/v1/portfolios/").Code)
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodDelete, "/api/v1/investments/").Code)
+}
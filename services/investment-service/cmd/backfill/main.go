@@ -0,0 +1,106 @@
+// Command backfill fills new columns on existing investment-service rows in
+// throttled, resumable batches.
+//
+//	backfill -job investments_tenant -default-tenant acme -batch-size 500 -pause 100ms -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"investment-service/internal/config"
+	"investment-service/internal/database"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/backfill"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// jobs builds the available backfills from the command-line options
+func jobs(defaultTenant string) map[string]backfill.Job {
+	setTenant := func(rows *gorm.DB) error {
+		return rows.Update("tenant_id", defaultTenant).Error
+	}
+
+	return map[string]backfill.Job{
+		"investments_tenant": {
+			Name:    "investments_tenant",
+			Table:   "investments",
+			Pending: "tenant_id = ''",
+			Apply:   setTenant,
+		},
+		"investment_sagas_tenant": {
+			Name:    "investment_sagas_tenant",
+			Table:   "investment_sagas",
+			Pending: "tenant_id = ''",
+			Apply:   setTenant,
+		},
+	}
+}
+
+func main() {
+	defaults := backfill.DefaultConfig()
+	jobName := flag.String("job", "", "backfill job to run")
+	defaultTenant := flag.String("default-tenant", "", "tenant assigned to rows without one")
+	batchSize := flag.Int("batch-size", defaults.BatchSize, "rows updated per transaction")
+	pause := flag.Duration("pause", defaults.Pause, "delay between batches")
+	dryRun := flag.Bool("dry-run", false, "count the rows that would be backfilled without changing them")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	available := jobs(*defaultTenant)
+	job, ok := available[*jobName]
+	if !ok {
+		names := make([]string, 0, len(available))
+		for name := range available {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		logger.Fatal("Unknown backfill job", zap.String("job", *jobName), zap.String("available", strings.Join(names, ", ")))
+	}
+	if *defaultTenant == "" {
+		logger.Fatal("A default tenant is required")
+	}
+
+	if err := config.Load(); err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+	if err := database.InitDB(); err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+
+	// Stop after the current batch on SIGINT or SIGTERM; the next run resumes from there
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Backfills span every tenant
+	db := database.DB.WithContext(tenant.WithoutScope(ctx))
+
+	cursors := backfill.NewGormCursorStore(db)
+	if err := cursors.Migrate(); err != nil {
+		logger.Fatal("Failed to create cursor table", zap.Error(err))
+	}
+
+	runner := backfill.NewRunner(db, cursors, backfill.Config{
+		BatchSize: *batchSize,
+		Pause:     *pause,
+		DryRun:    *dryRun,
+	}, logger)
+
+	if _, err := runner.Run(tenant.WithoutScope(ctx), job); err != nil {
+		logger.Fatal("Backfill stopped", zap.String("job", job.Name), zap.Error(err))
+	}
+}
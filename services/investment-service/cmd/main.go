@@ -2,23 +2,30 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	_ "investment-service/docs"
 	"investment-service/internal/config"
 	"investment-service/internal/database"
 	"investment-service/internal/handlers"
 	"investment-service/internal/middleware"
+	"investment-service/internal/repositories"
+	"investment-service/internal/services"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/apiversion"
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+	"github.com/adil-faiyaz98/sparkfund/pkg/events"
+	sharedServer "github.com/adil-faiyaz98/sparkfund/pkg/server"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 )
 
 // @title           Investment Service API
@@ -39,6 +46,11 @@ import (
 
 var version = "development" // Replaced during build
 
+// apiVersions are the API versions served. Add a version's route group
+// alongside /api/v1 to serve it; unversioned /api requests get the version in
+// their API-Version header, or v1.
+var apiVersions = apiversion.Config{Prefix: "/api", Versions: []string{"v1"}, Default: "v1"}
+
 func main() {
 	// Set up logging
 	log := logrus.New()
@@ -63,6 +75,39 @@ func main() {
 	if err := database.InitDB(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
+	if cfg.Database.EnsureIndexes {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.MigrationLockTimeout)
+		database.EnsureIndexes(ctx)
+		cancel()
+	}
+
+	// Create investments through the saga, finishing any a previous instance left
+	sagaLogger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Failed to create saga logger: %v", err)
+	}
+	defer sagaLogger.Sync()
+
+	handlers.InvestmentSagas = services.NewInvestmentSagaOrchestrator(
+		repositories.NewSagaRepository(),
+		services.NewUserServiceSuitability(client.New(client.Config{
+			Service: "user-service",
+			BaseURL: cfg.ExternalServices.UserService.URL,
+			Timeout: cfg.ExternalServices.UserService.Timeout,
+		})),
+		services.NewAccountsFunds(client.New(client.Config{
+			Service: "accounts-service",
+			BaseURL: cfg.ExternalServices.Accounts.URL,
+			Timeout: cfg.ExternalServices.Accounts.Timeout,
+		})),
+		services.NewEventBusPublisher(events.NewBus(events.Config{})),
+		sagaLogger,
+	)
+	go func() {
+		if err := handlers.InvestmentSagas.Resume(context.Background()); err != nil {
+			log.Errorf("Failed to resume investment sagas: %v", err)
+		}
+	}()
 
 	// Set Gin mode
 	if os.Getenv("APP_ENV") == "production" {
@@ -102,6 +147,7 @@ func main() {
 	investments := api.Group("/investments")
 	{
 		investments.POST("/", handlers.CreateInvestment)
+		investments.GET("/summary", middleware.RequireRole("admin"), handlers.GetInvestmentSummary)
 		investments.GET("/:id", handlers.GetInvestment)
 		investments.GET("/", handlers.ListInvestments)
 		investments.PUT("/:id", handlers.UpdateInvestment)
@@ -119,21 +165,31 @@ func main() {
 	transactions := api.Group("/transactions")
 	{
 		transactions.POST("/", handlers.CreateTransaction)
+		transactions.GET("/", handlers.ListTransactions)
+		transactions.GET("/export", handlers.ExportTransactions)
 	}
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           apiversion.Negotiate(apiVersions, router),
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	// Cap open connections so a flood of clients cannot exhaust the service
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
 	}
+	ln = sharedServer.LimitListener(ln, cfg.Server.MaxConnections)
 
 	// Start server in goroutine
 	go func() {
 		log.Infof("Starting server on port %s", cfg.Server.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
@@ -143,12 +199,14 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	// Graceful shutdown
+	// Graceful shutdown: fail readiness, let the load balancer deregister, then drain
 	log.Info("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
+	err = sharedServer.Shutdown(server, sharedServer.ShutdownConfig{
+		PreStopDelay: cfg.Server.PreStopDelay,
+		DrainTimeout: cfg.Server.ShutdownTimeout,
+		Readiness:    &handlers.Readiness,
+	})
+	if err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
@@ -12,11 +12,24 @@ import (
 
 // SecurityService provides fraud and anomaly detection services
 type SecurityService struct {
-	fraudModel   *fraud.FraudDetectionModel
-	anomalyModel *anomaly.AnomalyDetectionModel
+	fraudModel   FraudModel
+	anomalyModel AnomalyModel
 	repository   Repository
 }
 
+// FraudModel scores transactions for fraud. fraud.FraudDetectionModel is the
+// production implementation.
+type FraudModel interface {
+	DetectFraud(transaction fraud.Transaction, userProfile fraud.UserProfile, userTransactionHistory []fraud.Transaction) fraud.FraudDetectionResult
+}
+
+// AnomalyModel scores transactions for anomalous behaviour.
+// anomaly.AnomalyDetectionModel is the production implementation.
+type AnomalyModel interface {
+	DetectAnomaly(transaction anomaly.TransactionData, userData anomaly.UserBehaviorData, marketData anomaly.MarketData) anomaly.AnomalyDetectionResult
+	BuildUserBehaviorData(userID string, transactions []anomaly.TransactionData) anomaly.UserBehaviorData
+}
+
 // Repository defines the interface for accessing security-related data
 type Repository interface {
 	// User profile methods
@@ -43,9 +56,15 @@ type Repository interface {
 
 // NewSecurityService creates a new security service
 func NewSecurityService(repository Repository) *SecurityService {
+	return NewSecurityServiceWithModels(repository, fraud.NewFraudDetectionModel(), anomaly.NewAnomalyDetectionModel())
+}
+
+// NewSecurityServiceWithModels creates a security service using the given
+// detection models, e.g. an alternative model or a deterministic fake in tests
+func NewSecurityServiceWithModels(repository Repository, fraudModel FraudModel, anomalyModel AnomalyModel) *SecurityService {
 	return &SecurityService{
-		fraudModel:   fraud.NewFraudDetectionModel(),
-		anomalyModel: anomaly.NewAnomalyDetectionModel(),
+		fraudModel:   fraudModel,
+		anomalyModel: anomalyModel,
 		repository:   repository,
 	}
 }
@@ -0,0 +1,112 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sparkfund/services/investment-service/internal/ai/anomaly"
+	"github.com/sparkfund/services/investment-service/internal/ai/fraud"
+)
+
+// fixedFraudModel is a deterministic FraudModel returning the same score for every transaction
+type fixedFraudModel struct {
+	score float64
+}
+
+func (m fixedFraudModel) DetectFraud(transaction fraud.Transaction, userProfile fraud.UserProfile, history []fraud.Transaction) fraud.FraudDetectionResult {
+	return fraud.FraudDetectionResult{TransactionID: transaction.ID, UserID: transaction.UserID, FraudScore: m.score}
+}
+
+// fixedAnomalyModel is a deterministic AnomalyModel returning the same score for every transaction
+type fixedAnomalyModel struct {
+	score float64
+}
+
+func (m fixedAnomalyModel) DetectAnomaly(transaction anomaly.TransactionData, userData anomaly.UserBehaviorData, marketData anomaly.MarketData) anomaly.AnomalyDetectionResult {
+	return anomaly.AnomalyDetectionResult{TransactionID: transaction.ID, UserID: transaction.UserID, AnomalyScore: m.score}
+}
+
+func (m fixedAnomalyModel) BuildUserBehaviorData(userID string, transactions []anomaly.TransactionData) anomaly.UserBehaviorData {
+	return anomaly.UserBehaviorData{UserID: userID, TransactionHistory: transactions}
+}
+
+// emptyRepository has no history for any user and discards everything saved.
+// Methods not overridden here fall through to the embedded nil interface and
+// panic if called.
+type emptyRepository struct {
+	Repository
+	savedFraud []fraud.FraudDetectionResult
+}
+
+var errNoData = errors.New("no data")
+
+func (r *emptyRepository) GetUserSecurityProfile(ctx context.Context, userID string) (*fraud.UserProfile, error) {
+	return nil, errNoData
+}
+
+func (r *emptyRepository) GetUserTransactions(ctx context.Context, userID string, limit int) ([]fraud.Transaction, error) {
+	return nil, errNoData
+}
+
+func (r *emptyRepository) GetUserBehaviorData(ctx context.Context, userID string) (*anomaly.UserBehaviorData, error) {
+	return nil, errNoData
+}
+
+func (r *emptyRepository) GetLatestMarketData(ctx context.Context) (*anomaly.MarketData, error) {
+	return nil, errNoData
+}
+
+func (r *emptyRepository) SaveFraudDetectionResult(ctx context.Context, result *fraud.FraudDetectionResult) error {
+	r.savedFraud = append(r.savedFraud, *result)
+	return nil
+}
+
+func (r *emptyRepository) SaveAnomalyDetectionResult(ctx context.Context, result *anomaly.AnomalyDetectionResult) error {
+	return nil
+}
+
+func (r *emptyRepository) SaveUserBehaviorData(ctx context.Context, data *anomaly.UserBehaviorData) error {
+	return nil
+}
+
+func (r *emptyRepository) SaveUserSecurityProfile(ctx context.Context, profile *fraud.UserProfile) error {
+	return nil
+}
+
+func TestAnalyzeTransaction_FlagsByCombinedScore(t *testing.T) {
+	tests := []struct {
+		name         string
+		fraudScore   float64
+		anomalyScore float64
+		wantLevel    string
+		wantAction   string
+	}{
+		{"low risk is approved", 0.1, 0.1, "LOW", "APPROVE"},
+		{"medium risk is reviewed", 0.5, 0.4, "MEDIUM", "REVIEW"},
+		{"high risk below the reject threshold is reviewed", 0.7, 0.6, "HIGH", "REVIEW"},
+		{"very high risk is rejected", 0.9, 0.9, "HIGH", "REJECT"},
+		// Fraud is weighted above anomalies: 0.6*1.0 + 0.4*0.0 = 0.6
+		{"certain fraud alone is high risk", 1.0, 0.0, "HIGH", "REVIEW"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &emptyRepository{}
+			svc := NewSecurityServiceWithModels(repo, fixedFraudModel{tt.fraudScore}, fixedAnomalyModel{tt.anomalyScore})
+
+			result, err := svc.AnalyzeTransaction(context.Background(), fraud.Transaction{ID: "tx-1", UserID: "user-1", Amount: 100})
+			if err != nil {
+				t.Fatalf("AnalyzeTransaction: %v", err)
+			}
+
+			if result.OverallRiskLevel != tt.wantLevel || result.RecommendedAction != tt.wantAction {
+				t.Fatalf("expected %s/%s, got %s/%s (score %.2f)",
+					tt.wantLevel, tt.wantAction, result.OverallRiskLevel, result.RecommendedAction, result.OverallRiskScore)
+			}
+			if len(repo.savedFraud) != 1 || repo.savedFraud[0].FraudScore != tt.fraudScore {
+				t.Fatalf("expected the fraud result to be saved, got %+v", repo.savedFraud)
+			}
+		})
+	}
+}
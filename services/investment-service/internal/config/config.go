@@ -18,12 +18,15 @@ type Config struct {
 	Environment string `mapstructure:"environment"`
 
 	Server struct {
-		Port            string        `mapstructure:"port"`
-		ReadTimeout     time.Duration `mapstructure:"read_timeout"`
-		WriteTimeout    time.Duration `mapstructure:"write_timeout"`
-		IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
-		ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
-		TrustedProxies  []string      `mapstructure:"trusted_proxies"`
+		Port              string        `mapstructure:"port"`
+		ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+		WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+		IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+		ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`
+		PreStopDelay      time.Duration `mapstructure:"pre_stop_delay"`
+		MaxConnections    int           `mapstructure:"max_connections"`
+		TrustedProxies    []string      `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
 
 	Database struct {
@@ -37,13 +40,37 @@ type Config struct {
 		MaxOpenConns    int           `mapstructure:"max_open_conns"`
 		ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 		ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+
+		// Guard bounds concurrent queries and fails fast while the database is unhealthy
+		Guard struct {
+			Enabled        bool          `mapstructure:"enabled"`
+			MaxConcurrent  int           `mapstructure:"max_concurrent"`
+			AcquireTimeout time.Duration `mapstructure:"acquire_timeout"`
+			FailureRatio   float64       `mapstructure:"failure_ratio"`
+			MinRequests    uint32        `mapstructure:"min_requests"`
+			OpenTimeout    time.Duration `mapstructure:"open_timeout"`
+		} `mapstructure:"guard"`
+
+		// StatementTimeouts bound how long Postgres runs one statement, by query type
+		StatementTimeouts struct {
+			Default   time.Duration `mapstructure:"default"`
+			Report    time.Duration `mapstructure:"report"`
+			Analytics time.Duration `mapstructure:"analytics"`
+		} `mapstructure:"statement_timeouts"`
+
+		// MigrationLockTimeout bounds startup migrations, including the wait for
+		// another replica's migrations to finish
+		MigrationLockTimeout time.Duration `mapstructure:"migration_lock_timeout"`
+		// EnsureIndexes creates missing query indexes at startup
+		EnsureIndexes bool `mapstructure:"ensure_indexes"`
 	} `mapstructure:"database"`
 
 	JWT struct {
-		Secret  string        `mapstructure:"secret"`
-		Expiry  time.Duration `mapstructure:"expiry"`
-		Refresh time.Duration `mapstructure:"refresh"`
-		Issuer  string        `mapstructure:"issuer"`
+		Secret     string        `mapstructure:"secret"`
+		Expiry     time.Duration `mapstructure:"expiry"`
+		Refresh    time.Duration `mapstructure:"refresh"`
+		Issuer     string        `mapstructure:"issuer"`
+		Algorithms []string      `mapstructure:"algorithms"` // allowed signing algorithms
 	} `mapstructure:"jwt"`
 
 	RateLimit struct {
@@ -117,6 +144,16 @@ type Config struct {
 			Retries  int           `mapstructure:"retries"`
 			Failover string        `mapstructure:"failover"`
 		} `mapstructure:"market_data_api"`
+		// UserService checks investment suitability for the investment saga
+		UserService struct {
+			URL     string        `mapstructure:"url"`
+			Timeout time.Duration `mapstructure:"timeout"`
+		} `mapstructure:"user_service"`
+		// Accounts reserves the funds for the investment saga
+		Accounts struct {
+			URL     string        `mapstructure:"url"`
+			Timeout time.Duration `mapstructure:"timeout"`
+		} `mapstructure:"accounts"`
 	} `mapstructure:"external_services"`
 
 	Cache struct {
@@ -217,9 +254,12 @@ func setDefaults() {
 
 	config.Server.Port = "8081"
 	config.Server.ReadTimeout = 5 * time.Second
+	config.Server.ReadHeaderTimeout = 5 * time.Second
 	config.Server.WriteTimeout = 10 * time.Second
 	config.Server.IdleTimeout = 120 * time.Second
 	config.Server.ShutdownTimeout = 30 * time.Second
+	config.Server.PreStopDelay = 5 * time.Second
+	config.Server.MaxConnections = 1000
 	config.Server.TrustedProxies = []string{"127.0.0.1", "172.16.0.0/12", "172.17.0.0/16", "192.168.0.0/16"}
 
 	config.Database.Host = "postgres"
@@ -231,10 +271,22 @@ func setDefaults() {
 	config.Database.MaxOpenConns = 100
 	config.Database.ConnMaxLifetime = time.Hour
 	config.Database.ConnMaxIdleTime = 10 * time.Minute
+	config.Database.Guard.Enabled = true
+	config.Database.Guard.MaxConcurrent = 100
+	config.Database.Guard.AcquireTimeout = 100 * time.Millisecond
+	config.Database.Guard.FailureRatio = 0.5
+	config.Database.Guard.MinRequests = 20
+	config.Database.Guard.OpenTimeout = 5 * time.Second
+	config.Database.StatementTimeouts.Default = 5 * time.Second
+	config.Database.StatementTimeouts.Report = 30 * time.Second
+	config.Database.StatementTimeouts.Analytics = 2 * time.Minute
+	config.Database.MigrationLockTimeout = 5 * time.Minute
+	config.Database.EnsureIndexes = true
 
 	config.JWT.Expiry = 24 * time.Hour
 	config.JWT.Refresh = 7 * 24 * time.Hour
 	config.JWT.Issuer = "sparkfund"
+	config.JWT.Algorithms = []string{"HS256"}
 
 	config.RateLimit.Requests = 60
 	config.RateLimit.Window = time.Minute
@@ -281,6 +333,10 @@ func setDefaults() {
 
 	config.ExternalServices.MarketDataAPI.Timeout = 10 * time.Second
 	config.ExternalServices.MarketDataAPI.Retries = 3
+	config.ExternalServices.UserService.URL = "http://user-service:8084"
+	config.ExternalServices.UserService.Timeout = 5 * time.Second
+	config.ExternalServices.Accounts.URL = "http://accounts-service:8080"
+	config.ExternalServices.Accounts.Timeout = 5 * time.Second
 
 	config.Cache.Enabled = true
 	config.Cache.TTL = 5 * time.Minute
@@ -10,6 +10,9 @@ import (
 	"investment-service/internal/config"
 	"investment-service/internal/models"
 
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -18,6 +21,10 @@ import (
 // DB is the global database connection
 var DB *gorm.DB
 
+// StatementTimeouts bound how long Postgres runs one statement of each query
+// type; repositories pass them to sharedDB.WithStatementTimeout
+var StatementTimeouts sharedDB.StatementTimeouts
+
 // MaxRetries is the maximum number of database connection attempts
 const MaxRetries = 5
 
@@ -38,6 +45,16 @@ func InitDB() error {
 		cfg.Database.SSLMode,
 	)
 
+	timeouts := sharedDB.StatementTimeouts{
+		sharedDB.QueryTypeDefault:   cfg.Database.StatementTimeouts.Default,
+		sharedDB.QueryTypeReport:    cfg.Database.StatementTimeouts.Report,
+		sharedDB.QueryTypeAnalytics: cfg.Database.StatementTimeouts.Analytics,
+	}
+	// Bound every statement by the default; longer query types raise it per transaction
+	if timeout := timeouts.For(sharedDB.QueryTypeDefault); timeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", timeout.Milliseconds())
+	}
+
 	// Configure GORM logger
 	gormLogger := logger.New(
 		log.New(log.Writer(), "\r\n", log.LstdFlags), // io writer
@@ -92,8 +109,29 @@ func InitDB() error {
 	sqlDB.SetConnMaxLifetime(30 * time.Minute) // Connection reuse timeout
 	sqlDB.SetConnMaxIdleTime(10 * time.Minute) // How long connections can remain idle
 
+	// Shed load instead of queueing on the pool when the database is slow or failing
+	if guardCfg := cfg.Database.Guard; guardCfg.Enabled {
+		guard := sharedDB.NewGuard("investment", sharedDB.GuardConfig{
+			MaxConcurrent:  guardCfg.MaxConcurrent,
+			AcquireTimeout: guardCfg.AcquireTimeout,
+			FailureRatio:   guardCfg.FailureRatio,
+			MinRequests:    guardCfg.MinRequests,
+			Interval:       10 * time.Second,
+			OpenTimeout:    guardCfg.OpenTimeout,
+		})
+		if err := db.Use(guard); err != nil {
+			return fmt.Errorf("failed to install database guard: %w", err)
+		}
+	}
+
+	// Isolate tenant-scoped models by the tenant in each statement's context
+	if err := db.Use(sharedDB.NewTenantScope()); err != nil {
+		return fmt.Errorf("failed to install tenant scope: %w", err)
+	}
+
 	// Set global DB variable
 	DB = db
+	StatementTimeouts = timeouts
 	log.Println("Database connected successfully")
 
 	return nil
@@ -106,10 +144,16 @@ func InitDBMigrations() error {
 		return err
 	}
 
-	// Then run migrations
-	if err := RunMigrations(DB); err != nil {
+	// Then run migrations; they span every tenant. Replicas starting together
+	// take turns, so only the first applies them.
+	ctx, cancel := context.WithTimeout(tenant.WithoutScope(context.Background()), config.Get().Database.MigrationLockTimeout)
+	defer cancel()
+	if err := sharedDB.WithMigrationLock(ctx, DB, "investment-service", RunMigrations); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	if config.Get().Database.EnsureIndexes {
+		EnsureIndexes(ctx)
+	}
 
 	log.Println("Database migrations completed successfully")
 	return nil
@@ -132,6 +176,10 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to migrate Transaction model: %w", err)
 	}
 
+	if err := db.AutoMigrate(&models.InvestmentSaga{}); err != nil {
+		return fmt.Errorf("failed to migrate InvestmentSaga model: %w", err)
+	}
+
 	return nil
 }
 
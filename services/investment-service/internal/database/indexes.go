@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"log"
+
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+)
+
+// Indexes are the composite indexes the history, export and summary queries
+// rely on. AutoMigrate only creates the indexes in the model tags, so these
+// are created by EnsureIndexes.
+var Indexes = []sharedDB.Index{
+	// Transaction history pages backwards through created_at and the export
+	// forwards over a date range, both for one user
+	{Name: "idx_transactions_user_created", Table: "transactions", Columns: []string{"user_id", "created_at", "id"}},
+	// A user's investments, filtered by status, within the caller's tenant
+	{Name: "idx_investments_tenant_user_status", Table: "investments", Columns: []string{"tenant_id", "user_id", "status"}},
+}
+
+// EnsureIndexes creates any of Indexes missing from DB. Failures are logged,
+// not returned: without an index queries are slow, not wrong.
+func EnsureIndexes(ctx context.Context) {
+	// Index builds span every tenant
+	created, err := sharedDB.EnsureIndexes(tenant.WithoutScope(ctx), DB, Indexes)
+	if err != nil {
+		log.Printf("Failed to create database indexes: %v", err)
+	}
+	if len(created) > 0 {
+		log.Printf("Created database indexes: %v", created)
+	}
+}
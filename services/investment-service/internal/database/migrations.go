@@ -108,6 +108,125 @@ func RunMigrations(db *gorm.DB) error {
 				return nil
 			},
 		},
+		{
+			ID: "202503281204",
+			Migrate: func(tx *gorm.DB) error {
+				// Add currency and an index covering the admin summary GROUP BY
+				if err := tx.Exec("ALTER TABLE investments ADD COLUMN IF NOT EXISTS currency varchar(3) NOT NULL DEFAULT 'USD'").Error; err != nil {
+					return err
+				}
+				return tx.Exec("CREATE INDEX IF NOT EXISTS idx_investments_summary ON investments(status, type, currency) INCLUDE (amount)").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if err := tx.Exec("DROP INDEX IF EXISTS idx_investments_summary").Error; err != nil {
+					return err
+				}
+				return tx.Exec("ALTER TABLE investments DROP COLUMN IF EXISTS currency").Error
+			},
+		},
+		{
+			ID: "202503281205",
+			Migrate: func(tx *gorm.DB) error {
+				// Create investment saga table
+				return tx.AutoMigrate(&models.InvestmentSaga{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("investment_sagas")
+			},
+		},
+		{
+			ID: "202503281206",
+			Migrate: func(tx *gorm.DB) error {
+				// Scope investments and sagas to a tenant; idempotency keys are unique per tenant
+				for _, stmt := range []string{
+					"ALTER TABLE investments ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT ''",
+					"CREATE INDEX IF NOT EXISTS idx_investments_tenant_id ON investments(tenant_id)",
+					"ALTER TABLE investment_sagas ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT ''",
+					"DROP INDEX IF EXISTS idx_investment_sagas_idempotency_key",
+					"CREATE UNIQUE INDEX IF NOT EXISTS idx_investment_sagas_tenant_key ON investment_sagas(tenant_id, idempotency_key)",
+				} {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				for _, stmt := range []string{
+					"DROP INDEX IF EXISTS idx_investment_sagas_tenant_key",
+					"CREATE UNIQUE INDEX IF NOT EXISTS idx_investment_sagas_idempotency_key ON investment_sagas(idempotency_key)",
+					"ALTER TABLE investment_sagas DROP COLUMN IF EXISTS tenant_id",
+					"DROP INDEX IF EXISTS idx_investments_tenant_id",
+					"ALTER TABLE investments DROP COLUMN IF EXISTS tenant_id",
+				} {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202503281207",
+			Migrate: func(tx *gorm.DB) error {
+				// Normalize investment statuses and types, then only allow the values in
+				// models.InvestmentStatuses and models.InvestmentTypes. Investments the
+				// service marked "failed" are cancelled.
+				for _, stmt := range []string{
+					"UPDATE investments SET status = UPPER(TRIM(status)), type = UPPER(TRIM(type))",
+					"UPDATE investments SET status = 'CANCELLED' WHERE status = 'FAILED'",
+					"ALTER TABLE investments DROP CONSTRAINT IF EXISTS chk_investments_status",
+					"ALTER TABLE investments ADD CONSTRAINT chk_investments_status CHECK (status IN ('PENDING', 'ACTIVE', 'SOLD', 'CANCELLED'))",
+					"ALTER TABLE investments DROP CONSTRAINT IF EXISTS chk_investments_type",
+					"ALTER TABLE investments ADD CONSTRAINT chk_investments_type CHECK (type IN ('STOCK', 'BOND', 'ETF', 'MUTUAL_FUND', 'CRYPTO', 'REAL_ESTATE', 'OTHER'))",
+				} {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				for _, stmt := range []string{
+					"ALTER TABLE investments DROP CONSTRAINT IF EXISTS chk_investments_type",
+					"ALTER TABLE investments DROP CONSTRAINT IF EXISTS chk_investments_status",
+				} {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			ID: "202503281208",
+			Migrate: func(tx *gorm.DB) error {
+				// Scope saga idempotency keys to their user and record the request
+				// each saga was started with
+				for _, stmt := range []string{
+					"ALTER TABLE investment_sagas ADD COLUMN IF NOT EXISTS request_hash varchar(64) NOT NULL DEFAULT ''",
+					"DROP INDEX IF EXISTS idx_investment_sagas_tenant_key",
+					"CREATE UNIQUE INDEX IF NOT EXISTS idx_investment_sagas_tenant_user_key ON investment_sagas(tenant_id, user_id, idempotency_key)",
+				} {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				for _, stmt := range []string{
+					"DROP INDEX IF EXISTS idx_investment_sagas_tenant_user_key",
+					"CREATE UNIQUE INDEX IF NOT EXISTS idx_investment_sagas_tenant_key ON investment_sagas(tenant_id, idempotency_key)",
+					"ALTER TABLE investment_sagas DROP COLUMN IF EXISTS request_hash",
+				} {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
 	})
 
 	return m.Migrate()
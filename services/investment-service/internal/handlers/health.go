@@ -6,9 +6,13 @@ import (
 
 	"investment-service/internal/database"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/server"
 	"github.com/gin-gonic/gin"
 )
 
+// Readiness is flipped to not-ready when the server begins shutting down
+var Readiness server.ReadinessFlag
+
 // HealthResponse contains service health information
 type HealthResponse struct {
 	Status    string            `json:"status"`
@@ -70,6 +74,14 @@ func HealthCheck(c *gin.Context) {
 // @Failure 503 {object} map[string]string
 // @Router /ready [get]
 func ReadinessCheck(c *gin.Context) {
+	if !Readiness.Ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not ready",
+			"reason": "shutting down",
+		})
+		return
+	}
+
 	// Check if database migrations are complete
 	if err := database.DB.Exec("SELECT 1 FROM investments LIMIT 1").Error; err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
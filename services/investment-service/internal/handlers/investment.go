@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"os/exec"
 	"encoding/json"
 	"regexp"
+	"strconv"
 	"time"
 
 	"investment-service/internal/database"
 	"investment-service/internal/models"
+	"investment-service/internal/services"
+	"investment-service/internal/validation"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/fields"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -39,6 +44,8 @@ func (h *InvestmentHandler) RegisterRoutes(r *gin.Engine) {
 	transactions := r.Group("/transactions")
 	{
 		transactions.POST("", CreateTransaction)
+		transactions.GET("", ListTransactions)
+		transactions.GET("/export", ExportTransactions)
 	}
 
 	portfolios := r.Group("/portfolios")
@@ -50,12 +57,19 @@ func (h *InvestmentHandler) RegisterRoutes(r *gin.Engine) {
 	}
 	r.POST("/stock-recommendation", h.GetStockRecommendation)
 }
+// InvestmentSagas creates investments when set, reserving the funds and
+// publishing the created event; without it investments are only inserted
+var InvestmentSagas *services.InvestmentSagaOrchestrator
+
 // @Accept       json
 // @Produce      json
-// @Param        investment  body      models.Investment  true  "Investment data"
-// @Success      201         {object}  models.Investment
-// @Failure      400         {object}  models.ErrorResponse
-// @Failure      500         {object}  models.ErrorResponse
+// @Param        Idempotency-Key  header    string             false  "Key making retries return the first outcome"
+// @Param        investment       body      models.Investment  true   "Investment data"
+// @Success      201              {object}  models.Investment
+// @Failure      400              {object}  models.ErrorResponse
+// @Failure      409              {object}  models.ErrorResponse
+// @Failure      422              {object}  models.ErrorResponse
+// @Failure      500              {object}  models.ErrorResponse
 // @Router       /investments [post]
 func CreateInvestment(c *gin.Context) {
 	var investment models.Investment
@@ -91,12 +105,16 @@ func CreateInvestment(c *gin.Context) {
 	}
 
 	// Validate type enum
-	validTypes := map[string]bool{
-		"STOCK": true, "CRYPTO": true, "REAL_ESTATE": true,
-		"ETF": true, "BOND": true, "MUTUAL_FUND": true,
+	investmentType, ok := models.ParseInvestmentType(string(investment.Type))
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: validation.ErrInvalidType.Error()})
+		return
 	}
-	if !validTypes[investment.Type] {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "invalid investment type"})
+	investment.Type = investmentType
+	investment.Status = models.InvestmentStatusActive
+
+	if InvestmentSagas != nil {
+		createInvestmentSaga(c, &investment)
 		return
 	}
 
@@ -105,10 +123,9 @@ func CreateInvestment(c *gin.Context) {
 	investment.CreatedAt = now
 	investment.UpdatedAt = now
 	investment.PurchaseDate = now
-	investment.Status = "ACTIVE"
 
 	// Create investment
-	if err := database.DB.Create(&investment).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Create(&investment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create investment"})
 		return
 	}
@@ -116,6 +133,29 @@ func CreateInvestment(c *gin.Context) {
 	c.JSON(http.StatusCreated, investment)
 }
 
+// createInvestmentSaga creates investment through InvestmentSagas. Clients
+// retrying a request send the same Idempotency-Key to get the first outcome.
+func createInvestmentSaga(c *gin.Context, investment *models.Investment) {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.NewString()
+	}
+
+	created, err := InvestmentSagas.CreateInvestment(c.Request.Context(), idempotencyKey, investment)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, created)
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{Error: err.Error(), Code: "idempotency_key_reused"})
+	case errors.Is(err, services.ErrSagaInProgress):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Investment is still being created, retry with the same Idempotency-Key", Code: "in_progress"})
+	case errors.Is(err, services.ErrSagaAborted):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{Error: "Investment could not be created and its funds were released", Code: "aborted"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create investment"})
+	}
+}
+
 // GetInvestment godoc
 // @Summary      Get an investment by ID
 // @Description  Get investment details by ID
@@ -151,7 +191,7 @@ func GetInvestment(c *gin.Context) {
 	id := c.Param("id")
 	var investment models.Investment
 
-	if err := database.DB.First(&investment, id).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).First(&investment, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Investment not found"})
 		return
 	}
@@ -165,7 +205,9 @@ func GetInvestment(c *gin.Context) {
 // @Tags         investments
 // @Accept       json
 // @Produce      json
+// @Param        fields  query     string  false  "Comma separated fields to return, e.g. id,symbol,amount"
 // @Success      200  {array}   models.Investment
+// @Failure      400  {object}  models.ErrorResponse  "Unknown field"
 // @Failure      401  {object}  models.ErrorResponse  "Unauthorized"
 // @Failure      403  {object}  models.ErrorResponse  "Forbidden"
 // @Failure      500  {object}  models.ErrorResponse  "Internal server error"
@@ -208,15 +250,34 @@ func GetInvestment(c *gin.Context) {
 // @Example        }
 // @Example      ]
 func ListInvestments(c *gin.Context) {
+	selected, err := investmentFields.Parse(c.Query(fields.Param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	userID := c.GetUint("user_id")
 	var investments []models.Investment
 
-	if err := database.DB.Where("user_id = ?", userID).Find(&investments).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Where("user_id = ?", userID).Find(&investments).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch investments"})
 		return
 	}
 
-	c.JSON(http.StatusOK, investments)
+	response, err := fields.Select(investments, selected)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to render investments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// investmentFields are the investment fields clients may select with ?fields=
+var investmentFields = fields.Allowlist{
+	"id", "created_at", "updated_at", "user_id", "portfolio_id", "amount", "currency",
+	"type", "status", "purchase_date", "sell_date", "purchase_price", "sell_price",
+	"symbol", "quantity", "notes",
 }
 
 // UpdateInvestment godoc
@@ -268,17 +329,30 @@ func UpdateInvestment(c *gin.Context) {
 	id := c.Param("id")
 	var investment models.Investment
 
-	if err := database.DB.First(&investment, id).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).First(&investment, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Investment not found"})
 		return
 	}
 
+	previousStatus := investment.Status
 	if err := c.ShouldBindJSON(&investment); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	if err := database.DB.Save(&investment).Error; err != nil {
+	if err := validation.ValidateInvestment(&investment); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if investment.Status == "" {
+		investment.Status = previousStatus
+	}
+	if err := validation.ValidateStatusTransition(previousStatus, investment.Status); err != nil {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := database.DB.WithContext(c.Request.Context()).Save(&investment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update investment"})
 		return
 	}
@@ -307,12 +381,12 @@ func DeleteInvestment(c *gin.Context) {
 	id := c.Param("id")
 	var investment models.Investment
 
-	if err := database.DB.First(&investment, id).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).First(&investment, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Investment not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&investment).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&investment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete investment"})
 		return
 	}
@@ -466,7 +540,7 @@ func CreateTransaction(c *gin.Context) {
 	transaction.Status = "PENDING"
 
 	// Start transaction
-	tx := database.DB.Begin()
+	tx := database.DB.WithContext(c.Request.Context()).Begin()
 
 	// Create transaction record
 	if err := tx.Create(&transaction).Error; err != nil {
@@ -484,7 +558,12 @@ func CreateTransaction(c *gin.Context) {
 	}
 
 	if transaction.Type == "SELL" {
-		investment.Status = "SOLD"
+		if err := validation.ValidateStatusTransition(investment.Status, models.InvestmentStatusSold); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		investment.Status = models.InvestmentStatusSold
 		investment.SellDate = &transaction.Timestamp
 		investment.SellPrice = &transaction.Price
 	}
@@ -504,6 +583,56 @@ func CreateTransaction(c *gin.Context) {
 	c.JSON(http.StatusCreated, transaction)
 }
 
+// Transaction list page sizes
+const (
+	defaultTransactionPageSize = 50
+	maxTransactionPageSize     = 100
+)
+
+// ListTransactions godoc
+// @Summary      List transactions
+// @Description  List the authenticated user's transactions, newest first
+// @Tags         transactions
+// @Produce      json
+// @Param        before  query     string  false  "Only transactions created at or before this RFC 3339 time"
+// @Param        limit   query     int     false  "Maximum number of transactions (default 50, max 100)"
+// @Success      200     {array}   models.Transaction
+// @Failure      400     {object}  models.ErrorResponse  "Bad request"
+// @Failure      500     {object}  models.ErrorResponse  "Internal server error"
+// @Router       /transactions [get]
+func ListTransactions(c *gin.Context) {
+	limit := defaultTransactionPageSize
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "limit must be a positive integer"})
+			return
+		}
+		if parsed > maxTransactionPageSize {
+			parsed = maxTransactionPageSize
+		}
+		limit = parsed
+	}
+
+	query := database.DB.WithContext(c.Request.Context()).Where("user_id = ?", c.GetUint("user_id"))
+	if raw := c.Query("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "before must be an RFC 3339 time"})
+			return
+		}
+		query = query.Where("created_at <= ?", before)
+	}
+
+	var transactions []models.Transaction
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactions)
+}
+
 // GetPortfolio godoc
 // @Summary      Get a portfolio by ID
 // @Description  Get portfolio details by ID including its investments
@@ -544,7 +673,7 @@ func GetPortfolio(c *gin.Context) {
 	id := c.Param("id")
 	var portfolio models.Portfolio
 
-	if err := database.DB.Preload("Investments").First(&portfolio, id).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Preload("Investments").First(&portfolio, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Portfolio not found"})
 		return
 	}
@@ -603,7 +732,7 @@ func CreatePortfolio(c *gin.Context) {
 	portfolio.UpdatedAt = now
 	portfolio.LastUpdated = now
 
-	if err := database.DB.Create(&portfolio).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Create(&portfolio).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create portfolio"})
 		return
 	}
@@ -643,7 +772,7 @@ func UpdatePortfolio(c *gin.Context) {
 	id := c.Param("id")
 	var portfolio models.Portfolio
 
-	if err := database.DB.First(&portfolio, id).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).First(&portfolio, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Portfolio not found"})
 		return
 	}
@@ -655,7 +784,7 @@ func UpdatePortfolio(c *gin.Context) {
 
 	portfolio.LastUpdated = time.Now()
 
-	if err := database.DB.Save(&portfolio).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Save(&portfolio).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update portfolio"})
 		return
 	}
@@ -682,12 +811,12 @@ func DeletePortfolio(c *gin.Context) {
 	id := c.Param("id")
 	var portfolio models.Portfolio
 
-	if err := database.DB.First(&portfolio, id).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).First(&portfolio, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Portfolio not found"})
 		return
 	}
 
-	if err := database.DB.Delete(&portfolio).Error; err != nil {
+	if err := database.DB.WithContext(c.Request.Context()).Delete(&portfolio).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete portfolio"})
 		return
 	}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"investment-service/internal/middleware"
+	"investment-service/internal/models"
+	"investment-service/internal/repositories"
+	"investment-service/internal/validation"
+
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
+	"github.com/adil-faiyaz98/sparkfund/pkg/stream"
+)
+
+// exportWriteTimeout replaces the server's write timeout for export responses,
+// which stream every matching row and can outlast it
+const exportWriteTimeout = 10 * time.Minute
+
+// InvestmentListResponse is a page of investments
+type InvestmentListResponse struct {
+	Investments []models.Investment `json:"investments"`
+	Total       int64               `json:"total"`
+	Page        int                 `json:"page"`
+	PageSize    int                 `json:"page_size"`
+}
+
+// HTTPInvestmentHandler serves investment endpoints on plain net/http routers
+type HTTPInvestmentHandler struct {
+	repo repositories.InvestmentRepository
+}
+
+// NewHTTPInvestmentHandler creates a new net/http investment handler
+func NewHTTPInvestmentHandler(repo repositories.InvestmentRepository) *HTTPInvestmentHandler {
+	return &HTTPInvestmentHandler{repo: repo}
+}
+
+// ListInvestments godoc
+// @Summary      Get investments
+// @Description  Get a page of the caller's investments
+// @Tags         investments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int  false  "Page number"  default(1)
+// @Param        page_size  query     int  false  "Page size"    default(10)
+// @Param        userId     query     int  false  "User whose investments to list (default the caller)"
+// @Success      200        {object}  InvestmentListResponse
+// @Failure      400        {object}  models.ErrorResponse
+// @Failure      401        {object}  models.ErrorResponse
+// @Failure      403        {object}  models.ErrorResponse
+// @Failure      500        {object}  models.ErrorResponse
+// @Router       /api/v1/investments [get]
+func (h *HTTPInvestmentHandler) ListInvestments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	investments, total, err := h.repo.ListByUserID(r.Context(), userID, page, pageSize)
+	if sharedDB.IsOverloaded(err) {
+		writeJSON(w, http.StatusServiceUnavailable, models.ErrorResponse{Error: "Service temporarily unavailable"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch investments"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, InvestmentListResponse{
+		Investments: investments,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+	})
+}
+
+// CreateInvestment godoc
+// @Summary      Create investment
+// @Description  Create a new investment owned by the caller
+// @Tags         investments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        investment  body      models.Investment  true  "Investment data"
+// @Success      201         {object}  models.Investment
+// @Failure      400         {object}  models.ErrorResponse
+// @Failure      401         {object}  models.ErrorResponse
+// @Failure      500         {object}  models.ErrorResponse
+// @Router       /api/v1/investments/create [post]
+func (h *HTTPInvestmentHandler) CreateInvestment(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	var investment models.Investment
+	if err := json.NewDecoder(r.Body).Decode(&investment); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	// Server-managed fields are never taken from the client
+	investment.ID = 0
+	// Only admins may create investments on behalf of another user
+	if investment.UserID == 0 || !principal.HasRole("admin") {
+		investment.UserID = principal.UserID
+	}
+	if investment.Status == "" {
+		investment.Status = models.InvestmentStatusActive
+	}
+	if investment.Currency == "" {
+		investment.Currency = "USD"
+	}
+	if investment.PurchaseDate.IsZero() {
+		investment.PurchaseDate = time.Now()
+	}
+
+	if err := validation.ValidateInvestment(&investment); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if investment.Amount == 0 {
+		investment.Amount = investment.Quantity * investment.PurchasePrice
+	}
+
+	if err := h.repo.Create(r.Context(), &investment); err != nil {
+		if sharedDB.IsOverloaded(err) {
+			writeJSON(w, http.StatusServiceUnavailable, models.ErrorResponse{Error: "Service temporarily unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create investment"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, investment)
+}
+
+// ExportInvestments godoc
+// @Summary      Export investments
+// @Description  Stream the caller's investments as a JSON array. Admins may export any user's.
+// @Tags         investments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId  query     int  false  "User whose investments to export (default the caller)"
+// @Success      200     {array}   models.Investment
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      403     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/v1/investments/export [get]
+func (h *HTTPInvestmentHandler) ExportInvestments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := targetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Not every writer supports deadlines (e.g. httptest); the server timeout applies then
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+	var enc *stream.ArrayEncoder
+	err := h.repo.StreamByUserID(r.Context(), userID, func(investment *models.Investment) error {
+		// Delay the status line until the first row so early failures can still return 500
+		if enc == nil {
+			stream.WriteHeader(w, http.StatusOK)
+			enc = stream.NewArrayEncoder(w)
+		}
+		return enc.Encode(investment)
+	})
+
+	if err != nil {
+		if enc == nil {
+			if sharedDB.IsOverloaded(err) {
+				writeJSON(w, http.StatusServiceUnavailable, models.ErrorResponse{Error: "Service temporarily unavailable"})
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export investments"})
+			return
+		}
+		// The response is already partially written; abort so the client sees truncated output
+		panic(http.ErrAbortHandler)
+	}
+
+	if enc == nil {
+		stream.WriteHeader(w, http.StatusOK)
+		enc = stream.NewArrayEncoder(w)
+	}
+	enc.Close()
+}
+
+// targetUserID returns the user whose investments the request reads: the
+// caller, or the userId query parameter for admins. It writes the error
+// response itself and returns false when the request may not proceed.
+func targetUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return 0, false
+	}
+
+	userID := principal.UserID
+	if raw := r.URL.Query().Get("userId"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parsed == 0 {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "userId must be a positive integer"})
+			return 0, false
+		}
+		userID = uint(parsed)
+	}
+	if userID != principal.UserID && !principal.HasRole("admin") {
+		writeJSON(w, http.StatusForbidden, models.ErrorResponse{Error: "Insufficient permissions"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
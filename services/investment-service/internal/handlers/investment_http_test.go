@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"investment-service/internal/database"
+	"investment-service/internal/middleware"
+	"investment-service/internal/models"
+	"investment-service/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type HTTPInvestmentHandlerTestSuite struct {
+	suite.Suite
+	handler *HTTPInvestmentHandler
+	db      *gorm.DB
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) SetupSuite() {
+	// Use in-memory SQLite for testing
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	// Migrate models
+	err = db.AutoMigrate(&models.Investment{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	// Set DB for tests
+	database.DB = db
+	suite.db = db
+
+	suite.handler = NewHTTPInvestmentHandler(repositories.NewInvestmentRepository())
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) SetupTest() {
+	// Clean up tables between tests
+	suite.db.Where("1 = 1").Delete(&models.Investment{})
+}
+
+// asUser attaches the principal HTTPAuth would set for a user with the given roles
+func asUser(req *http.Request, userID uint, roles ...string) *http.Request {
+	return req.WithContext(middleware.WithPrincipal(req.Context(), middleware.Principal{UserID: userID, Roles: roles}))
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestCreateThenList() {
+	payload := map[string]interface{}{
+		"user_id":        1,
+		"portfolio_id":   1,
+		"type":           "stock",
+		"symbol":         "AAPL",
+		"quantity":       10,
+		"purchase_price": 150.0,
+	}
+	jsonValue, _ := json.Marshal(payload)
+
+	req := asUser(httptest.NewRequest("POST", "/api/v1/investments/create", bytes.NewBuffer(jsonValue)), 1)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.handler.CreateInvestment(w, req)
+
+	var created models.Investment
+	err := json.Unmarshal(w.Body.Bytes(), &created)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.NotZero(suite.T(), created.ID)
+	assert.Equal(suite.T(), models.InvestmentTypeStock, created.Type)
+	assert.Equal(suite.T(), models.InvestmentStatusActive, created.Status)
+	assert.Equal(suite.T(), 1500.0, created.Amount)
+
+	// The new investment is persisted and returned by the list endpoint
+	req = asUser(httptest.NewRequest("GET", "/api/v1/investments?page=1&page_size=10", nil), 1)
+	w = httptest.NewRecorder()
+	suite.handler.ListInvestments(w, req)
+
+	var list InvestmentListResponse
+	err = json.Unmarshal(w.Body.Bytes(), &list)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), int64(1), list.Total)
+	if assert.Len(suite.T(), list.Investments, 1) {
+		assert.Equal(suite.T(), created.ID, list.Investments[0].ID)
+		assert.Equal(suite.T(), "AAPL", list.Investments[0].Symbol)
+	}
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestCreateRejectsInvalidInvestment() {
+	payload := map[string]interface{}{
+		"user_id":        1,
+		"type":           "LOTTERY",
+		"symbol":         "AAPL",
+		"quantity":       10,
+		"purchase_price": 150.0,
+	}
+	jsonValue, _ := json.Marshal(payload)
+
+	req := asUser(httptest.NewRequest("POST", "/api/v1/investments/create", bytes.NewBuffer(jsonValue)), 1)
+	w := httptest.NewRecorder()
+	suite.handler.CreateInvestment(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var count int64
+	suite.db.Model(&models.Investment{}).Count(&count)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestCreateUsesCallerAsOwner() {
+	payload := map[string]interface{}{
+		"user_id":        2,
+		"type":           "stock",
+		"symbol":         "AAPL",
+		"quantity":       1,
+		"purchase_price": 100.0,
+	}
+	jsonValue, _ := json.Marshal(payload)
+
+	req := asUser(httptest.NewRequest("POST", "/api/v1/investments/create", bytes.NewBuffer(jsonValue)), 1)
+	w := httptest.NewRecorder()
+	suite.handler.CreateInvestment(w, req)
+
+	var created models.Investment
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), uint(1), created.UserID)
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestListOnlyReturnsCallersInvestments() {
+	suite.db.Create(&models.Investment{UserID: 1, Type: "STOCK", Symbol: "AAPL", Status: "ACTIVE"})
+	suite.db.Create(&models.Investment{UserID: 2, Type: "STOCK", Symbol: "MSFT", Status: "ACTIVE"})
+
+	req := asUser(httptest.NewRequest("GET", "/api/v1/investments", nil), 1)
+	w := httptest.NewRecorder()
+	suite.handler.ListInvestments(w, req)
+
+	var list InvestmentListResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), int64(1), list.Total)
+	if assert.Len(suite.T(), list.Investments, 1) {
+		assert.Equal(suite.T(), "AAPL", list.Investments[0].Symbol)
+	}
+
+	// Another user's investments need the admin role
+	req = asUser(httptest.NewRequest("GET", "/api/v1/investments?userId=2", nil), 1)
+	w = httptest.NewRecorder()
+	suite.handler.ListInvestments(w, req)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	req = asUser(httptest.NewRequest("GET", "/api/v1/investments?userId=2", nil), 1, "admin")
+	w = httptest.NewRecorder()
+	suite.handler.ListInvestments(w, req)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	if assert.Len(suite.T(), list.Investments, 1) {
+		assert.Equal(suite.T(), "MSFT", list.Investments[0].Symbol)
+	}
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestRequiresAuthenticatedCaller() {
+	w := httptest.NewRecorder()
+	suite.handler.ListInvestments(w, httptest.NewRequest("GET", "/api/v1/investments", nil))
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.handler.CreateInvestment(w, httptest.NewRequest("POST", "/api/v1/investments/create", bytes.NewBufferString("{}")))
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.handler.ExportInvestments(w, httptest.NewRequest("GET", "/api/v1/investments/export", nil))
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestExportStreamsCallersInvestments() {
+	for _, symbol := range []string{"AAPL", "MSFT", "GOOG"} {
+		suite.db.Create(&models.Investment{UserID: 1, Type: "STOCK", Symbol: symbol, Status: "ACTIVE"})
+	}
+	suite.db.Create(&models.Investment{UserID: 2, Type: "STOCK", Symbol: "TSLA", Status: "ACTIVE"})
+
+	req := asUser(httptest.NewRequest("GET", "/api/v1/investments/export", nil), 1)
+	w := httptest.NewRecorder()
+	suite.handler.ExportInvestments(w, req)
+
+	var exported []models.Investment
+	err := json.Unmarshal(w.Body.Bytes(), &exported)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	if assert.Len(suite.T(), exported, 3) {
+		assert.Equal(suite.T(), "AAPL", exported[0].Symbol)
+		assert.Equal(suite.T(), "GOOG", exported[2].Symbol)
+	}
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestExportWithNoInvestments() {
+	req := asUser(httptest.NewRequest("GET", "/api/v1/investments/export", nil), 1)
+	w := httptest.NewRecorder()
+	suite.handler.ExportInvestments(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), "[]", w.Body.String())
+}
+
+func (suite *HTTPInvestmentHandlerTestSuite) TestExportOutlivesServerWriteTimeout() {
+	for i := 0; i < 3; i++ {
+		suite.db.Create(&models.Investment{UserID: 1, Type: "STOCK", Symbol: "AAPL", Status: "ACTIVE"})
+	}
+
+	// The server's write timeout is far shorter than the export takes
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		suite.handler.ExportInvestments(w, asUser(r, 1))
+	}))
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if !assert.NoError(suite.T(), err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	var exported []models.Investment
+	assert.NoError(suite.T(), json.NewDecoder(resp.Body).Decode(&exported))
+	assert.Len(suite.T(), exported, 3)
+}
+
+func TestHTTPInvestmentHandlerSuite(t *testing.T) {
+	suite.Run(t, new(HTTPInvestmentHandlerTestSuite))
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"investment-service/internal/database"
+	"investment-service/internal/models"
+	"investment-service/internal/repositories"
+	"investment-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// stubSagaDeps accepts every investment and reserves funds without an accounts service
+type stubSagaDeps struct {
+	reserveCalls int
+}
+
+func (s *stubSagaDeps) CheckSuitability(ctx context.Context, investment *models.Investment) error {
+	return nil
+}
+
+func (s *stubSagaDeps) ReserveFunds(ctx context.Context, idempotencyKey string, userID uint, amount float64, currency string) (string, error) {
+	s.reserveCalls++
+	return "res-" + idempotencyKey, nil
+}
+
+func (s *stubSagaDeps) ReleaseFunds(ctx context.Context, reservationID string) error { return nil }
+
+func (s *stubSagaDeps) PublishInvestmentCreated(ctx context.Context, investment *models.Investment) error {
+	return nil
+}
+
+// setupSagaHandler points CreateInvestment at a saga over a fresh database
+func setupSagaHandler(t *testing.T) (*gin.Engine, *stubSagaDeps) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Investment{}, &models.InvestmentSaga{}))
+
+	previousDB, previousSagas := database.DB, InvestmentSagas
+	database.DB = db
+	deps := &stubSagaDeps{}
+	InvestmentSagas = services.NewInvestmentSagaOrchestrator(repositories.NewSagaRepository(), deps, deps, deps, zap.NewNop())
+	t.Cleanup(func() {
+		database.DB, InvestmentSagas = previousDB, previousSagas
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/investments", CreateInvestment)
+	return r, deps
+}
+
+func postInvestment(r *gin.Engine, idempotencyKey string, amount float64) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]interface{}{
+		"user_id":        1,
+		"portfolio_id":   1,
+		"amount":         amount,
+		"currency":       "USD",
+		"type":           "STOCK",
+		"symbol":         "AAPL",
+		"quantity":       10,
+		"purchase_price": 150,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/investments", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateInvestment_SagaRetryReturnsFirstInvestment(t *testing.T) {
+	r, deps := setupSagaHandler(t)
+
+	first := postInvestment(r, "key-1", 1500)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	second := postInvestment(r, "key-1", 1500)
+	require.Equal(t, http.StatusCreated, second.Code, second.Body.String())
+
+	var firstInvestment, secondInvestment models.Investment
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &firstInvestment))
+	require.NoError(t, json.Unmarshal(second.Body.Bytes(), &secondInvestment))
+	assert.NotZero(t, firstInvestment.ID)
+	assert.Equal(t, firstInvestment.ID, secondInvestment.ID)
+	assert.Equal(t, 1, deps.reserveCalls)
+}
+
+func TestCreateInvestment_SagaRejectsReusedKey(t *testing.T) {
+	r, deps := setupSagaHandler(t)
+
+	require.Equal(t, http.StatusCreated, postInvestment(r, "key-1", 1500).Code)
+	w := postInvestment(r, "key-1", 3000)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "idempotency_key_reused", response.Code)
+	assert.Equal(t, 1, deps.reserveCalls)
+}
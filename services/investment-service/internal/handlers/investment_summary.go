@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"investment-service/internal/models"
+	"investment-service/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInvestmentSummary godoc
+// @Summary      Summarize investments
+// @Description  Get investment counts and total amounts grouped by status, type and currency (admin only)
+// @Tags         investments
+// @Produce      json
+// @Success      200  {object}  models.InvestmentSummary
+// @Failure      401  {object}  models.ErrorResponse  "Unauthorized"
+// @Failure      403  {object}  models.ErrorResponse  "Forbidden"
+// @Failure      500  {object}  models.ErrorResponse  "Internal server error"
+// @Security     BearerAuth
+// @Router       /investments/summary [get]
+func GetInvestmentSummary(c *gin.Context) {
+	groups, err := repositories.NewInvestmentRepository().Summary(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to summarize investments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summarizeInvestments(groups))
+}
+
+// summarizeInvestments rolls the grouped rows up by status, type and currency
+func summarizeInvestments(groups []models.InvestmentSummaryGroup) models.InvestmentSummary {
+	summary := models.InvestmentSummary{
+		ByStatus:   make(map[string]models.InvestmentAggregate),
+		ByType:     make(map[string]models.InvestmentAggregate),
+		ByCurrency: make(map[string]models.InvestmentAggregate),
+		Groups:     groups,
+	}
+	if summary.Groups == nil {
+		summary.Groups = []models.InvestmentSummaryGroup{}
+	}
+
+	add := func(aggregates map[string]models.InvestmentAggregate, key string, group models.InvestmentSummaryGroup) {
+		aggregate := aggregates[key]
+		aggregate.Count += group.Count
+		aggregate.TotalAmount += group.TotalAmount
+		aggregates[key] = aggregate
+	}
+
+	for _, group := range groups {
+		summary.Total.Count += group.Count
+		summary.Total.TotalAmount += group.TotalAmount
+		add(summary.ByStatus, group.Status, group)
+		add(summary.ByType, group.Type, group)
+		add(summary.ByCurrency, group.Currency, group)
+	}
+
+	return summary
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"investment-service/internal/database"
+	"investment-service/internal/middleware"
+	"investment-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type InvestmentSummaryTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+}
+
+func (suite *InvestmentSummaryTestSuite) SetupSuite() {
+	db, err := gorm.Open(sqlite.Open("file:summary?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&models.Portfolio{}, &models.Investment{}, &models.Transaction{}); err != nil {
+		suite.T().Fatal(err)
+	}
+
+	database.DB = db
+	suite.db = db
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Stand in for JWTAuth: roles come from a test header
+	r.Use(func(c *gin.Context) {
+		if role := c.GetHeader("X-Test-Role"); role != "" {
+			c.Set("roles", []interface{}{role})
+		}
+		c.Next()
+	})
+	r.GET("/investments/summary", middleware.RequireRole("admin"), GetInvestmentSummary)
+
+	suite.router = r
+}
+
+func (suite *InvestmentSummaryTestSuite) TearDownSuite() {
+	sqlDB, err := suite.db.DB()
+	if err == nil {
+		sqlDB.Close()
+	}
+}
+
+func (suite *InvestmentSummaryTestSuite) SetupTest() {
+	database.DB = suite.db
+	suite.db.Where("1 = 1").Delete(&models.Investment{})
+}
+
+func (suite *InvestmentSummaryTestSuite) seed(status, investmentType, currency string, amount float64) {
+	investment := models.Investment{
+		UserID:        1,
+		PortfolioID:   1,
+		Amount:        amount,
+		Currency:      currency,
+		Type:          models.InvestmentType(investmentType),
+		Status:        models.InvestmentStatus(status),
+		PurchaseDate:  time.Now(),
+		PurchasePrice: amount,
+		Symbol:        "TEST",
+		Quantity:      1,
+	}
+	suite.Require().NoError(suite.db.Create(&investment).Error)
+}
+
+func (suite *InvestmentSummaryTestSuite) TestSummaryMatchesSeededInvestments() {
+	suite.seed("ACTIVE", "STOCK", "USD", 100)
+	suite.seed("ACTIVE", "STOCK", "USD", 250)
+	suite.seed("ACTIVE", "CRYPTO", "EUR", 50)
+	suite.seed("SOLD", "STOCK", "EUR", 400)
+	suite.seed("PENDING", "BOND", "USD", 1000)
+
+	req := httptest.NewRequest(http.MethodGet, "/investments/summary", nil)
+	req.Header.Set("X-Test-Role", "admin")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var summary models.InvestmentSummary
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &summary))
+
+	assert.Equal(suite.T(), models.InvestmentAggregate{Count: 5, TotalAmount: 1800}, summary.Total)
+	assert.Equal(suite.T(), map[string]models.InvestmentAggregate{
+		"ACTIVE":  {Count: 3, TotalAmount: 400},
+		"SOLD":    {Count: 1, TotalAmount: 400},
+		"PENDING": {Count: 1, TotalAmount: 1000},
+	}, summary.ByStatus)
+	assert.Equal(suite.T(), map[string]models.InvestmentAggregate{
+		"STOCK":  {Count: 3, TotalAmount: 750},
+		"CRYPTO": {Count: 1, TotalAmount: 50},
+		"BOND":   {Count: 1, TotalAmount: 1000},
+	}, summary.ByType)
+	assert.Equal(suite.T(), map[string]models.InvestmentAggregate{
+		"USD": {Count: 3, TotalAmount: 1350},
+		"EUR": {Count: 2, TotalAmount: 450},
+	}, summary.ByCurrency)
+
+	// The two ACTIVE/STOCK/USD investments share a group
+	assert.Len(suite.T(), summary.Groups, 4)
+	assert.Contains(suite.T(), summary.Groups, models.InvestmentSummaryGroup{
+		Status: "ACTIVE", Type: "STOCK", Currency: "USD", Count: 2, TotalAmount: 350,
+	})
+}
+
+func (suite *InvestmentSummaryTestSuite) TestSummaryEmpty() {
+	req := httptest.NewRequest(http.MethodGet, "/investments/summary", nil)
+	req.Header.Set("X-Test-Role", "admin")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var summary models.InvestmentSummary
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(suite.T(), int64(0), summary.Total.Count)
+	assert.Empty(suite.T(), summary.Groups)
+}
+
+func (suite *InvestmentSummaryTestSuite) TestSummaryRequiresAdmin() {
+	suite.seed("ACTIVE", "STOCK", "USD", 100)
+
+	for _, role := range []string{"", "user"} {
+		req := httptest.NewRequest(http.MethodGet, "/investments/summary", nil)
+		if role != "" {
+			req.Header.Set("X-Test-Role", role)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code, "role %q", role)
+	}
+}
+
+func TestInvestmentSummaryTestSuite(t *testing.T) {
+	suite.Run(t, new(InvestmentSummaryTestSuite))
+}
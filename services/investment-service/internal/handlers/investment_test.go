@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -52,6 +53,7 @@ func (suite *InvestmentHandlerTestSuite) SetupSuite() {
 	r.GET("/investments", ListInvestments)
 	r.PUT("/investments/:id", UpdateInvestment)
 	r.DELETE("/investments/:id", DeleteInvestment)
+	r.GET("/transactions", ListTransactions)
 
 	suite.router = r
 }
@@ -68,6 +70,7 @@ func (suite *InvestmentHandlerTestSuite) SetupTest() {
 	// Clean up tables between tests
 	suite.db.Where("1 = 1").Delete(&models.Investment{})
 	suite.db.Where("1 = 1").Delete(&models.Portfolio{})
+	suite.db.Where("1 = 1").Delete(&models.Transaction{})
 }
 
 func (suite *InvestmentHandlerTestSuite) TestCreateInvestment() {
@@ -171,6 +174,181 @@ func (suite *InvestmentHandlerTestSuite) TestGetInvestment() {
 	assert.Equal(suite.T(), investment.Amount, response.Amount)
 }
 
+func (suite *InvestmentHandlerTestSuite) TestListInvestmentsSelectsFields() {
+	// The test router has no auth middleware, so requests list user 0's investments
+	investment := models.Investment{
+		Amount:        500.0,
+		Type:          "STOCK",
+		Status:        "ACTIVE",
+		PurchaseDate:  time.Now(),
+		PurchasePrice: 100.0,
+		Symbol:        "MSFT",
+		Quantity:      5,
+		Notes:         "Sparse fieldset test",
+	}
+	assert.NoError(suite.T(), suite.db.Create(&investment).Error)
+
+	req := httptest.NewRequest("GET", "/investments?fields=id,symbol", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	var response []map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Len(suite.T(), response, 1)
+	assert.Equal(suite.T(), map[string]interface{}{
+		"id":     float64(investment.ID),
+		"symbol": "MSFT",
+	}, response[0])
+}
+
+func (suite *InvestmentHandlerTestSuite) TestListInvestmentsRejectsUnknownField() {
+	req := httptest.NewRequest("GET", "/investments?fields=id,tenant_id", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *InvestmentHandlerTestSuite) TestListTransactionsNewestFirst() {
+	// The test router has no auth middleware, so requests list user 0's transactions
+	base := time.Date(2025, 3, 28, 12, 0, 0, 0, time.UTC)
+	seeded := []struct {
+		id     string
+		offset time.Duration
+	}{{"tx-old", 0}, {"tx-new", 2 * time.Hour}, {"tx-mid", time.Hour}}
+	for i, seed := range seeded {
+		transaction := models.Transaction{
+			CreatedAt:     base.Add(seed.offset),
+			InvestmentID:  uint(i + 1),
+			Type:          "BUY",
+			Amount:        100,
+			Quantity:      1,
+			Timestamp:     base.Add(seed.offset),
+			Status:        "COMPLETED",
+			TransactionID: seed.id,
+		}
+		assert.NoError(suite.T(), suite.db.Create(&transaction).Error)
+	}
+	other := models.Transaction{UserID: 2, InvestmentID: 9, Type: "BUY", Quantity: 1, Timestamp: base, Status: "COMPLETED", TransactionID: "tx-other"}
+	assert.NoError(suite.T(), suite.db.Create(&other).Error)
+
+	list := func(query string) []models.Transaction {
+		req := httptest.NewRequest("GET", "/transactions"+query, nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response []models.Transaction
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	ids := func(transactions []models.Transaction) []string {
+		out := make([]string, len(transactions))
+		for i, transaction := range transactions {
+			out[i] = transaction.TransactionID
+		}
+		return out
+	}
+
+	assert.Equal(suite.T(), []string{"tx-new", "tx-mid", "tx-old"}, ids(list("")))
+	assert.Equal(suite.T(), []string{"tx-mid"}, ids(list("?limit=1&before="+base.Add(time.Hour).Format(time.RFC3339))))
+}
+
+func (suite *InvestmentHandlerTestSuite) TestListTransactionsRejectsBadLimit() {
+	req := httptest.NewRequest("GET", "/transactions?limit=0", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *InvestmentHandlerTestSuite) TestCreateInvestmentRejectsUnknownType() {
+	payload := map[string]interface{}{
+		"user_id":        1,
+		"portfolio_id":   1,
+		"type":           "STOKC",
+		"symbol":         "AAPL",
+		"quantity":       10,
+		"purchase_price": 150.0,
+	}
+	jsonValue, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest("POST", "/investments", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var count int64
+	suite.db.Model(&models.Investment{}).Count(&count)
+	assert.Zero(suite.T(), count)
+}
+
+// seedInvestment stores a valid investment with the given status
+func (suite *InvestmentHandlerTestSuite) seedInvestment(status models.InvestmentStatus) models.Investment {
+	investment := models.Investment{
+		UserID:        1,
+		PortfolioID:   1,
+		Amount:        1500.0,
+		Type:          models.InvestmentTypeStock,
+		Status:        status,
+		PurchaseDate:  time.Now(),
+		PurchasePrice: 150.0,
+		Symbol:        "AAPL",
+		Quantity:      10,
+	}
+	assert.NoError(suite.T(), suite.db.Create(&investment).Error)
+	return investment
+}
+
+// updateStatus PUTs a new status for an investment and returns the response code
+func (suite *InvestmentHandlerTestSuite) updateStatus(id uint, status string) int {
+	jsonValue, _ := json.Marshal(map[string]interface{}{"status": status})
+
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/investments/%d", id), bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// storedStatus reads an investment's status back from the database
+func (suite *InvestmentHandlerTestSuite) storedStatus(id uint) models.InvestmentStatus {
+	var stored models.Investment
+	assert.NoError(suite.T(), suite.db.First(&stored, id).Error)
+	return stored.Status
+}
+
+func (suite *InvestmentHandlerTestSuite) TestUpdateInvestmentRejectsUnknownStatus() {
+	investment := suite.seedInvestment(models.InvestmentStatusActive)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.updateStatus(investment.ID, "ACTIV"))
+	assert.Equal(suite.T(), models.InvestmentStatusActive, suite.storedStatus(investment.ID))
+}
+
+func (suite *InvestmentHandlerTestSuite) TestUpdateInvestmentBlocksIllegalTransition() {
+	investment := suite.seedInvestment(models.InvestmentStatusSold)
+
+	assert.Equal(suite.T(), http.StatusConflict, suite.updateStatus(investment.ID, "PENDING"))
+	assert.Equal(suite.T(), models.InvestmentStatusSold, suite.storedStatus(investment.ID))
+}
+
+func (suite *InvestmentHandlerTestSuite) TestUpdateInvestmentAllowsLegalTransition() {
+	investment := suite.seedInvestment(models.InvestmentStatusPending)
+
+	// Statuses are normalized, so lower case is accepted
+	assert.Equal(suite.T(), http.StatusOK, suite.updateStatus(investment.ID, "active"))
+	assert.Equal(suite.T(), models.InvestmentStatusActive, suite.storedStatus(investment.ID))
+
+	assert.Equal(suite.T(), http.StatusOK, suite.updateStatus(investment.ID, "SOLD"))
+	assert.Equal(suite.T(), models.InvestmentStatusSold, suite.storedStatus(investment.ID))
+}
+
 // Additional test methods for other endpoints...
 
 func TestInvestmentHandlerSuite(t *testing.T) {
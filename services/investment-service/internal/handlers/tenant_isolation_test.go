@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"investment-service/internal/database"
+	"investment-service/internal/models"
+
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type TenantIsolationTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+}
+
+func (suite *TenantIsolationTestSuite) SetupSuite() {
+	db, err := gorm.Open(sqlite.Open("file:tenants?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&models.Portfolio{}, &models.Investment{}, &models.Transaction{}); err != nil {
+		suite.T().Fatal(err)
+	}
+	if err := db.Use(sharedDB.NewTenantScope()); err != nil {
+		suite.T().Fatal(err)
+	}
+
+	database.DB = db
+	suite.db = db
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Stand in for JWTAuth: the tenant claim comes from a test header
+	r.Use(func(c *gin.Context) {
+		if tenantID := c.GetHeader("X-Test-Tenant"); tenantID != "" {
+			c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+		}
+		c.Next()
+	})
+	r.GET("/investments/:id", GetInvestment)
+	r.DELETE("/investments/:id", DeleteInvestment)
+
+	suite.router = r
+}
+
+func (suite *TenantIsolationTestSuite) TearDownSuite() {
+	sqlDB, err := suite.db.DB()
+	if err == nil {
+		sqlDB.Close()
+	}
+}
+
+func (suite *TenantIsolationTestSuite) SetupTest() {
+	database.DB = suite.db
+	suite.db.WithContext(tenant.WithoutScope(context.Background())).Where("1 = 1").Delete(&models.Investment{})
+}
+
+// seed creates an investment owned by tenantID
+func (suite *TenantIsolationTestSuite) seed(tenantID string) models.Investment {
+	investment := models.Investment{
+		UserID:        1,
+		PortfolioID:   1,
+		Amount:        100,
+		Currency:      "USD",
+		Type:          "STOCK",
+		Status:        "ACTIVE",
+		PurchaseDate:  time.Now(),
+		PurchasePrice: 100,
+		Symbol:        "TEST",
+		Quantity:      1,
+	}
+	ctx := tenant.WithTenant(context.Background(), tenantID)
+	suite.Require().NoError(suite.db.WithContext(ctx).Create(&investment).Error)
+	return investment
+}
+
+func (suite *TenantIsolationTestSuite) request(method, tenantID string, id uint) int {
+	req := httptest.NewRequest(method, "/investments/"+strconv.FormatUint(uint64(id), 10), nil)
+	if tenantID != "" {
+		req.Header.Set("X-Test-Tenant", tenantID)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func (suite *TenantIsolationTestSuite) TestOtherTenantCannotReadByID() {
+	investment := suite.seed("tenant-b")
+	assert.Equal(suite.T(), "tenant-b", investment.TenantID)
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodGet, "tenant-a", investment.ID))
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodGet, "tenant-b", investment.ID))
+}
+
+func (suite *TenantIsolationTestSuite) TestOtherTenantCannotDelete() {
+	investment := suite.seed("tenant-b")
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodDelete, "tenant-a", investment.ID))
+	assert.Equal(suite.T(), http.StatusOK, suite.request(http.MethodGet, "tenant-b", investment.ID))
+}
+
+func (suite *TenantIsolationTestSuite) TestRequestWithoutTenantIsRefused() {
+	investment := suite.seed("tenant-b")
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request(http.MethodGet, "", investment.ID))
+}
+
+func TestTenantIsolationTestSuite(t *testing.T) {
+	suite.Run(t, new(TenantIsolationTestSuite))
+}
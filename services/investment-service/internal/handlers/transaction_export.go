@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"investment-service/internal/database"
+	"investment-service/internal/middleware"
+	"investment-service/internal/models"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/timerange"
+	"github.com/gin-gonic/gin"
+)
+
+// Transaction export formats
+const (
+	exportFormatCSV   = "csv"
+	exportFormatJSONL = "jsonl"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the client,
+// bounding how much of the export is buffered at once
+const exportFlushEvery = 500
+
+// exportRangeParser parses the from and to parameters. Exports stream rows,
+// so the range is neither defaulted to a span nor capped.
+var exportRangeParser = timerange.Parser{FromParam: "from", ToParam: "to"}
+
+// exportTimeLayout stamps the export range into the file name
+const exportTimeLayout = "20060102T150405Z"
+
+// transactionExportHeader is the CSV header row, in the order of csvRecord
+var transactionExportHeader = []string{
+	"id", "transaction_id", "investment_id", "type", "status",
+	"amount", "price", "quantity", "timestamp", "created_at",
+}
+
+// ExportTransactions godoc
+// @Summary      Export transactions
+// @Description  Stream a user's transactions, oldest first, as CSV or JSON Lines. Users export their own transactions; admins may export any user's.
+// @Tags         transactions
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        userId  query     int     false  "User whose transactions to export (default the caller)"
+// @Param        from    query     string  false  "Only transactions created at or after this RFC 3339 time or YYYY-MM-DD date"
+// @Param        to      query     string  false  "Only transactions created before this RFC 3339 time, or on or before this YYYY-MM-DD date (default now)"
+// @Param        format  query     string  false  "csv (default) or jsonl"
+// @Success      200     {file}    file
+// @Failure      400     {object}  models.ErrorResponse  "Bad request"
+// @Failure      401     {object}  models.ErrorResponse  "Unauthorized"
+// @Failure      403     {object}  models.ErrorResponse  "Forbidden"
+// @Failure      500     {object}  models.ErrorResponse  "Internal server error"
+// @Router       /transactions/export [get]
+func ExportTransactions(c *gin.Context) {
+	callerID, ok := middleware.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+	userID := callerID
+	if raw := c.Query("userId"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "userId must be a positive integer"})
+			return
+		}
+		userID = uint(parsed)
+	}
+	if userID != callerID && !middleware.HasRole(c, "admin") {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Insufficient permissions"})
+		return
+	}
+
+	exportRange, err := exportRangeParser.Parse(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: "invalid_date_range"})
+		return
+	}
+	from, to := exportRange.From.UTC(), exportRange.To.UTC()
+
+	format := strings.ToLower(c.DefaultQuery("format", exportFormatCSV))
+	var contentType string
+	switch format {
+	case exportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case exportFormatJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "format must be csv or jsonl"})
+		return
+	}
+
+	// Rows are read one at a time from the open result set, never collected, so
+	// an export of any size holds a single transaction in memory
+	rows, err := database.DB.WithContext(c.Request.Context()).Model(&models.Transaction{}).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Order("created_at ASC, id ASC").
+		Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export transactions"})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("transactions-%d-%s-%s.%s", userID, from.Format(exportTimeLayout), to.Format(exportTimeLayout), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	var write func(models.Transaction) error
+	csvWriter := csv.NewWriter(c.Writer)
+	jsonEncoder := json.NewEncoder(c.Writer)
+	if format == exportFormatCSV {
+		if err := csvWriter.Write(transactionExportHeader); err != nil {
+			return
+		}
+		write = func(transaction models.Transaction) error {
+			return csvWriter.Write(csvRecord(transaction))
+		}
+	} else {
+		write = func(transaction models.Transaction) error {
+			return jsonEncoder.Encode(transaction)
+		}
+	}
+	flush := func() {
+		csvWriter.Flush()
+		c.Writer.Flush()
+	}
+
+	// The status is already sent, so a failure part way can only end the stream
+	written := 0
+	for rows.Next() {
+		var transaction models.Transaction
+		if err := database.DB.ScanRows(rows, &transaction); err != nil {
+			log.Printf("Transaction export for user %d failed after %d rows: %v", userID, written, err)
+			return
+		}
+		if err := write(transaction); err != nil {
+			// The client went away
+			return
+		}
+		written++
+		if written%exportFlushEvery == 0 {
+			flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Transaction export for user %d failed after %d rows: %v", userID, written, err)
+		return
+	}
+	flush()
+}
+
+// csvRecord renders transaction as a row under transactionExportHeader
+func csvRecord(transaction models.Transaction) []string {
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return []string{
+		strconv.FormatUint(uint64(transaction.ID), 10),
+		csvText(transaction.TransactionID),
+		strconv.FormatUint(uint64(transaction.InvestmentID), 10),
+		csvText(transaction.Type),
+		csvText(transaction.Status),
+		formatFloat(transaction.Amount),
+		formatFloat(transaction.Price),
+		formatFloat(transaction.Quantity),
+		transaction.Timestamp.UTC().Format(time.RFC3339),
+		transaction.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// csvText neutralises text a spreadsheet would run as a formula. Quoting of
+// commas, quotes and newlines is left to encoding/csv.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"investment-service/internal/config"
+	"investment-service/internal/database"
+	"investment-service/internal/middleware"
+	"investment-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var exportEpoch = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+const exportJWTSecret = "export-test-secret"
+
+type TransactionExportTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+}
+
+func (suite *TransactionExportTestSuite) SetupSuite() {
+	db, err := gorm.Open(sqlite.Open("file:export?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&models.Portfolio{}, &models.Investment{}, &models.Transaction{}); err != nil {
+		suite.T().Fatal(err)
+	}
+
+	database.DB = db
+	suite.db = db
+
+	// JWTAuth reads its secret from the config, loaded here from a secret file
+	secretFile := filepath.Join(suite.T().TempDir(), "jwt-secret")
+	if err := os.WriteFile(secretFile, []byte(exportJWTSecret), 0o600); err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.T().Setenv("JWT_SECRET_FILE", secretFile)
+	if err := config.Reload(); err != nil {
+		suite.T().Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.JWTAuth())
+	r.GET("/transactions/export", ExportTransactions)
+
+	suite.router = r
+}
+
+func (suite *TransactionExportTestSuite) TearDownSuite() {
+	sqlDB, err := suite.db.DB()
+	if err == nil {
+		sqlDB.Close()
+	}
+}
+
+func (suite *TransactionExportTestSuite) SetupTest() {
+	suite.db.Where("1 = 1").Delete(&models.Transaction{})
+}
+
+// seed inserts count transactions for userID, one minute apart from start
+func (suite *TransactionExportTestSuite) seed(userID uint, start time.Time, count int) {
+	transactions := make([]models.Transaction, count)
+	for i := range transactions {
+		at := start.Add(time.Duration(i) * time.Minute)
+		transactions[i] = models.Transaction{
+			CreatedAt:     at,
+			UserID:        userID,
+			InvestmentID:  1,
+			Type:          "BUY",
+			Amount:        100,
+			Price:         10,
+			Quantity:      10,
+			Timestamp:     at,
+			Status:        "COMPLETED",
+			TransactionID: "tx-" + strconv.Itoa(int(userID)) + "-" + at.Format(time.RFC3339),
+		}
+	}
+	assert.NoError(suite.T(), suite.db.CreateInBatches(transactions, 500).Error)
+}
+
+// export requests an export as caller, with role if not empty, authenticated
+// by a token signed as the auth service would
+func (suite *TransactionExportTestSuite) export(caller, role, query string, w http.ResponseWriter) {
+	claims := jwt.MapClaims{"sub": caller, "exp": time.Now().Add(time.Hour).Unix()}
+	if role != "" {
+		claims["roles"] = []string{role}
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(exportJWTSecret))
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/transactions/export"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	suite.router.ServeHTTP(w, req)
+}
+
+func (suite *TransactionExportTestSuite) TestExportFiltersByUserAndRange() {
+	suite.seed(1, exportEpoch, 10)
+	suite.seed(2, exportEpoch, 5)
+
+	from := exportEpoch.Add(2 * time.Minute).Format(time.RFC3339)
+	to := exportEpoch.Add(5 * time.Minute).Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	suite.export("1", "", "?format=jsonl&from="+from+"&to="+to, w)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Contains(suite.T(), w.Header().Get("Content-Disposition"), "attachment; filename=")
+
+	var exported []models.Transaction
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var transaction models.Transaction
+		assert.NoError(suite.T(), json.Unmarshal(scanner.Bytes(), &transaction))
+		exported = append(exported, transaction)
+	}
+
+	// from is inclusive and to exclusive: minutes 2, 3 and 4, oldest first
+	if assert.Len(suite.T(), exported, 3) {
+		for i, transaction := range exported {
+			assert.Equal(suite.T(), uint(1), transaction.UserID)
+			assert.True(suite.T(), transaction.CreatedAt.Equal(exportEpoch.Add(time.Duration(i+2)*time.Minute)))
+		}
+	}
+}
+
+func (suite *TransactionExportTestSuite) TestExportRequiresOwnerOrAdmin() {
+	suite.seed(2, exportEpoch, 3)
+
+	w := httptest.NewRecorder()
+	suite.export("1", "", "?userId=2", w)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.export("1", "admin", "?userId=2", w)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), records, 4, "header plus three transactions")
+}
+
+func (suite *TransactionExportTestSuite) TestExportEscapesCSV() {
+	transaction := models.Transaction{
+		CreatedAt:     exportEpoch,
+		UserID:        1,
+		InvestmentID:  1,
+		Type:          "=HYPERLINK(\"http://evil\")",
+		Status:        "COMPLETED",
+		Timestamp:     exportEpoch,
+		TransactionID: "tx, \"quoted\"\nline",
+	}
+	assert.NoError(suite.T(), suite.db.Create(&transaction).Error)
+
+	w := httptest.NewRecorder()
+	suite.export("1", "", "", w)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), records, 2) {
+		assert.Equal(suite.T(), transactionExportHeader, records[0])
+		assert.Equal(suite.T(), "tx, \"quoted\"\nline", records[1][1])
+		assert.Equal(suite.T(), "'=HYPERLINK(\"http://evil\")", records[1][3])
+	}
+}
+
+func (suite *TransactionExportTestSuite) TestExportRejectsNonNumericSubject() {
+	w := httptest.NewRecorder()
+	suite.export("not-a-user", "", "", w)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *TransactionExportTestSuite) TestExportRejectsBadParameters() {
+	for _, query := range []string{"?format=xml", "?from=yesterday", "?userId=abc", "?from=2025-03-02T00:00:00Z&to=2025-03-01T00:00:00Z"} {
+		w := httptest.NewRecorder()
+		suite.export("1", "", query, w)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// flushRecorder records how much output is buffered between flushes
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes    int
+	pending    int
+	maxPending int
+}
+
+func (r *flushRecorder) Write(b []byte) (int, error) {
+	r.pending += len(b)
+	return r.ResponseRecorder.Write(b)
+}
+
+func (r *flushRecorder) Flush() {
+	if r.pending > r.maxPending {
+		r.maxPending = r.pending
+	}
+	r.pending = 0
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+func (suite *TransactionExportTestSuite) TestLargeExportStreamsInBoundedChunks() {
+	const count = 5 * exportFlushEvery
+	suite.seed(1, exportEpoch, count)
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	suite.export("1", "", "?format=jsonl", w)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), count, strings.Count(w.Body.String(), "\n"))
+
+	// Output reaches the client every exportFlushEvery rows instead of all at the end
+	assert.GreaterOrEqual(suite.T(), w.flushes, count/exportFlushEvery)
+	perRow := w.Body.Len() / count
+	assert.LessOrEqual(suite.T(), w.maxPending, (exportFlushEvery+1)*perRow*2)
+}
+
+func TestTransactionExportSuite(t *testing.T) {
+	suite.Run(t, new(TransactionExportTestSuite))
+}
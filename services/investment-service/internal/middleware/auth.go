@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 )
@@ -31,7 +32,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		tokenString := parts[1]
 
 		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Only accept the configured signing algorithms
+		parser := &jwt.Parser{ValidMethods: jwtalg.FromEnv()}
+		token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			// Verify signing method
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, jwt.ErrSignatureInvalid
@@ -55,6 +55,10 @@ func handleError(err error, logger *logrus.Logger) (int, models.ErrorResponse) {
 		statusCode = http.StatusBadRequest
 		message = err.Error()
 
+	case errors.Is(err, validation.ErrIllegalStatusTransition):
+		statusCode = http.StatusConflict
+		message = err.Error()
+
 	default:
 		statusCode = http.StatusInternalServerError
 		message = "Internal server error"
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"investment-service/internal/config"
+	"investment-service/internal/models"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Principal is the authenticated caller of a net/http route, as set by HTTPAuth
+type Principal struct {
+	UserID uint
+	Roles  []string
+}
+
+// HasRole reports whether the principal's token carries role
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal set by HTTPAuth
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// HTTPAuth is JWTAuth for plain net/http routers such as gorilla/mux. It
+// rejects requests without a valid bearer token and puts the caller's
+// Principal, and tenant if the token has one, in the request context.
+func HTTPAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			writeHTTPError(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Authorization header required"})
+			return
+		}
+
+		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+		algorithms := config.Get().JWT.Algorithms
+		token, err := jwt.Parse(tokenString, jwtalg.Keyfunc(algorithms, func(token *jwt.Token) (interface{}, error) {
+			return []byte(config.Get().JWT.Secret), nil
+		}), jwtalg.ParserOptions(algorithms)...)
+		if err != nil || !token.Valid {
+			reason := jwtalg.Classify(err)
+			metrics.RecordJWTValidationFailure(reason)
+			writeHTTPError(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired token", Code: reason})
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			writeHTTPError(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid token claims"})
+			return
+		}
+		userID, ok := claimUserID(claims)
+		if !ok {
+			writeHTTPError(w, http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid user ID in token"})
+			return
+		}
+
+		ctx := WithPrincipal(r.Context(), Principal{UserID: userID, Roles: claimRoles(claims)})
+		if tenantID := tenant.FromClaims(claims); tenantID != "" {
+			ctx = tenant.WithTenant(ctx, tenantID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireTenant rejects requests whose context carries no tenant. Mount it after
+// HTTPAuth on routes backed by the tenant-scoped database, which refuses to run
+// queries without one.
+func RequireTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := tenant.FromContext(r.Context()); !ok {
+			writeHTTPError(w, http.StatusForbidden, models.ErrorResponse{Error: "Token is not scoped to a tenant", Code: "missing_tenant"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// claimUserID reads the numeric user ID from the sub claim
+func claimUserID(claims jwt.MapClaims) (uint, bool) {
+	return subjectUserID(claims["sub"])
+}
+
+// subjectUserID parses a sub claim, which decodes as a string or a float64
+func subjectUserID(sub interface{}) (uint, bool) {
+	switch sub := sub.(type) {
+	case string:
+		id, err := strconv.ParseUint(sub, 10, 64)
+		return uint(id), err == nil && id > 0
+	case float64:
+		return uint(sub), sub >= 1 && sub == float64(uint(sub))
+	default:
+		return 0, false
+	}
+}
+
+// claimRoles reads the roles claim, which decodes as []interface{}
+func claimRoles(claims jwt.MapClaims) []string {
+	values, _ := claims["roles"].([]interface{})
+	roles := make([]string, 0, len(values))
+	for _, value := range values {
+		if role, ok := value.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// writeHTTPError writes an error response for net/http routes
+func writeHTTPError(w http.ResponseWriter, status int, body models.ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
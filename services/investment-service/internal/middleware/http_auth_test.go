@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+)
+
+func TestRequireTenant(t *testing.T) {
+	handler := RequireTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/investments", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a tenant, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/investments", nil)
+	req = req.WithContext(tenant.WithTenant(req.Context(), "tenant-a"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected the request to reach the handler, got %d", w.Code)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"strings"
 	"time"
 
-	"errors"
 	"investment-service/internal/config"
 	"investment-service/internal/models"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/time/rate"
@@ -66,17 +68,18 @@ func JWTAuth() gin.HandlerFunc {
 		// Extract the token
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("Invalid signing method")
-			}
+		algorithms := config.Get().JWT.Algorithms
+		token, err := jwt.Parse(tokenString, jwtalg.Keyfunc(algorithms, func(token *jwt.Token) (interface{}, error) {
 			return []byte(config.Get().JWT.Secret), nil
-		})
+		}), jwtalg.ParserOptions(algorithms)...)
 
 		if err != nil || !token.Valid {
+			// Tell clients whether to refresh or discard the token
+			reason := jwtalg.Classify(err)
+			metrics.RecordJWTValidationFailure(reason)
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error: "Invalid or expired token",
+				Code:  reason,
 			})
 			c.Abort()
 			return
@@ -96,10 +99,60 @@ func JWTAuth() gin.HandlerFunc {
 		c.Set("userID", claims["sub"])
 		c.Set("roles", claims["roles"])
 
+		// Scope data access to the caller's tenant
+		if tenantID := tenant.FromClaims(claims); tenantID != "" {
+			c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+		}
+
 		c.Next()
 	}
 }
 
+// RequireRole allows only callers whose token carries role, as set by JWTAuth
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasRole(c, role) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "Insufficient permissions",
+		})
+		c.Abort()
+	}
+}
+
+// UserID returns the caller's numeric user ID from the sub claim set by JWTAuth
+func UserID(c *gin.Context) (uint, bool) {
+	sub, _ := c.Get("userID")
+	return subjectUserID(sub)
+}
+
+// HasRole reports whether the caller's token carries role, as set by JWTAuth
+func HasRole(c *gin.Context, role string) bool {
+	roles, ok := c.Get("roles")
+	if !ok {
+		return false
+	}
+
+	switch values := roles.(type) {
+	case []interface{}:
+		for _, value := range values {
+			if value == role {
+				return true
+			}
+		}
+	case []string:
+		for _, value := range values {
+			if value == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // CORS middleware
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -6,21 +6,47 @@ import (
 
 // Investment represents an investment made by a user
 type Investment struct {
-	ID            uint       `gorm:"primarykey" json:"id"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	UserID        uint       `gorm:"not null" json:"user_id"`
-	PortfolioID   uint       `json:"portfolio_id"` // Add this field to match with the foreignKey in Portfolio
-	Amount        float64    `gorm:"not null" json:"amount"`
-	Type          string     `gorm:"not null" json:"type" example:"STOCK"`    // e.g., "STOCK", "CRYPTO", "REAL_ESTATE"
-	Status        string     `gorm:"not null" json:"status" example:"ACTIVE"` // e.g., "ACTIVE", "SOLD", "PENDING"
-	PurchaseDate  time.Time  `gorm:"not null" json:"purchase_date"`
-	SellDate      *time.Time `json:"sell_date,omitempty"`
-	PurchasePrice float64    `gorm:"not null" json:"purchase_price"`
-	SellPrice     *float64   `json:"sell_price,omitempty"`
-	Symbol        string     `gorm:"not null" json:"symbol" example:"AAPL"` // e.g., "AAPL", "BTC", "ETH"
-	Quantity      float64    `gorm:"not null" json:"quantity"`
-	Notes         string     `json:"notes,omitempty"`
+	ID            uint             `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	TenantID      string           `gorm:"type:varchar(64);not null;default:'';index" json:"-"`
+	UserID        uint             `gorm:"not null" json:"user_id"`
+	PortfolioID   uint             `json:"portfolio_id"` // Add this field to match with the foreignKey in Portfolio
+	Amount        float64          `gorm:"not null" json:"amount"`
+	Currency      string           `gorm:"type:varchar(3);not null;default:'USD'" json:"currency" example:"USD"`
+	Type          InvestmentType   `gorm:"not null" json:"type" example:"STOCK"`
+	Status        InvestmentStatus `gorm:"not null" json:"status" example:"ACTIVE"`
+	PurchaseDate  time.Time        `gorm:"not null" json:"purchase_date"`
+	SellDate      *time.Time       `json:"sell_date,omitempty"`
+	PurchasePrice float64          `gorm:"not null" json:"purchase_price"`
+	SellPrice     *float64         `json:"sell_price,omitempty"`
+	Symbol        string           `gorm:"not null" json:"symbol" example:"AAPL"` // e.g., "AAPL", "BTC", "ETH"
+	Quantity      float64          `gorm:"not null" json:"quantity"`
+	Notes         string           `json:"notes,omitempty"`
+}
+
+// InvestmentSummaryGroup is the count and total amount of investments sharing a status, type and currency
+type InvestmentSummaryGroup struct {
+	Status      string  `json:"status"`
+	Type        string  `json:"type"`
+	Currency    string  `json:"currency"`
+	Count       int64   `json:"count"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// InvestmentAggregate is a count and total amount
+type InvestmentAggregate struct {
+	Count       int64   `json:"count"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// InvestmentSummary aggregates investments across all users
+type InvestmentSummary struct {
+	Total      InvestmentAggregate            `json:"total"`
+	ByStatus   map[string]InvestmentAggregate `json:"by_status"`
+	ByType     map[string]InvestmentAggregate `json:"by_type"`
+	ByCurrency map[string]InvestmentAggregate `json:"by_currency"`
+	Groups     []InvestmentSummaryGroup       `json:"groups"`
 }
 
 // Transaction represents a transaction related to an investment
@@ -0,0 +1,100 @@
+package models
+
+import "strings"
+
+// InvestmentStatus is the lifecycle state of an investment
+type InvestmentStatus string
+
+// Investment statuses
+const (
+	InvestmentStatusPending   InvestmentStatus = "PENDING"
+	InvestmentStatusActive    InvestmentStatus = "ACTIVE"
+	InvestmentStatusSold      InvestmentStatus = "SOLD"
+	InvestmentStatusCancelled InvestmentStatus = "CANCELLED"
+)
+
+// InvestmentStatuses lists every investment status
+var InvestmentStatuses = []InvestmentStatus{
+	InvestmentStatusPending,
+	InvestmentStatusActive,
+	InvestmentStatusSold,
+	InvestmentStatusCancelled,
+}
+
+// investmentStatusTransitions lists the statuses each status may move to. SOLD
+// and CANCELLED are final.
+var investmentStatusTransitions = map[InvestmentStatus][]InvestmentStatus{
+	InvestmentStatusPending: {InvestmentStatusActive, InvestmentStatusCancelled},
+	InvestmentStatusActive:  {InvestmentStatusSold, InvestmentStatusCancelled},
+}
+
+// ParseInvestmentStatus normalizes s and reports whether it is a known status
+func ParseInvestmentStatus(s string) (InvestmentStatus, bool) {
+	status := InvestmentStatus(strings.ToUpper(strings.TrimSpace(s)))
+	return status, status.Valid()
+}
+
+// Valid reports whether s is a known status
+func (s InvestmentStatus) Valid() bool {
+	for _, status := range InvestmentStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an investment in status s may move to next.
+// Keeping the current status is always allowed.
+func (s InvestmentStatus) CanTransitionTo(next InvestmentStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range investmentStatusTransitions[s] {
+		if next == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// InvestmentType is the asset class of an investment
+type InvestmentType string
+
+// Investment types
+const (
+	InvestmentTypeStock      InvestmentType = "STOCK"
+	InvestmentTypeBond       InvestmentType = "BOND"
+	InvestmentTypeETF        InvestmentType = "ETF"
+	InvestmentTypeMutualFund InvestmentType = "MUTUAL_FUND"
+	InvestmentTypeCrypto     InvestmentType = "CRYPTO"
+	InvestmentTypeRealEstate InvestmentType = "REAL_ESTATE"
+	InvestmentTypeOther      InvestmentType = "OTHER"
+)
+
+// InvestmentTypes lists every investment type
+var InvestmentTypes = []InvestmentType{
+	InvestmentTypeStock,
+	InvestmentTypeBond,
+	InvestmentTypeETF,
+	InvestmentTypeMutualFund,
+	InvestmentTypeCrypto,
+	InvestmentTypeRealEstate,
+	InvestmentTypeOther,
+}
+
+// ParseInvestmentType normalizes s and reports whether it is a known type
+func ParseInvestmentType(s string) (InvestmentType, bool) {
+	investmentType := InvestmentType(strings.ToUpper(strings.TrimSpace(s)))
+	return investmentType, investmentType.Valid()
+}
+
+// Valid reports whether t is a known type
+func (t InvestmentType) Valid() bool {
+	for _, investmentType := range InvestmentTypes {
+		if t == investmentType {
+			return true
+		}
+	}
+	return false
+}
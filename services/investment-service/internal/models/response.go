@@ -4,6 +4,8 @@ package models
 // @Description Error response
 type ErrorResponse struct {
 	Error string `json:"error" example:"Error message description"`
+	// Code is a machine-readable reason, set where clients can act on it
+	Code string `json:"code,omitempty" example:"expired"`
 }
 
 // SuccessResponse represents a standardized success response with a message
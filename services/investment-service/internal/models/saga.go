@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Investment saga statuses
+const (
+	SagaStatusPending      = "PENDING"
+	SagaStatusCompensating = "COMPENSATING"
+	SagaStatusCompleted    = "COMPLETED"
+	SagaStatusCompensated  = "COMPENSATED"
+	// SagaStatusFailed means a compensation failed and the saga needs manual attention
+	SagaStatusFailed = "FAILED"
+)
+
+// Investment saga steps, in the order they run
+const (
+	SagaStepCheckSuitability = "check_suitability"
+	SagaStepReserveFunds     = "reserve_funds"
+	SagaStepCreateInvestment = "create_investment"
+	SagaStepPublishEvent     = "publish_event"
+)
+
+// InvestmentSaga is the persisted state of a cross-service investment creation.
+// It is saved after every step so an interrupted saga can be resumed. Each
+// user's idempotency keys are their own.
+type InvestmentSaga struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TenantID       string    `gorm:"type:varchar(64);not null;default:'';uniqueIndex:idx_investment_sagas_tenant_user_key,priority:1" json:"-"`
+	IdempotencyKey string    `gorm:"type:varchar(255);uniqueIndex:idx_investment_sagas_tenant_user_key,priority:3;not null" json:"idempotency_key"`
+	Status         string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	CompletedStep  string    `gorm:"type:varchar(50)" json:"completed_step,omitempty"` // last step that completed
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_investment_sagas_tenant_user_key,priority:2" json:"user_id"`
+	Payload        string    `gorm:"type:text;not null" json:"-"`                   // the investment as JSON
+	RequestHash    string    `gorm:"type:varchar(64);not null;default:''" json:"-"` // identifies the request that started the saga
+	ReservationID  string    `gorm:"type:varchar(255)" json:"reservation_id,omitempty"`
+	InvestmentID   *uint     `json:"investment_id,omitempty"`
+	Error          string    `gorm:"type:text" json:"error,omitempty"`
+}
@@ -9,6 +9,7 @@ import (
 	"investment-service/internal/metrics"
 	"investment-service/internal/models"
 
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
 	"gorm.io/gorm"
 )
 
@@ -21,6 +22,9 @@ type InvestmentRepository interface {
 	Update(ctx context.Context, investment *models.Investment) error
 	Delete(ctx context.Context, id uint) error
 	GetAll(ctx context.Context, page, pageSize int) ([]models.Investment, int64, error)
+	ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Investment, int64, error)
+	StreamByUserID(ctx context.Context, userID uint, fn func(*models.Investment) error) error
+	Summary(ctx context.Context) ([]models.InvestmentSummaryGroup, error)
 }
 
 // GormInvestmentRepository implements InvestmentRepository using GORM
@@ -121,3 +125,66 @@ func (r *GormInvestmentRepository) GetAll(ctx context.Context, page, pageSize in
 
 	return investments, total, nil
 }
+
+// ListByUserID returns one page of the investments owned by a user
+func (r *GormInvestmentRepository) ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Investment, int64, error) {
+	defer metrics.TrackDBQuery("investment_list_by_user")()
+
+	var investments []models.Investment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Investment{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id").Offset(offset).Limit(pageSize).Find(&investments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return investments, total, nil
+}
+
+// StreamByUserID calls fn for every investment owned by a user, reading them from
+// a database cursor one row at a time so the full result set is never loaded into memory
+func (r *GormInvestmentRepository) StreamByUserID(ctx context.Context, userID uint, fn func(*models.Investment) error) error {
+	defer metrics.TrackDBQuery("investment_stream")()
+
+	db := r.db.WithContext(ctx)
+	rows, err := db.Model(&models.Investment{}).Where("user_id = ?", userID).Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var investment models.Investment
+		if err := db.ScanRows(rows, &investment); err != nil {
+			return err
+		}
+		if err := fn(&investment); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Summary returns investment counts and total amounts grouped by status, type and
+// currency. The aggregation runs in the database using idx_investments_summary.
+func (r *GormInvestmentRepository) Summary(ctx context.Context) ([]models.InvestmentSummaryGroup, error) {
+	defer metrics.TrackDBQuery("investment_summary")()
+
+	var groups []models.InvestmentSummaryGroup
+	err := sharedDB.WithStatementTimeout(ctx, r.db, database.StatementTimeouts, sharedDB.QueryTypeReport, func(tx *gorm.DB) error {
+		return tx.Model(&models.Investment{}).
+			Select("status, type, currency, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS total_amount").
+			Group("status, type, currency").
+			Order("status, type, currency").
+			Scan(&groups).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"investment-service/internal/database"
+	"investment-service/internal/metrics"
+	"investment-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvestmentAlreadyCreated is returned by CreateInvestment when the saga already created its investment
+var ErrInvestmentAlreadyCreated = errors.New("saga investment already created")
+
+// SagaRepository persists investment saga state
+type SagaRepository interface {
+	Create(ctx context.Context, saga *models.InvestmentSaga) error
+	GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*models.InvestmentSaga, error)
+	Update(ctx context.Context, saga *models.InvestmentSaga) error
+	CreateInvestment(ctx context.Context, saga *models.InvestmentSaga, investment *models.Investment) error
+	ListUnfinished(ctx context.Context) ([]models.InvestmentSaga, error)
+}
+
+// GormSagaRepository implements SagaRepository using GORM
+type GormSagaRepository struct {
+	db *gorm.DB
+}
+
+// NewSagaRepository creates a new saga repository
+func NewSagaRepository() SagaRepository {
+	return &GormSagaRepository{
+		db: database.DB,
+	}
+}
+
+// Create inserts a new saga
+func (r *GormSagaRepository) Create(ctx context.Context, saga *models.InvestmentSaga) error {
+	defer metrics.TrackDBQuery("saga_create")()
+	return r.db.WithContext(ctx).Create(saga).Error
+}
+
+// GetByIdempotencyKey retrieves the saga userID started with an idempotency key
+func (r *GormSagaRepository) GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*models.InvestmentSaga, error) {
+	defer metrics.TrackDBQuery("saga_get_by_key")()
+
+	var saga models.InvestmentSaga
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ?", userID, key).First(&saga).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &saga, nil
+}
+
+// Update saves the saga state
+func (r *GormSagaRepository) Update(ctx context.Context, saga *models.InvestmentSaga) error {
+	defer metrics.TrackDBQuery("saga_update")()
+	return r.db.WithContext(ctx).Save(saga).Error
+}
+
+// CreateInvestment inserts investment and records it on saga in one transaction,
+// so a resumed saga can never create the investment twice
+func (r *GormSagaRepository) CreateInvestment(ctx context.Context, saga *models.InvestmentSaga, investment *models.Investment) error {
+	defer metrics.TrackDBQuery("saga_create_investment")()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claim the step first so concurrent runs of the same saga cannot both create
+		result := tx.Model(&models.InvestmentSaga{}).
+			Where("id = ? AND investment_id IS NULL", saga.ID).
+			Update("completed_step", saga.CompletedStep)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvestmentAlreadyCreated
+		}
+
+		if err := tx.Create(investment).Error; err != nil {
+			return err
+		}
+		saga.InvestmentID = &investment.ID
+		return tx.Save(saga).Error
+	})
+}
+
+// ListUnfinished returns sagas that were interrupted before finishing
+func (r *GormSagaRepository) ListUnfinished(ctx context.Context) ([]models.InvestmentSaga, error) {
+	defer metrics.TrackDBQuery("saga_list_unfinished")()
+
+	var sagas []models.InvestmentSaga
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []string{models.SagaStatusPending, models.SagaStatusCompensating}).
+		Order("created_at").
+		Find(&sagas).Error
+	return sagas, err
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"investment-service/internal/models"
+	"investment-service/internal/repositories"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrSagaAborted is returned when an investment saga failed and was compensated
+	ErrSagaAborted = errors.New("investment saga aborted")
+	// ErrSagaInProgress is returned when a saga with the same idempotency key has not finished
+	ErrSagaInProgress = errors.New("investment saga in progress")
+	// ErrIdempotencyKeyReused is returned when a user reuses an idempotency key for a different investment
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused for a different investment")
+)
+
+// SuitabilityChecker checks with the user service that an investment suits the user
+type SuitabilityChecker interface {
+	CheckSuitability(ctx context.Context, investment *models.Investment) error
+}
+
+// FundsReserver reserves and releases funds in the accounts service. ReserveFunds
+// must be idempotent on the user's idempotencyKey so a retried saga never
+// reserves twice.
+type FundsReserver interface {
+	ReserveFunds(ctx context.Context, idempotencyKey string, userID uint, amount float64, currency string) (reservationID string, err error)
+	ReleaseFunds(ctx context.Context, reservationID string) error
+}
+
+// InvestmentEventPublisher publishes investment events to other services
+type InvestmentEventPublisher interface {
+	PublishInvestmentCreated(ctx context.Context, investment *models.Investment) error
+}
+
+// sagaStore is the subset of SagaRepository used by the orchestrator
+type sagaStore interface {
+	Create(ctx context.Context, saga *models.InvestmentSaga) error
+	GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*models.InvestmentSaga, error)
+	Update(ctx context.Context, saga *models.InvestmentSaga) error
+	CreateInvestment(ctx context.Context, saga *models.InvestmentSaga, investment *models.Investment) error
+	ListUnfinished(ctx context.Context) ([]models.InvestmentSaga, error)
+}
+
+// sagaRun is a saga being executed together with its decoded investment
+type sagaRun struct {
+	saga       *models.InvestmentSaga
+	investment *models.Investment
+}
+
+// sagaStep is one step of the investment saga. Steps up to and including the
+// pivot are undone by their compensations if a later step up to the pivot
+// fails; steps after the pivot cannot fail the saga and are retried on resume.
+type sagaStep struct {
+	name       string
+	run        func(ctx context.Context, r *sagaRun) error
+	compensate func(ctx context.Context, r *sagaRun) error
+	pivot      bool
+}
+
+// InvestmentSagaOrchestrator creates investments across the user, accounts and
+// investment services: check suitability, reserve funds, create the investment,
+// then publish an event. Saga state is persisted after every step.
+type InvestmentSagaOrchestrator struct {
+	sagas       sagaStore
+	suitability SuitabilityChecker
+	funds       FundsReserver
+	events      InvestmentEventPublisher
+	logger      *zap.Logger
+	steps       []sagaStep
+	running     sync.Map // tenant, user and idempotency keys of sagas executing in this instance
+}
+
+// NewInvestmentSagaOrchestrator creates a new investment saga orchestrator
+func NewInvestmentSagaOrchestrator(sagas sagaStore, suitability SuitabilityChecker, funds FundsReserver, events InvestmentEventPublisher, logger *zap.Logger) *InvestmentSagaOrchestrator {
+	o := &InvestmentSagaOrchestrator{
+		sagas:       sagas,
+		suitability: suitability,
+		funds:       funds,
+		events:      events,
+		logger:      logger,
+	}
+
+	o.steps = []sagaStep{
+		{
+			name: models.SagaStepCheckSuitability,
+			run: func(ctx context.Context, r *sagaRun) error {
+				return o.suitability.CheckSuitability(ctx, r.investment)
+			},
+		},
+		{
+			name: models.SagaStepReserveFunds,
+			run: func(ctx context.Context, r *sagaRun) error {
+				reservationID, err := o.funds.ReserveFunds(ctx, r.saga.IdempotencyKey, r.investment.UserID, r.investment.Amount, r.investment.Currency)
+				if err != nil {
+					return err
+				}
+				r.saga.ReservationID = reservationID
+				return nil
+			},
+			compensate: func(ctx context.Context, r *sagaRun) error {
+				return o.funds.ReleaseFunds(ctx, r.saga.ReservationID)
+			},
+		},
+		{
+			name: models.SagaStepCreateInvestment,
+			run: func(ctx context.Context, r *sagaRun) error {
+				// The investment and the completed step are saved together
+				r.saga.CompletedStep = models.SagaStepCreateInvestment
+				if err := o.sagas.CreateInvestment(ctx, r.saga, r.investment); err != nil {
+					r.saga.CompletedStep = models.SagaStepReserveFunds
+					r.saga.InvestmentID = nil
+					if errors.Is(err, repositories.ErrInvestmentAlreadyCreated) {
+						// Another run of this saga got there first; it owns the rest of the saga
+						return fmt.Errorf("%w: %v", ErrSagaInProgress, err)
+					}
+					return err
+				}
+				return nil
+			},
+			pivot: true,
+		},
+		{
+			name: models.SagaStepPublishEvent,
+			run: func(ctx context.Context, r *sagaRun) error {
+				return o.events.PublishInvestmentCreated(ctx, r.investment)
+			},
+		},
+	}
+
+	return o
+}
+
+// CreateInvestment runs the saga for investment. Calls by the same user with
+// the same idempotencyKey return the outcome of the first call instead of
+// repeating it; an unfinished saga for the key is resumed. Reusing a key for a
+// different investment returns ErrIdempotencyKeyReused.
+func (o *InvestmentSagaOrchestrator) CreateInvestment(ctx context.Context, idempotencyKey string, investment *models.Investment) (*models.Investment, error) {
+	requestHash, err := investmentRequestHash(investment)
+	if err != nil {
+		return nil, err
+	}
+
+	saga, err := o.sagas.GetByIdempotencyKey(ctx, investment.UserID, idempotencyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saga: %w", err)
+	}
+
+	if saga == nil {
+		now := time.Now()
+		investment.CreatedAt = now
+		investment.UpdatedAt = now
+		if investment.PurchaseDate.IsZero() {
+			investment.PurchaseDate = now
+		}
+
+		payload, err := json.Marshal(investment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode investment: %w", err)
+		}
+
+		saga = &models.InvestmentSaga{
+			IdempotencyKey: idempotencyKey,
+			Status:         models.SagaStatusPending,
+			UserID:         investment.UserID,
+			Payload:        string(payload),
+			RequestHash:    requestHash,
+		}
+		if err := o.sagas.Create(ctx, saga); err != nil {
+			// A concurrent call with the same key may have created it first
+			existing, getErr := o.sagas.GetByIdempotencyKey(ctx, investment.UserID, idempotencyKey)
+			if getErr != nil || existing == nil {
+				return nil, fmt.Errorf("failed to create saga: %w", err)
+			}
+			saga = existing
+		}
+	}
+
+	// Sagas saved before request hashes were recorded have none to compare
+	if saga.RequestHash != "" && saga.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	return o.execute(ctx, saga)
+}
+
+// investmentRequestHash identifies the investment a saga is requested for,
+// ignoring the ID and timestamps the saga assigns
+func investmentRequestHash(investment *models.Investment) (string, error) {
+	request := *investment
+	request.ID = 0
+	request.CreatedAt = time.Time{}
+	request.UpdatedAt = time.Time{}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode investment: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Resume continues every saga that was interrupted, e.g. by a crash. It is
+// called on startup. Each saga is resumed within its own tenant.
+func (o *InvestmentSagaOrchestrator) Resume(ctx context.Context) error {
+	sagas, err := o.sagas.ListUnfinished(tenant.WithoutScope(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to list unfinished sagas: %w", err)
+	}
+
+	var errs []error
+	for i := range sagas {
+		sagaCtx := tenant.WithoutScope(ctx)
+		if sagas[i].TenantID != "" {
+			sagaCtx = tenant.WithTenant(ctx, sagas[i].TenantID)
+		}
+		if _, err := o.execute(sagaCtx, &sagas[i]); err != nil && !errors.Is(err, ErrSagaAborted) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// execute runs saga from where it left off
+func (o *InvestmentSagaOrchestrator) execute(ctx context.Context, saga *models.InvestmentSaga) (*models.Investment, error) {
+	// Idempotency keys are unique per tenant and user
+	runKey := fmt.Sprintf("%s/%d/%s", saga.TenantID, saga.UserID, saga.IdempotencyKey)
+	if _, busy := o.running.LoadOrStore(runKey, struct{}{}); busy {
+		return nil, ErrSagaInProgress
+	}
+	defer o.running.Delete(runKey)
+
+	var investment models.Investment
+	if err := json.Unmarshal([]byte(saga.Payload), &investment); err != nil {
+		return nil, fmt.Errorf("failed to decode saga payload: %w", err)
+	}
+	if saga.InvestmentID != nil {
+		investment.ID = *saga.InvestmentID
+	}
+	r := &sagaRun{saga: saga, investment: &investment}
+
+	switch saga.Status {
+	case models.SagaStatusCompleted:
+		return r.investment, nil
+	case models.SagaStatusCompensated, models.SagaStatusFailed:
+		return nil, fmt.Errorf("%w: %s", ErrSagaAborted, saga.Error)
+	case models.SagaStatusCompensating:
+		return nil, o.compensate(ctx, r)
+	}
+
+	pastPivot := false
+	for i, step := range o.steps {
+		if i <= o.stepIndex(saga.CompletedStep) {
+			pastPivot = pastPivot || step.pivot
+			continue
+		}
+
+		if err := step.run(ctx, r); err != nil {
+			if errors.Is(err, ErrSagaInProgress) {
+				return nil, err
+			}
+			if pastPivot {
+				// The investment exists; leave the saga pending so the step is retried on resume
+				saga.Error = fmt.Sprintf("%s: %v", step.name, err)
+				if updateErr := o.sagas.Update(ctx, saga); updateErr != nil {
+					o.logger.Error("Failed to save saga", zap.String("idempotency_key", saga.IdempotencyKey), zap.Error(updateErr))
+				}
+				return nil, fmt.Errorf("%w: %s failed: %v", ErrSagaInProgress, step.name, err)
+			}
+
+			saga.Status = models.SagaStatusCompensating
+			saga.Error = fmt.Sprintf("%s: %v", step.name, err)
+			if err := o.sagas.Update(ctx, saga); err != nil {
+				return nil, fmt.Errorf("failed to save saga: %w", err)
+			}
+			return nil, o.compensate(ctx, r)
+		}
+
+		saga.CompletedStep = step.name
+		saga.Error = ""
+		if err := o.sagas.Update(ctx, saga); err != nil {
+			return nil, fmt.Errorf("failed to save saga after %s: %w", step.name, err)
+		}
+		pastPivot = pastPivot || step.pivot
+	}
+
+	saga.Status = models.SagaStatusCompleted
+	if err := o.sagas.Update(ctx, saga); err != nil {
+		return nil, fmt.Errorf("failed to save saga: %w", err)
+	}
+
+	return r.investment, nil
+}
+
+// compensate undoes the completed steps of a failed saga in reverse order
+func (o *InvestmentSagaOrchestrator) compensate(ctx context.Context, r *sagaRun) error {
+	saga := r.saga
+
+	for i := o.stepIndex(saga.CompletedStep); i >= 0; i-- {
+		step := o.steps[i]
+		if step.compensate != nil {
+			if err := step.compensate(ctx, r); err != nil {
+				saga.Status = models.SagaStatusFailed
+				saga.Error = fmt.Sprintf("%s; compensating %s: %v", saga.Error, step.name, err)
+				if updateErr := o.sagas.Update(ctx, saga); updateErr != nil {
+					o.logger.Error("Failed to save saga", zap.String("idempotency_key", saga.IdempotencyKey), zap.Error(updateErr))
+				}
+				o.logger.Error("Investment saga compensation failed",
+					zap.String("idempotency_key", saga.IdempotencyKey),
+					zap.String("step", step.name),
+					zap.Error(err))
+				return fmt.Errorf("%w: compensating %s failed: %v", ErrSagaAborted, step.name, err)
+			}
+		}
+
+		// Record progress so a resumed compensation does not repeat this step
+		if i > 0 {
+			saga.CompletedStep = o.steps[i-1].name
+		} else {
+			saga.CompletedStep = ""
+		}
+		if err := o.sagas.Update(ctx, saga); err != nil {
+			return fmt.Errorf("failed to save saga: %w", err)
+		}
+	}
+
+	saga.Status = models.SagaStatusCompensated
+	if err := o.sagas.Update(ctx, saga); err != nil {
+		return fmt.Errorf("failed to save saga: %w", err)
+	}
+
+	return fmt.Errorf("%w: %s", ErrSagaAborted, saga.Error)
+}
+
+// stepIndex returns the position of the named step, or -1 if no step has completed
+func (o *InvestmentSagaOrchestrator) stepIndex(name string) int {
+	for i, step := range o.steps {
+		if step.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,319 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"investment-service/internal/models"
+	"investment-service/internal/repositories"
+	"investment-service/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memorySagaStore is an in-memory saga repository that also holds created investments
+type memorySagaStore struct {
+	mu          sync.Mutex
+	sagas       map[string]models.InvestmentSaga
+	investments []models.Investment
+	createErr   error
+}
+
+// sagaKey scopes an idempotency key to its user like the unique index does
+func sagaKey(userID uint, key string) string {
+	return fmt.Sprintf("%d/%s", userID, key)
+}
+
+func newMemorySagaStore() *memorySagaStore {
+	return &memorySagaStore{sagas: make(map[string]models.InvestmentSaga)}
+}
+
+func (m *memorySagaStore) Create(ctx context.Context, saga *models.InvestmentSaga) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.sagas[sagaKey(saga.UserID, saga.IdempotencyKey)]; exists {
+		return errors.New("duplicate idempotency key")
+	}
+	saga.ID = uint(len(m.sagas) + 1)
+	m.sagas[sagaKey(saga.UserID, saga.IdempotencyKey)] = *saga
+	return nil
+}
+
+func (m *memorySagaStore) GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*models.InvestmentSaga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saga, ok := m.sagas[sagaKey(userID, key)]
+	if !ok {
+		return nil, nil
+	}
+	return &saga, nil
+}
+
+func (m *memorySagaStore) Update(ctx context.Context, saga *models.InvestmentSaga) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sagas[sagaKey(saga.UserID, saga.IdempotencyKey)] = *saga
+	return nil
+}
+
+func (m *memorySagaStore) CreateInvestment(ctx context.Context, saga *models.InvestmentSaga, investment *models.Investment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.createErr != nil {
+		return m.createErr
+	}
+	if m.sagas[sagaKey(saga.UserID, saga.IdempotencyKey)].InvestmentID != nil {
+		return repositories.ErrInvestmentAlreadyCreated
+	}
+	investment.ID = uint(len(m.investments) + 1)
+	m.investments = append(m.investments, *investment)
+	saga.InvestmentID = &investment.ID
+	m.sagas[sagaKey(saga.UserID, saga.IdempotencyKey)] = *saga
+	return nil
+}
+
+func (m *memorySagaStore) ListUnfinished(ctx context.Context) ([]models.InvestmentSaga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sagas []models.InvestmentSaga
+	for _, saga := range m.sagas {
+		if saga.Status == models.SagaStatusPending || saga.Status == models.SagaStatusCompensating {
+			sagas = append(sagas, saga)
+		}
+	}
+	return sagas, nil
+}
+
+func (m *memorySagaStore) saga(userID uint, key string) models.InvestmentSaga {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sagas[sagaKey(userID, key)]
+}
+
+// fakeAccounts reserves funds idempotently by user and key, like the accounts service
+type fakeAccounts struct {
+	mu           sync.Mutex
+	reservations map[string]string // reservation ID -> user's idempotency key
+	reserveCalls int
+}
+
+func newFakeAccounts() *fakeAccounts {
+	return &fakeAccounts{reservations: make(map[string]string)}
+}
+
+func (f *fakeAccounts) ReserveFunds(ctx context.Context, idempotencyKey string, userID uint, amount float64, currency string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reserveCalls++
+	for id, key := range f.reservations {
+		if key == sagaKey(userID, idempotencyKey) {
+			return id, nil
+		}
+	}
+	id := fmt.Sprintf("res-%d", f.reserveCalls)
+	f.reservations[id] = sagaKey(userID, idempotencyKey)
+	return id, nil
+}
+
+func (f *fakeAccounts) ReleaseFunds(ctx context.Context, reservationID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.reservations, reservationID)
+	return nil
+}
+
+type suitabilityFunc func(ctx context.Context, investment *models.Investment) error
+
+func (f suitabilityFunc) CheckSuitability(ctx context.Context, investment *models.Investment) error {
+	return f(ctx, investment)
+}
+
+func suitable(ctx context.Context, investment *models.Investment) error { return nil }
+
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []uint
+	err       error
+}
+
+func (f *fakePublisher) PublishInvestmentCreated(ctx context.Context, investment *models.Investment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, investment.ID)
+	return nil
+}
+
+func newSagaInvestment() *models.Investment {
+	return &models.Investment{
+		UserID:        1,
+		PortfolioID:   1,
+		Amount:        1500,
+		Currency:      "USD",
+		Type:          "STOCK",
+		Symbol:        "AAPL",
+		Quantity:      10,
+		PurchasePrice: 150,
+	}
+}
+
+func TestInvestmentSaga_CompletesAllSteps(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitabilityFunc(suitable), accounts, events, zap.NewNop())
+
+	investment, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.NoError(t, err)
+
+	assert.NotZero(t, investment.ID)
+	assert.Len(t, store.investments, 1)
+	assert.Len(t, accounts.reservations, 1)
+	assert.Equal(t, []uint{investment.ID}, events.published)
+
+	saga := store.saga(1, "key-1")
+	assert.Equal(t, models.SagaStatusCompleted, saga.Status)
+	assert.Equal(t, models.SagaStepPublishEvent, saga.CompletedStep)
+}
+
+func TestInvestmentSaga_CreateFailureReleasesFunds(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+	store.createErr = errors.New("database unavailable")
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitabilityFunc(suitable), accounts, events, zap.NewNop())
+
+	_, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.ErrorIs(t, err, services.ErrSagaAborted)
+
+	assert.Equal(t, 1, accounts.reserveCalls, "funds should have been reserved before the failure")
+	assert.Empty(t, accounts.reservations, "the reservation should have been released")
+	assert.Empty(t, store.investments)
+	assert.Empty(t, events.published)
+
+	saga := store.saga(1, "key-1")
+	assert.Equal(t, models.SagaStatusCompensated, saga.Status)
+	assert.Contains(t, saga.Error, models.SagaStepCreateInvestment)
+
+	// Retrying with the same key reports the original failure without reserving again
+	_, err = orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	assert.ErrorIs(t, err, services.ErrSagaAborted)
+	assert.Equal(t, 1, accounts.reserveCalls)
+}
+
+func TestInvestmentSaga_UnsuitableInvestmentReservesNothing(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+	unsuitable := suitabilityFunc(func(ctx context.Context, investment *models.Investment) error {
+		return errors.New("risk profile too conservative")
+	})
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, unsuitable, accounts, events, zap.NewNop())
+
+	_, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.ErrorIs(t, err, services.ErrSagaAborted)
+
+	assert.Zero(t, accounts.reserveCalls)
+	assert.Equal(t, models.SagaStatusCompensated, store.saga(1, "key-1").Status)
+}
+
+func TestInvestmentSaga_RetryIsIdempotent(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitabilityFunc(suitable), accounts, events, zap.NewNop())
+
+	first, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.NoError(t, err)
+	second, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ID, second.ID)
+	assert.Len(t, store.investments, 1)
+	assert.Equal(t, 1, accounts.reserveCalls)
+	assert.Len(t, events.published, 1)
+}
+
+func TestInvestmentSaga_ResumesAfterCrash(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+
+	// A previous instance reserved funds, saved the saga and then crashed
+	reservationID, _ := accounts.ReserveFunds(context.Background(), "key-1", 1, 1500, "USD")
+	require.NoError(t, store.Create(context.Background(), &models.InvestmentSaga{
+		IdempotencyKey: "key-1",
+		Status:         models.SagaStatusPending,
+		CompletedStep:  models.SagaStepReserveFunds,
+		UserID:         1,
+		Payload:        `{"user_id":1,"portfolio_id":1,"amount":1500,"currency":"USD","type":"STOCK","symbol":"AAPL","quantity":10,"purchase_price":150}`,
+		ReservationID:  reservationID,
+	}))
+
+	checked := false
+	suitability := suitabilityFunc(func(ctx context.Context, investment *models.Investment) error {
+		checked = true
+		return nil
+	})
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitability, accounts, events, zap.NewNop())
+	require.NoError(t, orchestrator.Resume(context.Background()))
+
+	assert.False(t, checked, "completed steps must not run again")
+	assert.Equal(t, 1, accounts.reserveCalls)
+	assert.Len(t, store.investments, 1)
+	assert.Len(t, events.published, 1)
+	assert.Equal(t, models.SagaStatusCompleted, store.saga(1, "key-1").Status)
+}
+
+func TestInvestmentSaga_PublishFailureIsRetriedNotCompensated(t *testing.T) {
+	store, accounts := newMemorySagaStore(), newFakeAccounts()
+	events := &fakePublisher{err: errors.New("broker unavailable")}
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitabilityFunc(suitable), accounts, events, zap.NewNop())
+
+	_, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.ErrorIs(t, err, services.ErrSagaInProgress)
+
+	// The investment exists, so the funds stay reserved
+	assert.Len(t, store.investments, 1)
+	assert.Len(t, accounts.reservations, 1)
+	assert.Equal(t, models.SagaStatusPending, store.saga(1, "key-1").Status)
+
+	events.err = nil
+	require.NoError(t, orchestrator.Resume(context.Background()))
+
+	assert.Len(t, store.investments, 1)
+	assert.Len(t, events.published, 1)
+	assert.Equal(t, models.SagaStatusCompleted, store.saga(1, "key-1").Status)
+}
+
+func TestInvestmentSaga_KeysAreScopedToUser(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitabilityFunc(suitable), accounts, events, zap.NewNop())
+
+	first, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.NoError(t, err)
+
+	other := newSagaInvestment()
+	other.UserID = 2
+	second, err := orchestrator.CreateInvestment(context.Background(), "key-1", other)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.ID, second.ID, "another user's key must not return their investment")
+	assert.Equal(t, uint(2), second.UserID)
+	assert.Len(t, store.investments, 2)
+	assert.Len(t, accounts.reservations, 2)
+	assert.Equal(t, models.SagaStatusCompleted, store.saga(2, "key-1").Status)
+}
+
+func TestInvestmentSaga_RejectsKeyReusedWithDifferentInvestment(t *testing.T) {
+	store, accounts, events := newMemorySagaStore(), newFakeAccounts(), &fakePublisher{}
+	orchestrator := services.NewInvestmentSagaOrchestrator(store, suitabilityFunc(suitable), accounts, events, zap.NewNop())
+
+	_, err := orchestrator.CreateInvestment(context.Background(), "key-1", newSagaInvestment())
+	require.NoError(t, err)
+
+	changed := newSagaInvestment()
+	changed.Amount = 3000
+	_, err = orchestrator.CreateInvestment(context.Background(), "key-1", changed)
+	require.ErrorIs(t, err, services.ErrIdempotencyKeyReused)
+
+	assert.Len(t, store.investments, 1)
+	assert.Equal(t, 1, accounts.reserveCalls)
+}
@@ -75,7 +75,7 @@ func (s *investmentService) CreateInvestment(ctx context.Context, investment *mo
 
 	// Set default status if not provided
 	if investment.Status == "" {
-		investment.Status = "ACTIVE"
+		investment.Status = models.InvestmentStatusActive
 	}
 
 	// Set purchase date if not provided
@@ -157,6 +157,15 @@ func (s *investmentService) UpdateInvestment(ctx context.Context, investment *mo
 		return ErrInvestmentNotFound
 	}
 
+	// Keep the current status unless a legal transition was requested
+	if investment.Status == "" {
+		investment.Status = existing.Status
+	}
+	if err := validation.ValidateStatusTransition(existing.Status, investment.Status); err != nil {
+		metrics.RecordBusinessError("validation")
+		return err
+	}
+
 	// Update investment
 	err = s.repo.Update(ctx, investment)
 	if err != nil {
@@ -283,14 +292,18 @@ func (s *investmentService) ProcessTransaction(ctx context.Context, transaction
 	}
 
 	// Update investment status based on transaction
+	next := investment.Status
 	switch transaction.Status {
 	case "completed":
-		investment.Status = "active"
-	case "failed":
-		investment.Status = "failed"
-	case "cancelled":
-		investment.Status = "cancelled"
+		next = models.InvestmentStatusActive
+	case "failed", "cancelled":
+		next = models.InvestmentStatusCancelled
+	}
+	if err := validation.ValidateStatusTransition(investment.Status, next); err != nil {
+		metrics.RecordBusinessError("validation")
+		return err
 	}
+	investment.Status = next
 
 	investment.UpdatedAt = time.Now()
 	if err := s.repo.UpdateInvestment(ctx, investment); err != nil {
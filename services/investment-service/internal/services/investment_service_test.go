@@ -55,6 +55,21 @@ func (m *MockInvestmentRepository) GetAll(ctx context.Context, page, pageSize in
 	return args.Get(0).([]models.Investment), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockInvestmentRepository) ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Investment, int64, error) {
+	args := m.Called(ctx, userID, page, pageSize)
+	return args.Get(0).([]models.Investment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockInvestmentRepository) Summary(ctx context.Context) ([]models.InvestmentSummaryGroup, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.InvestmentSummaryGroup), args.Error(1)
+}
+
+func (m *MockInvestmentRepository) StreamByUserID(ctx context.Context, userID uint, fn func(*models.Investment) error) error {
+	args := m.Called(ctx, userID, fn)
+	return args.Error(0)
+}
+
 // Tests
 func TestCreateInvestment(t *testing.T) {
 	// Create mock repository
@@ -86,7 +101,7 @@ func TestCreateInvestment(t *testing.T) {
 
 		// Assert
 		assert.NoError(t, err)
-		assert.Equal(t, "ACTIVE", investment.Status)
+		assert.Equal(t, models.InvestmentStatusActive, investment.Status)
 		assert.Equal(t, 1500.0, investment.Amount)
 		assert.False(t, investment.PurchaseDate.IsZero())
 		mockRepo.AssertExpectations(t)
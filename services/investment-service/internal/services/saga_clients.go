@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"investment-service/internal/models"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+	"github.com/adil-faiyaz98/sparkfund/pkg/events"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+)
+
+// ErrInvestmentUnsuitable is returned by CheckSuitability when the user service
+// finds an investment unsuitable for the user
+var ErrInvestmentUnsuitable = errors.New("investment is not suitable for the user")
+
+// EventInvestmentCreated is published once an investment saga created its investment
+const EventInvestmentCreated = "investment.created"
+
+// UserServiceSuitability is a SuitabilityChecker backed by the user service
+type UserServiceSuitability struct {
+	client *client.Client
+}
+
+// NewUserServiceSuitability creates a suitability checker calling the user service through c
+func NewUserServiceSuitability(c *client.Client) *UserServiceSuitability {
+	return &UserServiceSuitability{client: c}
+}
+
+// suitabilityRequest is the investment the user service checks
+type suitabilityRequest struct {
+	Type     models.InvestmentType `json:"type"`
+	Symbol   string                `json:"symbol"`
+	Amount   float64               `json:"amount"`
+	Currency string                `json:"currency"`
+}
+
+// suitabilityResponse is the user service's verdict
+type suitabilityResponse struct {
+	Suitable bool   `json:"suitable"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// CheckSuitability implements SuitabilityChecker
+func (s *UserServiceSuitability) CheckSuitability(ctx context.Context, investment *models.Investment) error {
+	path := fmt.Sprintf("/api/v1/users/%d/suitability", investment.UserID)
+	request := suitabilityRequest{
+		Type:     investment.Type,
+		Symbol:   investment.Symbol,
+		Amount:   investment.Amount,
+		Currency: investment.Currency,
+	}
+
+	var verdict suitabilityResponse
+	if err := s.client.PostJSON(ctx, "check_suitability", path, request, &verdict); err != nil {
+		return err
+	}
+	if !verdict.Suitable {
+		return fmt.Errorf("%w: %s", ErrInvestmentUnsuitable, verdict.Reason)
+	}
+	return nil
+}
+
+// AccountsFunds is a FundsReserver backed by the accounts service
+type AccountsFunds struct {
+	client *client.Client
+}
+
+// NewAccountsFunds creates a funds reserver calling the accounts service through c
+func NewAccountsFunds(c *client.Client) *AccountsFunds {
+	return &AccountsFunds{client: c}
+}
+
+// reservationRequest asks the accounts service to hold funds. The accounts
+// service returns the existing reservation for a repeated idempotency key.
+type reservationRequest struct {
+	IdempotencyKey string  `json:"idempotency_key"`
+	UserID         uint    `json:"user_id"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency"`
+}
+
+// reservationResponse identifies the funds held
+type reservationResponse struct {
+	ID string `json:"id"`
+}
+
+// ReserveFunds implements FundsReserver
+func (a *AccountsFunds) ReserveFunds(ctx context.Context, idempotencyKey string, userID uint, amount float64, currency string) (string, error) {
+	request := reservationRequest{
+		IdempotencyKey: idempotencyKey,
+		UserID:         userID,
+		Amount:         amount,
+		Currency:       currency,
+	}
+
+	var reservation reservationResponse
+	if err := a.client.PostJSON(ctx, "reserve_funds", "/api/v1/reservations", request, &reservation); err != nil {
+		return "", err
+	}
+	if reservation.ID == "" {
+		return "", errors.New("accounts service returned no reservation ID")
+	}
+	return reservation.ID, nil
+}
+
+// ReleaseFunds implements FundsReserver. A reservation the accounts service no
+// longer has counts as released, so a resumed compensation can repeat it.
+func (a *AccountsFunds) ReleaseFunds(ctx context.Context, reservationID string) error {
+	resp, err := a.client.Do(ctx, "release_funds", http.MethodDelete, "/api/v1/reservations/"+url.PathEscape(reservationID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return nil
+	}
+	return &client.StatusError{Service: "accounts-service", Operation: "release_funds", StatusCode: resp.StatusCode}
+}
+
+// EventBusPublisher is an InvestmentEventPublisher publishing to an event bus
+type EventBusPublisher struct {
+	publisher events.Publisher
+}
+
+// NewEventBusPublisher creates an investment event publisher over publisher
+func NewEventBusPublisher(publisher events.Publisher) *EventBusPublisher {
+	return &EventBusPublisher{publisher: publisher}
+}
+
+// PublishInvestmentCreated implements InvestmentEventPublisher
+func (p *EventBusPublisher) PublishInvestmentCreated(ctx context.Context, investment *models.Investment) error {
+	event := events.New(EventInvestmentCreated, "investment-service", strconv.FormatUint(uint64(investment.ID), 10), investment)
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		event.TenantID = tenantID
+	}
+	return p.publisher.Publish(ctx, event)
+}
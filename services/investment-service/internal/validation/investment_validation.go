@@ -2,7 +2,7 @@ package validation
 
 import (
 	"errors"
-	"strings"
+	"fmt"
 
 	"investment-service/internal/models"
 )
@@ -15,26 +15,11 @@ var (
 	ErrNonPositiveQuantity = errors.New("quantity must be positive")
 	ErrNonPositivePrice    = errors.New("price must be positive")
 	ErrMissingSymbol       = errors.New("symbol is required")
+	// ErrIllegalStatusTransition is returned when an investment may not move
+	// from its current status to the requested one
+	ErrIllegalStatusTransition = errors.New("illegal investment status transition")
 )
 
-// Valid investment types
-var validTypes = map[string]bool{
-	"STOCK":       true,
-	"BOND":        true,
-	"ETF":         true,
-	"MUTUAL_FUND": true,
-	"CRYPTO":      true,
-	"REAL_ESTATE": true,
-	"OTHER":       true,
-}
-
-// Valid investment statuses
-var validStatuses = map[string]bool{
-	"ACTIVE":  true,
-	"SOLD":    true,
-	"PENDING": true,
-}
-
 // ValidateInvestment validates investment data
 func ValidateInvestment(investment *models.Investment) error {
 	// Check required fields
@@ -46,18 +31,19 @@ func ValidateInvestment(investment *models.Investment) error {
 		return ErrMissingSymbol
 	}
 
-	// Normalize type and status to uppercase
-	investment.Type = strings.ToUpper(investment.Type)
-	investment.Status = strings.ToUpper(investment.Status)
-
-	// Validate type
-	if !validTypes[investment.Type] {
+	// Normalize and validate type and status
+	investmentType, ok := models.ParseInvestmentType(string(investment.Type))
+	if !ok {
 		return ErrInvalidType
 	}
+	investment.Type = investmentType
 
-	// Validate status
-	if investment.Status != "" && !validStatuses[investment.Status] {
-		return ErrInvalidStatus
+	if investment.Status != "" {
+		status, ok := models.ParseInvestmentStatus(string(investment.Status))
+		if !ok {
+			return ErrInvalidStatus
+		}
+		investment.Status = status
 	}
 
 	// Validate numeric fields
@@ -71,3 +57,12 @@ func ValidateInvestment(investment *models.Investment) error {
 
 	return nil
 }
+
+// ValidateStatusTransition checks that an investment may move from status from
+// to status to
+func ValidateStatusTransition(from, to models.InvestmentStatus) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", ErrIllegalStatusTransition, from, to)
+	}
+	return nil
+}
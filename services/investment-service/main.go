@@ -5,6 +5,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/server"
 )
 
 type HealthResponse struct {
@@ -37,12 +40,21 @@ func main() {
 		port = "8080"
 	}
 
-	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/api/v1/investments", investmentsHandler)
-	http.HandleFunc("/api/v1/investments/create", createInvestmentHandler)
+	http.HandleFunc("/health", metrics.InstrumentHandlerFunc("/health", healthHandler))
+	// Readiness fails once shutdown begins so the load balancer stops routing here
+	var readiness server.ReadinessFlag
+	http.HandleFunc("/ready", metrics.InstrumentHandlerFunc("/ready", readiness.ServeHTTP))
+	http.HandleFunc("/api/v1/investments", metrics.InstrumentHandlerFunc("/api/v1/investments", investmentsHandler))
+	http.HandleFunc("/api/v1/investments/create", metrics.InstrumentHandlerFunc("/api/v1/investments/create", createInvestmentHandler))
+	http.Handle("/metrics", metrics.Handler())
 	
+	srv := server.New(":"+port, nil, server.TimeoutsFromEnv())
+
 	log.Printf("Investment Service starting on port %s...", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	if err := server.ListenAndServe(srv, server.ShutdownConfigFromEnv(&readiness)); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Server exited")
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
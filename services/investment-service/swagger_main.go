@@ -6,6 +6,14 @@ import (
 	"net/http"
 	"os"
 
+	"investment-service/internal/config"
+	"investment-service/internal/database"
+	"investment-service/internal/handlers"
+	"investment-service/internal/middleware"
+	"investment-service/internal/repositories"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/server"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -32,46 +40,56 @@ type HealthResponse struct {
 	Version string `json:"version"`
 }
 
-type Investment struct {
-	ID             string  `json:"id"`
-	UserID         string  `json:"user_id"`
-	Amount         float64 `json:"amount"`
-	Currency       string  `json:"currency"`
-	Status         string  `json:"status"`
-	InvestmentType string  `json:"investment_type"`
-	CreatedAt      string  `json:"created_at"`
-	UpdatedAt      string  `json:"updated_at"`
-}
-
-type InvestmentsResponse struct {
-	Investments []Investment `json:"investments"`
-	Total       int          `json:"total"`
-	Page        int          `json:"page"`
-	PageSize    int          `json:"page_size"`
-}
-
 func main() {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if err := database.InitDB(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	investmentHandler := handlers.NewHTTPInvestmentHandler(repositories.NewInvestmentRepository())
+
 	r := mux.NewRouter()
 
+	// Metrics
+	r.Use(metrics.HTTPMiddleware(metrics.MuxRouteLabel))
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
 	// Swagger
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
 	// API Routes
 	r.HandleFunc("/health", healthHandler).Methods("GET")
-	r.HandleFunc("/api/v1/investments", investmentsHandler).Methods("GET")
-	r.HandleFunc("/api/v1/investments/create", createInvestmentHandler).Methods("POST")
+	// Readiness fails once shutdown begins so the load balancer stops routing here
+	var readiness server.ReadinessFlag
+	r.Handle("/ready", &readiness).Methods("GET")
+
+	// Investment routes require an authenticated caller, and a tenant for the
+	// tenant scope installed on the database
+	investments := r.PathPrefix("/api/v1/investments").Subrouter()
+	investments.Use(middleware.HTTPAuth, middleware.RequireTenant)
+	investments.HandleFunc("", investmentHandler.ListInvestments).Methods("GET")
+	investments.HandleFunc("/create", investmentHandler.CreateInvestment).Methods("POST")
+	investments.HandleFunc("/export", investmentHandler.ExportInvestments).Methods("GET")
 
 	// Serve static Swagger files
 	fs := http.FileServer(http.Dir("./docs"))
 	r.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", fs))
 
+	srv := server.New(":"+port, r, server.TimeoutsFromEnv())
+
 	log.Printf("Investment Service starting on port %s...", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	if err := server.ListenAndServe(srv, server.ShutdownConfigFromEnv(&readiness)); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Server exited")
 }
 
 // @Summary Health check
@@ -90,68 +108,3 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
-
-// @Summary Get investments
-// @Description Get list of investments
-// @Tags investments
-// @Accept json
-// @Produce json
-// @Success 200 {object} InvestmentsResponse
-// @Router /api/v1/investments [get]
-func investmentsHandler(w http.ResponseWriter, r *http.Request) {
-	investments := []Investment{
-		{
-			ID:             "inv-123456",
-			UserID:         "user-123456",
-			Amount:         1000.00,
-			Currency:       "USD",
-			Status:         "ACTIVE",
-			InvestmentType: "STOCK",
-			CreatedAt:      "2025-04-06T00:00:00Z",
-			UpdatedAt:      "2025-04-06T00:00:00Z",
-		},
-		{
-			ID:             "inv-789012",
-			UserID:         "user-123456",
-			Amount:         5000.00,
-			Currency:       "USD",
-			Status:         "PENDING",
-			InvestmentType: "BOND",
-			CreatedAt:      "2025-04-05T00:00:00Z",
-			UpdatedAt:      "2025-04-05T00:00:00Z",
-		},
-	}
-
-	response := InvestmentsResponse{
-		Investments: investments,
-		Total:       2,
-		Page:        1,
-		PageSize:    10,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// @Summary Create investment
-// @Description Create a new investment
-// @Tags investments
-// @Accept json
-// @Produce json
-// @Success 200 {object} Investment
-// @Router /api/v1/investments/create [post]
-func createInvestmentHandler(w http.ResponseWriter, r *http.Request) {
-	investment := Investment{
-		ID:             "inv-345678",
-		UserID:         "user-123456",
-		Amount:         2000.00,
-		Currency:       "USD",
-		Status:         "PENDING",
-		InvestmentType: "ETF",
-		CreatedAt:      "2025-04-06T00:00:00Z",
-		UpdatedAt:      "2025-04-06T00:00:00Z",
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(investment)
-}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/georisk"
 	"github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
 	"github.com/sparkfund/services/kyc-service/internal/models"
@@ -297,20 +298,19 @@ func (s *AMLRiskAnalysisService) calculateAge(dateOfBirth time.Time) int {
 	return age
 }
 
-// Get country risk score from configuration or API
-func (s *AMLRiskAnalysisService) getCountryRiskScore(countryCode string) (float64, error) {
-	// Implementation would fetch from config or external API
-	// For now, return a default based on high-risk countries list
-	highRiskCountries := map[string]bool{
-		"AF": true, "BY": true, "MM": true, "KP": true, "RU": true,
-		"SY": true, "VE": true, "YE": true, "ZW": true,
-	}
+// countryRisk classifies countries against the shared, configurable risk lists
+var countryRisk = georisk.New(nil, georisk.ConfigFromEnv())
 
-	if highRiskCountries[strings.ToUpper(countryCode)] {
+// Get country risk score from the shared country risk lists
+func (s *AMLRiskAnalysisService) getCountryRiskScore(countryCode string) (float64, error) {
+	switch countryRisk.Country(countryCode).Level {
+	case georisk.LevelHigh:
 		return 0.9, nil // High risk score
+	case georisk.LevelElevated:
+		return 0.6, nil
+	default:
+		return 0.3, nil // Default moderate-low risk
 	}
-
-	return 0.3, nil // Default moderate-low risk
 }
 
 // Helper functions
@@ -37,9 +37,7 @@ type DocumentResponse struct {
 // DocumentListResponse represents a paginated list of documents
 type DocumentListResponse struct {
 	Documents []DocumentResponse `json:"documents"`
-	Total     int64              `json:"total"`
-	Page      int                `json:"page"`
-	PageSize  int                `json:"page_size"`
+	Pagination
 }
 
 // DocumentStatusUpdateRequest represents a request to update document status
@@ -49,6 +47,61 @@ type DocumentStatusUpdateRequest struct {
 	VerifierID uuid.UUID `json:"verifier_id" binding:"required"`
 }
 
+// BulkDocumentStatusUpdateRequest represents a request to update the status of
+// many documents at once
+type BulkDocumentStatusUpdateRequest struct {
+	Updates    []BulkDocumentStatusUpdateItem `json:"updates" binding:"required,min=1"`
+	VerifierID uuid.UUID                      `json:"verifier_id" binding:"required"`
+}
+
+// BulkDocumentStatusUpdateItem is one document status change in a bulk update
+type BulkDocumentStatusUpdateItem struct {
+	DocumentID string `json:"document_id"`
+	Status     string `json:"status"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// BulkDocumentStatusUpdateResponse reports the outcome of every item in a bulk
+// status update
+type BulkDocumentStatusUpdateResponse struct {
+	Updated int                              `json:"updated"`
+	Failed  int                              `json:"failed"`
+	Results []BulkDocumentStatusUpdateResult `json:"results"`
+}
+
+// BulkDocumentStatusUpdateResult is the outcome of one item in a bulk status update
+type BulkDocumentStatusUpdateResult struct {
+	DocumentID string `json:"document_id"`
+	Status     string `json:"status,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DocumentDownloadURLResponse represents a signed, expiring document download link
+type DocumentDownloadURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DocumentIntegrityResponse reports whether a stored document still matches
+// the content hash recorded at upload
+type DocumentIntegrityResponse struct {
+	DocumentID   uuid.UUID `json:"document_id"`
+	Intact       bool      `json:"intact"`
+	ExpectedHash string    `json:"expected_hash"`
+	ActualHash   string    `json:"actual_hash"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// DocumentOCRResponse holds the data OCR extracted from a document
+type DocumentOCRResponse struct {
+	DocumentID  uuid.UUID         `json:"document_id"`
+	Fields      map[string]string `json:"fields"`
+	Confidence  float64           `json:"confidence"`
+	NeedsReview bool              `json:"needs_review"`
+	ExtractedAt time.Time         `json:"extracted_at"`
+}
+
 // DocumentStatsResponse represents document statistics
 type DocumentStatsResponse struct {
 	TotalCount         int64                  `json:"total_count"`
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"sparkfund/services/kyc-service/internal/domain"
+)
+
+// Page sizes of document listings
+const (
+	DefaultDocumentPageSize = 20
+	MaxDocumentPageSize     = 100
+)
+
+// ParseDocumentListOptions reads the page, page_size, sort and status
+// parameters of a document listing. A missing or invalid page is 1 and a
+// missing or invalid page_size is DefaultDocumentPageSize; larger page sizes
+// are capped at MaxDocumentPageSize. sort is created_at or status, prefixed
+// with - for descending order, and defaults to newest first. status is
+// matched case-insensitively. An unknown sort field or status is an error.
+func ParseDocumentListOptions(query url.Values) (domain.DocumentListOptions, error) {
+	options := domain.DocumentListOptions{
+		SortBy:     domain.DocumentSortCreatedAt,
+		Descending: true,
+		Page:       1,
+		PageSize:   DefaultDocumentPageSize,
+	}
+
+	if page, err := strconv.Atoi(query.Get("page")); err == nil && page >= 1 {
+		options.Page = page
+	}
+	if pageSize, err := strconv.Atoi(query.Get("page_size")); err == nil && pageSize >= 1 {
+		options.PageSize = min(pageSize, MaxDocumentPageSize)
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		field := strings.TrimPrefix(sort, "-")
+		if field != domain.DocumentSortCreatedAt && field != domain.DocumentSortStatus {
+			return options, fmt.Errorf("invalid sort %q: must be created_at or status, optionally prefixed with -", sort)
+		}
+		options.SortBy = field
+		options.Descending = strings.HasPrefix(sort, "-")
+	}
+
+	if raw := query.Get("status"); raw != "" {
+		status := domain.DocumentStatus(strings.ToUpper(raw))
+		if !status.Valid() {
+			return options, fmt.Errorf("invalid status %q", raw)
+		}
+		options.Status = status
+	}
+
+	return options, nil
+}
@@ -0,0 +1,93 @@
+package dto
+
+import (
+	"net/url"
+	"testing"
+
+	"sparkfund/services/kyc-service/internal/domain"
+)
+
+func TestParseDocumentListOptions_Paging(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantPage     int
+		wantPageSize int
+	}{
+		{name: "defaults", query: "", wantPage: 1, wantPageSize: DefaultDocumentPageSize},
+		{name: "explicit", query: "page=3&page_size=50", wantPage: 3, wantPageSize: 50},
+		{name: "smallest page size", query: "page_size=1", wantPage: 1, wantPageSize: 1},
+		{name: "largest page size", query: "page_size=100", wantPage: 1, wantPageSize: MaxDocumentPageSize},
+		{name: "page size over the cap", query: "page_size=101", wantPage: 1, wantPageSize: MaxDocumentPageSize},
+		{name: "zero page size", query: "page_size=0", wantPage: 1, wantPageSize: DefaultDocumentPageSize},
+		{name: "negative page size", query: "page_size=-5", wantPage: 1, wantPageSize: DefaultDocumentPageSize},
+		{name: "non-numeric page size", query: "page_size=all", wantPage: 1, wantPageSize: DefaultDocumentPageSize},
+		{name: "zero page", query: "page=0", wantPage: 1, wantPageSize: DefaultDocumentPageSize},
+		{name: "non-numeric page", query: "page=last", wantPage: 1, wantPageSize: DefaultDocumentPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := ParseDocumentListOptions(query)
+			if err != nil {
+				t.Fatalf("ParseDocumentListOptions: %v", err)
+			}
+			if got.Page != tt.wantPage || got.PageSize != tt.wantPageSize {
+				t.Fatalf("expected page %d size %d, got page %d size %d", tt.wantPage, tt.wantPageSize, got.Page, got.PageSize)
+			}
+		})
+	}
+}
+
+func TestParseDocumentListOptions_SortAndStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    domain.DocumentListOptions
+		wantErr bool
+	}{
+		{
+			name:  "newest first by default",
+			query: "",
+			want:  domain.DocumentListOptions{SortBy: domain.DocumentSortCreatedAt, Descending: true},
+		},
+		{
+			name:  "ascending created_at",
+			query: "sort=created_at",
+			want:  domain.DocumentListOptions{SortBy: domain.DocumentSortCreatedAt},
+		},
+		{
+			name:  "descending status",
+			query: "sort=-status",
+			want:  domain.DocumentListOptions{SortBy: domain.DocumentSortStatus, Descending: true},
+		},
+		{
+			name:  "status filter in any case",
+			query: "status=in_review",
+			want:  domain.DocumentListOptions{Status: domain.DocStatusInReview, SortBy: domain.DocumentSortCreatedAt, Descending: true},
+		},
+		{name: "unknown sort field", query: "sort=file_name", wantErr: true},
+		{name: "raw SQL in sort", query: "sort=created_at%20DESC", wantErr: true},
+		{name: "unknown status", query: "status=approved", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := ParseDocumentListOptions(query)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDocumentListOptions: %v", err)
+			}
+			if got.Status != tt.want.Status || got.SortBy != tt.want.SortBy || got.Descending != tt.want.Descending {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
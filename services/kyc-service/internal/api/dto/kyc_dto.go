@@ -74,10 +74,8 @@ type KYCDetailResponse struct {
 
 // KYCListResponse represents a paginated list of KYC verifications
 type KYCListResponse struct {
-	KYCs     []KYCResponse `json:"kycs"`
-	Total    int64         `json:"total"`
-	Page     int           `json:"page"`
-	PageSize int           `json:"page_size"`
+	KYCs []KYCResponse `json:"kycs"`
+	Pagination
 }
 
 // KYCStatusUpdateRequest represents a request to update KYC status
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Pagination is the page state and navigation links shared by list responses
+type Pagination struct {
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Links    PaginationLinks `json:"links"`
+}
+
+// PaginationLinks holds URLs of neighbouring pages. Next and Prev are omitted
+// on the last and first page.
+type PaginationLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// NewPagination builds the pagination block for a page of a list requested at
+// requestURL. Links keep the request's path and other query parameters, changing
+// only page and page_size.
+func NewPagination(requestURL *url.URL, page, pageSize int, total int64) Pagination {
+	lastPage := 1
+	if pageSize > 0 && total > 0 {
+		lastPage = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	link := func(page int) string {
+		query := requestURL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(pageSize))
+		return (&url.URL{Path: requestURL.Path, RawQuery: query.Encode()}).String()
+	}
+
+	links := PaginationLinks{
+		Self:  link(page),
+		First: link(1),
+		Last:  link(lastPage),
+	}
+	if page < lastPage {
+		links.Next = link(page + 1)
+	}
+	if page > 1 {
+		// A page past the end steps back to the last page
+		links.Prev = link(min(page-1, lastPage))
+	}
+
+	return Pagination{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Links:    links,
+	}
+}
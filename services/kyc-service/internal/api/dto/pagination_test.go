@@ -0,0 +1,139 @@
+package dto
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", raw, err)
+	}
+	return u
+}
+
+func TestNewPagination_Links(t *testing.T) {
+	requestURL := mustParseURL(t, "/api/v1/documents?user_id=42&page=2&page_size=10&sort=created_at")
+
+	tests := []struct {
+		name  string
+		page  int
+		total int64
+		want  PaginationLinks
+	}{
+		{
+			name:  "first page",
+			page:  1,
+			total: 25,
+			want: PaginationLinks{
+				Self:  "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				First: "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				Last:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+				Next:  "/api/v1/documents?page=2&page_size=10&sort=created_at&user_id=42",
+			},
+		},
+		{
+			name:  "middle page",
+			page:  2,
+			total: 25,
+			want: PaginationLinks{
+				Self:  "/api/v1/documents?page=2&page_size=10&sort=created_at&user_id=42",
+				First: "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				Last:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+				Next:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+				Prev:  "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+			},
+		},
+		{
+			name:  "last page",
+			page:  3,
+			total: 25,
+			want: PaginationLinks{
+				Self:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+				First: "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				Last:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+				Prev:  "/api/v1/documents?page=2&page_size=10&sort=created_at&user_id=42",
+			},
+		},
+		{
+			name:  "empty list",
+			page:  1,
+			total: 0,
+			want: PaginationLinks{
+				Self:  "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				First: "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				Last:  "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+			},
+		},
+		{
+			name:  "past the end",
+			page:  7,
+			total: 25,
+			want: PaginationLinks{
+				Self:  "/api/v1/documents?page=7&page_size=10&sort=created_at&user_id=42",
+				First: "/api/v1/documents?page=1&page_size=10&sort=created_at&user_id=42",
+				Last:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+				Prev:  "/api/v1/documents?page=3&page_size=10&sort=created_at&user_id=42",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pagination := NewPagination(requestURL, tt.page, 10, tt.total)
+			if pagination.Links != tt.want {
+				t.Fatalf("links = %+v, want %+v", pagination.Links, tt.want)
+			}
+			if pagination.Total != tt.total || pagination.Page != tt.page || pagination.PageSize != 10 {
+				t.Fatalf("unexpected page state: %+v", pagination)
+			}
+		})
+	}
+}
+
+func TestNewPagination_UsesEffectivePageSize(t *testing.T) {
+	// An out of range page_size was replaced by the default, so links carry the default
+	pagination := NewPagination(mustParseURL(t, "/api/v1/kyc?page_size=1000"), 1, 10, 15)
+
+	if want := "/api/v1/kyc?page=2&page_size=10"; pagination.Links.Next != want {
+		t.Fatalf("next = %q, want %q", pagination.Links.Next, want)
+	}
+}
+
+func TestNewPagination_EscapesQuery(t *testing.T) {
+	pagination := NewPagination(mustParseURL(t, "/api/v1/documents?q=a%26b+c"), 1, 5, 6)
+
+	next, err := url.Parse(pagination.Links.Next)
+	if err != nil {
+		t.Fatalf("next link does not parse: %v", err)
+	}
+	if got := next.Query().Get("q"); got != "a&b c" {
+		t.Fatalf("q = %q, want the original value preserved", got)
+	}
+}
+
+func TestListResponse_OmitsBoundaryLinks(t *testing.T) {
+	response := KYCListResponse{
+		KYCs:       []KYCResponse{},
+		Pagination: NewPagination(mustParseURL(t, "/api/v1/kyc"), 1, 10, 3),
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	for _, field := range []string{`"total":3`, `"page":1`, `"page_size":10`, `"links":{`, `"self":`} {
+		if !strings.Contains(string(body), field) {
+			t.Fatalf("expected %s in %s", field, body)
+		}
+	}
+	for _, field := range []string{`"next"`, `"prev"`} {
+		if strings.Contains(string(body), field) {
+			t.Fatalf("expected no %s link on a single page, got %s", field, body)
+		}
+	}
+}
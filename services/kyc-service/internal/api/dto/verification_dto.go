@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/fields"
 	"github.com/google/uuid"
 	"sparkfund/services/kyc-service/internal/domain"
 )
@@ -30,12 +31,16 @@ type VerificationResponse struct {
 	CompletedAt     string    `json:"completed_at,omitempty"`
 }
 
+// VerificationFields are the verification fields clients may select with ?fields=
+var VerificationFields = fields.Allowlist{
+	"id", "kyc_id", "document_id", "type", "status", "method", "confidence_score",
+	"match_score", "fraud_score", "notes", "created_at", "updated_at", "completed_at",
+}
+
 // VerificationListResponse represents a paginated list of verifications
 type VerificationListResponse struct {
 	Verifications []VerificationResponse `json:"verifications"`
-	Total         int64                  `json:"total"`
-	Page          int                    `json:"page"`
-	PageSize      int                    `json:"page_size"`
+	Pagination
 }
 
 // VerificationStatusUpdateRequest represents a request to update verification status
@@ -45,6 +50,35 @@ type VerificationStatusUpdateRequest struct {
 	Notes           string  `json:"notes,omitempty"`
 }
 
+// VerificationTransitionsResponse lists the statuses a verification may move to next
+type VerificationTransitionsResponse struct {
+	VerificationID uuid.UUID `json:"verification_id"`
+	Status         string    `json:"status"`
+	Next           []string  `json:"next"`
+}
+
+// WebhookReplayResponse reports a webhook queued for redelivery
+type WebhookReplayResponse struct {
+	VerificationID uuid.UUID `json:"verification_id"`
+	Event          string    `json:"event"`
+}
+
+// DecisionImportRowResponse reports the outcome of one imported review decision
+type DecisionImportRowResponse struct {
+	Row            int    `json:"row"`
+	VerificationID string `json:"verification_id"`
+	Outcome        string `json:"outcome"`
+	Error          string `json:"error,omitempty"`
+}
+
+// DecisionImportResponse represents the result of a batch decision import
+type DecisionImportResponse struct {
+	Applied  int                         `json:"applied"`
+	Rejected int                         `json:"rejected"`
+	Failed   int                         `json:"failed"`
+	Results  []DecisionImportRowResponse `json:"results"`
+}
+
 // VerificationResultRequest represents a request to create a verification result
 type VerificationResultRequest struct {
 	Score        float64                 `json:"score" binding:"required"`
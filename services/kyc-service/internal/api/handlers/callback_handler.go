@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sparkfund/services/kyc-service/internal/api/dto"
+	"sparkfund/services/kyc-service/internal/service"
+)
+
+// maxCallbackSize bounds the size of a vendor callback body
+const maxCallbackSize = 1 << 20
+
+// CallbackConfig configures the vendor callback endpoint
+type CallbackConfig struct {
+	// Secret is the shared HMAC secret the vendor signs callbacks with
+	Secret string
+	// SignatureHeader carries the hex HMAC-SHA256 of the request body
+	SignatureHeader string
+	service.VendorCallbackConfig
+}
+
+// CallbackHandler receives asynchronous verification results from the vendor
+type CallbackHandler struct {
+	verificationService *service.VerificationService
+	config              CallbackConfig
+}
+
+// NewCallbackHandler creates a new vendor callback handler
+func NewCallbackHandler(verificationService *service.VerificationService, config CallbackConfig) *CallbackHandler {
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = "X-Signature"
+	}
+	if config.ReferenceField == "" {
+		config.ReferenceField = "reference_id"
+	}
+	if config.EventIDField == "" {
+		config.EventIDField = "event_id"
+	}
+	return &CallbackHandler{
+		verificationService: verificationService,
+		config:              config,
+	}
+}
+
+// RegisterRoutes registers the vendor callback route. It is authenticated by
+// the callback signature rather than a user token.
+func (h *CallbackHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/verifications/callback", h.HandleCallback)
+}
+
+// HandleCallback handles a verification result posted by the vendor
+// @Summary Receive a vendor verification callback
+// @Description Apply an asynchronous verification result from the identity verification vendor. The body must be signed with the shared secret; redelivered callbacks are acknowledged without being applied again.
+// @Tags verifications
+// @Accept json
+// @Produce json
+// @Param X-Signature header string true "Hex HMAC-SHA256 of the request body"
+// @Success 200 {object} dto.VerificationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /verifications/callback [post]
+func (h *CallbackHandler) HandleCallback(c *gin.Context) {
+	// The signature covers the raw body, so read it before decoding
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCallbackSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid request body",
+		})
+		return
+	}
+
+	if !service.VerifyCallbackSignature(h.config.Secret, body, c.GetHeader(h.config.SignatureHeader)) {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error: "Invalid signature",
+		})
+		return
+	}
+
+	callback, err := service.ParseVendorCallback(body, h.config.VendorCallbackConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	verification, duplicate, err := h.verificationService.HandleVendorCallback(c.Request.Context(), callback)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCallback):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error: err.Error(),
+			})
+		case errors.Is(err, service.ErrUnknownReference), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error: "Verification not found",
+			})
+		case errors.Is(err, service.ErrIllegalTransition):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to process callback",
+			})
+		}
+		return
+	}
+
+	// Acknowledge redelivered callbacks so the vendor stops retrying
+	if duplicate {
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.FromDomainVerification(verification))
+}
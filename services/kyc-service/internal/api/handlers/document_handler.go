@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/timerange"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
@@ -31,8 +37,12 @@ func (h *DocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		documents.POST("", h.UploadDocument)
 		documents.GET("/:id", h.GetDocument)
+		documents.GET("/:id/download-url", h.GetDownloadURL)
+		documents.GET("/:id/verify-integrity", h.VerifyIntegrity)
+		documents.POST("/:id/ocr", h.ExtractDocumentData)
 		documents.GET("", h.ListDocuments)
 		documents.PUT("/:id/status", h.UpdateDocumentStatus)
+		documents.PUT("/status/bulk", h.BulkUpdateDocumentStatus)
 		documents.DELETE("/:id", h.DeleteDocument)
 		documents.GET("/stats", h.GetDocumentStats)
 		documents.GET("/by-status/:status", h.GetDocumentsByStatus)
@@ -40,6 +50,12 @@ func (h *DocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// RegisterPublicRoutes registers the document routes that need no user token:
+// downloads are authenticated by the signature minted by the download-url endpoint
+func (h *DocumentHandler) RegisterPublicRoutes(router *gin.RouterGroup) {
+	router.GET("/documents/:id/download", h.DownloadDocument)
+}
+
 // UploadDocument handles document upload
 // @Summary Upload a document
 // @Description Upload a new document for KYC verification
@@ -50,9 +66,14 @@ func (h *DocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
 // @Param type formData string true "Document type"
 // @Param metadata formData string false "Document metadata (JSON)"
 // @Param user_id formData string true "User ID"
+// @Param verification_method formData string false "Also open a pending verification of the document with this method" Enums(MANUAL, AUTOMATED, THIRD_PARTY, AI, BIOMETRIC, DOCUMENT, FACIAL)
 // @Success 201 {object} dto.DocumentResponse
+// @Success 202 {object} dto.DocumentResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
 // @Router /documents [post]
 func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 	// Parse user ID
@@ -83,6 +104,15 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		return
 	}
 
+	// Validate the verification method (optional)
+	method := domain.VerificationMethod(strings.ToUpper(c.PostForm("verification_method")))
+	if method != "" && !method.Valid() {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid verification method",
+		})
+		return
+	}
+
 	// Parse metadata (optional)
 	var metadata map[string]interface{}
 	metadataStr := c.PostForm("metadata")
@@ -95,12 +125,56 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		}
 	}
 
-	// Upload document
-	document, err := h.documentService.UploadDocument(c.Request.Context(), userID, file, docType, metadata)
+	// Upload document, opening its verification in the same transaction when asked
+	var document *domain.EnhancedDocument
+	if method != "" {
+		document, _, err = h.documentService.UploadDocumentWithVerification(c.Request.Context(), userID, file, docType, metadata, method)
+	} else {
+		document, err = h.documentService.UploadDocument(c.Request.Context(), userID, file, docType, metadata)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error: "Failed to upload document",
-		})
+		var invalid *service.FileValidationError
+		var duplicate *service.DuplicateDocumentError
+		switch {
+		case errors.As(err, &invalid):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:       "Invalid file",
+				Description: invalid.Reason,
+			})
+		case errors.As(err, &duplicate):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error:       "Document already uploaded",
+				Code:        "duplicate_document",
+				Description: "This file has already been uploaded as document " + duplicate.DocumentID.String(),
+			})
+		case errors.Is(err, service.ErrInfectedFile):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "File rejected by virus scan",
+			})
+		case errors.Is(err, service.ErrScanUnavailable):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Virus scan unavailable, please retry later",
+			})
+		case errors.Is(err, service.ErrStorageNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Document storage is not available",
+			})
+		case errors.Is(err, service.ErrPipelineFull), errors.Is(err, service.ErrPipelineClosed):
+			c.Header("Retry-After", "30")
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Document processing is at capacity, please retry later",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to upload document",
+			})
+		}
+		return
+	}
+
+	// Documents still being scanned are accepted but not yet available for review
+	if strings.EqualFold(string(document.Status), string(domain.DocStatusScanning)) {
+		c.JSON(http.StatusAccepted, dto.FromDomainDocument(document))
 		return
 	}
 
@@ -141,14 +215,327 @@ func (h *DocumentHandler) GetDocument(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.FromDomainDocument(document))
 }
 
+// GetDownloadURL handles signed download URL generation
+// @Summary Get a document download URL
+// @Description Mint a signed, expiring link to a document's content. Only the document's owner and admin or compliance reviewers may mint one.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} dto.DocumentDownloadURLResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Router /documents/{id}/download-url [get]
+func (h *DocumentHandler) GetDownloadURL(c *gin.Context) {
+	// Parse document ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid document ID",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error: "Authentication required",
+		})
+		return
+	}
+
+	document, err := h.documentService.GetDocument(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error: "Document not found",
+		})
+		return
+	}
+	if fmt.Sprint(userID) != document.UserID.String() && !hasAnyRole(c, "admin", "compliance") {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{
+			Error: "Not allowed to download this document",
+		})
+		return
+	}
+
+	link, err := h.documentService.DownloadURL(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDownloadsDisabled):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Document downloads are not available",
+			})
+		case errors.Is(err, service.ErrDocumentQuarantined):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "Document failed its virus scan and cannot be downloaded",
+			})
+		case errors.Is(err, service.ErrDocumentScanning):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "Document is still being scanned, please retry later",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to create download URL",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DocumentDownloadURLResponse{
+		URL:       link.URL,
+		ExpiresAt: link.ExpiresAt,
+	})
+}
+
+// DownloadDocument handles document content download through a signed URL
+// @Summary Download a document
+// @Description Stream a document's content. The request is authenticated by the signature minted by the download-url endpoint rather than a user token.
+// @Tags documents
+// @Produce octet-stream
+// @Param id path string true "Document ID"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} binary
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 410 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /documents/{id}/download [get]
+func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
+	// Parse document ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid document ID",
+		})
+		return
+	}
+
+	document, content, err := h.documentService.OpenDownload(c.Request.Context(), id, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDownloadURLExpired):
+			c.JSON(http.StatusGone, dto.ErrorResponse{
+				Error: "Download URL has expired",
+			})
+		case errors.Is(err, service.ErrDownloadURLInvalid):
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Error: "Invalid download URL",
+			})
+		case errors.Is(err, service.ErrDownloadsDisabled):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Document downloads are not available",
+			})
+		case errors.Is(err, service.ErrDocumentQuarantined):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "Document failed its virus scan and cannot be downloaded",
+			})
+		case errors.Is(err, service.ErrDocumentScanning):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "Document is still being scanned, please retry later",
+			})
+		default:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error: "Document not found",
+			})
+		}
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Type", document.MimeType)
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, content)
+}
+
+// VerifyIntegrity handles document integrity checks
+// @Summary Verify a document's integrity
+// @Description Re-read a document's stored file and confirm it still matches the content hash recorded at upload. Only the document's owner and admin or compliance reviewers may check it.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} dto.DocumentIntegrityResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Router /documents/{id}/verify-integrity [get]
+func (h *DocumentHandler) VerifyIntegrity(c *gin.Context) {
+	// Parse document ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid document ID",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error: "Authentication required",
+		})
+		return
+	}
+
+	document, err := h.documentService.GetDocument(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error: "Document not found",
+		})
+		return
+	}
+	if fmt.Sprint(userID) != document.UserID.String() && !hasAnyRole(c, "admin", "compliance") {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{
+			Error: "Not allowed to check this document",
+		})
+		return
+	}
+
+	result, err := h.documentService.VerifyIntegrity(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDocumentQuarantined):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "Document is quarantined",
+			})
+		case errors.Is(err, service.ErrStorageNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Document storage is not available",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to verify document integrity",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DocumentIntegrityResponse{
+		DocumentID:   result.DocumentID,
+		Intact:       result.Intact,
+		ExpectedHash: result.ExpectedHash,
+		ActualHash:   result.ActualHash,
+		CheckedAt:    result.CheckedAt,
+	})
+}
+
+// ExtractDocumentData handles OCR extraction
+// @Summary Extract data from a document with OCR
+// @Description Run OCR over a document, store the extracted fields in its metadata and return them. Readings below the confidence threshold are flagged for manual review. Only the document's owner and admin or compliance reviewers may run it.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} dto.DocumentOCRResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 502 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Failure 504 {object} dto.ErrorResponse
+// @Router /documents/{id}/ocr [post]
+func (h *DocumentHandler) ExtractDocumentData(c *gin.Context) {
+	// Parse document ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid document ID",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+			Error: "Authentication required",
+		})
+		return
+	}
+
+	document, err := h.documentService.GetDocument(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error: "Document not found",
+		})
+		return
+	}
+	if fmt.Sprint(userID) != document.UserID.String() && !hasAnyRole(c, "admin", "compliance") {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{
+			Error: "Not allowed to extract data from this document",
+		})
+		return
+	}
+
+	extraction, err := h.documentService.ExtractDocumentData(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			// The client went away; there is nobody to answer
+			c.Abort()
+		case errors.Is(err, context.DeadlineExceeded):
+			c.JSON(http.StatusGatewayTimeout, dto.ErrorResponse{
+				Error: "OCR timed out",
+				Code:  "ocr_timeout",
+			})
+		case errors.Is(err, service.ErrDocumentQuarantined):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "Document is quarantined",
+			})
+		case errors.Is(err, service.ErrOCRUnsupportedType):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: err.Error(),
+				Code:  "ocr_unsupported_type",
+			})
+		case errors.Is(err, service.ErrOCRNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "OCR is not available",
+			})
+		case errors.Is(err, service.ErrOCRFailed):
+			c.JSON(http.StatusBadGateway, dto.ErrorResponse{
+				Error: "OCR failed",
+				Code:  "ocr_failed",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to extract document data",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DocumentOCRResponse{
+		DocumentID:  extraction.DocumentID,
+		Fields:      extraction.Fields,
+		Confidence:  extraction.Confidence,
+		NeedsReview: extraction.NeedsReview,
+		ExtractedAt: extraction.ExtractedAt,
+	})
+}
+
 // ListDocuments handles document listing
 // @Summary List documents
-// @Description List documents for a user with pagination
+// @Description List documents for a user with pagination, optionally filtered by status
 // @Tags documents
 // @Produce json
 // @Param user_id query string true "User ID"
+// @Param status query string false "Document status, e.g. pending"
+// @Param sort query string false "created_at or status, prefixed with - for descending (default: -created_at)"
 // @Param page query int false "Page number (default: 1)"
-// @Param page_size query int false "Page size (default: 10)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
 // @Success 200 {object} dto.DocumentListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -164,11 +551,14 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	page, pageSize := getPaginationParams(c)
+	// Parse paging, filtering and sorting parameters
+	options, ok := parseDocumentListOptions(c)
+	if !ok {
+		return
+	}
 
 	// Get documents
-	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userID, page, pageSize)
+	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userID, options)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Failed to list documents",
@@ -178,10 +568,8 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 
 	// Return response
 	c.JSON(http.StatusOK, dto.DocumentListResponse{
-		Documents: dto.FromDomainDocuments(documents),
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
+		Documents:  dto.FromDomainDocuments(documents),
+		Pagination: dto.NewPagination(c.Request.URL, options.Page, options.PageSize, total),
 	})
 }
 
@@ -245,6 +633,69 @@ func (h *DocumentHandler) UpdateDocumentStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.FromDomainDocument(document))
 }
 
+// BulkUpdateDocumentStatus handles status updates for a batch of documents
+// @Summary Update the status of many documents
+// @Description Apply up to 500 document status changes in one transaction. Items that cannot be applied are reported and do not stop the others.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body dto.BulkDocumentStatusUpdateRequest true "Bulk status update request"
+// @Success 200 {object} dto.BulkDocumentStatusUpdateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /documents/status/bulk [put]
+func (h *DocumentHandler) BulkUpdateDocumentStatus(c *gin.Context) {
+	var req dto.BulkDocumentStatusUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid request body",
+		})
+		return
+	}
+
+	updates := make([]service.DocumentStatusUpdate, len(req.Updates))
+	for i, item := range req.Updates {
+		updates[i] = service.DocumentStatusUpdate{
+			DocumentID: item.DocumentID,
+			Status:     item.Status,
+			Notes:      item.Notes,
+		}
+	}
+
+	results, err := h.documentService.BulkUpdateStatus(c.Request.Context(), updates, req.VerifierID)
+	if err != nil {
+		if errors.Is(err, service.ErrBulkUpdateTooLarge) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error: err.Error(),
+				Code:  "bulk_update_too_large",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error: "Failed to update document statuses",
+		})
+		return
+	}
+
+	response := dto.BulkDocumentStatusUpdateResponse{
+		Results: make([]dto.BulkDocumentStatusUpdateResult, len(results)),
+	}
+	for i, result := range results {
+		response.Results[i] = dto.BulkDocumentStatusUpdateResult{
+			DocumentID: result.DocumentID,
+			Status:     string(result.Status),
+			Success:    result.Updated,
+			Error:      result.Error,
+		}
+		if result.Updated {
+			response.Updated++
+		} else {
+			response.Failed++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 // DeleteDocument handles document deletion
 // @Summary Delete a document
 // @Description Delete a document by ID
@@ -306,21 +757,32 @@ func (h *DocumentHandler) GetDocumentStats(c *gin.Context) {
 // @Tags documents
 // @Produce json
 // @Param status path string true "Document status"
+// @Param sort query string false "created_at or status, prefixed with - for descending (default: -created_at)"
 // @Param page query int false "Page number (default: 1)"
-// @Param page_size query int false "Page size (default: 10)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
 // @Success 200 {object} dto.DocumentListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /documents/by-status/{status} [get]
 func (h *DocumentHandler) GetDocumentsByStatus(c *gin.Context) {
 	// Parse status
-	status := domain.DocumentStatus(c.Param("status"))
+	status := domain.DocumentStatus(strings.ToUpper(c.Param("status")))
+	if !status.Valid() {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid document status",
+		})
+		return
+	}
 
-	// Parse pagination parameters
-	page, pageSize := getPaginationParams(c)
+	// Parse paging and sorting parameters
+	options, ok := parseDocumentListOptions(c)
+	if !ok {
+		return
+	}
+	options.Status = status
 
 	// Get documents
-	documents, total, err := h.documentService.GetDocumentsByStatus(c.Request.Context(), status, page, pageSize)
+	documents, total, err := h.documentService.GetDocumentsByStatus(c.Request.Context(), options)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Failed to get documents by status",
@@ -330,62 +792,41 @@ func (h *DocumentHandler) GetDocumentsByStatus(c *gin.Context) {
 
 	// Return response
 	c.JSON(http.StatusOK, dto.DocumentListResponse{
-		Documents: dto.FromDomainDocuments(documents),
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
+		Documents:  dto.FromDomainDocuments(documents),
+		Pagination: dto.NewPagination(c.Request.URL, options.Page, options.PageSize, total),
 	})
 }
 
 // GetDocumentsByDateRange handles document retrieval by date range
 // @Summary Get documents by date range
-// @Description Get documents by date range with pagination
+// @Description Get documents created in [start_date, end_date) with pagination. A date-only end_date includes that whole day.
 // @Tags documents
 // @Produce json
-// @Param start_date query string true "Start date (YYYY-MM-DD)"
-// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param start_date query string false "Start as RFC 3339 or YYYY-MM-DD (default: 30 days before end_date)"
+// @Param end_date query string false "End as RFC 3339 or YYYY-MM-DD (default: now)"
+// @Param status query string false "Document status, e.g. pending"
+// @Param sort query string false "created_at or status, prefixed with - for descending (default: -created_at)"
 // @Param page query int false "Page number (default: 1)"
-// @Param page_size query int false "Page size (default: 10)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
 // @Success 200 {object} dto.DocumentListResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /documents/by-date-range [get]
 func (h *DocumentHandler) GetDocumentsByDateRange(c *gin.Context) {
 	// Parse date range
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
-
-	if startDateStr == "" || endDateStr == "" {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Start date and end date are required",
-		})
+	dateRange, ok := parseDateRange(c)
+	if !ok {
 		return
 	}
 
-	startDate, err := time.Parse("2006-01-02", startDateStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid start date format (YYYY-MM-DD)",
-		})
+	// Parse paging, filtering and sorting parameters
+	options, ok := parseDocumentListOptions(c)
+	if !ok {
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid end date format (YYYY-MM-DD)",
-		})
-		return
-	}
-
-	// Add one day to end date to include the end date in the range
-	endDate = endDate.Add(24 * time.Hour)
-
-	// Parse pagination parameters
-	page, pageSize := getPaginationParams(c)
-
 	// Get documents
-	documents, total, err := h.documentService.GetDocumentsByDateRange(c.Request.Context(), startDate, endDate, page, pageSize)
+	documents, total, err := h.documentService.GetDocumentsByDateRange(c.Request.Context(), dateRange.From, dateRange.To, options)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Failed to get documents by date range",
@@ -395,13 +836,25 @@ func (h *DocumentHandler) GetDocumentsByDateRange(c *gin.Context) {
 
 	// Return response
 	c.JSON(http.StatusOK, dto.DocumentListResponse{
-		Documents: dto.FromDomainDocuments(documents),
-		Total:     total,
-		Page:      page,
-		PageSize:  pageSize,
+		Documents:  dto.FromDomainDocuments(documents),
+		Pagination: dto.NewPagination(c.Request.URL, options.Page, options.PageSize, total),
 	})
 }
 
+// parseDocumentListOptions parses the paging, filtering and sorting parameters
+// of a document listing, responding with a 400 and returning false if they are
+// invalid
+func parseDocumentListOptions(c *gin.Context) (domain.DocumentListOptions, bool) {
+	options, err := dto.ParseDocumentListOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: err.Error(),
+		})
+		return options, false
+	}
+	return options, true
+}
+
 // Helper function to get pagination parameters
 func getPaginationParams(c *gin.Context) (int, int) {
 	pageStr := c.DefaultQuery("page", "1")
@@ -419,3 +872,26 @@ func getPaginationParams(c *gin.Context) (int, int) {
 
 	return page, pageSize
 }
+
+// dateRangeParser parses the start_date and end_date parameters of every
+// date-range endpoint
+var dateRangeParser = timerange.Parser{
+	FromParam:   "start_date",
+	ToParam:     "end_date",
+	DefaultSpan: 30 * 24 * time.Hour,
+	MaxSpan:     366 * 24 * time.Hour,
+}
+
+// parseDateRange parses the date range of the request, responding with a 400
+// and returning false if it is invalid
+func parseDateRange(c *gin.Context) (timerange.Range, bool) {
+	dateRange, err := dateRangeParser.Parse(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: err.Error(),
+			Code:  "invalid_date_range",
+		})
+		return timerange.Range{}, false
+	}
+	return dateRange, true
+}
@@ -319,10 +319,8 @@ func (h *KYCHandler) ListKYCs(c *gin.Context) {
 
 	// Return response
 	c.JSON(http.StatusOK, dto.KYCListResponse{
-		KYCs:     dto.FromDomainKYCs(kycs),
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
+		KYCs:       dto.FromDomainKYCs(kycs),
+		Pagination: dto.NewPagination(c.Request.URL, page, pageSize, total),
 	})
 }
 
@@ -356,10 +354,8 @@ func (h *KYCHandler) GetKYCsByStatus(c *gin.Context) {
 
 	// Return response
 	c.JSON(http.StatusOK, dto.KYCListResponse{
-		KYCs:     dto.FromDomainKYCs(kycs),
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
+		KYCs:       dto.FromDomainKYCs(kycs),
+		Pagination: dto.NewPagination(c.Request.URL, page, pageSize, total),
 	})
 }
 
@@ -393,9 +389,7 @@ func (h *KYCHandler) GetKYCsByRiskLevel(c *gin.Context) {
 
 	// Return response
 	c.JSON(http.StatusOK, dto.KYCListResponse{
-		KYCs:     dto.FromDomainKYCs(kycs),
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
+		KYCs:       dto.FromDomainKYCs(kycs),
+		Pagination: dto.NewPagination(c.Request.URL, page, pageSize, total),
 	})
 }
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/fields"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"sparkfund/services/kyc-service/internal/api/dto"
 	"sparkfund/services/kyc-service/internal/domain"
@@ -32,7 +38,11 @@ func (h *VerificationHandler) RegisterRoutes(router *gin.RouterGroup) {
 		verifications.GET("/:id", h.GetVerification)
 		verifications.GET("", h.ListVerifications)
 		verifications.PUT("/:id/status", h.UpdateVerificationStatus)
+		verifications.GET("/:id/transitions", h.GetVerificationTransitions)
 		verifications.POST("/:id/result", h.CreateVerificationResult)
+		verifications.POST("/:id/reprocess", requireAdmin(), h.ReprocessVerification)
+		verifications.POST("/:id/replay-webhook", requireAdmin(), h.ReplayWebhook)
+		verifications.POST("/decisions/import", requireAdmin(), h.ImportDecisions)
 		verifications.GET("/document/:document_id", h.GetVerificationsByDocument)
 		verifications.GET("/kyc/:kyc_id", h.GetVerificationsByKYC)
 	}
@@ -47,6 +57,8 @@ func (h *VerificationHandler) RegisterRoutes(router *gin.RouterGroup) {
 // @Param request body dto.VerificationRequest true "Verification request"
 // @Success 201 {object} dto.VerificationResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /verifications [post]
 func (h *VerificationHandler) CreateVerification(c *gin.Context) {
@@ -66,9 +78,20 @@ func (h *VerificationHandler) CreateVerification(c *gin.Context) {
 		domain.VerificationMethod(req.Method),
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error: "Failed to create verification",
-		})
+		switch {
+		case errors.Is(err, service.ErrDocumentQuarantined):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "Document failed its virus scan and cannot be verified",
+			})
+		case errors.Is(err, service.ErrDocumentScanning):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "Document is still being scanned, please retry later",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to create verification",
+			})
+		}
 		return
 	}
 
@@ -116,13 +139,24 @@ func (h *VerificationHandler) GetVerification(c *gin.Context) {
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Page size (default: 10)"
+// @Param fields query string false "Comma separated verification fields to return, e.g. id,status"
 // @Success 200 {object} dto.VerificationListResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /verifications [get]
 func (h *VerificationHandler) ListVerifications(c *gin.Context) {
 	// Parse pagination parameters
 	page, pageSize := getPaginationParams(c)
 
+	// Parse the requested fields
+	selected, err := dto.VerificationFields.Parse(c.Query(fields.Param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
 	// Get verifications
 	verifications, total, err := h.verificationService.ListVerifications(c.Request.Context(), page, pageSize)
 	if err != nil {
@@ -132,12 +166,29 @@ func (h *VerificationHandler) ListVerifications(c *gin.Context) {
 		return
 	}
 
-	// Return response
-	c.JSON(http.StatusOK, dto.VerificationListResponse{
+	response := dto.VerificationListResponse{
 		Verifications: dto.FromDomainVerifications(verifications),
-		Total:         total,
-		Page:          page,
-		PageSize:      pageSize,
+		Pagination:    dto.NewPagination(c.Request.URL, page, pageSize, total),
+	}
+	if selected == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Return only the requested fields of each verification
+	items, err := fields.Select(response.Verifications, selected)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error: "Failed to list verifications",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"verifications": items,
+		"total":         response.Total,
+		"page":          response.Page,
+		"page_size":     response.PageSize,
+		"links":         response.Links,
 	})
 }
 
@@ -152,6 +203,7 @@ func (h *VerificationHandler) ListVerifications(c *gin.Context) {
 // @Success 200 {object} dto.VerificationResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /verifications/{id}/status [put]
 func (h *VerificationHandler) UpdateVerificationStatus(c *gin.Context) {
@@ -173,18 +225,31 @@ func (h *VerificationHandler) UpdateVerificationStatus(c *gin.Context) {
 		return
 	}
 
-	// Update verification status
+	// Update verification status; the reviewer is recorded in the history
 	err = h.verificationService.UpdateVerificationStatus(
 		c.Request.Context(),
 		id,
+		actorID(c),
 		domain.VerificationStatus(req.Status),
 		req.ConfidenceScore,
 		req.Notes,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error: "Failed to update verification status",
-		})
+		switch {
+		case errors.Is(err, service.ErrIllegalTransition):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: err.Error(),
+				Code:  "illegal_transition",
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error: "Verification not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to update verification status",
+			})
+		}
 		return
 	}
 
@@ -201,6 +266,45 @@ func (h *VerificationHandler) UpdateVerificationStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.FromDomainVerification(verification))
 }
 
+// GetVerificationTransitions handles listing a verification's next statuses
+// @Summary List a verification's allowed transitions
+// @Description Get a verification's status and the statuses it may be moved to next
+// @Tags verifications
+// @Produce json
+// @Param id path string true "Verification ID"
+// @Success 200 {object} dto.VerificationTransitionsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /verifications/{id}/transitions [get]
+func (h *VerificationHandler) GetVerificationTransitions(c *gin.Context) {
+	// Parse verification ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid verification ID",
+		})
+		return
+	}
+
+	status, next, err := h.verificationService.VerificationTransitions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error: "Verification not found",
+		})
+		return
+	}
+
+	response := dto.VerificationTransitionsResponse{
+		VerificationID: id,
+		Status:         string(status),
+		Next:           make([]string, len(next)),
+	}
+	for i, s := range next {
+		response.Next[i] = string(s)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 // CreateVerificationResult handles verification result creation
 // @Summary Create a verification result
 // @Description Create a result for a verification
@@ -324,3 +428,250 @@ func (h *VerificationHandler) GetVerificationsByKYC(c *gin.Context) {
 	// Return response
 	c.JSON(http.StatusOK, dto.FromDomainVerifications(verifications))
 }
+
+// ReprocessVerification handles re-running a stuck verification
+// @Summary Reprocess a verification
+// @Description Re-run the verification method pipeline for a stuck verification (admin only)
+// @Tags verifications
+// @Produce json
+// @Param id path string true "Verification ID"
+// @Param force query bool false "Reprocess even if the verification is in a terminal state"
+// @Success 200 {object} dto.VerificationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /verifications/{id}/reprocess [post]
+func (h *VerificationHandler) ReprocessVerification(c *gin.Context) {
+	// Parse verification ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid verification ID",
+		})
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+
+	// The acting admin is recorded in the audit trail
+	verification, err := h.verificationService.ReprocessVerification(c.Request.Context(), id, actorID(c), force)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrVerificationTerminal):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "Verification is in a terminal state; use force=true to reprocess",
+			})
+		case errors.Is(err, service.ErrReprocessInProgress):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "Verification is already being reprocessed",
+			})
+		case errors.Is(err, service.ErrNoProcessor):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error: "Verification method cannot be reprocessed",
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error: "Verification not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to reprocess verification",
+			})
+		}
+		return
+	}
+
+	// Return response
+	c.JSON(http.StatusOK, dto.FromDomainVerification(verification))
+}
+
+// ReplayWebhook handles re-sending a verification's outcome webhook
+// @Summary Replay a verification webhook
+// @Description Send the webhook for a verification's current status to every subscriber again (admin only). Delivery happens in the background.
+// @Tags verifications
+// @Produce json
+// @Param id path string true "Verification ID"
+// @Success 202 {object} dto.WebhookReplayResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Router /verifications/{id}/replay-webhook [post]
+func (h *VerificationHandler) ReplayWebhook(c *gin.Context) {
+	// Parse verification ID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid verification ID",
+		})
+		return
+	}
+
+	event, err := h.verificationService.ReplayWebhook(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoWebhookEvent):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "Verification has not reached an outcome that sends a webhook",
+				Code:  "no_webhook_event",
+			})
+		case errors.Is(err, service.ErrWebhooksNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error: "Webhooks are not configured",
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error: "Verification not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "Failed to replay webhook",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.WebhookReplayResponse{
+		VerificationID: id,
+		Event:          event,
+	})
+}
+
+// maxDecisionImportSize bounds the size of an uploaded decision file
+const maxDecisionImportSize = 10 << 20
+
+// ImportDecisions handles a batch of offline review decisions
+// @Summary Import review decisions
+// @Description Apply a batch of manual review decisions from a CSV or JSON file (admin only). Every row is reported; invalid rows and illegal transitions are rejected without affecting the others.
+// @Tags verifications
+// @Accept json
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "CSV or JSON decision file"
+// @Success 200 {object} dto.DecisionImportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /verifications/decisions/import [post]
+func (h *VerificationHandler) ImportDecisions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDecisionImportSize)
+
+	// The file may be uploaded as a form field or sent as the request body
+	var body io.Reader = c.Request.Body
+	isCSV := c.ContentType() == "text/csv"
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error: "Missing decision file",
+			})
+			return
+		}
+		defer file.Close()
+		body = file
+		isCSV = strings.HasSuffix(strings.ToLower(header.Filename), ".csv")
+	}
+
+	var decisions []service.VerificationDecision
+	var err error
+	if isCSV {
+		decisions, err = service.ParseDecisionsCSV(body)
+	} else {
+		decisions, err = service.ParseDecisionsJSON(body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: fmt.Sprintf("Invalid decision file: %v", err),
+		})
+		return
+	}
+
+	// The importing admin is recorded in the audit trail
+	results, err := h.verificationService.ImportDecisions(c.Request.Context(), decisions, actorID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error: "Failed to import decisions",
+		})
+		return
+	}
+
+	response := dto.DecisionImportResponse{
+		Results: make([]dto.DecisionImportRowResponse, len(results)),
+	}
+	for i, result := range results {
+		switch result.Outcome {
+		case service.DecisionApplied:
+			response.Applied++
+		case service.DecisionRejected:
+			response.Rejected++
+		case service.DecisionFailed:
+			response.Failed++
+		}
+		response.Results[i] = dto.DecisionImportRowResponse{
+			Row:            result.Row,
+			VerificationID: result.VerificationID,
+			Outcome:        string(result.Outcome),
+			Error:          result.Error,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// actorID returns the caller's user ID set by the auth middleware, or uuid.Nil
+// for requests without one, for recording in audit trails
+func actorID(c *gin.Context) uuid.UUID {
+	if userID, exists := c.Get("user_id"); exists {
+		if parsed, err := uuid.Parse(fmt.Sprint(userID)); err == nil {
+			return parsed
+		}
+	}
+	return uuid.Nil
+}
+
+// requireAdmin rejects callers without the admin role set by the auth middleware
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasAnyRole(c, "admin") {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+			Error: "Admin role required",
+		})
+	}
+}
+
+// hasAnyRole reports whether the auth middleware gave the caller one of roles,
+// in either the "roles" or the "role" context key
+func hasAnyRole(c *gin.Context, roles ...string) bool {
+	var granted []string
+	if value, exists := c.Get("roles"); exists {
+		switch v := value.(type) {
+		case []string:
+			granted = v
+		case []interface{}:
+			for _, role := range v {
+				granted = append(granted, fmt.Sprint(role))
+			}
+		}
+	}
+	if role, exists := c.Get("role"); exists {
+		granted = append(granted, fmt.Sprint(role))
+	}
+
+	for _, role := range granted {
+		for _, want := range roles {
+			if role == want {
+				return true
+			}
+		}
+	}
+	return false
+}
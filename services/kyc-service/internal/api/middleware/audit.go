@@ -9,9 +9,9 @@ import (
 	"strings"
 	"time"
 
+	sharedMiddleware "github.com/adil-faiyaz98/sparkfund/pkg/middleware"
 	"github.com/adil-faiyaz98/sparkfund/services/kyc-service/internal/audit"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // AuditMiddleware creates a middleware for audit logging
@@ -26,12 +26,15 @@ func AuditMiddleware(auditLogger audit.Logger) gin.HandlerFunc {
 		// Start time
 		startTime := time.Now()
 
-		// Get request ID
-		requestID := c.GetHeader("X-Request-ID")
+		// Get request ID, assigning one if the request ID middleware has not run
+		requestID := sharedMiddleware.GetRequestID(c)
 		if requestID == "" {
-			requestID = uuid.New().String()
-			c.Request.Header.Set("X-Request-ID", requestID)
-			c.Writer.Header().Set("X-Request-ID", requestID)
+			requestID = c.GetHeader(sharedMiddleware.RequestIDHeader)
+		}
+		if !sharedMiddleware.ValidRequestID(requestID) {
+			requestID = sharedMiddleware.NewRequestID(sharedMiddleware.RequestIDFormatUUID)
+			c.Request.Header.Set(sharedMiddleware.RequestIDHeader, requestID)
+			c.Writer.Header().Set(sharedMiddleware.RequestIDHeader, requestID)
 		}
 
 		// Get user ID
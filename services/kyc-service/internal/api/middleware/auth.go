@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -16,10 +17,17 @@ type AuthConfig struct {
 	TokenHeader   string
 	TokenPrefix   string
 	ExcludedPaths []string
+	// Algorithms is the allowlist of HMAC signing algorithms; HS256 if empty
+	Algorithms []string
 }
 
 // Auth returns a gin middleware for authentication
 func Auth(config AuthConfig) gin.HandlerFunc {
+	algorithms := config.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{jwt.SigningMethodHS256.Alg()}
+	}
+
 	return func(c *gin.Context) {
 		// Check if path is excluded
 		path := c.Request.URL.Path
@@ -54,8 +62,12 @@ func Auth(config AuthConfig) gin.HandlerFunc {
 
 		// Parse token
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			// Never verify with the secret unless the token is HMAC-signed
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
 			return []byte(config.JWTSecret), nil
-		})
+		}, jwt.WithValidMethods(algorithms))
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	sharedMiddleware "github.com/adil-faiyaz98/sparkfund/pkg/middleware"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // Logger returns a gin middleware for logging requests
@@ -14,11 +14,6 @@ func Logger() gin.HandlerFunc {
 		// Start timer
 		start := time.Now()
 
-		// Generate request ID
-		requestID := uuid.New().String()
-		c.Set("request_id", requestID)
-		c.Header("X-Request-ID", requestID)
-
 		// Process request
 		c.Next()
 
@@ -41,13 +36,14 @@ func Logger() gin.HandlerFunc {
 		errors := c.Errors.String()
 
 		// Log request
-		fmt.Printf("[GIN] %v | %3d | %13v | %15s | %-7s %s | %s\n",
+		fmt.Printf("[GIN] %v | %3d | %13v | %15s | %-7s %s | %s | %s\n",
 			time.Now().Format("2006/01/02 - 15:04:05"),
 			statusCode,
 			latency,
 			clientIP,
 			method,
 			path,
+			sharedMiddleware.GetRequestID(c),
 			errors,
 		)
 	}
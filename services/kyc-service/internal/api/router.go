@@ -1,6 +1,7 @@
 package api
 
 import (
+	sharedMiddleware "github.com/adil-faiyaz98/sparkfund/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
@@ -20,6 +21,12 @@ type RouterConfig struct {
 	Version   string
 	CommitSHA string
 	Debug     bool
+	Callback  handlers.CallbackConfig
+	RequestID sharedMiddleware.RequestIDConfig
+	// Auth authenticates every API route except health, metrics, vendor
+	// callbacks and signed downloads, setting the user_id and role the handlers
+	// authorize with. It is required.
+	Auth gin.HandlerFunc
 }
 
 // NewRouter creates a new router
@@ -42,6 +49,7 @@ func NewRouter(services *service.Services, config RouterConfig) *Router {
 
 	// Setup middleware
 	r.engine.Use(gin.Recovery())
+	r.engine.Use(sharedMiddleware.RequestID(config.RequestID))
 	r.engine.Use(middleware.Logger())
 	r.engine.Use(middleware.CORS())
 
@@ -50,6 +58,7 @@ func NewRouter(services *service.Services, config RouterConfig) *Router {
 	documentHandler := handlers.NewDocumentHandler(services.Document)
 	kycHandler := handlers.NewKYCHandler(services.KYC)
 	verificationHandler := handlers.NewVerificationHandler(services.Verification)
+	callbackHandler := handlers.NewCallbackHandler(services.Verification, config.Callback)
 
 	// Register routes
 	api := r.engine.Group("/api/v1")
@@ -60,14 +69,21 @@ func NewRouter(services *service.Services, config RouterConfig) *Router {
 		// Metrics
 		api.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+		// Vendor callbacks and document downloads carry their own signatures
+		callbackHandler.RegisterRoutes(api)
+		documentHandler.RegisterPublicRoutes(api)
+
+		// Every other route requires an authenticated caller
+		authenticated := api.Group("", config.Auth)
+
 		// Document routes
-		documentHandler.RegisterRoutes(api)
+		documentHandler.RegisterRoutes(authenticated)
 
 		// KYC routes
-		kycHandler.RegisterRoutes(api)
+		kycHandler.RegisterRoutes(authenticated)
 
 		// Verification routes
-		verificationHandler.RegisterRoutes(api)
+		verificationHandler.RegisterRoutes(authenticated)
 	}
 
 	return r
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"sparkfund/services/kyc-service/internal/controller"
+	"sparkfund/services/kyc-service/internal/service"
+)
+
+const testJWTSecret = "test-secret"
+
+// newTestRouter builds the API router behind the auth middleware the app uses
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	authService := service.NewAuthService(nil, nil, logger, testJWTSecret, time.Hour, false)
+
+	services := &service.Services{
+		Document:     service.NewDocumentService(nil, nil),
+		KYC:          service.NewKYCService(nil, nil, nil, nil),
+		Verification: service.NewVerificationService(nil, nil, nil),
+	}
+	return NewRouter(services, RouterConfig{Auth: controller.AuthMiddleware(authService)})
+}
+
+// signTestToken issues an access token for a caller with role
+func signTestToken(t *testing.T, role string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":    uuid.New().String(),
+		"email":      role + "@example.com",
+		"role":       role,
+		"mfa_passed": false,
+		"exp":        time.Now().Add(time.Hour).Unix(),
+		"jti":        uuid.New().String(),
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestRouter_AdminTokenReachesReprocess(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		// The handler rejects the malformed ID, so the request got past auth
+		{"admin", signTestToken(t, "admin"), http.StatusBadRequest},
+		{"non-admin", signTestToken(t, "user"), http.StatusForbidden},
+		{"anonymous", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/verifications/not-a-uuid/reprocess", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.Engine().ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("got %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_PublicRoutesNeedNoToken(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, path := range []string{"/api/v1/health", "/api/v1/documents/not-a-uuid/download"} {
+		w := httptest.NewRecorder()
+		router.Engine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code == http.StatusUnauthorized {
+			t.Fatalf("%s: expected no token to be required, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestRouter_UploadRejectsUnknownVerificationMethod(t *testing.T) {
+	router := newTestRouter(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("user_id", uuid.New().String())
+	form.WriteField("type", "PASSPORT")
+	form.WriteField("verification_method", "telepathy")
+	part, _ := form.CreateFormFile("file", "passport.pdf")
+	part.Write([]byte("%PDF-1.7 passport"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/documents", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+signTestToken(t, "user"))
+	w := httptest.NewRecorder()
+	router.Engine().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
@@ -11,12 +11,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
 	"sparkfund/services/kyc-service/internal/api"
+	"sparkfund/services/kyc-service/internal/api/handlers"
 	"sparkfund/services/kyc-service/internal/config"
+	"sparkfund/services/kyc-service/internal/controller"
+	"sparkfund/services/kyc-service/internal/model"
 	"sparkfund/services/kyc-service/internal/repository"
 	"sparkfund/services/kyc-service/internal/service"
+
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
+	sharedMiddleware "github.com/adil-faiyaz98/sparkfund/pkg/middleware"
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 )
 
 // App represents the application
@@ -43,6 +52,26 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Migrate the schema; replicas starting together wait for the first to finish
+	if cfg.Database.MigrateOnStartup {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.MigrationLockTimeout)
+		err := sharedDB.WithMigrationLock(ctx, db, "kyc-service", repository.AutoMigrate)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	// Create the indexes the listings rely on; without them queries are slow, not wrong
+	if cfg.Database.EnsureIndexes {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.MigrationLockTimeout)
+		_, err := sharedDB.EnsureIndexes(ctx, db, repository.Indexes)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to create database indexes: %v", err)
+		}
+	}
+
 	// Create repositories
 	repos := repository.NewRepositories(db)
 
@@ -55,13 +84,68 @@ func New(cfg *config.Config) (*App, error) {
 		EventPublisher: eventPublisher,
 		Config:         cfg,
 	})
+	services.Document.SetFileLimits(cfg.Validation.Document.MaxSize, cfg.Validation.Document.AllowedTypes)
+	storage, err := newDocumentStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	services.Document.SetStorage(storage)
+	if cfg.Storage.Scan.Enabled {
+		scanner, err := newVirusScanner(cfg)
+		if err != nil {
+			return nil, err
+		}
+		services.Document.SetScanner(scanner)
+	}
+	services.Document.SetDownloadURLs(service.NewDownloadURLSigner(service.DownloadURLConfig{
+		Secret:  cfg.Storage.Download.Secret,
+		TTL:     cfg.Storage.Download.TTL,
+		BaseURL: cfg.Storage.Download.BaseURL,
+	}))
+
+	if cfg.OCR.Provider != "" {
+		services.Document.SetOCR(service.NewDocumentOCR(repos.Document, storage, newOCRProvider(cfg)), newOCRConfig(cfg))
+		services.Verification.RegisterProcessor(model.VerificationMethodDocument, service.DocumentOCRProcessor(services.Document))
+	}
+
+	// Pipelines used to reprocess stuck verifications; methods without one are refused
+	services.Verification.RegisterProcessor(model.VerificationMethodManual, service.ManualReviewProcessor())
+
+	if len(cfg.Webhooks.Subscribers) > 0 {
+		services.Verification.SetWebhooks(newWebhookDispatcher(cfg, repos.Webhook))
+	}
+
+	// Authentication; logout revokes access tokens in Redis, and the denylist is
+	// checked wherever AuthMiddleware validates a token
+	authService := service.NewAuthService(
+		repository.NewUserRepository(db),
+		repository.NewSessionRepository(db),
+		logrus.StandardLogger(),
+		cfg.JWT.Secret,
+		cfg.JWT.Expiry,
+		false,
+	)
+	redisClient := newRedisClient(cfg)
+	authService.SetTokenDenylist(service.NewRedisTokenDenylist(redisClient, cfg.Cache.Redis.Prefix+"denylist:"))
+	authService.SetRefreshTokenStore(service.NewRedisRefreshTokenStore(redisClient, cfg.Cache.Redis.Prefix+"refresh:", cfg.JWT.Refresh))
 
 	// Create router
 	router := api.NewRouter(services, api.RouterConfig{
 		Version:   cfg.App.Version,
 		CommitSHA: os.Getenv("GIT_COMMIT"),
 		Debug:     cfg.App.Environment == "development",
+		Callback: handlers.CallbackConfig{
+			Secret:          cfg.Vendor.Callback.Secret,
+			SignatureHeader: cfg.Vendor.Callback.SignatureHeader,
+			VendorCallbackConfig: service.VendorCallbackConfig{
+				ReferenceField: cfg.Vendor.Callback.ReferenceField,
+				EventIDField:   cfg.Vendor.Callback.EventIDField,
+			},
+		},
+		RequestID: sharedMiddleware.RequestIDConfig{Format: cfg.Server.RequestIDFormat},
+		Auth:      controller.AuthMiddleware(authService),
 	})
+	controller.NewAuthController(authService, logrus.StandardLogger()).RegisterRoutes(router.Engine())
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -111,3 +195,99 @@ func (a *App) Run() error {
 	log.Println("Server exited properly")
 	return nil
 }
+
+// newDocumentStorage creates the document store selected by storage.type
+func newDocumentStorage(cfg *config.Config) (service.ListableStorage, error) {
+	switch cfg.Storage.Type {
+	case "local", "":
+		return service.NewLocalStorage(cfg.Storage.Local.Path)
+	case "s3":
+		s3 := cfg.Storage.S3
+		return service.NewS3Storage(service.S3Config{
+			Bucket:          s3.Bucket,
+			Region:          s3.Region,
+			Endpoint:        s3.Endpoint,
+			ForcePathStyle:  s3.ForcePathStyle,
+			AccessKeyID:     s3.AccessKeyID,
+			SecretAccessKey: s3.SecretAccessKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Type)
+	}
+}
+
+// newRedisClient creates the Redis client configured under cache.redis
+func newRedisClient(cfg *config.Config) *redis.Client {
+	opts := cfg.Cache.Redis.Pool
+	opts.Addr = fmt.Sprintf("%s:%d", cfg.Cache.Redis.Host, cfg.Cache.Redis.Port)
+	opts.Password = cfg.Cache.Redis.Password
+	opts.DB = cfg.Cache.Redis.DB
+	return redisclient.New(opts)
+}
+
+// newVirusScanner creates the scanner configured under storage.scan
+func newVirusScanner(cfg *config.Config) (*service.VirusScanner, error) {
+	scan := cfg.Storage.Scan
+
+	var provider service.ScanProvider
+	switch scan.Provider {
+	case "clamav", "":
+		provider = service.NewClamAVProvider(scan.Address)
+	default:
+		return nil, fmt.Errorf("unknown virus scan provider %q", scan.Provider)
+	}
+
+	scanConfig := service.DefaultScanConfig()
+	if scan.Timeout > 0 {
+		scanConfig.Timeout = scan.Timeout
+	}
+	scanConfig.FailOpen = scan.FailOpen
+	scanConfig.Async = scan.Async
+	if scan.QuarantinePath != "" {
+		scanConfig.QuarantineDir = scan.QuarantinePath
+	}
+	return service.NewVirusScanner(provider, scanConfig), nil
+}
+
+// newOCRProvider creates the OCR backend selected by ocr.provider
+func newOCRProvider(cfg *config.Config) service.OCRProvider {
+	if cfg.OCR.Provider == "remote" {
+		return service.NewRemoteOCRProvider(cfg.OCR.URL, cfg.OCR.APIKey, nil)
+	}
+	return service.NewTesseractProvider(cfg.OCR.TesseractPath, cfg.OCR.Languages)
+}
+
+// newOCRConfig creates the OCR extraction policy configured under ocr
+func newOCRConfig(cfg *config.Config) service.OCRConfig {
+	ocrConfig := service.DefaultOCRConfig()
+	if cfg.OCR.Timeout > 0 {
+		ocrConfig.Timeout = cfg.OCR.Timeout
+	}
+	if cfg.OCR.ReviewThreshold > 0 {
+		ocrConfig.ReviewThreshold = cfg.OCR.ReviewThreshold
+	}
+	return ocrConfig
+}
+
+// newWebhookDispatcher creates the dispatcher configured under webhooks
+func newWebhookDispatcher(cfg *config.Config, deadLetters service.WebhookDeadLetterStore) *service.WebhookDispatcher {
+	webhooks := cfg.Webhooks
+
+	webhookConfig := service.DefaultWebhookConfig()
+	webhookConfig.Subscribers = webhooks.Subscribers
+	webhookConfig.Secret = webhooks.Secret
+	webhookConfig.Egress = cfg.Security.Egress
+	if webhooks.Timeout > 0 {
+		webhookConfig.Timeout = webhooks.Timeout
+	}
+	if webhooks.MaxAttempts > 0 {
+		webhookConfig.Retry.MaxAttempts = webhooks.MaxAttempts
+	}
+	if webhooks.BaseDelay > 0 {
+		webhookConfig.Retry.BaseDelay = webhooks.BaseDelay
+	}
+	if webhooks.MaxDelay > 0 {
+		webhookConfig.Retry.MaxDelay = webhooks.MaxDelay
+	}
+	return service.NewWebhookDispatcher(webhookConfig, deadLetters)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 )
 
 // ErrCacheMiss is returned when a key is not found in the cache
@@ -53,6 +55,7 @@ type CacheConfig struct {
 	RedisPort string
 	RedisPass string
 	RedisDB   int
+	RedisPool redisclient.Options
 	Prefix    string
 }
 
@@ -65,6 +68,7 @@ func DefaultCacheConfig() CacheConfig {
 		RedisPort: "6379",
 		RedisPass: "",
 		RedisDB:   0,
+		RedisPool: redisclient.DefaultOptions(),
 		Prefix:    "kyc:",
 	}
 }
@@ -85,6 +89,7 @@ func NewCache(cfg CacheConfig) (Cache, error) {
 			Password: cfg.RedisPass,
 			DB:       cfg.RedisDB,
 			Prefix:   cfg.Prefix,
+			Pool:     cfg.RedisPool,
 		})
 	default:
 		return nil, errors.New("unsupported cache type")
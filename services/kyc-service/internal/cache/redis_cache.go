@@ -5,7 +5,8 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/go-redis/redis/v8"
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
+	"github.com/redis/go-redis/v9"
 )
 
 // RedisCache implements the Cache interface using Redis
@@ -21,6 +22,9 @@ type RedisConfig struct {
 	Password string
 	DB       int
 	Prefix   string
+	// Pool holds connection pool and timeout settings; its address fields are
+	// ignored in favour of Host, Port, Password and DB
+	Pool redisclient.Options
 }
 
 // DefaultRedisConfig returns default Redis configuration
@@ -31,16 +35,17 @@ func DefaultRedisConfig() RedisConfig {
 		Password: "",
 		DB:       0,
 		Prefix:   "kyc:",
+		Pool:     redisclient.DefaultOptions(),
 	}
 }
 
 // NewRedisCache creates a new Redis cache
 func NewRedisCache(cfg RedisConfig) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.Host + ":" + cfg.Port,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	opts := cfg.Pool
+	opts.Addr = cfg.Host + ":" + cfg.Port
+	opts.Password = cfg.Password
+	opts.DB = cfg.DB
+	client := redisclient.New(opts)
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -56,6 +61,12 @@ func NewRedisCache(cfg RedisConfig) (*RedisCache, error) {
 	}, nil
 }
 
+// Client returns the underlying Redis client, for example to export its pool
+// statistics with redisclient.NewPoolStatsCollector
+func (c *RedisCache) Client() *redis.Client {
+	return c.client
+}
+
 // Get retrieves a value from the cache
 func (c *RedisCache) Get(ctx context.Context, key string, value interface{}) error {
 	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
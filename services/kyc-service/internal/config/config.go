@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 	"github.com/spf13/viper"
 )
 
@@ -31,6 +33,9 @@ type Config struct {
 	Notifications  NotificationConfig   `mapstructure:"notifications"`
 	Monitoring     MonitoringConfig     `mapstructure:"monitoring"`
 	Events         EventsConfig         `mapstructure:"events"`
+	Vendor         VendorConfig         `mapstructure:"vendor"`
+	Webhooks       WebhookConfig        `mapstructure:"webhooks"`
+	OCR            OCRConfig            `mapstructure:"ocr"`
 }
 
 // AppConfig holds application configuration
@@ -50,6 +55,8 @@ type ServerConfig struct {
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 	Timeout         time.Duration `mapstructure:"timeout"`
 	TrustedProxies  []string      `mapstructure:"trusted_proxies"`
+	// RequestIDFormat is the format of generated request IDs: "uuid" or "ulid"
+	RequestIDFormat string `mapstructure:"request_id_format"`
 }
 
 // DatabaseConfig holds database configuration
@@ -64,6 +71,14 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	// MigrateOnStartup migrates the schema before serving; replicas take turns
+	MigrateOnStartup bool `mapstructure:"migrate_on_startup"`
+	// MigrationLockTimeout bounds startup migrations, including the wait for
+	// another replica's migrations to finish
+	MigrationLockTimeout time.Duration `mapstructure:"migration_lock_timeout"`
+	// EnsureIndexes creates missing query indexes after migrating; a failure is
+	// logged rather than stopping startup
+	EnsureIndexes bool `mapstructure:"ensure_indexes"`
 }
 
 // JWTConfig holds JWT configuration
@@ -129,6 +144,8 @@ type SecurityConfig struct {
 		RoleBased     bool     `mapstructure:"role_based"`
 		RequiredRoles []string `mapstructure:"required_roles"`
 	} `mapstructure:"access_control"`
+	// Egress restricts outbound calls to user-influenced URLs such as webhooks
+	Egress client.EgressPolicy `mapstructure:"egress"`
 }
 
 // FeatureConfig holds feature configuration
@@ -157,6 +174,8 @@ type CacheConfig struct {
 		Password string `mapstructure:"password"`
 		DB       int    `mapstructure:"db"`
 		Prefix   string `mapstructure:"prefix"`
+		// Pool tunes connection pooling and timeouts; zero fields use redisclient defaults
+		Pool redisclient.Options `mapstructure:"pool"`
 	} `mapstructure:"redis"`
 }
 
@@ -198,6 +217,8 @@ type ModelConfig struct {
 
 // StorageConfig holds storage configuration
 type StorageConfig struct {
+	// Type is the document store backend, "local" or "s3"; STORAGE_BACKEND
+	// also sets it
 	Type  string `mapstructure:"type"`
 	Local struct {
 		Path    string `mapstructure:"path"`
@@ -206,11 +227,80 @@ type StorageConfig struct {
 	S3 struct {
 		Bucket string `mapstructure:"bucket"`
 		Region string `mapstructure:"region"`
+		// Endpoint overrides the AWS endpoint for S3-compatible stores such as MinIO
+		Endpoint       string `mapstructure:"endpoint"`
+		ForcePathStyle bool   `mapstructure:"force_path_style"`
+		// AccessKeyID and SecretAccessKey fall back to the default AWS
+		// credential chain when empty
+		AccessKeyID     string `mapstructure:"access_key_id"`
+		SecretAccessKey string `mapstructure:"secret_access_key"`
 	} `mapstructure:"s3"`
 	Retention struct {
 		Documents           string `mapstructure:"documents"`
 		VerificationResults string `mapstructure:"verification_results"`
 	} `mapstructure:"retention"`
+	Scan struct {
+		Enabled        bool          `mapstructure:"enabled"`
+		Provider       string        `mapstructure:"provider"`
+		Address        string        `mapstructure:"address"`
+		Timeout        time.Duration `mapstructure:"timeout"`
+		FailOpen       bool          `mapstructure:"fail_open"`
+		Async          bool          `mapstructure:"async"`
+		QuarantinePath string        `mapstructure:"quarantine_path"`
+	} `mapstructure:"scan"`
+	// Download configures signed, expiring document download URLs
+	Download struct {
+		// Secret signs download URLs; downloads are disabled while empty
+		Secret  string        `mapstructure:"secret"`
+		TTL     time.Duration `mapstructure:"ttl"`
+		BaseURL string        `mapstructure:"base_url"`
+	} `mapstructure:"download"`
+}
+
+// VendorConfig holds configuration for the third-party identity verification vendor
+type VendorConfig struct {
+	Callback struct {
+		// Secret is the shared HMAC secret the vendor signs callbacks with
+		Secret          string `mapstructure:"secret"`
+		SignatureHeader string `mapstructure:"signature_header"`
+		// ReferenceField is the payload field holding our verification's external reference
+		ReferenceField string `mapstructure:"reference_field"`
+		// EventIDField is the payload field identifying a callback event for deduplication
+		EventIDField string `mapstructure:"event_id_field"`
+	} `mapstructure:"callback"`
+}
+
+// WebhookConfig holds configuration for the webhooks sent when a verification
+// reaches an outcome
+type WebhookConfig struct {
+	// Subscribers are the URLs every webhook is posted to; none disables webhooks
+	Subscribers []string `mapstructure:"subscribers"`
+	// Secret is the HMAC secret payloads are signed with
+	Secret string `mapstructure:"secret"`
+	// Timeout bounds a single delivery attempt
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAttempts, BaseDelay and MaxDelay control the exponential backoff
+	// between delivery attempts
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+}
+
+// OCRConfig holds configuration for extracting document data with OCR
+type OCRConfig struct {
+	// Provider is the OCR backend, "tesseract" or "remote"; empty disables OCR
+	Provider string `mapstructure:"provider"`
+	// TesseractPath and Languages configure the tesseract command line tool
+	TesseractPath string `mapstructure:"tesseract_path"`
+	Languages     string `mapstructure:"languages"`
+	// URL and APIKey configure the remote OCR service
+	URL    string `mapstructure:"url"`
+	APIKey string `mapstructure:"api_key"`
+	// Timeout bounds a single extraction
+	Timeout time.Duration `mapstructure:"timeout"`
+	// ReviewThreshold is the confidence, from 0 to 1, below which extracted
+	// data is flagged for manual review
+	ReviewThreshold float64 `mapstructure:"review_threshold"`
 }
 
 // ValidationConfig holds validation configuration
@@ -306,6 +396,8 @@ func Load() (*Config, error) {
 	v.SetEnvPrefix("APP")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	// The storage backend is also selectable without the prefix
+	v.BindEnv("storage.type", "STORAGE_BACKEND")
 
 	// Unmarshal configuration
 	var config Config
@@ -343,6 +435,33 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("server port is required")
 	}
 
+	switch cfg.Storage.Type {
+	case "local", "":
+	case "s3":
+		if cfg.Storage.S3.Bucket == "" {
+			return fmt.Errorf("storage.s3.bucket is required for the s3 storage backend")
+		}
+	default:
+		return fmt.Errorf("unknown storage backend %q", cfg.Storage.Type)
+	}
+
+	if len(cfg.Webhooks.Subscribers) > 0 && cfg.Webhooks.Secret == "" {
+		return fmt.Errorf("webhooks.secret is required when webhook subscribers are configured")
+	}
+
+	switch cfg.OCR.Provider {
+	case "", "tesseract":
+	case "remote":
+		if cfg.OCR.URL == "" {
+			return fmt.Errorf("ocr.url is required for the remote ocr provider")
+		}
+	default:
+		return fmt.Errorf("unknown ocr provider %q", cfg.OCR.Provider)
+	}
+	if cfg.OCR.ReviewThreshold < 0 || cfg.OCR.ReviewThreshold > 1 {
+		return fmt.Errorf("ocr.review_threshold must be between 0 and 1")
+	}
+
 	// Validate database configuration in production
 	if cfg.App.Environment == "production" {
 		if cfg.Database.Host == "" {
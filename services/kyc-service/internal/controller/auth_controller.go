@@ -124,6 +124,14 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 	}
 
 	response, err := c.authService.RefreshToken(ctx, refreshReq)
+	if errors.Is(err, service.ErrRevocationUnavailable) {
+		ctx.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Token refresh failed",
+			Details: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.logger.WithError(err).Error("Token refresh failed")
 		ctx.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -152,28 +160,49 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Logout a user and invalidate their refresh token
+// @Description Logout a user, revoking the bearer token and invalidating their refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body LogoutRequest true "Logout request"
+// @Param request body LogoutRequest false "Logout request"
 // @Success 200 {object} MessageResponse "Logout successful"
 // @Failure 400 {object} ErrorResponse "Bad request"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Token revocation unavailable"
 // @Router /auth/logout [post]
 func (c *AuthController) Logout(ctx *gin.Context) {
 	var req LogoutRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid request",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	accessToken := bearerToken(ctx)
+	if accessToken == "" && req.RefreshToken == "" {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{
 			Code:    http.StatusBadRequest,
 			Message: "Invalid request",
-			Details: err.Error(),
+			Details: "A bearer token or refresh token is required",
 		})
 		return
 	}
 
 	// Logout
-	err := c.authService.Logout(ctx, req.RefreshToken)
+	err := c.authService.Logout(ctx, req.RefreshToken, accessToken)
+	if errors.Is(err, service.ErrRevocationUnavailable) {
+		ctx.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Logout failed",
+			Details: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.logger.WithError(err).Error("Logout failed")
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -349,8 +378,8 @@ func (c *AuthController) RegisterRoutes(router *gin.Engine) {
 func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get token from Authorization header
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
+		tokenString := bearerToken(ctx)
+		if tokenString == "" {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
 				Code:    http.StatusUnauthorized,
 				Message: "Unauthorized",
@@ -359,14 +388,16 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// Extract token
-		tokenString := authHeader
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			tokenString = authHeader[7:]
-		}
-
 		// Validate token
-		claims, err := authService.ValidateToken(tokenString)
+		claims, err := authService.ValidateToken(ctx.Request.Context(), tokenString)
+		if errors.Is(err, service.ErrRevocationUnavailable) {
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{
+				Code:    http.StatusServiceUnavailable,
+				Message: "Unauthorized",
+				Details: err.Error(),
+			})
+			return
+		}
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
 				Code:    http.StatusUnauthorized,
@@ -386,6 +417,16 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 	}
 }
 
+// bearerToken returns the token from the Authorization header, with or without
+// its Bearer prefix
+func bearerToken(ctx *gin.Context) string {
+	authHeader := ctx.GetHeader("Authorization")
+	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+		return authHeader[7:]
+	}
+	return authHeader
+}
+
 // getUserIDFromContext gets the user ID from the context
 func getUserIDFromContext(ctx *gin.Context) (uuid.UUID, error) {
 	userIDStr, exists := ctx.Get("user_id")
@@ -436,7 +477,8 @@ type RefreshTokenRequest struct {
 
 // LogoutRequest represents a logout request
 type LogoutRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	// RefreshToken is optional when the request carries a bearer token
+	RefreshToken string `json:"refresh_token"`
 }
 
 // MFASetupResponse represents an MFA setup response
@@ -11,14 +11,43 @@ import (
 type DocumentStatus string
 
 const (
-	DocStatusPending    DocumentStatus = "PENDING"
-	DocStatusInReview   DocumentStatus = "IN_REVIEW"
-	DocStatusVerified   DocumentStatus = "VERIFIED"
-	DocStatusRejected   DocumentStatus = "REJECTED"
-	DocStatusExpired    DocumentStatus = "EXPIRED"
-	DocStatusIncomplete DocumentStatus = "INCOMPLETE"
+	DocStatusPending     DocumentStatus = "PENDING"
+	DocStatusScanning    DocumentStatus = "SCANNING"
+	DocStatusInReview    DocumentStatus = "IN_REVIEW"
+	DocStatusVerified    DocumentStatus = "VERIFIED"
+	DocStatusRejected    DocumentStatus = "REJECTED"
+	DocStatusExpired     DocumentStatus = "EXPIRED"
+	DocStatusIncomplete  DocumentStatus = "INCOMPLETE"
+	DocStatusQuarantined DocumentStatus = "QUARANTINED"
 )
 
+// Valid reports whether s is a known document status
+func (s DocumentStatus) Valid() bool {
+	switch s {
+	case DocStatusPending, DocStatusScanning, DocStatusInReview, DocStatusVerified,
+		DocStatusRejected, DocStatusExpired, DocStatusIncomplete, DocStatusQuarantined:
+		return true
+	}
+	return false
+}
+
+// Fields a document listing can be sorted by
+const (
+	DocumentSortCreatedAt = "created_at"
+	DocumentSortStatus    = "status"
+)
+
+// DocumentListOptions filters, sorts and pages a document listing
+type DocumentListOptions struct {
+	// Status limits the listing to one status; empty lists every status
+	Status DocumentStatus
+	// SortBy is DocumentSortCreatedAt or DocumentSortStatus
+	SortBy     string
+	Descending bool
+	Page       int
+	PageSize   int
+}
+
 // DocumentMetadata represents structured metadata for a document
 type DocumentMetadata struct {
 	OriginalFileName string                 `json:"original_file_name,omitempty"`
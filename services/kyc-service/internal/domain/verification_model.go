@@ -33,6 +33,16 @@ const (
 	VerMethodFacial     VerificationMethod = "FACIAL"
 )
 
+// Valid reports whether m is a known verification method
+func (m VerificationMethod) Valid() bool {
+	switch m {
+	case VerMethodManual, VerMethodAutomated, VerMethodThirdParty, VerMethodAI,
+		VerMethodBiometric, VerMethodDocument, VerMethodFacial:
+		return true
+	}
+	return false
+}
+
 // EnhancedVerification represents a verification in the domain model
 type EnhancedVerification struct {
 	ID              uuid.UUID              `json:"id"`
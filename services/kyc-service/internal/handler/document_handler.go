@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/timerange"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
@@ -248,24 +249,27 @@ func (h *DocumentHandler) GetDocumentsByType(c *gin.Context) {
 	})
 }
 
-// GetDocumentsByDateRange retrieves documents within a date range
-func (h *DocumentHandler) GetDocumentsByDateRange(c *gin.Context) {
-	startDate, err := time.Parse(time.RFC3339, c.Query("start_date"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start date"})
-		return
-	}
+// dateRangeParser parses the start_date and end_date parameters, defaulting to
+// the last 30 days and allowing at most a year
+var dateRangeParser = timerange.Parser{
+	FromParam:   "start_date",
+	ToParam:     "end_date",
+	DefaultSpan: 30 * 24 * time.Hour,
+	MaxSpan:     366 * 24 * time.Hour,
+}
 
-	endDate, err := time.Parse(time.RFC3339, c.Query("end_date"))
+// GetDocumentsByDateRange retrieves documents created in [start_date, end_date)
+func (h *DocumentHandler) GetDocumentsByDateRange(c *gin.Context) {
+	dateRange, err := dateRangeParser.Parse(c.Query("start_date"), c.Query("end_date"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end date"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "invalid_date_range"})
 		return
 	}
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
-	documents, total, err := h.documentService.GetDocumentsByDateRange(startDate, endDate, page, pageSize)
+	documents, total, err := h.documentService.GetDocumentsByDateRange(dateRange.From, dateRange.To, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -59,6 +59,37 @@ var (
 		},
 		[]string{"type", "code"},
 	)
+
+	// Document processing pipeline metrics
+	DocumentProcessingQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "document_processing_queue_depth",
+			Help: "Number of documents waiting for a processing worker",
+		},
+	)
+
+	DocumentProcessingRejected = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "document_processing_rejected_total",
+			Help: "Total number of uploads refused because the processing queue was full",
+		},
+	)
+
+	DocumentOrphansRemoved = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "document_orphaned_files_removed_total",
+			Help: "Total number of stored document files removed because no document referenced them",
+		},
+	)
+
+	DocumentProcessingDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "document_processing_duration_seconds",
+			Help:    "Time taken to run the processing steps for a document",
+			Buckets: []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300},
+		},
+		[]string{"status"},
+	)
 )
 
 // MetricsService handles all metrics operations
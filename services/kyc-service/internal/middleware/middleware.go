@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	sharedMiddleware "github.com/adil-faiyaz98/sparkfund/pkg/middleware"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/sparkfund/kyc-service/internal/logger"
 	"github.com/sparkfund/kyc-service/internal/metrics"
 	"go.uber.org/zap"
@@ -23,17 +23,9 @@ func New(logger *logger.Logger, metrics *metrics.MetricsService) *Middleware {
 	}
 }
 
-// RequestID adds a unique request ID to each request
+// RequestID adds a unique request ID to each request, keeping a valid incoming one
 func (m *Middleware) RequestID() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = uuid.New().String()
-		}
-		c.Set("request_id", requestID)
-		c.Header("X-Request-ID", requestID)
-		c.Next()
-	}
+	return sharedMiddleware.RequestID(sharedMiddleware.DefaultRequestIDConfig())
 }
 
 // Logger logs request and response details
@@ -63,6 +63,9 @@ type JWTClaims struct {
 	Email     string `json:"email"`
 	Role      string `json:"role"`
 	MFAPassed bool   `json:"mfa_passed"`
+	// TokenID is the token's jti claim, used to revoke it
+	TokenID   string    `json:"jti"`
+	ExpiresAt time.Time `json:"exp"`
 }
 
 // Session represents a user session
@@ -31,14 +31,21 @@ const (
 type DocumentStatus string
 
 const (
-	DocumentStatusPending    DocumentStatus = "pending"
-	DocumentStatusInReview   DocumentStatus = "in_review"
-	DocumentStatusVerified   DocumentStatus = "verified"
-	DocumentStatusRejected   DocumentStatus = "rejected"
-	DocumentStatusExpired    DocumentStatus = "expired"
-	DocumentStatusIncomplete DocumentStatus = "incomplete"
+	DocumentStatusPending     DocumentStatus = "pending"
+	DocumentStatusScanning    DocumentStatus = "scanning"
+	DocumentStatusInReview    DocumentStatus = "in_review"
+	DocumentStatusVerified    DocumentStatus = "verified"
+	DocumentStatusRejected    DocumentStatus = "rejected"
+	DocumentStatusExpired     DocumentStatus = "expired"
+	DocumentStatusIncomplete  DocumentStatus = "incomplete"
+	DocumentStatusQuarantined DocumentStatus = "quarantined"
 )
 
+// DocumentStatusInfected is the status a scan moves an infected document to:
+// uploads go from scanning to pending when clean or to infected otherwise.
+// Infected documents are quarantined, so the two are the same status.
+const DocumentStatusInfected = DocumentStatusQuarantined
+
 // Document represents a KYC document
 type Document struct {
 	ID                uuid.UUID      `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
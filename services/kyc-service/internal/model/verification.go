@@ -61,6 +61,7 @@ type Verification struct {
 	Metadata        map[string]interface{} `gorm:"type:jsonb" json:"metadata,omitempty"`
 	Result          map[string]interface{} `gorm:"type:jsonb" json:"result,omitempty"`
 	ErrorMessage    string                 `gorm:"type:text" json:"error_message,omitempty"`
+	ExternalRef     string                 `gorm:"column:external_reference;type:varchar(255);index" json:"external_reference,omitempty"`
 	CreatedAt       time.Time              `gorm:"not null" json:"created_at"`
 	UpdatedAt       time.Time              `gorm:"not null" json:"updated_at"`
 	CompletedAt     *time.Time             `json:"completed_at,omitempty"`
@@ -125,6 +126,21 @@ func (VerificationHistory) TableName() string {
 	return "verification_history"
 }
 
+// VerificationCallback records a vendor callback that has been applied, so a
+// redelivered callback is recognised and not applied twice
+type VerificationCallback struct {
+	ID             uuid.UUID          `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	EventKey       string             `gorm:"type:varchar(255);not null;uniqueIndex" json:"event_key"`
+	VerificationID uuid.UUID          `gorm:"type:uuid;not null;index" json:"verification_id"`
+	Status         VerificationStatus `gorm:"type:varchar(20);not null" json:"status"`
+	ReceivedAt     time.Time          `gorm:"not null" json:"received_at"`
+}
+
+// TableName specifies the table name for the VerificationCallback model
+func (VerificationCallback) TableName() string {
+	return "verification_callbacks"
+}
+
 // VerificationResult represents the result of a verification process
 type VerificationResult struct {
 	ID             uuid.UUID              `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
@@ -144,3 +160,22 @@ type VerificationResult struct {
 func (VerificationResult) TableName() string {
 	return "verification_results"
 }
+
+// WebhookDeadLetter records a webhook delivery that failed permanently, either
+// refused by the subscriber or still failing after every retry, so it can be
+// inspected and replayed
+type WebhookDeadLetter struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	VerificationID uuid.UUID `gorm:"type:uuid;not null;index" json:"verification_id"`
+	Event          string    `gorm:"type:varchar(50);not null" json:"event"`
+	URL            string    `gorm:"type:text;not null" json:"url"`
+	Payload        string    `gorm:"type:jsonb;not null" json:"payload"`
+	Attempts       int       `gorm:"not null" json:"attempts"`
+	LastError      string    `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt      time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for the WebhookDeadLetter model
+func (WebhookDeadLetter) TableName() string {
+	return "webhook_dead_letters"
+}
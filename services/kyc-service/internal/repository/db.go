@@ -62,5 +62,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.Verification{},
 		&model.VerificationHistory{},
 		&model.VerificationResult{},
+		&model.VerificationCallback{},
+		&model.WebhookDeadLetter{},
 	)
 }
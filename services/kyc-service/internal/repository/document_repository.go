@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,14 @@ func NewDocumentRepository(db *gorm.DB) *DocumentRepository {
 	return &DocumentRepository{db: db}
 }
 
+// WithTransaction runs fn inside a single database transaction. The repositories
+// passed to fn are bound to the transaction, which is rolled back if fn returns an error.
+func (r *DocumentRepository) WithTransaction(ctx context.Context, fn func(docRepo *DocumentRepository, verRepo *VerificationRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewDocumentRepository(tx), NewVerificationRepository(tx))
+	})
+}
+
 // Create creates a new document
 func (r *DocumentRepository) Create(ctx context.Context, doc *model.Document) error {
 	return r.db.WithContext(ctx).Create(doc).Error
@@ -49,21 +58,91 @@ func (r *DocumentRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 	return &doc, nil
 }
 
-// GetByUserIDPaginated retrieves documents for a user with pagination
+// FindByHash returns the user's oldest document with the content hash
+// fileHash, or nil if they have none
+func (r *DocumentRepository) FindByHash(ctx context.Context, userID uuid.UUID, fileHash string) (*model.Document, error) {
+	var doc model.Document
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND file_hash = ?", userID, fileHash).
+		Order("created_at ASC").
+		First(&doc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// GetByUserIDPaginated retrieves documents for a user with pagination, newest first
 func (r *DocumentRepository) GetByUserIDPaginated(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]*model.Document, int64, error) {
-	var documents []*model.Document
-	var total int64
+	return r.List(ctx, DocumentListOptions{UserID: &userID, Page: page, PageSize: pageSize, Descending: true})
+}
 
-	query := r.db.WithContext(ctx).Model(&model.Document{}).Where("user_id = ?", userID)
+// documentSortColumns maps the sort fields of a document listing to columns
+var documentSortColumns = map[string]string{
+	"created_at": "created_at",
+	"status":     "status",
+}
 
-	// Get total count
-	err := query.Count(&total).Error
-	if err != nil {
-		return nil, 0, err
+// DocumentListOptions filters, sorts and pages a document listing
+type DocumentListOptions struct {
+	// UserID limits the listing to one user's documents if set
+	UserID *uuid.UUID
+	// Status limits the listing to one status if set
+	Status model.DocumentStatus
+	// From and To limit the listing to documents created in [From, To); a zero
+	// bound is open
+	From time.Time
+	To   time.Time
+	// SortBy is created_at or status; anything else sorts by created_at
+	SortBy     string
+	Descending bool
+	Page       int
+	PageSize   int
+}
+
+// List returns a page of the documents matching opts and the total number
+// matching. The count and the page are read in one transaction so the total
+// agrees with the page it is returned with.
+func (r *DocumentRepository) List(ctx context.Context, opts DocumentListOptions) ([]*model.Document, int64, error) {
+	column, ok := documentSortColumns[opts.SortBy]
+	if !ok {
+		column = "created_at"
 	}
+	direction := "ASC"
+	if opts.Descending {
+		direction = "DESC"
+	}
+	// Break ties on the ID so rows do not move between pages
+	order := fmt.Sprintf("%s %s, id %s", column, direction, direction)
 
-	// Get paginated results
-	err = query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&documents).Error
+	var documents []*model.Document
+	var total int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&model.Document{})
+		if opts.UserID != nil {
+			query = query.Where("user_id = ?", *opts.UserID)
+		}
+		if opts.Status != "" {
+			query = query.Where("status = ?", opts.Status)
+		}
+		if !opts.From.IsZero() {
+			query = query.Where("created_at >= ?", opts.From)
+		}
+		if !opts.To.IsZero() {
+			query = query.Where("created_at < ?", opts.To)
+		}
+
+		// Get total count
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+
+		// Get paginated results
+		return query.Order(order).Offset((opts.Page - 1) * opts.PageSize).Limit(opts.PageSize).Find(&documents).Error
+	})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -81,6 +160,27 @@ func (r *DocumentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.Document{}, "id = ?", id).Error
 }
 
+// ReferencedFilePaths reports which of paths belong to a document that has not
+// been deleted
+func (r *DocumentRepository) ReferencedFilePaths(ctx context.Context, paths []string) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	if len(paths) == 0 {
+		return referenced, nil
+	}
+
+	var found []string
+	if err := r.db.WithContext(ctx).Model(&model.Document{}).
+		Where("file_path IN ?", paths).
+		Distinct().
+		Pluck("file_path", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, path := range found {
+		referenced[path] = true
+	}
+	return referenced, nil
+}
+
 // GetHistory retrieves the history of a document
 func (r *DocumentRepository) GetHistory(ctx context.Context, documentID uuid.UUID) ([]*model.DocumentHistory, error) {
 	var history []*model.DocumentHistory
@@ -250,27 +350,10 @@ func (r *DocumentRepository) GetByType(ctx context.Context, documentType model.D
 	return documents, total, nil
 }
 
-// GetByDateRange retrieves documents by date range
+// GetByDateRange retrieves documents by date range, created at or after startDate and
+// before endDate
 func (r *DocumentRepository) GetByDateRange(ctx context.Context, startDate, endDate time.Time, page, pageSize int) ([]*model.Document, int64, error) {
-	var documents []*model.Document
-	var total int64
-
-	query := r.db.WithContext(ctx).Model(&model.Document{}).
-		Where("created_at BETWEEN ? AND ?", startDate, endDate)
-
-	// Get total count
-	err := query.Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated results
-	err = query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&documents).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return documents, total, nil
+	return r.List(ctx, DocumentListOptions{From: startDate, To: endDate, Page: page, PageSize: pageSize, Descending: true})
 }
 
 // UpdateStatus updates the status of a document
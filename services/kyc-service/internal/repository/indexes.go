@@ -0,0 +1,24 @@
+package repository
+
+import (
+	sharedDB "github.com/adil-faiyaz98/sparkfund/pkg/database"
+)
+
+// Indexes are the composite indexes the document and verification queries
+// rely on. AutoMigrate only creates the single-column indexes in the model
+// tags, so these are created at startup by sharedDB.EnsureIndexes.
+var Indexes = []sharedDB.Index{
+	// A user's documents, newest first, and the date-range listing
+	{Name: "idx_documents_user_created", Table: "documents", Columns: []string{"user_id", "created_at DESC", "id DESC"}},
+	{Name: "idx_documents_created", Table: "documents", Columns: []string{"created_at DESC", "id DESC"}},
+	// Duplicate uploads, found by content hash
+	{Name: "idx_documents_user_file_hash", Table: "documents", Columns: []string{"user_id", "file_hash"}},
+	// Documents filtered by status
+	{Name: "idx_documents_status_created", Table: "documents", Columns: []string{"status", "created_at DESC", "id DESC"}},
+	// Document and verification history, newest first
+	{Name: "idx_document_history_document_created", Table: "document_history", Columns: []string{"document_id", "created_at DESC"}},
+	{Name: "idx_verification_history_verification_created", Table: "verification_history", Columns: []string{"verification_id", "created_at DESC"}},
+	// The verification date-range listing and the latest verification of a document
+	{Name: "idx_verifications_created", Table: "verifications", Columns: []string{"created_at DESC", "id DESC"}},
+	{Name: "idx_verifications_document_created", Table: "verifications", Columns: []string{"document_id", "created_at DESC"}},
+}
@@ -9,6 +9,7 @@ type Repositories struct {
 	Document     *DocumentRepository
 	KYC          *KYCRepository
 	Verification *VerificationRepository
+	Webhook      *WebhookRepository
 }
 
 // NewRepositories creates a new Repositories instance
@@ -17,5 +18,6 @@ func NewRepositories(db *gorm.DB) *Repositories {
 		Document:     NewDocumentRepository(db),
 		KYC:          NewKYCRepository(db),
 		Verification: NewVerificationRepository(db),
+		Webhook:      NewWebhookRepository(db),
 	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"sparkfund/services/kyc-service/internal/model"
 )
@@ -20,6 +21,14 @@ func NewVerificationRepository(db *gorm.DB) *VerificationRepository {
 	return &VerificationRepository{db: db}
 }
 
+// WithinTransaction runs fn with a repository bound to a single database
+// transaction, committing if fn returns nil and rolling back otherwise
+func (r *VerificationRepository) WithinTransaction(ctx context.Context, fn func(tx *VerificationRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&VerificationRepository{db: tx})
+	})
+}
+
 // Create creates a new verification
 func (r *VerificationRepository) Create(ctx context.Context, verification *model.Verification) error {
 	return r.db.WithContext(ctx).Create(verification).Error
@@ -35,6 +44,28 @@ func (r *VerificationRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	return &verification, nil
 }
 
+// GetByExternalReference retrieves a verification by the reference an external vendor knows it by
+func (r *VerificationRepository) GetByExternalReference(ctx context.Context, reference string) (*model.Verification, error) {
+	var verification model.Verification
+	err := r.db.WithContext(ctx).First(&verification, "external_reference = ?", reference).Error
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// RecordCallback stores a processed vendor callback. It reports false, without an
+// error, if a callback with the same event key was already recorded.
+func (r *VerificationRepository) RecordCallback(ctx context.Context, callback *model.VerificationCallback) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_key"}}, DoNothing: true}).
+		Create(callback)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // GetByDocumentID retrieves verifications by document ID
 func (r *VerificationRepository) GetByDocumentID(ctx context.Context, documentID uuid.UUID) ([]*model.Verification, error) {
 	var verifications []*model.Verification
@@ -60,6 +91,22 @@ func (r *VerificationRepository) Update(ctx context.Context, verification *model
 	return r.db.WithContext(ctx).Save(verification).Error
 }
 
+// ClaimForProcessing moves a verification to in_progress, but only if it has not
+// been modified since it was read at lastUpdatedAt. It reports whether the claim
+// succeeded, so concurrent callers cannot both process the same verification.
+func (r *VerificationRepository) ClaimForProcessing(ctx context.Context, id uuid.UUID, lastUpdatedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Verification{}).
+		Where("id = ? AND updated_at = ?", id, lastUpdatedAt).
+		Updates(map[string]interface{}{
+			"status":     model.VerificationStatusInProgress,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // Delete soft deletes a verification
 func (r *VerificationRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.Verification{}, "id = ?", id).Error
@@ -225,13 +272,14 @@ func (r *VerificationRepository) GetByMethod(ctx context.Context, method model.V
 	return verifications, total, nil
 }
 
-// GetByDateRange retrieves verifications within a date range
+// GetByDateRange retrieves verifications within a date range, created at or after startDate and
+// before endDate
 func (r *VerificationRepository) GetByDateRange(ctx context.Context, startDate, endDate time.Time, page, pageSize int) ([]*model.Verification, int64, error) {
 	var verifications []*model.Verification
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.Verification{}).
-		Where("created_at BETWEEN ? AND ?", startDate, endDate)
+		Where("created_at >= ? AND created_at < ?", startDate, endDate)
 
 	// Get total count
 	err := query.Count(&total).Error
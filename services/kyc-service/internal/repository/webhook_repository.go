@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"sparkfund/services/kyc-service/internal/model"
+)
+
+// WebhookRepository handles database operations for webhook deliveries
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// RecordDeadLetter stores a permanently failed webhook delivery
+func (r *WebhookRepository) RecordDeadLetter(ctx context.Context, deadLetter *model.WebhookDeadLetter) error {
+	return r.db.WithContext(ctx).Create(deadLetter).Error
+}
@@ -1,11 +1,13 @@
 package security
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -20,9 +22,14 @@ type Claims struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	SecretKey     string
+	SecretKey string
+	// Keys signs and verifies tokens by kid, for zero-downtime key rotation;
+	// SecretKey is the only key if nil
+	Keys          *jwtkeys.KeySet
 	TokenExpiry   time.Duration
 	RefreshExpiry time.Duration
+	// Algorithms is the allowlist of signing algorithms; jwtalg.DefaultAllowed if empty
+	Algorithms []string
 }
 
 // DefaultAuthConfig returns default authentication configuration
@@ -31,11 +38,22 @@ func DefaultAuthConfig() AuthConfig {
 		SecretKey:     "your-secret-key", // Change this in production
 		TokenExpiry:   15 * time.Minute,
 		RefreshExpiry: 24 * time.Hour,
+		Algorithms:    jwtalg.FromEnv(),
 	}
 }
 
+// keySet returns the keys tokens are signed and verified with
+func (config AuthConfig) keySet() *jwtkeys.KeySet {
+	if config.Keys != nil {
+		return config.Keys
+	}
+	return jwtkeys.FromSecret(config.SecretKey)
+}
+
 // AuthMiddleware provides JWT authentication
 func AuthMiddleware(config AuthConfig) gin.HandlerFunc {
+	keys := config.keySet()
+
 	return func(c *gin.Context) {
 		// Skip authentication for public endpoints
 		if isPublicEndpoint(c.Request.URL.Path) {
@@ -61,21 +79,13 @@ func AuthMiddleware(config AuthConfig) gin.HandlerFunc {
 
 		// Parse and validate token
 		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(config.SecretKey), nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+		token, err := jwt.ParseWithClaims(tokenString, claims, jwtalg.Keyfunc(config.Algorithms, keys.Keyfunc), jwtalg.ParserOptions(config.Algorithms)...)
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
+		if err != nil || !token.Valid {
+			// Tell clients whether to refresh or discard the token
+			reason := jwtalg.Classify(err)
+			metrics.RecordJWTValidationFailure(reason)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "code": reason})
 			c.Abort()
 			return
 		}
@@ -142,8 +152,7 @@ func GenerateToken(userID, username string, roles []string, config AuthConfig) (
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.SecretKey))
+	return config.keySet().Sign(claims)
 }
 
 // GenerateRefreshToken generates a new refresh token
@@ -156,8 +165,7 @@ func GenerateRefreshToken(userID string, config AuthConfig) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.SecretKey))
+	return config.keySet().Sign(claims)
 }
 
 // isPublicEndpoint checks if the endpoint is public
@@ -16,16 +16,36 @@ import (
 
 	"sparkfund/services/kyc-service/internal/model"
 	"sparkfund/services/kyc-service/internal/repository"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
 )
 
+// authUserStore is the part of repository.UserRepository AuthService uses
+type authUserStore interface {
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+}
+
+// authSessionStore is the part of repository.SessionRepository AuthService uses
+type authSessionStore interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error)
+	Update(ctx context.Context, session *model.Session) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
 // AuthService handles authentication operations
 type AuthService struct {
-	userRepo    *repository.UserRepository
-	sessionRepo *repository.SessionRepository
-	logger      *logrus.Logger
-	jwtSecret   []byte
-	jwtExpiry   time.Duration
-	mfaEnabled  bool
+	userRepo      authUserStore
+	sessionRepo   authSessionStore
+	logger        *logrus.Logger
+	jwtKeys       *jwtkeys.KeySet
+	jwtExpiry     time.Duration
+	mfaEnabled    bool
+	denylist      TokenDenylist
+	refreshTokens RefreshTokenStore
 }
 
 // NewAuthService creates a new authentication service
@@ -41,12 +61,32 @@ func NewAuthService(
 		userRepo:    userRepo,
 		sessionRepo: sessionRepo,
 		logger:      logger,
-		jwtSecret:   []byte(jwtSecret),
+		jwtKeys:     jwtkeys.FromSecret(jwtSecret),
 		jwtExpiry:   jwtExpiry,
 		mfaEnabled:  mfaEnabled,
 	}
 }
 
+// SetJWTKeys replaces the JWT secret with a rotatable key set. Tokens are signed
+// with its current key and validated with any key that has not retired. The keys
+// must be HMAC secrets, since AuthService signs with HS256 only.
+func (s *AuthService) SetJWTKeys(keys *jwtkeys.KeySet) {
+	s.jwtKeys = keys
+}
+
+// SetTokenDenylist lets access tokens be revoked before they expire. Logout
+// adds the token's jti to the denylist and ValidateToken rejects denied
+// tokens; while the denylist is unreachable, every token is rejected.
+func (s *AuthService) SetTokenDenylist(denylist TokenDenylist) {
+	s.denylist = denylist
+}
+
+// SetRefreshTokenStore enables refresh token reuse detection. A refresh token
+// presented after it was rotated out revokes every session of its user.
+func (s *AuthService) SetRefreshTokenStore(store RefreshTokenStore) {
+	s.refreshTokens = store
+}
+
 // Login authenticates a user
 func (s *AuthService) Login(ctx context.Context, req model.LoginRequest, deviceInfo model.DeviceInfo) (*model.LoginResponse, error) {
 	// Get user by email
@@ -133,6 +173,13 @@ func (s *AuthService) Login(ctx context.Context, req model.LoginRequest, deviceI
 		return nil, errors.New("authentication failed")
 	}
 
+	if s.refreshTokens != nil {
+		if err := s.refreshTokens.Rotate(ctx, user.ID, "", refreshTokenID(refreshToken)); err != nil {
+			s.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to record refresh token")
+			return nil, errors.New("authentication failed")
+		}
+	}
+
 	// Update user's last login information
 	now := time.Now()
 	user.LastLoginAt = &now
@@ -155,11 +202,16 @@ func (s *AuthService) Login(ctx context.Context, req model.LoginRequest, deviceI
 	}, nil
 }
 
-// RefreshToken refreshes an authentication token
+// RefreshToken refreshes an authentication token. The presented refresh token
+// is rotated out; presenting it again returns ErrRefreshTokenReused and
+// revokes every session of the user.
 func (s *AuthService) RefreshToken(ctx context.Context, req model.RefreshTokenRequest) (*model.LoginResponse, error) {
 	// Get session by refresh token
 	session, err := s.sessionRepo.GetByRefreshToken(ctx, req.RefreshToken)
 	if err != nil {
+		if err := s.checkRefreshTokenReuse(ctx, req.RefreshToken); err != nil {
+			return nil, err
+		}
 		s.logger.WithError(err).Error("Session not found during token refresh")
 		return nil, errors.New("invalid refresh token")
 	}
@@ -191,6 +243,20 @@ func (s *AuthService) RefreshToken(ctx context.Context, req model.RefreshTokenRe
 		return nil, errors.New("token refresh failed")
 	}
 
+	// Rotate out the presented token before it stops matching the session
+	if s.refreshTokens != nil {
+		err := s.refreshTokens.Rotate(ctx, user.ID, refreshTokenID(req.RefreshToken), refreshTokenID(refreshToken))
+		if errors.Is(err, ErrRefreshTokenReused) {
+			// Another refresh rotated the token out after this one read the session
+			s.revokeRefreshTokenFamily(ctx, user.ID)
+			return nil, ErrRefreshTokenReused
+		}
+		if err != nil {
+			s.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to rotate refresh token")
+			return nil, fmt.Errorf("%w: %v", ErrRevocationUnavailable, err)
+		}
+	}
+
 	// Update session
 	session.RefreshToken = refreshToken
 	session.ExpiresAt = time.Now().Add(30 * 24 * time.Hour) // 30 days
@@ -212,8 +278,55 @@ func (s *AuthService) RefreshToken(ctx context.Context, req model.RefreshTokenRe
 	}, nil
 }
 
-// Logout logs out a user
-func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+// checkRefreshTokenReuse returns ErrRefreshTokenReused, after revoking the
+// user's token family and sessions, if refreshToken was rotated out
+func (s *AuthService) checkRefreshTokenReuse(ctx context.Context, refreshToken string) error {
+	if s.refreshTokens == nil {
+		return nil
+	}
+
+	userID, reused, err := s.refreshTokens.IsReused(ctx, refreshTokenID(refreshToken))
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to check refresh token reuse")
+		return fmt.Errorf("%w: %v", ErrRevocationUnavailable, err)
+	}
+	if !reused {
+		return nil
+	}
+
+	s.revokeRefreshTokenFamily(ctx, userID)
+	return ErrRefreshTokenReused
+}
+
+// revokeRefreshTokenFamily revokes every refresh token and session of userID
+// after one of its rotated-out refresh tokens was reused
+func (s *AuthService) revokeRefreshTokenFamily(ctx context.Context, userID uuid.UUID) {
+	s.logger.WithField("user_id", userID).Warn("Rotated refresh token reused, revoking all sessions")
+	if err := s.refreshTokens.RevokeFamily(ctx, userID); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke refresh token family")
+	}
+	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to delete sessions after refresh token reuse")
+	}
+}
+
+// Logout logs out a user, revoking the access token if one is given and
+// deleting the session of the refresh token if one is given
+func (s *AuthService) Logout(ctx context.Context, refreshToken, accessToken string) error {
+	if accessToken != "" {
+		if err := s.RevokeToken(ctx, accessToken); err != nil {
+			if errors.Is(err, ErrRevocationUnavailable) {
+				s.logger.WithError(err).Error("Failed to revoke access token during logout")
+				return err
+			}
+			// An invalid or expired token cannot be used anyway
+			s.logger.WithError(err).Warn("Access token not revoked during logout")
+		}
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
 	// Get session by refresh token
 	session, err := s.sessionRepo.GetByRefreshToken(ctx, refreshToken)
 	if err != nil {
@@ -331,15 +444,49 @@ func (s *AuthService) DisableMFA(ctx context.Context, userID uuid.UUID) error {
 	return nil
 }
 
-// ValidateToken validates a JWT token
-func (s *AuthService) ValidateToken(tokenString string) (*model.JWTClaims, error) {
+// RevokeToken adds an access token to the denylist until it expires. Tokens
+// issued without a jti cannot be revoked and are left to expire. Without a
+// denylist only the refresh token can be revoked, so RevokeToken does nothing.
+func (s *AuthService) RevokeToken(ctx context.Context, tokenString string) error {
+	if s.denylist == nil {
+		return nil
+	}
+
+	claims, err := s.ValidateToken(ctx, tokenString)
+	if errors.Is(err, ErrTokenRevoked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if claims.TokenID == "" {
+		return nil
+	}
+
+	ttl := time.Until(claims.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.denylist.Revoke(ctx, claims.TokenID, ttl); err != nil {
+		return fmt.Errorf("%w: %v", ErrRevocationUnavailable, err)
+	}
+	return nil
+}
+
+// ValidateToken validates a JWT token. Tokens revoked by logging out are
+// rejected with ErrTokenRevoked.
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*model.JWTClaims, error) {
 	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	// Only accept the algorithm this service signs with
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Validate signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return s.jwtSecret, nil
+		// Tokens signed with a previous key keep validating until it retires
+		kid, _ := token.Header["kid"].(string)
+		return s.jwtKeys.VerificationKey(kid)
 	})
 
 	if err != nil {
@@ -364,6 +511,21 @@ func (s *AuthService) ValidateToken(tokenString string) (*model.JWTClaims, error
 		Role:      claims["role"].(string),
 		MFAPassed: claims["mfa_passed"].(bool),
 	}
+	jwtClaims.TokenID, _ = claims["jti"].(string)
+	if exp, ok := claims["exp"].(float64); ok {
+		jwtClaims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+
+	if s.denylist != nil && jwtClaims.TokenID != "" {
+		revoked, err := s.denylist.IsRevoked(ctx, jwtClaims.TokenID)
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to check token denylist")
+			return nil, fmt.Errorf("%w: %v", ErrRevocationUnavailable, err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
 
 	return jwtClaims, nil
 }
@@ -381,13 +543,18 @@ func (s *AuthService) generateJWT(user *model.User) (string, time.Time, error) {
 		"mfa_passed": user.MFAEnabled,
 		"exp":        expiresAt.Unix(),
 		"iat":        time.Now().Unix(),
+		"jti":        uuid.New().String(),
 	}
 
 	// Create token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	kid, secret := s.jwtKeys.SigningKey()
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
 	// Sign token
-	tokenString, err := token.SignedString(s.jwtSecret)
+	tokenString, err := token.SignedString(secret)
 	if err != nil {
 		return "", time.Time{}, err
 	}
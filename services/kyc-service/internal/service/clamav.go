@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamAVChunkSize is the size of the chunks streamed to clamd
+const clamAVChunkSize = 64 << 10
+
+// ClamAVProvider scans files with a clamd daemon over TCP using the INSTREAM
+// command
+type ClamAVProvider struct {
+	address string
+	dialer  net.Dialer
+}
+
+// NewClamAVProvider creates a provider for the clamd daemon at address,
+// e.g. localhost:3310
+func NewClamAVProvider(address string) *ClamAVProvider {
+	return &ClamAVProvider{address: address}
+}
+
+// NewClamAVScanner creates a Scanner for the clamd daemon at address
+func NewClamAVScanner(address string) Scanner {
+	return clamAVScanner{provider: NewClamAVProvider(address)}
+}
+
+// clamAVScanner is a Scanner backed by a ClamAVProvider
+type clamAVScanner struct {
+	provider *ClamAVProvider
+}
+
+func (s clamAVScanner) Scan(ctx context.Context, r io.Reader) (bool, string, error) {
+	result, err := s.provider.Scan(ctx, "", r)
+	if err != nil {
+		return false, "", err
+	}
+	return result.Clean, result.Signature, nil
+}
+
+// Scan streams r to clamd and reports its verdict. The scan is abandoned when
+// ctx is done.
+func (p *ClamAVProvider) Scan(ctx context.Context, fileName string, r io.Reader) (*ScanResult, error) {
+	conn, err := p.dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	// clamd only sees deadlines, so unblock reads and writes when ctx ends
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := clamAVStream(conn, r); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to send file to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+// clamAVStream sends the INSTREAM command and r as length-prefixed chunks,
+// ended by a zero-length chunk
+func clamAVStream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+clamAVChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseClamAVReply reads a clamd verdict such as "stream: OK",
+// "stream: Eicar-Signature FOUND" or "INSTREAM size limit exceeded. ERROR"
+func parseClamAVReply(reply string) (*ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case verdict == "OK":
+		return &ScanResult{Clean: true, Engine: "clamav", ScannedAt: time.Now()}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &ScanResult{
+			Clean:     false,
+			Signature: strings.TrimSuffix(verdict, " FOUND"),
+			Engine:    "clamav",
+			ScannedAt: time.Now(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd answers one INSTREAM scan with reply and sends the streamed bytes
+// on the returned channel
+func fakeClamd(t *testing.T, reply func(data []byte) string) (string, <-chan []byte) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		command, err := r.ReadString(0)
+		if err != nil || command != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			return
+		}
+
+		var data []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			data = append(data, chunk...)
+		}
+		received <- data
+		conn.Write([]byte(reply(data) + "\x00"))
+	}()
+
+	return ln.Addr().String(), received
+}
+
+func eicarReply(data []byte) string {
+	if bytes.Contains(data, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+		return "stream: Eicar-Test-Signature FOUND"
+	}
+	return "stream: OK"
+}
+
+func TestClamAVProvider_CleanFile(t *testing.T) {
+	address, received := fakeClamd(t, eicarReply)
+
+	// Larger than one chunk, so the stream is split
+	data := bytes.Repeat([]byte("%PDF-1.4 passport "), 8000)
+	result, err := NewClamAVProvider(address).Scan(context.Background(), "passport.pdf", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !result.Clean || result.Engine != "clamav" {
+		t.Fatalf("expected a clean clamav result, got %+v", result)
+	}
+	if got := <-received; !bytes.Equal(got, data) {
+		t.Fatalf("clamd received %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestClamAVProvider_InfectedFile(t *testing.T) {
+	address, _ := fakeClamd(t, eicarReply)
+
+	data := strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*")
+	result, err := NewClamAVProvider(address).Scan(context.Background(), "passport.pdf", data)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if result.Clean || result.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("expected the EICAR signature, got %+v", result)
+	}
+}
+
+func TestClamAVScanner_ReportsVerdict(t *testing.T) {
+	address, _ := fakeClamd(t, eicarReply)
+
+	clean, detail, err := NewClamAVScanner(address).Scan(context.Background(), strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if clean || detail != "Eicar-Test-Signature" {
+		t.Fatalf("expected the EICAR signature, got clean=%v detail=%q", clean, detail)
+	}
+}
+
+func TestClamAVProvider_ErrorReply(t *testing.T) {
+	address, _ := fakeClamd(t, func([]byte) string { return "INSTREAM size limit exceeded. ERROR" })
+
+	_, err := NewClamAVProvider(address).Scan(context.Background(), "passport.pdf", strings.NewReader("data"))
+	if err == nil || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Fatalf("expected the clamd error, got %v", err)
+	}
+}
+
+func TestClamAVProvider_UnresponsiveDaemonTimesOut(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept and never answer
+		if conn, err := ln.Accept(); err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = NewClamAVProvider(ln.Addr().String()).Scan(ctx, "passport.pdf", strings.NewReader("data"))
+	if err == nil {
+		t.Fatal("expected the scan to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("scan took %v to give up", elapsed)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sparkfund/services/kyc-service/internal/domain"
+	"sparkfund/services/kyc-service/internal/model"
+	"sparkfund/services/kyc-service/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxBulkStatusUpdates bounds the number of document status updates applied in
+// one BulkUpdateStatus call
+const MaxBulkStatusUpdates = 500
+
+var (
+	// ErrBulkUpdateTooLarge is returned when a bulk update has more than
+	// MaxBulkStatusUpdates items
+	ErrBulkUpdateTooLarge = fmt.Errorf("a bulk update may change at most %d documents", MaxBulkStatusUpdates)
+	// ErrInvalidStatusUpdate is returned for a bulk update item that cannot be applied
+	ErrInvalidStatusUpdate = errors.New("invalid status update")
+)
+
+// DocumentStatusUpdate is one item of a bulk document status update
+type DocumentStatusUpdate struct {
+	DocumentID string
+	Status     string
+	Notes      string
+}
+
+// DocumentStatusUpdateResult reports what happened to one bulk update item
+type DocumentStatusUpdateResult struct {
+	DocumentID string
+	Status     domain.DocumentStatus
+	Updated    bool
+	Error      string
+}
+
+// documentStatusStore is the subset of DocumentRepository used to change
+// document statuses within a transaction
+type documentStatusStore interface {
+	UpdateStatus(ctx context.Context, id uuid.UUID, status model.DocumentStatus, notes string, updatedBy uuid.UUID) error
+}
+
+// documentStatusTransactor runs fn against a documentStatusStore within one transaction
+type documentStatusTransactor interface {
+	WithinTransaction(ctx context.Context, fn func(store documentStatusStore) error) error
+}
+
+// documentRepositoryTransactor adapts DocumentRepository to documentStatusTransactor
+type documentRepositoryTransactor struct {
+	repo *repository.DocumentRepository
+}
+
+// WithinTransaction implements documentStatusTransactor
+func (t documentRepositoryTransactor) WithinTransaction(ctx context.Context, fn func(store documentStatusStore) error) error {
+	return t.repo.WithTransaction(ctx, func(docRepo *repository.DocumentRepository, _ *repository.VerificationRepository) error {
+		return fn(docRepo)
+	})
+}
+
+// BulkUpdateStatus applies a batch of document status changes in one
+// transaction and reports the outcome of every item. Each change is recorded in
+// the document history under verifierID. An item with an unknown document or
+// status is reported as not updated and does not stop the others; only a
+// database failure rolls back the batch, and is returned as an error.
+func (s *DocumentService) BulkUpdateStatus(ctx context.Context, updates []DocumentStatusUpdate, verifierID uuid.UUID) ([]DocumentStatusUpdateResult, error) {
+	if len(updates) > MaxBulkStatusUpdates {
+		return nil, ErrBulkUpdateTooLarge
+	}
+
+	results := make([]DocumentStatusUpdateResult, len(updates))
+	err := s.statusUpdates.WithinTransaction(ctx, func(store documentStatusStore) error {
+		for i, update := range updates {
+			results[i] = DocumentStatusUpdateResult{DocumentID: update.DocumentID}
+
+			status, err := applyStatusUpdate(ctx, store, update, verifierID)
+			if err != nil {
+				// Anything but a problem with the item itself rolls back the batch
+				if !errors.Is(err, ErrInvalidStatusUpdate) {
+					return err
+				}
+				results[i].Error = err.Error()
+				continue
+			}
+
+			results[i].Status = status
+			results[i].Updated = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update document statuses: %w", err)
+	}
+	return results, nil
+}
+
+// applyStatusUpdate validates update and moves its document to the new status
+func applyStatusUpdate(ctx context.Context, store documentStatusStore, update DocumentStatusUpdate, verifierID uuid.UUID) (domain.DocumentStatus, error) {
+	id, err := uuid.Parse(update.DocumentID)
+	if err != nil {
+		return "", fmt.Errorf("%w: document ID %q is not a UUID", ErrInvalidStatusUpdate, update.DocumentID)
+	}
+
+	status := domain.DocumentStatus(strings.ToUpper(update.Status))
+	if !status.Valid() {
+		return "", fmt.Errorf("%w: unknown status %q", ErrInvalidStatusUpdate, update.Status)
+	}
+
+	err = store.UpdateStatus(ctx, id, model.DocumentStatus(strings.ToLower(string(status))), update.Notes, verifierID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("%w: document %s not found", ErrInvalidStatusUpdate, id)
+	}
+	return status, err
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sparkfund/services/kyc-service/internal/domain"
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// statusChange is a status update recorded by memoryStatusStore
+type statusChange struct {
+	status    model.DocumentStatus
+	notes     string
+	updatedBy uuid.UUID
+}
+
+// memoryStatusStore is an in-memory documentStatusTransactor. Changes made in a
+// transaction are only kept if it commits.
+type memoryStatusStore struct {
+	statuses map[uuid.UUID]model.DocumentStatus
+	history  map[uuid.UUID][]statusChange
+	failOn   uuid.UUID
+
+	pending []func()
+}
+
+func newMemoryStatusStore(ids ...uuid.UUID) *memoryStatusStore {
+	store := &memoryStatusStore{
+		statuses: make(map[uuid.UUID]model.DocumentStatus),
+		history:  make(map[uuid.UUID][]statusChange),
+	}
+	for _, id := range ids {
+		store.statuses[id] = model.DocumentStatusPending
+	}
+	return store
+}
+
+func (m *memoryStatusStore) WithinTransaction(ctx context.Context, fn func(store documentStatusStore) error) error {
+	m.pending = nil
+	if err := fn(m); err != nil {
+		return err
+	}
+	for _, apply := range m.pending {
+		apply()
+	}
+	return nil
+}
+
+func (m *memoryStatusStore) UpdateStatus(ctx context.Context, id uuid.UUID, status model.DocumentStatus, notes string, updatedBy uuid.UUID) error {
+	if id == m.failOn {
+		return errors.New("connection reset by peer")
+	}
+	if _, ok := m.statuses[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	m.pending = append(m.pending, func() {
+		m.statuses[id] = status
+		m.history[id] = append(m.history[id], statusChange{status: status, notes: notes, updatedBy: updatedBy})
+	})
+	return nil
+}
+
+func TestBulkUpdateStatus_ReportsEachItem(t *testing.T) {
+	verified, rejected, missing := uuid.New(), uuid.New(), uuid.New()
+	store := newMemoryStatusStore(verified, rejected)
+	svc := &DocumentService{statusUpdates: store}
+	reviewer := uuid.New()
+
+	results, err := svc.BulkUpdateStatus(context.Background(), []DocumentStatusUpdate{
+		{DocumentID: verified.String(), Status: "verified", Notes: "matches"},
+		{DocumentID: rejected.String(), Status: "REJECTED", Notes: "blurry"},
+		{DocumentID: missing.String(), Status: "verified"},
+		{DocumentID: "not-a-uuid", Status: "verified"},
+		{DocumentID: verified.String(), Status: "approved"},
+	}, reviewer)
+	if err != nil {
+		t.Fatalf("BulkUpdateStatus: %v", err)
+	}
+
+	want := []bool{true, true, false, false, false}
+	for i, result := range results {
+		if result.Updated != want[i] {
+			t.Fatalf("item %d: updated = %v, want %v (error %q)", i, result.Updated, want[i], result.Error)
+		}
+		if !result.Updated && result.Error == "" {
+			t.Fatalf("item %d: expected an error explaining the failure", i)
+		}
+	}
+	if results[1].Status != domain.DocStatusRejected {
+		t.Fatalf("status = %q, want %q", results[1].Status, domain.DocStatusRejected)
+	}
+
+	if got := store.statuses[verified]; got != model.DocumentStatusVerified {
+		t.Fatalf("verified document status = %q", got)
+	}
+	if got := store.statuses[rejected]; got != model.DocumentStatusRejected {
+		t.Fatalf("rejected document status = %q", got)
+	}
+	history := store.history[rejected]
+	if len(history) != 1 || history[0].notes != "blurry" || history[0].updatedBy != reviewer {
+		t.Fatalf("unexpected history for the rejected document: %+v", history)
+	}
+}
+
+func TestBulkUpdateStatus_RollsBackOnDatabaseFailure(t *testing.T) {
+	first, broken := uuid.New(), uuid.New()
+	store := newMemoryStatusStore(first, broken)
+	store.failOn = broken
+	svc := &DocumentService{statusUpdates: store}
+
+	_, err := svc.BulkUpdateStatus(context.Background(), []DocumentStatusUpdate{
+		{DocumentID: first.String(), Status: "verified"},
+		{DocumentID: broken.String(), Status: "verified"},
+	}, uuid.New())
+	if err == nil {
+		t.Fatal("expected the database failure to be returned")
+	}
+	if got := store.statuses[first]; got != model.DocumentStatusPending {
+		t.Fatalf("expected the batch to roll back, first document is %q", got)
+	}
+}
+
+func TestBulkUpdateStatus_RejectsOversizedBatch(t *testing.T) {
+	svc := &DocumentService{statusUpdates: newMemoryStatusStore()}
+
+	updates := make([]DocumentStatusUpdate, MaxBulkStatusUpdates+1)
+	if _, err := svc.BulkUpdateStatus(context.Background(), updates, uuid.New()); !errors.Is(err, ErrBulkUpdateTooLarge) {
+		t.Fatalf("expected ErrBulkUpdateTooLarge, got %v", err)
+	}
+}
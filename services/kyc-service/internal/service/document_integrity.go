@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrDuplicateDocument is returned, wrapped in a *DuplicateDocumentError,
+	// when a user uploads content they have already uploaded
+	ErrDuplicateDocument = errors.New("duplicate document")
+	// ErrStorageNotConfigured is returned when an operation needs the document
+	// store and none is set
+	ErrStorageNotConfigured = errors.New("document storage is not configured")
+)
+
+// DuplicateDocumentError identifies the existing document an upload duplicates.
+// It matches ErrDuplicateDocument with errors.Is.
+type DuplicateDocumentError struct {
+	DocumentID uuid.UUID
+}
+
+func (e *DuplicateDocumentError) Error() string {
+	return fmt.Sprintf("duplicate of document %s", e.DocumentID)
+}
+
+func (e *DuplicateDocumentError) Unwrap() error {
+	return ErrDuplicateDocument
+}
+
+// IntegrityResult is the outcome of re-hashing a stored document
+type IntegrityResult struct {
+	DocumentID   uuid.UUID
+	Intact       bool
+	ExpectedHash string
+	ActualHash   string
+	CheckedAt    time.Time
+}
+
+// checkDuplicate returns a *DuplicateDocumentError if userID already has a
+// document with the content hash fileHash
+func (s *DocumentService) checkDuplicate(ctx context.Context, userID uuid.UUID, fileHash string) error {
+	existing, err := s.records.FindByHash(ctx, userID, fileHash)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicate documents: %w", err)
+	}
+	if existing != nil {
+		return &DuplicateDocumentError{DocumentID: existing.ID}
+	}
+	return nil
+}
+
+// VerifyIntegrity re-reads a document's stored file and checks it still has
+// the content hash recorded at upload, proving it was not altered since.
+// Quarantined documents are held outside the document store and return
+// ErrDocumentQuarantined.
+func (s *DocumentService) VerifyIntegrity(ctx context.Context, id uuid.UUID) (*IntegrityResult, error) {
+	if s.storage == nil {
+		return nil, ErrStorageNotConfigured
+	}
+
+	doc, err := s.records.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status == model.DocumentStatusQuarantined {
+		return nil, ErrDocumentQuarantined
+	}
+
+	content, err := s.storage.Get(ctx, doc.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	actual := base64.URLEncoding.EncodeToString(hash.Sum(nil))
+
+	result := &IntegrityResult{
+		DocumentID:   doc.ID,
+		Intact:       subtle.ConstantTimeCompare([]byte(actual), []byte(doc.FileHash)) == 1,
+		ExpectedHash: doc.FileHash,
+		ActualHash:   actual,
+		CheckedAt:    time.Now(),
+	}
+	if !result.Intact {
+		logrus.WithFields(logrus.Fields{
+			"document_id":   doc.ID,
+			"expected_hash": doc.FileHash,
+			"actual_hash":   actual,
+		}).Warn("Stored document does not match its content hash")
+	}
+	return result, nil
+}
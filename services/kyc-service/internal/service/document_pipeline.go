@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/metrics"
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrPipelineFull is returned when the processing queue has no room for another document
+	ErrPipelineFull = errors.New("document processing queue is full")
+	// ErrPipelineClosed is returned once the pipeline has begun shutting down
+	ErrPipelineClosed = errors.New("document processing pipeline is shut down")
+)
+
+// DocumentProcessingStep is one stage of document processing, e.g. OCR, face
+// matching or thumbnail generation. Steps load the content they need themselves,
+// so queued documents hold no file data.
+type DocumentProcessingStep struct {
+	Name    string
+	Process func(ctx context.Context, doc *model.Document) error
+}
+
+// PipelineConfig holds the document processing pipeline's limits
+type PipelineConfig struct {
+	// Workers is how many documents are processed at once
+	Workers int
+	// QueueDepth is how many accepted documents may wait for a worker; uploads
+	// beyond it are refused with ErrPipelineFull
+	QueueDepth int
+	// StepTimeout bounds a single processing step
+	StepTimeout time.Duration
+}
+
+// DefaultPipelineConfig returns the default pipeline limits
+func DefaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		Workers:     4,
+		QueueDepth:  100,
+		StepTimeout: 2 * time.Minute,
+	}
+}
+
+// documentProcessingStore is the subset of DocumentRepository used by the pipeline
+type documentProcessingStore interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Document, error)
+	Update(ctx context.Context, doc *model.Document) error
+}
+
+// DocumentPipeline runs the processing steps for uploaded documents on a fixed
+// pool of workers fed by a bounded queue, so upload latency does not depend on
+// processing cost and bursts cannot exhaust the service.
+type DocumentPipeline struct {
+	store  documentProcessingStore
+	config PipelineConfig
+	steps  []DocumentProcessingStep
+	logger *logrus.Logger
+
+	// slots holds one token per queued or reserved document
+	slots chan struct{}
+	queue chan uuid.UUID
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewDocumentPipeline creates a pipeline and starts its workers. A nil logger
+// logs to the standard logrus logger.
+func NewDocumentPipeline(store documentProcessingStore, config PipelineConfig, logger *logrus.Logger, steps ...DocumentProcessingStep) *DocumentPipeline {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.QueueDepth < 1 {
+		config.QueueDepth = 1
+	}
+
+	p := &DocumentPipeline{
+		store:  store,
+		config: config,
+		steps:  steps,
+		logger: logger,
+		slots:  make(chan struct{}, config.QueueDepth),
+		queue:  make(chan uuid.UUID, config.QueueDepth),
+	}
+	for i := 0; i < config.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// PipelineTicket is a reserved place in the processing queue
+type PipelineTicket struct {
+	pipeline *DocumentPipeline
+	once     sync.Once
+}
+
+// Reserve claims a place in the queue before a document is accepted, failing
+// fast with ErrPipelineFull when the queue is at capacity. The ticket must be
+// either submitted or released.
+func (p *DocumentPipeline) Reserve() (*PipelineTicket, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPipelineClosed
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		metrics.DocumentProcessingQueueDepth.Inc()
+		return &PipelineTicket{pipeline: p}, nil
+	default:
+		metrics.DocumentProcessingRejected.Inc()
+		return nil, ErrPipelineFull
+	}
+}
+
+// Submit queues the document for processing. A document submitted after
+// shutdown began is left unprocessed.
+func (t *PipelineTicket) Submit(id uuid.UUID) {
+	t.once.Do(func() {
+		p := t.pipeline
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		if p.closed {
+			p.logger.WithField("document_id", id).Warn("Document not processed: pipeline is shut down")
+			p.release()
+			return
+		}
+		// Never blocks: the reserved slot guarantees room in the queue
+		p.queue <- id
+	})
+}
+
+// Release gives up the reservation if the document was never submitted
+func (t *PipelineTicket) Release() {
+	t.once.Do(t.pipeline.release)
+}
+
+// release frees a queue slot
+func (p *DocumentPipeline) release() {
+	<-p.slots
+	metrics.DocumentProcessingQueueDepth.Dec()
+}
+
+// Shutdown stops accepting documents and waits until every queued document has
+// been processed or ctx ends
+func (p *DocumentPipeline) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work processes queued documents until the queue is closed and drained
+func (p *DocumentPipeline) work() {
+	defer p.wg.Done()
+	for id := range p.queue {
+		p.release()
+		p.process(id)
+	}
+}
+
+// process runs every step for a document and records the outcome in its metadata
+func (p *DocumentPipeline) process(id uuid.UUID) {
+	ctx := context.Background()
+	start := time.Now()
+
+	doc, err := p.store.GetByID(ctx, id)
+	if err != nil {
+		p.logger.WithError(err).WithField("document_id", id).Error("Document not processed")
+		return
+	}
+
+	outcome := map[string]interface{}{"started_at": start}
+	completed := make([]string, 0, len(p.steps))
+	for _, step := range p.steps {
+		stepCtx, cancel := context.WithTimeout(ctx, p.config.StepTimeout)
+		err := step.Process(stepCtx, doc)
+		cancel()
+		if err != nil {
+			outcome["failed_step"] = step.Name
+			outcome["error"] = err.Error()
+			break
+		}
+		completed = append(completed, step.Name)
+	}
+	outcome["completed_steps"] = completed
+	outcome["finished_at"] = time.Now()
+
+	status := "completed"
+	if _, failed := outcome["failed_step"]; failed {
+		status = "failed"
+	}
+	metrics.DocumentProcessingDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	doc.Metadata["processing"] = outcome
+	if err := p.store.Update(ctx, doc); err != nil {
+		p.logger.WithError(err).WithField("document_id", id).Error("Failed to record document processing")
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryDocumentStore keeps documents in memory for pipeline tests
+type memoryDocumentStore struct {
+	mu   sync.Mutex
+	docs map[uuid.UUID]*model.Document
+}
+
+func newMemoryDocumentStore() *memoryDocumentStore {
+	return &memoryDocumentStore{docs: make(map[uuid.UUID]*model.Document)}
+}
+
+func (s *memoryDocumentStore) add() uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.New()
+	s.docs[id] = &model.Document{ID: id}
+	return id
+}
+
+func (s *memoryDocumentStore) GetByID(ctx context.Context, id uuid.UUID) (*model.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.docs[id]
+	if !ok {
+		return nil, errors.New("document not found")
+	}
+	copied := *doc
+	return &copied, nil
+}
+
+func (s *memoryDocumentStore) Update(ctx context.Context, doc *model.Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[doc.ID] = doc
+	return nil
+}
+
+// processing returns the outcome recorded for a document, or nil if it was never processed
+func (s *memoryDocumentStore) processing(id uuid.UUID) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	outcome, _ := s.docs[id].Metadata["processing"].(map[string]interface{})
+	return outcome
+}
+
+// gatedStep blocks every document until release is closed and tracks how many run at once
+type gatedStep struct {
+	release   chan struct{}
+	running   int32
+	peak      int32
+	processed int32
+}
+
+func newGatedStep() *gatedStep {
+	return &gatedStep{release: make(chan struct{})}
+}
+
+func (s *gatedStep) step() DocumentProcessingStep {
+	return DocumentProcessingStep{
+		Name: "ocr",
+		Process: func(ctx context.Context, doc *model.Document) error {
+			running := atomic.AddInt32(&s.running, 1)
+			for {
+				peak := atomic.LoadInt32(&s.peak)
+				if running <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, running) {
+					break
+				}
+			}
+			defer atomic.AddInt32(&s.running, -1)
+
+			select {
+			case <-s.release:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			atomic.AddInt32(&s.processed, 1)
+			return nil
+		},
+	}
+}
+
+func newTestPipeline(store documentProcessingStore, workers, depth int, steps ...DocumentProcessingStep) *DocumentPipeline {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewDocumentPipeline(store, PipelineConfig{
+		Workers:     workers,
+		QueueDepth:  depth,
+		StepTimeout: 5 * time.Second,
+	}, logger, steps...)
+}
+
+func shutdown(t *testing.T, p *DocumentPipeline) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("pipeline did not drain: %v", err)
+	}
+}
+
+func TestDocumentPipeline_BurstStaysWithinBounds(t *testing.T) {
+	const workers, depth, burst = 3, 10, 100
+
+	store := newMemoryDocumentStore()
+	gate := newGatedStep()
+	p := newTestPipeline(store, workers, depth, gate.step())
+
+	var accepted []uuid.UUID
+	rejected := 0
+	for i := 0; i < burst; i++ {
+		ticket, err := p.Reserve()
+		if errors.Is(err, ErrPipelineFull) {
+			rejected++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected reserve error: %v", err)
+		}
+		id := store.add()
+		ticket.Submit(id)
+		accepted = append(accepted, id)
+	}
+
+	// Workers hold their documents, so at most workers+depth can be in flight
+	if len(accepted) > workers+depth {
+		t.Fatalf("accepted %d documents, want at most %d", len(accepted), workers+depth)
+	}
+	if rejected != burst-len(accepted) {
+		t.Fatalf("rejected %d documents, want %d", rejected, burst-len(accepted))
+	}
+
+	close(gate.release)
+	shutdown(t, p)
+
+	if peak := atomic.LoadInt32(&gate.peak); peak > workers {
+		t.Fatalf("%d documents processed at once, want at most %d", peak, workers)
+	}
+	if processed := atomic.LoadInt32(&gate.processed); int(processed) != len(accepted) {
+		t.Fatalf("processed %d documents, want %d", processed, len(accepted))
+	}
+	for _, id := range accepted {
+		outcome := store.processing(id)
+		if outcome == nil {
+			t.Fatalf("document %s has no processing outcome", id)
+		}
+		if _, failed := outcome["failed_step"]; failed {
+			t.Fatalf("document %s failed: %v", id, outcome["error"])
+		}
+	}
+}
+
+func TestDocumentPipeline_ReserveFailsFastWhenFull(t *testing.T) {
+	store := newMemoryDocumentStore()
+	gate := newGatedStep()
+	p := newTestPipeline(store, 1, 2, gate.step())
+	defer func() {
+		close(gate.release)
+		shutdown(t, p)
+	}()
+
+	// Unsubmitted reservations hold their slots
+	first, err := p.Reserve()
+	if err != nil {
+		t.Fatalf("unexpected reserve error: %v", err)
+	}
+	if _, err := p.Reserve(); err != nil {
+		t.Fatalf("unexpected reserve error: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := p.Reserve(); !errors.Is(err, ErrPipelineFull) {
+		t.Fatalf("expected ErrPipelineFull, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("expected a full queue to refuse immediately, took %v", elapsed)
+	}
+
+	// Releasing a reservation makes room again
+	first.Release()
+	first.Release()
+	if _, err := p.Reserve(); err != nil {
+		t.Fatalf("expected room after release, got %v", err)
+	}
+}
+
+func TestDocumentPipeline_RecordsFailedStep(t *testing.T) {
+	store := newMemoryDocumentStore()
+	var thumbnails int32
+	p := newTestPipeline(store, 1, 1,
+		DocumentProcessingStep{Name: "ocr", Process: func(ctx context.Context, doc *model.Document) error {
+			return errors.New("unreadable image")
+		}},
+		DocumentProcessingStep{Name: "thumbnail", Process: func(ctx context.Context, doc *model.Document) error {
+			atomic.AddInt32(&thumbnails, 1)
+			return nil
+		}},
+	)
+
+	ticket, err := p.Reserve()
+	if err != nil {
+		t.Fatalf("unexpected reserve error: %v", err)
+	}
+	id := store.add()
+	ticket.Submit(id)
+	shutdown(t, p)
+
+	outcome := store.processing(id)
+	if outcome["failed_step"] != "ocr" || outcome["error"] != "unreadable image" {
+		t.Fatalf("unexpected processing outcome: %v", outcome)
+	}
+	if atomic.LoadInt32(&thumbnails) != 0 {
+		t.Fatal("expected steps after a failure to be skipped")
+	}
+}
+
+func TestDocumentPipeline_ShutdownDrainsQueue(t *testing.T) {
+	store := newMemoryDocumentStore()
+	gate := newGatedStep()
+	p := newTestPipeline(store, 2, 5, gate.step())
+
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		ticket, err := p.Reserve()
+		if err != nil {
+			t.Fatalf("unexpected reserve error: %v", err)
+		}
+		id := store.add()
+		ticket.Submit(id)
+		ids = append(ids, id)
+	}
+
+	// Shutdown waits for the held documents, so it times out until they are let through
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected shutdown to wait for queued documents, got %v", err)
+	}
+
+	if _, err := p.Reserve(); !errors.Is(err, ErrPipelineClosed) {
+		t.Fatalf("expected ErrPipelineClosed after shutdown, got %v", err)
+	}
+
+	close(gate.release)
+	shutdown(t, p)
+
+	for _, id := range ids {
+		if store.processing(id) == nil {
+			t.Fatalf("queued document %s was not processed before shutdown", id)
+		}
+	}
+}
+
+func TestDocumentPipeline_SubmitAfterShutdownIsDropped(t *testing.T) {
+	store := newMemoryDocumentStore()
+	p := newTestPipeline(store, 1, 1)
+
+	ticket, err := p.Reserve()
+	if err != nil {
+		t.Fatalf("unexpected reserve error: %v", err)
+	}
+	shutdown(t, p)
+
+	id := store.add()
+	ticket.Submit(id)
+	if store.processing(id) != nil {
+		t.Fatal("expected a document submitted after shutdown to be left unprocessed")
+	}
+	if len(p.slots) != 0 {
+		t.Fatalf("expected the reservation to be released, %d slots held", len(p.slots))
+	}
+}
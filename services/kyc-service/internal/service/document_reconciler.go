@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReconcilerConfig holds the orphaned file reconciler's settings
+type ReconcilerConfig struct {
+	// Interval is how often the document store is swept
+	Interval time.Duration
+	// GracePeriod skips files younger than this. An upload stores its file before
+	// the record is committed, so a new file may not be referenced yet.
+	GracePeriod time.Duration
+	// BatchSize is how many paths are looked up per query
+	BatchSize int
+}
+
+// DefaultReconcilerConfig returns the default reconciler settings
+func DefaultReconcilerConfig() ReconcilerConfig {
+	return ReconcilerConfig{
+		Interval:    time.Hour,
+		GracePeriod: time.Hour,
+		BatchSize:   500,
+	}
+}
+
+// documentReferenceStore is the subset of DocumentRepository used by the reconciler
+type documentReferenceStore interface {
+	ReferencedFilePaths(ctx context.Context, paths []string) (map[string]bool, error)
+}
+
+// OrphanReconciler periodically removes stored files that no live document
+// references. It catches what compensation cannot: files left when cleanup
+// itself failed, files of deleted documents and files replaced by quarantine.
+type OrphanReconciler struct {
+	storage ListableStorage
+	store   documentReferenceStore
+	prefix  string
+	config  ReconcilerConfig
+	logger  *logrus.Logger
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewOrphanReconciler creates a reconciler for the files under prefix. A nil
+// logger logs to the standard logrus logger.
+func NewOrphanReconciler(storage ListableStorage, store documentReferenceStore, prefix string, config ReconcilerConfig, logger *logrus.Logger) *OrphanReconciler {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	defaults := DefaultReconcilerConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = defaults.BatchSize
+	}
+
+	return &OrphanReconciler{
+		storage: storage,
+		store:   store,
+		prefix:  prefix,
+		config:  config,
+		logger:  logger,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start sweeps the store every Interval until Stop is called
+func (r *OrphanReconciler) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), r.config.Interval)
+				if _, err := r.Reconcile(ctx); err != nil {
+					r.logger.WithError(err).Error("Orphaned document file reconciliation failed")
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic sweep and waits for a running sweep to finish
+func (r *OrphanReconciler) Stop() {
+	r.once.Do(func() { close(r.stop) })
+	r.wg.Wait()
+}
+
+// Reconcile removes every file older than the grace period that no live
+// document references, returning how many were removed. Files that fail to
+// delete are logged and retried on the next sweep.
+func (r *OrphanReconciler) Reconcile(ctx context.Context) (int, error) {
+	objects, err := r.storage.List(ctx, r.prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-r.config.GracePeriod)
+	var candidates []string
+	for _, object := range objects {
+		if object.ModifiedAt.Before(cutoff) {
+			candidates = append(candidates, object.Path)
+		}
+	}
+
+	removed := 0
+	for start := 0; start < len(candidates); start += r.config.BatchSize {
+		end := start + r.config.BatchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		batch := candidates[start:end]
+
+		referenced, err := r.store.ReferencedFilePaths(ctx, batch)
+		if err != nil {
+			return removed, err
+		}
+
+		for _, path := range batch {
+			if referenced[path] {
+				continue
+			}
+			if err := r.storage.Delete(ctx, path); err != nil {
+				r.logger.WithError(err).WithField("path", path).Warn("Failed to remove orphaned document file")
+				continue
+			}
+			removed++
+			metrics.DocumentOrphansRemoved.Inc()
+		}
+	}
+
+	if removed > 0 {
+		r.logger.WithField("removed", removed).Info("Removed orphaned document files")
+	}
+	return removed, nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
@@ -8,7 +9,8 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
-	"path/filepath"
+	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,21 +20,83 @@ import (
 	"sparkfund/services/kyc-service/internal/repository"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 // DocumentService handles business logic for document operations
 type DocumentService struct {
 	docRepo   *repository.DocumentRepository
+	records   documentRecordStore
 	verRepo   *repository.VerificationRepository
-	uploadDir string
+	scanner   *VirusScanner
+	storage   StorageService
+	pipeline  *DocumentPipeline
+	downloads *DownloadURLSigner
+
+	statusUpdates documentStatusTransactor
+	ocr           OCRExtractor
+	ocrConfig     OCRConfig
+
+	maxFileSize  int64
+	allowedTypes map[string]bool
 }
 
-// NewDocumentService creates a new document service
-func NewDocumentService(docRepo *repository.DocumentRepository, verRepo *repository.VerificationRepository, uploadDir string) *DocumentService {
+// DocumentKeyPrefix is the storage path prefix document content is kept under
+const DocumentKeyPrefix = "documents/"
+
+// NewDocumentService creates a new document service. Uploaded content is only
+// kept once a store is set with SetStorage.
+func NewDocumentService(docRepo *repository.DocumentRepository, verRepo *repository.VerificationRepository) *DocumentService {
 	return &DocumentService{
-		docRepo:   docRepo,
-		verRepo:   verRepo,
-		uploadDir: uploadDir,
+		docRepo:       docRepo,
+		records:       docRepo,
+		verRepo:       verRepo,
+		statusUpdates: documentRepositoryTransactor{repo: docRepo},
+	}
+}
+
+// documentRecordStore is the subset of DocumentRepository used to keep document
+// records and their stored files in step
+type documentRecordStore interface {
+	Create(ctx context.Context, doc *model.Document) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Document, error)
+	Update(ctx context.Context, doc *model.Document) error
+	AddHistoryEntry(ctx context.Context, entry *model.DocumentHistory) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ReferencedFilePaths(ctx context.Context, paths []string) (map[string]bool, error)
+	FindByHash(ctx context.Context, userID uuid.UUID, fileHash string) (*model.Document, error)
+}
+
+// SetScanner enables virus scanning of uploaded documents
+func (s *DocumentService) SetScanner(scanner *VirusScanner) {
+	s.scanner = scanner
+}
+
+// SetStorage sets the file store used to persist uploaded document content,
+// such as a LocalStorage or S3Storage
+func (s *DocumentService) SetStorage(storage StorageService) {
+	s.storage = storage
+}
+
+// SetDownloadURLs enables signed download URLs for document content
+func (s *DocumentService) SetDownloadURLs(signer *DownloadURLSigner) {
+	s.downloads = signer
+}
+
+// SetPipeline queues accepted documents for asynchronous processing. Uploads
+// are refused with ErrPipelineFull while the pipeline's queue is full.
+func (s *DocumentService) SetPipeline(pipeline *DocumentPipeline) {
+	s.pipeline = pipeline
+}
+
+// SetFileLimits sets the largest upload accepted, in bytes, and the content
+// types allowed. A zero maxSize or empty allowedTypes keeps the default,
+// DefaultMaxFileSize or DefaultAllowedMimeTypes.
+func (s *DocumentService) SetFileLimits(maxSize int64, allowedTypes []string) {
+	s.maxFileSize = maxSize
+	s.allowedTypes = nil
+	if len(allowedTypes) > 0 {
+		s.allowedTypes = mimeTypeSet(allowedTypes)
 	}
 }
 
@@ -49,28 +113,107 @@ func (s *DocumentService) UploadDocument(ctx context.Context, userID uuid.UUID,
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// Trust the content, not the client's Content-Type or file name
+	mimeType, err := s.validateContent(fileData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file: %w", err)
+	}
+	fileName := sanitizeFileName(file.Filename)
+
 	// Calculate file hash
 	fileHash := s.calculateFileHash(fileData)
 
+	// The same content uploaded twice is the same document
+	if err := s.checkDuplicate(ctx, userID, fileHash); err != nil {
+		return nil, err
+	}
+
+	// Refuse the upload before saving anything if it could not be processed
+	var ticket *PipelineTicket
+	if s.pipeline != nil {
+		ticket, err = s.pipeline.Reserve()
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if ticket != nil {
+				ticket.Release()
+			}
+		}()
+	}
+
 	// Create document record
+	id := uuid.New()
 	doc := &model.Document{
-		ID:        uuid.New(),
+		ID:        id,
 		UserID:    userID,
 		Type:      model.DocumentType(docType),
 		Status:    model.DocumentStatusPending,
-		FileName:  file.Filename,
-		FileSize:  file.Size,
-		MimeType:  file.Header.Get("Content-Type"),
+		FileName:  fileName,
+		FileSize:  int64(len(fileData)),
+		MimeType:  mimeType,
 		FileHash:  fileHash,
-		FilePath:  filepath.Join(s.uploadDir, fileHash),
+		FilePath:  s.filePath(id),
 		Metadata:  metadata,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+
+	// Hold the document in the scanning state until the background scan clears it
+	if s.scanner != nil && s.scanner.Async() {
+		doc.Status = model.DocumentStatusScanning
+		if err := s.saveDocument(ctx, doc, fileData); err != nil {
+			return nil, err
+		}
+
+		// The background scan takes over the reservation
+		go s.scanInBackground(doc.ID, fileName, fileHash, fileData, ticket)
+		ticket = nil
+
+		return mapper.DocumentModelToDomain(doc), nil
+	}
+
+	// Scan before the document is accepted
+	var scanErr error
+	if s.scanner != nil {
+		var result *ScanResult
+		result, scanErr = s.scanner.Check(ctx, fileName, fileData)
+		doc.Metadata["virus_scan"] = result.toMetadata()
+
+		switch {
+		case errors.Is(scanErr, ErrInfectedFile):
+			// Keep a record of the infected upload but never store it in the upload directory
+			quarantinePath, err := s.scanner.Quarantine(fileHash, fileData)
+			if err != nil {
+				return nil, err
+			}
+			doc.Status = model.DocumentStatusQuarantined
+			doc.FilePath = quarantinePath
+			doc.RejectionReason = result.Signature
+		case scanErr != nil:
+			return nil, scanErr
+		}
+	}
 
 	// Save document
-	if err := s.docRepo.Create(ctx, doc); err != nil {
-		return nil, fmt.Errorf("failed to save document: %w", err)
+	if doc.Status == model.DocumentStatusQuarantined {
+		// The file is held in quarantine, not the document store
+		if err := s.records.Create(ctx, doc); err != nil {
+			return nil, fmt.Errorf("failed to save document: %w", err)
+		}
+	} else if err := s.saveDocument(ctx, doc, fileData); err != nil {
+		return nil, err
+	}
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	if ticket != nil {
+		ticket.Submit(doc.ID)
 	}
 
 	// Convert to domain model
@@ -79,6 +222,204 @@ func (s *DocumentService) UploadDocument(ctx context.Context, userID uuid.UUID,
 	return domainDoc, nil
 }
 
+// UploadDocumentWithVerification stores an uploaded document and creates its pending
+// verification in one database transaction. The file store is not transactional, so
+// the stored file is deleted again if the transaction rolls back.
+func (s *DocumentService) UploadDocumentWithVerification(ctx context.Context, userID uuid.UUID, file *multipart.FileHeader, docType string, metadata map[string]interface{}, method domain.VerificationMethod) (*domain.EnhancedDocument, *domain.EnhancedVerification, error) {
+	if s.storage == nil {
+		return nil, nil, ErrStorageNotConfigured
+	}
+
+	// Validate file
+	if err := s.validateFile(file); err != nil {
+		return nil, nil, fmt.Errorf("invalid file: %w", err)
+	}
+
+	// Read file data
+	fileData, err := s.readFileData(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Trust the content, not the client's Content-Type or file name
+	mimeType, err := s.validateContent(fileData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid file: %w", err)
+	}
+	fileName := sanitizeFileName(file.Filename)
+
+	// Calculate file hash
+	fileHash := s.calculateFileHash(fileData)
+
+	// The same content uploaded twice is the same document
+	if err := s.checkDuplicate(ctx, userID, fileHash); err != nil {
+		return nil, nil, err
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+
+	// Infected files never reach the document store
+	if s.scanner != nil {
+		result, err := s.scanner.Check(ctx, fileName, fileData)
+		if errors.Is(err, ErrInfectedFile) {
+			if _, qerr := s.scanner.Quarantine(fileHash, fileData); qerr != nil {
+				return nil, nil, qerr
+			}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		metadata["virus_scan"] = result.toMetadata()
+	}
+
+	now := time.Now()
+	id := uuid.New()
+	doc := &model.Document{
+		ID:        id,
+		UserID:    userID,
+		Type:      model.DocumentType(docType),
+		Status:    model.DocumentStatusPending,
+		FileName:  fileName,
+		FileSize:  int64(len(fileData)),
+		MimeType:  mimeType,
+		FileHash:  fileHash,
+		FilePath:  s.filePath(id),
+		Metadata:  metadata,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Domain methods are upper case, stored methods lower case
+	storedMethod := model.VerificationMethod(strings.ToLower(string(method)))
+	verification := &model.Verification{
+		ID:         uuid.New(),
+		DocumentID: &doc.ID,
+		Type:       model.VerificationTypeDocument,
+		Status:     model.VerificationStatusPending,
+		Method:     storedMethod,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	// Both IDs are known up front, so each record is written once, already linked
+	doc.VerificationID = &verification.ID
+
+	err = s.storeWithCompensation(ctx, doc.FilePath, fileData, func() error {
+		return s.docRepo.WithTransaction(ctx, func(docRepo *repository.DocumentRepository, verRepo *repository.VerificationRepository) error {
+			if err := docRepo.Create(ctx, doc); err != nil {
+				return fmt.Errorf("failed to save document: %w", err)
+			}
+
+			if err := verRepo.Create(ctx, verification); err != nil {
+				return fmt.Errorf("failed to create verification: %w", err)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mapper.DocumentModelToDomain(doc), mapper.VerificationModelToDomain(verification), nil
+}
+
+// filePath returns where a document's content is stored. Every document gets an
+// object of its own, even for identical content, so cleaning up after one
+// document can never remove a file another document relies on.
+func (s *DocumentService) filePath(id uuid.UUID) string {
+	return DocumentKeyPrefix + id.String()
+}
+
+// saveDocument creates the document record, first storing its content when a
+// document store is configured. The stored file is removed again if the record
+// cannot be saved.
+func (s *DocumentService) saveDocument(ctx context.Context, doc *model.Document, data []byte) error {
+	create := func() error {
+		if err := s.records.Create(ctx, doc); err != nil {
+			return fmt.Errorf("failed to save document: %w", err)
+		}
+		return nil
+	}
+	if s.storage == nil {
+		return create()
+	}
+	return s.storeWithCompensation(ctx, doc.FilePath, data, create)
+}
+
+// storeWithCompensation writes the file and then runs persist. If persist fails the
+// stored file is deleted so no orphaned object is left behind.
+func (s *DocumentService) storeWithCompensation(ctx context.Context, path string, data []byte, persist func() error) error {
+	if err := s.storage.Store(ctx, path, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if err := persist(); err != nil {
+		// Use a fresh context so a cancelled request still cleans up
+		if delErr := s.storage.Delete(context.Background(), path); delErr != nil {
+			return fmt.Errorf("%w (cleanup of %s failed: %v)", err, path, delErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// scanInBackground scans a document held in the scanning state and records the
+// outcome: pending when clean, infected and quarantined when malware is found,
+// and rejected when the scan fails under a fail-closed policy. A document that
+// passes is submitted on ticket, if any, for processing.
+func (s *DocumentService) scanInBackground(id uuid.UUID, fileName, fileHash string, data []byte, ticket *PipelineTicket) {
+	ctx := context.Background()
+	if ticket != nil {
+		defer ticket.Release()
+	}
+
+	result, scanErr := s.scanner.Check(ctx, fileName, data)
+
+	doc, err := s.docRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+
+	status := model.DocumentStatusPending
+	notes := "virus scan passed"
+	switch {
+	case errors.Is(scanErr, ErrInfectedFile):
+		status = model.DocumentStatusInfected
+		notes = scanErr.Error()
+		// Move the file out of the document store so it cannot be served
+		if quarantinePath, err := s.scanner.Quarantine(fileHash, data); err == nil {
+			if s.storage != nil {
+				if err := s.storage.Delete(ctx, doc.FilePath); err != nil {
+					logrus.WithError(err).WithField("document_id", id).Warn("Failed to remove quarantined file from the document store")
+				}
+			}
+			doc.FilePath = quarantinePath
+		}
+	case scanErr != nil:
+		status = model.DocumentStatusRejected
+		notes = scanErr.Error()
+	}
+
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	doc.Metadata["virus_scan"] = result.toMetadata()
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return
+	}
+
+	if err := s.docRepo.UpdateStatus(ctx, id, status, notes, uuid.Nil); err != nil {
+		return
+	}
+
+	if ticket != nil && status == model.DocumentStatusPending {
+		ticket.Submit(id)
+	}
+}
+
 // GetDocument retrieves a document by ID
 func (s *DocumentService) GetDocument(ctx context.Context, id uuid.UUID) (*domain.EnhancedDocument, error) {
 	doc, err := s.docRepo.GetByID(ctx, id)
@@ -89,9 +430,71 @@ func (s *DocumentService) GetDocument(ctx context.Context, id uuid.UUID) (*domai
 	return mapper.DocumentModelToDomain(doc), nil
 }
 
-// ListDocuments retrieves documents for a user with pagination
-func (s *DocumentService) ListDocuments(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]*domain.EnhancedDocument, int64, error) {
-	docs, total, err := s.docRepo.GetByUserIDPaginated(ctx, userID, page, pageSize)
+// DownloadURL returns a time-limited link to a document's content. Storage
+// that presigns its own links, such as S3Storage, is used directly and needs
+// no download URL secret; otherwise the link points back at the service and
+// is signed with the secret. Documents that are quarantined or still being
+// scanned cannot be downloaded.
+func (s *DocumentService) DownloadURL(ctx context.Context, id uuid.UUID) (*DownloadURL, error) {
+	if s.storage == nil {
+		return nil, ErrDownloadsDisabled
+	}
+	presigner, presigns := s.storage.(PresigningStorage)
+	if !presigns && s.downloads == nil {
+		return nil, ErrDownloadsDisabled
+	}
+
+	doc, err := s.records.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScanned(doc); err != nil {
+		return nil, err
+	}
+
+	if presigns {
+		ttl := DefaultDownloadURLTTL
+		if s.downloads != nil {
+			ttl = s.downloads.TTL()
+		}
+		expiresAt := time.Now().Add(ttl)
+		link, err := presigner.PresignGet(ctx, doc.FilePath, ttl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to presign download: %w", err)
+		}
+		return &DownloadURL{URL: link, ExpiresAt: expiresAt}, nil
+	}
+	return s.downloads.URL(id), nil
+}
+
+// OpenDownload verifies a signed download URL and opens the document's content,
+// unless it was quarantined since the URL was minted. The caller must close the
+// returned reader.
+func (s *DocumentService) OpenDownload(ctx context.Context, id uuid.UUID, expires, signature string) (*model.Document, io.ReadCloser, error) {
+	if s.downloads == nil || s.storage == nil {
+		return nil, nil, ErrDownloadsDisabled
+	}
+	if err := s.downloads.Verify(id, expires, signature); err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := s.records.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkScanned(doc); err != nil {
+		return nil, nil, err
+	}
+	content, err := s.storage.Get(ctx, doc.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	return doc, content, nil
+}
+
+// ListDocuments retrieves a user's documents, filtered, sorted and paged by opts
+func (s *DocumentService) ListDocuments(ctx context.Context, userID uuid.UUID, opts domain.DocumentListOptions) ([]*domain.EnhancedDocument, int64, error) {
+	docs, total, err := s.docRepo.List(ctx, documentListOptions(opts, &userID))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -109,14 +512,37 @@ func (s *DocumentService) UpdateDocumentStatus(ctx context.Context, id uuid.UUID
 	return nil
 }
 
-// DeleteDocument soft deletes a document
+// DeleteDocument soft deletes a document and removes its stored file. The record
+// goes first: if removing the file then fails, the file is left for the orphan
+// reconciler instead of leaving a record whose file is gone.
 func (s *DocumentService) DeleteDocument(ctx context.Context, id uuid.UUID) error {
-	return s.docRepo.Delete(ctx, id)
+	if s.storage == nil {
+		return s.records.Delete(ctx, id)
+	}
+
+	doc, err := s.records.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.records.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Documents uploaded before files were stored per document may share a path
+	referenced, err := s.records.ReferencedFilePaths(ctx, []string{doc.FilePath})
+	if err == nil && !referenced[doc.FilePath] {
+		err = s.storage.Delete(ctx, doc.FilePath)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("path", doc.FilePath).
+			Warn("Failed to remove file of deleted document; leaving it for reconciliation")
+	}
+	return nil
 }
 
-// GetDocumentsByStatus retrieves documents by status with pagination
-func (s *DocumentService) GetDocumentsByStatus(ctx context.Context, status domain.DocumentStatus, page, pageSize int) ([]*domain.EnhancedDocument, int64, error) {
-	docs, total, err := s.docRepo.GetByType(ctx, model.DocumentType(status), page, pageSize)
+// GetDocumentsByStatus retrieves documents with opts.Status, sorted and paged by opts
+func (s *DocumentService) GetDocumentsByStatus(ctx context.Context, opts domain.DocumentListOptions) ([]*domain.EnhancedDocument, int64, error) {
+	docs, total, err := s.docRepo.List(ctx, documentListOptions(opts, nil))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -125,8 +551,10 @@ func (s *DocumentService) GetDocumentsByStatus(ctx context.Context, status domai
 }
 
 // GetDocumentsByDateRange retrieves documents by date range with pagination
-func (s *DocumentService) GetDocumentsByDateRange(ctx context.Context, startDate, endDate time.Time, page, pageSize int) ([]*domain.EnhancedDocument, int64, error) {
-	docs, total, err := s.docRepo.GetByDateRange(ctx, startDate, endDate, page, pageSize)
+func (s *DocumentService) GetDocumentsByDateRange(ctx context.Context, startDate, endDate time.Time, opts domain.DocumentListOptions) ([]*domain.EnhancedDocument, int64, error) {
+	listOptions := documentListOptions(opts, nil)
+	listOptions.From, listOptions.To = startDate, endDate
+	docs, total, err := s.docRepo.List(ctx, listOptions)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -134,6 +562,20 @@ func (s *DocumentService) GetDocumentsByDateRange(ctx context.Context, startDate
 	return mapper.DocumentModelsToDomains(docs), total, nil
 }
 
+// documentListOptions converts domain list options to repository ones, limited
+// to userID's documents if it is set
+func documentListOptions(opts domain.DocumentListOptions, userID *uuid.UUID) repository.DocumentListOptions {
+	return repository.DocumentListOptions{
+		UserID: userID,
+		// Domain statuses are upper case, stored statuses lower case
+		Status:     model.DocumentStatus(strings.ToLower(string(opts.Status))),
+		SortBy:     opts.SortBy,
+		Descending: opts.Descending,
+		Page:       opts.Page,
+		PageSize:   opts.PageSize,
+	}
+}
+
 // GetDocumentStats retrieves statistics about documents
 func (s *DocumentService) GetDocumentStats(ctx context.Context) (*domain.DocumentStats, error) {
 	stats, err := s.docRepo.GetStats(ctx)
@@ -163,30 +605,43 @@ func (s *DocumentService) GetDocumentStats(ctx context.Context) (*domain.Documen
 	return domainStats, nil
 }
 
-// validateFile validates the uploaded file
+// validateFile rejects uploads the client reports as over the size limit
+// before they are read
 func (s *DocumentService) validateFile(file *multipart.FileHeader) error {
-	// Check file size (max 10MB)
-	if file.Size > 10*1024*1024 {
-		return errors.New("file size exceeds 10MB limit")
+	if file.Size > s.fileSizeLimit() {
+		return s.fileTooLarge()
 	}
+	return nil
+}
 
-	// Check file type
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := map[string]bool{
-		".pdf":  true,
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
+// validateContent sniffs the type of an upload from its first bytes and
+// returns it if it is allowed
+func (s *DocumentService) validateContent(data []byte) (string, error) {
+	mimeType := http.DetectContentType(data)
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
 	}
 
-	if !allowedExts[ext] {
-		return errors.New("unsupported file type")
+	allowed := s.allowedTypes
+	if len(allowed) == 0 {
+		allowed = defaultAllowedTypes
 	}
-
-	return nil
+	if !allowed[mimeType] {
+		types := make([]string, 0, len(allowed))
+		for t := range allowed {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return "", &FileValidationError{
+			Err:    ErrUnsupportedFileType,
+			Reason: fmt.Sprintf("file content is %s; allowed types are %s", mimeType, strings.Join(types, ", ")),
+		}
+	}
+	return mimeType, nil
 }
 
-// readFileData reads the file data from the multipart file
+// readFileData reads the file data from the multipart file, refusing to read
+// past the size limit whatever size the client reported
 func (s *DocumentService) readFileData(file *multipart.FileHeader) ([]byte, error) {
 	src, err := file.Open()
 	if err != nil {
@@ -194,7 +649,30 @@ func (s *DocumentService) readFileData(file *multipart.FileHeader) ([]byte, erro
 	}
 	defer src.Close()
 
-	return io.ReadAll(src)
+	limit := s.fileSizeLimit()
+	data, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, s.fileTooLarge()
+	}
+	return data, nil
+}
+
+// fileSizeLimit returns the largest upload accepted
+func (s *DocumentService) fileSizeLimit() int64 {
+	if s.maxFileSize > 0 {
+		return s.maxFileSize
+	}
+	return DefaultMaxFileSize
+}
+
+func (s *DocumentService) fileTooLarge() error {
+	return &FileValidationError{
+		Err:    ErrFileTooLarge,
+		Reason: fmt.Sprintf("file exceeds the %s size limit", formatFileSize(s.fileSizeLimit())),
+	}
 }
 
 // calculateFileHash calculates SHA-256 hash of the file data
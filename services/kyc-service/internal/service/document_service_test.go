@@ -0,0 +1,497 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// memoryStorage is an in-memory ListableStorage
+type memoryStorage struct {
+	mu         sync.Mutex
+	objects    map[string][]byte
+	modifiedAt map[string]time.Time
+	deleteErr  error
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: make(map[string][]byte), modifiedAt: make(map[string]time.Time)}
+}
+
+func (m *memoryStorage) Store(ctx context.Context, path string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[path] = data
+	m.modifiedAt[path] = time.Now()
+	return nil
+}
+
+func (m *memoryStorage) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[path]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryStorage) Delete(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	delete(m.objects, path)
+	return nil
+}
+
+func (m *memoryStorage) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var objects []StoredObject
+	for path := range m.objects {
+		if strings.HasPrefix(path, prefix) {
+			objects = append(objects, StoredObject{Path: path, ModifiedAt: m.modifiedAt[path]})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Path < objects[j].Path })
+	return objects, nil
+}
+
+// age backdates a stored object
+func (m *memoryStorage) age(path string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.modifiedAt[path] = time.Now().Add(-d)
+}
+
+func (m *memoryStorage) has(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.objects[path]
+	return ok
+}
+
+func TestStoreWithCompensation_RemovesFileOnRollback(t *testing.T) {
+	storage := newMemoryStorage()
+	svc := &DocumentService{storage: storage}
+	rollback := errors.New("failed to create verification")
+
+	var storedDuringPersist bool
+	err := svc.storeWithCompensation(context.Background(), "uploads/hash", []byte("document"), func() error {
+		storedDuringPersist = storage.has("uploads/hash")
+		return rollback
+	})
+
+	if !errors.Is(err, rollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	if !storedDuringPersist {
+		t.Fatalf("expected file to be stored before the transaction ran")
+	}
+	if storage.has("uploads/hash") {
+		t.Fatalf("expected stored file to be deleted after rollback")
+	}
+}
+
+func TestStoreWithCompensation_KeepsFileOnCommit(t *testing.T) {
+	storage := newMemoryStorage()
+	svc := &DocumentService{storage: storage}
+
+	err := svc.storeWithCompensation(context.Background(), "uploads/hash", []byte("document"), func() error {
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !storage.has("uploads/hash") {
+		t.Fatalf("expected stored file to be kept after commit")
+	}
+}
+
+// memoryRecords is an in-memory documentRecordStore whose writes can be made to fail
+type memoryRecords struct {
+	mu        sync.Mutex
+	docs      map[uuid.UUID]*model.Document
+	history   []*model.DocumentHistory
+	createErr error
+}
+
+func newMemoryRecords(docs ...*model.Document) *memoryRecords {
+	r := &memoryRecords{docs: make(map[uuid.UUID]*model.Document)}
+	for _, doc := range docs {
+		r.docs[doc.ID] = doc
+	}
+	return r
+}
+
+func (r *memoryRecords) Create(ctx context.Context, doc *model.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.docs[doc.ID] = doc
+	return nil
+}
+
+func (r *memoryRecords) GetByID(ctx context.Context, id uuid.UUID) (*model.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.docs[id]
+	if !ok {
+		return nil, errors.New("document not found")
+	}
+	return doc, nil
+}
+
+func (r *memoryRecords) Update(ctx context.Context, doc *model.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.docs[doc.ID] = doc
+	return nil
+}
+
+func (r *memoryRecords) AddHistoryEntry(ctx context.Context, entry *model.DocumentHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.history = append(r.history, entry)
+	return nil
+}
+
+func (r *memoryRecords) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.docs, id)
+	return nil
+}
+
+func (r *memoryRecords) ReferencedFilePaths(ctx context.Context, paths []string) (map[string]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	referenced := make(map[string]bool)
+	for _, doc := range r.docs {
+		for _, path := range paths {
+			if doc.FilePath == path {
+				referenced[path] = true
+			}
+		}
+	}
+	return referenced, nil
+}
+
+func (r *memoryRecords) FindByHash(ctx context.Context, userID uuid.UUID, fileHash string) (*model.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found *model.Document
+	for _, doc := range r.docs {
+		if doc.UserID == userID && doc.FileHash == fileHash && (found == nil || doc.CreatedAt.Before(found.CreatedAt)) {
+			found = doc
+		}
+	}
+	return found, nil
+}
+
+// uploadedFile builds the multipart file header a handler would receive
+func uploadedFile(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("failed to read form: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestUploadDocument_RemovesStoredFileWhenRecordFails(t *testing.T) {
+	storage := newMemoryStorage()
+	records := newMemoryRecords()
+	records.createErr = errors.New("connection reset by peer")
+	svc := &DocumentService{records: records, storage: storage}
+
+	_, err := svc.UploadDocument(context.Background(), uuid.New(), uploadedFile(t, "passport.pdf", []byte("%PDF-1.4")), "passport", nil)
+	if !errors.Is(err, records.createErr) {
+		t.Fatalf("expected the database error, got %v", err)
+	}
+	if objects, _ := storage.List(context.Background(), ""); len(objects) != 0 {
+		t.Fatalf("expected the stored file to be cleaned up, found %v", objects)
+	}
+}
+
+func TestUploadDocument_StoresFilePerDocument(t *testing.T) {
+	storage := newMemoryStorage()
+	svc := &DocumentService{records: newMemoryRecords(), storage: storage}
+
+	// Identical content must not share an object, or deleting one would break the other
+	first, err := svc.UploadDocument(context.Background(), uuid.New(), uploadedFile(t, "a.pdf", []byte("%PDF-1.4 same")), "passport", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := svc.UploadDocument(context.Background(), uuid.New(), uploadedFile(t, "b.pdf", []byte("%PDF-1.4 same")), "passport", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !storage.has(svc.filePath(first.ID)) || !storage.has(svc.filePath(second.ID)) {
+		t.Fatal("expected each document's file to be stored under its own path")
+	}
+}
+
+func TestUploadDocument_RejectsDuplicateContent(t *testing.T) {
+	svc := &DocumentService{records: newMemoryRecords(), storage: newMemoryStorage()}
+	userID := uuid.New()
+
+	first, err := svc.UploadDocument(context.Background(), userID, uploadedFile(t, "a.pdf", []byte("%PDF-1.4 passport")), "passport", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A renamed copy is still the same document
+	_, err = svc.UploadDocument(context.Background(), userID, uploadedFile(t, "b.pdf", []byte("%PDF-1.4 passport")), "passport", nil)
+	var duplicate *DuplicateDocumentError
+	if !errors.Is(err, ErrDuplicateDocument) || !errors.As(err, &duplicate) {
+		t.Fatalf("expected a duplicate document error, got %v", err)
+	}
+	if duplicate.DocumentID != first.ID {
+		t.Fatalf("expected the duplicate to name %s, got %s", first.ID, duplicate.DocumentID)
+	}
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	storage := newMemoryStorage()
+	svc := &DocumentService{records: newMemoryRecords(), storage: storage}
+
+	doc, err := svc.UploadDocument(context.Background(), uuid.New(), uploadedFile(t, "a.pdf", []byte("%PDF-1.4 passport")), "passport", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := svc.VerifyIntegrity(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	if !result.Intact || result.ActualHash != doc.FileHash {
+		t.Fatalf("expected an untouched document to be intact, got %+v", result)
+	}
+
+	storage.objects[svc.filePath(doc.ID)] = []byte("%PDF-1.4 forged")
+	result, err = svc.VerifyIntegrity(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	if result.Intact || result.ExpectedHash != doc.FileHash {
+		t.Fatalf("expected a tampered document to fail the check, got %+v", result)
+	}
+}
+
+func TestUploadDocument_RejectsInvalidFiles(t *testing.T) {
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+		wantErr error
+	}{
+		{name: "script renamed to pdf", file: "passport.pdf", content: []byte("#!/bin/sh\nrm -rf /"), wantErr: ErrUnsupportedFileType},
+		{name: "html renamed to png", file: "selfie.png", content: []byte("<html><script>alert(1)</script></html>"), wantErr: ErrUnsupportedFileType},
+		{name: "over the size limit", file: "scan.png", content: append(pngHeader, make([]byte, 1024)...), wantErr: ErrFileTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMemoryStorage()
+			svc := &DocumentService{records: newMemoryRecords(), storage: storage}
+			svc.SetFileLimits(1024, nil)
+
+			_, err := svc.UploadDocument(context.Background(), uuid.New(), uploadedFile(t, tt.file, tt.content), "passport", nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			var invalid *FileValidationError
+			if !errors.As(err, &invalid) || invalid.Reason == "" {
+				t.Fatalf("expected a FileValidationError with a reason, got %v", err)
+			}
+			if objects, _ := storage.List(context.Background(), ""); len(objects) != 0 {
+				t.Fatalf("expected nothing to be stored, found %v", objects)
+			}
+		})
+	}
+}
+
+func TestUploadDocument_StoresSniffedTypeAndCleanName(t *testing.T) {
+	svc := &DocumentService{records: newMemoryRecords(), storage: newMemoryStorage()}
+
+	file := uploadedFile(t, "scan.png", []byte("%PDF-1.4 passport"))
+	file.Filename = "../../etc/passwd\x00.pdf"
+	file.Header.Set("Content-Type", "image/png")
+
+	doc, err := svc.UploadDocument(context.Background(), uuid.New(), file, "passport", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.MimeType != "application/pdf" {
+		t.Fatalf("expected the sniffed type application/pdf, got %q", doc.MimeType)
+	}
+	if doc.FileName != "passwd.pdf" {
+		t.Fatalf("expected the file name to be stripped to passwd.pdf, got %q", doc.FileName)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "passport.pdf", want: "passport.pdf"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: `C:\Users\me\id card.png`, want: "id card.png"},
+		{name: "scan\r\n.pdf", want: "scan.pdf"},
+		{name: "..", want: "document"},
+		{name: "/", want: "document"},
+		{name: "", want: "document"},
+		{name: ".hidden.pdf", want: "hidden.pdf"},
+		{name: strings.Repeat("é", 200) + ".pdf", want: strings.Repeat("é", 125) + ".pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteDocument_RemovesRecordAndFile(t *testing.T) {
+	storage := newMemoryStorage()
+	doc := &model.Document{ID: uuid.New(), FilePath: "uploads/doc"}
+	storage.Store(context.Background(), doc.FilePath, strings.NewReader("content"))
+	records := newMemoryRecords(doc)
+	svc := &DocumentService{records: records, storage: storage}
+
+	if err := svc.DeleteDocument(context.Background(), doc.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := records.GetByID(context.Background(), doc.ID); err == nil {
+		t.Fatal("expected the record to be deleted")
+	}
+	if storage.has(doc.FilePath) {
+		t.Fatal("expected the file to be deleted")
+	}
+}
+
+func TestDeleteDocument_KeepsSharedLegacyFile(t *testing.T) {
+	storage := newMemoryStorage()
+	deleted := &model.Document{ID: uuid.New(), FilePath: "uploads/hash"}
+	sharing := &model.Document{ID: uuid.New(), FilePath: "uploads/hash"}
+	storage.Store(context.Background(), "uploads/hash", strings.NewReader("content"))
+	svc := &DocumentService{records: newMemoryRecords(deleted, sharing), storage: storage}
+
+	if err := svc.DeleteDocument(context.Background(), deleted.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !storage.has("uploads/hash") {
+		t.Fatal("expected a file still referenced by another document to be kept")
+	}
+}
+
+func TestDeleteDocument_FileFailureLeavesStragglerForReconciler(t *testing.T) {
+	storage := newMemoryStorage()
+	doc := &model.Document{ID: uuid.New(), FilePath: "uploads/doc"}
+	storage.Store(context.Background(), doc.FilePath, strings.NewReader("content"))
+	records := newMemoryRecords(doc)
+	svc := &DocumentService{records: records, storage: storage}
+
+	storage.deleteErr = errors.New("store unavailable")
+	if err := svc.DeleteDocument(context.Background(), doc.ID); err != nil {
+		t.Fatalf("expected the delete to succeed once the record is gone, got %v", err)
+	}
+	if !storage.has(doc.FilePath) {
+		t.Fatal("expected the file to remain after the failed delete")
+	}
+
+	// The next sweep removes the straggler
+	storage.deleteErr = nil
+	storage.age(doc.FilePath, 2*time.Hour)
+	reconciler := NewOrphanReconciler(storage, records, "uploads/", DefaultReconcilerConfig(), nil)
+	if removed, err := reconciler.Reconcile(context.Background()); err != nil || removed != 1 {
+		t.Fatalf("expected 1 straggler to be removed, got %d (%v)", removed, err)
+	}
+	if storage.has(doc.FilePath) {
+		t.Fatal("expected the reconciler to remove the straggler")
+	}
+}
+
+func TestOrphanReconciler_RemovesOnlyOldUnreferencedFiles(t *testing.T) {
+	storage := newMemoryStorage()
+	live := &model.Document{ID: uuid.New(), FilePath: "uploads/live"}
+	for _, path := range []string{"uploads/live", "uploads/orphan-1", "uploads/orphan-2", "uploads/in-flight", "quarantine/infected"} {
+		storage.Store(context.Background(), path, strings.NewReader("content"))
+		if path != "uploads/in-flight" {
+			storage.age(path, 2*time.Hour)
+		}
+	}
+
+	// A batch size of 1 exercises batching across several lookups
+	reconciler := NewOrphanReconciler(storage, newMemoryRecords(live), "uploads/", ReconcilerConfig{GracePeriod: time.Hour, BatchSize: 1}, nil)
+	removed, err := reconciler.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("expected 2 orphans to be removed, got %d", removed)
+	}
+
+	for path, kept := range map[string]bool{
+		"uploads/live":        true,
+		"uploads/orphan-1":    false,
+		"uploads/orphan-2":    false,
+		"uploads/in-flight":   true, // within the grace period; its record may not be committed yet
+		"quarantine/infected": true, // outside the reconciled prefix
+	} {
+		if storage.has(path) != kept {
+			t.Errorf("%s: kept = %v, want %v", path, storage.has(path), kept)
+		}
+	}
+}
+
+func TestOrphanReconciler_StartStop(t *testing.T) {
+	storage := newMemoryStorage()
+	storage.Store(context.Background(), "uploads/orphan", strings.NewReader("content"))
+	reconciler := NewOrphanReconciler(storage, newMemoryRecords(), "uploads/", ReconcilerConfig{Interval: 5 * time.Millisecond}, nil)
+
+	reconciler.Start()
+	deadline := time.Now().Add(time.Second)
+	for storage.has("uploads/orphan") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	reconciler.Stop()
+
+	if storage.has("uploads/orphan") {
+		t.Fatal("expected the periodic sweep to remove the orphan")
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxFileSize is the largest document upload accepted unless
+// SetFileLimits sets another
+const DefaultMaxFileSize int64 = 10 << 20
+
+// DefaultAllowedMimeTypes are the document content types accepted unless
+// SetFileLimits sets others
+var DefaultAllowedMimeTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+var defaultAllowedTypes = mimeTypeSet(DefaultAllowedMimeTypes)
+
+// maxFileNameLength bounds the stored file name in bytes
+const maxFileNameLength = 255
+
+var (
+	// ErrFileTooLarge is returned for uploads over the size limit
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrUnsupportedFileType is returned for uploads whose content is not an
+	// allowed type, whatever their name or declared Content-Type
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+)
+
+// FileValidationError explains why an upload was rejected. It matches
+// ErrFileTooLarge or ErrUnsupportedFileType with errors.Is, and Reason is
+// safe to show to the client.
+type FileValidationError struct {
+	Err    error
+	Reason string
+}
+
+func (e *FileValidationError) Error() string {
+	return e.Reason
+}
+
+func (e *FileValidationError) Unwrap() error {
+	return e.Err
+}
+
+// sanitizeFileName reduces a client-supplied file name to a bare base name:
+// directories, either slash style, control characters and leading dots are
+// removed and long names are truncated, keeping the extension
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(strings.TrimLeft(name, "."))
+	if name == "" || name == "/" {
+		return "document"
+	}
+
+	if len(name) > maxFileNameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:maxFileNameLength-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+	return name
+}
+
+// mimeTypeSet returns the set of types, lower-cased
+func mimeTypeSet(types []string) map[string]bool {
+	set := make(map[string]bool, len(types))
+	for _, mimeType := range types {
+		set[strings.ToLower(strings.TrimSpace(mimeType))] = true
+	}
+	return set
+}
+
+// formatFileSize formats a size in bytes for error messages
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", size>>10)
+	default:
+		return fmt.Sprintf("%d-byte", size)
+	}
+}
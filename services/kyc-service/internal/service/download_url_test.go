@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// downloadFixture is a document service holding one stored document, with
+// signed downloads enabled
+func downloadFixture(t *testing.T) (*DocumentService, *model.Document) {
+	t.Helper()
+
+	storage := newMemoryStorage()
+	doc := &model.Document{ID: uuid.New(), FilePath: "uploads/passport", FileName: "passport.pdf"}
+	storage.Store(context.Background(), doc.FilePath, strings.NewReader("passport scan"))
+
+	svc := &DocumentService{records: newMemoryRecords(doc), storage: storage}
+	svc.SetDownloadURLs(NewDownloadURLSigner(DownloadURLConfig{Secret: "download-secret", TTL: time.Minute}))
+	return svc, doc
+}
+
+// signedQuery mints a download URL and returns its expires and signature parameters
+func signedQuery(t *testing.T, svc *DocumentService, id uuid.UUID) (string, string) {
+	t.Helper()
+
+	link, err := svc.DownloadURL(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to mint download URL: %v", err)
+	}
+	parsed, err := url.Parse(link.URL)
+	if err != nil {
+		t.Fatalf("invalid download URL %q: %v", link.URL, err)
+	}
+	if want := "/api/v1/documents/" + id.String() + "/download"; parsed.Path != want {
+		t.Fatalf("download path = %s, want %s", parsed.Path, want)
+	}
+	return parsed.Query().Get("expires"), parsed.Query().Get("signature")
+}
+
+func TestOpenDownload_ValidURL(t *testing.T) {
+	svc, doc := downloadFixture(t)
+	expires, signature := signedQuery(t, svc, doc.ID)
+
+	opened, content, err := svc.OpenDownload(context.Background(), doc.ID, expires, signature)
+	if err != nil {
+		t.Fatalf("expected a valid URL to open, got %v", err)
+	}
+	defer content.Close()
+
+	data, _ := io.ReadAll(content)
+	if string(data) != "passport scan" || opened.FileName != "passport.pdf" {
+		t.Fatalf("unexpected download %q of %s", data, opened.FileName)
+	}
+}
+
+func TestOpenDownload_RejectsExpiredURL(t *testing.T) {
+	svc, doc := downloadFixture(t)
+
+	// A URL signed correctly but for an expiry already past
+	expired := time.Now().Add(-time.Second).Unix()
+	signature := svc.downloads.signature(doc.ID, expired)
+
+	_, _, err := svc.OpenDownload(context.Background(), doc.ID, strconv.FormatInt(expired, 10), signature)
+	if !errors.Is(err, ErrDownloadURLExpired) {
+		t.Fatalf("expected ErrDownloadURLExpired, got %v", err)
+	}
+}
+
+func TestOpenDownload_RejectsAlteredURL(t *testing.T) {
+	svc, doc := downloadFixture(t)
+	expires, signature := signedQuery(t, svc, doc.ID)
+
+	other := &model.Document{ID: uuid.New(), FilePath: "uploads/other"}
+	svc.records.Create(context.Background(), other)
+
+	extended, _ := strconv.ParseInt(expires, 10, 64)
+	tests := []struct {
+		name               string
+		id                 uuid.UUID
+		expires, signature string
+	}{
+		{"other document", other.ID, expires, signature},
+		{"extended expiry", doc.ID, strconv.FormatInt(extended+3600, 10), signature},
+		{"altered signature", doc.ID, expires, strings.Repeat("0", len(signature))},
+		{"missing signature", doc.ID, expires, ""},
+		{"malformed expiry", doc.ID, "tomorrow", signature},
+	}
+	for _, tt := range tests {
+		if _, _, err := svc.OpenDownload(context.Background(), tt.id, tt.expires, tt.signature); !errors.Is(err, ErrDownloadURLInvalid) {
+			t.Errorf("%s: expected ErrDownloadURLInvalid, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestDownloadURL_DisabledWithoutSecret(t *testing.T) {
+	svc, doc := downloadFixture(t)
+	svc.SetDownloadURLs(NewDownloadURLSigner(DownloadURLConfig{}))
+
+	if _, err := svc.DownloadURL(context.Background(), doc.ID); !errors.Is(err, ErrDownloadsDisabled) {
+		t.Fatalf("expected ErrDownloadsDisabled, got %v", err)
+	}
+	if _, _, err := svc.OpenDownload(context.Background(), doc.ID, "0", ""); !errors.Is(err, ErrDownloadsDisabled) {
+		t.Fatalf("expected ErrDownloadsDisabled, got %v", err)
+	}
+}
+
+func TestDownloadURL_UsesPresigningStorage(t *testing.T) {
+	svc, doc := downloadFixture(t)
+	svc.storage = &presigningStorage{memoryStorage: svc.storage.(*memoryStorage)}
+
+	link, err := svc.DownloadURL(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if link.URL != "https://bucket.example.com/uploads/passport?ttl=1m0s" {
+		t.Fatalf("expected the storage's presigned URL, got %s", link.URL)
+	}
+}
+
+func TestDownloadURL_PresigningStorageNeedsNoSecret(t *testing.T) {
+	svc, doc := downloadFixture(t)
+	svc.SetDownloadURLs(NewDownloadURLSigner(DownloadURLConfig{}))
+	svc.storage = &presigningStorage{memoryStorage: svc.storage.(*memoryStorage)}
+
+	link, err := svc.DownloadURL(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if link.URL != "https://bucket.example.com/uploads/passport?ttl=5m0s" {
+		t.Fatalf("expected a presigned URL with the default TTL, got %s", link.URL)
+	}
+}
+
+func TestDownloadURL_RejectsUnscannedDocuments(t *testing.T) {
+	tests := []struct {
+		status model.DocumentStatus
+		want   error
+	}{
+		{model.DocumentStatusScanning, ErrDocumentScanning},
+		{model.DocumentStatusQuarantined, ErrDocumentQuarantined},
+	}
+	for _, tt := range tests {
+		svc, doc := downloadFixture(t)
+		doc.Status = tt.status
+
+		if _, err := svc.DownloadURL(context.Background(), doc.ID); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.status, tt.want, err)
+		}
+	}
+}
+
+func TestOpenDownload_RejectsDocumentQuarantinedAfterMinting(t *testing.T) {
+	svc, doc := downloadFixture(t)
+	expires, signature := signedQuery(t, svc, doc.ID)
+
+	// The scan reports the document infected while the URL is still valid
+	doc.Status = model.DocumentStatusQuarantined
+
+	if _, _, err := svc.OpenDownload(context.Background(), doc.ID, expires, signature); !errors.Is(err, ErrDocumentQuarantined) {
+		t.Fatalf("expected ErrDocumentQuarantined, got %v", err)
+	}
+}
+
+// presigningStorage presigns links to a fake bucket
+type presigningStorage struct {
+	*memoryStorage
+}
+
+func (p *presigningStorage) PresignGet(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	return "https://bucket.example.com/" + path + "?ttl=" + ttl.String(), nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// Names of the fields extracted from identity documents
+const (
+	OCRFieldText           = "text"
+	OCRFieldDocumentNumber = "document_number"
+	OCRFieldIssuingCountry = "issuing_country"
+	OCRFieldNationality    = "nationality"
+	OCRFieldSurname        = "surname"
+	OCRFieldGivenNames     = "given_names"
+	OCRFieldDateOfBirth    = "date_of_birth"
+	OCRFieldExpiryDate     = "expiry_date"
+	OCRFieldSex            = "sex"
+)
+
+// identityFields extracts fields from the text read off an identity document.
+// The text is always kept; the other fields come from the machine readable
+// zone of a passport (TD3) or ID card (TD1) when one is found. Values whose
+// check digit does not match are left out, as OCR misread them.
+func identityFields(text string) map[string]string {
+	fields := map[string]string{OCRFieldText: text}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(line), " ", "")))
+	}
+	for i := range lines {
+		switch {
+		case i+1 < len(lines) && isMRZLine(lines[i], 44) && isMRZLine(lines[i+1], 44) && lines[i][0] == 'P':
+			parseTD3(lines[i], lines[i+1], fields)
+			return fields
+		case i+2 < len(lines) && isMRZLine(lines[i], 30) && isMRZLine(lines[i+1], 30) && isMRZLine(lines[i+2], 30) && strings.IndexByte("IAC", lines[i][0]) >= 0:
+			parseTD1(lines[i], lines[i+1], lines[i+2], fields)
+			return fields
+		}
+	}
+	return fields
+}
+
+// isMRZLine reports whether line could be a machine readable zone line of length n
+func isMRZLine(line string, n int) bool {
+	if len(line) != n || !strings.Contains(line, "<") {
+		return false
+	}
+	for _, r := range line {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '<') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTD3 reads the two 44 character lines of a passport MRZ
+func parseTD3(line1, line2 string, fields map[string]string) {
+	setMRZField(fields, OCRFieldIssuingCountry, line1[2:5])
+	setMRZNames(fields, line1[5:])
+	setMRZChecked(fields, OCRFieldDocumentNumber, line2[0:9], line2[9])
+	setMRZField(fields, OCRFieldNationality, line2[10:13])
+	setMRZDate(fields, OCRFieldDateOfBirth, line2[13:19], line2[19], false)
+	setMRZField(fields, OCRFieldSex, line2[20:21])
+	setMRZDate(fields, OCRFieldExpiryDate, line2[21:27], line2[27], true)
+}
+
+// parseTD1 reads the three 30 character lines of an ID card MRZ
+func parseTD1(line1, line2, line3 string, fields map[string]string) {
+	setMRZField(fields, OCRFieldIssuingCountry, line1[2:5])
+	setMRZChecked(fields, OCRFieldDocumentNumber, line1[5:14], line1[14])
+	setMRZDate(fields, OCRFieldDateOfBirth, line2[0:6], line2[6], false)
+	setMRZField(fields, OCRFieldSex, line2[7:8])
+	setMRZDate(fields, OCRFieldExpiryDate, line2[8:14], line2[14], true)
+	setMRZField(fields, OCRFieldNationality, line2[15:18])
+	setMRZNames(fields, line3)
+}
+
+// setMRZField sets name to value with the filler characters removed
+func setMRZField(fields map[string]string, name, value string) {
+	if value = strings.Trim(value, "<"); value != "" {
+		fields[name] = value
+	}
+}
+
+// setMRZChecked sets name to value if it matches its check digit
+func setMRZChecked(fields map[string]string, name, value string, check byte) {
+	if mrzCheckDigit(value) == check {
+		setMRZField(fields, name, value)
+	}
+}
+
+// setMRZDate sets name to the YYMMDD date value as YYYY-MM-DD if it matches its
+// check digit. Expiry dates are taken to be this century; birth dates are in
+// the last one if they would otherwise be in the future.
+func setMRZDate(fields map[string]string, name, value string, check byte, expiry bool) {
+	if mrzCheckDigit(value) != check {
+		return
+	}
+	date, err := time.Parse("060102", value)
+	if err != nil {
+		return
+	}
+	year := 2000 + date.Year()%100
+	if !expiry && year > time.Now().Year() {
+		year -= 100
+	}
+	fields[name] = time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+}
+
+// setMRZNames reads the surname and given names, separated by "<<"
+func setMRZNames(fields map[string]string, value string) {
+	surname, given, _ := strings.Cut(strings.Trim(value, "<"), "<<")
+	setMRZField(fields, OCRFieldSurname, strings.ReplaceAll(surname, "<", " "))
+	setMRZField(fields, OCRFieldGivenNames, strings.ReplaceAll(strings.Trim(given, "<"), "<", " "))
+}
+
+// mrzCheckDigit computes the ICAO 9303 check digit of value
+func mrzCheckDigit(value string) byte {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i, r := range value {
+		var v int
+		switch {
+		case r >= '0' && r <= '9':
+			v = int(r - '0')
+		case r >= 'A' && r <= 'Z':
+			v = int(r-'A') + 10
+		}
+		sum += v * weights[i%3]
+	}
+	return byte('0' + sum%10)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxOCRResponseSize bounds the response read from a remote OCR service
+const maxOCRResponseSize = 1 << 20
+
+// RemoteOCRProvider sends documents to an OCR service over HTTP. The document
+// is posted as the request body with its content type, and the service replies
+// with JSON of the form {"fields": {"document_number": "..."}, "confidence": 0.93}.
+// If the reply includes the text under "text", fields the service did not
+// return are read from its machine readable zone as for Tesseract.
+type RemoteOCRProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewRemoteOCRProvider creates a provider posting to url. A non-empty apiKey is
+// sent as a bearer token. A nil client uses http.DefaultClient; deadlines come
+// from the request context.
+func NewRemoteOCRProvider(url, apiKey string, client *http.Client) *RemoteOCRProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RemoteOCRProvider{url: url, apiKey: apiKey, client: client}
+}
+
+// remoteOCRResponse is the reply of a remote OCR service
+type remoteOCRResponse struct {
+	Fields     map[string]string `json:"fields"`
+	Text       string            `json:"text"`
+	Confidence float64           `json:"confidence"`
+}
+
+// Recognize posts r to the OCR service. The request is abandoned when ctx is done.
+func (p *RemoteOCRProvider) Recognize(ctx context.Context, mimeType string, r io.Reader) (*OCRResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ocr request: %w", err)
+	}
+	req.Header.Set("Content-Type", mimeType)
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("ocr request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, maxOCRResponseSize)
+	switch {
+	case resp.StatusCode == http.StatusUnsupportedMediaType:
+		return nil, fmt.Errorf("%w: %s", ErrOCRUnsupportedType, mimeType)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("ocr service returned status %d", resp.StatusCode)
+	}
+
+	var reply remoteOCRResponse
+	if err := json.NewDecoder(body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("failed to decode ocr response: %w", err)
+	}
+	if reply.Confidence < 0 || reply.Confidence > 1 {
+		return nil, fmt.Errorf("ocr service returned confidence %v outside 0-1", reply.Confidence)
+	}
+
+	fields := identityFields(reply.Text)
+	for name, value := range reply.Fields {
+		fields[name] = value
+	}
+	if reply.Text == "" {
+		delete(fields, OCRFieldText)
+	}
+	return &OCRResult{Fields: fields, Confidence: reply.Confidence}, nil
+}
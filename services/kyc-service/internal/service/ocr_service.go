@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrOCRNotConfigured is returned when OCR is requested and no extractor is set
+	ErrOCRNotConfigured = errors.New("ocr is not configured")
+	// ErrOCRUnsupportedType is returned when the OCR backend cannot read a
+	// document's content type
+	ErrOCRUnsupportedType = errors.New("document type is not supported by ocr")
+	// ErrOCRFailed is returned when the OCR backend errors
+	ErrOCRFailed = errors.New("ocr failed")
+)
+
+// OCRResult is what an OCRProvider read from a document
+type OCRResult struct {
+	// Fields holds the extracted values keyed by name, e.g. document_number,
+	// and the full text under "text"
+	Fields map[string]string
+	// Confidence is the provider's confidence in the reading, from 0 to 1
+	Confidence float64
+}
+
+// OCRProvider reads text from document content (e.g. Tesseract or a remote
+// OCR service). Recognition is abandoned when ctx is done.
+type OCRProvider interface {
+	Recognize(ctx context.Context, mimeType string, r io.Reader) (*OCRResult, error)
+}
+
+// OCRExtractor extracts data from a stored document
+type OCRExtractor interface {
+	Extract(ctx context.Context, documentID uuid.UUID) (fields map[string]string, confidence float64, err error)
+}
+
+// OCRConfig holds the OCR extraction policy
+type OCRConfig struct {
+	// Timeout bounds a single extraction
+	Timeout time.Duration
+	// ReviewThreshold is the confidence below which extracted data is flagged
+	// for manual review
+	ReviewThreshold float64
+}
+
+// DefaultOCRConfig returns the default OCR extraction policy
+func DefaultOCRConfig() OCRConfig {
+	return OCRConfig{
+		Timeout:         30 * time.Second,
+		ReviewThreshold: 0.8,
+	}
+}
+
+// OCRExtraction is the outcome of extracting data from a document
+type OCRExtraction struct {
+	DocumentID  uuid.UUID
+	Fields      map[string]string
+	Confidence  float64
+	NeedsReview bool
+	ExtractedAt time.Time
+}
+
+// DocumentOCR is an OCRExtractor that reads documents from the document store
+// and passes their content to an OCRProvider
+type DocumentOCR struct {
+	records  documentRecordStore
+	storage  StorageService
+	provider OCRProvider
+}
+
+// NewDocumentOCR creates an extractor reading documents from records and storage
+func NewDocumentOCR(records documentRecordStore, storage StorageService, provider OCRProvider) *DocumentOCR {
+	return &DocumentOCR{
+		records:  records,
+		storage:  storage,
+		provider: provider,
+	}
+}
+
+// Extract runs OCR over the stored content of a document. Quarantined
+// documents are held outside the document store and return
+// ErrDocumentQuarantined. If ctx ends first its error is returned.
+func (o *DocumentOCR) Extract(ctx context.Context, documentID uuid.UUID) (map[string]string, float64, error) {
+	doc, err := o.records.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if doc.Status == model.DocumentStatusQuarantined {
+		return nil, 0, ErrDocumentQuarantined
+	}
+
+	content, err := o.storage.Get(ctx, doc.FilePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer content.Close()
+
+	result, err := o.provider.Recognize(ctx, doc.MimeType, content)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		if errors.Is(err, ErrOCRUnsupportedType) {
+			return nil, 0, err
+		}
+		return nil, 0, fmt.Errorf("%w: %v", ErrOCRFailed, err)
+	}
+	return result.Fields, result.Confidence, nil
+}
+
+// SetOCR enables data extraction from documents with extractor
+func (s *DocumentService) SetOCR(extractor OCRExtractor, config OCRConfig) {
+	s.ocr = extractor
+	s.ocrConfig = config
+}
+
+// ExtractDocumentData runs OCR over a document and stores the extracted fields
+// in its metadata under "ocr". The extraction is bounded by the OCR timeout
+// and abandoned if ctx is cancelled.
+//
+// A reading below the review threshold is flagged for manual review: a pending
+// document moves to in_review and nothing is filled in from it. A confident
+// reading fills in the document number, issuing country and expiry date where
+// the document has none.
+func (s *DocumentService) ExtractDocumentData(ctx context.Context, id uuid.UUID) (*OCRExtraction, error) {
+	if s.ocr == nil {
+		return nil, ErrOCRNotConfigured
+	}
+
+	extractCtx := ctx
+	if s.ocrConfig.Timeout > 0 {
+		var cancel context.CancelFunc
+		extractCtx, cancel = context.WithTimeout(ctx, s.ocrConfig.Timeout)
+		defer cancel()
+	}
+	fields, confidence, err := s.ocr.Extract(extractCtx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	extraction := &OCRExtraction{
+		DocumentID:  id,
+		Fields:      fields,
+		Confidence:  confidence,
+		NeedsReview: confidence < s.ocrConfig.ReviewThreshold,
+		ExtractedAt: time.Now(),
+	}
+	if err := s.saveExtraction(ctx, extraction); err != nil {
+		return nil, err
+	}
+	return extraction, nil
+}
+
+// saveExtraction records extraction on its document
+func (s *DocumentService) saveExtraction(ctx context.Context, extraction *OCRExtraction) error {
+	doc, err := s.records.GetByID(ctx, extraction.DocumentID)
+	if err != nil {
+		return err
+	}
+
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	doc.Metadata["ocr"] = map[string]interface{}{
+		"fields":       extraction.Fields,
+		"confidence":   extraction.Confidence,
+		"needs_review": extraction.NeedsReview,
+		"extracted_at": extraction.ExtractedAt,
+	}
+
+	flagged := extraction.NeedsReview && doc.Status == model.DocumentStatusPending
+	if flagged {
+		doc.Status = model.DocumentStatusInReview
+	}
+	if !extraction.NeedsReview {
+		fillFromOCR(doc, extraction.Fields)
+	}
+	doc.UpdatedAt = time.Now()
+
+	if err := s.records.Update(ctx, doc); err != nil {
+		return fmt.Errorf("failed to save ocr result: %w", err)
+	}
+	if !flagged {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"document_id": doc.ID,
+		"confidence":  extraction.Confidence,
+	}).Info("OCR confidence below review threshold; document flagged for manual review")
+
+	err = s.records.AddHistoryEntry(ctx, &model.DocumentHistory{
+		ID:         uuid.New(),
+		DocumentID: doc.ID,
+		Status:     model.DocumentStatusInReview,
+		Notes:      fmt.Sprintf("OCR confidence %.2f is below the review threshold %.2f", extraction.Confidence, s.ocrConfig.ReviewThreshold),
+		CreatedBy:  uuid.Nil,
+		CreatedAt:  time.Now(),
+		Metadata:   map[string]interface{}{"action": "ocr_review"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record ocr review: %w", err)
+	}
+	return nil
+}
+
+// fillFromOCR copies extracted fields into the document details it has no value for
+func fillFromOCR(doc *model.Document, fields map[string]string) {
+	if doc.DocumentNumber == "" && fields[OCRFieldDocumentNumber] != "" {
+		doc.DocumentNumber = fields[OCRFieldDocumentNumber]
+	}
+	if doc.IssuingCountry == "" && fields[OCRFieldIssuingCountry] != "" {
+		doc.IssuingCountry = fields[OCRFieldIssuingCountry]
+	}
+	if doc.ExpiryDate == nil {
+		if expiry, err := time.Parse("2006-01-02", fields[OCRFieldExpiryDate]); err == nil {
+			doc.ExpiryDate = &expiry
+		}
+	}
+}
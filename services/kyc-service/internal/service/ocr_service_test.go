@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// passportMRZ is the specimen passport MRZ from ICAO 9303
+const passportMRZ = "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122F1204159ZE184226B<<<<<10"
+
+// stubOCR is an OCRExtractor returning a fixed reading, or waiting for its
+// context to end when block is set
+type stubOCR struct {
+	fields     map[string]string
+	confidence float64
+	block      bool
+}
+
+func (s *stubOCR) Extract(ctx context.Context, documentID uuid.UUID) (map[string]string, float64, error) {
+	if s.block {
+		<-ctx.Done()
+		return nil, 0, ctx.Err()
+	}
+	return s.fields, s.confidence, nil
+}
+
+func TestIdentityFields_ReadsPassportMRZ(t *testing.T) {
+	fields := identityFields("PASSPORT\nUtopia\n" + passportMRZ)
+
+	want := map[string]string{
+		OCRFieldIssuingCountry: "UTO",
+		OCRFieldSurname:        "ERIKSSON",
+		OCRFieldGivenNames:     "ANNA MARIA",
+		OCRFieldDocumentNumber: "L898902C3",
+		OCRFieldNationality:    "UTO",
+		OCRFieldDateOfBirth:    "1974-08-12",
+		OCRFieldSex:            "F",
+		OCRFieldExpiryDate:     "2012-04-15",
+	}
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("%s = %q, want %q", name, fields[name], value)
+		}
+	}
+}
+
+func TestIdentityFields_DropsValuesFailingCheckDigit(t *testing.T) {
+	// The document number is misread as L898902C4
+	misread := strings.Replace(passportMRZ, "L898902C3", "L898902C4", 1)
+	fields := identityFields(misread)
+
+	if _, ok := fields[OCRFieldDocumentNumber]; ok {
+		t.Fatalf("expected the misread document number to be dropped, got %q", fields[OCRFieldDocumentNumber])
+	}
+	if fields[OCRFieldExpiryDate] != "2012-04-15" {
+		t.Fatalf("expected the other fields to be kept, got %v", fields)
+	}
+}
+
+func TestParseTesseractTSV(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t100\t100\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t90\tPASSPORT\n" +
+		"5\t1\t1\t1\t2\t1\t0\t0\t10\t10\t80\tAnna\n" +
+		"5\t1\t1\t1\t2\t2\t0\t0\t10\t10\t70\tMaria\n"
+
+	text, confidence := parseTesseractTSV(strings.NewReader(tsv))
+	if text != "PASSPORT\nAnna Maria" {
+		t.Fatalf("text = %q", text)
+	}
+	if confidence < 0.799 || confidence > 0.801 {
+		t.Fatalf("confidence = %v, want 0.8", confidence)
+	}
+}
+
+// fakeTesseract writes a shell script standing in for the tesseract binary
+func fakeTesseract(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tesseract")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o700); err != nil {
+		t.Fatalf("failed to write fake tesseract: %v", err)
+	}
+	return path
+}
+
+func TestTesseractProvider_Recognize(t *testing.T) {
+	path := fakeTesseract(t, `cat >/dev/null
+printf 'level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n'
+printf '5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t95\tRESIDENCE\n'`)
+
+	result, err := NewTesseractProvider(path, "").Recognize(context.Background(), "image/png", strings.NewReader("png"))
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if result.Fields[OCRFieldText] != "RESIDENCE" || result.Confidence != 0.95 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestTesseractProvider_StopsWhenContextEnds(t *testing.T) {
+	path := fakeTesseract(t, "sleep 10")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewTesseractProvider(path, "").Recognize(ctx, "image/png", strings.NewReader("png"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected tesseract to be killed at the deadline, took %v", elapsed)
+	}
+}
+
+func TestTesseractProvider_RejectsPDF(t *testing.T) {
+	_, err := NewTesseractProvider("tesseract", "").Recognize(context.Background(), "application/pdf", strings.NewReader("%PDF"))
+	if !errors.Is(err, ErrOCRUnsupportedType) {
+		t.Fatalf("expected ErrOCRUnsupportedType, got %v", err)
+	}
+}
+
+func TestRemoteOCRProvider_Recognize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "image/jpeg" || r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "jpeg" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"fields": {"surname": "ERIKSSON-LUND"}, "confidence": 0.91, "text": ` +
+			`"P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122F1204159ZE184226B<<<<<10"}`))
+	}))
+	defer server.Close()
+
+	result, err := NewRemoteOCRProvider(server.URL, "key", server.Client()).Recognize(context.Background(), "image/jpeg", strings.NewReader("jpeg"))
+	if err != nil {
+		t.Fatalf("Recognize: %v", err)
+	}
+	if result.Confidence != 0.91 {
+		t.Fatalf("confidence = %v, want 0.91", result.Confidence)
+	}
+	if result.Fields[OCRFieldSurname] != "ERIKSSON-LUND" {
+		t.Fatalf("expected the service's fields to win, got %q", result.Fields[OCRFieldSurname])
+	}
+	if result.Fields[OCRFieldDocumentNumber] != "L898902C3" {
+		t.Fatalf("expected missing fields to be read from the text, got %v", result.Fields)
+	}
+}
+
+func TestRemoteOCRProvider_ReportsServiceErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewRemoteOCRProvider(server.URL, "", server.Client()).Recognize(context.Background(), "image/png", strings.NewReader("png"))
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected the service status in the error, got %v", err)
+	}
+}
+
+func TestDocumentOCR_Extract(t *testing.T) {
+	storage := newMemoryStorage()
+	doc := &model.Document{ID: uuid.New(), FilePath: "documents/a", MimeType: "image/png", Status: model.DocumentStatusPending}
+	storage.Store(context.Background(), doc.FilePath, strings.NewReader("png"))
+	path := fakeTesseract(t, `cat >/dev/null
+printf 'level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n'
+printf '5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t88\tID\n'`)
+
+	ocr := NewDocumentOCR(newMemoryRecords(doc), storage, NewTesseractProvider(path, ""))
+	fields, confidence, err := ocr.Extract(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if fields[OCRFieldText] != "ID" || confidence != 0.88 {
+		t.Fatalf("unexpected reading %v %v", fields, confidence)
+	}
+
+	doc.Status = model.DocumentStatusQuarantined
+	if _, _, err := ocr.Extract(context.Background(), doc.ID); !errors.Is(err, ErrDocumentQuarantined) {
+		t.Fatalf("expected ErrDocumentQuarantined, got %v", err)
+	}
+}
+
+func TestExtractDocumentData_FillsDocumentFromConfidentReading(t *testing.T) {
+	doc := &model.Document{ID: uuid.New(), Status: model.DocumentStatusPending}
+	records := newMemoryRecords(doc)
+	svc := &DocumentService{records: records}
+	svc.SetOCR(&stubOCR{fields: identityFields(passportMRZ), confidence: 0.95}, DefaultOCRConfig())
+
+	extraction, err := svc.ExtractDocumentData(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("ExtractDocumentData: %v", err)
+	}
+	if extraction.NeedsReview {
+		t.Fatal("expected a confident reading not to need review")
+	}
+
+	saved, _ := records.GetByID(context.Background(), doc.ID)
+	if saved.DocumentNumber != "L898902C3" || saved.IssuingCountry != "UTO" {
+		t.Fatalf("expected the document details to be filled in, got %q %q", saved.DocumentNumber, saved.IssuingCountry)
+	}
+	if saved.ExpiryDate == nil || saved.ExpiryDate.Format("2006-01-02") != "2012-04-15" {
+		t.Fatalf("expected the expiry date to be filled in, got %v", saved.ExpiryDate)
+	}
+	if _, ok := saved.Metadata["ocr"]; !ok {
+		t.Fatal("expected the reading to be stored in the document metadata")
+	}
+	if saved.Status != model.DocumentStatusPending || len(records.history) != 0 {
+		t.Fatalf("expected the status to be left alone, got %q", saved.Status)
+	}
+}
+
+func TestExtractDocumentData_FlagsLowConfidenceForReview(t *testing.T) {
+	doc := &model.Document{ID: uuid.New(), Status: model.DocumentStatusPending}
+	records := newMemoryRecords(doc)
+	svc := &DocumentService{records: records}
+	svc.SetOCR(&stubOCR{fields: identityFields(passportMRZ), confidence: 0.4}, DefaultOCRConfig())
+
+	extraction, err := svc.ExtractDocumentData(context.Background(), doc.ID)
+	if err != nil {
+		t.Fatalf("ExtractDocumentData: %v", err)
+	}
+	if !extraction.NeedsReview {
+		t.Fatal("expected a low confidence reading to need review")
+	}
+
+	saved, _ := records.GetByID(context.Background(), doc.ID)
+	if saved.Status != model.DocumentStatusInReview {
+		t.Fatalf("status = %q, want in_review", saved.Status)
+	}
+	if saved.DocumentNumber != "" {
+		t.Fatalf("expected nothing filled in from an unreliable reading, got %q", saved.DocumentNumber)
+	}
+	if len(records.history) != 1 || records.history[0].Status != model.DocumentStatusInReview {
+		t.Fatalf("expected the review to be recorded in the history, got %+v", records.history)
+	}
+}
+
+func TestExtractDocumentData_TimesOut(t *testing.T) {
+	doc := &model.Document{ID: uuid.New(), Status: model.DocumentStatusPending}
+	svc := &DocumentService{records: newMemoryRecords(doc)}
+	svc.SetOCR(&stubOCR{block: true}, OCRConfig{Timeout: 50 * time.Millisecond, ReviewThreshold: 0.8})
+
+	if _, err := svc.ExtractDocumentData(context.Background(), doc.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the OCR timeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.ExtractDocumentData(ctx, doc.ID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled request to stop OCR, got %v", err)
+	}
+}
+
+func TestExtractDocumentData_NotConfigured(t *testing.T) {
+	svc := &DocumentService{records: newMemoryRecords()}
+	if _, err := svc.ExtractDocumentData(context.Background(), uuid.New()); !errors.Is(err, ErrOCRNotConfigured) {
+		t.Fatalf("expected ErrOCRNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TesseractProvider reads document images with the tesseract command line tool
+type TesseractProvider struct {
+	path      string
+	languages string
+}
+
+// NewTesseractProvider creates a provider running the tesseract binary at path
+// with the given languages, e.g. "eng" or "eng+deu". An empty path looks
+// tesseract up on PATH and empty languages use "eng".
+func NewTesseractProvider(path, languages string) *TesseractProvider {
+	if path == "" {
+		path = "tesseract"
+	}
+	if languages == "" {
+		languages = "eng"
+	}
+	return &TesseractProvider{path: path, languages: languages}
+}
+
+// Recognize pipes the image in r through tesseract. Only images are supported;
+// tesseract cannot read PDFs. The process is killed when ctx is done.
+func (p *TesseractProvider) Recognize(ctx context.Context, mimeType string, r io.Reader) (*OCRResult, error) {
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("%w: %s", ErrOCRUnsupportedType, mimeType)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path, "stdin", "stdout", "-l", p.languages, "tsv")
+	cmd.Stdin = r
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on a killed process that left its output pipes open
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	text, confidence := parseTesseractTSV(&stdout)
+	return &OCRResult{Fields: identityFields(text), Confidence: confidence}, nil
+}
+
+// parseTesseractTSV reads tesseract's TSV output, returning the recognised
+// text line by line and the mean word confidence scaled to 0-1
+func parseTesseractTSV(r io.Reader) (string, float64) {
+	var (
+		text     strings.Builder
+		lastLine string
+		total    float64
+		words    int
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// level page_num block_num par_num line_num word_num left top width height conf text
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) < 12 {
+			continue
+		}
+		conf, err := strconv.ParseFloat(cols[10], 64)
+		word := strings.TrimSpace(cols[11])
+		if err != nil || conf < 0 || word == "" {
+			continue
+		}
+
+		line := strings.Join(cols[1:5], ".")
+		switch {
+		case words == 0:
+		case line != lastLine:
+			text.WriteByte('\n')
+		default:
+			text.WriteByte(' ')
+		}
+		text.WriteString(word)
+		lastLine = line
+
+		total += conf
+		words++
+	}
+
+	if words == 0 {
+		return "", 0
+	}
+	return text.String(), total / float64(words) / 100
+}
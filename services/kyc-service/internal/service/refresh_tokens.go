@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated out is presented again. Either the client or someone who stole the
+// token used it first, so every refresh token issued to the user is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// RefreshTokenStore tracks the refresh tokens issued to each user, its token
+// family, so a rotated-out token presented again can be detected
+type RefreshTokenStore interface {
+	// Rotate records newJTI as issued to userID and oldJTI, if not empty, as
+	// rotated out. It returns ErrRefreshTokenReused, recording nothing, if oldJTI
+	// is no longer one of the user's current tokens, e.g. because a concurrent
+	// refresh rotated it out first.
+	Rotate(ctx context.Context, userID uuid.UUID, oldJTI, newJTI string) error
+	// IsReused reports whether jti was rotated out and, if so, the user it was issued to
+	IsReused(ctx context.Context, jti string) (uuid.UUID, bool, error)
+	// RevokeFamily rotates out every refresh token issued to userID
+	RevokeFamily(ctx context.Context, userID uuid.UUID) error
+}
+
+// refreshTokenID identifies an opaque refresh token without storing the token itself
+func refreshTokenID(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedisRefreshTokenStore is a RefreshTokenStore keeping a set of current token
+// IDs per user and one expiring key per rotated-out token
+type RedisRefreshTokenStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisRefreshTokenStore creates a store with keys under prefix, e.g.
+// "kyc:refresh:". ttl should be the refresh token lifetime: a rotated-out token
+// is remembered until it would have expired.
+func NewRedisRefreshTokenStore(client *redis.Client, prefix string, ttl time.Duration) *RedisRefreshTokenStore {
+	return &RedisRefreshTokenStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (s *RedisRefreshTokenStore) familyKey(userID uuid.UUID) string {
+	return s.prefix + "family:" + userID.String()
+}
+
+func (s *RedisRefreshTokenStore) rotatedKey(jti string) string {
+	return s.prefix + "rotated:" + jti
+}
+
+// rotateScript moves ARGV[1] from the family set KEYS[1] to the rotated-out key
+// KEYS[2] and adds ARGV[2], in one step so that only one of two concurrent
+// rotations of the same token succeeds. It returns 0 if ARGV[1] was not in the
+// family.
+var rotateScript = redis.NewScript(`
+if ARGV[1] ~= "" then
+	if redis.call("SREM", KEYS[1], ARGV[1]) == 0 then
+		return 0
+	end
+	redis.call("SET", KEYS[2], ARGV[3], "PX", ARGV[4])
+end
+redis.call("SADD", KEYS[1], ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return 1
+`)
+
+// Rotate implements RefreshTokenStore.Rotate
+func (s *RedisRefreshTokenStore) Rotate(ctx context.Context, userID uuid.UUID, oldJTI, newJTI string) error {
+	keys := []string{s.familyKey(userID), s.rotatedKey(oldJTI)}
+	rotated, err := rotateScript.Run(ctx, s.client, keys, oldJTI, newJTI, userID.String(), s.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if rotated == 0 {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// IsReused implements RefreshTokenStore.IsReused
+func (s *RedisRefreshTokenStore) IsReused(ctx context.Context, jti string) (uuid.UUID, bool, error) {
+	owner, err := s.client.Get(ctx, s.rotatedKey(jti)).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	userID, err := uuid.Parse(owner)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return userID, true, nil
+}
+
+// RevokeFamily implements RefreshTokenStore.RevokeFamily
+func (s *RedisRefreshTokenStore) RevokeFamily(ctx context.Context, userID uuid.UUID) error {
+	family := s.familyKey(userID)
+	jtis, err := s.client.SMembers(ctx, family).Result()
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, jti := range jtis {
+			pipe.Set(ctx, s.rotatedKey(jti), userID.String(), s.ttl)
+		}
+		pipe.Del(ctx, family)
+		return nil
+	})
+	return err
+}
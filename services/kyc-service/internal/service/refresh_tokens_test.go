@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"sparkfund/services/kyc-service/internal/model"
+)
+
+// memoryUsers is an authUserStore kept in memory
+type memoryUsers map[uuid.UUID]*model.User
+
+func (u memoryUsers) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	for _, user := range u {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (u memoryUsers) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	if user, ok := u[id]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func (u memoryUsers) Update(ctx context.Context, user *model.User) error {
+	u[user.ID] = user
+	return nil
+}
+
+// memorySessions is an authSessionStore kept in memory
+type memorySessions map[uuid.UUID]*model.Session
+
+func (m memorySessions) Create(ctx context.Context, session *model.Session) error {
+	m[session.ID] = session
+	return nil
+}
+
+func (m memorySessions) GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	for _, session := range m {
+		if session.RefreshToken == refreshToken {
+			return session, nil
+		}
+	}
+	return nil, errors.New("session not found")
+}
+
+func (m memorySessions) Update(ctx context.Context, session *model.Session) error {
+	m[session.ID] = session
+	return nil
+}
+
+func (m memorySessions) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(m, id)
+	return nil
+}
+
+func (m memorySessions) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	for id, session := range m {
+		if session.UserID == userID {
+			delete(m, id)
+		}
+	}
+	return nil
+}
+
+// memoryRefreshTokens is a RefreshTokenStore kept in memory
+type memoryRefreshTokens struct {
+	families map[uuid.UUID]map[string]bool
+	rotated  map[string]uuid.UUID
+}
+
+func (s *memoryRefreshTokens) Rotate(ctx context.Context, userID uuid.UUID, oldJTI, newJTI string) error {
+	if s.families[userID] == nil {
+		s.families[userID] = map[string]bool{}
+	}
+	if oldJTI != "" {
+		if !s.families[userID][oldJTI] {
+			return ErrRefreshTokenReused
+		}
+		delete(s.families[userID], oldJTI)
+		s.rotated[oldJTI] = userID
+	}
+	s.families[userID][newJTI] = true
+	return nil
+}
+
+func (s *memoryRefreshTokens) IsReused(ctx context.Context, jti string) (uuid.UUID, bool, error) {
+	userID, ok := s.rotated[jti]
+	return userID, ok, nil
+}
+
+func (s *memoryRefreshTokens) RevokeFamily(ctx context.Context, userID uuid.UUID) error {
+	for jti := range s.families[userID] {
+		s.rotated[jti] = userID
+	}
+	delete(s.families, userID)
+	return nil
+}
+
+func TestRefreshToken_ReuseRevokesTokenFamily(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-Horse-9"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: uuid.New(), Email: "frank@example.com", Role: "user", PasswordHash: string(hash)}
+	sessions := memorySessions{}
+	store := &memoryRefreshTokens{families: map[uuid.UUID]map[string]bool{}, rotated: map[string]uuid.UUID{}}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	svc := NewAuthService(nil, nil, logger, "test-secret", time.Hour, false)
+	svc.userRepo = memoryUsers{user.ID: user}
+	svc.sessionRepo = sessions
+	svc.SetRefreshTokenStore(store)
+	ctx := context.Background()
+
+	login, err := svc.Login(ctx, model.LoginRequest{Email: user.Email, Password: "correct-Horse-9"}, model.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("unexpected login error: %v", err)
+	}
+	first := login.RefreshToken
+
+	refreshed, err := svc.RefreshToken(ctx, model.RefreshTokenRequest{RefreshToken: first})
+	if err != nil {
+		t.Fatalf("unexpected refresh error: %v", err)
+	}
+	second := refreshed.RefreshToken
+	if second == first {
+		t.Fatal("expected refresh to issue a new refresh token")
+	}
+
+	// The rotated-out token is presented again, e.g. by whoever stole it
+	if _, err := svc.RefreshToken(ctx, model.RefreshTokenRequest{RefreshToken: first}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("expected every session of the user to be revoked, %d left", len(sessions))
+	}
+
+	// The token issued by the legitimate refresh was revoked with its family
+	if _, err := svc.RefreshToken(ctx, model.RefreshTokenRequest{RefreshToken: second}); err == nil {
+		t.Fatal("expected the current refresh token to be revoked")
+	}
+}
+
+// staleSessions is an authSessionStore whose lookups return the session as it
+// was created, as two refreshes racing on one refresh token both read it
+type staleSessions struct {
+	memorySessions
+	created map[string]model.Session
+}
+
+func (m staleSessions) Create(ctx context.Context, session *model.Session) error {
+	m.created[session.RefreshToken] = *session
+	return m.memorySessions.Create(ctx, session)
+}
+
+func (m staleSessions) GetByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	if session, ok := m.created[refreshToken]; ok {
+		return &session, nil
+	}
+	return nil, errors.New("session not found")
+}
+
+func TestRefreshToken_ConcurrentRotationFailsSecond(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-Horse-9"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: uuid.New(), Email: "grace@example.com", Role: "user", PasswordHash: string(hash)}
+	sessions := staleSessions{memorySessions: memorySessions{}, created: map[string]model.Session{}}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	svc := NewAuthService(nil, nil, logger, "test-secret", time.Hour, false)
+	svc.userRepo = memoryUsers{user.ID: user}
+	svc.sessionRepo = sessions
+	svc.SetRefreshTokenStore(&memoryRefreshTokens{families: map[uuid.UUID]map[string]bool{}, rotated: map[string]uuid.UUID{}})
+	ctx := context.Background()
+
+	login, err := svc.Login(ctx, model.LoginRequest{Email: user.Email, Password: "correct-Horse-9"}, model.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("unexpected login error: %v", err)
+	}
+
+	if _, err := svc.RefreshToken(ctx, model.RefreshTokenRequest{RefreshToken: login.RefreshToken}); err != nil {
+		t.Fatalf("unexpected refresh error: %v", err)
+	}
+	// The second refresh read the session before the first updated it
+	if _, err := svc.RefreshToken(ctx, model.RefreshTokenRequest{RefreshToken: login.RefreshToken}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if len(sessions.memorySessions) != 0 {
+		t.Fatalf("expected every session of the user to be revoked, %d left", len(sessions.memorySessions))
+	}
+}
+
+func TestRefreshToken_UnknownTokenIsNotReuse(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	svc := NewAuthService(nil, nil, logger, "test-secret", time.Hour, false)
+	svc.sessionRepo = memorySessions{}
+	svc.SetRefreshTokenStore(&memoryRefreshTokens{families: map[uuid.UUID]map[string]bool{}, rotated: map[string]uuid.UUID{}})
+
+	_, err := svc.RefreshToken(context.Background(), model.RefreshTokenRequest{RefreshToken: "never-issued"})
+	if err == nil || errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected an invalid refresh token error, got %v", err)
+	}
+}
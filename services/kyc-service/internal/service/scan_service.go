@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+)
+
+var (
+	// ErrInfectedFile is returned when the scanner reports malware in an upload
+	ErrInfectedFile = errors.New("file failed virus scan")
+	// ErrScanUnavailable is returned when the scanner errors or times out and the policy is fail-closed
+	ErrScanUnavailable = errors.New("virus scan unavailable")
+	// ErrDocumentQuarantined is returned when a verification would reference an infected document
+	ErrDocumentQuarantined = errors.New("document is quarantined")
+	// ErrDocumentScanning is returned when a verification would reference a document still being scanned
+	ErrDocumentScanning = errors.New("document is still being scanned")
+)
+
+// ScanResult holds the outcome of a malware scan
+type ScanResult struct {
+	Clean     bool      `json:"clean"`
+	Signature string    `json:"signature,omitempty"`
+	Engine    string    `json:"engine,omitempty"`
+	Skipped   bool      `json:"skipped,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// ScanProvider scans file content for malware (e.g. a ClamAV daemon)
+type ScanProvider interface {
+	Scan(ctx context.Context, fileName string, r io.Reader) (*ScanResult, error)
+}
+
+// Scanner is the minimal scanning hook for document content. clean is false
+// when malware was found, and detail then names it, e.g. by signature. Wrap a
+// Scanner with ScannerProvider to scan uploads with it.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (clean bool, detail string, err error)
+}
+
+// ScannerProvider adapts scanner to the ScanProvider a VirusScanner applies
+// its policy around
+func ScannerProvider(scanner Scanner) ScanProvider {
+	return scannerProvider{scanner: scanner}
+}
+
+// scannerProvider is a ScanProvider backed by a Scanner
+type scannerProvider struct {
+	scanner Scanner
+}
+
+func (p scannerProvider) Scan(ctx context.Context, fileName string, r io.Reader) (*ScanResult, error) {
+	clean, detail, err := p.scanner.Scan(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	result := &ScanResult{Clean: clean}
+	if !clean {
+		result.Signature = detail
+	}
+	return result, nil
+}
+
+// ScanConfig holds the virus scanning policy
+type ScanConfig struct {
+	// Timeout bounds a single scan
+	Timeout time.Duration
+	// FailOpen accepts uploads when the scanner errors or times out
+	FailOpen bool
+	// Async stores the document in the scanning state and scans in the background
+	Async bool
+	// QuarantineDir receives infected files instead of the upload directory
+	QuarantineDir string
+}
+
+// DefaultScanConfig returns the default scanning policy
+func DefaultScanConfig() ScanConfig {
+	return ScanConfig{
+		Timeout:       30 * time.Second,
+		FailOpen:      false,
+		Async:         false,
+		QuarantineDir: "/data/quarantine",
+	}
+}
+
+// VirusScanner applies the scanning policy around a ScanProvider
+type VirusScanner struct {
+	provider ScanProvider
+	config   ScanConfig
+}
+
+// NewVirusScanner creates a new virus scanner
+func NewVirusScanner(provider ScanProvider, config ScanConfig) *VirusScanner {
+	return &VirusScanner{
+		provider: provider,
+		config:   config,
+	}
+}
+
+// Async reports whether uploads should be scanned in the background
+func (v *VirusScanner) Async() bool {
+	return v.config.Async
+}
+
+// Check scans the file data. It returns ErrInfectedFile when malware is found and
+// ErrScanUnavailable when the scan fails under a fail-closed policy. The returned
+// result is non-nil in every case so it can be recorded on the document.
+func (v *VirusScanner) Check(ctx context.Context, fileName string, data []byte) (*ScanResult, error) {
+	if v.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.config.Timeout)
+		defer cancel()
+	}
+
+	type scanOutcome struct {
+		result *ScanResult
+		err    error
+	}
+
+	// Run the scan in its own goroutine so a provider that ignores the
+	// context cannot hold the upload past the timeout
+	done := make(chan scanOutcome, 1)
+	go func() {
+		result, err := v.provider.Scan(ctx, fileName, bytes.NewReader(data))
+		done <- scanOutcome{result: result, err: err}
+	}()
+
+	var outcome scanOutcome
+	select {
+	case outcome = <-done:
+	case <-ctx.Done():
+		outcome = scanOutcome{err: ctx.Err()}
+	}
+
+	if outcome.err == nil && outcome.result == nil {
+		outcome.err = errors.New("scanner returned no result")
+	}
+
+	if outcome.err != nil {
+		result := &ScanResult{
+			Skipped:   true,
+			Error:     outcome.err.Error(),
+			ScannedAt: time.Now(),
+		}
+		if v.config.FailOpen {
+			result.Clean = true
+			return result, nil
+		}
+		return result, fmt.Errorf("%w: %v", ErrScanUnavailable, outcome.err)
+	}
+
+	result := outcome.result
+	if result.ScannedAt.IsZero() {
+		result.ScannedAt = time.Now()
+	}
+	if !result.Clean {
+		return result, fmt.Errorf("%w: %s", ErrInfectedFile, result.Signature)
+	}
+
+	return result, nil
+}
+
+// Quarantine writes an infected file to the quarantine directory and returns its path
+func (v *VirusScanner) Quarantine(fileHash string, data []byte) (string, error) {
+	if err := os.MkdirAll(v.config.QuarantineDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	path := filepath.Join(v.config.QuarantineDir, fileHash)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to quarantine file: %w", err)
+	}
+
+	return path, nil
+}
+
+// checkScanned returns ErrDocumentQuarantined or ErrDocumentScanning for a
+// document that may not be verified because it failed, or has not yet passed,
+// its virus scan
+func checkScanned(doc *model.Document) error {
+	switch doc.Status {
+	case model.DocumentStatusInfected:
+		return ErrDocumentQuarantined
+	case model.DocumentStatusScanning:
+		return ErrDocumentScanning
+	}
+	return nil
+}
+
+// toMetadata converts the scan result into document metadata
+func (r *ScanResult) toMetadata() map[string]interface{} {
+	metadata := map[string]interface{}{
+		"clean":      r.Clean,
+		"scanned_at": r.ScannedAt,
+	}
+	if r.Signature != "" {
+		metadata["signature"] = r.Signature
+	}
+	if r.Engine != "" {
+		metadata["engine"] = r.Engine
+	}
+	if r.Skipped {
+		metadata["skipped"] = true
+		metadata["error"] = r.Error
+	}
+	return metadata
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+)
+
+// stubScanProvider flags any file containing the EICAR marker as infected
+type stubScanProvider struct {
+	delay time.Duration
+	err   error
+}
+
+func (p *stubScanProvider) Scan(ctx context.Context, fileName string, r io.Reader) (*ScanResult, error) {
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(data), "EICAR-STANDARD-ANTIVIRUS-TEST-FILE") {
+		return &ScanResult{Clean: false, Signature: "Eicar-Test-Signature", Engine: "stub"}, nil
+	}
+	return &ScanResult{Clean: true, Engine: "stub"}, nil
+}
+
+func newTestScanner(t *testing.T, provider ScanProvider, failOpen bool) *VirusScanner {
+	config := DefaultScanConfig()
+	config.Timeout = 50 * time.Millisecond
+	config.FailOpen = failOpen
+	config.QuarantineDir = filepath.Join(t.TempDir(), "quarantine")
+	return NewVirusScanner(provider, config)
+}
+
+func TestVirusScanner_InfectedFileRejected(t *testing.T) {
+	scanner := newTestScanner(t, &stubScanProvider{}, false)
+	data := []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*")
+
+	result, err := scanner.Check(context.Background(), "passport.pdf", data)
+	if !errors.Is(err, ErrInfectedFile) {
+		t.Fatalf("expected ErrInfectedFile, got %v", err)
+	}
+	if result.Clean || result.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("unexpected scan result: %+v", result)
+	}
+
+	path, err := scanner.Quarantine("infected-hash", data)
+	if err != nil {
+		t.Fatalf("quarantine failed: %v", err)
+	}
+	if filepath.Dir(path) != scanner.config.QuarantineDir {
+		t.Fatalf("expected file in quarantine directory, got %s", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("quarantined file missing: %v", err)
+	}
+}
+
+func TestVirusScanner_CleanFileProceeds(t *testing.T) {
+	scanner := newTestScanner(t, &stubScanProvider{}, false)
+
+	result, err := scanner.Check(context.Background(), "passport.pdf", []byte("%PDF-1.7 clean document"))
+	if err != nil {
+		t.Fatalf("expected clean file to pass, got %v", err)
+	}
+	if !result.Clean || result.Skipped {
+		t.Fatalf("unexpected scan result: %+v", result)
+	}
+	if result.toMetadata()["clean"] != true {
+		t.Fatalf("expected clean result to be recorded in metadata")
+	}
+}
+
+func TestVirusScanner_TimeoutPolicy(t *testing.T) {
+	slow := &stubScanProvider{delay: time.Second}
+
+	_, err := newTestScanner(t, slow, false).Check(context.Background(), "passport.pdf", []byte("data"))
+	if !errors.Is(err, ErrScanUnavailable) {
+		t.Fatalf("fail-closed: expected ErrScanUnavailable, got %v", err)
+	}
+
+	result, err := newTestScanner(t, slow, true).Check(context.Background(), "passport.pdf", []byte("data"))
+	if err != nil {
+		t.Fatalf("fail-open: expected upload to proceed, got %v", err)
+	}
+	if !result.Clean || !result.Skipped {
+		t.Fatalf("fail-open: expected skipped clean result, got %+v", result)
+	}
+}
+
+func TestVirusScanner_ProviderError(t *testing.T) {
+	scanner := newTestScanner(t, &stubScanProvider{err: errors.New("connection refused")}, false)
+
+	result, err := scanner.Check(context.Background(), "passport.pdf", []byte("data"))
+	if !errors.Is(err, ErrScanUnavailable) {
+		t.Fatalf("expected ErrScanUnavailable, got %v", err)
+	}
+	if result == nil || result.Error == "" {
+		t.Fatalf("expected scanner error to be recorded, got %+v", result)
+	}
+}
+
+// eicarScanner is a Scanner flagging any content containing the EICAR marker
+type eicarScanner struct{}
+
+func (eicarScanner) Scan(ctx context.Context, r io.Reader) (bool, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return false, "", err
+	}
+	if strings.Contains(string(data), "EICAR-STANDARD-ANTIVIRUS-TEST-FILE") {
+		return false, "Eicar-Test-Signature", nil
+	}
+	return true, "", nil
+}
+
+func TestScannerProvider_AppliesScanPolicy(t *testing.T) {
+	scanner := newTestScanner(t, ScannerProvider(eicarScanner{}), false)
+
+	if _, err := scanner.Check(context.Background(), "passport.pdf", []byte("%PDF-1.7 clean document")); err != nil {
+		t.Fatalf("expected clean file to pass, got %v", err)
+	}
+
+	result, err := scanner.Check(context.Background(), "passport.pdf", []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE"))
+	if !errors.Is(err, ErrInfectedFile) || result.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("expected the infected file to be refused with its signature, got %v %+v", err, result)
+	}
+}
+
+func TestCheckScanned(t *testing.T) {
+	tests := []struct {
+		status  model.DocumentStatus
+		wantErr error
+	}{
+		{status: model.DocumentStatusPending},
+		{status: model.DocumentStatusVerified},
+		{status: model.DocumentStatusScanning, wantErr: ErrDocumentScanning},
+		{status: model.DocumentStatusQuarantined, wantErr: ErrDocumentQuarantined},
+		{status: model.DocumentStatusInfected, wantErr: ErrDocumentQuarantined},
+	}
+	for _, tt := range tests {
+		if err := checkScanned(&model.Document{Status: tt.status}); err != tt.wantErr {
+			t.Errorf("checkScanned(%s) = %v, want %v", tt.status, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"sparkfund/services/kyc-service/internal/config"
+	"sparkfund/services/kyc-service/internal/repository"
+)
+
+// Services holds the services the API handlers are built from
+type Services struct {
+	Document     *DocumentService
+	KYC          *KYCService
+	Verification *VerificationService
+}
+
+// ServicesDeps holds the dependencies of NewServices
+type ServicesDeps struct {
+	Repos          *repository.Repositories
+	EventPublisher EventPublisher
+	Config         *config.Config
+}
+
+// NewServices creates the services over deps.Repos. Optional collaborators such
+// as storage, scanning and webhooks are set on the returned services.
+func NewServices(deps ServicesDeps) *Services {
+	repos := deps.Repos
+	return &Services{
+		Document:     NewDocumentService(repos.Document, repos.Verification),
+		KYC:          NewKYCService(repos.KYC, repos.Document, repos.Verification, deps.EventPublisher),
+		Verification: NewVerificationService(repos.Verification, repos.Document, repos.KYC),
+	}
+}
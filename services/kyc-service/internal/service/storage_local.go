@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// localTempPrefix marks files still being written, which List skips
+const localTempPrefix = ".upload-"
+
+// LocalStorage keeps documents on the local filesystem under a root directory.
+// Replicas do not share it, so it only suits a single instance; use S3Storage
+// when the service is scaled out.
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a store rooted at dir, creating the directory if needed
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStorage{root: dir}, nil
+}
+
+// resolve maps a storage path to a file under the root. Paths are cleaned as
+// if rooted, so ".." cannot escape the root.
+func (s *LocalStorage) resolve(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+// Store writes content to path, replacing any existing file. The file is
+// written under a temporary name and renamed, so readers never see a partial
+// file.
+func (s *LocalStorage) Store(ctx context.Context, p string, content io.Reader) error {
+	name := s.resolve(p)
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), localTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get opens the file at path. The caller must close it.
+func (s *LocalStorage) Get(ctx context.Context, p string) (io.ReadCloser, error) {
+	f, err := os.Open(s.resolve(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file at path. Deleting a missing file is not an error.
+func (s *LocalStorage) Delete(ctx context.Context, p string) error {
+	err := os.Remove(s.resolve(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List returns the files whose paths start with prefix
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	var objects []StoredObject
+	err := filepath.WalkDir(s.root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), localTempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		p := filepath.ToSlash(rel)
+		if !strings.HasPrefix(p, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, StoredObject{Path: p, ModifiedAt: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+	return objects, nil
+}
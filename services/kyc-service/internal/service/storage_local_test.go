@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorage_StoreGetDelete(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := storage.Store(ctx, "documents/a", strings.NewReader("passport")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	content, err := storage.Get(ctx, "documents/a")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "passport" {
+		t.Fatalf("read %q, want passport", data)
+	}
+
+	if err := storage.Delete(ctx, "documents/a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := storage.Get(ctx, "documents/a"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound after delete, got %v", err)
+	}
+	if err := storage.Delete(ctx, "documents/a"); err != nil {
+		t.Fatalf("deleting a missing file: %v", err)
+	}
+}
+
+func TestLocalStorage_List(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	ctx := context.Background()
+	for _, p := range []string{"documents/b", "documents/a", "quarantine/c"} {
+		if err := storage.Store(ctx, p, strings.NewReader(p)); err != nil {
+			t.Fatalf("Store %s: %v", p, err)
+		}
+	}
+
+	objects, err := storage.List(ctx, DocumentKeyPrefix)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Path != "documents/a" || objects[1].Path != "documents/b" {
+		t.Fatalf("unexpected listing %+v", objects)
+	}
+	if objects[0].ModifiedAt.IsZero() {
+		t.Fatal("expected a modification time")
+	}
+}
+
+func TestLocalStorage_StaysInsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	storage, err := NewLocalStorage(root)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	if err := storage.Store(context.Background(), "../../escaped", strings.NewReader("x")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); !os.IsNotExist(err) {
+		t.Fatal("expected the file to stay inside the storage root")
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); err != nil {
+		t.Fatalf("expected the file under the root: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Config configures an S3 or S3-compatible document store
+type S3Config struct {
+	Bucket string
+	Region string
+	// Endpoint overrides the AWS endpoint for S3-compatible stores such as
+	// MinIO, e.g. http://minio:9000
+	Endpoint string
+	// ForcePathStyle addresses the bucket in the path rather than the host
+	// name, as MinIO usually requires
+	ForcePathStyle bool
+	// AccessKeyID and SecretAccessKey are static credentials. When empty the
+	// default AWS chain is used: environment, shared config, then the instance
+	// or task role.
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Storage keeps documents in an S3 bucket, so every replica sees the same
+// files. Downloads are served by S3 through presigned URLs.
+type S3Storage struct {
+	client *s3.S3
+	bucket string
+}
+
+// NewS3Storage creates a store for cfg.Bucket
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 storage requires a bucket")
+	}
+
+	awsConfig := aws.NewConfig().
+		WithRegion(cfg.Region).
+		WithS3ForcePathStyle(cfg.ForcePathStyle)
+	if cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(cfg.Endpoint)
+	}
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	return &S3Storage{client: s3.New(sess), bucket: cfg.Bucket}, nil
+}
+
+// Store uploads content to path, replacing any existing object
+func (s *S3Storage) Store(ctx context.Context, path string, content io.Reader) error {
+	// The request is signed over its body, so it must be seekable
+	body, ok := content.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+		Body:   body,
+	})
+	return err
+}
+
+// Get opens the object at path. The caller must close it.
+func (s *S3Storage) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// Delete removes the object at path. Deleting a missing object is not an error.
+func (s *S3Storage) Delete(ctx context.Context, path string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+	})
+	return err
+}
+
+// List returns the objects whose keys start with prefix
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]StoredObject, error) {
+	var objects []StoredObject
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := s.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			objects = append(objects, StoredObject{
+				Path:       aws.StringValue(obj.Key),
+				ModifiedAt: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored objects: %w", err)
+	}
+	return objects, nil
+}
+
+// PresignGet returns a URL that downloads the object at path directly from S3
+// until ttl has passed
+func (s *S3Storage) PresignGet(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+	})
+	req.SetContext(ctx)
+	return req.Presign(ttl)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestS3Storage_PresignGetUsesEndpointOverride(t *testing.T) {
+	storage, err := NewS3Storage(S3Config{
+		Bucket:          "kyc-documents",
+		Region:          "us-east-1",
+		Endpoint:        "http://minio:9000",
+		ForcePathStyle:  true,
+		AccessKeyID:     "minio",
+		SecretAccessKey: "minio-secret",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	// Presigning is local, so no server is needed
+	link, err := storage.PresignGet(context.Background(), "documents/passport", 5*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid presigned URL %q: %v", link, err)
+	}
+	if parsed.Host != "minio:9000" || parsed.Path != "/kyc-documents/documents/passport" {
+		t.Fatalf("expected a path-style MinIO URL, got %s", link)
+	}
+	if got := parsed.Query().Get("X-Amz-Expires"); got != "300" {
+		t.Fatalf("X-Amz-Expires = %q, want 300", got)
+	}
+	if parsed.Query().Get("X-Amz-Signature") == "" {
+		t.Fatal("expected the URL to be signed")
+	}
+}
+
+func TestNewS3Storage_RequiresBucket(t *testing.T) {
+	if _, err := NewS3Storage(S3Config{Region: "us-east-1"}); err == nil {
+		t.Fatal("expected an error without a bucket")
+	}
+}
@@ -2,12 +2,31 @@ package service
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
-// StorageService defines the interface for document storage operations
+// ErrObjectNotFound is returned by Get for paths with nothing stored
+var ErrObjectNotFound = errors.New("stored object not found")
+
+// StorageService defines the interface for document storage operations. Paths
+// are slash-separated keys relative to the store, such as documents/<id>.
 type StorageService interface {
 	Store(ctx context.Context, path string, content io.Reader) error
 	Get(ctx context.Context, path string) (io.ReadCloser, error)
 	Delete(ctx context.Context, path string) error
 }
+
+// StoredObject describes an object in the document store
+type StoredObject struct {
+	Path       string
+	ModifiedAt time.Time
+}
+
+// ListableStorage is a StorageService that can enumerate its objects, as the
+// orphan reconciler needs
+type ListableStorage interface {
+	StorageService
+	List(ctx context.Context, prefix string) ([]StoredObject, error)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrTokenRevoked is returned when a token was revoked by logging out
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrRevocationUnavailable is returned when the denylist cannot be reached.
+	// Tokens are rejected rather than accepted unchecked.
+	ErrRevocationUnavailable = errors.New("token revocation list unavailable")
+)
+
+// TokenDenylist records revoked tokens by their jti claim until they would
+// have expired anyway
+type TokenDenylist interface {
+	// Revoke denies the token with the given jti for ttl
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	// IsRevoked reports whether the token with the given jti was revoked
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// RedisTokenDenylist is a TokenDenylist keeping one expiring key per revoked token
+type RedisTokenDenylist struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisTokenDenylist creates a denylist storing keys under prefix, e.g. "kyc:denylist:"
+func NewRedisTokenDenylist(client *redis.Client, prefix string) *RedisTokenDenylist {
+	return &RedisTokenDenylist{client: client, prefix: prefix}
+}
+
+// Revoke implements TokenDenylist.Revoke
+func (d *RedisTokenDenylist) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	return d.client.Set(ctx, d.prefix+jti, 1, ttl).Err()
+}
+
+// IsRevoked implements TokenDenylist.IsRevoked
+func (d *RedisTokenDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := d.client.Exists(ctx, d.prefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
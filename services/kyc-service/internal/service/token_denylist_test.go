@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"sparkfund/services/kyc-service/internal/model"
+)
+
+// memoryDenylist is a TokenDenylist kept in memory, failing every call when down
+type memoryDenylist struct {
+	revoked map[string]time.Duration
+	down    bool
+}
+
+func (d *memoryDenylist) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if d.down {
+		return errors.New("connection refused")
+	}
+	d.revoked[jti] = ttl
+	return nil
+}
+
+func (d *memoryDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if d.down {
+		return false, errors.New("connection refused")
+	}
+	_, ok := d.revoked[jti]
+	return ok, nil
+}
+
+func newTestAuthService(t *testing.T, denylist TokenDenylist) (*AuthService, string) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	svc := NewAuthService(nil, nil, logger, "test-secret", time.Hour, false)
+	svc.SetTokenDenylist(denylist)
+
+	token, _, err := svc.generateJWT(&model.User{ID: uuid.New(), Email: "erin@example.com", Role: "user"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	return svc, token
+}
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	denylist := &memoryDenylist{revoked: map[string]time.Duration{}}
+	svc, token := newTestAuthService(t, denylist)
+	ctx := context.Background()
+
+	claims, err := svc.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("expected a fresh token to validate, got %v", err)
+	}
+	if claims.TokenID == "" {
+		t.Fatal("expected the token to carry a jti")
+	}
+
+	if err := svc.Logout(ctx, "", token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ttl, ok := denylist.revoked[claims.TokenID]
+	if !ok {
+		t.Fatal("expected the token's jti to be denied")
+	}
+	if ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected the denial to last the token's remaining lifetime, got %v", ttl)
+	}
+
+	if _, err := svc.ValidateToken(ctx, token); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected ErrTokenRevoked, got %v", err)
+	}
+}
+
+func TestValidateToken_FailsClosedWithoutDenylist(t *testing.T) {
+	denylist := &memoryDenylist{revoked: map[string]time.Duration{}, down: true}
+	svc, token := newTestAuthService(t, denylist)
+	ctx := context.Background()
+
+	if _, err := svc.ValidateToken(ctx, token); !errors.Is(err, ErrRevocationUnavailable) {
+		t.Fatalf("expected ErrRevocationUnavailable, got %v", err)
+	}
+	if err := svc.Logout(ctx, "", token); !errors.Is(err, ErrRevocationUnavailable) {
+		t.Fatalf("expected logout to fail, got %v", err)
+	}
+}
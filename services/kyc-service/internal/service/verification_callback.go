@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/domain"
+	"sparkfund/services/kyc-service/internal/mapper"
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCallback is returned for a vendor callback that cannot be decoded or applied
+	ErrInvalidCallback = errors.New("invalid vendor callback")
+	// ErrUnknownReference is returned when no verification carries the callback's reference
+	ErrUnknownReference = errors.New("no verification matches the callback reference")
+)
+
+// VendorCallbackConfig configures how vendor callbacks are correlated and deduplicated
+type VendorCallbackConfig struct {
+	// ReferenceField is the payload field holding the verification's external reference
+	ReferenceField string
+	// EventIDField is the payload field identifying the callback event. Callbacks
+	// without it are identified by a hash of their body.
+	EventIDField string
+}
+
+// VendorCallback is a verification result posted back by the vendor
+type VendorCallback struct {
+	Reference  string
+	EventKey   string
+	Decision   string
+	Confidence float64
+	Notes      string
+}
+
+// VerifyCallbackSignature reports whether signature is the hex encoded
+// HMAC-SHA256 of body under secret, optionally prefixed with "sha256=". Nothing
+// verifies against an empty secret.
+func VerifyCallbackSignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParseVendorCallback decodes a callback body. The reference and event ID are
+// read from the fields named in cfg; the result is read from decision,
+// confidence and notes.
+func ParseVendorCallback(body []byte, cfg VendorCallbackConfig) (*VendorCallback, error) {
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallback, err)
+	}
+
+	field := func(name string) string {
+		if value, ok := payload[name]; ok && value != nil {
+			return strings.TrimSpace(fmt.Sprint(value))
+		}
+		return ""
+	}
+
+	callback := &VendorCallback{
+		Reference: field(cfg.ReferenceField),
+		EventKey:  field(cfg.EventIDField),
+		Decision:  field("decision"),
+		Notes:     field("notes"),
+	}
+	if callback.Reference == "" {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidCallback, cfg.ReferenceField)
+	}
+	if callback.EventKey == "" {
+		sum := sha256.Sum256(body)
+		callback.EventKey = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if raw := field("confidence"); raw != "" {
+		var confidence float64
+		if _, err := fmt.Sscan(raw, &confidence); err != nil {
+			return nil, fmt.Errorf("%w: confidence %q is not a number", ErrInvalidCallback, raw)
+		}
+		callback.Confidence = confidence
+	}
+	return callback, nil
+}
+
+// HandleVendorCallback applies a vendor result to the verification carrying its
+// reference, through the normal status transition rules. Every callback event is
+// applied at most once: a redelivered callback reports duplicate and changes
+// nothing.
+func (s *VerificationService) HandleVendorCallback(ctx context.Context, callback *VendorCallback) (verification *domain.EnhancedVerification, duplicate bool, err error) {
+	status := model.VerificationStatus(strings.ToLower(callback.Decision))
+	switch status {
+	case model.VerificationStatusApproved, model.VerificationStatusRejected, model.VerificationStatusFailed:
+	default:
+		return nil, false, fmt.Errorf("%w: unsupported decision %q", ErrInvalidCallback, callback.Decision)
+	}
+	if callback.Confidence < 0 || callback.Confidence > 1 {
+		return nil, false, fmt.Errorf("%w: confidence must be between 0 and 1, got %v", ErrInvalidCallback, callback.Confidence)
+	}
+
+	var updated *model.Verification
+	var previous model.VerificationStatus
+	err = s.transactions.WithinTransaction(ctx, func(store transactionStore) error {
+		current, err := store.GetByExternalReference(ctx, callback.Reference)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUnknownReference
+			}
+			return err
+		}
+
+		recorded, err := store.RecordCallback(ctx, &model.VerificationCallback{
+			ID:             uuid.New(),
+			EventKey:       callback.EventKey,
+			VerificationID: current.ID,
+			Status:         status,
+			ReceivedAt:     time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record callback: %w", err)
+		}
+		if !recorded {
+			duplicate = true
+			return nil
+		}
+
+		previous = current.Status
+		if err := transitionVerification(current, status, callback.Confidence, callback.Notes); err != nil {
+			return err
+		}
+		if err := store.Update(ctx, current); err != nil {
+			return fmt.Errorf("failed to update verification: %w", err)
+		}
+
+		// Vendor results are recorded without an acting user
+		err = store.AddHistoryEntry(ctx, &model.VerificationHistory{
+			ID:             uuid.New(),
+			VerificationID: current.ID,
+			Status:         status,
+			Notes:          callback.Notes,
+			CreatedBy:      uuid.Nil,
+			CreatedAt:      time.Now(),
+			Metadata: map[string]interface{}{
+				"action":          "vendor_callback",
+				"event_key":       callback.EventKey,
+				"previous_status": string(previous),
+				"confidence":      callback.Confidence,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record callback: %w", err)
+		}
+
+		updated = current
+		return nil
+	})
+	if err != nil || duplicate {
+		return nil, duplicate, err
+	}
+
+	if updated.Status != previous {
+		s.notifyOutcome(updated)
+	}
+	if err := s.syncDocumentStatus(ctx, updated); err != nil {
+		return nil, false, err
+	}
+	return mapper.VerificationModelToDomain(updated), false, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+const testCallbackSecret = "vendor-secret"
+
+var testCallbackConfig = VendorCallbackConfig{ReferenceField: "reference_id", EventIDField: "event_id"}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyCallbackSignature(t *testing.T) {
+	body := []byte(`{"reference_id":"ref-1","decision":"approved"}`)
+	valid := sign(testCallbackSecret, body)
+
+	if !VerifyCallbackSignature(testCallbackSecret, body, valid) {
+		t.Fatal("expected a valid signature to verify")
+	}
+	if !VerifyCallbackSignature(testCallbackSecret, body, "sha256="+valid) {
+		t.Fatal("expected a prefixed signature to verify")
+	}
+
+	rejected := map[string]struct {
+		secret    string
+		body      []byte
+		signature string
+	}{
+		"wrong secret":  {"other-secret", body, valid},
+		"tampered body": {testCallbackSecret, []byte(`{"reference_id":"ref-1","decision":"rejected"}`), valid},
+		"missing":       {testCallbackSecret, body, ""},
+		"not hex":       {testCallbackSecret, body, "not-a-signature"},
+		"no secret":     {"", body, sign("", body)},
+	}
+	for name, tc := range rejected {
+		if VerifyCallbackSignature(tc.secret, tc.body, tc.signature) {
+			t.Errorf("%s: expected the signature to be rejected", name)
+		}
+	}
+}
+
+func TestHandleVendorCallback_AppliesCorrelatedResult(t *testing.T) {
+	pending := model.Verification{
+		ID:          uuid.New(),
+		Status:      model.VerificationStatusInProgress,
+		Method:      model.VerificationMethodThirdParty,
+		ExternalRef: "vendor-ref-42",
+		UpdatedAt:   time.Now(),
+	}
+	svc, store := newImportTestService(pending)
+
+	callback, err := ParseVendorCallback([]byte(`{"event_id":"evt-1","reference_id":"vendor-ref-42","decision":"approved","confidence":0.91,"notes":"match"}`), testCallbackConfig)
+	if err != nil {
+		t.Fatalf("ParseVendorCallback: %v", err)
+	}
+
+	result, duplicate, err := svc.HandleVendorCallback(context.Background(), callback)
+	if err != nil || duplicate {
+		t.Fatalf("HandleVendorCallback: duplicate=%v err=%v", duplicate, err)
+	}
+	if result.ID != pending.ID {
+		t.Fatalf("expected the referenced verification, got %s", result.ID)
+	}
+
+	saved, _ := store.GetByID(context.Background(), pending.ID)
+	if saved.Status != model.VerificationStatusApproved || saved.ConfidenceScore != 0.91 || saved.Notes != "match" {
+		t.Fatalf("verification not updated: %+v", saved)
+	}
+	if len(store.history) != 1 || store.history[0].Metadata["action"] != "vendor_callback" {
+		t.Fatalf("expected the callback to be audited, got %+v", store.history)
+	}
+}
+
+func TestHandleVendorCallback_DuplicateIsIdempotent(t *testing.T) {
+	pending := model.Verification{ID: uuid.New(), Status: model.VerificationStatusPending, ExternalRef: "vendor-ref-7"}
+	svc, store := newImportTestService(pending)
+
+	body := []byte(`{"event_id":"evt-9","reference_id":"vendor-ref-7","decision":"rejected","confidence":0.3}`)
+	for attempt := 1; attempt <= 2; attempt++ {
+		callback, err := ParseVendorCallback(body, testCallbackConfig)
+		if err != nil {
+			t.Fatalf("ParseVendorCallback: %v", err)
+		}
+
+		_, duplicate, err := svc.HandleVendorCallback(context.Background(), callback)
+		if err != nil {
+			t.Fatalf("attempt %d: HandleVendorCallback: %v", attempt, err)
+		}
+		if duplicate != (attempt == 2) {
+			t.Fatalf("attempt %d: unexpected duplicate=%v", attempt, duplicate)
+		}
+	}
+
+	if len(store.history) != 1 {
+		t.Fatalf("expected the callback to be applied once, got %d history entries", len(store.history))
+	}
+	saved, _ := store.GetByID(context.Background(), pending.ID)
+	if saved.Status != model.VerificationStatusRejected {
+		t.Fatalf("expected status %q, got %q", model.VerificationStatusRejected, saved.Status)
+	}
+}
+
+func TestHandleVendorCallback_UnknownReference(t *testing.T) {
+	svc, store := newImportTestService()
+
+	callback, err := ParseVendorCallback([]byte(`{"reference_id":"missing","decision":"approved"}`), testCallbackConfig)
+	if err != nil {
+		t.Fatalf("ParseVendorCallback: %v", err)
+	}
+
+	_, _, err = svc.HandleVendorCallback(context.Background(), callback)
+	if !errors.Is(err, ErrUnknownReference) {
+		t.Fatalf("expected ErrUnknownReference, got %v", err)
+	}
+	if len(store.callbacks) != 0 {
+		t.Fatal("an unmatched callback must not be recorded as processed")
+	}
+}
+
+func TestParseVendorCallback_ConfigurableFields(t *testing.T) {
+	body := []byte(`{"id":12345,"case":"case-1","decision":"approved"}`)
+
+	callback, err := ParseVendorCallback(body, VendorCallbackConfig{ReferenceField: "case", EventIDField: "id"})
+	if err != nil {
+		t.Fatalf("ParseVendorCallback: %v", err)
+	}
+	if callback.Reference != "case-1" || callback.EventKey != "12345" {
+		t.Fatalf("unexpected callback %+v", callback)
+	}
+
+	// Without an event ID the body identifies the callback
+	callback, err = ParseVendorCallback(body, VendorCallbackConfig{ReferenceField: "case", EventIDField: "event_id"})
+	if err != nil {
+		t.Fatalf("ParseVendorCallback: %v", err)
+	}
+	if callback.EventKey == "" {
+		t.Fatal("expected an event key derived from the body")
+	}
+
+	if _, err := ParseVendorCallback(body, testCallbackConfig); !errors.Is(err, ErrInvalidCallback) {
+		t.Fatalf("expected a missing reference to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+	"sparkfund/services/kyc-service/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DecisionImportBatchSize bounds the number of decisions applied in one transaction
+const DecisionImportBatchSize = 100
+
+// ErrInvalidDecision is returned for an imported decision that cannot be applied
+var ErrInvalidDecision = errors.New("invalid decision")
+
+// VerificationDecision is one row of a batch of offline review results
+type VerificationDecision struct {
+	// Row is the 1-based position of the decision in the imported file
+	Row            int     `json:"-"`
+	VerificationID string  `json:"verification_id"`
+	Decision       string  `json:"decision"`
+	Confidence     float64 `json:"confidence"`
+	Reviewer       string  `json:"reviewer"`
+	Notes          string  `json:"notes"`
+
+	// parseErr records a row that could not be read, so it is reported rather than dropped
+	parseErr error
+}
+
+// DecisionOutcome is the result of importing a single decision
+type DecisionOutcome string
+
+const (
+	// DecisionApplied means the verification moved to the decided status
+	DecisionApplied DecisionOutcome = "applied"
+	// DecisionRejected means the row was invalid or the transition was not allowed
+	DecisionRejected DecisionOutcome = "rejected"
+	// DecisionFailed means the row was valid but its batch could not be committed
+	DecisionFailed DecisionOutcome = "failed"
+)
+
+// DecisionImportResult reports what happened to one imported decision
+type DecisionImportResult struct {
+	Row            int
+	VerificationID string
+	Outcome        DecisionOutcome
+	Error          string
+}
+
+// transactionStore is the subset of VerificationRepository used within a transaction
+type transactionStore interface {
+	verificationStore
+	GetByExternalReference(ctx context.Context, reference string) (*model.Verification, error)
+	RecordCallback(ctx context.Context, callback *model.VerificationCallback) (bool, error)
+}
+
+// verificationTransactor runs fn against a transactionStore within one transaction
+type verificationTransactor interface {
+	WithinTransaction(ctx context.Context, fn func(store transactionStore) error) error
+}
+
+// repositoryTransactor adapts VerificationRepository to verificationTransactor
+type repositoryTransactor struct {
+	repo *repository.VerificationRepository
+}
+
+// WithinTransaction implements verificationTransactor
+func (t repositoryTransactor) WithinTransaction(ctx context.Context, fn func(store transactionStore) error) error {
+	return t.repo.WithinTransaction(ctx, func(tx *repository.VerificationRepository) error {
+		return fn(tx)
+	})
+}
+
+// ParseDecisionsCSV reads decisions from CSV with a header row naming the columns
+// verification_id, decision, confidence, reviewer and notes, in any order. Rows
+// with an unreadable confidence are kept and reported by ImportDecisions.
+func ParseDecisionsCSV(r io.Reader) ([]VerificationDecision, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"verification_id", "decision"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var decisions []VerificationDecision
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %d: %w", row, err)
+		}
+
+		decision := VerificationDecision{
+			Row:            row,
+			VerificationID: field(record, "verification_id"),
+			Decision:       field(record, "decision"),
+			Reviewer:       field(record, "reviewer"),
+			Notes:          field(record, "notes"),
+		}
+		if raw := field(record, "confidence"); raw != "" {
+			confidence, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				decision.parseErr = fmt.Errorf("%w: confidence %q is not a number", ErrInvalidDecision, raw)
+			}
+			decision.Confidence = confidence
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, nil
+}
+
+// ParseDecisionsJSON reads decisions from a JSON array of objects
+func ParseDecisionsJSON(r io.Reader) ([]VerificationDecision, error) {
+	var decisions []VerificationDecision
+	if err := json.NewDecoder(r).Decode(&decisions); err != nil {
+		return nil, fmt.Errorf("failed to decode decisions: %w", err)
+	}
+	for i := range decisions {
+		decisions[i].Row = i + 1
+	}
+	return decisions, nil
+}
+
+// ImportDecisions applies a batch of offline review decisions and reports the
+// outcome of every row. Each decision goes through the normal status transition
+// rules and is recorded in the verification history under actorID. Decisions are
+// applied in transactions of DecisionImportBatchSize rows; if a batch cannot be
+// committed, its valid rows are reported as failed and the import continues
+// with the next batch.
+func (s *VerificationService) ImportDecisions(ctx context.Context, decisions []VerificationDecision, actorID uuid.UUID) ([]DecisionImportResult, error) {
+	results := make([]DecisionImportResult, len(decisions))
+
+	for start := 0; start < len(decisions); start += DecisionImportBatchSize {
+		if err := ctx.Err(); err != nil {
+			return results[:start], err
+		}
+
+		end := start + DecisionImportBatchSize
+		if end > len(decisions) {
+			end = len(decisions)
+		}
+
+		applied, err := s.importDecisionBatch(ctx, decisions[start:end], results[start:end], actorID)
+		if err != nil {
+			for i := start; i < end; i++ {
+				if results[i].Outcome != DecisionRejected {
+					results[i] = DecisionImportResult{
+						Row:            decisions[i].Row,
+						VerificationID: decisions[i].VerificationID,
+						Outcome:        DecisionFailed,
+						Error:          fmt.Sprintf("batch rolled back: %v", err),
+					}
+				}
+			}
+			continue
+		}
+
+		// Webhooks are sent and documents updated once their verifications are committed
+		for i, verification := range applied {
+			if verification == nil {
+				continue
+			}
+			s.notifyOutcome(verification)
+			if err := s.syncDocumentStatus(ctx, verification); err != nil {
+				results[start+i].Error = err.Error()
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// importDecisionBatch applies batch in one transaction, filling in results. It
+// returns the updated verification for each applied row, or an error if the
+// transaction was rolled back.
+func (s *VerificationService) importDecisionBatch(ctx context.Context, batch []VerificationDecision, results []DecisionImportResult, actorID uuid.UUID) ([]*model.Verification, error) {
+	applied := make([]*model.Verification, len(batch))
+
+	err := s.transactions.WithinTransaction(ctx, func(store transactionStore) error {
+		for i, decision := range batch {
+			results[i] = DecisionImportResult{Row: decision.Row, VerificationID: decision.VerificationID}
+
+			verification, err := s.applyDecision(ctx, store, decision, actorID)
+			if err != nil {
+				// Anything but a problem with the row itself rolls back the batch
+				if !errors.Is(err, ErrInvalidDecision) && !errors.Is(err, ErrIllegalTransition) {
+					return err
+				}
+				results[i].Outcome = DecisionRejected
+				results[i].Error = err.Error()
+				continue
+			}
+
+			results[i].Outcome = DecisionApplied
+			applied[i] = verification
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// applyDecision validates decision and moves its verification to the decided status
+func (s *VerificationService) applyDecision(ctx context.Context, store verificationStore, decision VerificationDecision, actorID uuid.UUID) (*model.Verification, error) {
+	if decision.parseErr != nil {
+		return nil, decision.parseErr
+	}
+
+	id, err := uuid.Parse(decision.VerificationID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: verification ID %q is not a UUID", ErrInvalidDecision, decision.VerificationID)
+	}
+
+	status := model.VerificationStatus(strings.ToLower(decision.Decision))
+	if status != model.VerificationStatusApproved && status != model.VerificationStatusRejected {
+		return nil, fmt.Errorf("%w: decision must be approved or rejected, got %q", ErrInvalidDecision, decision.Decision)
+	}
+	if decision.Confidence < 0 || decision.Confidence > 1 {
+		return nil, fmt.Errorf("%w: confidence must be between 0 and 1, got %v", ErrInvalidDecision, decision.Confidence)
+	}
+
+	verification, err := store.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: verification %s not found", ErrInvalidDecision, id)
+		}
+		return nil, err
+	}
+
+	previous := verification.Status
+	if err := transitionVerification(verification, status, decision.Confidence, decision.Notes); err != nil {
+		return nil, err
+	}
+	if err := store.Update(ctx, verification); err != nil {
+		return nil, fmt.Errorf("failed to update verification: %w", err)
+	}
+
+	err = store.AddHistoryEntry(ctx, &model.VerificationHistory{
+		ID:             uuid.New(),
+		VerificationID: id,
+		Status:         status,
+		Notes:          decision.Notes,
+		CreatedBy:      actorID,
+		CreatedAt:      time.Now(),
+		Metadata: map[string]interface{}{
+			"action":          "import_decision",
+			"reviewer":        decision.Reviewer,
+			"previous_status": string(previous),
+			"confidence":      decision.Confidence,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record decision: %w", err)
+	}
+
+	return verification, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// transactionalStore is a memoryVerificationStore with repository-style not-found
+// errors and transactions that restore its contents when they fail
+type transactionalStore struct {
+	*memoryVerificationStore
+	failUpdate uuid.UUID
+	callbacks  map[string]bool
+}
+
+func (s *transactionalStore) GetByID(ctx context.Context, id uuid.UUID) (*model.Verification, error) {
+	s.mu.Lock()
+	_, ok := s.verifications[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.memoryVerificationStore.GetByID(ctx, id)
+}
+
+func (s *transactionalStore) Update(ctx context.Context, verification *model.Verification) error {
+	if verification.ID == s.failUpdate {
+		return errors.New("connection reset")
+	}
+	return s.memoryVerificationStore.Update(ctx, verification)
+}
+
+func (s *transactionalStore) GetByExternalReference(ctx context.Context, reference string) (*model.Verification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.verifications {
+		if v.ExternalRef == reference {
+			return &v, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *transactionalStore) RecordCallback(ctx context.Context, callback *model.VerificationCallback) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.callbacks[callback.EventKey] {
+		return false, nil
+	}
+	s.callbacks[callback.EventKey] = true
+	return true, nil
+}
+
+func (s *transactionalStore) WithinTransaction(ctx context.Context, fn func(store transactionStore) error) error {
+	s.mu.Lock()
+	snapshot := make(map[uuid.UUID]model.Verification, len(s.verifications))
+	for id, v := range s.verifications {
+		snapshot[id] = v
+	}
+	historyLen := len(s.history)
+	callbacks := make(map[string]bool, len(s.callbacks))
+	for key := range s.callbacks {
+		callbacks[key] = true
+	}
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.verifications = snapshot
+		s.history = s.history[:historyLen]
+		s.callbacks = callbacks
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func newImportTestService(verifications ...model.Verification) (*VerificationService, *transactionalStore) {
+	store := &transactionalStore{
+		memoryVerificationStore: newMemoryVerificationStore(verifications...),
+		callbacks:               make(map[string]bool),
+	}
+	return &VerificationService{store: store, transactions: store}, store
+}
+
+func TestImportDecisions_ReportsEveryRow(t *testing.T) {
+	pending := model.Verification{ID: uuid.New(), Status: model.VerificationStatusPending, UpdatedAt: time.Now()}
+	approved := model.Verification{ID: uuid.New(), Status: model.VerificationStatusApproved, UpdatedAt: time.Now()}
+	svc, store := newImportTestService(pending, approved)
+	admin := uuid.New()
+
+	results, err := svc.ImportDecisions(context.Background(), []VerificationDecision{
+		{Row: 1, VerificationID: pending.ID.String(), Decision: "approved", Confidence: 0.92, Reviewer: "vendor-7", Notes: "ID matches"},
+		{Row: 2, VerificationID: uuid.New().String(), Decision: "approved", Confidence: 0.9},
+		{Row: 3, VerificationID: approved.ID.String(), Decision: "rejected", Confidence: 0.4},
+	}, admin)
+	if err != nil {
+		t.Fatalf("ImportDecisions: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected a result per row, got %d", len(results))
+	}
+
+	// A valid decision is applied through the transition rules and audited
+	if results[0].Outcome != DecisionApplied {
+		t.Fatalf("row 1: expected applied, got %+v", results[0])
+	}
+	saved, _ := store.GetByID(context.Background(), pending.ID)
+	if saved.Status != model.VerificationStatusApproved || saved.ConfidenceScore != 0.92 || saved.CompletedAt == nil {
+		t.Fatalf("row 1: verification not updated: %+v", saved)
+	}
+	if len(store.history) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(store.history))
+	}
+	if entry := store.history[0]; entry.CreatedBy != admin || entry.Metadata["reviewer"] != "vendor-7" {
+		t.Fatalf("unexpected audit entry %+v", entry)
+	}
+
+	// An unknown verification is reported
+	if results[1].Outcome != DecisionRejected || !strings.Contains(results[1].Error, "not found") {
+		t.Fatalf("row 2: expected a not found rejection, got %+v", results[1])
+	}
+
+	// A decision on a terminal verification is refused and leaves it unchanged
+	if results[2].Outcome != DecisionRejected || !strings.Contains(results[2].Error, ErrIllegalTransition.Error()) {
+		t.Fatalf("row 3: expected an illegal transition rejection, got %+v", results[2])
+	}
+	unchanged, _ := store.GetByID(context.Background(), approved.ID)
+	if unchanged.Status != model.VerificationStatusApproved {
+		t.Fatalf("row 3: terminal verification changed to %q", unchanged.Status)
+	}
+}
+
+func TestImportDecisions_RollsBackFailedBatch(t *testing.T) {
+	first := model.Verification{ID: uuid.New(), Status: model.VerificationStatusPending}
+	second := model.Verification{ID: uuid.New(), Status: model.VerificationStatusInProgress}
+	svc, store := newImportTestService(first, second)
+	store.failUpdate = second.ID
+
+	results, err := svc.ImportDecisions(context.Background(), []VerificationDecision{
+		{Row: 1, VerificationID: first.ID.String(), Decision: "approved", Confidence: 0.9},
+		{Row: 2, VerificationID: second.ID.String(), Decision: "rejected", Confidence: 0.2},
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("ImportDecisions: %v", err)
+	}
+
+	for _, result := range results {
+		if result.Outcome != DecisionFailed {
+			t.Fatalf("expected every row of the batch to fail, got %+v", result)
+		}
+	}
+	saved, _ := store.GetByID(context.Background(), first.ID)
+	if saved.Status != model.VerificationStatusPending || len(store.history) != 0 {
+		t.Fatal("expected the batch to be rolled back")
+	}
+}
+
+func TestParseDecisionsCSV_KeepsUnreadableRows(t *testing.T) {
+	id := uuid.New().String()
+	input := "decision,verification_id,confidence,reviewer,notes\n" +
+		"APPROVED," + id + ",0.8,vendor-7,clear photo\n" +
+		"rejected," + id + ",high,vendor-7,\n"
+
+	decisions, err := ParseDecisionsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDecisionsCSV: %v", err)
+	}
+	if len(decisions) != 2 {
+		t.Fatalf("expected 2 decisions, got %d", len(decisions))
+	}
+	if d := decisions[0]; d.Row != 1 || d.VerificationID != id || d.Confidence != 0.8 || d.Notes != "clear photo" {
+		t.Fatalf("unexpected first decision %+v", d)
+	}
+	if !errors.Is(decisions[1].parseErr, ErrInvalidDecision) {
+		t.Fatalf("expected the unreadable confidence to be kept as an invalid row, got %v", decisions[1].parseErr)
+	}
+
+	if _, err := ParseDecisionsCSV(strings.NewReader("id,decision\n")); err == nil {
+		t.Fatal("expected a missing verification_id column to be refused")
+	}
+}
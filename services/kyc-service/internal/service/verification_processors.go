@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoDocument is returned when a document verification has no document to process
+var ErrNoDocument = errors.New("verification has no document")
+
+// documentExtractor is the part of DocumentService the document pipeline uses
+type documentExtractor interface {
+	ExtractDocumentData(ctx context.Context, id uuid.UUID) (*OCRExtraction, error)
+}
+
+// ManualReviewProcessor returns a verification to the manual review queue.
+// Manual verifications have no automated pipeline, so reprocessing one means
+// handing it back to a reviewer.
+func ManualReviewProcessor() VerificationProcessor {
+	return VerificationProcessorFunc(func(ctx context.Context, verification *model.Verification) (VerificationOutcome, error) {
+		return VerificationOutcome{
+			Status:          model.VerificationStatusPending,
+			ConfidenceScore: verification.ConfidenceScore,
+			Notes:           "Returned to the manual review queue",
+		}, nil
+	})
+}
+
+// DocumentOCRProcessor re-runs OCR over a verification's document. A confident
+// reading completes the verification; one below the review threshold leaves it
+// pending for manual review, as ExtractDocumentData flags the document.
+func DocumentOCRProcessor(documents documentExtractor) VerificationProcessor {
+	return VerificationProcessorFunc(func(ctx context.Context, verification *model.Verification) (VerificationOutcome, error) {
+		if verification.DocumentID == nil {
+			return VerificationOutcome{}, ErrNoDocument
+		}
+
+		extraction, err := documents.ExtractDocumentData(ctx, *verification.DocumentID)
+		if err != nil {
+			return VerificationOutcome{}, err
+		}
+
+		if extraction.NeedsReview {
+			return VerificationOutcome{
+				Status:          model.VerificationStatusPending,
+				ConfidenceScore: extraction.Confidence,
+				Notes:           fmt.Sprintf("OCR confidence %.2f needs manual review", extraction.Confidence),
+			}, nil
+		}
+		return VerificationOutcome{
+			Status:          model.VerificationStatusCompleted,
+			ConfidenceScore: extraction.Confidence,
+			Notes:           "Document data extracted by OCR",
+		}, nil
+	})
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// stubExtractor is a documentExtractor returning a fixed extraction
+type stubExtractor struct {
+	extraction *OCRExtraction
+	err        error
+}
+
+func (s stubExtractor) ExtractDocumentData(ctx context.Context, id uuid.UUID) (*OCRExtraction, error) {
+	return s.extraction, s.err
+}
+
+func TestDocumentOCRProcessor(t *testing.T) {
+	documentID := uuid.New()
+	tests := []struct {
+		name       string
+		extraction *OCRExtraction
+		want       model.VerificationStatus
+	}{
+		{"confident reading", &OCRExtraction{Confidence: 0.95}, model.VerificationStatusCompleted},
+		{"needs review", &OCRExtraction{Confidence: 0.4, NeedsReview: true}, model.VerificationStatusPending},
+	}
+	for _, tt := range tests {
+		verification := &model.Verification{DocumentID: &documentID, Status: model.VerificationStatusInProgress}
+		outcome, err := DocumentOCRProcessor(stubExtractor{extraction: tt.extraction}).Process(context.Background(), verification)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if outcome.Status != tt.want || outcome.ConfidenceScore != tt.extraction.Confidence {
+			t.Fatalf("%s: got status %q score %v", tt.name, outcome.Status, outcome.ConfidenceScore)
+		}
+		if verification.Status != model.VerificationStatusInProgress {
+			t.Fatalf("%s: the processor must leave the status change to the state machine", tt.name)
+		}
+	}
+
+	_, err := DocumentOCRProcessor(stubExtractor{}).Process(context.Background(), &model.Verification{})
+	if !errors.Is(err, ErrNoDocument) {
+		t.Fatalf("expected ErrNoDocument without a document, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/domain"
+	"sparkfund/services/kyc-service/internal/mapper"
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrVerificationTerminal is returned when reprocessing a finished verification without force
+	ErrVerificationTerminal = errors.New("verification is in a terminal state")
+	// ErrReprocessInProgress is returned when another reprocess of the same verification is running
+	ErrReprocessInProgress = errors.New("verification is already being reprocessed")
+	// ErrNoProcessor is returned when no pipeline is registered for the verification's method
+	ErrNoProcessor = errors.New("no processor registered for verification method")
+)
+
+// VerificationOutcome is the status, score and notes a pipeline reached
+type VerificationOutcome struct {
+	Status          model.VerificationStatus
+	ConfidenceScore float64
+	Notes           string
+}
+
+// VerificationProcessor runs the pipeline for one verification method, e.g. OCR
+// or face matching, and returns its outcome. The caller moves the verification
+// to the outcome through the state machine and persists it.
+type VerificationProcessor interface {
+	Process(ctx context.Context, verification *model.Verification) (VerificationOutcome, error)
+}
+
+// VerificationProcessorFunc adapts a function to VerificationProcessor
+type VerificationProcessorFunc func(ctx context.Context, verification *model.Verification) (VerificationOutcome, error)
+
+// Process calls f
+func (f VerificationProcessorFunc) Process(ctx context.Context, verification *model.Verification) (VerificationOutcome, error) {
+	return f(ctx, verification)
+}
+
+// verificationStore is the subset of VerificationRepository used for reprocessing
+type verificationStore interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Verification, error)
+	ClaimForProcessing(ctx context.Context, id uuid.UUID, lastUpdatedAt time.Time) (bool, error)
+	Update(ctx context.Context, verification *model.Verification) error
+	AddHistoryEntry(ctx context.Context, entry *model.VerificationHistory) error
+}
+
+// RegisterProcessor sets the pipeline used to (re)process verifications of method
+func (s *VerificationService) RegisterProcessor(method model.VerificationMethod, processor VerificationProcessor) {
+	s.processors[method] = processor
+}
+
+// isTerminalVerificationStatus reports whether a verification has finished
+func isTerminalVerificationStatus(status model.VerificationStatus) bool {
+	switch status {
+	case model.VerificationStatusCompleted,
+		model.VerificationStatusApproved,
+		model.VerificationStatusRejected,
+		model.VerificationStatusExpired:
+		return true
+	}
+	return false
+}
+
+// ReprocessVerification re-runs the method pipeline for a stuck verification.
+// Verifications in a terminal state are refused unless force is set. The
+// verification is claimed before the pipeline runs, so concurrent or repeated
+// requests never run it twice. Every reprocess is recorded in the verification
+// history as an audit trail.
+func (s *VerificationService) ReprocessVerification(ctx context.Context, id uuid.UUID, actorID uuid.UUID, force bool) (*domain.EnhancedVerification, error) {
+	// Fast path for concurrent requests within this instance
+	if _, busy := s.reprocessing.LoadOrStore(id, struct{}{}); busy {
+		return nil, ErrReprocessInProgress
+	}
+	defer s.reprocessing.Delete(id)
+
+	verification, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTerminalVerificationStatus(verification.Status) && !force {
+		return nil, ErrVerificationTerminal
+	}
+
+	processor, ok := s.processors[verification.Method]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoProcessor, verification.Method)
+	}
+
+	// Claim the verification; a concurrent reprocess on another instance loses the race
+	claimed, err := s.store.ClaimForProcessing(ctx, id, verification.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim verification: %w", err)
+	}
+	if !claimed {
+		return nil, ErrReprocessInProgress
+	}
+
+	// Only the request that won the claim is audited, before the pipeline runs
+	err = s.recordReprocess(ctx, id, actorID, verification.Status, "Reprocess requested", map[string]interface{}{
+		"action": "reprocess",
+		"forced": force,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record reprocess: %w", err)
+	}
+
+	previous := verification.Status
+	if err := verificationStates.Reopen(verification); err != nil {
+		return nil, err
+	}
+
+	outcome, err := processor.Process(ctx, verification)
+	if err == nil {
+		err = applyOutcome(verification, outcome)
+	}
+	if err != nil {
+		// In progress may always move to failed
+		_ = transitionVerification(verification, model.VerificationStatusFailed, verification.ConfidenceScore, fmt.Sprintf("Reprocess failed: %v", err))
+	}
+
+	if err := s.store.Update(ctx, verification); err != nil {
+		return nil, fmt.Errorf("failed to update verification: %w", err)
+	}
+	s.notifyOutcome(verification)
+
+	// The outcome is already persisted, so a failure to audit it is not reported to the caller
+	_ = s.recordReprocess(ctx, id, actorID, verification.Status, verification.Notes, map[string]interface{}{
+		"action":          "reprocess",
+		"forced":          force,
+		"previous_status": string(previous),
+		"confidence":      verification.ConfidenceScore,
+	})
+
+	return mapper.VerificationModelToDomain(verification), nil
+}
+
+// applyOutcome moves a reprocessed verification to the pipeline's outcome. An
+// undecided outcome requeues it for manual review.
+func applyOutcome(verification *model.Verification, outcome VerificationOutcome) error {
+	if outcome.Status == model.VerificationStatusPending {
+		return verificationStates.Requeue(verification, outcome.ConfidenceScore, outcome.Notes)
+	}
+	return transitionVerification(verification, outcome.Status, outcome.ConfidenceScore, outcome.Notes)
+}
+
+// recordReprocess adds an audit entry to the verification history
+func (s *VerificationService) recordReprocess(ctx context.Context, id, actorID uuid.UUID, status model.VerificationStatus, notes string, metadata map[string]interface{}) error {
+	return s.store.AddHistoryEntry(ctx, &model.VerificationHistory{
+		ID:             uuid.New(),
+		VerificationID: id,
+		Status:         status,
+		Notes:          notes,
+		CreatedBy:      actorID,
+		CreatedAt:      time.Now(),
+		Metadata:       metadata,
+	})
+}
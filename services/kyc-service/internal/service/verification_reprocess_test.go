@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// memoryVerificationStore is an in-memory verificationStore
+type memoryVerificationStore struct {
+	mu            sync.Mutex
+	verifications map[uuid.UUID]model.Verification
+	history       []*model.VerificationHistory
+}
+
+func newMemoryVerificationStore(verifications ...model.Verification) *memoryVerificationStore {
+	store := &memoryVerificationStore{verifications: make(map[uuid.UUID]model.Verification)}
+	for _, v := range verifications {
+		store.verifications[v.ID] = v
+	}
+	return store
+}
+
+func (m *memoryVerificationStore) GetByID(ctx context.Context, id uuid.UUID) (*model.Verification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.verifications[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &v, nil
+}
+
+func (m *memoryVerificationStore) ClaimForProcessing(ctx context.Context, id uuid.UUID, lastUpdatedAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.verifications[id]
+	if !ok || !v.UpdatedAt.Equal(lastUpdatedAt) {
+		return false, nil
+	}
+	v.Status = model.VerificationStatusInProgress
+	v.UpdatedAt = v.UpdatedAt.Add(time.Millisecond)
+	m.verifications[id] = v
+	return true, nil
+}
+
+func (m *memoryVerificationStore) Update(ctx context.Context, verification *model.Verification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifications[verification.ID] = *verification
+	return nil
+}
+
+func (m *memoryVerificationStore) AddHistoryEntry(ctx context.Context, entry *model.VerificationHistory) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = append(m.history, entry)
+	return nil
+}
+
+func newReprocessTestService(store verificationStore, processor VerificationProcessor) *VerificationService {
+	svc := &VerificationService{
+		store:      store,
+		processors: make(map[model.VerificationMethod]VerificationProcessor),
+	}
+	svc.RegisterProcessor(model.VerificationMethodAI, processor)
+	return svc
+}
+
+func approve(ctx context.Context, verification *model.Verification) (VerificationOutcome, error) {
+	return VerificationOutcome{Status: model.VerificationStatusApproved, ConfidenceScore: 0.97, Notes: "Approved"}, nil
+}
+
+func TestReprocessVerification_AdvancesStuckVerification(t *testing.T) {
+	stuck := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusInProgress,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMemoryVerificationStore(stuck)
+	svc := newReprocessTestService(store, VerificationProcessorFunc(approve))
+	admin := uuid.New()
+
+	result, err := svc.ReprocessVerification(context.Background(), stuck.ID, admin, false)
+	if err != nil {
+		t.Fatalf("ReprocessVerification: %v", err)
+	}
+	if result.ConfidenceScore != 0.97 {
+		t.Fatalf("expected the pipeline result to be returned, got score %v", result.ConfidenceScore)
+	}
+
+	saved, _ := store.GetByID(context.Background(), stuck.ID)
+	if saved.Status != model.VerificationStatusApproved {
+		t.Fatalf("expected status %q, got %q", model.VerificationStatusApproved, saved.Status)
+	}
+	if saved.CompletedAt == nil {
+		t.Fatal("expected the verification to be marked completed")
+	}
+
+	if len(store.history) != 2 {
+		t.Fatalf("expected request and outcome audit entries, got %d", len(store.history))
+	}
+	for _, entry := range store.history {
+		if entry.CreatedBy != admin || entry.Metadata["action"] != "reprocess" {
+			t.Fatalf("unexpected audit entry %+v", entry)
+		}
+	}
+}
+
+func TestReprocessVerification_RefusesTerminalWithoutForce(t *testing.T) {
+	completed := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusCompleted,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now(),
+	}
+	store := newMemoryVerificationStore(completed)
+
+	var runs int32
+	svc := newReprocessTestService(store, VerificationProcessorFunc(func(ctx context.Context, v *model.Verification) (VerificationOutcome, error) {
+		atomic.AddInt32(&runs, 1)
+		return approve(ctx, v)
+	}))
+
+	_, err := svc.ReprocessVerification(context.Background(), completed.ID, uuid.New(), false)
+	if !errors.Is(err, ErrVerificationTerminal) {
+		t.Fatalf("expected ErrVerificationTerminal, got %v", err)
+	}
+	if runs != 0 || len(store.history) != 0 {
+		t.Fatal("a refused reprocess must not run the pipeline or be recorded")
+	}
+
+	if _, err := svc.ReprocessVerification(context.Background(), completed.ID, uuid.New(), true); err != nil {
+		t.Fatalf("forced reprocess: %v", err)
+	}
+	if runs != 1 {
+		t.Fatalf("expected the forced reprocess to run the pipeline once, ran %d times", runs)
+	}
+
+	outcome := store.history[len(store.history)-1]
+	if outcome.Status != model.VerificationStatusApproved || outcome.Metadata["previous_status"] != string(model.VerificationStatusCompleted) {
+		t.Fatalf("expected the outcome entry to record completed to approved, got %+v", outcome)
+	}
+}
+
+func TestReprocessVerification_ConcurrentRequestsRunPipelineOnce(t *testing.T) {
+	stuck := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusPending,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMemoryVerificationStore(stuck)
+
+	var runs int32
+	release := make(chan struct{})
+	svc := newReprocessTestService(store, VerificationProcessorFunc(func(ctx context.Context, v *model.Verification) (VerificationOutcome, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return approve(ctx, v)
+	}))
+
+	const callers = 5
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.ReprocessVerification(context.Background(), stuck.ID, uuid.New(), false)
+			errs <- err
+		}()
+	}
+
+	// Let the rejected callers return before the winner finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrReprocessInProgress), errors.Is(err, ErrVerificationTerminal):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if runs != 1 || succeeded != 1 {
+		t.Fatalf("expected exactly one reprocess to run, ran %d times with %d successes", runs, succeeded)
+	}
+}
+
+// lostClaimStore is a store on which every claim is won by another instance
+type lostClaimStore struct {
+	*memoryVerificationStore
+}
+
+func (lostClaimStore) ClaimForProcessing(ctx context.Context, id uuid.UUID, lastUpdatedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+func TestReprocessVerification_LostClaimIsNotAudited(t *testing.T) {
+	stuck := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusPending,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMemoryVerificationStore(stuck)
+	svc := newReprocessTestService(lostClaimStore{store}, VerificationProcessorFunc(approve))
+
+	if _, err := svc.ReprocessVerification(context.Background(), stuck.ID, uuid.New(), false); !errors.Is(err, ErrReprocessInProgress) {
+		t.Fatalf("expected ErrReprocessInProgress, got %v", err)
+	}
+	if len(store.history) != 0 {
+		t.Fatalf("expected no audit entries for a lost claim, got %d", len(store.history))
+	}
+}
+
+func TestReprocessVerification_PipelineFailureMarksFailed(t *testing.T) {
+	stuck := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusInProgress,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMemoryVerificationStore(stuck)
+	svc := newReprocessTestService(store, VerificationProcessorFunc(func(ctx context.Context, v *model.Verification) (VerificationOutcome, error) {
+		return VerificationOutcome{}, errors.New("OCR provider unavailable")
+	}))
+
+	if _, err := svc.ReprocessVerification(context.Background(), stuck.ID, uuid.New(), false); err != nil {
+		t.Fatalf("ReprocessVerification: %v", err)
+	}
+
+	saved, _ := store.GetByID(context.Background(), stuck.ID)
+	if saved.Status != model.VerificationStatusFailed {
+		t.Fatalf("expected status %q, got %q", model.VerificationStatusFailed, saved.Status)
+	}
+}
+
+func TestReprocessVerification_ManualReviewRequeuesThroughStateMachine(t *testing.T) {
+	stuck := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusInProgress,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMemoryVerificationStore(stuck)
+	svc := newReprocessTestService(store, ManualReviewProcessor())
+
+	if _, err := svc.ReprocessVerification(context.Background(), stuck.ID, uuid.New(), false); err != nil {
+		t.Fatalf("ReprocessVerification: %v", err)
+	}
+
+	saved, _ := store.GetByID(context.Background(), stuck.ID)
+	if saved.Status != model.VerificationStatusPending || saved.CompletedAt != nil {
+		t.Fatalf("expected an open pending verification, got %q", saved.Status)
+	}
+	outcome := store.history[len(store.history)-1]
+	if outcome.Status != model.VerificationStatusPending || outcome.Metadata["previous_status"] != string(model.VerificationStatusInProgress) {
+		t.Fatalf("expected the outcome entry to record in progress to pending, got %+v", outcome)
+	}
+}
+
+func TestReprocessVerification_IllegalOutcomeMarksFailed(t *testing.T) {
+	stuck := model.Verification{
+		ID:        uuid.New(),
+		Status:    model.VerificationStatusPending,
+		Method:    model.VerificationMethodAI,
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMemoryVerificationStore(stuck)
+	svc := newReprocessTestService(store, VerificationProcessorFunc(func(ctx context.Context, v *model.Verification) (VerificationOutcome, error) {
+		return VerificationOutcome{Status: "unknown"}, nil
+	}))
+
+	if _, err := svc.ReprocessVerification(context.Background(), stuck.ID, uuid.New(), false); err != nil {
+		t.Fatalf("ReprocessVerification: %v", err)
+	}
+
+	saved, _ := store.GetByID(context.Background(), stuck.ID)
+	if saved.Status != model.VerificationStatusFailed {
+		t.Fatalf("expected status %q, got %q", model.VerificationStatusFailed, saved.Status)
+	}
+}
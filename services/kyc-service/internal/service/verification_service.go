@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"sparkfund/services/kyc-service/internal/domain"
@@ -16,17 +18,25 @@ import (
 
 // VerificationService handles business logic for verification operations
 type VerificationService struct {
-	verRepo     *repository.VerificationRepository
-	docRepo     *repository.DocumentRepository
-	kycRepo     *repository.KYCRepository
+	verRepo      *repository.VerificationRepository
+	docRepo      *repository.DocumentRepository
+	kycRepo      *repository.KYCRepository
+	store        verificationStore
+	transactions verificationTransactor
+	processors   map[model.VerificationMethod]VerificationProcessor
+	reprocessing sync.Map
+	webhooks     *WebhookDispatcher
 }
 
 // NewVerificationService creates a new verification service
 func NewVerificationService(verRepo *repository.VerificationRepository, docRepo *repository.DocumentRepository, kycRepo *repository.KYCRepository) *VerificationService {
 	return &VerificationService{
-		verRepo: verRepo,
-		docRepo: docRepo,
-		kycRepo: kycRepo,
+		verRepo:      verRepo,
+		docRepo:      docRepo,
+		kycRepo:      kycRepo,
+		store:        verRepo,
+		transactions: repositoryTransactor{repo: verRepo},
+		processors:   make(map[model.VerificationMethod]VerificationProcessor),
 	}
 }
 
@@ -38,6 +48,11 @@ func (s *VerificationService) CreateVerification(ctx context.Context, documentID
 		return nil, err
 	}
 
+	// Only documents that passed the virus scan can be verified
+	if err := checkScanned(document); err != nil {
+		return nil, err
+	}
+
 	// Create verification record
 	verification := &model.Verification{
 		ID:              uuid.New(),
@@ -81,44 +96,96 @@ func (s *VerificationService) ListVerifications(ctx context.Context, page, pageS
 	return mapper.VerificationModelsToDomains(verifications), total, nil
 }
 
-// UpdateVerificationStatus updates the status of a verification
-func (s *VerificationService) UpdateVerificationStatus(ctx context.Context, id uuid.UUID, status domain.VerificationStatus, confidenceScore float64, notes string) error {
-	// Get existing verification
-	verification, err := s.verRepo.GetByID(ctx, id)
+// UpdateVerificationStatus moves a verification to status if the state machine
+// allows it, and records the change and actorID in the verification history
+func (s *VerificationService) UpdateVerificationStatus(ctx context.Context, id uuid.UUID, actorID uuid.UUID, status domain.VerificationStatus, confidenceScore float64, notes string) error {
+	// Domain statuses are upper case, stored statuses lower case
+	newStatus := model.VerificationStatus(strings.ToLower(string(status)))
+
+	var updated *model.Verification
+	var previous model.VerificationStatus
+	err := s.transactions.WithinTransaction(ctx, func(store transactionStore) error {
+		verification, err := store.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		previous = verification.Status
+		if err := transitionVerification(verification, newStatus, confidenceScore, notes); err != nil {
+			return err
+		}
+		if err := store.Update(ctx, verification); err != nil {
+			return fmt.Errorf("failed to update verification: %w", err)
+		}
+
+		err = store.AddHistoryEntry(ctx, &model.VerificationHistory{
+			ID:             uuid.New(),
+			VerificationID: id,
+			Status:         newStatus,
+			Notes:          notes,
+			CreatedBy:      actorID,
+			CreatedAt:      time.Now(),
+			Metadata: map[string]interface{}{
+				"action":          "status_update",
+				"previous_status": string(previous),
+				"confidence":      confidenceScore,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record status change: %w", err)
+		}
+
+		updated = verification
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// Update verification
-	verification.Status = model.VerificationStatus(status)
-	verification.ConfidenceScore = confidenceScore
-	verification.Notes = notes
-	verification.UpdatedAt = time.Now()
+	if updated.Status != previous {
+		s.notifyOutcome(updated)
+	}
 
-	if status == domain.VerStatusCompleted || status == domain.VerStatusApproved {
-		now := time.Now()
-		verification.CompletedAt = &now
+	// If document verification, update document status
+	return s.syncDocumentStatus(ctx, updated)
+}
+
+// VerificationTransitions returns a verification's status and the statuses it
+// may move to next, spelled as the verification's own status is
+func (s *VerificationService) VerificationTransitions(ctx context.Context, id uuid.UUID) (domain.VerificationStatus, []domain.VerificationStatus, error) {
+	verification, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return "", nil, err
 	}
 
-	// Save verification
-	if err := s.verRepo.Update(ctx, verification); err != nil {
-		return fmt.Errorf("failed to update verification: %w", err)
+	next := verificationStates.Next(verification.Status)
+	statuses := make([]domain.VerificationStatus, len(next))
+	for i, status := range next {
+		statuses[i] = domain.VerificationStatus(status)
 	}
+	return domain.VerificationStatus(verification.Status), statuses, nil
+}
 
-	// If document verification, update document status
-	if verification.DocumentID != nil && (status == domain.VerStatusApproved || status == domain.VerStatusRejected) {
-		var docStatus model.DocumentStatus
-		if status == domain.VerStatusApproved {
-			docStatus = model.DocumentStatusVerified
-		} else {
-			docStatus = model.DocumentStatusRejected
-		}
+// syncDocumentStatus marks the document of an approved or rejected document
+// verification as verified or rejected
+func (s *VerificationService) syncDocumentStatus(ctx context.Context, verification *model.Verification) error {
+	if verification.DocumentID == nil {
+		return nil
+	}
 
-		if err := s.docRepo.UpdateStatus(ctx, *verification.DocumentID, docStatus, notes, id); err != nil {
-			return fmt.Errorf("failed to update document status: %w", err)
-		}
+	var docStatus model.DocumentStatus
+	switch verification.Status {
+	case model.VerificationStatusApproved:
+		docStatus = model.DocumentStatusVerified
+	case model.VerificationStatusRejected:
+		docStatus = model.DocumentStatusRejected
+	default:
+		return nil
 	}
 
+	if err := s.docRepo.UpdateStatus(ctx, *verification.DocumentID, docStatus, verification.Notes, verification.ID); err != nil {
+		return fmt.Errorf("failed to update document status: %w", err)
+	}
 	return nil
 }
 
@@ -150,6 +217,7 @@ func (s *VerificationService) CreateVerificationResult(ctx context.Context, veri
 		status = model.VerificationStatusRejected
 	}
 
+	previous := verification.Status
 	verification.Status = status
 	verification.ConfidenceScore = result.Score
 	verification.UpdatedAt = time.Now()
@@ -161,6 +229,9 @@ func (s *VerificationService) CreateVerificationResult(ctx context.Context, veri
 		return fmt.Errorf("failed to update verification: %w", err)
 	}
 
+	if verification.Status != previous {
+		s.notifyOutcome(verification)
+	}
 	return nil
 }
 
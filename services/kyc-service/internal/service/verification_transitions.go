@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+)
+
+// ErrIllegalTransition is returned, wrapped in an *IllegalTransitionError, when
+// a verification cannot move to the requested status
+var ErrIllegalTransition = errors.New("illegal verification status transition")
+
+// IllegalTransitionError reports a status change the state machine does not
+// allow. It matches ErrIllegalTransition with errors.Is.
+type IllegalTransitionError struct {
+	From model.VerificationStatus
+	To   model.VerificationStatus
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("%s: %s to %s", ErrIllegalTransition, e.From, e.To)
+}
+
+func (e *IllegalTransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// VerificationStateMachine defines the statuses a verification may move
+// between. Every status change goes through it, whether made by a reviewer, a
+// vendor callback or an imported decision.
+type VerificationStateMachine struct {
+	transitions map[model.VerificationStatus][]model.VerificationStatus
+}
+
+// verificationStates is the state machine verifications follow. A verification
+// starts pending, is picked up and finishes completed, approved, rejected,
+// failed or expired; a pending one may also be decided directly. Terminal
+// statuses have no outgoing transitions; a forced reprocess is the only way to
+// reopen them, through Reopen, and a reprocess pipeline the only way to send
+// one in progress back to pending, through Requeue. Failed verifications may be
+// retried or decided manually.
+var verificationStates = &VerificationStateMachine{
+	transitions: map[model.VerificationStatus][]model.VerificationStatus{
+		model.VerificationStatusPending: {
+			model.VerificationStatusInProgress,
+			model.VerificationStatusCompleted,
+			model.VerificationStatusApproved,
+			model.VerificationStatusRejected,
+			model.VerificationStatusFailed,
+			model.VerificationStatusExpired,
+		},
+		model.VerificationStatusInProgress: {
+			model.VerificationStatusCompleted,
+			model.VerificationStatusApproved,
+			model.VerificationStatusRejected,
+			model.VerificationStatusFailed,
+			model.VerificationStatusExpired,
+		},
+		model.VerificationStatusFailed: {
+			model.VerificationStatusPending,
+			model.VerificationStatusInProgress,
+			model.VerificationStatusApproved,
+			model.VerificationStatusRejected,
+		},
+	},
+}
+
+// Next returns the statuses a verification in status from may move to
+func (m *VerificationStateMachine) Next(from model.VerificationStatus) []model.VerificationStatus {
+	return append([]model.VerificationStatus(nil), m.transitions[from]...)
+}
+
+// Check reports whether a verification in status from may move to status to.
+// An open verification may keep its status, e.g. to update its notes.
+func (m *VerificationStateMachine) Check(from, to model.VerificationStatus) error {
+	if from == to && !isTerminalVerificationStatus(from) {
+		return nil
+	}
+	for _, allowed := range m.transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &IllegalTransitionError{From: from, To: to}
+}
+
+// Transition moves verification to status, recording the decision's
+// confidence and notes. The verification is left unchanged if the transition
+// is not allowed.
+func (m *VerificationStateMachine) Transition(verification *model.Verification, status model.VerificationStatus, confidenceScore float64, notes string) error {
+	if err := m.Check(verification.Status, status); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	verification.Status = status
+	verification.ConfidenceScore = confidenceScore
+	verification.Notes = notes
+	verification.UpdatedAt = now
+	if isTerminalVerificationStatus(status) {
+		verification.CompletedAt = &now
+	}
+	return nil
+}
+
+// Reopen moves verification back in progress to be processed again. Open
+// verifications follow the transition table; a terminal one is reopened
+// regardless, which only a forced reprocess may ask for.
+func (m *VerificationStateMachine) Reopen(verification *model.Verification) error {
+	if !isTerminalVerificationStatus(verification.Status) {
+		return m.Transition(verification, model.VerificationStatusInProgress, verification.ConfidenceScore, verification.Notes)
+	}
+
+	verification.Status = model.VerificationStatusInProgress
+	verification.UpdatedAt = time.Now()
+	verification.CompletedAt = nil
+	return nil
+}
+
+// Requeue hands a verification in progress back to pending for manual review.
+// Only a reprocess pipeline that cannot decide a verification may do so;
+// reviewers cannot move a verification back to pending.
+func (m *VerificationStateMachine) Requeue(verification *model.Verification, confidenceScore float64, notes string) error {
+	if verification.Status != model.VerificationStatusInProgress {
+		return &IllegalTransitionError{From: verification.Status, To: model.VerificationStatusPending}
+	}
+
+	verification.Status = model.VerificationStatusPending
+	verification.ConfidenceScore = confidenceScore
+	verification.Notes = notes
+	verification.UpdatedAt = time.Now()
+	return nil
+}
+
+// transitionVerification moves verification to status under verificationStates
+func transitionVerification(verification *model.Verification, status model.VerificationStatus, confidenceScore float64, notes string) error {
+	return verificationStates.Transition(verification, status, confidenceScore, notes)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sparkfund/services/kyc-service/internal/domain"
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestVerificationStateMachine_Check(t *testing.T) {
+	tests := []struct {
+		from, to model.VerificationStatus
+		allowed  bool
+	}{
+		{model.VerificationStatusPending, model.VerificationStatusInProgress, true},
+		{model.VerificationStatusInProgress, model.VerificationStatusCompleted, true},
+		{model.VerificationStatusInProgress, model.VerificationStatusFailed, true},
+		{model.VerificationStatusInProgress, model.VerificationStatusInProgress, true},
+		{model.VerificationStatusFailed, model.VerificationStatusPending, true},
+		{model.VerificationStatusCompleted, model.VerificationStatusPending, false},
+		{model.VerificationStatusRejected, model.VerificationStatusApproved, false},
+		{model.VerificationStatusApproved, model.VerificationStatusApproved, false},
+		{model.VerificationStatusInProgress, model.VerificationStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		err := verificationStates.Check(tt.from, tt.to)
+		if tt.allowed {
+			if err != nil {
+				t.Errorf("%s to %s: unexpected error %v", tt.from, tt.to, err)
+			}
+			continue
+		}
+
+		var illegal *IllegalTransitionError
+		if !errors.Is(err, ErrIllegalTransition) || !errors.As(err, &illegal) {
+			t.Errorf("%s to %s: expected an IllegalTransitionError, got %v", tt.from, tt.to, err)
+			continue
+		}
+		if illegal.From != tt.from || illegal.To != tt.to {
+			t.Errorf("%s to %s: error reports %s to %s", tt.from, tt.to, illegal.From, illegal.To)
+		}
+	}
+}
+
+func TestUpdateVerificationStatus_RecordsEachTransition(t *testing.T) {
+	id := uuid.New()
+	svc, store := newImportTestService(model.Verification{ID: id, Status: model.VerificationStatusPending})
+	reviewer := uuid.New()
+	ctx := context.Background()
+
+	if err := svc.UpdateVerificationStatus(ctx, id, reviewer, domain.VerStatusInProgress, 0, "picked up"); err != nil {
+		t.Fatalf("pending to in progress: %v", err)
+	}
+	if err := svc.UpdateVerificationStatus(ctx, id, reviewer, domain.VerStatusCompleted, 0.9, "checks passed"); err != nil {
+		t.Fatalf("in progress to completed: %v", err)
+	}
+
+	if len(store.history) != 2 {
+		t.Fatalf("expected a history entry per transition, got %d", len(store.history))
+	}
+	entry := store.history[1]
+	if entry.Status != model.VerificationStatusCompleted || entry.CreatedBy != reviewer {
+		t.Fatalf("unexpected history entry %+v", entry)
+	}
+	if entry.Metadata["previous_status"] != string(model.VerificationStatusInProgress) {
+		t.Fatalf("expected the previous status to be recorded, got %v", entry.Metadata)
+	}
+}
+
+func TestUpdateVerificationStatus_RejectsIllegalTransition(t *testing.T) {
+	id := uuid.New()
+	svc, store := newImportTestService(model.Verification{ID: id, Status: model.VerificationStatusCompleted})
+
+	err := svc.UpdateVerificationStatus(context.Background(), id, uuid.New(), domain.VerStatusPending, 0, "reopen")
+	if !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("expected ErrIllegalTransition, got %v", err)
+	}
+	if got := store.verifications[id].Status; got != model.VerificationStatusCompleted {
+		t.Fatalf("expected the verification to stay completed, got %s", got)
+	}
+	if len(store.history) != 0 {
+		t.Fatalf("expected no history for a refused transition, got %d entries", len(store.history))
+	}
+}
+
+func TestVerificationTransitions(t *testing.T) {
+	failed := model.Verification{ID: uuid.New(), Status: model.VerificationStatusFailed}
+	approved := model.Verification{ID: uuid.New(), Status: model.VerificationStatusApproved}
+	svc, _ := newImportTestService(failed, approved)
+
+	status, next, err := svc.VerificationTransitions(context.Background(), failed.ID)
+	if err != nil {
+		t.Fatalf("VerificationTransitions: %v", err)
+	}
+	if status != domain.VerificationStatus(model.VerificationStatusFailed) || len(next) != 4 {
+		t.Fatalf("unexpected transitions from %s: %v", status, next)
+	}
+
+	// Terminal statuses have nowhere to go
+	if _, next, _ := svc.VerificationTransitions(context.Background(), approved.ID); len(next) != 0 {
+		t.Fatalf("expected no transitions from approved, got %v", next)
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+	"github.com/adil-faiyaz98/sparkfund/pkg/retry"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrWebhooksNotConfigured is returned when a webhook is replayed and no
+	// subscribers are configured
+	ErrWebhooksNotConfigured = errors.New("webhooks are not configured")
+	// ErrNoWebhookEvent is returned when a webhook is replayed for a
+	// verification whose status does not send one
+	ErrNoWebhookEvent = errors.New("verification status has no webhook event")
+)
+
+// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the request
+// body under the webhook secret, prefixed with "sha256="
+const WebhookSignatureHeader = "X-Signature"
+
+// webhookStatuses are the statuses that send a webhook when a verification
+// reaches them
+var webhookStatuses = map[model.VerificationStatus]bool{
+	model.VerificationStatusCompleted: true,
+	model.VerificationStatusApproved:  true,
+	model.VerificationStatusRejected:  true,
+	model.VerificationStatusFailed:    true,
+}
+
+// webhookEvent names the webhook event for a status
+func webhookEvent(status model.VerificationStatus) string {
+	return "verification." + string(status)
+}
+
+// WebhookConfig configures verification outcome webhooks
+type WebhookConfig struct {
+	// Subscribers are the URLs every webhook is posted to
+	Subscribers []string
+	// Secret signs every payload; subscribers check the X-Signature header with it
+	Secret string
+	// Timeout bounds a single delivery attempt
+	Timeout time.Duration
+	// Egress restricts where deliveries may go. Subscriber URLs are configured
+	// by operators and partners, so by default only public addresses are reached.
+	Egress client.EgressPolicy
+	// Retry controls how failed deliveries are retried. Its Retryable is
+	// replaced: network errors, 429 and 5xx responses are retried, any other
+	// response is permanent.
+	Retry retry.Policy
+}
+
+// DefaultWebhookConfig returns the default webhook delivery settings, without
+// subscribers
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout: 10 * time.Second,
+		Retry: retry.Policy{
+			MaxAttempts: 5,
+			BaseDelay:   time.Second,
+			MaxDelay:    time.Minute,
+			Strategy:    retry.StrategyExponential,
+			Jitter:      0.2,
+		},
+	}
+}
+
+// WebhookPayload is the body posted to subscribers
+type WebhookPayload struct {
+	// Event is "verification." followed by the status, e.g. verification.approved
+	Event string `json:"event"`
+	// DeliveryID is new for every dispatch, including replays
+	DeliveryID      uuid.UUID  `json:"delivery_id"`
+	VerificationID  uuid.UUID  `json:"verification_id"`
+	KYCID           *uuid.UUID `json:"kyc_id,omitempty"`
+	DocumentID      *uuid.UUID `json:"document_id,omitempty"`
+	Status          string     `json:"status"`
+	ConfidenceScore float64    `json:"confidence_score"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	SentAt          time.Time  `json:"sent_at"`
+}
+
+// WebhookDeadLetterStore records deliveries that failed permanently
+type WebhookDeadLetterStore interface {
+	RecordDeadLetter(ctx context.Context, deadLetter *model.WebhookDeadLetter) error
+}
+
+// permanentWebhookError is a delivery that cannot succeed, such as one the
+// subscriber refused; it is not retried
+type permanentWebhookError struct {
+	err error
+}
+
+func (e *permanentWebhookError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentWebhookError) Unwrap() error {
+	return e.err
+}
+
+// WebhookDispatcher posts signed webhooks to subscribers when verifications
+// reach an outcome. Deliveries run in the background and are retried with
+// exponential backoff; a delivery that still fails is recorded as a dead
+// letter.
+type WebhookDispatcher struct {
+	config      WebhookConfig
+	client      *http.Client
+	deadLetters WebhookDeadLetterStore
+	pending     sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a dispatcher that records failed deliveries in
+// deadLetters
+func NewWebhookDispatcher(config WebhookConfig, deadLetters WebhookDeadLetterStore) *WebhookDispatcher {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebhookConfig().Timeout
+	}
+	config.Retry.Retryable = func(err error) bool {
+		var permanent *permanentWebhookError
+		return !errors.As(err, &permanent)
+	}
+	return &WebhookDispatcher{
+		config:      config,
+		client:      &http.Client{Timeout: config.Timeout, Transport: client.NewEgressTransport(config.Egress)},
+		deadLetters: deadLetters,
+	}
+}
+
+// SignWebhookPayload returns the X-Signature value for body under secret
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatch sends the webhook for verification's status to every subscriber in
+// the background. It reports false, sending nothing, for a status that has no
+// webhook.
+func (d *WebhookDispatcher) Dispatch(verification *model.Verification) bool {
+	if !webhookStatuses[verification.Status] {
+		return false
+	}
+
+	payload := WebhookPayload{
+		Event:           webhookEvent(verification.Status),
+		DeliveryID:      uuid.New(),
+		VerificationID:  verification.ID,
+		KYCID:           verification.KYCID,
+		DocumentID:      verification.DocumentID,
+		Status:          string(verification.Status),
+		ConfidenceScore: verification.ConfidenceScore,
+		CompletedAt:     verification.CompletedAt,
+		SentAt:          time.Now().UTC(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logrus.WithError(err).WithField("verification_id", verification.ID).Error("Failed to encode webhook")
+		return true
+	}
+
+	for _, url := range d.config.Subscribers {
+		d.pending.Add(1)
+		go func(url string) {
+			defer d.pending.Done()
+			d.deliver(context.Background(), url, payload, body)
+		}(url)
+	}
+	return true
+}
+
+// Wait blocks until every dispatched delivery has succeeded or been dead-lettered
+func (d *WebhookDispatcher) Wait() {
+	d.pending.Wait()
+}
+
+// deliver posts body to url until it is accepted, and records a dead letter
+// if it never is
+func (d *WebhookDispatcher) deliver(ctx context.Context, url string, payload WebhookPayload, body []byte) {
+	attempts := 0
+	err := d.config.Retry.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return d.post(ctx, url, payload.Event, body)
+	})
+	if err == nil {
+		return
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"verification_id": payload.VerificationID,
+		"event":           payload.Event,
+		"url":             url,
+		"attempts":        attempts,
+	})
+	logger.WithError(err).Warn("Webhook delivery failed permanently")
+
+	err = d.deadLetters.RecordDeadLetter(ctx, &model.WebhookDeadLetter{
+		ID:             uuid.New(),
+		VerificationID: payload.VerificationID,
+		Event:          payload.Event,
+		URL:            url,
+		Payload:        string(body),
+		Attempts:       attempts,
+		LastError:      err.Error(),
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to record webhook dead letter")
+	}
+}
+
+// post makes a single delivery attempt
+func (d *WebhookDispatcher) post(ctx context.Context, url, event string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentWebhookError{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(d.config.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// A subscriber the egress policy refuses stays refused
+		if errors.Is(err, client.ErrEgressDenied) {
+			return &permanentWebhookError{err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("subscriber responded %d", resp.StatusCode)
+	default:
+		return &permanentWebhookError{err: fmt.Errorf("subscriber responded %d", resp.StatusCode)}
+	}
+}
+
+// SetWebhooks sends a webhook to subscribers whenever a verification reaches
+// an outcome
+func (s *VerificationService) SetWebhooks(webhooks *WebhookDispatcher) {
+	s.webhooks = webhooks
+}
+
+// notifyOutcome dispatches the webhook for a verification that has just been
+// committed in a new status, if webhooks are configured
+func (s *VerificationService) notifyOutcome(verification *model.Verification) {
+	if s.webhooks != nil {
+		s.webhooks.Dispatch(verification)
+	}
+}
+
+// ReplayWebhook sends the webhook for a verification's current status again,
+// e.g. after a subscriber recovers from an outage. It returns the event sent;
+// delivery happens in the background.
+func (s *VerificationService) ReplayWebhook(ctx context.Context, id uuid.UUID) (string, error) {
+	if s.webhooks == nil {
+		return "", ErrWebhooksNotConfigured
+	}
+
+	verification, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if !s.webhooks.Dispatch(verification) {
+		return "", fmt.Errorf("%w: %s", ErrNoWebhookEvent, verification.Status)
+	}
+	return webhookEvent(verification.Status), nil
+}
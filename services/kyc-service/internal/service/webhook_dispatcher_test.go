@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sparkfund/services/kyc-service/internal/domain"
+	"sparkfund/services/kyc-service/internal/model"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/client"
+	"github.com/google/uuid"
+)
+
+// memoryDeadLetters records dead letters in memory
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	letters []*model.WebhookDeadLetter
+}
+
+func (m *memoryDeadLetters) RecordDeadLetter(ctx context.Context, deadLetter *model.WebhookDeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, deadLetter)
+	return nil
+}
+
+// testWebhookConfig retries quickly so tests do not wait on backoff, and lets
+// deliveries reach the loopback test servers
+func testWebhookConfig(subscribers ...string) WebhookConfig {
+	config := DefaultWebhookConfig()
+	config.Subscribers = subscribers
+	config.Secret = "webhook-secret"
+	config.Egress.AllowPrivate = true
+	config.Retry.MaxAttempts = 3
+	config.Retry.BaseDelay = time.Millisecond
+	config.Retry.MaxDelay = 5 * time.Millisecond
+	return config
+}
+
+func TestWebhookDispatcher_SignsPayload(t *testing.T) {
+	var received WebhookPayload
+	var validSignature bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		validSignature = VerifyCallbackSignature("webhook-secret", body, r.Header.Get(WebhookSignatureHeader))
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	deadLetters := &memoryDeadLetters{}
+	dispatcher := NewWebhookDispatcher(testWebhookConfig(server.URL), deadLetters)
+
+	verification := &model.Verification{ID: uuid.New(), Status: model.VerificationStatusRejected, ConfidenceScore: 0.2}
+	if !dispatcher.Dispatch(verification) {
+		t.Fatal("expected a webhook for a rejected verification")
+	}
+	dispatcher.Wait()
+
+	if !validSignature {
+		t.Fatal("expected the payload to carry a valid signature")
+	}
+	if received.Event != "verification.rejected" || received.VerificationID != verification.ID {
+		t.Fatalf("unexpected payload %+v", received)
+	}
+	if len(deadLetters.letters) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(deadLetters.letters))
+	}
+}
+
+func TestWebhookDispatcher_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	deadLetters := &memoryDeadLetters{}
+	dispatcher := NewWebhookDispatcher(testWebhookConfig(server.URL), deadLetters)
+	dispatcher.Dispatch(&model.Verification{ID: uuid.New(), Status: model.VerificationStatusCompleted})
+	dispatcher.Wait()
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+	if len(deadLetters.letters) != 0 {
+		t.Fatalf("expected the delivery to succeed on retry, got %d dead letters", len(deadLetters.letters))
+	}
+}
+
+func TestWebhookDispatcher_DeadLettersFailedDeliveries(t *testing.T) {
+	var refusedCalls, failingCalls atomic.Int32
+	refused := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refusedCalls.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer refused.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	deadLetters := &memoryDeadLetters{}
+	dispatcher := NewWebhookDispatcher(testWebhookConfig(refused.URL, failing.URL), deadLetters)
+	verification := &model.Verification{ID: uuid.New(), Status: model.VerificationStatusFailed}
+	dispatcher.Dispatch(verification)
+	dispatcher.Wait()
+
+	// A refused delivery is not retried; a failing one is retried to the limit
+	if refusedCalls.Load() != 1 || failingCalls.Load() != 3 {
+		t.Fatalf("expected 1 and 3 attempts, got %d and %d", refusedCalls.Load(), failingCalls.Load())
+	}
+	if len(deadLetters.letters) != 2 {
+		t.Fatalf("expected both deliveries to be dead-lettered, got %d", len(deadLetters.letters))
+	}
+	for _, letter := range deadLetters.letters {
+		if letter.VerificationID != verification.ID || letter.Event != "verification.failed" || letter.Payload == "" {
+			t.Fatalf("unexpected dead letter %+v", letter)
+		}
+	}
+}
+
+func TestWebhookDispatcher_EgressPolicyRefusesPrivateSubscribers(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := testWebhookConfig(server.URL)
+	config.Egress = client.DefaultEgressPolicy()
+	deadLetters := &memoryDeadLetters{}
+	dispatcher := NewWebhookDispatcher(config, deadLetters)
+	dispatcher.Dispatch(&model.Verification{ID: uuid.New(), Status: model.VerificationStatusApproved})
+	dispatcher.Wait()
+
+	if calls.Load() != 0 {
+		t.Fatalf("expected the loopback subscriber to be refused, got %d calls", calls.Load())
+	}
+	if len(deadLetters.letters) != 1 || deadLetters.letters[0].Attempts != 1 {
+		t.Fatalf("expected one dead letter after a single attempt, got %+v", deadLetters.letters)
+	}
+}
+
+func TestWebhooks_SentOnOutcomeAndReplayed(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		events = append(events, r.Header.Get("X-Webhook-Event"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	id := uuid.New()
+	svc, _ := newImportTestService(model.Verification{ID: id, Status: model.VerificationStatusPending})
+	dispatcher := NewWebhookDispatcher(testWebhookConfig(server.URL), &memoryDeadLetters{})
+	svc.SetWebhooks(dispatcher)
+	ctx := context.Background()
+
+	// Intermediate statuses send nothing
+	if err := svc.UpdateVerificationStatus(ctx, id, uuid.New(), domain.VerStatusInProgress, 0, ""); err != nil {
+		t.Fatalf("UpdateVerificationStatus: %v", err)
+	}
+	if _, err := svc.ReplayWebhook(ctx, id); !errors.Is(err, ErrNoWebhookEvent) {
+		t.Fatalf("expected ErrNoWebhookEvent, got %v", err)
+	}
+
+	if err := svc.UpdateVerificationStatus(ctx, id, uuid.New(), domain.VerStatusCompleted, 0.95, ""); err != nil {
+		t.Fatalf("UpdateVerificationStatus: %v", err)
+	}
+	event, err := svc.ReplayWebhook(ctx, id)
+	if err != nil || event != "verification.completed" {
+		t.Fatalf("ReplayWebhook: %q, %v", event, err)
+	}
+	dispatcher.Wait()
+
+	if len(events) != 2 || events[0] != "verification.completed" || events[1] != "verification.completed" {
+		t.Fatalf("expected the completion and its replay, got %v", events)
+	}
+}
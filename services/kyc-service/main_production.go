@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,11 +12,16 @@ import (
 
 	"github.com/adil-faiyaz98/sparkfund/pkg/config"
 	"github.com/adil-faiyaz98/sparkfund/pkg/logger"
+	"github.com/adil-faiyaz98/sparkfund/pkg/masking"
 	"github.com/adil-faiyaz98/sparkfund/pkg/middleware"
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
+	sharedServer "github.com/adil-faiyaz98/sparkfund/pkg/server"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"go.uber.org/zap"
+
+	"sparkfund/services/kyc-service/internal/service"
 )
 
 func main() {
@@ -28,6 +34,7 @@ func main() {
 		log.Fatal("Failed to load configuration", zap.Error(err))
 	}
 	cfg := config.Get()
+	logger.SetLevel(cfg.Log.Level)
 
 	// Set Gin mode
 	if config.IsProduction() {
@@ -46,21 +53,50 @@ func main() {
 	corsConfig.AllowedMethods = cfg.Security.AllowedMethods
 	corsConfig.AllowedHeaders = cfg.Security.AllowedHeaders
 	router.Use(middleware.CORS(corsConfig))
-	router.Use(middleware.SecurityHeaders())
-	
-	// Add rate limiting
-	rateLimitConfig := middleware.DefaultRateLimiterConfig()
-	rateLimitConfig.Enabled = cfg.RateLimit.Enabled
-	rateLimitConfig.Requests = cfg.RateLimit.Requests
-	rateLimitConfig.Window = cfg.RateLimit.Window
-	rateLimitConfig.Burst = cfg.RateLimit.Burst
-	router.Use(middleware.RateLimiter(rateLimitConfig))
+	headersConfig := middleware.DefaultSecurityHeadersConfig()
+	if cfg.Security.ContentSecurityPolicy != "" {
+		headersConfig.ContentSecurityPolicy = cfg.Security.ContentSecurityPolicy
+	}
+	headersConfig.HSTSMaxAge = cfg.Security.HSTSMaxAge
+	router.Use(middleware.SecurityHeaders(headersConfig))
 	
 	// Add JWT authentication if enabled
 	jwtConfig := middleware.DefaultJWTConfig()
 	jwtConfig.Secret = cfg.JWT.Secret
+	jwtKeys, err := cfg.JWTKeySet()
+	if err != nil {
+		log.Fatal("Invalid JWT key configuration", zap.Error(err))
+	}
+	jwtConfig.Keys = jwtKeys
+	// Only accept tokens signed with the algorithm this service signs with
+	jwtConfig.Algorithms = []string{jwtKeys.Algorithm()}
 	jwtConfig.Enabled = cfg.JWT.Enabled
+	jwtConfig.RefreshLeadTime = cfg.JWT.RefreshLeadTime
+	// Reject tokens revoked at logout, which the auth service records in Redis
+	if cfg.JWT.Denylist.Enabled {
+		redisClient := redisclient.New(cfg.JWT.Denylist.Redis)
+		defer redisClient.Close()
+		jwtConfig.Denylist = service.NewRedisTokenDenylist(redisClient, cfg.JWT.Denylist.Prefix)
+	}
+
+	// Publish the RS256 public keys so other services can validate tokens
+	// without the signing key; registered before JWTAuth so it needs no token,
+	// and limited per client IP since it has no user
+	anonymousRateLimiter := middleware.NewReloadableRateLimiter(rateLimiterConfig(cfg, middleware.RateLimitKeyIP))
+	router.GET("/auth/jwks.json", anonymousRateLimiter.Handler(), func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwtKeys.JWKS())
+	})
 	router.Use(middleware.JWTAuth(jwtConfig))
+
+	// Add rate limiting after JWTAuth so each user gets their own bucket;
+	// requests JWTAuth lets through without a token fall back to their IP
+	rateLimiter := middleware.NewReloadableRateLimiter(rateLimiterConfig(cfg, middleware.RateLimitKeyUser))
+	router.Use(rateLimiter.Handler())
+
+	// Mask PII in responses according to the caller's roles; configured fields
+	// override the defaults, which still cover every field the config leaves out
+	router.Use(masking.Middleware(masking.DefaultPolicy().Merge(cfg.Security.Masking)))
 	
 	// Add CSRF protection if enabled
 	if cfg.Security.EnableCSRF {
@@ -81,7 +117,40 @@ func main() {
 	tracingConfig := middleware.DefaultTracingConfig()
 	tracingConfig.ServiceName = cfg.Tracing.ServiceName
 	tracingConfig.Enabled = cfg.Tracing.Enabled
+	if cfg.Tracing.SamplingRate > 0 {
+		tracingConfig.SampleRatio = cfg.Tracing.SamplingRate
+	}
+	tracingConfig.AlwaysSampleErrors = cfg.Tracing.AlwaysSampleErrors
+	// Slow requests are retained by SlowRequestLogger, which applies per-route thresholds
+	tracingConfig.SlowRequestThreshold = 0
+	if _, err := middleware.InitTracing(tracingConfig); err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
 	router.Use(middleware.TracingMiddleware(tracingConfig))
+
+	// Log and trace requests slower than their route's threshold
+	slowConfig := middleware.DefaultSlowRequestConfig()
+	slowConfig.Threshold = cfg.Tracing.SlowRequestThreshold
+	slowConfig.Routes = make(map[string]time.Duration, len(cfg.Tracing.SlowRequestRoutes))
+	for _, route := range cfg.Tracing.SlowRequestRoutes {
+		slowConfig.Routes[route.Route] = route.Threshold
+	}
+	router.Use(middleware.SlowRequestLogger(slowConfig))
+
+	// Count requests against their route group's latency and availability objectives
+	sloConfig := middleware.DefaultSLOConfig()
+	if len(cfg.SLO.Objectives) > 0 {
+		sloConfig.Objectives = make([]middleware.SLO, 0, len(cfg.SLO.Objectives))
+		for _, objective := range cfg.SLO.Objectives {
+			sloConfig.Objectives = append(sloConfig.Objectives, middleware.SLO{
+				Name:    objective.Name,
+				Routes:  objective.Routes,
+				Latency: objective.Latency,
+				Target:  objective.Target,
+			})
+		}
+	}
+	router.Use(middleware.SLOMiddleware(sloConfig))
 	
 	// Add metrics endpoint
 	if cfg.Metrics.Enabled {
@@ -96,6 +165,16 @@ func main() {
 			"version": "1.0.0",
 		})
 	})
+
+	// Readiness fails once shutdown begins so the load balancer stops routing here
+	var readiness sharedServer.ReadinessFlag
+	router.GET("/ready", func(c *gin.Context) {
+		if !readiness.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
 	
 	// Add API endpoints
 	v1 := router.Group("/api/v1")
@@ -130,23 +209,25 @@ func main() {
 		v1.GET("/get-api-key", getAPIKeyHandler)
 	}
 	
-	// Serve Swagger UI
-	router.GET("/swagger-ui.html", func(c *gin.Context) {
+	// Serve Swagger UI; it needs inline scripts the API policy forbids
+	swaggerHeaders := middleware.OverrideSecurityHeaders(middleware.SwaggerSecurityHeaders())
+	router.GET("/swagger-ui.html", swaggerEnabled, swaggerHeaders, func(c *gin.Context) {
 		c.File("swagger-ui.html")
 	})
 	
 	// Serve Swagger JSON
-	router.GET("/swagger.json", func(c *gin.Context) {
+	router.GET("/swagger.json", swaggerEnabled, swaggerHeaders, func(c *gin.Context) {
 		c.File("swagger.json")
 	})
 	
 	// Create HTTP server
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+		Addr:              fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 	
 	// Start server in a goroutine
@@ -155,11 +236,17 @@ func main() {
 			logger.String("port", cfg.Server.Port),
 			logger.String("environment", cfg.Environment))
 			
-		var err error
+		ln, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			logger.Fatal("Failed to listen", logger.ErrorField(err))
+		}
+		// Cap open connections so a flood of clients cannot exhaust the service
+		ln = sharedServer.LimitListener(ln, cfg.Server.MaxConnections)
+
 		if cfg.TLS.Enabled {
-			err = server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			err = server.ServeTLS(ln, cfg.TLS.CertFile, cfg.TLS.KeyFile)
 		} else {
-			err = server.ListenAndServe()
+			err = server.Serve(ln)
 		}
 		
 		if err != nil && err != http.ErrServerClosed {
@@ -167,23 +254,56 @@ func main() {
 		}
 	}()
 	
+	// Apply log level, rate limit and feature changes on SIGHUP; other
+	// settings need a restart
+	config.OnReload(func(cfg config.Config) {
+		logger.SetLevel(cfg.Log.Level)
+		anonymousRateLimiter.Update(rateLimiterConfig(cfg, middleware.RateLimitKeyIP))
+		rateLimiter.Update(rateLimiterConfig(cfg, middleware.RateLimitKeyUser))
+	})
+	config.WatchReload(context.Background(), "./config")
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	
-	// Shutdown server gracefully
+	// Shutdown server gracefully: fail readiness, let the load balancer deregister, then drain
 	logger.Info("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
-	defer cancel()
-	
-	if err := server.Shutdown(ctx); err != nil {
+	err = sharedServer.Shutdown(server, sharedServer.ShutdownConfig{
+		PreStopDelay: cfg.Server.PreStopDelay,
+		DrainTimeout: cfg.Server.ShutdownTimeout,
+		Readiness:    &readiness,
+	})
+	if err != nil {
 		logger.Fatal("Server forced to shutdown", logger.ErrorField(err))
 	}
 	
 	logger.Info("Server exited gracefully")
 }
 
+// rateLimiterConfig returns the rate limits configured in cfg, keyed by
+// keyStrategy
+func rateLimiterConfig(cfg config.Config, keyStrategy string) middleware.RateLimiterConfig {
+	rateLimitConfig := middleware.DefaultRateLimiterConfig()
+	rateLimitConfig.Enabled = cfg.RateLimit.Enabled
+	rateLimitConfig.Requests = cfg.RateLimit.Requests
+	rateLimitConfig.Window = cfg.RateLimit.Window
+	rateLimitConfig.Burst = cfg.RateLimit.Burst
+	rateLimitConfig.KeyStrategy = keyStrategy
+	return rateLimitConfig
+}
+
+// swaggerEnabled hides the Swagger routes while feature.enable_swagger is off.
+// It reads the flag per request so a config reload takes effect immediately.
+func swaggerEnabled(c *gin.Context) {
+	if !config.Get().Feature.EnableSwagger {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Next()
+}
+
 // Handler implementations
 func loginHandler(c *gin.Context) {
 	// Implementation details
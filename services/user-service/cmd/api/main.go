@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,6 +11,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/apiversion"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/adil-faiyaz98/sparkfund/pkg/password"
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 	"github.com/sparkfund/services/user-service/internal/config"
@@ -18,6 +23,11 @@ import (
 	"github.com/sparkfund/services/user-service/internal/service"
 )
 
+// apiVersions are the API versions served. Register a version's routes
+// alongside /api/v1 to serve it; unversioned /api requests get the version in
+// their API-Version header, or v1.
+var apiVersions = apiversion.Config{Prefix: "/api", Versions: []string{"v1"}, Default: "v1"}
+
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
@@ -34,18 +44,80 @@ func main() {
 
 	// Initialize service
 	userService := service.NewUserService(userRepo)
+	if cfg.Cache.Enabled {
+		cacheConfig := service.UserCacheConfig{
+			TTL:      cfg.Cache.TTL,
+			LocalTTL: cfg.Cache.LocalTTL,
+			Prefix:   cfg.Cache.Redis.Prefix,
+		}
+		if cfg.Cache.Type == "redis" {
+			redisOptions := cfg.Cache.Redis.Pool
+			redisOptions.Addr = fmt.Sprintf("%s:%d", cfg.Cache.Redis.Host, cfg.Cache.Redis.Port)
+			redisOptions.Password = cfg.Cache.Redis.Password
+			redisOptions.DB = cfg.Cache.Redis.DB
+			cacheConfig.Redis = redisclient.New(redisOptions)
+			defer cacheConfig.Redis.Close()
+		}
+		userService.SetCache(service.NewUserCache(cacheConfig))
+	}
+
+	// Enforce the password policy on registration, change and reset
+	var breaches password.BreachChecker
+	if cfg.Password.BreachCheck.Enabled {
+		breaches = password.NewPwnedPasswordsChecker(cfg.Password.BreachCheck.URL, &http.Client{Timeout: cfg.Password.BreachCheck.Timeout})
+	}
+	userService.SetPasswordPolicy(password.New(password.Config{
+		MinLength:        cfg.Password.MinLength,
+		RequireUppercase: cfg.Password.RequireUppercase,
+		RequireLowercase: cfg.Password.RequireLowercase,
+		RequireNumber:    cfg.Password.RequireNumber,
+		RequireSpecial:   cfg.Password.RequireSpecial,
+		Blocklist:        cfg.Password.Blocklist,
+	}, breaches))
+
+	// Initialize the activity feed over the investment and KYC services
+	activityClient := &http.Client{Timeout: cfg.Activity.Timeout}
+	activityService := service.NewActivityService(cfg.Activity.Timeout,
+		service.NewTransactionActivitySource(cfg.Activity.InvestmentServiceURL, activityClient),
+		service.NewInvestmentActivitySource(cfg.Activity.InvestmentServiceURL, activityClient),
+		service.NewKYCActivitySource(cfg.Activity.KYCServiceURL, activityClient),
+	)
+
+	// Initialize admin impersonation
+	jwtKeys := jwtkeys.FromSecret(cfg.JWTSecret)
+	impersonationService := service.NewImpersonationService(userRepo, jwtKeys, service.ImpersonationConfig{
+		TokenTTL:     cfg.Impersonation.TokenTTL,
+		Scopes:       cfg.Impersonation.Scopes,
+		MaxPerWindow: cfg.Impersonation.MaxPerWindow,
+		Window:       cfg.Impersonation.Window,
+	})
 
-	// Initialize handler
+	// Write the auth audit trail in the background, off the login path
+	authAudit := service.NewAsyncAuditLogger(postgres.NewAuthAuditRepository(db), service.AsyncAuditConfig{
+		BufferSize:   cfg.AuthAudit.BufferSize,
+		WriteTimeout: cfg.AuthAudit.WriteTimeout,
+	})
+
+	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
+	userHandler.SetAuditLogger(authAudit)
+	activityHandler := handlers.NewActivityHandler(activityService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService, jwtKeys)
+	authAuditHandler := handlers.NewAuthAuditHandler(authAudit, jwtKeys)
 
 	// Create router
 	router := mux.NewRouter()
+	router.Use(handlers.TenantMiddleware(cfg.JWTSecret))
+	router.Use(handlers.ImpersonationGuard(jwtKeys))
 	userHandler.RegisterRoutes(router)
+	activityHandler.RegisterRoutes(router)
+	impersonationHandler.RegisterRoutes(router)
+	authAuditHandler.RegisterRoutes(router)
 
 	// Create server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
-		Handler:      router,
+		Handler:      apiversion.Negotiate(apiVersions, router),
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
@@ -73,5 +145,8 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Flush audit events queued by the last requests
+	authAudit.Close()
+
 	log.Println("Server exited properly")
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/redisclient"
 	"github.com/spf13/viper"
 )
 
@@ -32,6 +33,9 @@ type Config struct {
 	Notifications  NotificationConfig   `mapstructure:"notifications"`
 	Monitoring     MonitoringConfig     `mapstructure:"monitoring"`
 	Events         EventsConfig         `mapstructure:"events"`
+	Activity       ActivityConfig       `mapstructure:"activity"`
+	Impersonation  ImpersonationConfig  `mapstructure:"impersonation"`
+	AuthAudit      AuthAuditConfig      `mapstructure:"auth_audit"`
 
 	// Legacy fields for backward compatibility
 	Port         string
@@ -157,12 +161,17 @@ type CacheConfig struct {
 	Type            string        `mapstructure:"type"`
 	TTL             time.Duration `mapstructure:"ttl"`
 	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
-	Redis           struct {
+	// LocalTTL bounds how long each replica keeps users in memory; invalidations
+	// on other replicas only reach Redis
+	LocalTTL time.Duration `mapstructure:"local_ttl"`
+	Redis    struct {
 		Host     string `mapstructure:"host"`
 		Port     int    `mapstructure:"port"`
 		Password string `mapstructure:"password"`
 		DB       int    `mapstructure:"db"`
 		Prefix   string `mapstructure:"prefix"`
+		// Pool tunes connection pooling and timeouts; zero fields use redisclient defaults
+		Pool redisclient.Options `mapstructure:"pool"`
 	} `mapstructure:"redis"`
 }
 
@@ -195,6 +204,18 @@ type PasswordConfig struct {
 	HistoryCount     int           `mapstructure:"history_count"`
 	LockoutThreshold int           `mapstructure:"lockout_threshold"`
 	LockoutDuration  time.Duration `mapstructure:"lockout_duration"`
+	// Blocklist replaces the built-in list of common passwords when set
+	Blocklist   []string                  `mapstructure:"blocklist"`
+	BreachCheck PasswordBreachCheckConfig `mapstructure:"breach_check"`
+}
+
+// PasswordBreachCheckConfig holds configuration for checking new passwords
+// against a Pwned Passwords style range API
+type PasswordBreachCheckConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// URL is the range API, e.g. https://api.pwnedpasswords.com/range/
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
 // SessionConfig holds session configuration
@@ -259,6 +280,35 @@ type EventsConfig struct {
 	TopicPrefix string `mapstructure:"topic_prefix"`
 }
 
+// ActivityConfig holds configuration for the account activity feed
+type ActivityConfig struct {
+	// Timeout bounds each source; a source that misses it is reported unavailable
+	Timeout              time.Duration `mapstructure:"timeout"`
+	InvestmentServiceURL string        `mapstructure:"investment_service_url"`
+	KYCServiceURL        string        `mapstructure:"kyc_service_url"`
+}
+
+// ImpersonationConfig holds configuration for admin impersonation
+type ImpersonationConfig struct {
+	// TokenTTL is how long an impersonation token is valid
+	TokenTTL time.Duration `mapstructure:"token_ttl"`
+	// Scopes are granted to impersonation tokens: "read", and "write" for changes
+	// other than to credentials and security settings
+	Scopes []string `mapstructure:"scopes"`
+	// MaxPerWindow caps the tokens an admin can be issued per Window
+	MaxPerWindow int           `mapstructure:"max_per_window"`
+	Window       time.Duration `mapstructure:"window"`
+}
+
+// AuthAuditConfig holds configuration for the auth audit trail
+type AuthAuditConfig struct {
+	// BufferSize is how many events may wait to be written; events beyond it
+	// are dropped rather than delaying logins
+	BufferSize int `mapstructure:"buffer_size"`
+	// WriteTimeout bounds writing a single event
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+}
+
 // Global configuration instance
 var cfg *Config
 
@@ -44,6 +44,10 @@ var (
 		Code:    http.StatusUnauthorized,
 		Message: "Invalid authentication token",
 	}
+	ErrInvalidMFACode = &Error{
+		Code:    http.StatusUnauthorized,
+		Message: "Invalid MFA code",
+	}
 
 	// Authorization errors
 	ErrInsufficientPermissions = &Error{
@@ -54,6 +58,10 @@ var (
 		Code:    http.StatusForbidden,
 		Message: "Access denied",
 	}
+	ErrImpersonationRestricted = &Error{
+		Code:    http.StatusForbidden,
+		Message: "Not permitted while impersonating a user",
+	}
 
 	// Validation errors
 	ErrInvalidInput = &Error{
@@ -72,6 +80,26 @@ var (
 		Code:    http.StatusBadRequest,
 		Message: "Invalid phone number format",
 	}
+	ErrBatchTooLarge = &Error{
+		Code:    http.StatusBadRequest,
+		Message: "Too many IDs in batch request",
+	}
+	ErrInvalidActivityCursor = &Error{
+		Code:    http.StatusBadRequest,
+		Message: "Invalid activity cursor",
+	}
+	ErrUnknownActivityType = &Error{
+		Code:    http.StatusBadRequest,
+		Message: "Unknown activity type",
+	}
+	ErrImpersonationReasonRequired = &Error{
+		Code:    http.StatusBadRequest,
+		Message: "A reason is required to impersonate a user",
+	}
+	ErrSelfImpersonation = &Error{
+		Code:    http.StatusBadRequest,
+		Message: "Cannot impersonate yourself",
+	}
 
 	// Resource errors
 	ErrUserNotFound = &Error{
@@ -96,6 +124,10 @@ var (
 		Code:    http.StatusTooManyRequests,
 		Message: "Too many login attempts",
 	}
+	ErrTooManyImpersonations = &Error{
+		Code:    http.StatusTooManyRequests,
+		Message: "Too many impersonation requests",
+	}
 	ErrPasswordResetExpired = &Error{
 		Code:    http.StatusBadRequest,
 		Message: "Password reset token has expired",
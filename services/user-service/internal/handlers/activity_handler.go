@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sparkfund/services/user-service/internal/errors"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"github.com/sparkfund/services/user-service/internal/service"
+)
+
+// ActivityHandler handles HTTP requests for the account activity feed
+type ActivityHandler struct {
+	activityService *service.ActivityService
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(activityService *service.ActivityService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+	}
+}
+
+// RegisterRoutes registers the activity feed routes
+func (h *ActivityHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/users/{id}/activity", h.handleGetActivity).Methods("GET")
+}
+
+// handleGetActivity returns a page of the user's activity across services.
+// Query parameters: type (comma-separated activity types), cursor and limit.
+func (h *ActivityHandler) handleGetActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeError(w, errors.Wrap(errors.ErrInvalidInput, "Invalid user ID"))
+		return
+	}
+
+	params := r.URL.Query()
+	query := service.ActivityQuery{
+		Cursor:        params.Get("cursor"),
+		Authorization: r.Header.Get("Authorization"),
+	}
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			writeError(w, errors.Wrap(errors.ErrInvalidInput, "limit must be a positive integer"))
+			return
+		}
+		query.Limit = limit
+	}
+	for _, raw := range strings.Split(params.Get("type"), ",") {
+		if raw = strings.TrimSpace(raw); raw != "" {
+			query.Types = append(query.Types, models.ActivityType(strings.ToLower(raw)))
+		}
+	}
+
+	feed, err := h.activityService.GetActivity(r.Context(), userID, query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(feed)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sparkfund/services/user-service/internal/errors"
+	"github.com/sparkfund/services/user-service/internal/logger"
+	"github.com/sparkfund/services/user-service/internal/service"
+)
+
+// AdminRole is the role allowed to impersonate users
+const AdminRole = "admin"
+
+// restrictedRoutes can never be used with an impersonation token, whatever its
+// scopes: an admin acting as a user must not change how that user signs in
+var restrictedRoutes = []string{
+	"/api/v1/users/{id}/password",
+	"/api/v1/users/{id}/mfa/",
+	"/api/v1/users/{id}/sessions",
+	"/api/v1/users/{id}/security/",
+	"/api/v1/users/{id}/impersonate",
+	"/api/v1/users/password/reset",
+}
+
+// ImpersonationHandler handles HTTP requests for admin impersonation
+type ImpersonationHandler struct {
+	impersonationService *service.ImpersonationService
+	keys                 *jwtkeys.KeySet
+	algorithms           []string
+}
+
+// NewImpersonationHandler creates a new impersonation handler. keys verifies
+// the caller's token.
+func NewImpersonationHandler(impersonationService *service.ImpersonationService, keys *jwtkeys.KeySet) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+		keys:                 keys,
+		algorithms:           jwtalg.FromEnv(),
+	}
+}
+
+// RegisterRoutes registers the impersonation routes
+func (h *ImpersonationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/v1/users/{id}/impersonate", h.handleImpersonate).Methods("POST")
+}
+
+// handleImpersonate issues a token for an admin to act as the user. The body
+// must give a reason, which is written to the audit log.
+func (h *ImpersonationHandler) handleImpersonate(w http.ResponseWriter, r *http.Request) {
+	claims, err := bearerClaims(r, h.keys, h.algorithms)
+	if err != nil {
+		writeError(w, errors.ErrInvalidToken)
+		return
+	}
+	// An impersonation token carries the target's roles, never the admin's
+	if _, impersonating := service.Impersonator(claims); impersonating || !hasRole(claims, AdminRole) {
+		writeError(w, errors.ErrInsufficientPermissions)
+		return
+	}
+	subject, _ := claims["sub"].(string)
+	adminID, err := uuid.Parse(subject)
+	if err != nil {
+		writeError(w, errors.ErrInvalidToken)
+		return
+	}
+
+	targetID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, errors.Wrap(errors.ErrInvalidInput, "Invalid user ID"))
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, errors.Wrap(errors.ErrInvalidInput, "Invalid request body"))
+		return
+	}
+
+	token, err := h.impersonationService.Impersonate(r.Context(), service.ImpersonationRequest{
+		AdminID:   adminID,
+		TargetID:  targetID,
+		Reason:    request.Reason,
+		IPAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// ImpersonationGuard restricts requests made with impersonation tokens: they
+// are refused on restrictedRoutes, and need the write scope for anything but
+// reads. Every impersonated request is logged with the acting admin. Register
+// it with router.Use so the matched route is known.
+func ImpersonationGuard(keys *jwtkeys.KeySet) mux.MiddlewareFunc {
+	algorithms := jwtalg.FromEnv()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Invalid tokens are rejected by TenantMiddleware
+			claims, err := bearerClaims(r, keys, algorithms)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			admin, impersonating := service.Impersonator(claims)
+			if !impersonating {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+
+			fields := map[string]interface{}{
+				"admin_id":     admin,
+				"user_id":      claims["sub"],
+				"audit_id":     claims[service.ImpersonationIDClaim],
+				"method":       r.Method,
+				"route":        route,
+				"impersonated": true,
+			}
+
+			if !impersonationPermits(claims, r.Method, route) {
+				logger.Warn("Impersonated request refused", fields)
+				writeError(w, errors.ErrImpersonationRestricted)
+				return
+			}
+
+			logger.Info("Impersonated request", fields)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// impersonationPermits reports whether an impersonation token may make a
+// request to route
+func impersonationPermits(claims jwt.MapClaims, method, route string) bool {
+	for _, restricted := range restrictedRoutes {
+		if strings.HasPrefix(route, restricted) {
+			return false
+		}
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return service.HasScope(claims, service.ScopeRead) || service.HasScope(claims, service.ScopeWrite)
+	default:
+		return service.HasScope(claims, service.ScopeWrite)
+	}
+}
+
+// bearerClaims verifies the request's bearer token and returns its claims
+func bearerClaims(r *http.Request, keys *jwtkeys.KeySet, algorithms []string) (jwt.MapClaims, error) {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, errors.ErrInvalidToken
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(authHeader, "Bearer "), claims,
+		jwtalg.Keyfunc(algorithms, keys.Keyfunc), jwtalg.ParserOptions(algorithms)...)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// hasRole reports whether claims grant role, in either a "role" or a "roles" claim
+func hasRole(claims jwt.MapClaims, role string) bool {
+	if claimed, ok := claims["role"].(string); ok && claimed == role {
+		return true
+	}
+	roles, _ := claims["roles"].([]interface{})
+	for _, claimed := range roles {
+		if claimed == role {
+			return true
+		}
+	}
+	return false
+}
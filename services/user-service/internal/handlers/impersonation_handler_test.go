@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"github.com/sparkfund/services/user-service/internal/repository"
+	"github.com/sparkfund/services/user-service/internal/service"
+)
+
+// impersonationRepository serves users from memory and keeps the audit records
+// written. Methods not overridden here panic if called.
+type impersonationRepository struct {
+	repository.UserRepository
+	users  map[uuid.UUID]*models.User
+	audits []*models.ImpersonationAuditLog
+}
+
+func (r *impersonationRepository) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *impersonationRepository) CreateImpersonationAudit(ctx context.Context, log *models.ImpersonationAuditLog) error {
+	r.audits = append(r.audits, log)
+	return nil
+}
+
+// impersonationFixture routes the impersonation endpoint and a few user routes
+// behind ImpersonationGuard
+type impersonationFixture struct {
+	router *mux.Router
+	repo   *impersonationRepository
+	keys   *jwtkeys.KeySet
+	admin  uuid.UUID
+	target *models.User
+}
+
+func newImpersonationFixture(t *testing.T, config service.ImpersonationConfig) *impersonationFixture {
+	t.Helper()
+
+	f := &impersonationFixture{
+		keys:   jwtkeys.FromSecret(testSecret),
+		admin:  uuid.New(),
+		target: &models.User{ID: uuid.New(), TenantID: "tenant-a", Email: "alice@example.com"},
+	}
+	f.repo = &impersonationRepository{users: map[uuid.UUID]*models.User{f.target.ID: f.target}}
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	f.router = mux.NewRouter()
+	f.router.Use(ImpersonationGuard(f.keys))
+	NewImpersonationHandler(service.NewImpersonationService(f.repo, f.keys, config), f.keys).RegisterRoutes(f.router)
+	f.router.HandleFunc("/api/v1/users/{id}", ok).Methods("GET", "PUT")
+	f.router.HandleFunc("/api/v1/users/{id}/password", ok).Methods("PUT")
+	f.router.HandleFunc("/api/v1/users/{id}/mfa/disable", ok).Methods("POST")
+	return f
+}
+
+// token signs claims for a caller
+func (f *impersonationFixture) token(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	token, err := f.keys.Sign(claims)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func (f *impersonationFixture) adminToken(t *testing.T) string {
+	return f.token(t, jwt.MapClaims{"sub": f.admin.String(), "role": AdminRole})
+}
+
+func (f *impersonationFixture) serve(method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func (f *impersonationFixture) impersonate(t *testing.T, token, reason string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"reason": reason})
+	return f.serve(http.MethodPost, "/api/v1/users/"+f.target.ID.String()+"/impersonate", token, string(body))
+}
+
+// mint impersonates the target as the admin and returns the issued token
+func (f *impersonationFixture) mint(t *testing.T) string {
+	t.Helper()
+	w := f.impersonate(t, f.adminToken(t), "Reproducing ticket SUP-1234")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var issued service.ImpersonationToken
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return issued.Token
+}
+
+func TestImpersonate_TokenCarriesImpersonationMarker(t *testing.T) {
+	f := newImpersonationFixture(t, service.ImpersonationConfig{TokenTTL: 10 * time.Minute})
+	token := f.mint(t)
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, f.keys.Keyfunc); err != nil {
+		t.Fatalf("issued token does not verify: %v", err)
+	}
+	if claims["sub"] != f.target.ID.String() {
+		t.Fatalf("expected the token to act as %s, got %v", f.target.ID, claims["sub"])
+	}
+	if admin, ok := service.Impersonator(claims); !ok || admin != f.admin.String() {
+		t.Fatalf("expected the token to name %s as the impersonator, got %q", f.admin, admin)
+	}
+	if claims["scope"] != service.ScopeRead {
+		t.Fatalf("expected a read-only scope, got %v", claims["scope"])
+	}
+	if claims["tenant_id"] != f.target.TenantID {
+		t.Fatalf("expected the target's tenant, got %v", claims["tenant_id"])
+	}
+	exp, _ := claims.GetExpirationTime()
+	if exp == nil || time.Until(exp.Time) > 10*time.Minute {
+		t.Fatalf("expected a short-lived token, got expiry %v", exp)
+	}
+
+	if len(f.repo.audits) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(f.repo.audits))
+	}
+	audit := f.repo.audits[0]
+	if audit.AdminID != f.admin || audit.TargetUserID != f.target.ID || audit.Reason != "Reproducing ticket SUP-1234" {
+		t.Fatalf("unexpected audit record %+v", audit)
+	}
+	if claims[service.ImpersonationIDClaim] != audit.ID.String() {
+		t.Fatalf("expected the token to reference audit record %s, got %v", audit.ID, claims[service.ImpersonationIDClaim])
+	}
+}
+
+func TestImpersonate_TokenIsScopeRestricted(t *testing.T) {
+	f := newImpersonationFixture(t, service.ImpersonationConfig{})
+	token := f.mint(t)
+	user := "/api/v1/users/" + f.target.ID.String()
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, user, http.StatusOK},
+		{http.MethodPut, user, http.StatusForbidden},
+		{http.MethodPut, user + "/password", http.StatusForbidden},
+		{http.MethodPost, user + "/mfa/disable", http.StatusForbidden},
+		// An impersonation token can never mint another
+		{http.MethodPost, user + "/impersonate", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := f.serve(tt.method, tt.path, token, `{"reason":"nested"}`); w.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestImpersonate_WriteScopeStillExcludesSecuritySettings(t *testing.T) {
+	f := newImpersonationFixture(t, service.ImpersonationConfig{Scopes: []string{service.ScopeRead, service.ScopeWrite}})
+	token := f.mint(t)
+	user := "/api/v1/users/" + f.target.ID.String()
+
+	if w := f.serve(http.MethodPut, user, token, "{}"); w.Code != http.StatusOK {
+		t.Fatalf("expected the write scope to permit updates, got %d", w.Code)
+	}
+	if w := f.serve(http.MethodPut, user+"/password", token, "{}"); w.Code != http.StatusForbidden {
+		t.Fatalf("expected password changes to be refused, got %d", w.Code)
+	}
+}
+
+func TestImpersonate_RequiresAdminAndReason(t *testing.T) {
+	f := newImpersonationFixture(t, service.ImpersonationConfig{})
+
+	if w := f.impersonate(t, f.adminToken(t), "  "); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing reason: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	user := f.token(t, jwt.MapClaims{"sub": uuid.New().String(), "role": "user"})
+	if w := f.impersonate(t, user, "curious"); w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin: got %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	if w := f.impersonate(t, "", "no token"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated: got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	if len(f.repo.audits) != 0 {
+		t.Fatalf("expected refused requests not to be audited, got %d records", len(f.repo.audits))
+	}
+}
+
+func TestImpersonate_RateLimitedPerAdmin(t *testing.T) {
+	f := newImpersonationFixture(t, service.ImpersonationConfig{MaxPerWindow: 2, Window: time.Hour})
+
+	f.mint(t)
+	f.mint(t)
+	if w := f.impersonate(t, f.adminToken(t), "third attempt"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	// Another admin has a budget of their own
+	other := f.token(t, jwt.MapClaims{"sub": uuid.New().String(), "roles": []string{AdminRole}})
+	if w := f.impersonate(t, other, "separate ticket"); w.Code != http.StatusCreated {
+		t.Fatalf("other admin: got %d, want %d", w.Code, http.StatusCreated)
+	}
+}
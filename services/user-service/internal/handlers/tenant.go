@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtalg"
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantHeader names the partner tenant on unauthenticated requests such as
+// registration and login
+const TenantHeader = "X-Tenant-ID"
+
+// TenantMiddleware scopes each request to a tenant. Authenticated requests use
+// the tenant claim of the caller's token, which a TenantHeader cannot override;
+// unauthenticated requests use TenantHeader. Requests without a tenant are refused.
+func TenantMiddleware(secret string) func(http.Handler) http.Handler {
+	algorithms := jwtalg.FromEnv()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := r.Header.Get(TenantHeader)
+
+			if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+				claims := jwt.MapClaims{}
+				_, err := jwt.ParseWithClaims(strings.TrimPrefix(authHeader, "Bearer "), claims, jwtalg.Keyfunc(algorithms, func(token *jwt.Token) (interface{}, error) {
+					return []byte(secret), nil
+				}), jwtalg.ParserOptions(algorithms)...)
+				if err != nil {
+					// Tell clients whether to refresh or discard the token
+					reason := jwtalg.Classify(err)
+					metrics.RecordJWTValidationFailure(reason)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusUnauthorized)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error": "Invalid or expired token",
+						"code":  reason,
+					})
+					return
+				}
+
+				claimed := tenant.FromClaims(claims)
+				if tenantID != "" && tenantID != claimed {
+					http.Error(w, "Tenant does not match token", http.StatusForbidden)
+					return
+				}
+				tenantID = claimed
+			}
+
+			if tenantID == "" {
+				http.Error(w, "Tenant is required", http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), tenantID)))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func tenantToken(t *testing.T, tenantID string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":        "user-1",
+		tenant.Claim: tenantID,
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+// serveTenant runs a request through TenantMiddleware and returns the status
+// and the tenant the handler saw
+func serveTenant(req *http.Request) (int, string) {
+	var seen string
+	handler := TenantMiddleware(testSecret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = tenant.FromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code, seen
+}
+
+func TestTenantMiddleware_UsesTokenTenant(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	req.Header.Set("Authorization", "Bearer "+tenantToken(t, "tenant-a"))
+
+	code, seen := serveTenant(req)
+	if code != http.StatusOK || seen != "tenant-a" {
+		t.Fatalf("expected tenant-a to be served, got %d with tenant %q", code, seen)
+	}
+}
+
+func TestTenantMiddleware_HeaderCannotOverrideToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	req.Header.Set("Authorization", "Bearer "+tenantToken(t, "tenant-a"))
+	req.Header.Set(TenantHeader, "tenant-b")
+
+	if code, _ := serveTenant(req); code != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestTenantMiddleware_RequiresTenant(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+	if code, _ := serveTenant(req); code != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d", code, http.StatusBadRequest)
+	}
+
+	req.Header.Set(TenantHeader, "tenant-b")
+	if code, seen := serveTenant(req); code != http.StatusOK || seen != "tenant-b" {
+		t.Fatalf("expected an unauthenticated request to use the header tenant, got %d with %q", code, seen)
+	}
+}
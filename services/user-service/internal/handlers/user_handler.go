@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/fields"
+	"github.com/adil-faiyaz98/sparkfund/pkg/password"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/sparkfund/services/user-service/internal/errors"
@@ -16,6 +20,7 @@ import (
 // UserHandler handles HTTP requests for user operations
 type UserHandler struct {
 	userService *service.UserService
+	audit       service.AuditLogger
 }
 
 // NewUserHandler creates a new user handler
@@ -25,10 +30,16 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 	}
 }
 
+// SetAuditLogger records logins and logouts to the auth audit trail
+func (h *UserHandler) SetAuditLogger(audit service.AuditLogger) {
+	h.audit = audit
+}
+
 // RegisterRoutes registers all user routes
 func (h *UserHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/users", h.handleRegister).Methods("POST")
 	router.HandleFunc("/api/v1/users/login", h.handleLogin).Methods("POST")
+	router.HandleFunc("/api/v1/users/batch", h.handleBatchGetUsers).Methods("POST")
 	router.HandleFunc("/api/v1/users/{id}", h.handleGetUser).Methods("GET")
 	router.HandleFunc("/api/v1/users/{id}", h.handleUpdateUser).Methods("PUT")
 	router.HandleFunc("/api/v1/users/{id}/profile", h.handleGetProfile).Methods("GET")
@@ -50,14 +61,18 @@ func (h *UserHandler) RegisterRoutes(router *mux.Router) {
 
 // handleRegister handles user registration
 func (h *UserHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
-	var user models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+	var request struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.userService.RegisterUser(r.Context(), &user); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	user := models.User{Email: request.Email}
+	if err := h.userService.RegisterUser(r.Context(), &user, request.Password); err != nil {
+		h.handleError(w, err)
 		return
 	}
 
@@ -82,7 +97,8 @@ func (h *UserHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userService.AuthenticateUser(r.Context(), credentials.Email, credentials.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		h.auditAuth(r, models.AuthEventLogin, nil, "invalid credentials")
+		h.handleError(w, err)
 		return
 	}
 
@@ -91,17 +107,18 @@ func (h *UserHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		ID:        uuid.New(),
 		UserID:    user.ID,
 		Token:     uuid.New().String(),
-		IP:        r.RemoteAddr,
+		IPAddress: r.RemoteAddr,
 		UserAgent: r.UserAgent(),
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(24 * time.Hour),
-		LastUsed:  time.Now(),
 	}
 
 	if err := h.userService.CreateSession(r.Context(), session); err != nil {
+		h.auditAuth(r, models.AuthEventLogin, &user.ID, "session not created")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.auditAuth(r, models.AuthEventLogin, &user.ID, "")
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -129,6 +146,61 @@ func (h *UserHandler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// userFields are the user fields clients may select with ?fields=
+var userFields = fields.Allowlist{"id", "email", "status", "created_at", "updated_at", "last_login_at"}
+
+// handleBatchGetUsers handles fetching several users in one request
+func (h *UserHandler) handleBatchGetUsers(w http.ResponseWriter, r *http.Request) {
+	selected, err := userFields.Parse(r.URL.Query().Get(fields.Param))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Reject oversized batches before parsing every ID
+	if len(req.IDs) > service.MaxBatchSize {
+		h.handleError(w, errors.ErrBatchTooLarge)
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			http.Error(w, "Invalid user ID: "+raw, http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	users, notFound, err := h.userService.GetUsersByIDs(r.Context(), ids)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	selectedUsers, err := fields.Select(users, selected)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users":     selectedUsers,
+		"not_found": notFound,
+	})
+}
+
 // handleUpdateUser handles updating user details
 func (h *UserHandler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -146,7 +218,7 @@ func (h *UserHandler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	user.ID = userID
 	if err := h.userService.UpdateUser(r.Context(), &user); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.handleError(w, err)
 		return
 	}
 
@@ -222,6 +294,10 @@ func (h *UserHandler) handleChangePassword(w http.ResponseWriter, r *http.Reques
 	}
 
 	if err := h.userService.ChangePassword(r.Context(), userID, passwords.OldPassword, passwords.NewPassword); err != nil {
+		if errors.IsValidationError(err) {
+			h.handleError(w, err)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -267,6 +343,10 @@ func (h *UserHandler) handleConfirmResetPassword(w http.ResponseWriter, r *http.
 	}
 
 	if err := h.userService.ConfirmResetPassword(r.Context(), request.Token, request.NewPassword); err != nil {
+		if errors.IsValidationError(err) {
+			h.handleError(w, err)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -312,9 +392,11 @@ func (h *UserHandler) handleRevokeSession(w http.ResponseWriter, r *http.Request
 	}
 
 	if err := h.userService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		h.auditAuth(r, models.AuthEventLogout, &userID, "session not revoked")
 		h.handleError(w, err)
 		return
 	}
+	h.auditAuth(r, models.AuthEventLogout, &userID, "")
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -332,9 +414,11 @@ func (h *UserHandler) handleRevokeAllSessions(w http.ResponseWriter, r *http.Req
 	}
 
 	if err := h.userService.RevokeAllSessions(r.Context(), userID); err != nil {
+		h.auditAuth(r, models.AuthEventLogout, &userID, "sessions not revoked")
 		h.handleError(w, err)
 		return
 	}
+	h.auditAuth(r, models.AuthEventLogout, &userID, "")
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -450,8 +534,44 @@ func (h *UserHandler) handleGetSecurityActivity(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(activity)
 }
 
+// auditAuth records an authentication action; a non-empty failure is the
+// reason it failed. Audit errors are logged by the audit logger and never fail
+// the request.
+func (h *UserHandler) auditAuth(r *http.Request, eventType models.AuthEventType, userID *uuid.UUID, failure string) {
+	if h.audit == nil {
+		return
+	}
+
+	event := &models.AuthAuditEvent{
+		EventType: eventType,
+		UserID:    userID,
+		SourceIP:  clientIP(r),
+		UserAgent: r.UserAgent(),
+		Outcome:   models.AuthOutcomeSuccess,
+		Reason:    failure,
+	}
+	if failure != "" {
+		event.Outcome = models.AuthOutcomeFailure
+	}
+	_ = h.audit.Log(r.Context(), event)
+}
+
+// clientIP returns the address of the client without its port
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
 // handleError handles error responses
 func (h *UserHandler) handleError(w http.ResponseWriter, err error) {
+	writeError(w, err)
+}
+
+// writeError writes err as a JSON error response, using the status code of an
+// *errors.Error and 500 for anything else
+func writeError(w http.ResponseWriter, err error) {
 	if err == nil {
 		return
 	}
@@ -473,9 +593,16 @@ func (h *UserHandler) handleError(w http.ResponseWriter, err error) {
 		"message":     message,
 	})
 
+	response := map[string]interface{}{
+		"error": message,
+	}
+	// Tell the user every password rule they missed, not just that one failed
+	var policyErr *password.PolicyError
+	if stderrors.As(err, &policyErr) {
+		response["violations"] = policyErr.Violations
+	}
+
 	// Send error response
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{
-		"error": message,
-	})
+	json.NewEncoder(w).Encode(response)
 }
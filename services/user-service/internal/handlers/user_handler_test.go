@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"github.com/sparkfund/services/user-service/internal/repository"
+	"github.com/sparkfund/services/user-service/internal/service"
+)
+
+// fakeUserRepository serves users from memory. Methods not overridden here
+// fall through to the embedded nil interface and panic if called.
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (r *fakeUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error) {
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, user *models.User) error {
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return repository.ErrUserExists
+		}
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+// batchGet posts ids to the batch endpoint with the given query string
+func batchGet(t *testing.T, handler *UserHandler, query string, ids ...uuid.UUID) *httptest.ResponseRecorder {
+	t.Helper()
+
+	raw := make([]string, len(ids))
+	for i, id := range ids {
+		raw[i] = `"` + id.String() + `"`
+	}
+	body := `{"ids":[` + strings.Join(raw, ",") + `]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/batch"+query, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.handleBatchGetUsers(w, req)
+	return w
+}
+
+func TestBatchGetUsers_ReturnsSelectedFields(t *testing.T) {
+	alice := &models.User{ID: uuid.New(), Email: "alice@example.com", Status: models.UserStatusActive}
+	handler := NewUserHandler(service.NewUserService(&fakeUserRepository{
+		users: map[uuid.UUID]*models.User{alice.ID: alice},
+	}))
+
+	w := batchGet(t, handler, "?fields=id,email", alice.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Users []map[string]interface{} `json:"users"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Users) != 1 {
+		t.Fatalf("expected 1 user, got %d", len(response.Users))
+	}
+	user := response.Users[0]
+	if len(user) != 2 || user["id"] != alice.ID.String() || user["email"] != alice.Email {
+		t.Fatalf("expected only id and email, got %v", user)
+	}
+}
+
+func TestBatchGetUsers_RejectsUnknownField(t *testing.T) {
+	handler := NewUserHandler(service.NewUserService(&fakeUserRepository{}))
+
+	w := batchGet(t, handler, "?fields=id,hashed_password", uuid.New())
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestRegister_DuplicateEmailIsConflict(t *testing.T) {
+	existing := &models.User{ID: uuid.New(), Email: "jane@example.com"}
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{existing.ID: existing}}
+	handler := NewUserHandler(service.NewUserService(repo))
+
+	for _, email := range []string{"jane@example.com", "Jane@Example.com", " JANE@EXAMPLE.COM "} {
+		body := `{"email":"` + email + `","password":"correct-Horse-battery-9"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.handleRegister(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Fatalf("%q: expected 409, got %d: %s", email, w.Code, w.Body.String())
+		}
+	}
+	if len(repo.users) != 1 {
+		t.Fatalf("expected no new users, got %d", len(repo.users))
+	}
+}
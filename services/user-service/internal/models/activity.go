@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// ActivityType identifies the source of an activity feed item
+type ActivityType string
+
+const (
+	ActivityTypeTransaction ActivityType = "transaction"
+	ActivityTypeInvestment  ActivityType = "investment"
+	ActivityTypeKYC         ActivityType = "kyc"
+)
+
+// ActivityItem is a single entry in a user's activity feed, normalized across
+// the services it is collected from
+type ActivityItem struct {
+	// ID is unique within the feed: the source type and the source's own ID
+	ID          string                 `json:"id"`
+	Type        ActivityType           `json:"type"`
+	Action      string                 `json:"action"`
+	Description string                 `json:"description"`
+	Status      string                 `json:"status,omitempty"`
+	Amount      *float64               `json:"amount,omitempty"`
+	Currency    string                 `json:"currency,omitempty"`
+	OccurredAt  time.Time              `json:"occurred_at"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+// ActivitySourceError reports a source that could not be read for a feed page
+type ActivitySourceError struct {
+	Type  ActivityType `json:"type"`
+	Error string       `json:"error"`
+}
+
+// ActivityFeed is a page of a user's activity, newest first
+type ActivityFeed struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	// Unavailable lists the sources missing from this page
+	Unavailable []ActivitySourceError `json:"unavailable,omitempty"`
+}
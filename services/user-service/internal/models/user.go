@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,7 @@ const (
 // User represents a user in the system
 type User struct {
 	ID             uuid.UUID  `json:"id"`
+	TenantID       string     `json:"-"`
 	Email          string     `json:"email"`
 	HashedPassword string     `json:"-"`
 	Status         UserStatus `json:"status"`
@@ -27,6 +29,12 @@ type User struct {
 	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
 }
 
+// NormalizeEmail returns the form emails are stored and looked up in: trimmed
+// and lower case, so "John@x.com " and "john@x.com" are the same account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 // UserProfile represents additional user information
 type UserProfile struct {
 	UserID      uuid.UUID `json:"user_id"`
@@ -69,6 +77,62 @@ type SecurityAuditLog struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ImpersonationAuditLog records an admin being issued a token to act as a user
+type ImpersonationAuditLog struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     string    `json:"-"`
+	AdminID      uuid.UUID `json:"admin_id"`
+	TargetUserID uuid.UUID `json:"target_user_id"`
+	Reason       string    `json:"reason"`
+	Scopes       []string  `json:"scopes"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// AuthEventType is the kind of authentication action an audit record covers
+type AuthEventType string
+
+const (
+	AuthEventLogin    AuthEventType = "login"
+	AuthEventRefresh  AuthEventType = "refresh"
+	AuthEventLogout   AuthEventType = "logout"
+	AuthEventValidate AuthEventType = "validate"
+)
+
+// AuthOutcome is whether an audited authentication action succeeded
+type AuthOutcome string
+
+const (
+	AuthOutcomeSuccess AuthOutcome = "success"
+	AuthOutcomeFailure AuthOutcome = "failure"
+)
+
+// AuthAuditEvent records an authentication action. UserID is nil when the
+// caller could not be identified, such as a login for an unknown email.
+type AuthAuditEvent struct {
+	ID        uuid.UUID     `json:"id"`
+	TenantID  string        `json:"-"`
+	Timestamp time.Time     `json:"timestamp"`
+	EventType AuthEventType `json:"event_type"`
+	UserID    *uuid.UUID    `json:"user_id,omitempty"`
+	SourceIP  string        `json:"source_ip"`
+	UserAgent string        `json:"user_agent"`
+	Outcome   AuthOutcome   `json:"outcome"`
+	// Reason explains a failure; it never contains credentials
+	Reason string `json:"reason,omitempty"`
+}
+
+// AuthAuditFilter selects authentication audit records created in [From, To)
+type AuthAuditFilter struct {
+	// UserID restricts records to one user; nil matches every user
+	UserID *uuid.UUID
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
 // SecurityActivity represents recent security activity for a user
 type SecurityActivity struct {
 	ID          uuid.UUID `json:"id"`
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/models"
+)
+
+// AuthAuditRepository stores authentication audit events in auth_audit_log. It
+// implements service.AuditLogger synchronously; wrap it in
+// service.AsyncAuditLogger to keep writes off the request path.
+type AuthAuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuthAuditRepository creates a new PostgreSQL auth audit repository
+func NewAuthAuditRepository(db *sql.DB) *AuthAuditRepository {
+	return &AuthAuditRepository{db: db}
+}
+
+// Log writes an event. Its tenant is the event's TenantID, or the caller's
+// tenant if that is empty.
+func (r *AuthAuditRepository) Log(ctx context.Context, event *models.AuthAuditEvent) error {
+	if event.TenantID == "" {
+		tenantID, err := callerTenant(ctx)
+		if err != nil {
+			return err
+		}
+		event.TenantID = tenantID
+	}
+
+	query := `
+		INSERT INTO auth_audit_log (
+			id, tenant_id, created_at, event_type, user_id,
+			source_ip, user_agent, outcome, reason
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID,
+		event.TenantID,
+		event.Timestamp,
+		event.EventType,
+		event.UserID,
+		event.SourceIP,
+		event.UserAgent,
+		event.Outcome,
+		event.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write auth audit event: %w", err)
+	}
+	return nil
+}
+
+// Query returns the caller's tenant's events matching filter, newest first
+func (r *AuthAuditRepository) Query(ctx context.Context, filter models.AuthAuditFilter) ([]models.AuthAuditEvent, error) {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, created_at, event_type, user_id, source_ip, user_agent, outcome, reason
+		FROM auth_audit_log
+		WHERE tenant_id = $1 AND created_at >= $2 AND created_at < $3
+			AND ($4::uuid IS NULL OR user_id = $4)
+		ORDER BY created_at DESC
+		LIMIT $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, filter.From, filter.To, filter.UserID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuthAuditEvent{}
+	for rows.Next() {
+		event := models.AuthAuditEvent{TenantID: tenantID}
+		var userID uuid.NullUUID
+		if err := rows.Scan(
+			&event.ID,
+			&event.Timestamp,
+			&event.EventType,
+			&userID,
+			&event.SourceIP,
+			&event.UserAgent,
+			&event.Outcome,
+			&event.Reason,
+		); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			event.UserID = &userID.UUID
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -3,9 +3,14 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sparkfund/services/user-service/internal/models"
 	"github.com/sparkfund/services/user-service/internal/repository"
 )
@@ -15,21 +20,48 @@ type UserRepository struct {
 	db *sql.DB
 }
 
-// NewUserRepository creates a new PostgreSQL user repository
+// NewUserRepository creates a new PostgreSQL user repository. Every query on
+// users is scoped to the tenant in the request context.
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// callerTenant returns the caller's tenant, failing closed when there is none
+func callerTenant(ctx context.Context) (string, error) {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", tenant.ErrMissingTenant
+	}
+	return id, nil
+}
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// emailTaken reports whether err is a violation of the per-tenant unique email
+// index. Two registrations racing for the same email both pass any earlier
+// lookup, so the index is what decides which one wins.
+func emailTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == "idx_users_tenant_email"
+}
+
 // Create implements repository.UserRepository.Create
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+	user.TenantID = tenantID
+
 	query := `
 		INSERT INTO users (
 			id, email, password, first_name, last_name, phone_number,
-			country, status, is_locked, failed_attempts, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			country, status, is_locked, failed_attempts, created_at, updated_at, tenant_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.Password,
@@ -42,21 +74,30 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.FailedAttempts,
 		user.CreatedAt,
 		user.UpdatedAt,
+		user.TenantID,
 	)
+	if emailTaken(err) {
+		return repository.ErrUserExists
+	}
 	return err
 }
 
 // Get implements repository.UserRepository.Get
 func (r *UserRepository) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT id, email, password, first_name, last_name, phone_number,
 			country, status, is_locked, failed_attempts, last_login_at,
 			created_at, updated_at
-		FROM users WHERE id = $1
+		FROM users WHERE id = $1 AND tenant_id = $2
 	`
 
 	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err = r.db.QueryRowContext(ctx, query, id, tenantID).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -79,15 +120,20 @@ func (r *UserRepository) Get(ctx context.Context, id uuid.UUID) (*models.User, e
 
 // GetByEmail implements repository.UserRepository.GetByEmail
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT id, email, password, first_name, last_name, phone_number,
 			country, status, is_locked, failed_attempts, last_login_at,
 			created_at, updated_at
-		FROM users WHERE email = $1
+		FROM users WHERE email = $1 AND tenant_id = $2
 	`
 
 	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
+	err = r.db.QueryRowContext(ctx, query, email, tenantID).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -108,8 +154,64 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return user, err
 }
 
+// GetByIDs implements repository.UserRepository.GetByIDs. Only the ID, email,
+// status and timestamps of each user are loaded.
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error) {
+	if len(ids) == 0 {
+		return []*models.User{}, nil
+	}
+
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// $1 is the tenant; the IDs follow
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, tenantID)
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+
+	// Batch lookups return summaries only; the password hash is never read
+	query := `
+		SELECT id, email, status, created_at, updated_at, last_login_at
+		FROM users WHERE tenant_id = $1 AND id IN (` + strings.Join(placeholders, ", ") + `)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0, len(ids))
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Status,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.LastLoginAt,
+		); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
 // Update implements repository.UserRepository.Update
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE users SET
 			email = $1,
@@ -122,7 +224,7 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 			failed_attempts = $8,
 			last_login_at = $9,
 			updated_at = $10
-		WHERE id = $11
+		WHERE id = $11 AND tenant_id = $12
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
@@ -137,7 +239,11 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 		user.LastLoginAt,
 		time.Now(),
 		user.ID,
+		tenantID,
 	)
+	if emailTaken(err) {
+		return repository.ErrUserExists
+	}
 	if err != nil {
 		return err
 	}
@@ -154,9 +260,14 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 
 // UpdateStatus implements repository.UserRepository.UpdateStatus
 func (r *UserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
-	query := `UPDATE users SET status = $1, updated_at = $2 WHERE id = $3`
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET status = $1, updated_at = $2 WHERE id = $3 AND tenant_id = $4`
 
-	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id, tenantID)
 	if err != nil {
 		return err
 	}
@@ -173,9 +284,14 @@ func (r *UserRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status
 
 // UpdatePassword implements repository.UserRepository.UpdatePassword
 func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error {
-	query := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3`
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
 
-	result, err := r.db.ExecContext(ctx, query, hashedPassword, time.Now(), id)
+	query := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3 AND tenant_id = $4`
+
+	result, err := r.db.ExecContext(ctx, query, hashedPassword, time.Now(), id, tenantID)
 	if err != nil {
 		return err
 	}
@@ -192,14 +308,22 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashe
 
 // GetProfile implements repository.UserRepository.GetProfile
 func (r *UserRepository) GetProfile(ctx context.Context, userID uuid.UUID) (*models.UserProfile, error) {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Profiles belong to the tenant of their user
 	query := `
-		SELECT user_id, address, city, state, postal_code,
-			date_of_birth, occupation, income, updated_at
-		FROM user_profiles WHERE user_id = $1
+		SELECT p.user_id, p.address, p.city, p.state, p.postal_code,
+			p.date_of_birth, p.occupation, p.income, p.updated_at
+		FROM user_profiles p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $1 AND u.tenant_id = $2
 	`
 
 	profile := &models.UserProfile{}
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+	err = r.db.QueryRowContext(ctx, query, userID, tenantID).Scan(
 		&profile.UserID,
 		&profile.Address,
 		&profile.City,
@@ -218,11 +342,19 @@ func (r *UserRepository) GetProfile(ctx context.Context, userID uuid.UUID) (*mod
 
 // UpdateProfile implements repository.UserRepository.UpdateProfile
 func (r *UserRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, profile *models.UserProfile) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The profile is only written when its user belongs to the caller's tenant
 	query := `
 		INSERT INTO user_profiles (
 			user_id, address, city, state, postal_code,
 			date_of_birth, occupation, income, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9
+		WHERE EXISTS (SELECT 1 FROM users WHERE id = $1 AND tenant_id = $10)
 		ON CONFLICT (user_id) DO UPDATE SET
 			address = EXCLUDED.address,
 			city = EXCLUDED.city,
@@ -234,7 +366,7 @@ func (r *UserRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, pr
 			updated_at = EXCLUDED.updated_at
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	result, err := r.db.ExecContext(ctx, query,
 		userID,
 		profile.Address,
 		profile.City,
@@ -244,18 +376,35 @@ func (r *UserRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, pr
 		profile.Occupation,
 		profile.Income,
 		profile.UpdatedAt,
+		tenantID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return repository.ErrUserNotFound
+	}
+	return nil
 }
 
 // StoreResetToken implements repository.UserRepository.StoreResetToken
 func (r *UserRepository) StoreResetToken(ctx context.Context, email string, token string, expiresAt time.Time) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO password_resets (user_id, token, expires_at, created_at)
-		SELECT id, $1, $2, $3 FROM users WHERE email = $4
+		SELECT id, $1, $2, $3 FROM users WHERE email = $4 AND tenant_id = $5
 	`
 
-	result, err := r.db.ExecContext(ctx, query, token, expiresAt, time.Now(), email)
+	result, err := r.db.ExecContext(ctx, query, token, expiresAt, time.Now(), email, tenantID)
 	if err != nil {
 		return err
 	}
@@ -409,6 +558,11 @@ func (r *UserRepository) DeleteExpiredSessions(ctx context.Context) error {
 
 // IncrementFailedAttempts implements repository.UserRepository.IncrementFailedAttempts
 func (r *UserRepository) IncrementFailedAttempts(ctx context.Context, userID uuid.UUID) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE users 
 		SET failed_attempts = failed_attempts + 1,
@@ -417,10 +571,10 @@ func (r *UserRepository) IncrementFailedAttempts(ctx context.Context, userID uui
 				ELSE is_locked 
 			END,
 			updated_at = $2
-		WHERE id = $3
+		WHERE id = $3 AND tenant_id = $4
 	`
 
-	result, err := r.db.ExecContext(ctx, query, 5, time.Now(), userID)
+	result, err := r.db.ExecContext(ctx, query, 5, time.Now(), userID, tenantID)
 	if err != nil {
 		return err
 	}
@@ -437,15 +591,20 @@ func (r *UserRepository) IncrementFailedAttempts(ctx context.Context, userID uui
 
 // ResetFailedAttempts implements repository.UserRepository.ResetFailedAttempts
 func (r *UserRepository) ResetFailedAttempts(ctx context.Context, userID uuid.UUID) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE users 
 		SET failed_attempts = 0,
 			is_locked = false,
 			updated_at = $1
-		WHERE id = $2
+		WHERE id = $2 AND tenant_id = $3
 	`
 
-	result, err := r.db.ExecContext(ctx, query, time.Now(), userID)
+	result, err := r.db.ExecContext(ctx, query, time.Now(), userID, tenantID)
 	if err != nil {
 		return err
 	}
@@ -462,10 +621,15 @@ func (r *UserRepository) ResetFailedAttempts(ctx context.Context, userID uuid.UU
 
 // GetFailedAttempts implements repository.UserRepository.GetFailedAttempts
 func (r *UserRepository) GetFailedAttempts(ctx context.Context, userID uuid.UUID) (int, error) {
-	query := `SELECT failed_attempts FROM users WHERE id = $1`
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT failed_attempts FROM users WHERE id = $1 AND tenant_id = $2`
 
 	var attempts int
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&attempts)
+	err = r.db.QueryRowContext(ctx, query, userID, tenantID).Scan(&attempts)
 	if err == sql.ErrNoRows {
 		return 0, repository.ErrUserNotFound
 	}
@@ -474,9 +638,14 @@ func (r *UserRepository) GetFailedAttempts(ctx context.Context, userID uuid.UUID
 
 // Delete implements repository.UserRepository.Delete
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	query := `DELETE FROM users WHERE id = $1 AND tenant_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, tenantID)
 	if err != nil {
 		return err
 	}
@@ -490,3 +659,33 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// CreateImpersonationAudit implements repository.UserRepository.CreateImpersonationAudit
+func (r *UserRepository) CreateImpersonationAudit(ctx context.Context, log *models.ImpersonationAuditLog) error {
+	tenantID, err := callerTenant(ctx)
+	if err != nil {
+		return err
+	}
+	log.TenantID = tenantID
+
+	query := `
+		INSERT INTO impersonation_audit_logs (
+			id, tenant_id, admin_id, target_user_id, reason, scopes,
+			ip_address, user_agent, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		log.ID,
+		log.TenantID,
+		log.AdminID,
+		log.TargetUserID,
+		log.Reason,
+		strings.Join(log.Scopes, " "),
+		log.IPAddress,
+		log.UserAgent,
+		log.CreatedAt,
+		log.ExpiresAt,
+	)
+	return err
+}
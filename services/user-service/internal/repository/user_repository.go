@@ -14,6 +14,7 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	Get(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.UserStatus) error
@@ -45,6 +46,7 @@ type UserRepository interface {
 	// Security audit operations
 	GetSecurityAuditLogs(ctx context.Context, userID uuid.UUID) ([]models.SecurityAuditLog, error)
 	GetSecurityActivity(ctx context.Context, userID uuid.UUID) ([]models.SecurityActivity, error)
+	CreateImpersonationAudit(ctx context.Context, log *models.ImpersonationAuditLog) error
 
 	// Login attempt operations
 	IncrementFailedAttempts(ctx context.Context, userID uuid.UUID) error
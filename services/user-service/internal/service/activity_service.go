@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/errors"
+	"github.com/sparkfund/services/user-service/internal/logger"
+	"github.com/sparkfund/services/user-service/internal/models"
+)
+
+// Activity feed page sizes
+const (
+	DefaultActivityPageSize = 20
+	MaxActivityPageSize     = 100
+)
+
+// DefaultActivitySourceTimeout bounds each source when no timeout is configured
+const DefaultActivitySourceTimeout = 3 * time.Second
+
+// ActivityRequest asks a source for a user's activity, newest first
+type ActivityRequest struct {
+	UserID uuid.UUID
+	// Before limits results to activity at or before this time; zero for the newest
+	Before time.Time
+	// Limit is the most items the feed needs from the source
+	Limit int
+	// Authorization is the caller's Authorization header, for services that
+	// scope results to the token holder
+	Authorization string
+}
+
+// ActivitySource supplies one type of activity to the feed
+type ActivitySource interface {
+	Type() models.ActivityType
+	ListActivity(ctx context.Context, req ActivityRequest) ([]models.ActivityItem, error)
+}
+
+// ActivityQuery selects a page of a user's activity feed
+type ActivityQuery struct {
+	// Types restricts the feed to these sources; every source if empty
+	Types         []models.ActivityType
+	Cursor        string
+	Limit         int
+	Authorization string
+}
+
+// ActivityService merges activity from other services into a single feed
+type ActivityService struct {
+	sources []ActivitySource
+	timeout time.Duration
+}
+
+// NewActivityService creates an activity service reading from sources, giving
+// each at most timeout per page
+func NewActivityService(timeout time.Duration, sources ...ActivitySource) *ActivityService {
+	if timeout <= 0 {
+		timeout = DefaultActivitySourceTimeout
+	}
+	return &ActivityService{
+		sources: sources,
+		timeout: timeout,
+	}
+}
+
+// GetActivity returns a page of the user's activity, newest first. Sources are
+// read concurrently; a source that fails or times out is listed in the feed's
+// Unavailable rather than failing the page.
+func (s *ActivityService) GetActivity(ctx context.Context, userID uuid.UUID, query ActivityQuery) (*models.ActivityFeed, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultActivityPageSize
+	}
+	if limit > MaxActivityPageSize {
+		limit = MaxActivityPageSize
+	}
+
+	var after *models.ActivityItem
+	if query.Cursor != "" {
+		position, err := decodeActivityCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = position
+	}
+
+	sources, err := s.selectSources(query.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	req := ActivityRequest{
+		UserID: userID,
+		// One extra item tells whether there is another page
+		Limit:         limit + 1,
+		Authorization: query.Authorization,
+	}
+	if after != nil {
+		req.Before = after.OccurredAt
+	}
+
+	type sourceResult struct {
+		items []models.ActivityItem
+		err   error
+	}
+	results := make([]sourceResult, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source ActivitySource) {
+			defer wg.Done()
+			sourceCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+
+			items, err := source.ListActivity(sourceCtx, req)
+			results[i] = sourceResult{items: items, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+
+	feed := &models.ActivityFeed{Items: make([]models.ActivityItem, 0, limit)}
+	var merged []models.ActivityItem
+	for i, result := range results {
+		if result.err != nil {
+			logger.Error(result.err, "Activity source unavailable", map[string]interface{}{
+				"source":  sources[i].Type(),
+				"user_id": userID,
+			})
+			feed.Unavailable = append(feed.Unavailable, models.ActivitySourceError{
+				Type:  sources[i].Type(),
+				Error: "source unavailable",
+			})
+			continue
+		}
+		for _, item := range result.items {
+			// Sources return activity at the cursor time, which may already have been served
+			if after != nil && !activityNewer(*after, item) {
+				continue
+			}
+			merged = append(merged, item)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return activityNewer(merged[i], merged[j])
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+		feed.NextCursor = encodeActivityCursor(merged[limit-1])
+	}
+	feed.Items = append(feed.Items, merged...)
+
+	return feed, nil
+}
+
+// selectSources returns the sources of the requested types
+func (s *ActivityService) selectSources(types []models.ActivityType) ([]ActivitySource, error) {
+	if len(types) == 0 {
+		return s.sources, nil
+	}
+
+	wanted := make(map[models.ActivityType]bool, len(types))
+	for _, activityType := range types {
+		switch activityType {
+		case models.ActivityTypeTransaction, models.ActivityTypeInvestment, models.ActivityTypeKYC:
+			wanted[activityType] = true
+		default:
+			return nil, errors.Wrap(errors.ErrUnknownActivityType, fmt.Sprintf("Unknown activity type: %s", activityType))
+		}
+	}
+
+	selected := make([]ActivitySource, 0, len(wanted))
+	for _, source := range s.sources {
+		if wanted[source.Type()] {
+			selected = append(selected, source)
+		}
+	}
+	return selected, nil
+}
+
+// activityNewer orders the feed: newest first, then by ID so the order is total
+func activityNewer(a, b models.ActivityItem) bool {
+	if !a.OccurredAt.Equal(b.OccurredAt) {
+		return a.OccurredAt.After(b.OccurredAt)
+	}
+	return a.ID > b.ID
+}
+
+// encodeActivityCursor returns a cursor for the page after item
+func encodeActivityCursor(item models.ActivityItem) string {
+	raw := strconv.FormatInt(item.OccurredAt.UnixNano(), 10) + "|" + item.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeActivityCursor returns the position of the last item a cursor was issued for
+func decodeActivityCursor(cursor string) (*models.ActivityItem, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.ErrInvalidActivityCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, errors.ErrInvalidActivityCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errors.ErrInvalidActivityCursor
+	}
+
+	return &models.ActivityItem{ID: id, OccurredAt: time.Unix(0, unixNano)}, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/errors"
+	"github.com/sparkfund/services/user-service/internal/models"
+)
+
+var activityEpoch = time.Date(2025, 3, 28, 12, 0, 0, 0, time.UTC)
+
+// fakeActivitySource serves fixed items, honouring Before like the real services
+type fakeActivitySource struct {
+	activityType models.ActivityType
+	items        []models.ActivityItem
+	err          error
+	requests     []ActivityRequest
+}
+
+func (s *fakeActivitySource) Type() models.ActivityType {
+	return s.activityType
+}
+
+func (s *fakeActivitySource) ListActivity(ctx context.Context, req ActivityRequest) ([]models.ActivityItem, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var items []models.ActivityItem
+	for _, item := range s.items {
+		if req.Before.IsZero() || !item.OccurredAt.After(req.Before) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// activityAt returns an item of the given type that occurred minutes after activityEpoch
+func activityAt(activityType models.ActivityType, id string, minutes int) models.ActivityItem {
+	return models.ActivityItem{
+		ID:         string(activityType) + ":" + id,
+		Type:       activityType,
+		OccurredAt: activityEpoch.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func activityIDs(feed *models.ActivityFeed) []string {
+	ids := make([]string, len(feed.Items))
+	for i, item := range feed.Items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func assertActivityIDs(t *testing.T, feed *models.ActivityFeed, want ...string) {
+	t.Helper()
+	got := activityIDs(feed)
+	if len(got) != len(want) {
+		t.Fatalf("got items %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got items %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetActivity_MergesSourcesNewestFirst(t *testing.T) {
+	transactions := &fakeActivitySource{activityType: models.ActivityTypeTransaction, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeTransaction, "1", 10),
+		activityAt(models.ActivityTypeTransaction, "2", 40),
+	}}
+	investments := &fakeActivitySource{activityType: models.ActivityTypeInvestment, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeInvestment, "1", 30),
+	}}
+	kyc := &fakeActivitySource{activityType: models.ActivityTypeKYC, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeKYC, "1", 0),
+		activityAt(models.ActivityTypeKYC, "1:approved", 20),
+	}}
+	svc := NewActivityService(time.Second, transactions, investments, kyc)
+
+	feed, err := svc.GetActivity(context.Background(), uuid.New(), ActivityQuery{Authorization: "Bearer token"})
+	if err != nil {
+		t.Fatalf("GetActivity: %v", err)
+	}
+
+	assertActivityIDs(t, feed, "transaction:2", "investment:1", "kyc:1:approved", "transaction:1", "kyc:1")
+	if len(feed.Unavailable) != 0 || feed.NextCursor != "" {
+		t.Fatalf("expected a complete single page, got %+v", feed)
+	}
+	if got := transactions.requests[0].Authorization; got != "Bearer token" {
+		t.Fatalf("expected the caller's token to be forwarded, got %q", got)
+	}
+}
+
+func TestGetActivity_NotesFailedSource(t *testing.T) {
+	transactions := &fakeActivitySource{activityType: models.ActivityTypeTransaction, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeTransaction, "1", 10),
+	}}
+	investments := &fakeActivitySource{activityType: models.ActivityTypeInvestment, err: stderrors.New("connection refused")}
+	svc := NewActivityService(time.Second, transactions, investments)
+
+	feed, err := svc.GetActivity(context.Background(), uuid.New(), ActivityQuery{})
+	if err != nil {
+		t.Fatalf("a failed source must not fail the feed: %v", err)
+	}
+
+	assertActivityIDs(t, feed, "transaction:1")
+	if len(feed.Unavailable) != 1 || feed.Unavailable[0].Type != models.ActivityTypeInvestment {
+		t.Fatalf("expected the investment source to be noted as unavailable, got %+v", feed.Unavailable)
+	}
+}
+
+// blockingActivitySource never answers until its context ends
+type blockingActivitySource struct{}
+
+func (blockingActivitySource) Type() models.ActivityType {
+	return models.ActivityTypeKYC
+}
+
+func (blockingActivitySource) ListActivity(ctx context.Context, req ActivityRequest) ([]models.ActivityItem, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGetActivity_SlowSourceTimesOut(t *testing.T) {
+	transactions := &fakeActivitySource{activityType: models.ActivityTypeTransaction, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeTransaction, "1", 10),
+	}}
+	svc := NewActivityService(20*time.Millisecond, transactions, blockingActivitySource{})
+
+	feed, err := svc.GetActivity(context.Background(), uuid.New(), ActivityQuery{})
+	if err != nil {
+		t.Fatalf("GetActivity: %v", err)
+	}
+
+	assertActivityIDs(t, feed, "transaction:1")
+	if len(feed.Unavailable) != 1 || feed.Unavailable[0].Type != models.ActivityTypeKYC {
+		t.Fatalf("expected the slow source to be noted as unavailable, got %+v", feed.Unavailable)
+	}
+}
+
+func TestGetActivity_PagesWithCursor(t *testing.T) {
+	transactions := &fakeActivitySource{activityType: models.ActivityTypeTransaction, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeTransaction, "1", 10),
+		activityAt(models.ActivityTypeTransaction, "2", 20),
+		activityAt(models.ActivityTypeTransaction, "3", 30),
+	}}
+	// Shares a timestamp with transaction:2, so the cursor must break the tie
+	investments := &fakeActivitySource{activityType: models.ActivityTypeInvestment, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeInvestment, "1", 20),
+	}}
+	svc := NewActivityService(time.Second, transactions, investments)
+	userID := uuid.New()
+
+	var pages [][]string
+	query := ActivityQuery{Limit: 2}
+	for {
+		feed, err := svc.GetActivity(context.Background(), userID, query)
+		if err != nil {
+			t.Fatalf("GetActivity: %v", err)
+		}
+		pages = append(pages, activityIDs(feed))
+		if feed.NextCursor == "" {
+			break
+		}
+		query.Cursor = feed.NextCursor
+	}
+
+	want := [][]string{
+		{"transaction:3", "transaction:2"},
+		{"investment:1", "transaction:1"},
+	}
+	if len(pages) != len(want) {
+		t.Fatalf("got pages %v, want %v", pages, want)
+	}
+	for i := range want {
+		if len(pages[i]) != len(want[i]) || pages[i][0] != want[i][0] || pages[i][1] != want[i][1] {
+			t.Fatalf("got pages %v, want %v", pages, want)
+		}
+	}
+}
+
+func TestGetActivity_FiltersByType(t *testing.T) {
+	transactions := &fakeActivitySource{activityType: models.ActivityTypeTransaction, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeTransaction, "1", 10),
+	}}
+	kyc := &fakeActivitySource{activityType: models.ActivityTypeKYC, items: []models.ActivityItem{
+		activityAt(models.ActivityTypeKYC, "1", 20),
+	}}
+	svc := NewActivityService(time.Second, transactions, kyc)
+
+	feed, err := svc.GetActivity(context.Background(), uuid.New(), ActivityQuery{Types: []models.ActivityType{models.ActivityTypeKYC}})
+	if err != nil {
+		t.Fatalf("GetActivity: %v", err)
+	}
+	assertActivityIDs(t, feed, "kyc:1")
+	if len(transactions.requests) != 0 {
+		t.Fatal("expected unselected sources not to be called")
+	}
+
+	_, err = svc.GetActivity(context.Background(), uuid.New(), ActivityQuery{Types: []models.ActivityType{"loans"}})
+	if !errors.IsValidationError(err) {
+		t.Fatalf("expected an unknown type to be refused, got %v", err)
+	}
+	_, err = svc.GetActivity(context.Background(), uuid.New(), ActivityQuery{Cursor: "not-a-cursor"})
+	if !errors.IsValidationError(err) {
+		t.Fatalf("expected a bad cursor to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sparkfund/services/user-service/internal/models"
+)
+
+// httpActivitySource reads one type of activity from another service's JSON API
+type httpActivitySource struct {
+	activityType models.ActivityType
+	client       *http.Client
+	url          func(req ActivityRequest) string
+	// decode converts the response body into feed items
+	decode func(body io.Reader) ([]models.ActivityItem, error)
+	// notFoundIsEmpty treats a 404 as a user with no activity of this type
+	notFoundIsEmpty bool
+}
+
+// Type returns the type of activity the source supplies
+func (s *httpActivitySource) Type() models.ActivityType {
+	return s.activityType
+}
+
+// ListActivity fetches the user's activity, forwarding the caller's token
+func (s *httpActivitySource) ListActivity(ctx context.Context, req ActivityRequest) ([]models.ActivityItem, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url(req), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if req.Authorization != "" {
+		httpReq.Header.Set("Authorization", req.Authorization)
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s activity request failed: %w", s.activityType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && s.notFoundIsEmpty {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s activity request returned %d", s.activityType, resp.StatusCode)
+	}
+
+	items, err := s.decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s activity: %w", s.activityType, err)
+	}
+	return items, nil
+}
+
+// NewTransactionActivitySource reads the caller's transactions from the investment service
+func NewTransactionActivitySource(investmentServiceURL string, client *http.Client) ActivitySource {
+	base := strings.TrimRight(investmentServiceURL, "/") + "/api/v1/transactions/"
+	return &httpActivitySource{
+		activityType: models.ActivityTypeTransaction,
+		client:       client,
+		url: func(req ActivityRequest) string {
+			query := url.Values{}
+			query.Set("limit", strconv.Itoa(req.Limit))
+			if !req.Before.IsZero() {
+				// The investment service filters at second precision
+				query.Set("before", req.Before.UTC().Add(time.Second).Truncate(time.Second).Format(time.RFC3339))
+			}
+			return base + "?" + query.Encode()
+		},
+		decode: decodeTransactionActivity,
+	}
+}
+
+// NewInvestmentActivitySource reads the caller's investments from the investment service
+func NewInvestmentActivitySource(investmentServiceURL string, client *http.Client) ActivitySource {
+	base := strings.TrimRight(investmentServiceURL, "/") + "/api/v1/investments/"
+	return &httpActivitySource{
+		activityType: models.ActivityTypeInvestment,
+		client:       client,
+		url: func(ActivityRequest) string {
+			return base
+		},
+		decode: decodeInvestmentActivity,
+	}
+}
+
+// NewKYCActivitySource reads the user's KYC status from the KYC service
+func NewKYCActivitySource(kycServiceURL string, client *http.Client) ActivitySource {
+	base := strings.TrimRight(kycServiceURL, "/") + "/api/v1/kyc/user/"
+	return &httpActivitySource{
+		activityType: models.ActivityTypeKYC,
+		client:       client,
+		url: func(req ActivityRequest) string {
+			return base + req.UserID.String()
+		},
+		decode:          decodeKYCActivity,
+		notFoundIsEmpty: true,
+	}
+}
+
+// decodeTransactionActivity maps investment service transactions to feed items
+func decodeTransactionActivity(body io.Reader) ([]models.ActivityItem, error) {
+	var transactions []struct {
+		ID            uint      `json:"id"`
+		TransactionID string    `json:"transaction_id"`
+		InvestmentID  uint      `json:"investment_id"`
+		Type          string    `json:"type"`
+		Status        string    `json:"status"`
+		Amount        float64   `json:"amount"`
+		Price         float64   `json:"price"`
+		Quantity      float64   `json:"quantity"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+	if err := json.NewDecoder(body).Decode(&transactions); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ActivityItem, 0, len(transactions))
+	for _, transaction := range transactions {
+		amount := transaction.Amount
+		items = append(items, models.ActivityItem{
+			ID:          fmt.Sprintf("transaction:%d", transaction.ID),
+			Type:        models.ActivityTypeTransaction,
+			Action:      strings.ToLower(transaction.Type),
+			Description: fmt.Sprintf("%s of %g units", strings.ToUpper(transaction.Type), transaction.Quantity),
+			Status:      transaction.Status,
+			Amount:      &amount,
+			OccurredAt:  transaction.CreatedAt,
+			Details: map[string]interface{}{
+				"transaction_id": transaction.TransactionID,
+				"investment_id":  transaction.InvestmentID,
+				"price":          transaction.Price,
+				"quantity":       transaction.Quantity,
+			},
+		})
+	}
+	return items, nil
+}
+
+// decodeInvestmentActivity maps each investment to an opened item, and a sold
+// item once it has been sold
+func decodeInvestmentActivity(body io.Reader) ([]models.ActivityItem, error) {
+	var investments []struct {
+		ID        uint       `json:"id"`
+		Type      string     `json:"type"`
+		Status    string     `json:"status"`
+		Symbol    string     `json:"symbol"`
+		Amount    float64    `json:"amount"`
+		Currency  string     `json:"currency"`
+		Quantity  float64    `json:"quantity"`
+		SellPrice *float64   `json:"sell_price"`
+		SellDate  *time.Time `json:"sell_date"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+	if err := json.NewDecoder(body).Decode(&investments); err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ActivityItem, 0, len(investments))
+	for _, investment := range investments {
+		amount := investment.Amount
+		details := map[string]interface{}{
+			"investment_id": investment.ID,
+			"symbol":        investment.Symbol,
+			"asset_type":    investment.Type,
+			"quantity":      investment.Quantity,
+		}
+		items = append(items, models.ActivityItem{
+			ID:          fmt.Sprintf("investment:%d", investment.ID),
+			Type:        models.ActivityTypeInvestment,
+			Action:      "opened",
+			Description: fmt.Sprintf("Invested in %s", investment.Symbol),
+			Status:      investment.Status,
+			Amount:      &amount,
+			Currency:    investment.Currency,
+			OccurredAt:  investment.CreatedAt,
+			Details:     details,
+		})
+
+		if investment.SellDate != nil {
+			item := models.ActivityItem{
+				ID:          fmt.Sprintf("investment:%d:sold", investment.ID),
+				Type:        models.ActivityTypeInvestment,
+				Action:      "sold",
+				Description: fmt.Sprintf("Sold %s", investment.Symbol),
+				Status:      investment.Status,
+				Currency:    investment.Currency,
+				OccurredAt:  *investment.SellDate,
+				Details:     details,
+			}
+			if investment.SellPrice != nil {
+				proceeds := *investment.SellPrice * investment.Quantity
+				item.Amount = &proceeds
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// decodeKYCActivity maps the user's KYC record to a submitted item, and an item
+// for its current status once it has moved on from pending
+func decodeKYCActivity(body io.Reader) ([]models.ActivityItem, error) {
+	var kyc struct {
+		ID        string    `json:"id"`
+		Status    string    `json:"status"`
+		RiskLevel string    `json:"risk_level"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	if err := json.NewDecoder(body).Decode(&kyc); err != nil {
+		return nil, err
+	}
+
+	items := []models.ActivityItem{{
+		ID:          fmt.Sprintf("kyc:%s", kyc.ID),
+		Type:        models.ActivityTypeKYC,
+		Action:      "submitted",
+		Description: "Identity verification submitted",
+		Status:      kyc.Status,
+		OccurredAt:  kyc.CreatedAt,
+	}}
+
+	status := strings.ToLower(kyc.Status)
+	if status != "" && status != "pending" && kyc.UpdatedAt.After(kyc.CreatedAt) {
+		items = append(items, models.ActivityItem{
+			ID:          fmt.Sprintf("kyc:%s:%s", kyc.ID, status),
+			Type:        models.ActivityTypeKYC,
+			Action:      status,
+			Description: fmt.Sprintf("Identity verification %s", status),
+			Status:      kyc.Status,
+			OccurredAt:  kyc.UpdatedAt,
+			Details: map[string]interface{}{
+				"risk_level": kyc.RiskLevel,
+			},
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/logger"
+	"github.com/sparkfund/services/user-service/internal/models"
+)
+
+// ErrAuditBufferFull is returned when an auth audit event is dropped because
+// the write buffer is full
+var ErrAuditBufferFull = errors.New("auth audit buffer is full")
+
+// ErrAuditLoggerClosed is returned for events logged after Close
+var ErrAuditLoggerClosed = errors.New("auth audit logger is closed")
+
+// AuditLogger records authentication actions for the security audit trail
+type AuditLogger interface {
+	// Log records an event
+	Log(ctx context.Context, event *models.AuthAuditEvent) error
+	// Query returns the events matching filter, newest first
+	Query(ctx context.Context, filter models.AuthAuditFilter) ([]models.AuthAuditEvent, error)
+}
+
+// AsyncAuditConfig holds configuration for the asynchronous audit logger
+type AsyncAuditConfig struct {
+	// BufferSize is how many events may wait to be written; further events are
+	// dropped and logged rather than slowing down the auth path
+	BufferSize int
+	// WriteTimeout bounds writing a single event
+	WriteTimeout time.Duration
+}
+
+// DefaultAsyncAuditConfig returns default asynchronous audit logger configuration
+func DefaultAsyncAuditConfig() AsyncAuditConfig {
+	return AsyncAuditConfig{
+		BufferSize:   1024,
+		WriteTimeout: 5 * time.Second,
+	}
+}
+
+// AsyncAuditLogger queues events on a buffered channel and writes them to a
+// store in the background, so logging adds no database round trip to the
+// request. Queries go straight to the store and do not see queued events.
+type AsyncAuditLogger struct {
+	store        AuditLogger
+	writeTimeout time.Duration
+	events       chan *models.AuthAuditEvent
+	done         chan struct{}
+	mu           sync.RWMutex
+	closed       bool
+}
+
+// NewAsyncAuditLogger creates an asynchronous audit logger writing to store
+// and starts its writer. Call Close on shutdown to flush queued events.
+func NewAsyncAuditLogger(store AuditLogger, config AsyncAuditConfig) *AsyncAuditLogger {
+	defaults := DefaultAsyncAuditConfig()
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaults.WriteTimeout
+	}
+
+	l := &AsyncAuditLogger{
+		store:        store,
+		writeTimeout: config.WriteTimeout,
+		events:       make(chan *models.AuthAuditEvent, config.BufferSize),
+		done:         make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Log queues event for writing. The ID, timestamp and the tenant of ctx are
+// filled in if unset. It never blocks: if the buffer is full the event is
+// dropped and ErrAuditBufferFull returned.
+func (l *AsyncAuditLogger) Log(ctx context.Context, event *models.AuthAuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if event.TenantID == "" {
+		event.TenantID, _ = tenant.FromContext(ctx)
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return ErrAuditLoggerClosed
+	}
+
+	select {
+	case l.events <- event:
+		return nil
+	default:
+		logger.Warn("Auth audit buffer full, dropping event", map[string]interface{}{
+			"event_type": event.EventType,
+			"outcome":    event.Outcome,
+			"user_id":    event.UserID,
+		})
+		return ErrAuditBufferFull
+	}
+}
+
+// Query returns the written events matching filter
+func (l *AsyncAuditLogger) Query(ctx context.Context, filter models.AuthAuditFilter) ([]models.AuthAuditEvent, error) {
+	return l.store.Query(ctx, filter)
+}
+
+// Close stops accepting events and waits for the queued ones to be written
+func (l *AsyncAuditLogger) Close() {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.events)
+	}
+	l.mu.Unlock()
+
+	<-l.done
+}
+
+// run writes queued events until the logger is closed
+func (l *AsyncAuditLogger) run() {
+	defer close(l.done)
+
+	for event := range l.events {
+		ctx, cancel := context.WithTimeout(context.Background(), l.writeTimeout)
+		if err := l.store.Log(ctx, event); err != nil {
+			logger.Error(err, "Failed to write auth audit event", map[string]interface{}{
+				"event_id":   event.ID,
+				"event_type": event.EventType,
+				"outcome":    event.Outcome,
+			})
+		}
+		cancel()
+	}
+}
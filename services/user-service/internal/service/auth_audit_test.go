@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/models"
+)
+
+// memoryAuditStore keeps written events in memory. While gate is set, writes
+// block until it is closed.
+type memoryAuditStore struct {
+	mu     sync.Mutex
+	events []models.AuthAuditEvent
+	gate   chan struct{}
+}
+
+func (s *memoryAuditStore) Log(ctx context.Context, event *models.AuthAuditEvent) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *memoryAuditStore) Query(ctx context.Context, filter models.AuthAuditFilter) ([]models.AuthAuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AuthAuditEvent(nil), s.events...), nil
+}
+
+func TestAsyncAuditLogger_WritesQueuedEventsOnClose(t *testing.T) {
+	store := &memoryAuditStore{}
+	audit := NewAsyncAuditLogger(store, AsyncAuditConfig{BufferSize: 10})
+
+	userID := uuid.New()
+	ctx := tenant.WithTenant(context.Background(), "tenant-a")
+	for _, outcome := range []models.AuthOutcome{models.AuthOutcomeFailure, models.AuthOutcomeSuccess} {
+		event := &models.AuthAuditEvent{EventType: models.AuthEventLogin, UserID: &userID, Outcome: outcome}
+		if err := audit.Log(ctx, event); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+	audit.Close()
+
+	events, _ := audit.Query(ctx, models.AuthAuditFilter{})
+	if len(events) != 2 {
+		t.Fatalf("expected both events to be written, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.ID == uuid.Nil || event.Timestamp.IsZero() {
+			t.Fatalf("expected the ID and timestamp to be set, got %+v", event)
+		}
+		if event.TenantID != "tenant-a" {
+			t.Fatalf("expected the caller's tenant, got %q", event.TenantID)
+		}
+	}
+	if events[0].Outcome != models.AuthOutcomeFailure || events[1].Outcome != models.AuthOutcomeSuccess {
+		t.Fatalf("expected events in the order logged, got %+v", events)
+	}
+
+	if err := audit.Log(ctx, &models.AuthAuditEvent{EventType: models.AuthEventLogout}); !errors.Is(err, ErrAuditLoggerClosed) {
+		t.Fatalf("expected events after Close to be refused, got %v", err)
+	}
+}
+
+func TestAsyncAuditLogger_DropsEventsWhenBufferIsFull(t *testing.T) {
+	store := &memoryAuditStore{gate: make(chan struct{})}
+	audit := NewAsyncAuditLogger(store, AsyncAuditConfig{BufferSize: 1})
+	ctx := tenant.WithTenant(context.Background(), "tenant-a")
+
+	// The writer holds one event while the store is blocked and the buffer
+	// holds one more; Log must fail fast instead of waiting for the store
+	var dropped int
+	for i := 0; i < 5; i++ {
+		if err := audit.Log(ctx, &models.AuthAuditEvent{EventType: models.AuthEventLogin}); errors.Is(err, ErrAuditBufferFull) {
+			dropped++
+		}
+	}
+	if dropped < 3 {
+		t.Fatalf("expected at least 3 of 5 events to be dropped, got %d", dropped)
+	}
+
+	close(store.gate)
+	audit.Close()
+	events, _ := store.Query(ctx, models.AuthAuditFilter{})
+	if len(events)+dropped != 5 {
+		t.Fatalf("expected every event to be written or dropped, got %d written and %d dropped", len(events), dropped)
+	}
+}
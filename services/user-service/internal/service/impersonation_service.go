@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/clock"
+	"github.com/adil-faiyaz98/sparkfund/pkg/jwtkeys"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/errors"
+	"github.com/sparkfund/services/user-service/internal/logger"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"github.com/sparkfund/services/user-service/internal/repository"
+)
+
+// Claims that mark an impersonation token. Downstream services should treat a
+// token carrying ActorClaim as issued to the actor, not the subject.
+const (
+	// ActorClaim holds the impersonating admin as {"sub": "<admin id>"}, following RFC 8693
+	ActorClaim = "act"
+	// ImpersonationIDClaim links the token to its impersonation audit record
+	ImpersonationIDClaim = "imp_id"
+	// ScopeClaim lists the space-separated scopes an impersonation token grants
+	ScopeClaim = "scope"
+)
+
+// Impersonation scopes
+const (
+	// ScopeRead permits reading the impersonated user's data
+	ScopeRead = "read"
+	// ScopeWrite permits changes other than to credentials and security settings
+	ScopeWrite = "write"
+)
+
+// ImpersonationConfig holds configuration for admin impersonation
+type ImpersonationConfig struct {
+	// TokenTTL is how long an impersonation token is valid
+	TokenTTL time.Duration
+	// Scopes are granted to every impersonation token
+	Scopes []string
+	// MaxPerWindow caps the tokens an admin can be issued per Window
+	MaxPerWindow int
+	Window       time.Duration
+	// Clock times tokens and the rate limit; the system clock if nil
+	Clock clock.Clock
+}
+
+// DefaultImpersonationConfig returns default impersonation configuration:
+// read-only tokens valid for 15 minutes, at most 5 per admin per hour
+func DefaultImpersonationConfig() ImpersonationConfig {
+	return ImpersonationConfig{
+		TokenTTL:     15 * time.Minute,
+		Scopes:       []string{ScopeRead},
+		MaxPerWindow: 5,
+		Window:       time.Hour,
+	}
+}
+
+// ImpersonationRequest is an admin's request to act as a user
+type ImpersonationRequest struct {
+	AdminID   uuid.UUID
+	TargetID  uuid.UUID
+	Reason    string
+	IPAddress string
+	UserAgent string
+}
+
+// ImpersonationToken is a token issued to an admin acting as a user
+type ImpersonationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Scopes    []string  `json:"scopes"`
+	AuditID   uuid.UUID `json:"audit_id"`
+}
+
+// ImpersonationService issues short-lived, scope-restricted tokens that let
+// support admins act as a user. Every token is audited before it is issued.
+type ImpersonationService struct {
+	userRepo repository.UserRepository
+	keys     *jwtkeys.KeySet
+	config   ImpersonationConfig
+	clock    clock.Clock
+
+	mu     sync.Mutex
+	issued map[uuid.UUID][]time.Time
+}
+
+// NewImpersonationService creates a new impersonation service. Zero config
+// fields use DefaultImpersonationConfig.
+func NewImpersonationService(userRepo repository.UserRepository, keys *jwtkeys.KeySet, config ImpersonationConfig) *ImpersonationService {
+	defaults := DefaultImpersonationConfig()
+	if config.TokenTTL <= 0 {
+		config.TokenTTL = defaults.TokenTTL
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaults.Scopes
+	}
+	if config.MaxPerWindow <= 0 {
+		config.MaxPerWindow = defaults.MaxPerWindow
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+
+	clk := config.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
+	return &ImpersonationService{
+		userRepo: userRepo,
+		keys:     keys,
+		config:   config,
+		clock:    clk,
+		issued:   make(map[uuid.UUID][]time.Time),
+	}
+}
+
+// Impersonate issues a token for the admin to act as the target user. The
+// target must be in the caller's tenant.
+func (s *ImpersonationService) Impersonate(ctx context.Context, req ImpersonationRequest) (*ImpersonationToken, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.ErrImpersonationReasonRequired
+	}
+	if req.AdminID == req.TargetID {
+		return nil, errors.ErrSelfImpersonation
+	}
+	if !s.allow(req.AdminID) {
+		logger.Warn("Impersonation rate limit exceeded", map[string]interface{}{
+			"admin_id":       req.AdminID,
+			"target_user_id": req.TargetID,
+		})
+		return nil, errors.ErrTooManyImpersonations
+	}
+
+	target, err := s.userRepo.Get(ctx, req.TargetID)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrUserNotFound) {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "Failed to look up user")
+	}
+
+	now := s.clock.Now()
+	audit := &models.ImpersonationAuditLog{
+		ID:           uuid.New(),
+		AdminID:      req.AdminID,
+		TargetUserID: target.ID,
+		Reason:       reason,
+		Scopes:       s.config.Scopes,
+		IPAddress:    req.IPAddress,
+		UserAgent:    req.UserAgent,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.config.TokenTTL),
+	}
+
+	// Record the impersonation first so no token is ever issued unaudited
+	if err := s.userRepo.CreateImpersonationAudit(ctx, audit); err != nil {
+		return nil, errors.Wrap(err, "Failed to record impersonation")
+	}
+
+	claims := jwt.MapClaims{
+		"sub":                target.ID.String(),
+		ActorClaim:           map[string]interface{}{"sub": req.AdminID.String()},
+		ImpersonationIDClaim: audit.ID.String(),
+		ScopeClaim:           strings.Join(s.config.Scopes, " "),
+		"iat":                now.Unix(),
+		"exp":                audit.ExpiresAt.Unix(),
+	}
+	if target.TenantID != "" {
+		claims[tenant.Claim] = target.TenantID
+	}
+
+	token, err := s.keys.Sign(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to sign impersonation token")
+	}
+
+	logger.Info("Impersonation token issued", map[string]interface{}{
+		"audit_id":       audit.ID,
+		"admin_id":       req.AdminID,
+		"target_user_id": target.ID,
+		"reason":         reason,
+		"scopes":         s.config.Scopes,
+		"expires_at":     audit.ExpiresAt,
+	})
+
+	return &ImpersonationToken{
+		Token:     token,
+		ExpiresAt: audit.ExpiresAt,
+		Scopes:    s.config.Scopes,
+		AuditID:   audit.ID,
+	}, nil
+}
+
+// allow records an impersonation by admin, reporting whether it is within the
+// admin's rate limit
+func (s *ImpersonationService) allow(admin uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	cutoff := now.Add(-s.config.Window)
+	recent := s.issued[admin][:0]
+	for _, at := range s.issued[admin] {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+
+	if len(recent) >= s.config.MaxPerWindow {
+		s.issued[admin] = recent
+		return false
+	}
+	s.issued[admin] = append(recent, now)
+	return true
+}
+
+// Impersonator returns the admin acting through claims, if they belong to an
+// impersonation token
+func Impersonator(claims jwt.MapClaims) (string, bool) {
+	actor, ok := claims[ActorClaim].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	admin, ok := actor["sub"].(string)
+	return admin, ok && admin != ""
+}
+
+// HasScope reports whether an impersonation token's claims grant scope
+func HasScope(claims jwt.MapClaims, scope string) bool {
+	granted, _ := claims[ScopeClaim].(string)
+	for _, s := range strings.Fields(granted) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/cache"
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sparkfund/services/user-service/internal/logger"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// UserCacheConfig holds configuration for the user lookup cache
+type UserCacheConfig struct {
+	// TTL is how long users stay in Redis
+	TTL time.Duration
+	// LocalTTL is how long users stay in each replica's memory. Keep it short:
+	// invalidations on one replica do not reach the others' memory.
+	LocalTTL time.Duration
+	// Redis is shared by all replicas; nil caches in memory only
+	Redis *redis.Client
+	// Prefix namespaces the Redis keys
+	Prefix string
+}
+
+// DefaultUserCacheConfig returns default user cache configuration
+func DefaultUserCacheConfig() UserCacheConfig {
+	return UserCacheConfig{
+		TTL:      5 * time.Minute,
+		LocalTTL: 30 * time.Second,
+		Prefix:   "user:",
+	}
+}
+
+// UserCache is a read-through cache for user lookups, in memory and
+// optionally in Redis. Concurrent misses for the same user share one load, so a
+// hot user expiring causes a single database query rather than a stampede.
+//
+// Cached users never carry the password hash; flows that need it must read the
+// repository directly.
+type UserCache struct {
+	local    *cache.Cache
+	redis    *redis.Client
+	ttl      time.Duration
+	localTTL time.Duration
+	prefix   string
+	loads    singleflight.Group
+	// generation is bumped by every invalidation. A load that started before an
+	// invalidation may have read the old row, so its result is not cached.
+	generation atomic.Uint64
+}
+
+// NewUserCache creates a user cache. Zero config fields use
+// DefaultUserCacheConfig.
+func NewUserCache(config UserCacheConfig) *UserCache {
+	defaults := DefaultUserCacheConfig()
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.LocalTTL <= 0 {
+		config.LocalTTL = defaults.LocalTTL
+	}
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+
+	return &UserCache{
+		local: cache.New(cache.Config{
+			DefaultExpiration: config.LocalTTL,
+			CleanupInterval:   config.LocalTTL * 2,
+		}),
+		redis:    config.Redis,
+		ttl:      config.TTL,
+		localTTL: config.LocalTTL,
+		prefix:   config.Prefix,
+	}
+}
+
+// cachedUser is the cached form of a user. TenantID and the password hash are
+// left out of models.User's JSON, so it is stored explicitly, without the hash.
+type cachedUser struct {
+	ID          uuid.UUID         `json:"id"`
+	TenantID    string            `json:"tenant_id"`
+	Email       string            `json:"email"`
+	Status      models.UserStatus `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	LastLoginAt *time.Time        `json:"last_login_at,omitempty"`
+}
+
+func newCachedUser(user *models.User) *cachedUser {
+	return &cachedUser{
+		ID:          user.ID,
+		TenantID:    user.TenantID,
+		Email:       user.Email,
+		Status:      user.Status,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		LastLoginAt: user.LastLoginAt,
+	}
+}
+
+// user returns a copy callers may modify
+func (u *cachedUser) user() *models.User {
+	return &models.User{
+		ID:          u.ID,
+		TenantID:    u.TenantID,
+		Email:       u.Email,
+		Status:      u.Status,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		LastLoginAt: u.LastLoginAt,
+	}
+}
+
+// GetByID returns the user with id, calling load on a miss
+func (c *UserCache) GetByID(ctx context.Context, id uuid.UUID, load func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	key := c.idKey(ctx, id)
+	if cached, ok := c.get(ctx, key); ok {
+		return cached.user(), nil
+	}
+	return c.load(ctx, key, load)
+}
+
+// GetByEmail returns the user with email, calling load on a miss. The email
+// key only records the user's ID, so invalidating the user by ID also covers
+// lookups by email.
+func (c *UserCache) GetByEmail(ctx context.Context, email string, load func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	emailKey := c.emailKey(ctx, email)
+	if id, ok := c.getID(ctx, emailKey); ok {
+		// A user whose email has since changed no longer matches
+		if cached, ok := c.get(ctx, c.idKey(ctx, id)); ok && strings.EqualFold(cached.Email, email) {
+			return cached.user(), nil
+		}
+	}
+	return c.load(ctx, emailKey, load)
+}
+
+// Invalidate drops a user after it has been changed
+func (c *UserCache) Invalidate(ctx context.Context, id uuid.UUID) {
+	c.generation.Add(1)
+
+	key := c.idKey(ctx, id)
+	c.loads.Forget(key)
+	c.local.Delete(key)
+	if c.redis != nil {
+		if err := c.redis.Del(ctx, key).Err(); err != nil {
+			logger.Warn("Failed to invalidate cached user", map[string]interface{}{
+				"user_id": id,
+				"error":   err.Error(),
+			})
+		}
+	}
+}
+
+// load runs load once for concurrent misses on key and caches the result
+func (c *UserCache) load(ctx context.Context, key string, load func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	generation := c.generation.Load()
+
+	result, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		// The load is shared, so one caller's cancellation must not fail the rest
+		ctx := context.WithoutCancel(ctx)
+		user, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		cached := newCachedUser(user)
+		if c.generation.Load() == generation {
+			c.set(ctx, cached)
+		}
+		return cached, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*cachedUser).user(), nil
+}
+
+// get reads a user from memory, then Redis
+func (c *UserCache) get(ctx context.Context, key string) (*cachedUser, bool) {
+	if value, ok := c.local.Get(key); ok {
+		if cached, ok := value.(*cachedUser); ok {
+			return cached, true
+		}
+	}
+	if c.redis == nil {
+		return nil, false
+	}
+
+	data, err := c.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Warn("Failed to read cached user", map[string]interface{}{"error": err.Error()})
+		}
+		return nil, false
+	}
+	var cached cachedUser
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	c.local.SetWithExpiration(key, &cached, c.localTTL)
+	return &cached, true
+}
+
+// getID reads the user ID an email key points at
+func (c *UserCache) getID(ctx context.Context, key string) (uuid.UUID, bool) {
+	if value, ok := c.local.Get(key); ok {
+		if id, ok := value.(uuid.UUID); ok {
+			return id, true
+		}
+	}
+	if c.redis == nil {
+		return uuid.Nil, false
+	}
+
+	value, err := c.redis.Get(ctx, key).Result()
+	if err != nil {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	c.local.SetWithExpiration(key, id, c.localTTL)
+	return id, true
+}
+
+// set caches a user under its ID and its email
+func (c *UserCache) set(ctx context.Context, cached *cachedUser) {
+	idKey := c.idKey(ctx, cached.ID)
+	emailKey := c.emailKey(ctx, cached.Email)
+
+	c.local.SetWithExpiration(idKey, cached, c.localTTL)
+	c.local.SetWithExpiration(emailKey, cached.ID, c.localTTL)
+	if c.redis == nil {
+		return
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	_, err = c.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, idKey, data, c.ttl)
+		pipe.Set(ctx, emailKey, cached.ID.String(), c.ttl)
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Failed to cache user", map[string]interface{}{
+			"user_id": cached.ID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// idKey and emailKey are scoped to the request's tenant, as lookups are
+func (c *UserCache) idKey(ctx context.Context, id uuid.UUID) string {
+	tenantID, _ := tenant.FromContext(ctx)
+	return c.prefix + tenantID + ":id:" + id.String()
+}
+
+func (c *UserCache) emailKey(ctx context.Context, email string) string {
+	tenantID, _ := tenant.FromContext(ctx)
+	return c.prefix + tenantID + ":email:" + strings.ToLower(email)
+}
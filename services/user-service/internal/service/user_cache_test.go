@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"github.com/sparkfund/services/user-service/internal/repository"
+)
+
+// countingUserRepository serves and updates users in memory, counting reads.
+// While gate is set, reads block until it is closed.
+type countingUserRepository struct {
+	repository.UserRepository
+	mu    sync.Mutex
+	users map[uuid.UUID]*models.User
+	reads atomic.Int32
+	gate  chan struct{}
+}
+
+func (r *countingUserRepository) read() {
+	r.reads.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+}
+
+func (r *countingUserRepository) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.read()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *countingUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.read()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *countingUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func newCachedUserService(users ...*models.User) (*UserService, *countingUserRepository) {
+	repo := &countingUserRepository{users: make(map[uuid.UUID]*models.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	svc := NewUserService(repo)
+	svc.SetCache(NewUserCache(UserCacheConfig{TTL: time.Minute, LocalTTL: time.Minute}))
+	return svc, repo
+}
+
+func TestUserCache_ServesRepeatLookupsFromCache(t *testing.T) {
+	alice := &models.User{ID: uuid.New(), Email: "alice@example.com", HashedPassword: "hash"}
+	svc, repo := newCachedUserService(alice)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		user, err := svc.GetUser(ctx, alice.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user.Email != alice.Email {
+			t.Fatalf("expected %s, got %s", alice.Email, user.Email)
+		}
+		if user.HashedPassword != "" {
+			t.Fatal("expected cached users not to carry the password hash")
+		}
+	}
+	if _, err := svc.GetUserByEmail(ctx, "Alice@Example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.GetUserByEmail(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Loading by ID also records the email, so only the first lookup reads
+	if got := repo.reads.Load(); got != 1 {
+		t.Fatalf("expected 1 repository read, got %d", got)
+	}
+}
+
+func TestUserCache_InvalidatedOnUpdate(t *testing.T) {
+	alice := &models.User{ID: uuid.New(), Email: "alice@example.com", Status: models.UserStatus("active")}
+	svc, repo := newCachedUserService(alice)
+	ctx := context.Background()
+
+	if _, err := svc.GetUser(ctx, alice.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := *alice
+	updated.Email = "alice@new.example.com"
+	if err := svc.UpdateUser(ctx, &updated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	user, err := svc.GetUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Email != "alice@new.example.com" {
+		t.Fatalf("expected the update to be visible, got %s", user.Email)
+	}
+	if got := repo.reads.Load(); got != 2 {
+		t.Fatalf("expected the update to force a reload, got %d reads", got)
+	}
+
+	// The old email no longer finds the user
+	if _, err := svc.GetUserByEmail(ctx, "alice@example.com"); err != repository.ErrUserNotFound {
+		t.Fatalf("expected the old email to miss, got %v", err)
+	}
+}
+
+func TestUserCache_ConcurrentMissesLoadOnce(t *testing.T) {
+	alice := &models.User{ID: uuid.New(), Email: "alice@example.com"}
+	svc, repo := newCachedUserService(alice)
+	repo.gate = make(chan struct{})
+
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.GetUser(context.Background(), alice.ID)
+			errs <- err
+		}()
+	}
+
+	// Let every caller reach the cache before the first load completes
+	deadline := time.Now().Add(time.Second)
+	for repo.reads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(repo.gate)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := repo.reads.Load(); got != 1 {
+		t.Fatalf("expected concurrent misses to share one load, got %d reads", got)
+	}
+}
+
+func TestUserCache_ScopedToTenant(t *testing.T) {
+	alice := &models.User{ID: uuid.New(), TenantID: "tenant-a", Email: "alice@example.com"}
+	svc, repo := newCachedUserService(alice)
+
+	if _, err := svc.GetUser(tenant.WithTenant(context.Background(), "tenant-a"), alice.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.GetUser(tenant.WithTenant(context.Background(), "tenant-b"), alice.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another tenant's request must reach the tenant-scoped repository
+	if got := repo.reads.Load(); got != 2 {
+		t.Fatalf("expected each tenant to load separately, got %d reads", got)
+	}
+}
@@ -3,67 +3,214 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base32"
-	"errors"
+	stderrors "errors"
+	"strings"
 	"time"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/password"
 	"github.com/google/uuid"
 	"github.com/sparkfund/services/user-service/internal/errors"
 	"github.com/sparkfund/services/user-service/internal/logger"
 	"github.com/sparkfund/services/user-service/internal/models"
 	"github.com/sparkfund/services/user-service/internal/repository"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	cache     *UserCache
+	passwords *password.Policy
 }
 
-// NewUserService creates a new user service
+// NewUserService creates a new user service. Passwords are checked against
+// the default policy until SetPasswordPolicy is called.
 func NewUserService(userRepo repository.UserRepository) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		passwords: password.New(password.DefaultConfig(), nil),
 	}
 }
 
-// RegisterUser registers a new user
-func (s *UserService) RegisterUser(ctx context.Context, user *models.User) error {
+// SetPasswordPolicy sets the policy new passwords must meet on registration,
+// password change and password reset
+func (s *UserService) SetPasswordPolicy(policy *password.Policy) {
+	s.passwords = policy
+}
+
+// checkPassword validates a new password against the password policy. A
+// failure is an ErrPasswordTooWeak that wraps the *password.PolicyError
+// listing each rule the password missed.
+func (s *UserService) checkPassword(ctx context.Context, newPassword string) error {
+	if err := s.passwords.Validate(ctx, newPassword); err != nil {
+		return &errors.Error{
+			Code:    errors.ErrPasswordTooWeak.Code,
+			Message: errors.ErrPasswordTooWeak.Message,
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// SetCache serves user lookups through a read-through cache. Every user
+// mutation made through the service invalidates it.
+func (s *UserService) SetCache(cache *UserCache) {
+	s.cache = cache
+}
+
+// invalidate drops a changed user from the cache, if one is set
+func (s *UserService) invalidate(ctx context.Context, userID uuid.UUID) {
+	if s.cache != nil {
+		s.cache.Invalidate(ctx, userID)
+	}
+}
+
+// RegisterUser registers a new user with the given plaintext password. Emails
+// are normalized first, so an address that differs from an existing one only
+// in case or surrounding space is rejected with ErrEmailAlreadyInUse.
+func (s *UserService) RegisterUser(ctx context.Context, user *models.User, password string) error {
+	user.Email = models.NormalizeEmail(user.Email)
+	if err := s.checkPassword(ctx, password); err != nil {
+		return err
+	}
+
 	// Hash password before storing
-	hashedPassword, err := hashPassword(user.Password)
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
 		return err
 	}
-	user.Password = hashedPassword
+	user.HashedPassword = hashedPassword
 	user.ID = uuid.New()
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	return s.userRepo.Create(ctx, user)
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if stderrors.Is(err, repository.ErrUserExists) {
+			return errors.ErrEmailAlreadyInUse
+		}
+		return err
+	}
+	s.invalidate(ctx, user.ID)
+	return nil
 }
 
-// AuthenticateUser authenticates a user with email and password
+// AuthenticateUser authenticates a user with email and password. An unknown
+// email and a wrong password both return ErrInvalidCredentials and take as
+// long as each other, so logins do not reveal which emails have accounts.
+// A password stored before hashing was introduced is hashed on a successful
+// login.
 func (s *UserService) AuthenticateUser(ctx context.Context, email, password string) (*models.User, error) {
-	user, err := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.userRepo.GetByEmail(ctx, models.NormalizeEmail(email))
 	if err != nil {
-		return nil, err
+		if !stderrors.Is(err, repository.ErrUserNotFound) {
+			return nil, err
+		}
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, errors.ErrInvalidCredentials
 	}
 
-	if !verifyPassword(password, user.Password) {
-		return nil, errors.New("invalid credentials")
+	if !verifyPassword(password, user.HashedPassword) {
+		return nil, errors.ErrInvalidCredentials
 	}
 
+	if !isPasswordHash(user.HashedPassword) {
+		if err := s.upgradePassword(ctx, user, password); err != nil {
+			logger.Error(err, "Failed to hash legacy password", map[string]interface{}{"user_id": user.ID})
+		}
+	}
 	return user, nil
 }
 
+// upgradePassword replaces a user's legacy plaintext password with its hash
+func (s *UserService) upgradePassword(ctx context.Context, user *models.User, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return err
+	}
+	user.HashedPassword = hashedPassword
+	s.invalidate(ctx, user.ID)
+	return nil
+}
+
 // GetUser retrieves a user by ID
 func (s *UserService) GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
-	return s.userRepo.Get(ctx, userID)
+	if s.cache == nil {
+		return s.userRepo.Get(ctx, userID)
+	}
+	return s.cache.GetByID(ctx, userID, func(ctx context.Context) (*models.User, error) {
+		return s.userRepo.Get(ctx, userID)
+	})
+}
+
+// GetUserByEmail retrieves a user by email address
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	email = models.NormalizeEmail(email)
+	if s.cache == nil {
+		return s.userRepo.GetByEmail(ctx, email)
+	}
+	return s.cache.GetByEmail(ctx, email, func(ctx context.Context) (*models.User, error) {
+		return s.userRepo.GetByEmail(ctx, email)
+	})
+}
+
+// MaxBatchSize caps the number of users that can be fetched in one batch lookup
+const MaxBatchSize = 100
+
+// GetUsersByIDs retrieves several users in a single query. Duplicate IDs are
+// collapsed and IDs with no matching user are returned in notFound rather than
+// failing the whole batch.
+func (s *UserService) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, []uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	if len(unique) > MaxBatchSize {
+		return nil, nil, errors.ErrBatchTooLarge
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to look up users")
+	}
+
+	found := make(map[uuid.UUID]bool, len(users))
+	for _, user := range users {
+		found[user.ID] = true
+	}
+
+	notFound := make([]uuid.UUID, 0)
+	for _, id := range unique {
+		if !found[id] {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return users, notFound, nil
 }
 
 // UpdateUser updates user details
 func (s *UserService) UpdateUser(ctx context.Context, user *models.User) error {
+	user.Email = models.NormalizeEmail(user.Email)
 	user.UpdatedAt = time.Now()
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		if stderrors.Is(err, repository.ErrUserExists) {
+			return errors.ErrEmailAlreadyInUse
+		}
+		return err
+	}
+	s.invalidate(ctx, user.ID)
+	return nil
 }
 
 // GetUserProfile retrieves a user's profile
@@ -73,8 +220,9 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID uuid.UUID) (*mo
 
 // UpdateUserProfile updates a user's profile
 func (s *UserService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, profile *models.UserProfile) error {
+	profile.UserID = userID
 	profile.UpdatedAt = time.Now()
-	return s.userRepo.UpdateProfile(ctx, userID, profile)
+	return s.userRepo.UpdateProfile(ctx, profile)
 }
 
 // ChangePassword changes a user's password
@@ -84,8 +232,11 @@ func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, oldP
 		return err
 	}
 
-	if !verifyPassword(oldPassword, user.Password) {
-		return errors.New("invalid old password")
+	if !verifyPassword(oldPassword, user.HashedPassword) {
+		return stderrors.New("invalid old password")
+	}
+	if err := s.checkPassword(ctx, newPassword); err != nil {
+		return err
 	}
 
 	hashedPassword, err := hashPassword(newPassword)
@@ -93,12 +244,16 @@ func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, oldP
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(ctx, userID, hashedPassword)
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+	s.invalidate(ctx, userID)
+	return nil
 }
 
 // ResetPassword initiates a password reset
 func (s *UserService) ResetPassword(ctx context.Context, email string) error {
-	user, err := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.userRepo.GetByEmail(ctx, models.NormalizeEmail(email))
 	if err != nil {
 		return err
 	}
@@ -117,7 +272,10 @@ func (s *UserService) ConfirmResetPassword(ctx context.Context, token, newPasswo
 	}
 
 	if time.Now().After(resetToken.ExpiresAt) {
-		return errors.New("reset token expired")
+		return stderrors.New("reset token expired")
+	}
+	if err := s.checkPassword(ctx, newPassword); err != nil {
+		return err
 	}
 
 	hashedPassword, err := hashPassword(newPassword)
@@ -128,6 +286,7 @@ func (s *UserService) ConfirmResetPassword(ctx context.Context, token, newPasswo
 	if err := s.userRepo.UpdatePassword(ctx, resetToken.UserID, hashedPassword); err != nil {
 		return err
 	}
+	s.invalidate(ctx, resetToken.UserID)
 
 	return s.userRepo.MarkResetTokenUsed(ctx, token)
 }
@@ -142,9 +301,8 @@ func (s *UserService) GetSession(ctx context.Context, token string) (*models.Ses
 	return s.userRepo.GetSession(ctx, token)
 }
 
-// UpdateSession updates a session's last used time
+// UpdateSession updates a session
 func (s *UserService) UpdateSession(ctx context.Context, session *models.Session) error {
-	session.LastUsed = time.Now()
 	return s.userRepo.UpdateSession(ctx, session)
 }
 
@@ -191,19 +349,26 @@ func (s *UserService) ListUserSessions(ctx context.Context, userID uuid.UUID) ([
 	return activeSessions, nil
 }
 
-// RevokeSession revokes a specific session
+// RevokeSession revokes a specific session. Only the user's own sessions are
+// searched, so another user's session ID is reported as not found.
 func (s *UserService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
-	// Verify session belongs to user
-	session, err := s.userRepo.GetSession(ctx, sessionID)
+	sessions, err := s.userRepo.GetUserSessions(ctx, userID)
 	if err != nil {
-		return errors.Wrap(err, "Failed to get session")
+		return errors.Wrap(err, "Failed to get user sessions")
 	}
 
-	if session.UserID != userID {
-		return errors.ErrUnauthorized
+	var session *models.Session
+	for i := range sessions {
+		if sessions[i].ID == sessionID {
+			session = &sessions[i]
+			break
+		}
+	}
+	if session == nil {
+		return repository.ErrSessionNotFound
 	}
 
-	if err := s.userRepo.DeleteSession(ctx, sessionID); err != nil {
+	if err := s.userRepo.DeleteSession(ctx, session.Token); err != nil {
 		return errors.Wrap(err, "Failed to revoke session")
 	}
 
@@ -223,7 +388,7 @@ func (s *UserService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) e
 	}
 
 	for _, session := range sessions {
-		if err := s.userRepo.DeleteSession(ctx, session.ID); err != nil {
+		if err := s.userRepo.DeleteSession(ctx, session.Token); err != nil {
 			return errors.Wrap(err, "Failed to revoke session")
 		}
 	}
@@ -273,8 +438,7 @@ func (s *UserService) DisableMFA(ctx context.Context, userID uuid.UUID) error {
 
 // VerifyMFA verifies an MFA code
 func (s *UserService) VerifyMFA(ctx context.Context, userID uuid.UUID, code string) error {
-	secret, err := s.userRepo.GetMFASecret(ctx, userID)
-	if err != nil {
+	if _, err := s.userRepo.GetMFASecret(ctx, userID); err != nil {
 		return errors.Wrap(err, "Failed to get MFA secret")
 	}
 
@@ -311,13 +475,30 @@ func (s *UserService) GetSecurityActivity(ctx context.Context, userID uuid.UUID)
 	return activity, nil
 }
 
-// Helper functions for password hashing and verification
+// dummyPasswordHash is compared against when a login names an unknown email,
+// so the login takes as long as one with a wrong password
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("no such user"), bcrypt.DefaultCost)
+
+// hashPassword returns the bcrypt hash of password
 func hashPassword(password string) (string, error) {
-	// TODO: Implement proper password hashing
-	return password, nil
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
 }
 
+// verifyPassword reports whether password matches the stored hash. Accounts
+// created before passwords were hashed store them in plaintext; those are
+// compared in constant time.
 func verifyPassword(password, hash string) bool {
-	// TODO: Implement proper password verification
-	return password == hash
+	if !isPasswordHash(hash) {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(hash)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// isPasswordHash reports whether a stored password is a bcrypt hash
+func isPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
 }
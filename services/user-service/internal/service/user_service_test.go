@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/adil-faiyaz98/sparkfund/pkg/password"
+	"github.com/google/uuid"
+	"github.com/sparkfund/services/user-service/internal/errors"
+	"github.com/sparkfund/services/user-service/internal/models"
+	"github.com/sparkfund/services/user-service/internal/repository"
+)
+
+// fakeUserRepository serves users from memory. Methods not overridden here
+// fall through to the embedded nil interface and panic if called.
+type fakeUserRepository struct {
+	repository.UserRepository
+	users   map[uuid.UUID]*models.User
+	lookups [][]uuid.UUID
+}
+
+func (r *fakeUserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error) {
+	r.lookups = append(r.lookups, ids)
+
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// Create enforces unique emails the way the users index does; it compares
+// emails exactly, so only the service's normalization makes case variants clash
+func (r *fakeUserRepository) Create(ctx context.Context, user *models.User) error {
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return repository.ErrUserExists
+		}
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error {
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.HashedPassword = hashedPassword
+	return nil
+}
+
+func TestGetUsersByIDs_ReportsNotFound(t *testing.T) {
+	alice := &models.User{ID: uuid.New(), Email: "alice@example.com"}
+	bob := &models.User{ID: uuid.New(), Email: "bob@example.com"}
+	missing := uuid.New()
+
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{
+		alice.ID: alice,
+		bob.ID:   bob,
+	}}
+	svc := NewUserService(repo)
+
+	users, notFound, err := svc.GetUsersByIDs(context.Background(), []uuid.UUID{alice.ID, missing, bob.ID, alice.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	if len(notFound) != 1 || notFound[0] != missing {
+		t.Fatalf("expected %s to be reported as not found, got %v", missing, notFound)
+	}
+
+	// Duplicates are collapsed before the single repository query
+	if len(repo.lookups) != 1 || len(repo.lookups[0]) != 3 {
+		t.Fatalf("expected one query with 3 unique IDs, got %v", repo.lookups)
+	}
+}
+
+func TestGetUsersByIDs_RejectsOversizedBatch(t *testing.T) {
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{}}
+	svc := NewUserService(repo)
+
+	ids := make([]uuid.UUID, MaxBatchSize+1)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	_, _, err := svc.GetUsersByIDs(context.Background(), ids)
+	if !stderrors.Is(err, errors.ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge, got %v", err)
+	}
+	if len(repo.lookups) != 0 {
+		t.Fatalf("expected no repository query for an oversized batch")
+	}
+}
+
+func TestRegisterUser_RejectsEmailInAnotherCase(t *testing.T) {
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{}}
+	svc := NewUserService(repo)
+	ctx := context.Background()
+
+	first := &models.User{Email: "  John@Example.com"}
+	if err := svc.RegisterUser(ctx, first, "correct-Horse-battery-9"); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+	if first.Email != "john@example.com" {
+		t.Fatalf("expected the email to be stored normalized, got %q", first.Email)
+	}
+
+	err := svc.RegisterUser(ctx, &models.User{Email: "JOHN@example.COM"}, "correct-Horse-battery-9")
+	if !stderrors.Is(err, errors.ErrEmailAlreadyInUse) {
+		t.Fatalf("expected ErrEmailAlreadyInUse, got %v", err)
+	}
+	if len(repo.users) != 1 {
+		t.Fatalf("expected one user, got %d", len(repo.users))
+	}
+
+	// Lookups by email normalize the same way
+	user, err := svc.GetUserByEmail(ctx, "John@EXAMPLE.com ")
+	if err != nil || user.ID != first.ID {
+		t.Fatalf("expected to find the registered user, got %v, %v", user, err)
+	}
+}
+
+// breachedPasswords is a password.BreachChecker that reports a fixed set
+type breachedPasswords map[string]bool
+
+func (b breachedPasswords) Breached(ctx context.Context, password string) (bool, error) {
+	return b[password], nil
+}
+
+func TestRegisterUser_EnforcesPasswordPolicy(t *testing.T) {
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{}}
+	svc := NewUserService(repo)
+	svc.SetPasswordPolicy(password.New(password.DefaultConfig(), breachedPasswords{"Tr0ub4dor&3xyz": true}))
+	ctx := context.Background()
+
+	tests := []struct {
+		password string
+		rule     password.Rule
+	}{
+		{"letmein", password.RuleMinLength},
+		{"Tr0ub4dor&3xyz", password.RuleBreached},
+	}
+	for _, tt := range tests {
+		err := svc.RegisterUser(ctx, &models.User{Email: "weak@example.com"}, tt.password)
+
+		var appErr *errors.Error
+		var policyErr *password.PolicyError
+		if !stderrors.As(err, &appErr) || appErr.Code != errors.ErrPasswordTooWeak.Code {
+			t.Fatalf("%q: expected ErrPasswordTooWeak, got %v", tt.password, err)
+		}
+		if !stderrors.As(err, &policyErr) || !policyErr.Has(tt.rule) {
+			t.Fatalf("%q: expected a %s violation, got %v", tt.password, tt.rule, err)
+		}
+	}
+	if len(repo.users) != 0 {
+		t.Fatalf("expected no users for rejected passwords, got %d", len(repo.users))
+	}
+
+	if err := svc.RegisterUser(ctx, &models.User{Email: "strong@example.com"}, "correct-Horse-battery-9"); err != nil {
+		t.Fatalf("expected a strong password to be accepted, got %v", err)
+	}
+}
+
+func TestAuthenticateUser_ComparesHashedPasswords(t *testing.T) {
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{}}
+	svc := NewUserService(repo)
+	ctx := context.Background()
+
+	user := &models.User{Email: "carol@example.com"}
+	if err := svc.RegisterUser(ctx, user, "correct-Horse-battery-9"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.users[user.ID].HashedPassword == "correct-Horse-battery-9" {
+		t.Fatal("expected the password to be stored hashed")
+	}
+
+	if _, err := svc.AuthenticateUser(ctx, "Carol@example.com", "correct-Horse-battery-9"); err != nil {
+		t.Fatalf("expected the right password to authenticate, got %v", err)
+	}
+
+	// A wrong password and an unknown email fail the same way
+	for _, creds := range [][2]string{
+		{"carol@example.com", "wrong-Horse-battery-9"},
+		{"nobody@example.com", "correct-Horse-battery-9"},
+	} {
+		if _, err := svc.AuthenticateUser(ctx, creds[0], creds[1]); err != errors.ErrInvalidCredentials {
+			t.Fatalf("%s: expected ErrInvalidCredentials, got %v", creds[0], err)
+		}
+	}
+}
+
+func TestAuthenticateUser_HashesLegacyPasswords(t *testing.T) {
+	legacy := &models.User{ID: uuid.New(), Email: "dave@example.com", HashedPassword: "plaintext-Pass-1"}
+	repo := &fakeUserRepository{users: map[uuid.UUID]*models.User{legacy.ID: legacy}}
+	svc := NewUserService(repo)
+	ctx := context.Background()
+
+	if _, err := svc.AuthenticateUser(ctx, legacy.Email, "plaintext-Pass-1"); err != nil {
+		t.Fatalf("expected the legacy password to authenticate, got %v", err)
+	}
+	if !isPasswordHash(legacy.HashedPassword) {
+		t.Fatalf("expected the legacy password to be hashed on login, got %q", legacy.HashedPassword)
+	}
+	if _, err := svc.AuthenticateUser(ctx, legacy.Email, "plaintext-Pass-1"); err != nil {
+		t.Fatalf("expected the hashed password to authenticate, got %v", err)
+	}
+}
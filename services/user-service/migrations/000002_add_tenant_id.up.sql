@@ -0,0 +1,7 @@
+-- Scope users to a partner tenant; emails are unique per tenant
+ALTER TABLE users ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_users_tenant_email ON users(tenant_id, email);
+CREATE INDEX idx_users_tenant_id ON users(tenant_id);
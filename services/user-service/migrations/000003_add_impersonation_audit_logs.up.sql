@@ -0,0 +1,18 @@
+-- Record every token issued to an admin acting as a user
+CREATE TABLE impersonation_audit_logs (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    admin_id UUID NOT NULL REFERENCES users(id),
+    target_user_id UUID NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Create indexes
+CREATE INDEX idx_impersonation_audit_logs_admin_id ON impersonation_audit_logs(admin_id);
+CREATE INDEX idx_impersonation_audit_logs_target_user_id ON impersonation_audit_logs(target_user_id);
+CREATE INDEX idx_impersonation_audit_logs_created_at ON impersonation_audit_logs(created_at);
@@ -0,0 +1,16 @@
+-- Record every login, refresh, logout and token validation
+CREATE TABLE auth_audit_log (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    event_type VARCHAR(20) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    source_ip VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+-- Create indexes
+CREATE INDEX idx_auth_audit_log_tenant_created_at ON auth_audit_log(tenant_id, created_at);
+CREATE INDEX idx_auth_audit_log_user_id_created_at ON auth_audit_log(user_id, created_at);
@@ -0,0 +1,4 @@
+-- Restore case-sensitive email uniqueness; emails stay lower case
+DROP INDEX IF EXISTS idx_users_tenant_email;
+
+CREATE UNIQUE INDEX idx_users_tenant_email ON users(tenant_id, email);
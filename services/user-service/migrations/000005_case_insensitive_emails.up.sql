@@ -0,0 +1,8 @@
+-- Store emails trimmed and lower case, and keep them unique per tenant
+-- regardless of case. If two accounts in a tenant differ only in the case of
+-- their email this migration fails and they must be merged first.
+DROP INDEX IF EXISTS idx_users_tenant_email;
+
+UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
+
+CREATE UNIQUE INDEX idx_users_tenant_email ON users(tenant_id, LOWER(email));
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/adil-faiyaz98/sparkfund/pkg/metrics"
+	"github.com/adil-faiyaz98/sparkfund/pkg/server"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -57,11 +59,18 @@ func main() {
 
 	r := mux.NewRouter()
 
+	// Metrics
+	r.Use(metrics.HTTPMiddleware(metrics.MuxRouteLabel))
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
 	// Swagger
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
 	// API Routes
 	r.HandleFunc("/health", healthHandler).Methods("GET")
+	// Readiness fails once shutdown begins so the load balancer stops routing here
+	var readiness server.ReadinessFlag
+	r.Handle("/ready", &readiness).Methods("GET")
 	r.HandleFunc("/api/v1/users", usersHandler).Methods("GET")
 	r.HandleFunc("/api/v1/users/register", registerUserHandler).Methods("POST")
 
@@ -69,8 +78,13 @@ func main() {
 	fs := http.FileServer(http.Dir("./docs"))
 	r.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", fs))
 
+	srv := server.New(":"+port, r, server.TimeoutsFromEnv())
+
 	log.Printf("User Service starting on port %s...", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	if err := server.ListenAndServe(srv, server.ShutdownConfigFromEnv(&readiness)); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Server exited")
 }
 
 // @Summary Health check